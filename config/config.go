@@ -0,0 +1,624 @@
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the server configuration, loaded from environment variables
+type Config struct {
+	Port      string // PORT
+	DataDir   string // CALSUN_DATA_DIR, where persistent state is stored
+	Server    Server
+	Site      Site
+	Sync      Sync
+	Digest    Digest
+	Aurora    Aurora
+	Terrain   Terrain
+	SMTP      SMTP
+	Push      Push
+	Influx    Influx
+	Admin     Admin
+	Analytics Analytics
+
+	IFTTTServiceKey string // CALSUN_IFTTT_SERVICE_KEY, enables the IFTTT service endpoints
+	CacheSize       int64  // CALSUN_CACHE_MB, memory for cached calendar responses in bytes (0 disables the cache)
+	CoordPrecision  int    // CALSUN_COORD_PRECISION, default decimals of coordinates shown in events and hashed into UIDs (1-4)
+	MaxDays         int    // CALSUN_MAX_DAYS, the largest days parameter accepted by the calendars (up to MaxDaysLimit)
+	UIDDomain       string // CALSUN_UID_DOMAIN, the part of event UIDs after the "@"
+	UIDMigrate      bool   // CALSUN_UID_MIGRATE, allows changing the UID domain from the one recorded in the data directory
+	Headless        bool   // CALSUN_HEADLESS, serves the calendars and APIs without the web UI
+	DisablePreview  bool   // CALSUN_DISABLE_PREVIEW, also disables the preview fragment and QR code endpoints (requires CALSUN_HEADLESS)
+	Debug           bool   // CALSUN_DEBUG, accepts the now parameter to fix the time output is generated for (for tests, not production)
+}
+
+// Server configures the protocols the HTTP server speaks. HTTP/1.1 is always
+// served; HTTP/2 is negotiated over TLS when a certificate is set.
+type Server struct {
+	TLSCert string // CALSUN_TLS_CERT, PEM certificate file; serves HTTPS with CALSUN_TLS_KEY
+	TLSKey  string // CALSUN_TLS_KEY, PEM private key file
+	H2C     bool   // CALSUN_H2C, accept HTTP/2 without TLS (prior knowledge), for reverse proxies
+	HTTP3   bool   // CALSUN_HTTP3, also serve HTTP/3 over QUIC on the same UDP port (requires TLS)
+
+	BasePath string // CALSUN_BASE_PATH, prefix the app is served under (e.g., "/calsun"), without trailing slash
+
+	AccessLog AccessLog
+}
+
+// AccessLog configures logging one line per request, separately from the
+// application log. Logging is enabled when a format is set.
+type AccessLog struct {
+	Format string  // CALSUN_ACCESS_LOG_FORMAT, "common" (Common Log Format) or "json"
+	Path   string  // CALSUN_ACCESS_LOG_PATH, file to append to (standard output if empty)
+	Sample float64 // CALSUN_ACCESS_LOG_SAMPLE, fraction of successful requests logged (errors are always logged)
+}
+
+// Enabled reports whether access logging is configured
+func (a AccessLog) Enabled() bool {
+	return a.Format != ""
+}
+
+// TLSEnabled reports whether the server serves HTTPS
+func (s Server) TLSEnabled() bool {
+	return s.TLSCert != "" && s.TLSKey != ""
+}
+
+// Site holds the operator's branding for the web UI
+type Site struct {
+	Title       string // CALSUN_SITE_TITLE
+	Tagline     string // CALSUN_SITE_TAGLINE
+	LogoURL     string // CALSUN_LOGO_URL
+	AccentColor string // CALSUN_ACCENT_COLOR, hex color (e.g., "#ff6600")
+	FooterLinks []Link // CALSUN_FOOTER_LINKS, "Label|URL" pairs separated by commas
+	Message     string // CALSUN_MESSAGE, shown above the form
+}
+
+// Sync configures pushing events into users' calendars. A provider is
+// enabled when its OAuth client credentials are set.
+type Sync struct {
+	Interval           time.Duration // CALSUN_SYNC_INTERVAL
+	GoogleClientID     string        // CALSUN_GOOGLE_CLIENT_ID
+	GoogleClientSecret string        // CALSUN_GOOGLE_CLIENT_SECRET
+
+	MicrosoftClientID     string // CALSUN_MICROSOFT_CLIENT_ID
+	MicrosoftClientSecret string // CALSUN_MICROSOFT_CLIENT_SECRET
+	MicrosoftTenant       string // CALSUN_MICROSOFT_TENANT, Entra ID tenant ("organizations", "common", or a tenant ID)
+}
+
+// GoogleEnabled reports whether the Google Calendar integration is configured
+func (s Sync) GoogleEnabled() bool {
+	return s.GoogleClientID != "" && s.GoogleClientSecret != ""
+}
+
+// MicrosoftEnabled reports whether the Outlook (Microsoft Graph) integration
+// is configured
+func (s Sync) MicrosoftEnabled() bool {
+	return s.MicrosoftClientID != "" && s.MicrosoftClientSecret != ""
+}
+
+// Digest configures the daily sun digest posted to chat webhooks
+type Digest struct {
+	Time     time.Duration // CALSUN_DIGEST_TIME ("HH:MM"), as an offset from local midnight at each location
+	Webhooks []Webhook     // CALSUN_DIGEST_WEBHOOKS, "platform|URL|lat,lng|name" entries separated by semicolons
+}
+
+// Aurora configures aurora alerts posted to chat webhooks when NOAA's Kp
+// forecast reaches a threshold at night
+type Aurora struct {
+	Interval time.Duration // CALSUN_AURORA_INTERVAL, how often the forecast is polled
+	Kp       int           // CALSUN_AURORA_KP, the Kp index to alert at (0 for each location's threshold)
+	Webhooks []Webhook     // CALSUN_AURORA_WEBHOOKS, "platform|URL|lat,lng|name" entries separated by semicolons
+}
+
+// Terrain configures looking up the terrain elevation of calendar locations
+// without an altitude parameter, for sunrise and sunset over a lower horizon
+type Terrain struct {
+	Lookup bool   // CALSUN_ELEVATION_LOOKUP
+	URL    string // CALSUN_ELEVATION_URL, an Open-Elevation compatible API (empty for the public one)
+}
+
+// SMTP configures the mail server used for email digest subscriptions. Email
+// subscriptions are enabled when a host and sender address are set.
+type SMTP struct {
+	Host     string // CALSUN_SMTP_HOST
+	Port     string // CALSUN_SMTP_PORT
+	Username string // CALSUN_SMTP_USERNAME
+	Password string // CALSUN_SMTP_PASSWORD
+	From     string // CALSUN_SMTP_FROM, e.g. "CalSun <sun@example.com>"
+}
+
+// Enabled reports whether email subscriptions are configured
+func (s SMTP) Enabled() bool {
+	return s.Host != "" && s.From != ""
+}
+
+// Push configures browser push alerts before sunrise and sunset (Web Push
+// with VAPID). They are enabled when a contact subject is set; the signing
+// key is generated in the data directory.
+type Push struct {
+	Subject string // CALSUN_PUSH_SUBJECT, contact for push services: "mailto:" address or https URL
+}
+
+// Enabled reports whether push alerts are configured
+func (p Push) Enabled() bool {
+	return p.Subject != ""
+}
+
+// Admin configures the admin dashboard, which is enabled when a token is set
+type Admin struct {
+	Token string // CALSUN_ADMIN_TOKEN, the password of the dashboard (HTTP Basic, any user name) or a Bearer token
+}
+
+// Enabled reports whether the admin dashboard is configured
+func (a Admin) Enabled() bool {
+	return a.Token != ""
+}
+
+// Analytics configures aggregate usage statistics (requests per endpoint,
+// coarse location cells, and option popularity per day), stored in the data
+// directory and shown on the admin dashboard
+type Analytics struct {
+	Enabled   bool // CALSUN_ANALYTICS (requires CALSUN_ADMIN_TOKEN)
+	Retention int  // CALSUN_ANALYTICS_RETENTION, days of statistics kept
+}
+
+// MaxAnalyticsRetention bounds CALSUN_ANALYTICS_RETENTION
+const MaxAnalyticsRetention = 366
+
+// Influx configures pushing sun metrics to an InfluxDB write endpoint. The
+// push is enabled when a URL and at least one location are set.
+type Influx struct {
+	URL       string        // CALSUN_INFLUX_URL, write endpoint including org and bucket (v2) or db (v1)
+	Token     string        // CALSUN_INFLUX_TOKEN, sent as "Authorization: Token ..."
+	Interval  time.Duration // CALSUN_INFLUX_INTERVAL
+	Locations []Location    // CALSUN_INFLUX_LOCATIONS, "lat,lng|name" entries separated by semicolons
+}
+
+// Enabled reports whether the InfluxDB push is configured
+func (i Influx) Enabled() bool {
+	return i.URL != "" && len(i.Locations) > 0
+}
+
+// Location is a named point on the map
+type Location struct {
+	Lat  float64
+	Lng  float64
+	Name string
+}
+
+// Webhook is a chat incoming webhook that receives the digest or aurora alerts
+// for a location
+type Webhook struct {
+	Platform string // "slack" or "discord"
+	URL      string
+	Lat      float64
+	Lng      float64
+	Name     string
+}
+
+// MaxDaysLimit bounds CALSUN_MAX_DAYS: ten years, for institutions
+// publishing multi-year calendars
+const MaxDaysLimit = 3660
+
+// Link is a labelled URL
+type Link struct {
+	Label string
+	URL   string
+}
+
+var (
+	hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+	tenantPattern   = regexp.MustCompile(`^[A-Za-z0-9.-]+$`)
+	basePathPattern = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+	domainPattern   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9.-]*[A-Za-z0-9])?$`)
+)
+
+// Default returns the configuration used when no environment variables are set
+func Default() *Config {
+	return &Config{
+		Port:           "8080",
+		DataDir:        "data",
+		CacheSize:      64 << 20,
+		CoordPrecision: 4,
+		MaxDays:        90,
+		UIDDomain:      "calsun",
+		Server: Server{
+			AccessLog: AccessLog{Sample: 1},
+		},
+		Sync: Sync{
+			Interval:        6 * time.Hour,
+			MicrosoftTenant: "organizations",
+		},
+		Digest: Digest{
+			Time: 7 * time.Hour,
+		},
+		Aurora: Aurora{
+			Interval: 30 * time.Minute,
+		},
+		SMTP: SMTP{
+			Port: "587",
+		},
+		Influx: Influx{
+			Interval: time.Hour,
+		},
+		Analytics: Analytics{
+			Retention: 90,
+		},
+		Site: Site{
+			Title:   "CalSun",
+			Tagline: "Subscribe to sunrise & sunset times in your calendar",
+		},
+	}
+}
+
+// Load reads the configuration from environment variables, falling back to
+// the defaults for unset values
+func Load() (*Config, error) {
+	return load(os.Getenv)
+}
+
+// load reads the configuration using getenv to look up variables
+func load(getenv func(string) string) (*Config, error) {
+	cfg := Default()
+
+	if port := getenv("PORT"); port != "" {
+		cfg.Port = port
+	}
+
+	if dataDir := getenv("CALSUN_DATA_DIR"); dataDir != "" {
+		cfg.DataDir = dataDir
+	}
+
+	cfg.Server.TLSCert = getenv("CALSUN_TLS_CERT")
+	cfg.Server.TLSKey = getenv("CALSUN_TLS_KEY")
+	if (cfg.Server.TLSCert == "") != (cfg.Server.TLSKey == "") {
+		return nil, fmt.Errorf("CALSUN_TLS_CERT and CALSUN_TLS_KEY must be set together")
+	}
+	if basePath := strings.TrimRight(getenv("CALSUN_BASE_PATH"), "/"); basePath != "" {
+		if !basePathPattern.MatchString(basePath) {
+			return nil, fmt.Errorf("CALSUN_BASE_PATH must be a path like /calsun")
+		}
+		cfg.Server.BasePath = basePath
+	}
+	if format := getenv("CALSUN_ACCESS_LOG_FORMAT"); format != "" {
+		if format != "common" && format != "json" {
+			return nil, fmt.Errorf("CALSUN_ACCESS_LOG_FORMAT must be common or json")
+		}
+		cfg.Server.AccessLog.Format = format
+	}
+	cfg.Server.AccessLog.Path = getenv("CALSUN_ACCESS_LOG_PATH")
+	if sample := getenv("CALSUN_ACCESS_LOG_SAMPLE"); sample != "" {
+		rate, err := strconv.ParseFloat(sample, 64)
+		if err != nil || rate <= 0 || rate > 1 {
+			return nil, fmt.Errorf("CALSUN_ACCESS_LOG_SAMPLE must be a fraction between 0 and 1 (e.g., 0.1)")
+		}
+		cfg.Server.AccessLog.Sample = rate
+	}
+	if h2c := getenv("CALSUN_H2C"); h2c != "" {
+		enabled, err := strconv.ParseBool(h2c)
+		if err != nil {
+			return nil, fmt.Errorf("CALSUN_H2C must be true or false")
+		}
+		cfg.Server.H2C = enabled
+	}
+	if http3 := getenv("CALSUN_HTTP3"); http3 != "" {
+		enabled, err := strconv.ParseBool(http3)
+		if err != nil {
+			return nil, fmt.Errorf("CALSUN_HTTP3 must be true or false")
+		}
+		if enabled && !cfg.Server.TLSEnabled() {
+			return nil, fmt.Errorf("CALSUN_HTTP3 requires CALSUN_TLS_CERT and CALSUN_TLS_KEY")
+		}
+		cfg.Server.HTTP3 = enabled
+	}
+
+	if headless := getenv("CALSUN_HEADLESS"); headless != "" {
+		enabled, err := strconv.ParseBool(headless)
+		if err != nil {
+			return nil, fmt.Errorf("CALSUN_HEADLESS must be true or false")
+		}
+		cfg.Headless = enabled
+	}
+	if disable := getenv("CALSUN_DISABLE_PREVIEW"); disable != "" {
+		enabled, err := strconv.ParseBool(disable)
+		if err != nil {
+			return nil, fmt.Errorf("CALSUN_DISABLE_PREVIEW must be true or false")
+		}
+		if enabled && !cfg.Headless {
+			return nil, fmt.Errorf("CALSUN_DISABLE_PREVIEW requires CALSUN_HEADLESS, as the web UI uses the preview")
+		}
+		cfg.DisablePreview = enabled
+	}
+
+	if debug := getenv("CALSUN_DEBUG"); debug != "" {
+		enabled, err := strconv.ParseBool(debug)
+		if err != nil {
+			return nil, fmt.Errorf("CALSUN_DEBUG must be true or false")
+		}
+		cfg.Debug = enabled
+	}
+
+	if title := getenv("CALSUN_SITE_TITLE"); title != "" {
+		cfg.Site.Title = title
+	}
+	if tagline := getenv("CALSUN_SITE_TAGLINE"); tagline != "" {
+		cfg.Site.Tagline = tagline
+	}
+	cfg.Site.Message = getenv("CALSUN_MESSAGE")
+
+	if logo := getenv("CALSUN_LOGO_URL"); logo != "" {
+		if !isWebURL(logo) && !strings.HasPrefix(logo, "/") {
+			return nil, fmt.Errorf("CALSUN_LOGO_URL must be an http(s) URL or absolute path")
+		}
+		cfg.Site.LogoURL = logo
+	}
+
+	if color := getenv("CALSUN_ACCENT_COLOR"); color != "" {
+		if !hexColorPattern.MatchString(color) {
+			return nil, fmt.Errorf("CALSUN_ACCENT_COLOR must be a hex color like #ff6600")
+		}
+		cfg.Site.AccentColor = color
+	}
+
+	if links := getenv("CALSUN_FOOTER_LINKS"); links != "" {
+		for _, pair := range strings.Split(links, ",") {
+			label, link, ok := strings.Cut(pair, "|")
+			label, link = strings.TrimSpace(label), strings.TrimSpace(link)
+			if !ok || label == "" || !isWebURL(link) {
+				return nil, fmt.Errorf("CALSUN_FOOTER_LINKS entry %q must be \"Label|https://...\"", pair)
+			}
+			cfg.Site.FooterLinks = append(cfg.Site.FooterLinks, Link{Label: label, URL: link})
+		}
+	}
+
+	if interval := getenv("CALSUN_SYNC_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d < time.Minute {
+			return nil, fmt.Errorf("CALSUN_SYNC_INTERVAL must be a duration of at least 1m (e.g., 6h)")
+		}
+		cfg.Sync.Interval = d
+	}
+	cfg.Sync.GoogleClientID = getenv("CALSUN_GOOGLE_CLIENT_ID")
+	cfg.Sync.GoogleClientSecret = getenv("CALSUN_GOOGLE_CLIENT_SECRET")
+	cfg.Sync.MicrosoftClientID = getenv("CALSUN_MICROSOFT_CLIENT_ID")
+	cfg.Sync.MicrosoftClientSecret = getenv("CALSUN_MICROSOFT_CLIENT_SECRET")
+	if tenant := getenv("CALSUN_MICROSOFT_TENANT"); tenant != "" {
+		if !tenantPattern.MatchString(tenant) {
+			return nil, fmt.Errorf("CALSUN_MICROSOFT_TENANT must be a tenant ID, domain, or one of organizations, common, consumers")
+		}
+		cfg.Sync.MicrosoftTenant = tenant
+	}
+
+	if at := getenv("CALSUN_DIGEST_TIME"); at != "" {
+		t, err := time.Parse("15:04", at)
+		if err != nil {
+			return nil, fmt.Errorf("CALSUN_DIGEST_TIME must be a time like 07:00")
+		}
+		cfg.Digest.Time = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	if webhooks := getenv("CALSUN_DIGEST_WEBHOOKS"); webhooks != "" {
+		for _, entry := range strings.Split(webhooks, ";") {
+			if strings.TrimSpace(entry) == "" {
+				continue
+			}
+			webhook, err := parseWebhook(entry)
+			if err != nil {
+				return nil, fmt.Errorf("CALSUN_DIGEST_WEBHOOKS entry %q: %w", entry, err)
+			}
+			cfg.Digest.Webhooks = append(cfg.Digest.Webhooks, webhook)
+		}
+	}
+
+	if interval := getenv("CALSUN_AURORA_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d < time.Minute {
+			return nil, fmt.Errorf("CALSUN_AURORA_INTERVAL must be a duration of at least 1m (e.g., 30m)")
+		}
+		cfg.Aurora.Interval = d
+	}
+	if kp := getenv("CALSUN_AURORA_KP"); kp != "" {
+		n, err := strconv.Atoi(kp)
+		if err != nil || n < 1 || n > 9 {
+			return nil, fmt.Errorf("CALSUN_AURORA_KP must be a Kp index from 1 to 9")
+		}
+		cfg.Aurora.Kp = n
+	}
+	if webhooks := getenv("CALSUN_AURORA_WEBHOOKS"); webhooks != "" {
+		for _, entry := range strings.Split(webhooks, ";") {
+			if strings.TrimSpace(entry) == "" {
+				continue
+			}
+			webhook, err := parseWebhook(entry)
+			if err != nil {
+				return nil, fmt.Errorf("CALSUN_AURORA_WEBHOOKS entry %q: %w", entry, err)
+			}
+			cfg.Aurora.Webhooks = append(cfg.Aurora.Webhooks, webhook)
+		}
+	}
+
+	if lookup := getenv("CALSUN_ELEVATION_LOOKUP"); lookup != "" {
+		enabled, err := strconv.ParseBool(lookup)
+		if err != nil {
+			return nil, fmt.Errorf("CALSUN_ELEVATION_LOOKUP must be true or false")
+		}
+		cfg.Terrain.Lookup = enabled
+	}
+	if elevationURL := getenv("CALSUN_ELEVATION_URL"); elevationURL != "" {
+		if !isWebURL(elevationURL) {
+			return nil, fmt.Errorf("CALSUN_ELEVATION_URL must be an http(s) URL")
+		}
+		cfg.Terrain.URL = elevationURL
+	}
+
+	if subject := getenv("CALSUN_PUSH_SUBJECT"); subject != "" {
+		address, isMail := strings.CutPrefix(subject, "mailto:")
+		if isMail {
+			if _, err := mail.ParseAddress(address); err != nil {
+				return nil, fmt.Errorf("CALSUN_PUSH_SUBJECT must be a mailto: address or https URL")
+			}
+		} else if !strings.HasPrefix(subject, "https://") || !isWebURL(subject) {
+			return nil, fmt.Errorf("CALSUN_PUSH_SUBJECT must be a mailto: address or https URL")
+		}
+		cfg.Push.Subject = subject
+	}
+
+	cfg.Admin.Token = getenv("CALSUN_ADMIN_TOKEN")
+	if analytics := getenv("CALSUN_ANALYTICS"); analytics != "" {
+		enabled, err := strconv.ParseBool(analytics)
+		if err != nil {
+			return nil, fmt.Errorf("CALSUN_ANALYTICS must be true or false")
+		}
+		if enabled && !cfg.Admin.Enabled() {
+			return nil, fmt.Errorf("CALSUN_ANALYTICS requires CALSUN_ADMIN_TOKEN, as the statistics are shown on the admin dashboard")
+		}
+		cfg.Analytics.Enabled = enabled
+	}
+	if retention := getenv("CALSUN_ANALYTICS_RETENTION"); retention != "" {
+		n, err := strconv.Atoi(retention)
+		if err != nil || n < 1 || n > MaxAnalyticsRetention {
+			return nil, fmt.Errorf("CALSUN_ANALYTICS_RETENTION must be 1 to %d days", MaxAnalyticsRetention)
+		}
+		cfg.Analytics.Retention = n
+	}
+
+	cfg.IFTTTServiceKey = getenv("CALSUN_IFTTT_SERVICE_KEY")
+
+	if size := getenv("CALSUN_CACHE_MB"); size != "" {
+		mb, err := strconv.Atoi(size)
+		if err != nil || mb < 0 {
+			return nil, fmt.Errorf("CALSUN_CACHE_MB must be a whole number of megabytes (0 disables the cache)")
+		}
+		cfg.CacheSize = int64(mb) << 20
+	}
+
+	if precision := getenv("CALSUN_COORD_PRECISION"); precision != "" {
+		n, err := strconv.Atoi(precision)
+		if err != nil || n < 1 || n > 4 {
+			return nil, fmt.Errorf("CALSUN_COORD_PRECISION must be 1 to 4 decimals")
+		}
+		cfg.CoordPrecision = n
+	}
+
+	if maxDays := getenv("CALSUN_MAX_DAYS"); maxDays != "" {
+		n, err := strconv.Atoi(maxDays)
+		if err != nil || n < 1 || n > MaxDaysLimit {
+			return nil, fmt.Errorf("CALSUN_MAX_DAYS must be 1 to %d days", MaxDaysLimit)
+		}
+		cfg.MaxDays = n
+	}
+
+	if domain := getenv("CALSUN_UID_DOMAIN"); domain != "" {
+		if !domainPattern.MatchString(domain) {
+			return nil, fmt.Errorf("CALSUN_UID_DOMAIN must be a domain like calsun.example.com")
+		}
+		cfg.UIDDomain = domain
+	}
+	if migrate := getenv("CALSUN_UID_MIGRATE"); migrate != "" {
+		enabled, err := strconv.ParseBool(migrate)
+		if err != nil {
+			return nil, fmt.Errorf("CALSUN_UID_MIGRATE must be true or false")
+		}
+		cfg.UIDMigrate = enabled
+	}
+
+	cfg.SMTP.Host = getenv("CALSUN_SMTP_HOST")
+	if port := getenv("CALSUN_SMTP_PORT"); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("CALSUN_SMTP_PORT must be a port number")
+		}
+		cfg.SMTP.Port = port
+	}
+	cfg.SMTP.Username = getenv("CALSUN_SMTP_USERNAME")
+	cfg.SMTP.Password = getenv("CALSUN_SMTP_PASSWORD")
+	if from := getenv("CALSUN_SMTP_FROM"); from != "" {
+		if _, err := mail.ParseAddress(from); err != nil {
+			return nil, fmt.Errorf("CALSUN_SMTP_FROM must be an email address")
+		}
+		cfg.SMTP.From = from
+	}
+
+	if influxURL := getenv("CALSUN_INFLUX_URL"); influxURL != "" {
+		if !isWebURL(influxURL) {
+			return nil, fmt.Errorf("CALSUN_INFLUX_URL must be an http(s) URL")
+		}
+		cfg.Influx.URL = influxURL
+	}
+	cfg.Influx.Token = getenv("CALSUN_INFLUX_TOKEN")
+	if interval := getenv("CALSUN_INFLUX_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d < time.Minute {
+			return nil, fmt.Errorf("CALSUN_INFLUX_INTERVAL must be a duration of at least 1m (e.g., 1h)")
+		}
+		cfg.Influx.Interval = d
+	}
+	if locations := getenv("CALSUN_INFLUX_LOCATIONS"); locations != "" {
+		for _, entry := range strings.Split(locations, ";") {
+			if strings.TrimSpace(entry) == "" {
+				continue
+			}
+			coords, name, _ := strings.Cut(entry, "|")
+			lat, lng, err := parseCoordinates(coords)
+			if err != nil {
+				return nil, fmt.Errorf("CALSUN_INFLUX_LOCATIONS entry %q: %w", entry, err)
+			}
+			cfg.Influx.Locations = append(cfg.Influx.Locations, Location{Lat: lat, Lng: lng, Name: strings.TrimSpace(name)})
+		}
+	}
+
+	return cfg, nil
+}
+
+// parseWebhook parses a "platform|URL|lat,lng|name" webhook entry. The name
+// is optional.
+func parseWebhook(entry string) (Webhook, error) {
+	fields := strings.Split(entry, "|")
+	if len(fields) < 3 || len(fields) > 4 {
+		return Webhook{}, fmt.Errorf("must be \"platform|URL|lat,lng|name\"")
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+
+	webhook := Webhook{Platform: fields[0], URL: fields[1]}
+	if len(fields) == 4 {
+		webhook.Name = fields[3]
+	}
+
+	if webhook.Platform != "slack" && webhook.Platform != "discord" {
+		return Webhook{}, fmt.Errorf("platform must be slack or discord")
+	}
+	if u, err := url.Parse(webhook.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return Webhook{}, fmt.Errorf("URL must be an https URL")
+	}
+
+	lat, lng, err := parseCoordinates(fields[2])
+	if err != nil {
+		return Webhook{}, err
+	}
+	webhook.Lat, webhook.Lng = lat, lng
+
+	return webhook, nil
+}
+
+// parseCoordinates parses a "lat,lng" pair in decimal degrees
+func parseCoordinates(s string) (lat, lng float64, err error) {
+	latStr, lngStr, ok := strings.Cut(s, ",")
+	lat, latErr := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	lng, lngErr := strconv.ParseFloat(strings.TrimSpace(lngStr), 64)
+	if !ok || latErr != nil || lngErr != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return 0, 0, fmt.Errorf("location must be \"lat,lng\" in decimal degrees")
+	}
+	return lat, lng, nil
+}
+
+// isWebURL reports whether s is an absolute http or https URL
+func isWebURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,499 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+// env returns a getenv function backed by a map
+func env(vars map[string]string) func(string) string {
+	return func(key string) string {
+		return vars[key]
+	}
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := load(env(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Port != "8080" {
+		t.Errorf("expected default port 8080, got %s", cfg.Port)
+	}
+	if cfg.Site.Title != "CalSun" {
+		t.Errorf("expected default title CalSun, got %s", cfg.Site.Title)
+	}
+	if cfg.Site.AccentColor != "" || cfg.Site.LogoURL != "" || len(cfg.Site.FooterLinks) != 0 {
+		t.Errorf("expected no branding by default, got %+v", cfg.Site)
+	}
+	if cfg.IFTTTServiceKey != "" {
+		t.Error("IFTTT should be disabled by default")
+	}
+}
+
+func TestLoad_Site(t *testing.T) {
+	cfg, err := load(env(map[string]string{
+		"PORT":                "9000",
+		"CALSUN_SITE_TITLE":   "Acme Sun",
+		"CALSUN_SITE_TAGLINE": "Sun times for Acme staff",
+		"CALSUN_LOGO_URL":     "https://acme.example/logo.svg",
+		"CALSUN_ACCENT_COLOR": "#ff6600",
+		"CALSUN_FOOTER_LINKS": "Privacy|https://acme.example/privacy, Contact|https://acme.example/contact",
+		"CALSUN_MESSAGE":      "Maintenance on Sunday",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Port != "9000" {
+		t.Errorf("expected port 9000, got %s", cfg.Port)
+	}
+	if cfg.Site.Title != "Acme Sun" || cfg.Site.Tagline != "Sun times for Acme staff" {
+		t.Errorf("unexpected title/tagline: %+v", cfg.Site)
+	}
+	if cfg.Site.AccentColor != "#ff6600" {
+		t.Errorf("expected accent color, got %s", cfg.Site.AccentColor)
+	}
+	if len(cfg.Site.FooterLinks) != 2 || cfg.Site.FooterLinks[1] != (Link{"Contact", "https://acme.example/contact"}) {
+		t.Errorf("unexpected footer links: %+v", cfg.Site.FooterLinks)
+	}
+	if cfg.Site.Message != "Maintenance on Sunday" {
+		t.Errorf("expected message, got %s", cfg.Site.Message)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		vars map[string]string
+	}{
+		{"invalid color", map[string]string{"CALSUN_ACCENT_COLOR": "red; background: url(x)"}},
+		{"invalid logo", map[string]string{"CALSUN_LOGO_URL": "javascript:alert(1)"}},
+		{"footer link without url", map[string]string{"CALSUN_FOOTER_LINKS": "Privacy"}},
+		{"footer link with bad scheme", map[string]string{"CALSUN_FOOTER_LINKS": "Privacy|ftp://example.com"}},
+		{"invalid sync interval", map[string]string{"CALSUN_SYNC_INTERVAL": "often"}},
+		{"sync interval too short", map[string]string{"CALSUN_SYNC_INTERVAL": "5s"}},
+		{"invalid microsoft tenant", map[string]string{"CALSUN_MICROSOFT_TENANT": "../evil"}},
+		{"invalid smtp port", map[string]string{"CALSUN_SMTP_PORT": "smtp"}},
+		{"invalid smtp sender", map[string]string{"CALSUN_SMTP_FROM": "not an address"}},
+		{"invalid digest time", map[string]string{"CALSUN_DIGEST_TIME": "7am"}},
+		{"webhook with unknown platform", map[string]string{"CALSUN_DIGEST_WEBHOOKS": "teams|https://example.com/hook|55,12"}},
+		{"webhook without https", map[string]string{"CALSUN_DIGEST_WEBHOOKS": "slack|http://example.com/hook|55,12"}},
+		{"webhook with bad location", map[string]string{"CALSUN_DIGEST_WEBHOOKS": "slack|https://example.com/hook|95,12"}},
+		{"webhook missing location", map[string]string{"CALSUN_DIGEST_WEBHOOKS": "slack|https://example.com/hook"}},
+		{"invalid aurora interval", map[string]string{"CALSUN_AURORA_INTERVAL": "30s"}},
+		{"aurora kp too high", map[string]string{"CALSUN_AURORA_KP": "10"}},
+		{"aurora kp zero", map[string]string{"CALSUN_AURORA_KP": "0"}},
+		{"aurora webhook without https", map[string]string{"CALSUN_AURORA_WEBHOOKS": "slack|http://example.com/hook|69.6,19"}},
+		{"invalid elevation lookup", map[string]string{"CALSUN_ELEVATION_LOOKUP": "sometimes"}},
+		{"invalid elevation url", map[string]string{"CALSUN_ELEVATION_URL": "localhost:8080"}},
+		{"invalid push subject", map[string]string{"CALSUN_PUSH_SUBJECT": "admin@example.com"}},
+		{"invalid push mailto", map[string]string{"CALSUN_PUSH_SUBJECT": "mailto:admin"}},
+		{"http push subject", map[string]string{"CALSUN_PUSH_SUBJECT": "http://example.com"}},
+		{"invalid influx url", map[string]string{"CALSUN_INFLUX_URL": "influx:8086"}},
+		{"invalid influx interval", map[string]string{"CALSUN_INFLUX_INTERVAL": "10s"}},
+		{"invalid influx location", map[string]string{"CALSUN_INFLUX_LOCATIONS": "55.6761|Copenhagen"}},
+		{"tls cert without key", map[string]string{"CALSUN_TLS_CERT": "cert.pem"}},
+		{"relative base path", map[string]string{"CALSUN_BASE_PATH": "calsun"}},
+		{"base path with query", map[string]string{"CALSUN_BASE_PATH": "/calsun?x=1"}},
+		{"invalid access log format", map[string]string{"CALSUN_ACCESS_LOG_FORMAT": "combined"}},
+		{"access log sample above 1", map[string]string{"CALSUN_ACCESS_LOG_SAMPLE": "10"}},
+		{"zero access log sample", map[string]string{"CALSUN_ACCESS_LOG_SAMPLE": "0"}},
+		{"invalid h2c", map[string]string{"CALSUN_H2C": "maybe"}},
+		{"http3 without tls", map[string]string{"CALSUN_HTTP3": "true"}},
+		{"invalid headless", map[string]string{"CALSUN_HEADLESS": "yes please"}},
+		{"invalid debug", map[string]string{"CALSUN_DEBUG": "on"}},
+		{"coordinate precision too high", map[string]string{"CALSUN_COORD_PRECISION": "6"}},
+		{"coordinate precision zero", map[string]string{"CALSUN_COORD_PRECISION": "0"}},
+		{"invalid max days", map[string]string{"CALSUN_MAX_DAYS": "forever"}},
+		{"max days zero", map[string]string{"CALSUN_MAX_DAYS": "0"}},
+		{"max days above limit", map[string]string{"CALSUN_MAX_DAYS": "3661"}},
+		{"preview disabled with web ui", map[string]string{"CALSUN_DISABLE_PREVIEW": "true"}},
+		{"invalid cache size", map[string]string{"CALSUN_CACHE_MB": "lots"}},
+		{"uid domain with at sign", map[string]string{"CALSUN_UID_DOMAIN": "calsun@example.com"}},
+		{"uid domain with trailing dot", map[string]string{"CALSUN_UID_DOMAIN": "example.com."}},
+		{"invalid uid migrate", map[string]string{"CALSUN_UID_MIGRATE": "please"}},
+		{"negative cache size", map[string]string{"CALSUN_CACHE_MB": "-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := load(env(tt.vars)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestLoad_Sync(t *testing.T) {
+	cfg, err := load(env(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Sync.GoogleEnabled() {
+		t.Error("Google integration should be disabled by default")
+	}
+	if cfg.Sync.MicrosoftEnabled() {
+		t.Error("Microsoft integration should be disabled by default")
+	}
+	if cfg.Sync.MicrosoftTenant != "organizations" {
+		t.Errorf("expected organizations tenant by default, got %s", cfg.Sync.MicrosoftTenant)
+	}
+
+	cfg, err = load(env(map[string]string{
+		"CALSUN_DATA_DIR":                "/var/lib/calsun",
+		"CALSUN_SYNC_INTERVAL":           "2h",
+		"CALSUN_GOOGLE_CLIENT_ID":        "id",
+		"CALSUN_GOOGLE_CLIENT_SECRET":    "secret",
+		"CALSUN_MICROSOFT_CLIENT_ID":     "id",
+		"CALSUN_MICROSOFT_CLIENT_SECRET": "secret",
+		"CALSUN_MICROSOFT_TENANT":        "contoso.onmicrosoft.com",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.DataDir != "/var/lib/calsun" {
+		t.Errorf("expected data dir, got %s", cfg.DataDir)
+	}
+	if cfg.Sync.Interval != 2*time.Hour {
+		t.Errorf("expected 2h interval, got %s", cfg.Sync.Interval)
+	}
+	if !cfg.Sync.GoogleEnabled() {
+		t.Error("Google integration should be enabled with credentials")
+	}
+	if !cfg.Sync.MicrosoftEnabled() {
+		t.Error("Microsoft integration should be enabled with credentials")
+	}
+	if cfg.Sync.MicrosoftTenant != "contoso.onmicrosoft.com" {
+		t.Errorf("expected tenant, got %s", cfg.Sync.MicrosoftTenant)
+	}
+}
+
+func TestLoad_Digest(t *testing.T) {
+	cfg, err := load(env(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Digest.Time != 7*time.Hour || len(cfg.Digest.Webhooks) != 0 {
+		t.Errorf("unexpected default digest config: %+v", cfg.Digest)
+	}
+
+	cfg, err = load(env(map[string]string{
+		"CALSUN_DIGEST_TIME":     "06:30",
+		"CALSUN_DIGEST_WEBHOOKS": "slack|https://hooks.slack.com/services/T/B/X|55.6761,12.5683|Copenhagen; discord|https://discord.com/api/webhooks/1/abc|-33.8688, 151.2093",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Digest.Time != 6*time.Hour+30*time.Minute {
+		t.Errorf("expected 06:30, got %s", cfg.Digest.Time)
+	}
+
+	want := []Webhook{
+		{Platform: "slack", URL: "https://hooks.slack.com/services/T/B/X", Lat: 55.6761, Lng: 12.5683, Name: "Copenhagen"},
+		{Platform: "discord", URL: "https://discord.com/api/webhooks/1/abc", Lat: -33.8688, Lng: 151.2093},
+	}
+	if len(cfg.Digest.Webhooks) != len(want) {
+		t.Fatalf("expected %d webhooks, got %+v", len(want), cfg.Digest.Webhooks)
+	}
+	for i := range want {
+		if cfg.Digest.Webhooks[i] != want[i] {
+			t.Errorf("webhook %d: expected %+v, got %+v", i, want[i], cfg.Digest.Webhooks[i])
+		}
+	}
+}
+
+func TestLoad_Aurora(t *testing.T) {
+	cfg, err := load(env(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Aurora.Interval != 30*time.Minute || cfg.Aurora.Kp != 0 || len(cfg.Aurora.Webhooks) != 0 {
+		t.Errorf("unexpected default aurora config: %+v", cfg.Aurora)
+	}
+
+	cfg, err = load(env(map[string]string{
+		"CALSUN_AURORA_INTERVAL": "1h",
+		"CALSUN_AURORA_KP":       "5",
+		"CALSUN_AURORA_WEBHOOKS": "discord|https://discord.com/api/webhooks/1/abc|69.6492,18.9553|Tromsø",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Webhook{Platform: "discord", URL: "https://discord.com/api/webhooks/1/abc", Lat: 69.6492, Lng: 18.9553, Name: "Tromsø"}
+	if cfg.Aurora.Interval != time.Hour || cfg.Aurora.Kp != 5 || len(cfg.Aurora.Webhooks) != 1 || cfg.Aurora.Webhooks[0] != want {
+		t.Errorf("unexpected aurora config: %+v", cfg.Aurora)
+	}
+}
+
+func TestLoad_Terrain(t *testing.T) {
+	cfg, err := load(env(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Terrain.Lookup || cfg.Terrain.URL != "" {
+		t.Errorf("expected elevation lookup disabled by default, got %+v", cfg.Terrain)
+	}
+
+	cfg, err = load(env(map[string]string{
+		"CALSUN_ELEVATION_LOOKUP": "true",
+		"CALSUN_ELEVATION_URL":    "http://open-elevation:8080",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Terrain.Lookup || cfg.Terrain.URL != "http://open-elevation:8080" {
+		t.Errorf("unexpected terrain config: %+v", cfg.Terrain)
+	}
+}
+
+func TestLoad_Push(t *testing.T) {
+	cfg, err := load(env(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Push.Enabled() {
+		t.Error("expected push alerts disabled by default")
+	}
+
+	for _, subject := range []string{"mailto:admin@example.com", "https://sun.example.com/contact"} {
+		cfg, err = load(env(map[string]string{"CALSUN_PUSH_SUBJECT": subject}))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !cfg.Push.Enabled() || cfg.Push.Subject != subject {
+			t.Errorf("unexpected push config: %+v", cfg.Push)
+		}
+	}
+}
+
+func TestLoad_Analytics(t *testing.T) {
+	cfg, err := load(env(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Admin.Enabled() || cfg.Analytics.Enabled || cfg.Analytics.Retention != 90 {
+		t.Errorf("expected the dashboard and analytics disabled by default, got %+v %+v", cfg.Admin, cfg.Analytics)
+	}
+
+	cfg, err = load(env(map[string]string{
+		"CALSUN_ADMIN_TOKEN":         "secret",
+		"CALSUN_ANALYTICS":           "true",
+		"CALSUN_ANALYTICS_RETENTION": "30",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Admin.Enabled() || !cfg.Analytics.Enabled || cfg.Analytics.Retention != 30 {
+		t.Errorf("unexpected config: %+v %+v", cfg.Admin, cfg.Analytics)
+	}
+
+	for _, vars := range []map[string]string{
+		{"CALSUN_ANALYTICS": "true"},
+		{"CALSUN_ADMIN_TOKEN": "secret", "CALSUN_ANALYTICS": "yes please"},
+		{"CALSUN_ANALYTICS_RETENTION": "0"},
+		{"CALSUN_ANALYTICS_RETENTION": "400"},
+	} {
+		if _, err := load(env(vars)); err == nil {
+			t.Errorf("expected an error for %v", vars)
+		}
+	}
+}
+
+func TestLoad_SMTP(t *testing.T) {
+	cfg, err := load(env(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.SMTP.Enabled() {
+		t.Error("email subscriptions should be disabled by default")
+	}
+
+	cfg, err = load(env(map[string]string{
+		"CALSUN_SMTP_HOST":     "smtp.example.com",
+		"CALSUN_SMTP_PORT":     "465",
+		"CALSUN_SMTP_USERNAME": "user",
+		"CALSUN_SMTP_PASSWORD": "pass",
+		"CALSUN_SMTP_FROM":     "CalSun <sun@example.com>",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := SMTP{Host: "smtp.example.com", Port: "465", Username: "user", Password: "pass", From: "CalSun <sun@example.com>"}
+	if cfg.SMTP != want {
+		t.Errorf("expected %+v, got %+v", want, cfg.SMTP)
+	}
+	if !cfg.SMTP.Enabled() {
+		t.Error("email subscriptions should be enabled")
+	}
+}
+
+func TestLoad_Influx(t *testing.T) {
+	cfg, err := load(env(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Influx.Enabled() || cfg.Influx.Interval != time.Hour {
+		t.Errorf("unexpected default influx config: %+v", cfg.Influx)
+	}
+
+	cfg, err = load(env(map[string]string{
+		"CALSUN_INFLUX_URL":       "http://influx:8086/api/v2/write?org=acme&bucket=sun",
+		"CALSUN_INFLUX_TOKEN":     "secret",
+		"CALSUN_INFLUX_INTERVAL":  "15m",
+		"CALSUN_INFLUX_LOCATIONS": "55.6761,12.5683|Copenhagen; -33.8688,151.2093",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Influx.Enabled() || cfg.Influx.Token != "secret" || cfg.Influx.Interval != 15*time.Minute {
+		t.Errorf("unexpected influx config: %+v", cfg.Influx)
+	}
+	want := []Location{{Lat: 55.6761, Lng: 12.5683, Name: "Copenhagen"}, {Lat: -33.8688, Lng: 151.2093}}
+	if len(cfg.Influx.Locations) != len(want) || cfg.Influx.Locations[0] != want[0] || cfg.Influx.Locations[1] != want[1] {
+		t.Errorf("expected %+v, got %+v", want, cfg.Influx.Locations)
+	}
+}
+
+func TestLoad_CacheSize(t *testing.T) {
+	cfg, err := load(env(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.CacheSize != 64<<20 {
+		t.Errorf("expected a 64 MB cache by default, got %d bytes", cfg.CacheSize)
+	}
+
+	cfg, err = load(env(map[string]string{"CALSUN_CACHE_MB": "0"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.CacheSize != 0 {
+		t.Errorf("expected the cache to be disabled, got %d bytes", cfg.CacheSize)
+	}
+}
+
+func TestLoad_Server(t *testing.T) {
+	cfg, err := load(env(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Server.TLSEnabled() || cfg.Server.H2C || cfg.Server.HTTP3 {
+		t.Errorf("expected plain HTTP/1.1 by default, got %+v", cfg.Server)
+	}
+
+	cfg, err = load(env(map[string]string{
+		"CALSUN_TLS_CERT":  "/etc/calsun/cert.pem",
+		"CALSUN_TLS_KEY":   "/etc/calsun/key.pem",
+		"CALSUN_H2C":       "true",
+		"CALSUN_HTTP3":     "1",
+		"CALSUN_BASE_PATH": "/tools/calsun/",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Server.TLSEnabled() || !cfg.Server.H2C || !cfg.Server.HTTP3 {
+		t.Errorf("unexpected server config: %+v", cfg.Server)
+	}
+	if cfg.Server.BasePath != "/tools/calsun" {
+		t.Errorf("expected base path without trailing slash, got %q", cfg.Server.BasePath)
+	}
+
+	// The root is the same as no base path
+	cfg, err = load(env(map[string]string{"CALSUN_BASE_PATH": "/"}))
+	if err != nil || cfg.Server.BasePath != "" {
+		t.Errorf("expected no base path, got %q (%v)", cfg.Server.BasePath, err)
+	}
+}
+
+func TestLoad_Headless(t *testing.T) {
+	cfg, err := load(env(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Headless || cfg.DisablePreview {
+		t.Error("expected the web UI to be enabled by default")
+	}
+
+	cfg, err = load(env(map[string]string{"CALSUN_HEADLESS": "true", "CALSUN_DISABLE_PREVIEW": "true"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Headless || !cfg.DisablePreview {
+		t.Errorf("expected headless mode without preview, got %+v", cfg)
+	}
+}
+
+func TestLoad_Debug(t *testing.T) {
+	cfg, err := load(env(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Debug {
+		t.Error("expected debug mode to be off by default")
+	}
+
+	cfg, err = load(env(map[string]string{"CALSUN_DEBUG": "true"}))
+	if err != nil || !cfg.Debug {
+		t.Errorf("expected debug mode, got %v (%v)", cfg.Debug, err)
+	}
+}
+
+func TestLoad_AccessLog(t *testing.T) {
+	cfg, err := load(env(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Server.AccessLog.Enabled() || cfg.Server.AccessLog.Sample != 1 {
+		t.Errorf("unexpected default access log config: %+v", cfg.Server.AccessLog)
+	}
+
+	cfg, err = load(env(map[string]string{
+		"CALSUN_ACCESS_LOG_FORMAT": "json",
+		"CALSUN_ACCESS_LOG_PATH":   "/var/log/calsun/access.log",
+		"CALSUN_ACCESS_LOG_SAMPLE": "0.1",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := AccessLog{Format: "json", Path: "/var/log/calsun/access.log", Sample: 0.1}
+	if !cfg.Server.AccessLog.Enabled() || cfg.Server.AccessLog != want {
+		t.Errorf("expected %+v, got %+v", want, cfg.Server.AccessLog)
+	}
+}
+
+func TestLoad_CoordPrecision(t *testing.T) {
+	cfg, err := load(env(nil))
+	if err != nil || cfg.CoordPrecision != 4 {
+		t.Errorf("expected 4 decimals by default, got %d (%v)", cfg.CoordPrecision, err)
+	}
+
+	cfg, err = load(env(map[string]string{"CALSUN_COORD_PRECISION": "2"}))
+	if err != nil || cfg.CoordPrecision != 2 {
+		t.Errorf("expected 2 decimals, got %d (%v)", cfg.CoordPrecision, err)
+	}
+}
+
+func TestLoad_MaxDays(t *testing.T) {
+	cfg, err := load(env(nil))
+	if err != nil || cfg.MaxDays != 90 {
+		t.Errorf("expected 90 days by default, got %d (%v)", cfg.MaxDays, err)
+	}
+	cfg, err = load(env(map[string]string{"CALSUN_MAX_DAYS": "1095"}))
+	if err != nil || cfg.MaxDays != 1095 {
+		t.Errorf("expected 1095 days, got %d (%v)", cfg.MaxDays, err)
+	}
+}
+
+func TestLoad_UIDDomain(t *testing.T) {
+	cfg, err := load(env(nil))
+	if err != nil || cfg.UIDDomain != "calsun" || cfg.UIDMigrate {
+		t.Errorf("expected the calsun UID domain by default, got %q (%v)", cfg.UIDDomain, err)
+	}
+
+	cfg, err = load(env(map[string]string{"CALSUN_UID_DOMAIN": "sun.example.com", "CALSUN_UID_MIGRATE": "true"}))
+	if err != nil || cfg.UIDDomain != "sun.example.com" || !cfg.UIDMigrate {
+		t.Errorf("expected the configured UID domain and migration, got %q, %v (%v)", cfg.UIDDomain, cfg.UIDMigrate, err)
+	}
+}
@@ -1,10 +1,22 @@
 module calsun
 
-go 1.23
+go 1.24
 
 require (
 	github.com/arran4/golang-ical v0.3.2
 	github.com/sixdouglas/suncalc v0.0.0-20250114185126-291b1938b70c
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 )
 
-require github.com/bradfitz/latlong v0.0.0-20170410180902-f3db6d0dff40 // indirect
+require (
+	github.com/bradfitz/latlong v0.0.0-20170410180902-f3db6d0dff40
+	github.com/quic-go/quic-go v0.59.0
+)
+
+require (
+	github.com/quic-go/qpack v0.6.0 // indirect
+	golang.org/x/crypto v0.41.0 // indirect
+	golang.org/x/net v0.43.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+	golang.org/x/text v0.28.0 // indirect
+)
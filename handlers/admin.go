@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	"calsun/config"
+	"calsun/services"
+)
+
+var adminDaysParam = paramDef{
+	Name:        "days",
+	Type:        paramTypeInteger,
+	Min:         bound(1),
+	Max:         bound(config.MaxAnalyticsRetention),
+	Default:     30,
+	Description: "Days of usage statistics to total, up to the retention",
+}
+
+var adminTemplate *template.Template
+
+func init() {
+	var err error
+	adminTemplate, err = template.ParseFS(templatesFS, "templates/admin.html")
+	if err != nil {
+		panic("failed to parse admin template: " + err.Error())
+	}
+}
+
+// adminData is the template data for the admin dashboard
+type adminData struct {
+	Site      config.Site
+	BasePath  string
+	Days      int
+	Retention int
+	Analytics *services.UsageSummary // nil when analytics are disabled
+	Caches    []cacheListing
+}
+
+// adminAuthorized checks the admin token, given as the password of HTTP Basic
+// authentication (for browsers) or as a Bearer token. Writes a not found
+// response if the dashboard is disabled and asks for credentials if the
+// token is wrong.
+func adminAuthorized(w http.ResponseWriter, r *http.Request) bool {
+	if !cfg.Admin.Enabled() {
+		http.NotFound(w, r)
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		_, token, _ = r.BasicAuth()
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Admin.Token)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Basic realm="CalSun admin", charset="UTF-8"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// AdminHandler shows the admin dashboard with the usage statistics of the
+// last days days
+func AdminHandler(w http.ResponseWriter, r *http.Request) {
+	if !adminAuthorized(w, r) {
+		return
+	}
+	days, errMsg := adminDaysParam.parseInt(r.URL.Query())
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	data := adminData{Site: cfg.Site, BasePath: cfg.Server.BasePath, Days: days, Retention: cfg.Analytics.Retention}
+	if usageAnalytics != nil {
+		summary := usageAnalytics.Summary(days)
+		data.Analytics = &summary
+	}
+	now := clock()
+	for _, c := range adminCaches() {
+		data.Caches = append(data.Caches, listCache(c, now))
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := adminTemplate.Execute(w, data); err != nil {
+		log.Printf("render admin page: %v", err)
+	}
+}
+
+// AdminAnalyticsHandler returns the usage statistics of the last days days
+// as JSON
+func AdminAnalyticsHandler(w http.ResponseWriter, r *http.Request) {
+	if !adminAuthorized(w, r) {
+		return
+	}
+	if usageAnalytics == nil {
+		http.Error(w, "analytics are disabled (set CALSUN_ANALYTICS)", http.StatusNotFound)
+		return
+	}
+	days, errMsg := adminDaysParam.parseInt(r.URL.Query())
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(usageAnalytics.Summary(days))
+}
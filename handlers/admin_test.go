@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"calsun/config"
+	"calsun/services"
+)
+
+// enableAdmin configures the admin dashboard with token for the test
+func enableAdmin(t *testing.T, token string) {
+	t.Helper()
+	original := cfg
+	t.Cleanup(func() { Configure(original) })
+	c := config.Default()
+	c.Admin.Token = token
+	Configure(c)
+}
+
+func TestAdminAuthorized(t *testing.T) {
+	req := httptest.NewRequest("GET", "/admin", nil)
+	w := httptest.NewRecorder()
+	AdminHandler(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without a token configured, got %d", w.Code)
+	}
+
+	enableAdmin(t, "secret")
+	tests := []struct {
+		name   string
+		modify func(*http.Request)
+		status int
+	}{
+		{"no credentials", func(*http.Request) {}, http.StatusUnauthorized},
+		{"wrong password", func(r *http.Request) { r.SetBasicAuth("admin", "guess") }, http.StatusUnauthorized},
+		{"basic", func(r *http.Request) { r.SetBasicAuth("anyone", "secret") }, http.StatusOK},
+		{"bearer", func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret") }, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/admin", nil)
+			tt.modify(req)
+			w := httptest.NewRecorder()
+			AdminHandler(w, req)
+			if w.Code != tt.status {
+				t.Errorf("expected %d, got %d", tt.status, w.Code)
+			}
+			if tt.status == http.StatusUnauthorized && !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Basic") {
+				t.Error("expected a Basic authentication challenge")
+			}
+		})
+	}
+}
+
+func TestAdminHandler(t *testing.T) {
+	enableAdmin(t, "secret")
+	req := httptest.NewRequest("GET", "/admin", nil)
+	req.SetBasicAuth("admin", "secret")
+	w := httptest.NewRecorder()
+	AdminHandler(w, req)
+	if !strings.Contains(w.Body.String(), "Usage analytics are disabled") {
+		t.Errorf("expected the analytics to be disabled, got %s", w.Body.String())
+	}
+
+	a := useTestAnalytics(t)
+	a.Record(services.UsageRecord{Endpoint: "/calendar.ics", HasLocation: true, Lat: 55.6761, Lng: 12.5683, Options: []string{"events=sunset"}})
+	w = httptest.NewRecorder()
+	AdminHandler(w, req)
+	body := w.Body.String()
+	for _, want := range []string{"1 requests", "/calendar.ics", "50N 10E", "events=sunset", "<td>calendar</td>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the dashboard", want)
+		}
+	}
+}
+
+func TestAdminAnalyticsHandler(t *testing.T) {
+	enableAdmin(t, "secret")
+	request := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		AdminAnalyticsHandler(w, req)
+		return w
+	}
+	if w := request("/admin/analytics"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 with analytics disabled, got %d", w.Code)
+	}
+
+	a := useTestAnalytics(t)
+	a.Record(services.UsageRecord{Endpoint: "/api/today"})
+	w := request("/admin/analytics?days=7")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var summary services.UsageSummary
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil || summary.Requests != 1 {
+		t.Errorf("expected one request, got %+v (%v)", summary, err)
+	}
+	if w := request("/admin/analytics?days=0"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for days=0, got %d", w.Code)
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"calsun/services"
+)
+
+var (
+	adminCacheParam = paramDef{
+		Name:        "cache",
+		Type:        paramTypeString,
+		Description: "Cache to list or flush (calendar, timezone, geocode, weather, aurora, elevation, horizon, or satellites), all of them if not given",
+	}
+	adminCacheKeyParam = paramDef{
+		Name:        "key",
+		Type:        paramTypeString,
+		Description: "Key of the entry to flush, every entry if not given; requires cache",
+	}
+)
+
+// cacheOwner is an upstream provider that caches its responses
+type cacheOwner interface {
+	Cache() services.Cache
+}
+
+// namedCache is a cache managed through the admin endpoints
+type namedCache struct {
+	name  string
+	cache services.Cache
+}
+
+// adminCaches returns the calendar response cache, the detected timezone
+// cache, and the caches of the upstream providers. Providers without a cache
+// (e.g., fakes in tests) are left out.
+func adminCaches() []namedCache {
+	caches := []namedCache{{"calendar", calendarCache}, {"timezone", services.TimezoneCache()}}
+	providers := []struct {
+		name     string
+		provider any
+	}{
+		{"geocode", geocoder},
+		{"weather", weather},
+		{"aurora", kpForecast},
+		{"elevation", terrain},
+		{"horizon", horizons},
+		{"satellites", orbits},
+	}
+	for _, p := range providers {
+		if owner, ok := p.provider.(cacheOwner); ok {
+			caches = append(caches, namedCache{p.name, owner.Cache()})
+		}
+	}
+	return caches
+}
+
+// selectCaches returns the cache named name, or every cache if name is
+// empty. Writes a not found response for an unknown name.
+func selectCaches(w http.ResponseWriter, name string) ([]namedCache, bool) {
+	caches := adminCaches()
+	if name == "" {
+		return caches, true
+	}
+	for _, c := range caches {
+		if c.name == name {
+			return []namedCache{c}, true
+		}
+	}
+	http.Error(w, "unknown cache "+name, http.StatusNotFound)
+	return nil, false
+}
+
+// cacheListing describes a cache's contents
+type cacheListing struct {
+	Name    string              `json:"name"`
+	Entries int                 `json:"entries"`
+	Bytes   int                 `json:"bytes"`
+	Keys    []cacheEntryListing `json:"keys"`
+}
+
+// cacheEntryListing describes a cached entry, with its remaining lifetime
+type cacheEntryListing struct {
+	services.CacheEntry
+	TTL int `json:"ttl"` // Seconds until the entry expires
+}
+
+// listCache describes the entries of a cache at now
+func listCache(c namedCache, now time.Time) cacheListing {
+	listing := cacheListing{Name: c.name, Keys: []cacheEntryListing{}}
+	for _, entry := range c.cache.Entries() {
+		ttl := max(0, int(entry.Expires.Sub(now).Seconds()))
+		listing.Keys = append(listing.Keys, cacheEntryListing{CacheEntry: entry, TTL: ttl})
+		listing.Entries++
+		listing.Bytes += entry.Size
+	}
+	return listing
+}
+
+// AdminCacheHandler lists the keys, sizes, and remaining lifetimes of the
+// entries of one or every cache as JSON
+func AdminCacheHandler(w http.ResponseWriter, r *http.Request) {
+	if !adminAuthorized(w, r) {
+		return
+	}
+	caches, ok := selectCaches(w, r.URL.Query().Get(adminCacheParam.Name))
+	if !ok {
+		return
+	}
+
+	now := clock()
+	listings := make([]cacheListing, len(caches))
+	for i, c := range caches {
+		listings[i] = listCache(c, now)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(struct {
+		Caches []cacheListing `json:"caches"`
+	}{listings})
+}
+
+// AdminCacheFlushHandler removes an entry of a cache, every entry of a
+// cache, or every entry of every cache, and returns how many were removed
+func AdminCacheFlushHandler(w http.ResponseWriter, r *http.Request) {
+	if !adminAuthorized(w, r) {
+		return
+	}
+
+	name, key := r.FormValue(adminCacheParam.Name), r.FormValue(adminCacheKeyParam.Name)
+	if key != "" && name == "" {
+		http.Error(w, "key requires the cache parameter", http.StatusBadRequest)
+		return
+	}
+	caches, ok := selectCaches(w, name)
+	if !ok {
+		return
+	}
+
+	flushed := 0
+	if key != "" {
+		if !caches[0].cache.Delete(key) {
+			http.Error(w, "no cached entry for key", http.StatusNotFound)
+			return
+		}
+		flushed = 1
+	} else {
+		for _, c := range caches {
+			flushed += c.cache.Clear()
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(struct {
+		Flushed int `json:"flushed"`
+	}{flushed})
+}
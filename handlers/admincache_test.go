@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// adminRequest serves an authenticated admin request
+func adminRequest(handler http.HandlerFunc, method, target string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+func TestAdminCacheHandler(t *testing.T) {
+	enableAdmin(t, "secret")
+	calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&days=2")
+
+	w := adminRequest(AdminCacheHandler, "GET", "/admin/cache", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var listing struct {
+		Caches []struct {
+			Name    string `json:"name"`
+			Entries int    `json:"entries"`
+			Bytes   int    `json:"bytes"`
+			Keys    []struct {
+				Key  string `json:"key"`
+				Size int    `json:"size"`
+				TTL  int    `json:"ttl"`
+			} `json:"keys"`
+		} `json:"caches"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&listing); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	var names []string
+	for _, c := range listing.Caches {
+		names = append(names, c.Name)
+	}
+	if len(names) == 0 || names[0] != "calendar" || !strings.Contains(strings.Join(names, ","), "geocode") {
+		t.Errorf("expected the calendar and provider caches, got %v", names)
+	}
+	calendar := listing.Caches[0]
+	if calendar.Entries != 1 || len(calendar.Keys) != 1 || !strings.HasPrefix(calendar.Keys[0].Key, "ics:") {
+		t.Fatalf("expected the cached calendar, got %+v", calendar)
+	}
+	if calendar.Bytes != calendar.Keys[0].Size || calendar.Bytes == 0 || calendar.Keys[0].TTL <= 0 {
+		t.Errorf("expected the size and remaining lifetime, got %+v", calendar)
+	}
+
+	w = adminRequest(AdminCacheHandler, "GET", "/admin/cache?cache=calendar", nil)
+	if err := json.NewDecoder(w.Body).Decode(&listing); err != nil || len(listing.Caches) != 1 {
+		t.Errorf("expected only the calendar cache, got %+v (%v)", listing, err)
+	}
+	if w := adminRequest(AdminCacheHandler, "GET", "/admin/cache?cache=nope", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown cache, got %d", w.Code)
+	}
+	req := httptest.NewRequest("GET", "/admin/cache", nil)
+	w = httptest.NewRecorder()
+	AdminCacheHandler(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without credentials, got %d", w.Code)
+	}
+}
+
+func TestAdminCacheFlushHandler(t *testing.T) {
+	enableAdmin(t, "secret")
+	calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&days=2")
+	calendarBody(t, "/calendar.ics?lat=40.7128&lng=-74.006&days=2")
+	key := calendarCache.Entries()[0].Key
+
+	if w := adminRequest(AdminCacheFlushHandler, "POST", "/admin/cache/flush", url.Values{"key": {key}}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a key without a cache, got %d", w.Code)
+	}
+
+	flushed := func(w *httptest.ResponseRecorder) int {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Flushed int `json:"flushed"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Flushed
+	}
+	if n := flushed(adminRequest(AdminCacheFlushHandler, "POST", "/admin/cache/flush", url.Values{"cache": {"calendar"}, "key": {key}})); n != 1 || calendarCache.Len() != 1 {
+		t.Errorf("expected the one entry flushed, got %d (%d left)", n, calendarCache.Len())
+	}
+	if w := adminRequest(AdminCacheFlushHandler, "POST", "/admin/cache/flush", url.Values{"cache": {"calendar"}, "key": {key}}); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a key no longer cached, got %d", w.Code)
+	}
+	if n := flushed(adminRequest(AdminCacheFlushHandler, "POST", "/admin/cache/flush", nil)); n < 1 || calendarCache.Len() != 0 {
+		t.Errorf("expected every cache flushed, got %d (%d left)", n, calendarCache.Len())
+	}
+}
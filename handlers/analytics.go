@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"calsun/services"
+)
+
+// usageAnalytics counts served requests; nil unless CALSUN_ANALYTICS is set
+var usageAnalytics *services.Analytics
+
+// SetAnalytics sets the usage statistics requests are counted in
+func SetAnalytics(a *services.Analytics) {
+	usageAnalytics = a
+}
+
+// WithAnalytics counts the requests next serves successfully by the path of
+// their route pattern, so a route's methods count together. It must wrap the
+// ServeMux directly, as the pattern is set on the request by the mux.
+func WithAnalytics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if usageAnalytics == nil || r.Pattern == "" || rec.status >= 400 {
+			return
+		}
+		q := r.URL.Query()
+		usage := services.UsageRecord{Endpoint: routePath(r.Pattern), Options: usageOptions(q)}
+		lat, latErr := latParam.parseFloat(q)
+		lng, lngErr := lngParam.parseFloat(q)
+		if q.Has(latParam.Name) && q.Has(lngParam.Name) && latErr == "" && lngErr == "" {
+			usage.HasLocation, usage.Lat, usage.Lng = true, lat, lng
+		}
+		usageAnalytics.Record(usage)
+	})
+}
+
+// usageOptions returns the calendar parameters given in q, with their values
+// for parameters with a fixed set of choices (e.g., "events=sunset"). The
+// location and free-text parameters are left out.
+func usageOptions(q url.Values) []string {
+	var options []string
+	for _, p := range calendarParamDefs {
+		if !q.Has(p.Name) || p.Type == paramTypeString || p.Name == latParam.Name || p.Name == lngParam.Name {
+			continue
+		}
+		if p.Values == nil {
+			options = append(options, p.Name)
+			continue
+		}
+		for _, value := range strings.Split(q.Get(p.Name), ",") {
+			if slices.Contains(p.Values, value) {
+				options = append(options, p.Name+"="+value)
+			}
+		}
+	}
+	return options
+}
+
+// statusRecorder records the status code of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"calsun/services"
+)
+
+// useTestAnalytics counts requests in fresh usage statistics for the test
+func useTestAnalytics(t *testing.T) *services.Analytics {
+	t.Helper()
+	a, err := services.OpenAnalytics(filepath.Join(t.TempDir(), "analytics.json"), 30)
+	if err != nil {
+		t.Fatalf("failed to open analytics: %v", err)
+	}
+	original := usageAnalytics
+	t.Cleanup(func() { SetAnalytics(original) })
+	SetAnalytics(a)
+	return a
+}
+
+func TestWithAnalytics(t *testing.T) {
+	a := useTestAnalytics(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /calendar.ics", CalendarHandler)
+	handler := WithAnalytics(mux)
+
+	for _, target := range []string{
+		"/calendar.ics?lat=55.6761&lng=12.5683&name=Home&events=sunrise,sunset&days=3",
+		"/calendar.ics?lat=100&lng=12.5683", // Invalid, not counted
+		"/unknown",                          // Not routed, not counted
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+	}
+
+	summary := a.Summary(1)
+	if summary.Requests != 1 || summary.Endpoints[0] != (services.UsageCount{Key: "/calendar.ics", Count: 1}) {
+		t.Errorf("expected one calendar request, got %+v", summary)
+	}
+	if len(summary.Locations) != 1 || summary.Locations[0].Key != "50N 10E" {
+		t.Errorf("expected the coarse location cell, got %+v", summary.Locations)
+	}
+	var options []string
+	for _, option := range summary.Options {
+		options = append(options, option.Key)
+	}
+	if want := []string{"days", "events=sunrise", "events=sunset"}; !slices.Equal(options, want) {
+		t.Errorf("expected options %v, got %v", want, options)
+	}
+}
+
+func TestUsageOptions(t *testing.T) {
+	req := httptest.NewRequest("GET", "/calendar.ics?place=Copenhagen&name=Home&filename=home&geohash=u3buz&azformat=compass&events=sunset,bogus", nil)
+	if got := usageOptions(req.URL.Query()); !slices.Equal(got, []string{"events=sunset", "azformat=compass"}) {
+		t.Errorf("expected only the known events and azformat, got %v", got)
+	}
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"archive/zip"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"calsun/services"
+)
+
+var (
+	archiveYearParam = paramDef{
+		Name:        "year",
+		Type:        paramTypeInteger,
+		Min:         bound(1000),
+		Max:         bound(3000),
+		Description: "Year to archive (default: the current year at the location)",
+	}
+	archiveSplitParam = paramDef{
+		Name:        "split",
+		Type:        paramTypeEnum,
+		Values:      []string{"month", "year"},
+		Default:     "month",
+		Description: "One calendar file per month, or one for the whole year",
+	}
+)
+
+// archiveParamDefs lists the parameters accepted by the archive download:
+// those of the calendar except the date range, plus the year
+var archiveParamDefs = append(slices.DeleteFunc(slices.Clone(calendarParamDefs), func(p paramDef) bool {
+	return p.Name == daysParam.Name || p.Name == dateParam.Name
+}), archiveYearParam, archiveSplitParam)
+
+// archiveFile is a calendar file in the archive, covering local dates from
+// start up to (not including) end
+type archiveFile struct {
+	name  string
+	start time.Time
+	end   time.Time
+}
+
+// ArchiveHandler streams a zip of static iCal files covering a year, one per
+// month (e.g., calsun-2025-01.ics) or one for the year, for importing instead
+// of subscribing. Accepts the calendar's parameters except days and date.
+func ArchiveHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	year, errMsg := archiveYearParam.parseInt(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	split, errMsg := archiveSplitParam.parseEnum(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	tz := services.GetTimezone(params.lat, params.lng)
+	if year == 0 {
+		year = params.now.In(tz).Year()
+	}
+	// The archive covers whole months, not the requested date
+	params.date = time.Time{}
+
+	base := strings.TrimSuffix(params.filename, ".ics")
+	if base == "" {
+		base = "calsun"
+	}
+	files := archiveFiles(base, year, split, tz)
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", attachment(fmt.Sprintf("%s-%d.zip", base, year), "calsun.zip"))
+	zw := zip.NewWriter(w)
+	for _, file := range files {
+		// Events of a local date may fall on the adjacent UTC dates
+		startDate := time.Date(file.start.Year(), file.start.Month(), file.start.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+		endDate := time.Date(file.end.Year(), file.end.Month(), file.end.Day(), 0, 0, 0, 0, time.UTC)
+		days := int(endDate.Sub(startDate).Hours()/24) + 1
+		calName, events := buildCalendarEventsRange(r, params, tz, startDate, days)
+		events = slices.DeleteFunc(events, func(event services.CalendarEvent) bool {
+			return event.Start.Before(file.start) || !event.Start.Before(file.end)
+		})
+
+		body, err := serializeICS(calName, tz, events, params.now)
+		if err != nil {
+			log.Printf("archive %s: %v", file.name, err)
+			return
+		}
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: params.now})
+		if err == nil {
+			_, err = fw.Write(body)
+		}
+		if err != nil {
+			log.Printf("archive %s: %v", file.name, err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Printf("archive: %v", err)
+	}
+}
+
+// archiveFiles returns the files of a year's archive, named after base
+func archiveFiles(base string, year int, split string, tz *time.Location) []archiveFile {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, tz)
+	if split == "year" {
+		return []archiveFile{{fmt.Sprintf("%s-%d.ics", base, year), start, start.AddDate(1, 0, 0)}}
+	}
+	files := make([]archiveFile, 0, 12)
+	for month := start; month.Year() == year; month = month.AddDate(0, 1, 0) {
+		files = append(files, archiveFile{fmt.Sprintf("%s-%s.ics", base, month.Format("2006-01")), month, month.AddDate(0, 1, 0)})
+	}
+	return files
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// readArchive returns the files of a zip response by name
+func readArchive(t *testing.T, w *httptest.ResponseRecorder) (map[string]string, []string) {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	files := map[string]string{}
+	var names []string
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+		names = append(names, f.Name)
+	}
+	return files, names
+}
+
+func TestArchiveHandler_Months(t *testing.T) {
+	req := httptest.NewRequest("GET", "/archive.zip?lat=55.6761&lng=12.5683&name=Copenhagen&year=2025&exclude=sunset", nil)
+	w := httptest.NewRecorder()
+
+	ArchiveHandler(w, req)
+
+	if got := w.Header().Get("Content-Type"); got != "application/zip" {
+		t.Errorf("expected application/zip, got %q", got)
+	}
+	if got := w.Header().Get("Content-Disposition"); !strings.Contains(got, "calsun-2025.zip") {
+		t.Errorf("expected calsun-2025.zip, got %q", got)
+	}
+	files, names := readArchive(t, w)
+	if len(names) != 12 || names[0] != "calsun-2025-01.ics" || names[11] != "calsun-2025-12.ics" {
+		t.Fatalf("expected 12 monthly files, got %v", names)
+	}
+
+	// Each month has one sunrise per day, all on its own local dates
+	days := []int{31, 28, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+	for i, name := range names {
+		body := files[name]
+		if n := strings.Count(body, "BEGIN:VEVENT"); n != days[i] {
+			t.Errorf("%s: expected %d events, got %d", name, days[i], n)
+		}
+		if report := lintICS([]byte(body)); !report.Valid {
+			t.Errorf("%s: invalid calendar: %+v", name, report.Errors)
+		}
+	}
+	// Sunrise on January 1 in Copenhagen is 08:40 CET, 07:40 UTC
+	if !strings.Contains(files["calsun-2025-01.ics"], "DTSTART:20250101T0740") {
+		t.Error("expected January to start with the sunrise of January 1")
+	}
+	if strings.Contains(files["calsun-2025-01.ics"], "DTSTART:20250201") || strings.Contains(files["calsun-2025-01.ics"], "DTSTART:20241231") {
+		t.Error("expected no events of other months in January")
+	}
+}
+
+func TestArchiveHandler_Year(t *testing.T) {
+	req := httptest.NewRequest("GET", "/archive.zip?lat=55.6761&lng=12.5683&year=2024&split=year&exclude=sunset&filename=copenhagen.ics", nil)
+	w := httptest.NewRecorder()
+
+	ArchiveHandler(w, req)
+
+	if got := w.Header().Get("Content-Disposition"); !strings.Contains(got, "copenhagen-2024.zip") {
+		t.Errorf("expected copenhagen-2024.zip, got %q", got)
+	}
+	files, names := readArchive(t, w)
+	if len(names) != 1 || names[0] != "copenhagen-2024.ics" {
+		t.Fatalf("expected one file for the year, got %v", names)
+	}
+	if n := strings.Count(files[names[0]], "BEGIN:VEVENT"); n != 366 {
+		t.Errorf("expected 366 sunrises in the leap year, got %d", n)
+	}
+}
+
+func TestArchiveHandler_InvalidParams(t *testing.T) {
+	for _, query := range []string{
+		"lat=55.6761&lng=12.5683&year=999",
+		"lat=55.6761&lng=12.5683&split=week",
+		"lng=12.5683",
+	} {
+		w := httptest.NewRecorder()
+		ArchiveHandler(w, httptest.NewRequest("GET", "/archive.zip?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"calsun/services"
+)
+
+// auroraTimeout bounds how long a calendar request waits for the Kp forecast
+const auroraTimeout = 3 * time.Second
+
+// kpForecast is the Kp forecast provider shared by all handlers
+var kpForecast services.KpProvider = services.NewSWPC(services.SWPCURL)
+
+// auroraThreshold returns the Kp index the aurora parameter asks for at a
+// location: its own threshold for "auto", or the given index. Zero means no
+// aurora events, also for "auto" where aurora is not seen.
+func auroraThreshold(value string, lat, lng float64) int {
+	if value == "auto" {
+		kp, _ := services.AuroraKpThreshold(lat, lng)
+		return kp
+	}
+	kp, _ := strconv.Atoi(value)
+	return kp
+}
+
+// buildAuroraEvents returns the aurora events for the nights of the date
+// range. An unavailable forecast is skipped, so the calendar is still served.
+func buildAuroraEvents(ctx context.Context, params *calendarParams, opts services.CalendarOptions, startDate time.Time, days int) []services.CalendarEvent {
+	ctx, cancel := context.WithTimeout(ctx, auroraTimeout)
+	defer cancel()
+
+	forecast, err := kpForecast.KpForecast(ctx)
+	if err != nil {
+		log.Printf("Kp forecast: %v", err)
+		return nil
+	}
+	return services.BuildAuroraEvents(opts, forecast, params.aurora, startDate, days)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+// fakeKpForecast is a KpProvider returning a storm reaching Kp 6- on the
+// evening of 15 March 2025, or an error. It counts its calls.
+type fakeKpForecast struct {
+	err   error
+	calls int
+}
+
+func (f *fakeKpForecast) KpForecast(ctx context.Context) ([]services.KpPeriod, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	start := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	var forecast []services.KpPeriod
+	for i, kp := range []float64{3.33, 4, 5.67, 5, 4.33, 2.67} {
+		forecast = append(forecast, services.KpPeriod{Start: start.Add(time.Duration(i) * 3 * time.Hour), Kp: kp})
+	}
+	return forecast, nil
+}
+
+// useTestKpForecast replaces the Kp forecast provider for the duration of the
+// test
+func useTestKpForecast(t *testing.T, provider services.KpProvider) {
+	original := kpForecast
+	kpForecast = provider
+	t.Cleanup(func() { kpForecast = original })
+}
+
+func TestCalendarHandler_Aurora(t *testing.T) {
+	enableDebug(t)
+	provider := &fakeKpForecast{}
+	useTestKpForecast(t, provider)
+
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&aurora=auto&days=3&now=2025-03-15T12:00:00Z", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := unfoldICal(w.Body.String())
+	for _, want := range []string{"SUMMARY:Aurora possible tonight (Kp 6)", "Threshold: Kp 5 (geomagnetic latitude 55.3°)", "SUMMARY:Sunrise"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q", want)
+		}
+	}
+
+	// A higher threshold than the forecast reaches
+	w = httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&aurora=7&now=2025-03-15T12:00:00Z", nil))
+	if strings.Contains(w.Body.String(), "Aurora") {
+		t.Error("expected no aurora events below the threshold")
+	}
+
+	// Too far from the poles, the forecast is not fetched
+	calls := provider.calls
+	w = httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=40.4168&lng=-3.7038&aurora=auto&now=2025-03-15T12:00:00Z", nil))
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "Aurora") || provider.calls != calls {
+		t.Error("expected no aurora events or forecast in Madrid")
+	}
+
+	w = httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&aurora=10", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid Kp index, got %d", w.Code)
+	}
+}
+
+func TestCalendarHandler_AuroraUnavailable(t *testing.T) {
+	useTestKpForecast(t, &fakeKpForecast{err: errors.New("upstream down")})
+
+	w := httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=69.6492&lng=18.9553&aurora=auto", nil))
+
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "Aurora") || !strings.Contains(w.Body.String(), "BEGIN:VEVENT") {
+		t.Error("expected the calendar without aurora events")
+	}
+}
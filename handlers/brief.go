@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"calsun/services"
+)
+
+var briefFormatParam = paramDef{
+	Name:        "format",
+	Type:        paramTypeEnum,
+	Values:      []string{"json", "text"},
+	Description: "Response format: json, or text for the paragraph alone as plain text (default: text if the Accept header asks for text/plain, else json)",
+}
+
+// briefParamDefs lists the parameters accepted by BriefHandler
+var briefParamDefs = []paramDef{latParam, lngParam, nameParam, dateParam, localeParam, briefFormatParam}
+
+// briefResponse is the JSON body returned by BriefHandler
+type briefResponse struct {
+	Date     string `json:"date"`
+	Location string `json:"location"`
+	Timezone string `json:"timezone"`
+	Text     string `json:"text"` // The paragraph to speak
+}
+
+// BriefHandler returns a paragraph describing today's sun and moon for a
+// location, written to be read aloud by smart speakers and morning briefing
+// scripts
+func BriefHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	format, errMsg := briefFormatParam.parseEnum(r.URL.Query())
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	if format == "" {
+		format = "json"
+		if strings.Contains(r.Header.Get("Accept"), "text/plain") {
+			format = "text"
+		}
+	}
+
+	tz := services.GetTimezone(params.lat, params.lng)
+	now := params.now.In(tz)
+	day := services.BriefDay{Location: params.name, Timezone: tz, Now: now}
+	if !params.date.IsZero() {
+		// Another date is told in the present tense
+		now, day.Now = params.date, time.Time{}
+	}
+	noon := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, tz)
+	day.Today = services.GetSunTimes(params.lat, params.lng, noon)
+	day.Yesterday = services.GetSunTimes(params.lat, params.lng, noon.AddDate(0, 0, -1))
+	text := services.Brief(day, params.locale)
+
+	if format == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(text + "\n"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(briefResponse{
+		Date:     noon.Format("2006-01-02"),
+		Location: locationName(params),
+		Timezone: tz.String(),
+		Text:     text,
+	})
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+func TestBriefHandler(t *testing.T) {
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	SetClock(services.FixedClock(time.Date(2025, 11, 3, 9, 0, 0, 0, time.UTC)))
+
+	req := httptest.NewRequest("GET", "/api/brief?lat=55.6761&lng=12.5683&name=Copenhagen", nil)
+	w := httptest.NewRecorder()
+
+	BriefHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp briefResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Date != "2025-11-03" || resp.Location != "Copenhagen" || resp.Timezone != "Europe/Copenhagen" {
+		t.Errorf("unexpected response fields: %+v", resp)
+	}
+	if !strings.HasPrefix(resp.Text, "Today is Monday, November 3 in Copenhagen. The sun rose at 7:20 this morning, two minutes later than yesterday, and sets at 4:28 this afternoon") {
+		t.Errorf("unexpected text %q", resp.Text)
+	}
+}
+
+func TestBriefHandler_Text(t *testing.T) {
+	for _, tt := range []struct {
+		url, accept string
+	}{
+		{"/api/brief?lat=55.6761&lng=12.5683&date=2025-11-03&locale=en-GB&format=text", ""},
+		{"/api/brief?lat=55.6761&lng=12.5683&date=2025-11-03&locale=en-GB", "text/plain"},
+	} {
+		req := httptest.NewRequest("GET", tt.url, nil)
+		req.Header.Set("Accept", tt.accept)
+		w := httptest.NewRecorder()
+		BriefHandler(w, req)
+
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+			t.Errorf("%s: expected plain text, got %s", tt.url, ct)
+		}
+		// Another date is told in the present tense, without a name
+		if body := w.Body.String(); !strings.HasPrefix(body, "Today is Monday 3 November. The sun rises at 7:20") {
+			t.Errorf("%s: unexpected text %q", tt.url, body)
+		}
+	}
+}
+
+func TestBriefHandler_InvalidParams(t *testing.T) {
+	for _, url := range []string{"/api/brief", "/api/brief?lat=100&lng=0", "/api/brief?lat=0&lng=0&format=xml", "/api/brief?lat=0&lng=0&locale=xx"} {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		BriefHandler(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", url, w.Code)
+		}
+	}
+}
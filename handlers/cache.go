@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"container/list"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"calsun/services"
+)
+
+// responseCache is a concurrency-safe LRU cache of serialized responses,
+// bounded by the total size of the cached bodies. Entries also expire at a
+// per-entry time, so responses for a date range are dropped when it changes.
+type responseCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	order    *list.List // Most recently used first
+	entries  map[string]*list.Element
+}
+
+// cachedResponse is a cached response body
+type cachedResponse struct {
+	key     string
+	body    []byte
+	expires time.Time
+}
+
+// newResponseCache creates a cache holding up to maxBytes of response
+// bodies. A cache with maxBytes of 0 stores nothing.
+func newResponseCache(maxBytes int64) *responseCache {
+	return &responseCache{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached response for key, if present and not expired
+func (c *responseCache) Get(key string, now time.Time) (*cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	resp := elem.Value.(*cachedResponse)
+	if !now.Before(resp.expires) {
+		c.removeLocked(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return resp, true
+}
+
+// Set stores a response under key until expires, evicting the least recently
+// used responses to stay within the size limit. Responses larger than the
+// whole cache are not stored.
+func (c *responseCache) Set(key string, body []byte, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if int64(len(body)) > c.maxBytes {
+		return
+	}
+	if elem, ok := c.entries[key]; ok {
+		c.removeLocked(elem)
+	}
+	for c.size+int64(len(body)) > c.maxBytes {
+		c.removeLocked(c.order.Back())
+	}
+
+	resp := &cachedResponse{key: key, body: body, expires: expires}
+	c.entries[key] = c.order.PushFront(resp)
+	c.size += int64(len(body))
+}
+
+// Len returns the number of cached responses
+func (c *responseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// Entries lists the cached responses by key, including expired ones not yet
+// removed
+func (c *responseCache) Entries() []services.CacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := make([]services.CacheEntry, 0, len(c.entries))
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		resp := elem.Value.(*cachedResponse)
+		entries = append(entries, services.CacheEntry{Key: resp.key, Size: len(resp.body), Expires: resp.expires})
+	}
+	slices.SortFunc(entries, func(a, b services.CacheEntry) int { return strings.Compare(a.Key, b.Key) })
+	return entries
+}
+
+// Delete removes the cached response for key, reporting whether there was
+// one
+func (c *responseCache) Delete(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if ok {
+		c.removeLocked(elem)
+	}
+	return ok
+}
+
+// Clear removes every cached response, returning how many there were
+func (c *responseCache) Clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := c.order.Len()
+	c.order.Init()
+	clear(c.entries)
+	c.size = 0
+	return n
+}
+
+// removeLocked removes a cached response. The caller must hold c.mu.
+func (c *responseCache) removeLocked(elem *list.Element) {
+	resp := c.order.Remove(elem).(*cachedResponse)
+	delete(c.entries, resp.key)
+	c.size -= int64(len(resp.body))
+}
+
+// nextDateChange returns when the calendar's date range next changes after
+// now: the earlier of midnight UTC (where the range starts) and local
+// midnight in tz (where the events' local dates roll over)
+func nextDateChange(now time.Time, tz *time.Location) time.Time {
+	utcMidnight := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	local := now.In(tz)
+	localMidnight := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, tz)
+	if localMidnight.Before(utcMidnight) {
+		return localMidnight
+	}
+	return utcMidnight
+}
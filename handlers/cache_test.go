@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestResponseCache_GetSet(t *testing.T) {
+	c := newResponseCache(1024)
+	now := time.Now()
+
+	if _, ok := c.Get("a", now); ok {
+		t.Error("expected miss on empty cache")
+	}
+
+	c.Set("a", []byte("body"), now.Add(time.Hour))
+	resp, ok := c.Get("a", now)
+	if !ok || string(resp.body) != "body" {
+		t.Errorf("expected hit with body, got %v (ok=%v)", resp, ok)
+	}
+	if _, ok := c.Get("a", now.Add(time.Hour)); ok {
+		t.Error("expected entry to have expired")
+	}
+	if c.Len() != 0 {
+		t.Error("expected expired entry to be removed")
+	}
+}
+
+func TestResponseCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newResponseCache(10)
+	now := time.Now()
+	expires := now.Add(time.Hour)
+
+	c.Set("a", []byte("1234"), expires)
+	c.Set("b", []byte("1234"), expires)
+	c.Get("a", now) // a is now more recently used than b
+	c.Set("c", []byte("1234"), expires)
+
+	if _, ok := c.Get("b", now); ok {
+		t.Error("expected least recently used entry to be evicted")
+	}
+	if _, ok := c.Get("a", now); !ok {
+		t.Error("expected a to remain")
+	}
+	if _, ok := c.Get("c", now); !ok {
+		t.Error("expected c to be stored")
+	}
+
+	// Larger than the whole cache
+	c.Set("d", []byte("12345678901"), expires)
+	if _, ok := c.Get("d", now); ok || c.Len() != 2 {
+		t.Error("expected oversized response not to be stored")
+	}
+}
+
+func TestResponseCache_EntriesDeleteClear(t *testing.T) {
+	c := newResponseCache(1024)
+	expires := time.Now().Add(time.Hour)
+	c.Set("b", []byte("12"), expires)
+	c.Set("a", []byte("1234"), expires)
+
+	entries := c.Entries()
+	if len(entries) != 2 || entries[0].Key != "a" || entries[0].Size != 4 || !entries[0].Expires.Equal(expires) {
+		t.Fatalf("expected entries a and b by key with sizes and expiry, got %+v", entries)
+	}
+
+	if !c.Delete("a") || c.Delete("a") {
+		t.Error("expected Delete to report whether the entry was present")
+	}
+	if n := c.Clear(); n != 1 || c.Len() != 0 {
+		t.Errorf("expected Clear to remove 1 entry, got %d (%d left)", n, c.Len())
+	}
+	// The size limit starts over
+	c.Set("c", make([]byte, 1024), expires)
+	if c.Len() != 1 {
+		t.Error("expected the whole size limit available after Clear")
+	}
+}
+
+func TestNextDateChange(t *testing.T) {
+	copenhagen, _ := time.LoadLocation("Europe/Copenhagen")
+	newYork, _ := time.LoadLocation("America/New_York")
+	now := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+
+	// Local midnight in Copenhagen (22:00 UTC) comes before midnight UTC
+	if got, want := nextDateChange(now, copenhagen), time.Date(2024, 6, 21, 22, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	// Midnight UTC comes before local midnight in New York
+	if got, want := nextDateChange(now, newYork), time.Date(2024, 6, 22, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestCanonicalQuery(t *testing.T) {
+	a, _ := url.ParseQuery("lng=12.568300&lat=55.6761&days=30&utm_source=x")
+	b, _ := url.ParseQuery("lat=55.67610&lng=12.5683")
+	if canonicalQuery(calendarParamDefs, a) != canonicalQuery(calendarParamDefs, b) {
+		t.Errorf("expected equivalent queries to match: %q vs %q", canonicalQuery(calendarParamDefs, a), canonicalQuery(calendarParamDefs, b))
+	}
+
+	// An explicit zero flight offset overrides the rule, unlike an absent one
+	c, _ := url.ParseQuery("lat=55.6761&lng=12.5683&flight_offset=0")
+	if canonicalQuery(calendarParamDefs, b) == canonicalQuery(calendarParamDefs, c) {
+		t.Error("expected an explicit parameter without default to change the key")
+	}
+
+	// Event lists match regardless of order and repeats
+	d, _ := url.ParseQuery("lat=55.6761&lng=12.5683&events=dusk,dawn")
+	e, _ := url.ParseQuery("lat=55.6761&lng=12.5683&events=dawn,dusk,dawn")
+	if canonicalQuery(calendarParamDefs, d) != canonicalQuery(calendarParamDefs, e) {
+		t.Errorf("expected equivalent event lists to match: %q vs %q", canonicalQuery(calendarParamDefs, d), canonicalQuery(calendarParamDefs, e))
+	}
+}
+
+func TestCalendarHandler_Cache(t *testing.T) {
+	original := calendarCache
+	calendarCache = newResponseCache(1 << 20)
+	t.Cleanup(func() { calendarCache = original })
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		CalendarHandler(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		return w
+	}
+
+	first := get("/calendar.ics?lat=55.6761&lng=12.5683&name=Copenhagen")
+	if first.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("expected first request to miss, got %q", first.Header().Get("X-Cache"))
+	}
+	second := get("/calendar.ics?name=Copenhagen&lng=12.56830&lat=55.6761&days=30")
+	if second.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("expected equivalent request to hit, got %q", second.Header().Get("X-Cache"))
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Error("expected cached response to match")
+	}
+	if third := get("/calendar.ics?lat=55.6761&lng=12.5683&name=Aarhus"); third.Header().Get("X-Cache") != "MISS" {
+		t.Error("expected different parameters to miss")
+	}
+
+	// Forecasts change hourly, so weather annotated calendars are not cached
+	useTestWeather(t, fakeWeather{cover: 50})
+	get("/calendar.ics?lat=55.6761&lng=12.5683&weather=clouds")
+	if w := get("/calendar.ics?lat=55.6761&lng=12.5683&weather=clouds"); w.Header().Get("X-Cache") != "MISS" {
+		t.Error("expected weather annotated calendar not to be cached")
+	}
+}
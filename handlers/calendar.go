@@ -1,12 +1,16 @@
 package handlers
 
 import (
-	"crypto/sha256"
 	"fmt"
+	"mime"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	ics "github.com/arran4/golang-ical"
 
@@ -15,220 +19,923 @@ import (
 
 const (
 	defaultDays = 30
-	maxDays     = 90
+	maxDays     = 90 // Unless configured otherwise
 	pastDays    = 14
 )
 
 // calendarParams holds the validated parameters for calendar generation
 type calendarParams struct {
-	lat            float64
-	lng            float64
-	name           string
-	days           int
-	includeSunrise bool
-	includeSunset  bool
+	lat           float64
+	lng           float64
+	name          string
+	days          int
+	events        services.EventSet // Enabled event types
+	weather       string            // Forecasts to annotate events with ("clouds", "aqi", or both)
+	preset        string            // Activity preset replacing sunrise/sunset events ("drone", "solunar", "nautical", "aviation", or "night")
+	flightRule    services.FlightRule
+	window        *services.ActivityWindow // Optional daily activity window
+	solarPanel    *services.SolarPanel     // Optional panel for production window events
+	facade        *services.Facade         // Optional window for direct sun events
+	photoperiod   []float64                // Day lengths in hours to mark crossings of
+	dayAlerts     []services.DaylightAlert // Day length crossings to alert on in one direction
+	countdown     []string                 // Seasons to count down to
+	apsis         []string                 // Lunar apsides to mark the days of
+	planets       []string                 // Planets to add rise and set events for
+	satellites    []string                 // Satellites to add visible pass events for
+	aurora        int                      // Kp index to add aurora events at, 0 for none
+	eclipses      []string                 // Kinds of eclipses to add events for
+	summary       []string                 // Periods to add daylight summaries for
+	details       []string                 // Optional sunrise and sunset description lines
+	altDates      []string                 // Alternate calendars to add event dates in
+	altitude      *float64                 // Observer height in metres above the horizon, nil if not given
+	skyline       string                   // "terrain" for sunrise and sunset over the terrain skyline, else empty
+	grouping      string                   // Days sun events are grouped by (services.GroupCivil or GroupSolar)
+	compat        string                   // Calendar app whose quirks to adjust iCal output for, empty for none
+	locale        services.Locale          // Formats of dates and numbers in events
+	homeTimezone  *time.Location           // Subscriber's timezone to also show event times in, nil for none
+	uidVersion    int                      // Event UID scheme (services.UIDVersion1 or UIDVersion2)
+	uidOptions    string                   // Canonical event options hashed into version 2 UIDs
+	prefix        string                   // Sanitized text before event titles, empty for none
+	suffix        string                   // Sanitized text after event titles, empty for none
+	azimuthFormat string                   // "degrees", "compass", or "both"
+	precision     int                      // Decimals of coordinates shown in events and UIDs
+	filename      string                   // Download file name, sanitized (empty for the endpoint's default)
+	date          time.Time                // Local midnight of the only date to generate, zero for the days ahead
+	skip          []dateSpan               // Local dates to leave out events on
+	now           time.Time                // Time output is generated for
+	fixedNow      bool                     // now came from the now parameter rather than the clock
+	page          *pageRequest             // Page of the events to serve as JSON, nil for all of them
 }
 
 // parseCalendarParams extracts and validates query parameters from the request.
 // Returns the parsed params and an error message if validation fails.
 func parseCalendarParams(r *http.Request) (*calendarParams, string) {
-	q := r.URL.Query()
+	return parseCalendarQuery(r.URL.Query())
+}
 
-	// Parse and validate latitude
-	latStr := q.Get("lat")
-	lngStr := q.Get("lng")
-	if latStr == "" || lngStr == "" {
-		return nil, "lat and lng parameters are required"
+// parseCalendarQuery validates calendar parameters given as URL values (from
+// a query string or another source, such as a JSON body)
+func parseCalendarQuery(q url.Values) (*calendarParams, string) {
+	q, errMsg := resolveLocation(q)
+	if errMsg != "" {
+		return nil, errMsg
 	}
 
-	lat, err := strconv.ParseFloat(latStr, 64)
-	if err != nil || lat < -90 || lat > 90 {
-		return nil, "invalid lat parameter"
+	// Parse and validate coordinates
+	if q.Get(latParam.Name) == "" || q.Get(lngParam.Name) == "" {
+		return nil, "lat and lng (or one of place, geohash, pluscode, utm, or mgrs) parameters are required"
 	}
 
-	lng, err := strconv.ParseFloat(lngStr, 64)
-	if err != nil || lng < -180 || lng > 180 {
-		return nil, "invalid lng parameter"
+	lat, errMsg := latParam.parseFloat(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	lng, errMsg := lngParam.parseFloat(q)
+	if errMsg != "" {
+		return nil, errMsg
 	}
 
 	// Parse days parameter with default
-	days := defaultDays
-	if daysStr := q.Get("days"); daysStr != "" {
-		days, err = strconv.Atoi(daysStr)
-		if err != nil || days < 1 || days > maxDays {
-			return nil, fmt.Sprintf("days must be between 1 and %d", maxDays)
+	days, errMsg := daysParam.parseInt(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	events, errMsg := parseEvents(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	precision := cfg.CoordPrecision
+	if q.Get(precisionParam.Name) != "" {
+		if precision, errMsg = precisionParam.parseInt(q); errMsg != "" {
+			return nil, errMsg
 		}
 	}
 
-	// Parse exclude parameter
-	includeSunrise, includeSunset := true, true
-	switch exclude := q.Get("exclude"); exclude {
-	case "sunrise":
-		includeSunrise = false
-	case "sunset":
-		includeSunset = false
-	case "":
-		// No exclusion
-	default:
-		return nil, "exclude must be 'sunrise' or 'sunset'"
+	azimuthFormat, errMsg := azFormatParam.parseEnum(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	weather, errMsg := weatherParam.parseEnum(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	preset, errMsg := presetParam.parseEnum(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	ruleID, errMsg := flightRuleParam.parseEnum(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	flightRule := services.FlightRules[ruleID]
+	flightOffset, errMsg := flightOffsetParam.parseInt(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	if q.Get(flightOffsetParam.Name) != "" {
+		flightRule.Minutes = flightOffset
+	}
+
+	window, errMsg := parseActivityWindow(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	solarPanel, errMsg := parseSolarPanel(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	facade, errMsg := parseFacade(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	photoperiod, errMsg := parsePhotoperiod(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	dayAlerts, errMsg := parseDaylightAlerts(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	countdown, errMsg := countdownParam.parseList(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	apsis, errMsg := apsisParam.parseList(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	planets, errMsg := planetsParam.parseList(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	satellites, errMsg := satellitesParam.parseList(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	auroraValue, errMsg := auroraParam.parseEnum(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	eclipses, errMsg := eclipsesParam.parseList(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	summary, errMsg := summaryParam.parseList(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	details, errMsg := detailsParam.parseList(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	altDates, errMsg := altDatesParam.parseList(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	var altitude *float64
+	if q.Get(altitudeParam.Name) != "" {
+		v, errMsg := altitudeParam.parseFloat(q)
+		if errMsg != "" {
+			return nil, errMsg
+		}
+		altitude = &v
+	}
+
+	skyline, errMsg := skylineParam.parseEnum(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	grouping, errMsg := groupParam.parseEnum(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	localeTag, errMsg := localeParam.parseEnum(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	locale, _ := services.LookupLocale(localeTag)
+
+	homeTimezone, errMsg := parseHomeTimezone(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	compat, errMsg := compatParam.parseEnum(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	uidVersion, errMsg := uidVersionParam.parseInt(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	var options string
+	if uidVersion == services.UIDVersion2 {
+		options = uidOptions(q)
+	}
+
+	prefix, errMsg := parseAffix(q, prefixParam)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	suffix, errMsg := parseAffix(q, suffixParam)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	now, fixedNow, errMsg := requestNow(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	date, errMsg := parseDate(q, lat, lng)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	skip, errMsg := parseSkipDates(q, lat, lng)
+	if errMsg != "" {
+		return nil, errMsg
 	}
 
 	return &calendarParams{
-		lat:            lat,
-		lng:            lng,
-		name:           q.Get("name"),
-		days:           days,
-		includeSunrise: includeSunrise,
-		includeSunset:  includeSunset,
+		lat:           lat,
+		lng:           lng,
+		name:          q.Get(nameParam.Name),
+		days:          days,
+		events:        events,
+		weather:       weather,
+		preset:        preset,
+		flightRule:    flightRule,
+		window:        window,
+		solarPanel:    solarPanel,
+		facade:        facade,
+		photoperiod:   photoperiod,
+		dayAlerts:     dayAlerts,
+		countdown:     countdown,
+		apsis:         apsis,
+		planets:       planets,
+		satellites:    satellites,
+		aurora:        auroraThreshold(auroraValue, lat, lng),
+		eclipses:      eclipses,
+		summary:       summary,
+		details:       details,
+		altDates:      altDates,
+		altitude:      altitude,
+		skyline:       skyline,
+		grouping:      grouping,
+		compat:        compat,
+		locale:        locale,
+		homeTimezone:  homeTimezone,
+		uidVersion:    uidVersion,
+		uidOptions:    options,
+		prefix:        prefix,
+		suffix:        suffix,
+		azimuthFormat: azimuthFormat,
+		precision:     precision,
+		filename:      sanitizeFilename(q.Get(filenameParam.Name)),
+		date:          date,
+		skip:          skip,
+		now:           now,
+		fixedNow:      fixedNow,
 	}, ""
 }
 
+// parseEvents parses the enabled event types: those listed in events
+// (sunrise and sunset by default), less the type in exclude
+func parseEvents(q url.Values) (services.EventSet, string) {
+	types, errMsg := eventsParam.parseList(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	exclude, errMsg := excludeParam.parseEnum(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	events := services.DefaultEvents()
+	if types != nil {
+		events = services.NewEventSet(types...)
+	}
+	delete(events, exclude)
+	if len(events) == 0 {
+		return nil, "no event types left after exclude; choose at least one in events"
+	}
+	return events, ""
+}
+
+// parseDate parses the optional single date, as local midnight at the
+// location. Returns the zero time if no date is requested.
+func parseDate(q url.Values, lat, lng float64) (time.Time, string) {
+	str := q.Get(dateParam.Name)
+	if str == "" {
+		return time.Time{}, ""
+	}
+	if q.Get(daysParam.Name) != "" {
+		return time.Time{}, "date cannot be combined with days"
+	}
+
+	date, err := time.ParseInLocation(time.DateOnly, str, services.GetTimezone(lat, lng))
+	if err != nil {
+		return time.Time{}, "date must be a date like 2025-06-21"
+	}
+	return date, ""
+}
+
+// parseHomeTimezone parses the optional IANA timezone to also show event
+// times in. Returns nil if none is requested.
+func parseHomeTimezone(q url.Values) (*time.Location, string) {
+	name := q.Get(homeTimezoneParam.Name)
+	if name == "" {
+		return nil, ""
+	}
+	// "Local" would be the server's own zone
+	loc, err := services.LoadZone(name)
+	if err != nil || name == "Local" {
+		return nil, "home_tz must be an IANA timezone like America/New_York"
+	}
+	return loc, ""
+}
+
+// dateSpan is a range of local dates, as midnights, including both ends
+type dateSpan struct {
+	first, last time.Time
+}
+
+// parseSkipDates parses the optional dates and date ranges to leave out
+// events on, as local midnights at the location
+func parseSkipDates(q url.Values, lat, lng float64) ([]dateSpan, string) {
+	tz := services.GetTimezone(lat, lng)
+	parse := func(str string) (time.Time, bool) {
+		date, err := time.ParseInLocation(time.DateOnly, strings.TrimSpace(str), tz)
+		return date, err == nil
+	}
+
+	var spans []dateSpan
+	if str := q.Get(skipParam.Name); str != "" {
+		for _, s := range strings.Split(str, ",") {
+			date, ok := parse(s)
+			if !ok {
+				return nil, "skip must be comma-separated dates like 2025-07-04"
+			}
+			spans = append(spans, dateSpan{date, date})
+		}
+	}
+	if str := q.Get(skipRangeParam.Name); str != "" {
+		for _, s := range strings.Split(str, ",") {
+			from, to, found := strings.Cut(s, "/")
+			first, firstOK := parse(from)
+			last, lastOK := parse(to)
+			if !found || !firstOK || !lastOK {
+				return nil, "skip_range must be comma-separated date ranges like 2025-12-20/2026-01-02"
+			}
+			if last.Before(first) {
+				return nil, fmt.Sprintf("skip_range %s ends before it starts", strings.TrimSpace(s))
+			}
+			spans = append(spans, dateSpan{first, last})
+		}
+	}
+	return spans, ""
+}
+
+// withoutSkipped removes the events starting on a skipped local date
+func (p *calendarParams) withoutSkipped(events []services.CalendarEvent, tz *time.Location) []services.CalendarEvent {
+	if len(p.skip) == 0 {
+		return events
+	}
+	return slices.DeleteFunc(events, func(event services.CalendarEvent) bool {
+		local := event.Start.In(tz)
+		date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, tz)
+		return slices.ContainsFunc(p.skip, func(s dateSpan) bool {
+			return !date.Before(s.first) && !date.After(s.last)
+		})
+	})
+}
+
+// withICSCompat widens the days ahead to the compat profile's minimum, within
+// the configured maximum. Only iCal output is adjusted for calendar apps.
+func (p *calendarParams) withICSCompat() {
+	if compat, ok := icsCompats[p.compat]; ok && p.days < compat.minDays {
+		p.days = min(compat.minDays, cfg.MaxDays)
+	}
+}
+
+// eventRange returns the first day and the number of days to generate events
+// for: the past 14 days and the days ahead, or the requested date with its
+// neighbours, as events on a local date may fall on adjacent UTC dates
+func (p *calendarParams) eventRange() (time.Time, int) {
+	if !p.date.IsZero() {
+		return time.Date(p.date.Year(), p.date.Month(), p.date.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1), 3
+	}
+	return p.now.Truncate(24*time.Hour).AddDate(0, 0, -pastDays), p.days + pastDays
+}
+
+// onDate returns the events starting on the requested local date, or all
+// events if no date is requested. With solar grouping, the date runs from
+// the previous evening's sunset to its own.
+func (p *calendarParams) onDate(events []services.CalendarEvent) []services.CalendarEvent {
+	if p.date.IsZero() {
+		return events
+	}
+	start, end := p.date, p.date.AddDate(0, 0, 1)
+	if p.grouping == services.GroupSolar {
+		altitude := 0.0
+		if p.altitude != nil {
+			altitude = *p.altitude
+		}
+		startDate, days := p.eventRange()
+		sunTimes := services.GetSunTimesRangeAt(p.lat, p.lng, altitude, startDate, days)
+		if s, e, ok := services.NewSolarDays(sunTimes, p.date.Location()).Span(p.date); ok {
+			start, end = s, e
+		}
+	}
+	return slices.DeleteFunc(events, func(event services.CalendarEvent) bool {
+		return event.Start.Before(start) || !event.Start.Before(end)
+	})
+}
+
+// parseActivityWindow parses the optional activity window parameters.
+// Returns nil if no window is requested.
+func parseActivityWindow(q url.Values) (*services.ActivityWindow, string) {
+	startStr, endStr := q.Get(windowStartParam.Name), q.Get(windowEndParam.Name)
+	if startStr == "" && endStr == "" {
+		return nil, ""
+	}
+	if startStr == "" || endStr == "" {
+		return nil, "window_start and window_end must be given together"
+	}
+
+	start, err := services.ParseSunOffset(startStr)
+	if err != nil {
+		return nil, "invalid window_start parameter: " + err.Error()
+	}
+	end, err := services.ParseSunOffset(endStr)
+	if err != nil {
+		return nil, "invalid window_end parameter: " + err.Error()
+	}
+
+	name := q.Get(windowNameParam.Name)
+	if name == "" {
+		name = windowNameParam.Default.(string)
+	}
+	return &services.ActivityWindow{Name: name, Start: start, End: end}, ""
+}
+
+// parseSolarPanel parses the optional solar panel parameters. Returns nil if
+// no panel direction is given.
+func parseSolarPanel(q url.Values) (*services.SolarPanel, string) {
+	if q.Get(solarAzimuthParam.Name) == "" {
+		return nil, ""
+	}
+
+	azimuth, errMsg := solarAzimuthParam.parseFloat(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	spread, errMsg := solarSpreadParam.parseFloat(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	elevation, errMsg := solarElevationParam.parseFloat(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	return &services.SolarPanel{Azimuth: azimuth, Spread: spread, MinElevation: elevation}, ""
+}
+
+// parseFacade parses the optional window parameters. Returns nil if no
+// window direction is given.
+func parseFacade(q url.Values) (*services.Facade, string) {
+	if q.Get(facadeAzimuthParam.Name) == "" {
+		return nil, ""
+	}
+
+	azimuth, errMsg := facadeAzimuthParam.parseFloat(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	fov, errMsg := facadeFOVParam.parseFloat(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	return &services.Facade{Azimuth: azimuth, FieldOfView: fov}, ""
+}
+
+// maxPhotoperiodThresholds limits the number of photoperiod thresholds
+const maxPhotoperiodThresholds = 10
+
+// parsePhotoperiod parses the optional comma-separated day length thresholds
+func parsePhotoperiod(q url.Values) ([]float64, string) {
+	str := q.Get(photoperiodParam.Name)
+	if str == "" {
+		return nil, ""
+	}
+
+	parts := strings.Split(str, ",")
+	if len(parts) > maxPhotoperiodThresholds {
+		return nil, fmt.Sprintf("photoperiod accepts at most %d thresholds", maxPhotoperiodThresholds)
+	}
+	thresholds := make([]float64, len(parts))
+	for i, part := range parts {
+		hours, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || hours <= 0 || hours >= 24 {
+			return nil, "photoperiod thresholds must be hours between 0 and 24"
+		}
+		thresholds[i] = hours
+	}
+	return thresholds, ""
+}
+
+// parseDaylightAlerts parses the optional comma-separated day length alerts,
+// each a direction and a day length in hours or as a duration (below:9,
+// above:14h30m)
+func parseDaylightAlerts(q url.Values) ([]services.DaylightAlert, string) {
+	str := q.Get(dayLengthAlertsParam.Name)
+	if str == "" {
+		return nil, ""
+	}
+
+	parts := strings.Split(str, ",")
+	if len(parts) > maxPhotoperiodThresholds {
+		return nil, fmt.Sprintf("daylength accepts at most %d alerts", maxPhotoperiodThresholds)
+	}
+	alerts := make([]services.DaylightAlert, len(parts))
+	for i, part := range parts {
+		direction, value, _ := strings.Cut(strings.TrimSpace(part), ":")
+		if direction != "below" && direction != "above" {
+			return nil, fmt.Sprintf("alert %q must start with below: or above:", part)
+		}
+		hours, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			d, durationErr := time.ParseDuration(value)
+			hours, err = d.Hours(), durationErr
+		}
+		if err != nil || hours <= 0 || hours >= 24 {
+			return nil, "daylength alerts must be between 0 and 24 hours (e.g., below:9 or above:14h30m)"
+		}
+		alerts[i] = services.DaylightAlert{Hours: hours, Rising: direction == "above"}
+	}
+	return alerts, ""
+}
+
 // CalendarHandler generates an iCal calendar with sunrise/sunset events
 func CalendarHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		serveCalendarConfig(w, r)
+		return
+	}
 	params, errMsg := parseCalendarParams(r)
 	if errMsg != "" {
 		http.Error(w, errMsg, http.StatusBadRequest)
 		return
 	}
+	serveCalendar(w, r, params, calendarFormats[0])
+}
 
-	// Generate calendar
-	calName := calendarName(params.name, params.includeSunrise, params.includeSunset)
-	cal := ics.NewCalendar()
-	cal.SetMethod(ics.MethodPublish)
-	cal.SetProductId("-//CalSun//Sunrise Sunset Calendar//EN")
-	cal.SetName(calName)
-	cal.SetXWRCalName(calName)
+// serveCalendar generates the calendar for params and writes it in format
+func serveCalendar(w http.ResponseWriter, r *http.Request, params *calendarParams, format calendarFormat) {
+	// Serve identical requests from the cache until the date range changes.
+	// Forecasts change hourly and satellite orbits several times a day, so
+	// weather annotated calendars, aurora forecasts, and satellite passes are
+	// not cached, and neither are calendars for a fixed time.
+	now := params.now
+	tz := services.GetTimezone(params.lat, params.lng)
+	cacheable := params.weather == "" && params.aurora == 0 && len(params.satellites) == 0 && !params.fixedNow
+	key := format.name + ":" + canonicalQuery(calendarParamDefs, r.URL.Query())
+	if params.page != nil {
+		key += ":" + canonicalQuery(pageParamDefs, r.URL.Query())
+	}
+	if cacheable {
+		if resp, ok := calendarCache.Get(key, now); ok {
+			writeCalendarResponse(w, resp.body, format, params.filename, "HIT")
+			return
+		}
+	}
+
+	if format.name == "ics" {
+		params.withICSCompat()
+	}
+	calName, events := buildCalendarEvents(r, params, tz)
+	serialize := format.serialize
+	if params.page != nil {
+		var nextCursor string
+		events, nextCursor = paginateEvents(events, *params.page)
+		serialize = func(name string, tz *time.Location, events []services.CalendarEvent, _ time.Time) ([]byte, error) {
+			return serializeJSONPage(name, tz, events, nextCursor)
+		}
+	}
+	if compat, ok := icsCompats[params.compat]; ok && format.name == "ics" {
+		serialize = compat.wrap(serialize)
+	}
+	body, err := serialize(calName, tz, events, now)
+	if err != nil {
+		http.Error(w, "failed to serialize calendar", http.StatusInternalServerError)
+		return
+	}
+	if cacheable {
+		calendarCache.Set(key, body, nextDateChange(now, tz))
+	}
+	writeCalendarResponse(w, body, format, params.filename, "MISS")
+}
 
-	// Get sun times for the date range (including past 14 days)
-	startDate := time.Now().Truncate(24 * time.Hour).AddDate(0, 0, -pastDays)
-	sunTimes := services.GetSunTimesRange(params.lat, params.lng, startDate, params.days+pastDays)
+// buildCalendarEvents returns the calendar's name and its events for the
+// date range (including the past 14 days) or the requested date
+func buildCalendarEvents(r *http.Request, params *calendarParams, tz *time.Location) (string, []services.CalendarEvent) {
+	startDate, days := params.eventRange()
+	return buildCalendarEventsRange(r, params, tz, startDate, days)
+}
 
-	// Location name for descriptions (use coordinates if no name provided)
-	locationStr := params.name
-	if locationStr == "" {
-		locationStr = fmt.Sprintf("%.4f, %.4f", params.lat, params.lng)
+// buildCalendarEventsRange returns the calendar's name and its events for
+// days days from the UTC date startDate
+func buildCalendarEventsRange(r *http.Request, params *calendarParams, tz *time.Location, startDate time.Time, days int) (string, []services.CalendarEvent) {
+	opts := services.CalendarOptions{
+		Lat:           params.lat,
+		Lng:           params.lng,
+		Location:      locationName(params),
+		Timezone:      tz,
+		Events:        params.events,
+		AzimuthFormat: params.azimuthFormat,
+		Precision:     params.precision,
+		Details:       params.details,
+		Grouping:      params.grouping,
+		Locale:        params.locale,
+		HomeTimezone:  params.homeTimezone,
 	}
 
-	// Auto-detect timezone from coordinates
-	tz := services.GetTimezone(params.lat, params.lng)
+	calName := calendarName(params.name, params.events)
+	var events []services.CalendarEvent
+	switch params.preset {
+	case "drone":
+		calName = presetCalendarName("Drone Flight Windows", params.name)
+		events = services.BuildDroneEvents(opts, params.flightRule, startDate, days)
+	case "solunar":
+		calName = presetCalendarName("Solunar Periods", params.name)
+		events = services.BuildSolunarEvents(opts, startDate, days)
+	case "nautical":
+		calName = presetCalendarName("Nautical Times", params.name)
+		events = services.BuildNauticalEvents(opts, startDate, days)
+	case "aviation":
+		calName = presetCalendarName("Aviation Times", params.name)
+		events = services.BuildAviationEvents(opts, startDate, days)
+	case "night":
+		calName = presetCalendarName("Nights", params.name)
+		events = services.BuildNightEvents(opts, startDate, days)
+	default:
+		sunTimes := services.GetSunTimesRangeAt(params.lat, params.lng, observerAltitude(r.Context(), params), startDate, days)
+		applyTerrainHorizon(r.Context(), params, sunTimes)
+		events = services.BuildSunEvents(sunTimes, opts)
+	}
+	if params.window != nil {
+		events = append(events, services.BuildWindowEvents(opts, *params.window, startDate, days)...)
+	}
+	if len(params.photoperiod) > 0 {
+		events = append(events, services.BuildPhotoperiodEvents(opts, params.photoperiod, startDate, days)...)
+	}
+	if len(params.dayAlerts) > 0 {
+		events = append(events, services.BuildDaylightAlertEvents(opts, params.dayAlerts, startDate, days)...)
+	}
+	if len(params.countdown) > 0 {
+		events = append(events, services.BuildSeasonCountdownEvents(opts, params.countdown, startDate, days)...)
+	}
+	if len(params.apsis) > 0 {
+		events = append(events, services.BuildMoonApsisEvents(opts, params.apsis, startDate, days)...)
+	}
+	if len(params.planets) > 0 {
+		events = append(events, services.BuildPlanetEvents(opts, params.planets, startDate, days)...)
+	}
+	if len(params.satellites) > 0 {
+		events = append(events, buildSatelliteEvents(r.Context(), params, opts, startDate, days)...)
+	}
+	if params.aurora > 0 {
+		events = append(events, buildAuroraEvents(r.Context(), params, opts, startDate, days)...)
+	}
+	if slices.Contains(params.eclipses, "solar") {
+		events = append(events, services.BuildSolarEclipseEvents(opts, startDate, days)...)
+	}
+	if slices.Contains(params.summary, services.SummaryWeekly) {
+		events = append(events, services.BuildWeeklySummaryEvents(opts, startDate, days)...)
+	}
+	if slices.Contains(params.summary, services.SummaryMonthly) {
+		events = append(events, services.BuildMonthlySummaryEvents(opts, startDate, days)...)
+	}
+	if params.solarPanel != nil {
+		events = append(events, services.BuildSolarEvents(opts, *params.solarPanel, startDate, days)...)
+	}
+	if params.facade != nil {
+		events = append(events, services.BuildFacadeEvents(opts, *params.facade, startDate, days)...)
+	}
+	events = params.withoutSkipped(params.onDate(events), tz)
+	if params.weather != "" {
+		annotateWeather(r.Context(), events, params)
+	}
+	if len(params.altDates) > 0 {
+		services.AnnotateAltDates(events, tz, params.altDates)
+	}
+	for i := range events {
+		events[i].UID = services.VersionedUID(events[i].UID, params.uidVersion, params.uidOptions)
+		events[i].Summary = affixSummary(events[i].Summary, params.prefix, params.suffix)
+	}
+	return calName, events
+}
 
-	// Add events
-	var prevDay *services.DaySunTimes
-	for i := range sunTimes {
-		day := &sunTimes[i]
-		if params.includeSunrise && day.Sunrise != nil {
-			cal.AddVEvent(createSunEvent(day.Sunrise, day, prevDay, params.lat, params.lng, locationStr, tz))
+// uidOptions returns the canonical form of the event options in a validated
+// calendar query, for version 2 UIDs
+func uidOptions(q url.Values) string {
+	defs := slices.DeleteFunc(slices.Clone(calendarParamDefs), func(p paramDef) bool {
+		return slices.ContainsFunc(uidIgnoredParams, func(ignored paramDef) bool { return ignored.Name == p.Name })
+	})
+	return canonicalQuery(defs, q)
+}
+
+// writeCalendarResponse writes a serialized calendar, reporting in the
+// X-Cache header whether it came from the response cache
+func writeCalendarResponse(w http.ResponseWriter, body []byte, format calendarFormat, filename, cacheStatus string) {
+	w.Header().Set("Content-Type", format.contentType)
+	w.Header().Set("Content-Disposition", attachment(withExtension(filename, format.extension), "calsun."+format.extension))
+	w.Header().Set("X-Cache", cacheStatus)
+	// Sent with HEAD responses too, which have no body to count
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Write(body)
+}
+
+// maxFilenameLength limits download file names, in characters
+const maxFilenameLength = 100
+
+// sanitizeFilename reduces a requested download file name to a safe base
+// name with an .ics extension: path components, control characters, and
+// characters with special meaning in headers or file systems are removed.
+// Returns "" if nothing usable remains.
+func sanitizeFilename(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`"*:<>?|;%`, r) {
+			return -1
 		}
-		if params.includeSunset && day.Sunset != nil {
-			cal.AddVEvent(createSunEvent(day.Sunset, day, prevDay, params.lat, params.lng, locationStr, tz))
+		return r
+	}, name)
+	name = strings.TrimRight(name, ". ")
+	if ext := len(name) - len(".ics"); ext >= 0 && strings.EqualFold(name[ext:], ".ics") {
+		name = name[:ext]
+	}
+	name = strings.Trim(name, ". ")
+	if name == "" {
+		return ""
+	}
+	if runes := []rune(name); len(runes) > maxFilenameLength {
+		name = strings.TrimRight(string(runes[:maxFilenameLength]), ". ")
+	}
+	return name + ".ics"
+}
+
+// maxAffixLength limits title prefixes and suffixes, in characters
+const maxAffixLength = 24
+
+// parseAffix parses a title prefix or suffix from the query: control
+// characters are removed and runs of whitespace collapsed to one space.
+// Returns an error message if it is longer than maxAffixLength.
+func parseAffix(q url.Values, p paramDef) (string, string) {
+	affix := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
 		}
-		prevDay = day
+		return r
+	}, q.Get(p.Name))
+	affix = strings.Join(strings.Fields(affix), " ")
+	if utf8.RuneCountInString(affix) > maxAffixLength {
+		return "", fmt.Sprintf("%s must be at most %d characters", p.Name, maxAffixLength)
 	}
+	return affix, ""
+}
 
-	// Set response headers and write calendar
-	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
-	w.Header().Set("Content-Disposition", "attachment; filename=calsun.ics")
-	w.Write([]byte(cal.Serialize()))
+// affixSummary adds a prefix and suffix to an event title, separated by
+// spaces (e.g., "[CPH] Sunrise 06:42")
+func affixSummary(summary, prefix, suffix string) string {
+	if prefix != "" {
+		summary = prefix + " " + summary
+	}
+	if suffix != "" {
+		summary += " " + suffix
+	}
+	return summary
+}
+
+// attachment returns a Content-Disposition header value for downloading a
+// file named filename, or fallback if filename is empty. Non-ASCII names are
+// encoded per RFC 2231.
+func attachment(filename, fallback string) string {
+	if filename == "" {
+		filename = fallback
+	}
+	return mime.FormatMediaType("attachment", map[string]string{"filename": filename})
 }
 
-func calendarName(name string, includeSunrise, includeSunset bool) string {
+// locationName returns the display name for the location, falling back to
+// the coordinates if no name was provided
+func locationName(params *calendarParams) string {
+	if params.name != "" {
+		return params.name
+	}
+	return services.FormatCoordinates(params.lat, params.lng, params.precision)
+}
+
+// eventTypeLabels names event types in calendar names
+var eventTypeLabels = map[string]string{
+	services.EventDawn:       "Dawn",
+	services.EventSunrise:    "Sunrise",
+	services.EventGoldenHour: "Golden hour",
+	services.EventNoon:       "Solar noon",
+	services.EventSunset:     "Sunset",
+	services.EventDusk:       "Dusk",
+}
+
+// calendarName returns the name of a sun times calendar, noting the event
+// type if only one is enabled (e.g., "Sun Times - Home (Sunrise only)")
+func calendarName(name string, events services.EventSet) string {
 	base := "Sun Times"
 	if name != "" {
 		base = fmt.Sprintf("Sun Times - %s", name)
 	}
 
-	if !includeSunrise {
-		return base + " (Sunset only)"
-	}
-	if !includeSunset {
-		return base + " (Sunrise only)"
+	if types := events.Types(); len(types) == 1 {
+		return fmt.Sprintf("%s (%s only)", base, eventTypeLabels[types[0]])
 	}
 	return base
 }
 
-func createSunEvent(event *services.SunEvent, day *services.DaySunTimes, prevDay *services.DaySunTimes, lat, lng float64, location string, tz *time.Location) *ics.VEvent {
-	uid := generateUID(event.Time, lat, lng, event.Type)
-	e := ics.NewEvent(uid)
+// presetCalendarName returns the calendar name for a preset, with the
+// location name if provided
+func presetCalendarName(base, name string) string {
+	if name != "" {
+		return fmt.Sprintf("%s - %s", base, name)
+	}
+	return base
+}
 
-	// Set times (1 minute duration)
-	e.SetStartAt(event.Time)
-	e.SetEndAt(event.Time.Add(time.Minute))
+// calendarProductID identifies CalSun as the producer of calendars
+const calendarProductID = "-//CalSun//Sunrise Sunset Calendar//EN"
 
-	// Set title with local time (e.g., "Sunrise 06:42")
-	localTime := event.Time.In(tz)
-	eventTitle := strings.ToUpper(event.Type[:1]) + event.Type[1:]
-	e.SetSummary(fmt.Sprintf("%s %s", eventTitle, localTime.Format("15:04")))
+// icsNewLine ends serialized iCal lines with CRLF as RFC 5545 requires;
+// golang-ical defaults to LF
+const icsNewLine = ics.WithNewLine("\r\n")
 
-	// Build enhanced description
-	description := buildDescription(event, day, prevDay, lat, lng, location, tz)
-	e.SetDescription(description)
-	e.SetLocation(location)
+// altDescProperty holds the HTML version of an event's description, shown
+// by Outlook and Apple Calendar instead of the plain text
+const altDescProperty = ics.ComponentProperty("X-ALT-DESC")
 
-	return e
+// newCalendar creates an empty published calendar with the given name
+func newCalendar(name string) *ics.Calendar {
+	cal := ics.NewCalendar()
+	cal.SetMethod(ics.MethodPublish)
+	cal.SetProductId(calendarProductID)
+	cal.SetName(name)
+	cal.SetXWRCalName(name)
+	return cal
 }
 
-func buildDescription(event *services.SunEvent, day *services.DaySunTimes, prevDay *services.DaySunTimes, lat, lng float64, location string, tz *time.Location) string {
-	var lines []string
-
-	// Basic info (show local time)
-	localTime := event.Time.In(tz)
-	lines = append(lines, fmt.Sprintf("Time: %s", localTime.Format("15:04:05")))
-	lines = append(lines, fmt.Sprintf("Location: %s", location))
-	lines = append(lines, fmt.Sprintf("Coordinates: %.4f, %.4f", lat, lng))
-	lines = append(lines, fmt.Sprintf("Azimuth: %.1f°", event.Azimuth))
-	lines = append(lines, "") // blank line
-
-	// Day length (only if both sunrise and sunset exist)
-	if day.Sunrise != nil && day.Sunset != nil {
-		dayLength := day.Sunset.Time.Sub(day.Sunrise.Time)
-		hours := int(dayLength.Hours())
-		minutes := int(dayLength.Minutes()) % 60
-		lines = append(lines, fmt.Sprintf("Day length: %dh %dm", hours, minutes))
-	}
-
-	// Delta from yesterday
-	if prevDay != nil {
-		var prevEvent *services.SunEvent
-		if event.Type == "sunrise" {
-			prevEvent = prevDay.Sunrise
-		} else {
-			prevEvent = prevDay.Sunset
-		}
-
-		if prevEvent != nil {
-			// Compare times by extracting just hour/minute/second in local timezone
-			prevLocalTime := prevEvent.Time.In(tz)
-			todaySeconds := localTime.Hour()*3600 + localTime.Minute()*60 + localTime.Second()
-			yesterdaySeconds := prevLocalTime.Hour()*3600 + prevLocalTime.Minute()*60 + prevLocalTime.Second()
-			deltaSeconds := todaySeconds - yesterdaySeconds
-			deltaMinutes := deltaSeconds / 60
-
-			if deltaMinutes != 0 {
-				direction := "later"
-				if deltaMinutes < 0 {
-					direction = "earlier"
-					deltaMinutes = -deltaMinutes
-				}
-				lines = append(lines, fmt.Sprintf("Yesterday: %dm %s", deltaMinutes, direction))
-			} else {
-				lines = append(lines, "Yesterday: same time")
-			}
-		}
-	}
-
-	// Days until next solstice
-	days, solsticeType := services.DaysUntilNextSolstice(event.Time)
-	if days == 0 {
-		lines = append(lines, fmt.Sprintf("Today is the %s solstice!", solsticeType))
+// toVEvent converts a generated event to an iCal VEVENT, stamped with the
+// time the calendar is generated for
+func toVEvent(event services.CalendarEvent, stamp time.Time) *ics.VEvent {
+	e := ics.NewEvent(event.UID)
+	e.SetDtStampTime(stamp)
+	if event.AllDay {
+		e.SetAllDayStartAt(event.Start)
+		e.SetAllDayEndAt(event.End)
 	} else {
-		lines = append(lines, fmt.Sprintf("Next solstice: %d days (%s)", days, solsticeType))
+		e.SetStartAt(event.Start)
+		e.SetEndAt(event.End)
 	}
-
-	return strings.Join(lines, "\n")
-}
-
-func generateUID(t time.Time, lat, lng float64, eventType string) string {
-	data := fmt.Sprintf("%s-%.4f-%.4f-%s", t.Format("2006-01-02"), lat, lng, eventType)
-	hash := sha256.Sum256([]byte(data))
-	return fmt.Sprintf("%x@calsun", hash[:8])
+	e.SetSummary(event.Summary)
+	e.SetDescription(event.Description)
+	if altDesc := services.BuildHTMLDescription(event); altDesc != "" {
+		e.SetProperty(altDescProperty, altDesc, ics.WithFmtType("text/html"))
+	}
+	e.SetLocation(event.Location)
+	for _, category := range event.Categories {
+		e.AddCategory(category)
+	}
+	return e
 }
@@ -3,8 +3,15 @@ package handlers
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
 	"strings"
 	"testing"
+	"time"
+
+	"calsun/config"
+	"calsun/services"
 )
 
 func TestCalendarHandler_ValidRequest(t *testing.T) {
@@ -78,6 +85,32 @@ func TestCalendarHandler_InvalidParams(t *testing.T) {
 		{"invalid days", "/calendar.ics?lat=55.6761&lng=12.5683&days=abc"},
 		{"days too high", "/calendar.ics?lat=55.6761&lng=12.5683&days=100"},
 		{"invalid exclude", "/calendar.ics?lat=55.6761&lng=12.5683&exclude=invalid"},
+		{"invalid events", "/calendar.ics?lat=55.6761&lng=12.5683&events=sunrise,midnight"},
+		{"invalid date", "/calendar.ics?lat=55.6761&lng=12.5683&date=2025-02-30"},
+		{"invalid countdown", "/calendar.ics?lat=55.6761&lng=12.5683&countdown=monsoon"},
+		{"invalid apsis", "/calendar.ics?lat=55.6761&lng=12.5683&apsis=node"},
+		{"invalid summary", "/calendar.ics?lat=55.6761&lng=12.5683&summary=daily"},
+		{"date with days", "/calendar.ics?lat=55.6761&lng=12.5683&date=2025-06-21&days=7"},
+		{"invalid skip date", "/calendar.ics?lat=55.6761&lng=12.5683&skip=2025-07-04,July%205"},
+		{"skip range without end", "/calendar.ics?lat=55.6761&lng=12.5683&skip_range=2025-07-01"},
+		{"skip range ending before it starts", "/calendar.ics?lat=55.6761&lng=12.5683&skip_range=2025-07-10/2025-07-01"},
+		{"precision too high", "/calendar.ics?lat=55.6761&lng=12.5683&precision=5"},
+		{"invalid azimuth format", "/calendar.ics?lat=55.6761&lng=12.5683&azformat=mils"},
+		{"invalid preset", "/calendar.ics?lat=55.6761&lng=12.5683&preset=kite"},
+		{"invalid flight rule", "/calendar.ics?lat=55.6761&lng=12.5683&preset=drone&flight_rule=easa"},
+		{"flight offset too high", "/calendar.ics?lat=55.6761&lng=12.5683&preset=drone&flight_offset=121"},
+		{"window without end", "/calendar.ics?lat=55.6761&lng=12.5683&window_start=sunrise"},
+		{"invalid window start", "/calendar.ics?lat=55.6761&lng=12.5683&window_start=moonrise&window_end=sunset"},
+		{"solar azimuth out of range", "/calendar.ics?lat=55.6761&lng=12.5683&solar_azimuth=361"},
+		{"solar spread too low", "/calendar.ics?lat=55.6761&lng=12.5683&solar_azimuth=180&solar_spread=1"},
+		{"facade azimuth out of range", "/calendar.ics?lat=55.6761&lng=12.5683&facade_azimuth=-1"},
+		{"facade field of view too wide", "/calendar.ics?lat=55.6761&lng=12.5683&facade_azimuth=180&facade_fov=270"},
+		{"invalid photoperiod", "/calendar.ics?lat=55.6761&lng=12.5683&photoperiod=12,long"},
+		{"photoperiod out of range", "/calendar.ics?lat=55.6761&lng=12.5683&photoperiod=24"},
+		{"day length alert without direction", "/calendar.ics?lat=55.6761&lng=12.5683&daylength=9"},
+		{"day length alert out of range", "/calendar.ics?lat=55.6761&lng=12.5683&daylength=above:25h"},
+		{"invalid day length alert", "/calendar.ics?lat=55.6761&lng=12.5683&daylength=below:long"},
+		{"invalid window end", "/calendar.ics?lat=55.6761&lng=12.5683&window_start=sunrise&window_end=sunset-30"},
 	}
 
 	for _, tt := range tests {
@@ -134,6 +167,788 @@ func TestCalendarHandler_ExcludeSunset(t *testing.T) {
 	}
 }
 
+func TestCalendarHandler_Events(t *testing.T) {
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&days=3&events=dawn,noon,dusk", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, summary := range []string{"SUMMARY:Dawn", "SUMMARY:Solar noon", "SUMMARY:Dusk"} {
+		if !strings.Contains(body, summary) {
+			t.Errorf("response should contain %s events", summary)
+		}
+	}
+	if strings.Contains(body, "SUMMARY:Sunrise") || strings.Contains(body, "SUMMARY:Sunset") {
+		t.Error("response should only contain the chosen event types")
+	}
+}
+
+func TestCalendarHandler_EventsWithExclude(t *testing.T) {
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&days=3&events=sunrise,goldenhour&exclude=sunrise", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	body := w.Body.String()
+	if strings.Contains(body, "SUMMARY:Sunrise") || !strings.Contains(body, "SUMMARY:Morning golden hour") {
+		t.Error("exclude should remove its type from the chosen events")
+	}
+
+	// Nothing left to generate
+	req = httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&events=sunset&exclude=sunset", nil)
+	w = httptest.NewRecorder()
+	CalendarHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestCalendarHandler_Date(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		sunrise string
+	}{
+		{"copenhagen", "/calendar.ics?lat=55.6761&lng=12.5683&date=2025-06-21", "DTSTART:20250621T"},
+		// Sydney's local sunrise falls on the previous UTC date
+		{"sydney", "/calendar.ics?lat=-33.8688&lng=151.2093&date=2025-06-21", "DTSTART:20250620T"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+
+			CalendarHandler(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			body := w.Body.String()
+			if n := strings.Count(body, "BEGIN:VEVENT"); n != 2 {
+				t.Errorf("expected one sunrise and one sunset, got %d events", n)
+			}
+			if !strings.Contains(body, tt.sunrise) {
+				t.Errorf("expected an event starting %s", tt.sunrise)
+			}
+			if !strings.Contains(body, "Yesterday:") {
+				t.Error("expected the previous day's delta")
+			}
+		})
+	}
+}
+
+func TestCalendarHandler_SolarGrouping(t *testing.T) {
+	body := calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&date=2025-06-21&group=solar")
+
+	// Saturday runs from Friday's sunset to Saturday's
+	sunset := strings.Index(body, "DTSTART:20250620T")
+	sunrise := strings.Index(body, "DTSTART:20250621T")
+	if n := strings.Count(body, "BEGIN:VEVENT"); n != 2 || sunset < 0 || sunrise < sunset {
+		t.Errorf("expected Friday's sunset and then Saturday's sunrise, got %s", body)
+	}
+	if !strings.Contains(body, "SUMMARY:Sunset 21:58") {
+		t.Error("expected the sunset of the evening before")
+	}
+
+	civil := calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&date=2025-06-21")
+	uids := regexp.MustCompile(`UID:\S+`)
+	if slices.Equal(uids.FindAllString(body, -1), uids.FindAllString(civil, -1)) {
+		t.Error("expected different events than the civil day")
+	}
+
+	w := httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&group=lunar", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown grouping, got %d", w.Code)
+	}
+}
+
+func TestCalendarHandler_Locale(t *testing.T) {
+	body := calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&date=2025-06-21&events=sunrise,noon&azformat=both&locale=de")
+
+	// iCal escapes the decimal commas
+	if !strings.Contains(body, `SUMMARY:Sunrise 04:26 NE (43\,6°)`) {
+		t.Error("expected a decimal comma in the azimuth")
+	}
+	if !strings.Contains(body, `Sun at its highest: 57\,8° elevation`) {
+		t.Error("expected a decimal comma in the noon elevation")
+	}
+	if !strings.Contains(body, `Coordinates: 55.6761\, 12.5683`) {
+		t.Error("expected coordinates with a decimal point")
+	}
+
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	SetClock(services.FixedClock(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)))
+	const summary = "/calendar.ics?lat=55.6761&lng=12.5683&summary=weekly&days=14"
+	if body := calendarBody(t, summary); !strings.Contains(body, `Period: Monday\, January 13 – Sunday\, January 19\, 2025`) {
+		t.Error("expected month-first dates by default")
+	}
+	if body := calendarBody(t, summary+"&locale=en-GB"); !strings.Contains(body, "Period: Monday 13 January – Sunday 19 January 2025") {
+		t.Error("expected day-first dates for en-GB")
+	}
+
+	w := httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&locale=xx", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown locale, got %d", w.Code)
+	}
+}
+
+func TestCalendarHandler_HomeTimezone(t *testing.T) {
+	body := calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&date=2025-01-15&events=sunrise&home_tz=America/New_York")
+	if !strings.Contains(body, "SUMMARY:Sunrise 08:30 CET / 02:30 EST") {
+		t.Errorf("expected sunrise in both timezones, got:\n%s", body)
+	}
+
+	body = calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&date=2025-01-15&preset=night&home_tz=Asia/Tokyo")
+	if !strings.Contains(body, "SUMMARY:Night 16:10 to 08:29 CET / 00:10 to 16:29 JST (16h 19m)") {
+		t.Errorf("expected the night in both timezones, got:\n%s", body)
+	}
+
+	for _, zone := range []string{"Mars/Olympus", "Local", "../etc"} {
+		w := httptest.NewRecorder()
+		CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&home_tz="+zone, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", zone, w.Code)
+		}
+	}
+}
+
+func TestCalendarHandler_Skip(t *testing.T) {
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	SetClock(services.FixedClock(time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)))
+
+	const base = "/calendar.ics?lat=55.6761&lng=12.5683&days=10&events=sunrise"
+	dates := func(body string) []string {
+		var dates []string
+		for _, line := range strings.Split(body, "\r\n") {
+			if date, ok := strings.CutPrefix(line, "DTSTART:"); ok {
+				dates = append(dates, date[:8])
+			}
+		}
+		return dates
+	}
+
+	all := dates(calendarBody(t, base))
+	skipped := dates(calendarBody(t, base+"&skip=2025-07-04,2025-07-09&skip_range=2025-07-06/2025-07-07"))
+	for _, date := range []string{"20250704", "20250706", "20250707", "20250709"} {
+		if !slices.Contains(all, date) {
+			t.Fatalf("expected a sunrise on %s without skip, got %v", date, all)
+		}
+		if slices.Contains(skipped, date) {
+			t.Errorf("expected no sunrise on skipped %s", date)
+		}
+	}
+	if len(skipped) != len(all)-4 {
+		t.Errorf("expected exactly 4 days left out, got %d of %d", len(all)-len(skipped), len(all))
+	}
+}
+
+func TestCalendarHandler_SkipLocalDate(t *testing.T) {
+	// Tokyo's sunrise is on the previous UTC date, so only the local date counts
+	body := calendarBody(t, "/calendar.ics?lat=35.68&lng=139.69&date=2025-07-04&events=sunrise&skip=2025-07-03")
+	if !strings.Contains(body, "SUMMARY:Sunrise") {
+		t.Error("expected the sunrise of the local date to be kept")
+	}
+	body = calendarBody(t, "/calendar.ics?lat=35.68&lng=139.69&date=2025-07-04&events=sunrise&skip=2025-07-04")
+	if strings.Contains(body, "BEGIN:VEVENT") {
+		t.Error("expected the sunrise of the skipped local date to be left out")
+	}
+}
+
+func TestCalendarHandler_AltDates(t *testing.T) {
+	body := calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&date=2025-01-15&events=sunrise&alt_dates=hebrew,hijri")
+	if !strings.Contains(body, `Hijri date: 15 Rajab 1446 AH\nHebrew date: 15 Tevet 5785`) {
+		t.Errorf("expected both dates in the description, got:\n%s", body)
+	}
+	if body := calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&date=2025-01-15&events=sunrise"); strings.Contains(body, "Hebrew date") {
+		t.Error("expected no alternate dates by default")
+	}
+
+	w := httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&alt_dates=julian", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown calendar, got %d", w.Code)
+	}
+}
+
+func TestAltDatesParamValues(t *testing.T) {
+	if !slices.Equal(altDatesParam.Values, []string{services.AltDateHijri, services.AltDateHebrew}) {
+		t.Errorf("expected the services' calendars, got %v", altDatesParam.Values)
+	}
+}
+
+func TestLocaleParamValues(t *testing.T) {
+	if !slices.Equal(localeParam.Values, services.LocaleTags()) {
+		t.Errorf("expected the supported locales, got %v", localeParam.Values)
+	}
+}
+
+func TestCalendarHandler_UIDVersion(t *testing.T) {
+	uids := func(url string) []string {
+		t.Helper()
+		return regexp.MustCompile(`UID:\S+`).FindAllString(calendarBody(t, url), -1)
+	}
+	const base = "/calendar.ics?lat=55.6761&lng=12.5683&days=3"
+
+	v1 := uids(base)
+	if !slices.Equal(uids(base+"&uidv=1&azformat=compass"), v1) {
+		t.Error("expected default UIDs to stay stable across option changes")
+	}
+
+	v2 := uids(base + "&uidv=2")
+	if len(v2) != len(v1) || slices.ContainsFunc(v2, func(uid string) bool { return !strings.HasPrefix(uid, "UID:v2-") }) {
+		t.Fatalf("expected versioned UIDs for every event, got %v", v2)
+	}
+	if slices.Contains(v2, v1[0]) {
+		t.Error("expected version 2 UIDs to differ from the default ones")
+	}
+	if !slices.Equal(uids(base+"&uidv=2&lat=55.67610&filename=sun.ics"), v2) {
+		t.Error("expected version 2 UIDs to ignore the range, name, and file name")
+	}
+	changed := uids(base + "&uidv=2&azformat=compass")
+	if slices.ContainsFunc(changed, func(uid string) bool { return slices.Contains(v2, uid) }) {
+		t.Error("expected changed options to give every event a new version 2 UID")
+	}
+	if longer := uids("/calendar.ics?lat=55.6761&lng=12.5683&days=5&uidv=2"); !slices.Equal(longer[:len(v2)], v2) {
+		t.Error("expected more days to keep the version 2 UIDs of the first days")
+	}
+
+	w := httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", base+"&uidv=3", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown UID version, got %d", w.Code)
+	}
+}
+
+func TestCalendarHandler_PrefixSuffix(t *testing.T) {
+	body := calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&days=2&prefix=%5BCPH%5D%20%20&suffix=(home)")
+	if !regexp.MustCompile(`SUMMARY:\[CPH\] Sunrise \d\d:\d\d \(home\)\r\n`).MatchString(body) {
+		t.Error("expected the prefix and suffix around every title")
+	}
+	if strings.Count(body, "SUMMARY:[CPH] ") != strings.Count(body, "BEGIN:VEVENT") {
+		t.Error("expected every event prefixed")
+	}
+
+	for _, tt := range []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"line breaks", "prefix=%5BCPH%5D%0D%0ADESCRIPTION:x", http.StatusOK},
+		{"at the limit", "suffix=" + strings.Repeat("é", maxAffixLength), http.StatusOK},
+		{"too long", "prefix=" + strings.Repeat("x", maxAffixLength+1), http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&days=1&"+tt.query, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.want, w.Code)
+		}
+		if strings.Contains(w.Body.String(), "\r\nDESCRIPTION:x") {
+			t.Errorf("%s: expected control characters removed", tt.name)
+		}
+	}
+}
+
+func TestParseAffix(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"  [CPH]  ", "[CPH]"},
+		{"a\r\nb\tc", "a b c"},
+		{"🌅 Home", "🌅 Home"},
+	}
+	for _, tt := range tests {
+		got, errMsg := parseAffix(url.Values{"prefix": {tt.value}}, prefixParam)
+		if got != tt.want || errMsg != "" {
+			t.Errorf("parseAffix(%q) = %q (%s), want %q", tt.value, got, errMsg, tt.want)
+		}
+	}
+	if got := affixSummary("Sunset 21:58", "", "✦"); got != "Sunset 21:58 ✦" {
+		t.Errorf("expected only the suffix, got %q", got)
+	}
+}
+
+func TestCalendarHandler_Countdown(t *testing.T) {
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&countdown=summer,winter&days=14", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := unfoldICal(w.Body.String())
+	// 28 days hold four weekly countdowns for each season
+	if n := strings.Count(body, "days until the summer solstice"); n != 4 {
+		t.Errorf("expected 4 summer countdowns, got %d", n)
+	}
+	if n := strings.Count(body, "days until the winter solstice"); n != 4 {
+		t.Errorf("expected 4 winter countdowns, got %d", n)
+	}
+}
+
+func TestCalendarHandler_Apsis(t *testing.T) {
+	enableDebug(t)
+
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&apsis=perigee,apogee&days=14&now=2024-10-20T12:00:00Z", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := unfoldICal(w.Body.String())
+	if !strings.Contains(body, "SUMMARY:Moon at perigee (357\\,175 km)") || !strings.Contains(body, "DTSTART;VALUE=DATE:20241017") {
+		t.Error("expected the perigee of 2024-10-17")
+	}
+	if n := strings.Count(body, "SUMMARY:Moon at apogee"); n != 1 {
+		t.Errorf("expected 1 apogee, got %d", n)
+	}
+}
+
+func TestCalendarHandler_Eclipses(t *testing.T) {
+	enableDebug(t)
+
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&eclipses=solar&days=30&now=2025-03-20T12:00:00Z", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := unfoldICal(w.Body.String())
+	if n := strings.Count(body, "SUMMARY:Partial solar eclipse (21% of the sun covered)"); n != 1 {
+		t.Errorf("expected the eclipse of 2025-03-29, got %d", n)
+	}
+	if !strings.Contains(body, "DTSTART:20250329T1032") {
+		t.Error("expected the event to start at the first contact")
+	}
+
+	w = httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&eclipses=lunar", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown eclipse kind, got %d", w.Code)
+	}
+}
+
+func TestCalendarHandler_Planets(t *testing.T) {
+	enableDebug(t)
+
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&planets=venus,jupiter&days=1&now=2025-01-10T12:00:00Z", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := unfoldICal(w.Body.String())
+	for _, want := range []string{"SUMMARY:Venus rises ", "SUMMARY:Venus sets 20:3", "SUMMARY:Jupiter rises ", "SUMMARY:Jupiter sets ", "SUMMARY:Sunrise"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q", want)
+		}
+	}
+	if strings.Contains(body, "Mars") || strings.Contains(body, "Saturn") {
+		t.Error("expected only the requested planets")
+	}
+
+	w = httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&planets=pluto", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown planet, got %d", w.Code)
+	}
+}
+
+func TestCalendarHandler_WeeklySummary(t *testing.T) {
+	enableDebug(t)
+
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&summary=weekly&days=14&now=2025-01-15T12:00:00Z", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := unfoldICal(w.Body.String())
+	// Sundays from January 5 (in the past 14 days) to January 26
+	if n := strings.Count(body, "SUMMARY:Daylight this week: +"); n != 4 {
+		t.Errorf("expected 4 weekly summaries, got %d", n)
+	}
+	if !strings.Contains(body, "DTSTART;VALUE=DATE:20250119") {
+		t.Error("expected a summary on Sunday 2025-01-19")
+	}
+}
+
+func TestCalendarHandler_MonthlySummary(t *testing.T) {
+	enableDebug(t)
+
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&summary=weekly,monthly&days=30&now=2025-02-20T12:00:00Z", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := unfoldICal(w.Body.String())
+	if n := strings.Count(body, "SUMMARY:March 2025: "); n != 1 {
+		t.Errorf("expected 1 monthly summary, got %d", n)
+	}
+	if !strings.Contains(body, "SUMMARY:Daylight this week: +") {
+		t.Error("expected weekly summaries alongside the monthly one")
+	}
+}
+
+func TestCalendarHandler_DronePreset(t *testing.T) {
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=40.7128&lng=-74.0060&name=NYC&preset=drone&flight_rule=faa&flight_offset=-10&days=7", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := unfoldICal(w.Body.String())
+	if !strings.Contains(body, "X-WR-CALNAME:Drone Flight Windows - NYC") {
+		t.Error("expected drone calendar name")
+	}
+	if strings.Contains(body, "SUMMARY:Sunrise") || strings.Contains(body, "SUMMARY:Sunset") {
+		t.Error("expected sunrise and sunset events to be replaced")
+	}
+	if n := strings.Count(body, "SUMMARY:Flight window opens "); n != 21 {
+		t.Errorf("expected 21 window openings, got %d", n)
+	}
+	if n := strings.Count(body, "SUMMARY:Flight window closes "); n != 21 {
+		t.Errorf("expected 21 window closings, got %d", n)
+	}
+	if !strings.Contains(body, "FAA 30-minute rule\\, 10 minutes after sunrise") {
+		t.Error("expected the offset to replace the rule's minutes")
+	}
+}
+
+func TestCalendarHandler_SolunarPreset(t *testing.T) {
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=40.7128&lng=-74.0060&preset=solunar&days=7", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := unfoldICal(w.Body.String())
+	if !strings.Contains(body, "X-WR-CALNAME:Solunar Periods") {
+		t.Error("expected solunar calendar name")
+	}
+	if strings.Contains(body, "SUMMARY:Sunrise") {
+		t.Error("expected sunrise and sunset events to be replaced")
+	}
+
+	// Each event is skipped about once a month, as the lunar day is longer
+	majors, minors := strings.Count(body, "SUMMARY:Major solunar period "), strings.Count(body, "SUMMARY:Minor solunar period ")
+	if majors < 38 || majors > 42 || minors < 38 || minors > 42 {
+		t.Errorf("expected about 42 major and minor periods in 21 days, got %d and %d", majors, minors)
+	}
+}
+
+func TestCalendarHandler_NauticalPreset(t *testing.T) {
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=50.8&lng=-1.1&name=Solent&preset=nautical&days=1", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := unfoldICal(w.Body.String())
+	if !strings.Contains(body, "X-WR-CALNAME:Nautical Times - Solent") {
+		t.Error("expected nautical calendar name")
+	}
+	for _, want := range []string{"SUMMARY:Nautical dawn ", "SUMMARY:Sunrise ", "SUMMARY:Sunset ", "SUMMARY:Nautical dusk ", "SUMMARY:Moonrise ", "SUMMARY:Moonset ", "Bearing: "} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in calendar", want)
+		}
+	}
+}
+
+func TestCalendarHandler_ActivityWindow(t *testing.T) {
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&days=7&window_name=Safe+run+window&window_start=sunrise%2B15m&window_end=sunset-30m", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := unfoldICal(w.Body.String())
+	if n := strings.Count(body, "SUMMARY:Safe run window"); n != 21 {
+		t.Errorf("expected 21 window events, got %d", n)
+	}
+	if n := strings.Count(body, "SUMMARY:Sunrise "); n != 21 {
+		t.Errorf("expected sunrise events to be kept, got %d", n)
+	}
+	if !strings.Contains(body, "Window: sunrise + 15m to sunset - 30m") {
+		t.Error("expected window definition in description")
+	}
+}
+
+func TestCalendarHandler_Facade(t *testing.T) {
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&days=7&facade_azimuth=90&facade_fov=120", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := unfoldICal(w.Body.String())
+	if n := strings.Count(body, "SUMMARY:Direct sun on window"); n != 21 {
+		t.Errorf("expected 21 spans of morning sun through an east window, got %d", n)
+	}
+	if !strings.Contains(body, "Window: facing E (90°)\\, 120° field of view") {
+		t.Error("expected the window in the description")
+	}
+	if n := strings.Count(body, "SUMMARY:Sunrise"); n != 21 {
+		t.Errorf("expected the sun events to stay, got %d sunrises", n)
+	}
+}
+
+func TestCalendarHandler_SolarWindow(t *testing.T) {
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=-33.8688&lng=151.2093&days=7&solar_azimuth=0&solar_elevation=25", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := unfoldICal(w.Body.String())
+	if n := strings.Count(body, "SUMMARY:Peak production window"); n != 21 {
+		t.Errorf("expected 21 production windows for a north-facing panel in Sydney, got %d", n)
+	}
+	if !strings.Contains(body, "Panel: facing N (0°)\\, sun within 60° and above 25°") {
+		t.Error("expected panel description with default spread")
+	}
+}
+
+func TestCalendarHandler_Photoperiod(t *testing.T) {
+	// Copenhagen's day length spans about 7h to 17.5h over the year, so in any
+	// 104 days it crosses at least one of these hourly thresholds
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&days=90&photoperiod=8,9,10,11,12,13,14,15,16,17", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := unfoldICal(w.Body.String())
+	if !strings.Contains(body, "SUMMARY:Day length ") {
+		t.Fatal("expected photoperiod events")
+	}
+	if !strings.Contains(body, "DTSTART;VALUE=DATE:") {
+		t.Error("expected all-day photoperiod events")
+	}
+}
+
+func TestCalendarHandler_DaylightAlerts(t *testing.T) {
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	SetClock(services.FixedClock(time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)))
+
+	body := calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&days=60&events=sunset&daylength=below:10h30m,above:10.5,below:9")
+	if n := strings.Count(body, "SUMMARY:Days now shorter than 10h 30m"); n != 1 {
+		t.Errorf("expected one alert for the falling crossing, got %d", n)
+	}
+	if n := strings.Count(body, "SUMMARY:Days now shorter than 9h"); n != 1 {
+		t.Errorf("expected one alert for 9h, got %d", n)
+	}
+	if strings.Contains(body, "SUMMARY:Days now longer") {
+		t.Error("expected no alert for the rising direction in autumn")
+	}
+}
+
+func TestCalendarHandler_AviationPreset(t *testing.T) {
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=40.7128&lng=-74.0060&name=KJFK&preset=aviation&days=7", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := unfoldICal(w.Body.String())
+	if !strings.Contains(body, "X-WR-CALNAME:Aviation Times - KJFK") {
+		t.Error("expected aviation calendar name")
+	}
+	for _, summary := range []string{"SUMMARY:Night ends ", "SUMMARY:Sunrise ", "SUMMARY:Sunset ", "SUMMARY:Night begins "} {
+		if n := strings.Count(body, summary); n != 21 {
+			t.Errorf("expected 21 %q events, got %d", summary, n)
+		}
+	}
+	if !strings.Contains(body, "Z)") || !strings.Contains(body, " local\\, ") {
+		t.Error("expected local and Zulu times")
+	}
+}
+
+func TestCalendarHandler_NightPreset(t *testing.T) {
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&name=Copenhagen&preset=night&date=2024-12-21", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := unfoldICal(w.Body.String())
+	if !strings.Contains(body, "X-WR-CALNAME:Nights - Copenhagen") {
+		t.Error("expected night calendar name")
+	}
+	for _, want := range []string{"DTSTART:20241221T143900Z", "DTEND:20241222T073900Z", "SUMMARY:Night 15:39 to 08:39 (16h 59m)", "Darkest: 00:09"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in calendar", want)
+		}
+	}
+	if n := strings.Count(body, "BEGIN:VEVENT"); n != 1 {
+		t.Errorf("expected only the night starting on the date, got %d events", n)
+	}
+}
+
+func TestCalendarHandler_AzimuthFormat(t *testing.T) {
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&days=1&azformat=both", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	body := unfoldICal(w.Body.String())
+	if !regexp.MustCompile(`SUMMARY:Sunrise \d\d:\d\d [NESW]{1,3} \(\d+\.\d°\)`).MatchString(body) {
+		t.Error("expected compass direction and degrees in sunrise titles")
+	}
+	if !regexp.MustCompile(`Azimuth: [NESW]{1,3} \(\d+\.\d°\)`).MatchString(body) {
+		t.Error("expected compass direction and degrees in descriptions")
+	}
+}
+
+func TestCalendarHandler_AltDescription(t *testing.T) {
+	body := calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&days=1&events=sunrise&name=Home+%3Cb%3E")
+
+	if strings.Count(body, "X-ALT-DESC;FMTTYPE=text/html:<!DOCTYPE html>") != strings.Count(body, "BEGIN:VEVENT") {
+		t.Errorf("expected an HTML description for every event, got %s", body)
+	}
+	// HTML is escaped for HTML, then for iCal text
+	if !strings.Contains(body, `<th align="left">Location</th><td>Home &lt\;b&gt\;</td>`) {
+		t.Errorf("expected the escaped location in the HTML description, got %s", body)
+	}
+	if !strings.Contains(body, `<a href="https://www.openstreetmap.org/?mlat=55.6761&amp\;mlon=12.5683`) {
+		t.Error("expected the coordinates linked to a map")
+	}
+}
+
+func TestCalendarHandler_Precision(t *testing.T) {
+	get := func(url string) string {
+		w := httptest.NewRecorder()
+		CalendarHandler(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		return unfoldICal(w.Body.String())
+	}
+	uids := func(body string) []string {
+		return regexp.MustCompile(`UID:\S+`).FindAllString(body, -1)
+	}
+
+	body := get("/calendar.ics?lat=55.67612&lng=12.56834&days=1&precision=2")
+	if !strings.Contains(body, `LOCATION:55.68\, 12.57`) || !strings.Contains(body, `Coordinates: 55.68\, 12.57`) {
+		t.Error("expected coordinates rounded to 2 decimals")
+	}
+	if strings.Contains(body, "55.676") {
+		t.Error("expected the exact coordinates to be hidden")
+	}
+
+	// Nearby homes share UIDs at a coarse precision
+	nearby := get("/calendar.ics?lat=55.67801&lng=12.57123&days=1&precision=2")
+	if !slices.Equal(uids(body), uids(nearby)) {
+		t.Error("expected the same UIDs for coordinates that round alike")
+	}
+
+	// The default precision keeps the existing UIDs
+	if !slices.Equal(uids(get("/calendar.ics?lat=55.6761&lng=12.5683&days=1")), uids(get("/calendar.ics?lat=55.6761&lng=12.5683&days=1&precision=4"))) {
+		t.Error("expected precision=4 to match the default UIDs")
+	}
+}
+
+func TestCalendarHandler_ConfiguredPrecision(t *testing.T) {
+	original := cfg
+	t.Cleanup(func() { Configure(original) })
+	c := config.Default()
+	c.CoordPrecision = 1
+	Configure(c)
+
+	w := httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&days=1", nil))
+
+	if !strings.Contains(unfoldICal(w.Body.String()), `Coordinates: 55.7\, 12.6`) {
+		t.Error("expected the configured precision by default")
+	}
+}
+
+func TestCalendarHandler_MultiYear(t *testing.T) {
+	w := httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&days=91", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 beyond the default 90 days, got %d", w.Code)
+	}
+
+	original := cfg
+	t.Cleanup(func() { Configure(original) })
+	c := config.Default()
+	c.MaxDays = 1095
+	c.Debug = true
+	Configure(c)
+
+	w = httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&days=1095&countdown=summer&summary=monthly&now=2025-01-15T12:00:00Z", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if report := lintICS(w.Body.Bytes()); !report.Valid {
+		t.Errorf("expected a valid calendar, got %+v", report.Errors)
+	}
+
+	body := unfoldICal(w.Body.String())
+	seen := map[string]bool{}
+	for _, uid := range regexp.MustCompile(`UID:\S+`).FindAllString(body, -1) {
+		if seen[uid] {
+			t.Fatalf("duplicate %s", uid)
+		}
+		seen[uid] = true
+	}
+	// Seasonal events continue across the year boundaries
+	for _, year := range []string{"2025", "2026", "2027"} {
+		if !strings.Contains(body, "SUMMARY:June "+year+": ") {
+			t.Errorf("expected a monthly summary for June %s", year)
+		}
+		if !regexp.MustCompile(`DTSTART;VALUE=DATE:` + year + `\d{4}\r\nDTEND;VALUE=DATE:\d{8}\r\nSUMMARY:\d+ days until the summer solstice`).MatchString(body) {
+			t.Errorf("expected summer solstice countdowns in %s", year)
+		}
+	}
+	if !strings.Contains(body, "SUMMARY:January 2028: ") {
+		t.Error("expected the calendar to reach January 2028")
+	}
+}
+
 func TestCalendarHandler_CustomDays(t *testing.T) {
 	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&days=7", nil)
 	w := httptest.NewRecorder()
@@ -162,6 +977,7 @@ func TestCalendarHandler_CalendarName(t *testing.T) {
 		{"with name", "/calendar.ics?lat=55.6761&lng=12.5683&name=Copenhagen", "Sun Times - Copenhagen"},
 		{"without name", "/calendar.ics?lat=55.6761&lng=12.5683", "Sun Times"},
 		{"sunset only with name", "/calendar.ics?lat=55.6761&lng=12.5683&name=Test&exclude=sunrise", "Sun Times - Test (Sunset only)"},
+		{"single event type", "/calendar.ics?lat=55.6761&lng=12.5683&events=goldenhour", "Sun Times (Golden hour only)"},
 	}
 
 	for _, tt := range tests {
@@ -178,3 +994,59 @@ func TestCalendarHandler_CalendarName(t *testing.T) {
 		})
 	}
 }
+
+// unfoldICal joins iCal content lines that were folded at 75 characters
+func unfoldICal(s string) string {
+	return strings.NewReplacer("\r\n ", "", "\n ", "").Replace(s)
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"copenhagen-sun.ics", "copenhagen-sun.ics"},
+		{"copenhagen-sun", "copenhagen-sun.ics"},
+		{"Home.ICS", "Home.ics"},
+		{"København sol", "København sol.ics"},
+		{"../../etc/passwd", "passwd.ics"},
+		{`C:\Users\me\sun`, "sun.ics"},
+		{"sun\r\nSet-Cookie: a=1", "sunSet-Cookie a=1.ics"},
+		{`"quoted";name.ics`, "quotedname.ics"},
+		{".ics", ""},
+		{"...", ""},
+		{"", ""},
+		{strings.Repeat("a", 150), strings.Repeat("a", maxFilenameLength) + ".ics"},
+	}
+
+	for _, tt := range tests {
+		if got := sanitizeFilename(tt.name); got != tt.expected {
+			t.Errorf("sanitizeFilename(%q) = %q, want %q", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestCalendarHandler_Filename(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"/calendar.ics?lat=55.6761&lng=12.5683&days=1", "attachment; filename=calsun.ics"},
+		{"/calendar.ics?lat=55.6761&lng=12.5683&days=1&filename=copenhagen-sun", "attachment; filename=copenhagen-sun.ics"},
+		{"/calendar.ics?lat=55.6761&lng=12.5683&days=1&filename=my%20sun", `attachment; filename="my sun.ics"`},
+		{"/calendar.ics?lat=55.6761&lng=12.5683&days=1&filename=K%C3%B8benhavn", "attachment; filename*=utf-8''K%C3%B8benhavn.ics"},
+		{"/calendar.ics?lat=55.6761&lng=12.5683&days=1&filename=%0D%0ASet-Cookie%3A%20a%3D1", `attachment; filename="Set-Cookie a=1.ics"`},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		CalendarHandler(w, httptest.NewRequest("GET", tt.url, nil))
+
+		if got := w.Header().Get("Content-Disposition"); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.url, tt.expected, got)
+		}
+		if len(w.Header().Values("Set-Cookie")) != 0 {
+			t.Errorf("%s: header injected", tt.url)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"calsun/services"
+)
+
+const (
+	// maxConfigBody is the largest JSON configuration accepted
+	maxConfigBody = 64 << 10
+	// maxConfigLocations bounds the locations of a configured calendar
+	maxConfigLocations = 10
+	// maxConfigTemplate bounds the length of summary and description templates
+	maxConfigTemplate = 1000
+)
+
+// configLocationParams are the calendar parameters set per location in a
+// configuration
+var configLocationParams = []paramDef{latParam, lngParam, placeParam, geohashParam, plusCodeParam, utmParam, mgrsParam, nameParam, altitudeParam}
+
+// configOverrideParams are the calendar parameters set in options that a
+// location may override, e.g. a prefix telling its events apart
+var configOverrideParams = []paramDef{prefixParam, suffixParam}
+
+// calendarConfig is a calendar configuration posted as JSON, for calendars
+// too rich for a query string
+type calendarConfig struct {
+	Name      string           `json:"name"`      // Calendar name; defaults to the location names
+	Locations []map[string]any `json:"locations"` // Location parameters (lat and lng, place, ...), name, and prefix or suffix
+	Events    []string         `json:"events"`    // Event types, as the events parameter
+	Options   map[string]any   `json:"options"`   // Other calendar parameters by name (days, azformat, preset, ...)
+	Templates configTemplates  `json:"templates"`
+	Filters   configFilters    `json:"filters"`
+}
+
+// configTemplates rewrite event summaries and descriptions. The
+// placeholders {summary}, {description}, {location}, {type}, {date}, and
+// {time} are replaced with the event's own, in local time.
+type configTemplates struct {
+	Summary     string `json:"summary"`
+	Description string `json:"description"`
+}
+
+// configFilters select events by when they start in local time. All-day
+// events pass the time filters.
+type configFilters struct {
+	From     string   `json:"from"`     // Earliest start time, e.g. "06:00"
+	To       string   `json:"to"`       // Start times before this one; before from to wrap past midnight
+	Weekdays []string `json:"weekdays"` // Days of the week to keep, e.g. ["sat", "sun"]
+}
+
+// configWeekdays names days of the week in weekday filters
+var configWeekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// serveCalendarConfig serves the iCal calendar configured by the JSON body
+// of a POST to /calendar.ics
+func serveCalendarConfig(w http.ResponseWriter, r *http.Request) {
+	var config calendarConfig
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxConfigBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&config); err != nil {
+		if tooLarge(err) {
+			http.Error(w, "configuration too large (max 64 KiB)", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid JSON configuration: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	queries, errMsg := config.queries()
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	filter, errMsg := config.Filters.parse()
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	for _, template := range []string{config.Templates.Summary, config.Templates.Description} {
+		if len(template) > maxConfigTemplate {
+			http.Error(w, fmt.Sprintf("templates must be at most %d characters", maxConfigTemplate), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var first *calendarParams
+	var tz *time.Location
+	var calName string
+	var names []string
+	var events []services.CalendarEvent
+	for i, q := range queries {
+		params, errMsg := parseCalendarQuery(q)
+		if errMsg != "" {
+			http.Error(w, fmt.Sprintf("locations[%d]: %s", i, errMsg), http.StatusBadRequest)
+			return
+		}
+		params.withICSCompat()
+		locationTZ := services.GetTimezone(params.lat, params.lng)
+		name, locationEvents := buildCalendarEvents(r, params, locationTZ)
+		if first == nil {
+			first, tz, calName = params, locationTZ, name
+		}
+		names = append(names, locationName(params))
+		for _, event := range locationEvents {
+			if filter(event, locationTZ) {
+				events = append(events, config.Templates.apply(event, locationName(params), locationTZ))
+			}
+		}
+	}
+	slices.SortStableFunc(events, func(a, b services.CalendarEvent) int { return a.Start.Compare(b.Start) })
+	if config.Name != "" || len(queries) > 1 {
+		calName = calendarName(cmp.Or(config.Name, strings.Join(names, ", ")), first.events)
+	}
+
+	serialize := serializeICS
+	if compat, ok := icsCompats[first.compat]; ok {
+		serialize = compat.wrap(serialize)
+	}
+	body, err := serialize(calName, tz, events, first.now)
+	if err != nil {
+		http.Error(w, "failed to serialize calendar", http.StatusInternalServerError)
+		return
+	}
+	writeCalendarResponse(w, body, calendarFormats[0], first.filename, "MISS")
+}
+
+// queries returns the calendar query of each location of the configuration
+func (c calendarConfig) queries() ([]url.Values, string) {
+	if len(c.Locations) == 0 {
+		return nil, "locations must list at least one location"
+	}
+	if len(c.Locations) > maxConfigLocations {
+		return nil, fmt.Sprintf("locations must list at most %d locations", maxConfigLocations)
+	}
+
+	shared := url.Values{}
+	for name, value := range c.Options {
+		def := slices.IndexFunc(calendarParamDefs, func(p paramDef) bool { return p.Name == name })
+		switch {
+		case def < 0:
+			return nil, fmt.Sprintf("options: unknown parameter %q", name)
+		case name == eventsParam.Name:
+			return nil, "options: set event types in events"
+		case slices.ContainsFunc(configLocationParams, func(p paramDef) bool { return p.Name == name }):
+			return nil, fmt.Sprintf("options: set %s in locations", name)
+		}
+		str, ok := configValue(value)
+		if !ok {
+			return nil, fmt.Sprintf("options: %s must be a string, number, boolean, or list", name)
+		}
+		shared.Set(name, str)
+	}
+	if len(c.Events) > 0 {
+		shared.Set(eventsParam.Name, strings.Join(c.Events, ","))
+	}
+
+	queries := make([]url.Values, len(c.Locations))
+	for i, location := range c.Locations {
+		q := url.Values{}
+		for name, values := range shared {
+			q[name] = values
+		}
+		for name, value := range location {
+			isParam := func(p paramDef) bool { return p.Name == name }
+			if !slices.ContainsFunc(configLocationParams, isParam) && !slices.ContainsFunc(configOverrideParams, isParam) {
+				return nil, fmt.Sprintf("locations[%d]: unknown parameter %q", i, name)
+			}
+			str, ok := configValue(value)
+			if !ok {
+				return nil, fmt.Sprintf("locations[%d]: %s must be a string or number", i, name)
+			}
+			q.Set(name, str)
+		}
+		queries[i] = q
+	}
+	return queries, ""
+}
+
+// configValue formats a JSON value as a query parameter value, joining lists
+// with commas
+func configValue(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			part, ok := configValue(item)
+			if _, isList := item.([]any); !ok || isList {
+				return "", false
+			}
+			parts[i] = part
+		}
+		return strings.Join(parts, ","), true
+	}
+	return "", false
+}
+
+// parse returns a function reporting whether an event passes the filters
+func (f configFilters) parse() (func(services.CalendarEvent, *time.Location) bool, string) {
+	var from, to time.Duration
+	for _, bound := range []struct {
+		name, value string
+		d           *time.Duration
+	}{{"from", f.From, &from}, {"to", f.To, &to}} {
+		if bound.value == "" {
+			continue
+		}
+		t, err := time.Parse("15:04", bound.value)
+		if err != nil {
+			return nil, fmt.Sprintf("filters: %s must be a time like 06:00", bound.name)
+		}
+		*bound.d = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	if f.To == "" {
+		to = 24 * time.Hour
+	}
+
+	var weekdays []time.Weekday
+	for _, day := range f.Weekdays {
+		i := slices.Index(configWeekdays, strings.ToLower(day))
+		if i < 0 {
+			return nil, fmt.Sprintf("filters: unknown weekday %q (use %s)", day, strings.Join(configWeekdays, ", "))
+		}
+		weekdays = append(weekdays, time.Weekday(i))
+	}
+
+	return func(event services.CalendarEvent, tz *time.Location) bool {
+		local := event.Start.In(tz)
+		if event.AllDay {
+			local = event.Start
+		}
+		if len(weekdays) > 0 && !slices.Contains(weekdays, local.Weekday()) {
+			return false
+		}
+		if event.AllDay {
+			return true
+		}
+		clock := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute
+		if from <= to {
+			return clock >= from && clock < to
+		}
+		return clock >= from || clock < to
+	}, ""
+}
+
+// apply rewrites the event's summary and description with the templates
+func (t configTemplates) apply(event services.CalendarEvent, location string, tz *time.Location) services.CalendarEvent {
+	if t.Summary == "" && t.Description == "" {
+		return event
+	}
+	local := event.Start.In(tz)
+	replacer := strings.NewReplacer(
+		"{summary}", event.Summary,
+		"{description}", event.Description,
+		"{location}", location,
+		"{type}", event.Type,
+		"{date}", local.Format(time.DateOnly),
+		"{time}", local.Format("15:04"),
+	)
+	if t.Summary != "" {
+		event.Summary = replacer.Replace(t.Summary)
+	}
+	if t.Description != "" {
+		event.Description = replacer.Replace(t.Description)
+	}
+	return event
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+// postCalendarConfig posts a JSON configuration to the calendar endpoint
+func postCalendarConfig(t *testing.T, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/calendar.ics", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	CalendarHandler(w, req)
+	return w
+}
+
+func TestCalendarHandler_PostConfig(t *testing.T) {
+	w := postCalendarConfig(t, `{
+		"name": "Trips",
+		"locations": [
+			{"lat": 55.6761, "lng": 12.5683, "name": "Copenhagen"},
+			{"lat": 59.9139, "lng": 10.7522, "name": "Oslo"}
+		],
+		"events": ["sunset"],
+		"options": {"days": 3, "azformat": "compass"},
+		"templates": {"summary": "{location}: {summary}"}
+	}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, "text/calendar") {
+		t.Errorf("expected an iCal calendar, got %s", ct)
+	}
+	body := unfoldICal(w.Body.String())
+
+	if !strings.Contains(body, "X-WR-CALNAME:Sun Times - Trips (Sunset only)") {
+		t.Error("expected the configured calendar name")
+	}
+	copenhagen, oslo := strings.Count(body, "SUMMARY:Copenhagen: Sunset"), strings.Count(body, "SUMMARY:Oslo: Sunset")
+	if copenhagen == 0 || copenhagen != oslo {
+		t.Errorf("expected templated sunsets for both locations, got %d and %d", copenhagen, oslo)
+	}
+	if strings.Contains(body, "SUMMARY:Copenhagen: Sunrise") {
+		t.Error("expected only the configured event types")
+	}
+	if !regexp.MustCompile(`SUMMARY:Oslo: Sunset \d\d:\d\d [NESW]+\r\n`).MatchString(body) {
+		t.Error("expected the shared options (compass directions) applied")
+	}
+}
+
+func TestCalendarHandler_PostConfigPrefix(t *testing.T) {
+	w := postCalendarConfig(t, `{
+		"locations": [
+			{"lat": 55.6761, "lng": 12.5683, "prefix": "[CPH]"},
+			{"lat": 59.9139, "lng": 10.7522}
+		],
+		"events": ["sunset"],
+		"options": {"days": 2, "prefix": "[?]", "suffix": "*"},
+		"templates": {"summary": "{summary}!"}
+	}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := unfoldICal(w.Body.String())
+	if !regexp.MustCompile(`SUMMARY:\[CPH\] Sunset \d\d:\d\d \*!\r\n`).MatchString(body) {
+		t.Error("expected the location's prefix, the shared suffix, and the template")
+	}
+	if !strings.Contains(body, "SUMMARY:[?] Sunset") {
+		t.Error("expected the shared prefix for the location without its own")
+	}
+}
+
+func TestCalendarHandler_PostConfigFilters(t *testing.T) {
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	SetClock(services.FixedClock(time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)))
+
+	w := postCalendarConfig(t, `{
+		"locations": [{"lat": 55.6761, "lng": 12.5683}],
+		"events": ["sunrise", "sunset", "noon"],
+		"options": {"days": 7},
+		"filters": {"from": "12:00", "to": "23:00", "weekdays": ["sat", "Sun"]}
+	}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := unfoldICal(w.Body.String())
+
+	if strings.Contains(body, "SUMMARY:Sunrise") {
+		t.Error("expected sunrises before 12:00 filtered out")
+	}
+	// Three weekends from the 14 past days to a week ahead
+	if n := strings.Count(body, "SUMMARY:Sunset"); n != 6 {
+		t.Errorf("expected 6 weekend sunsets, got %d", n)
+	}
+	for _, line := range strings.Split(body, "\r\n") {
+		if date, ok := strings.CutPrefix(line, "DTSTART:"); ok {
+			start, _ := time.Parse("20060102T150405Z", date)
+			if day := start.Weekday(); day != time.Saturday && day != time.Sunday {
+				t.Errorf("expected only weekend events, got %s", date)
+			}
+		}
+	}
+}
+
+func TestCalendarHandler_PostConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"invalid JSON", `{"locations": [`, "invalid JSON"},
+		{"unknown field", `{"locations": [{"lat": 1, "lng": 2}], "colour": "red"}`, "unknown field"},
+		{"no locations", `{"locations": []}`, "at least one location"},
+		{"unknown option", `{"locations": [{"lat": 1, "lng": 2}], "options": {"bogus": 1}}`, `unknown parameter "bogus"`},
+		{"location in options", `{"locations": [{"lat": 1, "lng": 2}], "options": {"lat": 1}}`, "set lat in locations"},
+		{"unknown location parameter", `{"locations": [{"lat": 1, "lng": 2, "days": 3}]}`, `locations[0]: unknown parameter "days"`},
+		{"invalid location", `{"locations": [{"lat": 1, "lng": 2}, {"lat": 100, "lng": 2}]}`, "locations[1]: "},
+		{"invalid option value", `{"locations": [{"lat": 1, "lng": 2}], "options": {"azformat": "radians"}}`, "azformat"},
+		{"object option", `{"locations": [{"lat": 1, "lng": 2}], "options": {"days": {"n": 3}}}`, "must be a string"},
+		{"invalid filter time", `{"locations": [{"lat": 1, "lng": 2}], "filters": {"from": "6am"}}`, "filters: from"},
+		{"invalid weekday", `{"locations": [{"lat": 1, "lng": 2}], "filters": {"weekdays": ["funday"]}}`, "unknown weekday"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postCalendarConfig(t, tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected status 400, got %d", w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("expected %q in the error, got %q", tt.want, w.Body.String())
+			}
+		})
+	}
+
+	w := postCalendarConfig(t, `{"name": "`+strings.Repeat("x", maxConfigBody)+`"}`)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status 413 for a large configuration, got %d", w.Code)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PathCalendarHandler serves the calendar of /calendar.ics with the
+// coordinates and number of days in the path, /cal/{lat},{lng}.ics or
+// /cal/{lat},{lng}/{days}.ics, for older calendar clients that mangle query
+// strings in webcal URLs. Other parameters may follow as a query string and
+// are validated as for /calendar.ics. Paths spelling the numbers differently
+// (e.g., "+55.67610" or "007.ics") redirect to the canonical path.
+func PathCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	lat, lng, days, ok := parseCalendarPath(r.PathValue("location"), r.PathValue("file"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	q := r.URL.Query()
+	for _, p := range []paramDef{latParam, lngParam, daysParam} {
+		if q.Has(p.Name) {
+			http.Error(w, fmt.Sprintf("%s is given in the path and cannot be repeated in the query", p.Name), http.StatusBadRequest)
+			return
+		}
+	}
+	q.Set(latParam.Name, lat)
+	q.Set(lngParam.Name, lng)
+	if days != "" {
+		q.Set(daysParam.Name, days)
+	}
+	params, errMsg := parseCalendarQuery(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	canonicalDays := 0
+	if days != "" {
+		canonicalDays = params.days
+	}
+	if path := calendarPath(params.lat, params.lng, canonicalDays); path != r.URL.Path {
+		target := appPath(path)
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+		return
+	}
+
+	// Serve it as the equivalent query, which shares its cache entry
+	r = r.Clone(r.Context())
+	r.URL.RawQuery = q.Encode()
+	serveCalendar(w, r, params, calendarFormats[0])
+}
+
+// parseCalendarPath splits the segments of a path calendar URL into the
+// latitude, longitude, and days ("" if not given), which are left to
+// parseCalendarQuery to validate. file is "" for /cal/{lat},{lng}.ics.
+// Returns false if the path is not a calendar path.
+func parseCalendarPath(location, file string) (lat, lng, days string, ok bool) {
+	if file == "" {
+		location, ok = strings.CutSuffix(location, ".ics")
+	} else {
+		days, ok = strings.CutSuffix(file, ".ics")
+	}
+	if !ok {
+		return "", "", "", false
+	}
+	lat, lng, ok = strings.Cut(location, ",")
+	return lat, lng, days, ok
+}
+
+// calendarPath returns the canonical path of the calendar for the
+// coordinates and days, e.g. "/cal/55.6761,12.5683/14.ics", leaving out the
+// days if days is 0
+func calendarPath(lat, lng float64, days int) string {
+	path := "/cal/" + strconv.FormatFloat(lat, 'f', -1, 64) + "," + strconv.FormatFloat(lng, 'f', -1, 64)
+	if days == 0 {
+		return path + ".ics"
+	}
+	return path + "/" + strconv.Itoa(days) + ".ics"
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"calsun/config"
+	"calsun/services"
+)
+
+func TestPathCalendarHandler(t *testing.T) {
+	configureRoutes(t, false, false)
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	SetClock(services.FixedClock(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)))
+	mux := NewMux()
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		return w
+	}
+
+	query := get("/calendar.ics?lat=55.6761&lng=12.5683&days=3&name=Copenhagen")
+	path := get("/cal/55.6761,12.5683/3.ics?name=Copenhagen")
+	if path.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", path.Code, path.Body.String())
+	}
+	if path.Body.String() != query.Body.String() {
+		t.Error("expected the same calendar as the query string")
+	}
+	if path.Header().Get("X-Cache") != "HIT" {
+		t.Error("expected the path to share the query string's cache entry")
+	}
+
+	// Without days, the default range
+	query = get("/calendar.ics?lat=55.6761&lng=12.5683")
+	path = get("/cal/55.6761,12.5683.ics")
+	if path.Code != http.StatusOK || path.Body.String() != query.Body.String() {
+		t.Errorf("expected the default calendar, got %d: %s", path.Code, path.Body.String())
+	}
+	// Clients may escape the comma
+	if w := get("/cal/55.6761%2C12.5683.ics"); w.Code != http.StatusOK {
+		t.Errorf("expected an escaped comma to be accepted, got %d", w.Code)
+	}
+}
+
+func TestPathCalendarHandler_Redirect(t *testing.T) {
+	configureRoutes(t, false, false)
+	mux := NewMux()
+
+	tests := map[string]string{
+		"/cal/+55.67610,12.5683/3.ics":          "/cal/55.6761,12.5683/3.ics",
+		"/cal/55.6761,012.5683.ics":             "/cal/55.6761,12.5683.ics",
+		"/cal/55.6761,12.5683/007.ics?name=Cph": "/cal/55.6761,12.5683/7.ics?name=Cph",
+		"/cal/55.0,-0.5e1/3.ics":                "/cal/55,-5/3.ics",
+	}
+	for target, location := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != http.StatusMovedPermanently {
+			t.Errorf("%s: expected status 301, got %d", target, w.Code)
+		}
+		if got := w.Header().Get("Location"); got != location {
+			t.Errorf("%s: expected redirect to %s, got %s", target, location, got)
+		}
+	}
+}
+
+func TestPathCalendarHandler_BasePath(t *testing.T) {
+	original := cfg
+	t.Cleanup(func() { Configure(original) })
+	mounted := config.Default()
+	mounted.Server.BasePath = "/calsun"
+	Configure(mounted)
+
+	req := httptest.NewRequest("GET", "/cal/55.67610,12.5683.ics", nil)
+	req.SetPathValue("location", "55.67610,12.5683.ics")
+	w := httptest.NewRecorder()
+	PathCalendarHandler(w, req)
+
+	if got := w.Header().Get("Location"); got != "/calsun/cal/55.6761,12.5683.ics" {
+		t.Errorf("expected a redirect under the base path, got %q", got)
+	}
+}
+
+func TestPathCalendarHandler_Invalid(t *testing.T) {
+	configureRoutes(t, false, false)
+	mux := NewMux()
+
+	tests := []struct {
+		target string
+		status int
+	}{
+		{"/cal/55.6761,12.5683", http.StatusNotFound},
+		{"/cal/55.6761,12.5683/3", http.StatusNotFound},
+		{"/cal/55.6761.ics", http.StatusNotFound},
+		{"/cal/95,12.5683.ics", http.StatusBadRequest},
+		{"/cal/north,12.5683.ics", http.StatusBadRequest},
+		{"/cal/55.6761,12.5683/0.ics", http.StatusBadRequest},
+		{"/cal/55.6761,12.5683/many.ics", http.StatusBadRequest},
+		{"/cal/55.6761,12.5683.ics?lat=10", http.StatusBadRequest},
+		{"/cal/55.6761,12.5683/3.ics?days=5", http.StatusBadRequest},
+		{"/cal/55.6761,12.5683.ics?place=Copenhagen", http.StatusBadRequest},
+		{"/cal/55.6761,12.5683.ics?exclude=bogus", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.target, tt.status, w.Code)
+		}
+	}
+}
+
+func TestCalendarPath(t *testing.T) {
+	if got := calendarPath(55.6761, 12.5683, 14); got != "/cal/55.6761,12.5683/14.ics" {
+		t.Errorf("unexpected path %s", got)
+	}
+	if got := calendarPath(-33.8688, 151.2093, 0); got != "/cal/-33.8688,151.2093.ics" {
+		t.Errorf("unexpected path %s", got)
+	}
+}
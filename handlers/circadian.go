@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"calsun/services"
+)
+
+// circadianParamDefs lists the parameters accepted by CircadianHandler
+var circadianParamDefs = []paramDef{latParam, lngParam, nameParam, dateParam}
+
+// circadianCalendarParamDefs lists the parameters accepted by the circadian
+// lighting calendar
+var circadianCalendarParamDefs = []paramDef{latParam, lngParam, nameParam, daysParam, dateParam, homeTimezoneParam}
+
+// circadianResponse is the JSON body returned by CircadianHandler. Times are
+// in the location's timezone (RFC 3339).
+type circadianResponse struct {
+	Date        string                `json:"date"`
+	Location    string                `json:"location"`
+	Timezone    string                `json:"timezone"`
+	Current     *circadianTransition  `json:"current,omitempty"` // The setting now: the last transition, possibly yesterday's; omitted with date
+	Next        *circadianTransition  `json:"next,omitempty"`    // The next transition today; omitted with date
+	Transitions []circadianTransition `json:"transitions"`
+}
+
+// circadianTransition is a lighting transition, with field names matching
+// Home Assistant's light.turn_on service data
+type circadianTransition struct {
+	Phase           string    `json:"phase"`
+	Time            time.Time `json:"time"`
+	ColorTempKelvin int       `json:"color_temp_kelvin"`
+	BrightnessPct   int       `json:"brightness_pct"`
+	SunElevation    float64   `json:"sun_elevation"`
+	Rising          bool      `json:"rising"`
+}
+
+// CircadianHandler returns a day's circadian lighting schedule for a
+// location as JSON: color temperature and brightness transitions keyed to the
+// sun's elevation, for adaptive lighting automations
+func CircadianHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	tz := services.GetTimezone(params.lat, params.lng)
+	now := params.now.In(tz)
+	if !params.date.IsZero() {
+		now = params.date
+	}
+	noon := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, tz)
+	schedule := services.CircadianSchedule(params.lat, params.lng, noon)
+
+	resp := circadianResponse{
+		Date:        noon.Format("2006-01-02"),
+		Location:    locationName(params),
+		Timezone:    tz.String(),
+		Transitions: []circadianTransition{},
+	}
+	for _, t := range schedule {
+		resp.Transitions = append(resp.Transitions, newCircadianTransition(t, tz))
+	}
+	if params.date.IsZero() {
+		yesterday := services.CircadianSchedule(params.lat, params.lng, noon.AddDate(0, 0, -1))
+		for _, t := range append(yesterday, schedule...) {
+			transition := newCircadianTransition(t, tz)
+			if !t.Time.After(now) {
+				resp.Current = &transition
+			} else if resp.Next == nil {
+				resp.Next = &transition
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// CircadianCalendarHandler generates an iCal calendar of circadian lighting
+// transitions, for automations that trigger on calendar events
+func CircadianCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	opts := services.CalendarOptions{
+		Lat:          params.lat,
+		Lng:          params.lng,
+		Location:     locationName(params),
+		Timezone:     services.GetTimezone(params.lat, params.lng),
+		Precision:    params.precision,
+		HomeTimezone: params.homeTimezone,
+	}
+
+	calName := "Circadian Lighting"
+	if params.name != "" {
+		calName = fmt.Sprintf("Circadian Lighting - %s", params.name)
+	}
+	cal := newCalendar(calName)
+
+	start, days := params.eventRange()
+	for _, event := range params.onDate(services.BuildCircadianEvents(opts, start, days)) {
+		cal.AddVEvent(toVEvent(event, params.now))
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", attachment(params.filename, "calsun-circadian.ics"))
+	w.Write([]byte(cal.Serialize(icsNewLine)))
+}
+
+// newCircadianTransition returns a transition with its time in tz
+func newCircadianTransition(t services.CircadianTransition, tz *time.Location) circadianTransition {
+	return circadianTransition{
+		Phase:           t.Phase,
+		Time:            t.Time.In(tz).Truncate(time.Second),
+		ColorTempKelvin: t.Kelvin,
+		BrightnessPct:   t.Brightness,
+		SunElevation:    t.Elevation,
+		Rising:          t.Rising,
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+func TestCircadianHandler(t *testing.T) {
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	SetClock(services.FixedClock(time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC)))
+
+	req := httptest.NewRequest("GET", "/api/circadian?lat=55.6761&lng=12.5683&name=Copenhagen", nil)
+	w := httptest.NewRecorder()
+
+	CircadianHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp circadianResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Date != "2024-12-21" || resp.Location != "Copenhagen" || resp.Timezone != "Europe/Copenhagen" {
+		t.Errorf("unexpected day: %+v", resp)
+	}
+	if len(resp.Transitions) != 7 || resp.Transitions[0].Phase != "wake" || resp.Transitions[0].Time.Format("15:04") != "07:50" {
+		t.Fatalf("expected 7 transitions from the wake light at 07:50, got %+v", resp.Transitions)
+	}
+	// At 13:00 the lights are in daylight until the evening transition
+	if resp.Current == nil || resp.Current.Phase != "daylight" || resp.Current.ColorTempKelvin != 5500 || resp.Current.BrightnessPct != 100 {
+		t.Errorf("expected daylight now, got %+v", resp.Current)
+	}
+	if resp.Next == nil || resp.Next.Phase != "evening" || !resp.Next.Time.After(resp.Current.Time) {
+		t.Errorf("expected the evening transition next, got %+v", resp.Next)
+	}
+	if !strings.Contains(w.Body.String(), `"color_temp_kelvin":5500,"brightness_pct":100`) {
+		t.Error("expected Home Assistant service data field names")
+	}
+
+	// Before the wake light, yesterday's night light holds
+	SetClock(services.FixedClock(time.Date(2024, 12, 21, 5, 0, 0, 0, time.UTC)))
+	w = httptest.NewRecorder()
+	CircadianHandler(w, req)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Current == nil || resp.Current.Phase != "night" || resp.Current.Time.Day() != 20 || resp.Next.Phase != "wake" {
+		t.Errorf("expected yesterday's night light before waking, got %+v then %+v", resp.Current, resp.Next)
+	}
+}
+
+func TestCircadianHandler_Date(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/circadian?lat=55.6761&lng=12.5683&date=2024-06-21", nil)
+	w := httptest.NewRecorder()
+
+	CircadianHandler(w, req)
+
+	var resp circadianResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	// No nautical dusk at midsummer, so no night light
+	if resp.Date != "2024-06-21" || len(resp.Transitions) != 6 || resp.Current != nil || resp.Next != nil {
+		t.Errorf("expected 6 transitions and no current setting, got %+v", resp)
+	}
+
+	for _, url := range []string{"/api/circadian?lng=12.5683", "/api/circadian?lat=55.6761&lng=12.5683&date=June"} {
+		w := httptest.NewRecorder()
+		CircadianHandler(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", url, w.Code)
+		}
+	}
+}
+
+func TestCircadianCalendarHandler(t *testing.T) {
+	req := httptest.NewRequest("GET", "/circadian.ics?lat=55.6761&lng=12.5683&name=Copenhagen&date=2024-12-21", nil)
+	w := httptest.NewRecorder()
+
+	CircadianCalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), "calsun-circadian.ics") {
+		t.Errorf("unexpected Content-Disposition %q", w.Header().Get("Content-Disposition"))
+	}
+	if report := lintICS(w.Body.Bytes()); !report.Valid {
+		t.Errorf("expected a valid calendar, got %+v", report.Errors)
+	}
+	body := unfoldICal(w.Body.String())
+	for _, want := range []string{"X-WR-CALNAME:Circadian Lighting - Copenhagen", "SUMMARY:Wake light 07:50\\, 2700K 40%", "SUMMARY:Night light ", "Color temperature: 1800K"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q", want)
+		}
+	}
+	if n := strings.Count(body, "BEGIN:VEVENT"); n != 7 {
+		t.Errorf("expected 7 transitions on the date, got %d", n)
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"calsun/services"
+)
+
+// nowParam fixes the time output is generated for, so tests can compare
+// calendars byte for byte. Only accepted in debug mode, and not listed by the
+// options endpoint.
+var nowParam = paramDef{
+	Name:        "now",
+	Type:        paramTypeString,
+	Description: "Time to generate output for, as RFC 3339 (e.g., 2024-03-01T12:00:00Z) or a date (midnight UTC); requires CALSUN_DEBUG",
+}
+
+// clock tells the handlers the current time
+var clock services.Clock = time.Now
+
+// SetClock sets the clock the handlers generate output for
+func SetClock(c services.Clock) {
+	clock = c
+}
+
+// requestNow returns the time a query's output is generated for: the
+// clock's time, or the now parameter in debug mode. Reports whether the
+// parameter overrode the clock, and an error message if it is invalid or
+// not allowed.
+func requestNow(q url.Values) (now time.Time, overridden bool, errMsg string) {
+	str := q.Get(nowParam.Name)
+	if str == "" {
+		return clock(), false, ""
+	}
+	if !cfg.Debug {
+		return time.Time{}, false, "the now parameter is only accepted in debug mode"
+	}
+
+	// An unescaped "+" in a UTC offset arrives as a space
+	str = strings.ReplaceAll(str, " ", "+")
+	if now, err := time.Parse(time.RFC3339, str); err == nil {
+		return now, true, ""
+	}
+	if now, err := time.Parse(time.DateOnly, str); err == nil {
+		return now, true, ""
+	}
+	return time.Time{}, false, "now must be an RFC 3339 time (e.g., 2024-03-01T12:00:00Z) or a date (2024-03-01)"
+}
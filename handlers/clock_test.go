@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"calsun/config"
+	"calsun/services"
+)
+
+// enableDebug turns on debug mode for the duration of a test
+func enableDebug(t *testing.T) {
+	original := cfg
+	t.Cleanup(func() { Configure(original) })
+	c := config.Default()
+	c.Debug = true
+	Configure(c)
+}
+
+func TestRequestNow(t *testing.T) {
+	enableDebug(t)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2024-03-01T12:00:00Z", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{"2024-03-01T12:00:00 01:00", time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC)},
+		{"2024-03-01", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		now, fixed, errMsg := requestNow(url.Values{"now": {tt.value}})
+		if errMsg != "" || !fixed || !now.Equal(tt.want) {
+			t.Errorf("requestNow(%q) = %s, %v, %q, want %s", tt.value, now, fixed, errMsg, tt.want)
+		}
+	}
+
+	if _, _, errMsg := requestNow(url.Values{"now": {"yesterday"}}); errMsg == "" {
+		t.Error("expected an error for an invalid time")
+	}
+}
+
+func TestRequestNow_RequiresDebug(t *testing.T) {
+	if _, _, errMsg := requestNow(url.Values{"now": {"2024-03-01"}}); errMsg == "" {
+		t.Error("expected the now parameter to be rejected outside debug mode")
+	}
+
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&now=2024-03-01", nil)
+	w := httptest.NewRecorder()
+	CalendarHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestCalendarHandler_FixedNow(t *testing.T) {
+	enableDebug(t)
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+
+	// The wall clock moves between the requests; the output must not
+	get := func(wall time.Time) *httptest.ResponseRecorder {
+		SetClock(services.FixedClock(wall))
+		req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&days=3&now=2024-03-01T12:00:00Z", nil)
+		w := httptest.NewRecorder()
+		CalendarHandler(w, req)
+		return w
+	}
+
+	first := get(time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC))
+	second := get(time.Date(2025, 1, 1, 9, 0, 5, 0, time.UTC))
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", first.Code, first.Body.String())
+	}
+	if first.Body.String() != second.Body.String() {
+		t.Error("expected byte-identical calendars for the same time")
+	}
+	if !strings.Contains(first.Body.String(), "DTSTAMP:20240301T120000Z") {
+		t.Error("expected events to be stamped with the fixed time")
+	}
+	if second.Header().Get("X-Cache") != "MISS" {
+		t.Error("calendars for a fixed time should not be cached")
+	}
+	// The range starts 14 days before the fixed time
+	if !strings.Contains(first.Body.String(), "DTSTART:20240216T") {
+		t.Error("expected events from the fixed time's range")
+	}
+}
+
+func TestSetClock(t *testing.T) {
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	SetClock(services.FixedClock(time.Date(2024, 6, 21, 10, 0, 0, 0, time.UTC)))
+
+	req := httptest.NewRequest("GET", "/api/today?lat=55.6761&lng=12.5683", nil)
+	w := httptest.NewRecorder()
+	TodayHandler(w, req)
+
+	if !strings.Contains(w.Body.String(), `"date":"2024-06-21"`) {
+		t.Errorf("expected today to follow the clock, got %s", w.Body.String())
+	}
+}
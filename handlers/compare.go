@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"calsun/config"
+	"calsun/services"
+)
+
+// secondLocationSuffix marks the location parameters of the second location
+// (e.g., lat2, place2)
+const secondLocationSuffix = "2"
+
+// secondLocationParam returns the definition of a location parameter for the
+// second location
+func secondLocationParam(p paramDef) paramDef {
+	p.Name += secondLocationSuffix
+	p.Description += ", for the second location"
+	return p
+}
+
+// compareParamDefs lists the parameters accepted by the comparison
+// endpoints. The second location also accepts place2, geohash2, pluscode2,
+// utm2, and mgrs2.
+var compareParamDefs = []paramDef{
+	latParam,
+	lngParam,
+	nameParam,
+	secondLocationParam(latParam),
+	secondLocationParam(lngParam),
+	secondLocationParam(nameParam),
+	dateParam,
+}
+
+var compareTemplate *template.Template
+
+func init() {
+	var err error
+	compareTemplate, err = template.ParseFS(templatesFS, "templates/compare.html")
+	if err != nil {
+		panic("failed to parse compare template: " + err.Error())
+	}
+}
+
+// compareResponse is the JSON body returned by CompareHandler. Differences
+// are of the second location relative to the first.
+type compareResponse struct {
+	Date       string             `json:"date"`
+	Locations  [2]compareLocation `json:"locations"`
+	Difference compareDifference  `json:"difference"`
+}
+
+// compareLocation is one location's sun times. Times are RFC 3339 in the
+// location's timezone and null when the sun does not rise or set.
+type compareLocation struct {
+	Location         string     `json:"location"`
+	Lat              float64    `json:"lat"`
+	Lng              float64    `json:"lng"`
+	Timezone         string     `json:"timezone"`
+	Sunrise          *time.Time `json:"sunrise"`
+	Sunset           *time.Time `json:"sunset"`
+	DayLength        string     `json:"day_length,omitempty"`
+	DayLengthSeconds *int       `json:"day_length_seconds,omitempty"`
+}
+
+// compareDifference holds the differences in local clock time and day
+// length, left out when either location lacks the value
+type compareDifference struct {
+	Sunrise          string `json:"sunrise,omitempty"` // e.g., "38m later"
+	SunriseSeconds   *int   `json:"sunrise_seconds,omitempty"`
+	Sunset           string `json:"sunset,omitempty"`
+	SunsetSeconds    *int   `json:"sunset_seconds,omitempty"`
+	DayLength        string `json:"day_length,omitempty"` // e.g., "2h 5m longer"
+	DayLengthSeconds *int   `json:"day_length_seconds,omitempty"`
+}
+
+// compareData is the template data for the comparison page
+type compareData struct {
+	Site      config.Site
+	BasePath  string
+	Date      string
+	Locations [2]compareLocation
+	Rows      []compareRow
+}
+
+// compareRow is a row of the comparison table
+type compareRow struct {
+	Label      string
+	Values     [2]string
+	Difference string
+}
+
+// CompareHandler compares the sunrise, sunset, and day length of two
+// locations on a date (today at the first location by default) as JSON
+func CompareHandler(w http.ResponseWriter, r *http.Request) {
+	resp, errMsg := buildComparison(r.URL.Query())
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ComparePageHandler shows the comparison of CompareHandler as an HTML page
+func ComparePageHandler(w http.ResponseWriter, r *http.Request) {
+	resp, errMsg := buildComparison(r.URL.Query())
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	data := compareData{
+		Site:      cfg.Site,
+		BasePath:  cfg.Server.BasePath,
+		Date:      resp.Date,
+		Locations: resp.Locations,
+		Rows: []compareRow{
+			{"Sunrise", clockValues(resp.Locations, func(l compareLocation) *time.Time { return l.Sunrise }), resp.Difference.Sunrise},
+			{"Sunset", clockValues(resp.Locations, func(l compareLocation) *time.Time { return l.Sunset }), resp.Difference.Sunset},
+			{"Day length", [2]string{orDash(resp.Locations[0].DayLength), orDash(resp.Locations[1].DayLength)}, resp.Difference.DayLength},
+		},
+	}
+	for i := range data.Rows {
+		data.Rows[i].Difference = orDash(data.Rows[i].Difference)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := compareTemplate.Execute(w, data); err != nil {
+		log.Printf("render compare page: %v", err)
+	}
+}
+
+// buildComparison parses both locations from q and compares their sun times
+func buildComparison(q url.Values) (*compareResponse, string) {
+	first, errMsg := parseCalendarQuery(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+	second, errMsg := parseSecondLocation(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	tzA := services.GetTimezone(first.lat, first.lng)
+	tzB := services.GetTimezone(second.lat, second.lng)
+	date := first.now.In(tzA)
+	if !first.date.IsZero() {
+		date = first.date
+	}
+	// The same calendar date at both locations
+	dayA := services.GetSunTimes(first.lat, first.lng, time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, tzA))
+	dayB := services.GetSunTimes(second.lat, second.lng, time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, tzB))
+
+	diff := services.CompareSunTimes(dayA, tzA, dayB, tzB)
+	resp := &compareResponse{
+		Date:      date.Format("2006-01-02"),
+		Locations: [2]compareLocation{newCompareLocation(first, dayA, tzA), newCompareLocation(second, dayB, tzB)},
+	}
+	if diff.Sunrise != nil {
+		resp.Difference.Sunrise = services.FormatDifference(*diff.Sunrise, "later", "earlier")
+		resp.Difference.SunriseSeconds = durationSeconds(*diff.Sunrise)
+	}
+	if diff.Sunset != nil {
+		resp.Difference.Sunset = services.FormatDifference(*diff.Sunset, "later", "earlier")
+		resp.Difference.SunsetSeconds = durationSeconds(*diff.Sunset)
+	}
+	if diff.DayLength != nil {
+		resp.Difference.DayLength = services.FormatDifference(*diff.DayLength, "longer", "shorter")
+		resp.Difference.DayLengthSeconds = durationSeconds(*diff.DayLength)
+	}
+	return resp, ""
+}
+
+// parseSecondLocation parses the location parameters with the second
+// location's suffix (lat2, lng2, name2, place2, ...)
+func parseSecondLocation(q url.Values) (*calendarParams, string) {
+	second := url.Values{}
+	for _, p := range append([]paramDef{latParam, lngParam, nameParam}, locationParams...) {
+		if v, ok := q[p.Name+secondLocationSuffix]; ok {
+			second[p.Name] = v
+		}
+	}
+	if len(second) == 0 || (len(second) == 1 && second.Has(nameParam.Name)) {
+		return nil, "a second location is required: lat2 and lng2 (or one of place2, geohash2, pluscode2, utm2, or mgrs2)"
+	}
+	params, errMsg := parseCalendarQuery(second)
+	if errMsg != "" {
+		return nil, "second location: " + errMsg
+	}
+	return params, ""
+}
+
+// newCompareLocation returns a location's sun times for the comparison
+func newCompareLocation(params *calendarParams, day services.DaySunTimes, tz *time.Location) compareLocation {
+	loc := compareLocation{
+		Location: locationName(params),
+		Lat:      params.lat,
+		Lng:      params.lng,
+		Timezone: tz.String(),
+		Sunrise:  localEventTime(day.Sunrise, tz),
+		Sunset:   localEventTime(day.Sunset, tz),
+	}
+	if dayLength, ok := day.DayLength(); ok {
+		loc.DayLength = services.FormatDuration(dayLength)
+		loc.DayLengthSeconds = durationSeconds(dayLength)
+	}
+	return loc
+}
+
+// durationSeconds returns d in whole seconds
+func durationSeconds(d time.Duration) *int {
+	seconds := int(d.Seconds())
+	return &seconds
+}
+
+// clockValues formats a time of both locations as local clock times
+func clockValues(locations [2]compareLocation, value func(compareLocation) *time.Time) [2]string {
+	var values [2]string
+	for i, loc := range locations {
+		values[i] = "—"
+		if t := value(loc); t != nil {
+			values[i] = t.Format("15:04")
+		}
+	}
+	return values
+}
+
+// orDash returns s, or a dash if s is empty
+func orDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
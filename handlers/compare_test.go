@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompareHandler(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/compare?lat=55.6761&lng=12.5683&name=Copenhagen&lat2=51.5074&lng2=-0.1278&name2=London&date=2024-06-21", nil)
+	w := httptest.NewRecorder()
+
+	CompareHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp compareResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Date != "2024-06-21" {
+		t.Errorf("expected date 2024-06-21, got %s", resp.Date)
+	}
+	if resp.Locations[0].Location != "Copenhagen" || resp.Locations[1].Location != "London" {
+		t.Errorf("unexpected locations %+v", resp.Locations)
+	}
+	if resp.Locations[1].Timezone != "Europe/London" || resp.Locations[1].Sunrise == nil {
+		t.Errorf("unexpected second location %+v", resp.Locations[1])
+	}
+	if !strings.HasSuffix(resp.Difference.Sunrise, "later") || !strings.HasSuffix(resp.Difference.Sunset, "earlier") || !strings.HasSuffix(resp.Difference.DayLength, "shorter") {
+		t.Errorf("unexpected differences %+v", resp.Difference)
+	}
+	if resp.Difference.DayLengthSeconds == nil || *resp.Difference.DayLengthSeconds >= 0 {
+		t.Errorf("expected a negative day length difference, got %v", resp.Difference.DayLengthSeconds)
+	}
+}
+
+func TestCompareHandler_PolarDay(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/compare?lat=55.6761&lng=12.5683&lat2=78.2232&lng2=15.6267&date=2024-06-21", nil)
+	w := httptest.NewRecorder()
+
+	CompareHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"sunrise":null`) {
+		t.Errorf("expected a null sunrise for the polar day, got %s", body)
+	}
+	if strings.Contains(body, `"sunrise_seconds"`) {
+		t.Errorf("expected no sunrise difference, got %s", body)
+	}
+}
+
+func TestCompareHandler_MissingSecondLocation(t *testing.T) {
+	for _, query := range []string{
+		"lat=55.6761&lng=12.5683",
+		"lat=55.6761&lng=12.5683&name2=London",
+	} {
+		req := httptest.NewRequest("GET", "/api/compare?"+query, nil)
+		w := httptest.NewRecorder()
+
+		CompareHandler(w, req)
+
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "second location is required") {
+			t.Errorf("%s: expected 400 for a missing second location, got %d: %s", query, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestCompareHandler_InvalidSecondLocation(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/compare?lat=55.6761&lng=12.5683&lat2=95&lng2=0", nil)
+	w := httptest.NewRecorder()
+
+	CompareHandler(w, req)
+
+	if w.Code != http.StatusBadRequest || !strings.HasPrefix(w.Body.String(), "second location: ") {
+		t.Errorf("expected 400 for an invalid second location, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestComparePageHandler(t *testing.T) {
+	req := httptest.NewRequest("GET", "/compare?lat=55.6761&lng=12.5683&name=Copenhagen&lat2=51.5074&lng2=-0.1278&name2=London&date=2024-06-21", nil)
+	w := httptest.NewRecorder()
+
+	ComparePageHandler(w, req)
+
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+		t.Fatalf("expected HTML, got %s", contentType)
+	}
+	body := w.Body.String()
+	for _, want := range []string{"Copenhagen vs London", "Europe/London", "Day length", "shorter"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	}
+}
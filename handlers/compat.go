@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"calsun/services"
+)
+
+var compatParam = paramDef{
+	Name:        "compat",
+	Type:        paramTypeEnum,
+	Values:      []string{"outlook", "google"},
+	Description: "Adjust the iCal feed for a calendar app's quirks: outlook folds lines conservatively, leaves out properties Outlook doesn't support, and shortens long descriptions; google leaves out properties Google Calendar ignores and covers at least 2 days ahead between its refreshes",
+	Advanced:    true,
+}
+
+// icsCompat is a calendar app's quirks profile, applied to iCal calendars
+// after serialization
+type icsCompat struct {
+	// dropProperties lists properties removed from the calendar and events
+	dropProperties []string
+	// foldOctets is the longest content line in octets, excluding the CRLF
+	foldOctets int
+	// maxDescription bounds descriptions in characters, cut at a line break
+	maxDescription int
+	// safeUIDs replaces characters other than letters, digits, and "@._-"
+	// in UIDs
+	safeUIDs bool
+	// minDays is the fewest days ahead the calendar covers, for apps that
+	// refresh subscriptions rarely
+	minDays int
+}
+
+// icsCompats holds the quirks profiles by compat value
+var icsCompats = map[string]icsCompat{
+	// Outlook ignores or mangles RFC 7986 properties (it names calendars by
+	// X-WR-CALNAME), shows a literal "\n" when a fold splits an escape, and
+	// cuts off long descriptions of subscribed events
+	"outlook": {
+		dropProperties: []string{"NAME", "COLOR", "REFRESH-INTERVAL", "IMAGE", "CONFERENCE", "SOURCE"},
+		foldOctets:     74,
+		maxDescription: 1000,
+		safeUIDs:       true,
+	},
+	// Google Calendar refreshes subscriptions only every 12 to 24 hours,
+	// ignores HTML descriptions and RFC 7986 properties (X-ALT-DESC alone
+	// doubles a feed's size, and Google gives up on large feeds), and reads
+	// times best in UTC, which CalSun already writes
+	"google": {
+		dropProperties: []string{"X-ALT-DESC", "NAME", "COLOR", "REFRESH-INTERVAL", "X-PUBLISHED-TTL", "IMAGE", "CONFERENCE", "SOURCE"},
+		foldOctets:     75,
+		minDays:        2,
+	},
+}
+
+// wrap returns serialize adjusted for the profile: descriptions are
+// shortened before, and lines rewritten after, serialization
+func (c icsCompat) wrap(serialize serializer) serializer {
+	return func(name string, tz *time.Location, events []services.CalendarEvent, stamp time.Time) ([]byte, error) {
+		if c.maxDescription > 0 {
+			events = slices.Clone(events)
+			for i := range events {
+				events[i].Description = truncateDescription(events[i].Description, c.maxDescription)
+			}
+		}
+		body, err := serialize(name, tz, events, stamp)
+		if err != nil {
+			return nil, err
+		}
+		return c.rewrite(body), nil
+	}
+}
+
+// rewrite unfolds the calendar's content lines, drops and fixes properties,
+// and folds the lines again, ending each with CRLF
+func (c icsCompat) rewrite(body []byte) []byte {
+	var out bytes.Buffer
+	for _, line := range unfoldContentLines(string(body)) {
+		name, _, _ := strings.Cut(line, ":")
+		name, _, _ = strings.Cut(name, ";")
+		if slices.Contains(c.dropProperties, strings.ToUpper(name)) {
+			continue
+		}
+		if c.safeUIDs && strings.EqualFold(name, "UID") {
+			line = "UID:" + safeUID(line[len(name)+1:])
+		}
+		foldContentLine(&out, line, c.foldOctets)
+	}
+	return out.Bytes()
+}
+
+// unfoldContentLines splits serialized iCal into content lines, joining
+// folded lines and accepting bare LF line endings
+func unfoldContentLines(ics string) []string {
+	var lines []string
+	for _, raw := range strings.Split(strings.ReplaceAll(ics, "\r\n", "\n"), "\n") {
+		raw = strings.TrimSuffix(raw, "\r")
+		if (strings.HasPrefix(raw, " ") || strings.HasPrefix(raw, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += raw[1:]
+			continue
+		}
+		if raw != "" {
+			lines = append(lines, raw)
+		}
+	}
+	return lines
+}
+
+// foldContentLine writes line folded to at most octets octets per line
+// (RFC 5545 section 3.1), ending each with CRLF. Folds never split a UTF-8
+// character or a backslash escape.
+func foldContentLine(out *bytes.Buffer, line string, octets int) {
+	limit := octets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		// An odd run of backslashes before the cut ends in an escape's first half
+		backslashes := 0
+		for i := cut - 1; i >= 0 && line[i] == '\\'; i-- {
+			backslashes++
+		}
+		if backslashes%2 == 1 {
+			cut--
+		}
+		out.WriteString(line[:cut])
+		out.WriteString("\r\n ")
+		line = line[cut:]
+		limit = octets - 1 // Continuation lines start with a space
+	}
+	out.WriteString(line)
+	out.WriteString("\r\n")
+}
+
+// truncateDescription shortens a description to at most limit characters,
+// cutting at the last line break that fits and marking the cut with "…"
+func truncateDescription(description string, limit int) string {
+	if utf8.RuneCountInString(description) <= limit {
+		return description
+	}
+	runes := []rune(description)[:limit-2] // Room for "\n…"
+	cut := string(runes)
+	if i := strings.LastIndex(cut, "\n"); i > 0 {
+		cut = strings.TrimRight(cut[:i], "\n")
+	}
+	return cut + "\n…"
+}
+
+// safeUID replaces the characters of a UID other than ASCII letters, digits,
+// and "@._-" with "-"
+func safeUID(uid string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("@._-", r) {
+			return r
+		}
+		return '-'
+	}, uid)
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"calsun/config"
+)
+
+func TestFoldContentLine(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("Sunrise ☀ ", 10) + strings.Repeat(`a\n`, 40)
+
+	var out bytes.Buffer
+	foldContentLine(&out, line, 74)
+	folded := out.String()
+
+	if !strings.HasSuffix(folded, "\r\n") {
+		t.Error("expected the line to end with CRLF")
+	}
+	physical := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n")
+	if len(physical) < 2 {
+		t.Fatalf("expected the line folded, got %q", folded)
+	}
+	for i, l := range physical {
+		if len(l) > 74 {
+			t.Errorf("line %d is %d octets, want at most 74", i, len(l))
+		}
+		if !utf8.ValidString(l) {
+			t.Errorf("line %d splits a UTF-8 character: %q", i, l)
+		}
+		if i > 0 && !strings.HasPrefix(l, " ") {
+			t.Errorf("continuation line %d should start with a space: %q", i, l)
+		}
+		if trailing := len(l) - len(strings.TrimRight(l, `\`)); trailing%2 == 1 {
+			t.Errorf("line %d splits a backslash escape: %q", i, l)
+		}
+	}
+	if got := strings.Join(unfoldContentLines(folded), ""); got != line {
+		t.Errorf("unfolding the folded line should give it back, got %q", got)
+	}
+}
+
+func TestTruncateDescription(t *testing.T) {
+	if got := truncateDescription("Short", 10); got != "Short" {
+		t.Errorf("expected a short description unchanged, got %q", got)
+	}
+
+	got := truncateDescription("First line\nSecond line\nThird line", 30)
+	if got != "First line\nSecond line\n…" {
+		t.Errorf("expected the description cut at a line break, got %q", got)
+	}
+	if utf8.RuneCountInString(got) > 30 {
+		t.Errorf("expected at most 30 characters, got %d", utf8.RuneCountInString(got))
+	}
+
+	got = truncateDescription(strings.Repeat("x", 50), 20)
+	if utf8.RuneCountInString(got) > 20 || !strings.HasSuffix(got, "…") {
+		t.Errorf("expected a single line cut to 20 characters, got %q", got)
+	}
+}
+
+func TestSafeUID(t *testing.T) {
+	if got := safeUID("sunrise-2024-06-21-55.68-12.57@calsun"); got != "sunrise-2024-06-21-55.68-12.57@calsun" {
+		t.Errorf("expected a safe UID unchanged, got %q", got)
+	}
+	if got := safeUID("window:Golden hour/1+ø@calsun"); got != "window-Golden-hour-1--@calsun" {
+		t.Errorf("expected unsafe characters replaced, got %q", got)
+	}
+}
+
+func TestICSCompat_Rewrite(t *testing.T) {
+	compat := icsCompats["outlook"]
+	body := "BEGIN:VCALENDAR\nNAME:Home\nX-WR-CALNAME:Home\nCOLOR:tomato\nBEGIN:VEVENT\nUID:a b@calsun\nSUMMARY:Sunrise\nEND:VEVENT\nEND:VCALENDAR\n"
+
+	got := string(compat.rewrite([]byte(body)))
+
+	want := "BEGIN:VCALENDAR\r\nX-WR-CALNAME:Home\r\nBEGIN:VEVENT\r\nUID:a-b@calsun\r\nSUMMARY:Sunrise\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	if got != want {
+		t.Errorf("rewrite() = %q, want %q", got, want)
+	}
+}
+
+func TestCalendarHandler_CompatOutlook(t *testing.T) {
+	w := httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&days=2&name=K%C3%B8benhavn&compat=outlook", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+
+	if strings.Contains(strings.ReplaceAll(body, "\r\n", ""), "\n") {
+		t.Error("expected every line to end with CRLF")
+	}
+	for _, line := range strings.Split(body, "\r\n") {
+		if len(line) > 74 {
+			t.Errorf("expected lines of at most 74 octets, got %d: %q", len(line), line)
+		}
+	}
+	unfolded := unfoldICal(body)
+	if strings.Contains(unfolded, "\r\nNAME:") {
+		t.Error("expected the RFC 7986 NAME property left out")
+	}
+	if !strings.Contains(unfolded, "X-WR-CALNAME:Sun Times - København") {
+		t.Error("expected the calendar name in X-WR-CALNAME")
+	}
+	if !strings.Contains(unfolded, "SUMMARY:Sunrise") {
+		t.Error("expected the events kept")
+	}
+
+	w = httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&days=2&compat=lotus", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown compat value, got %d", w.Code)
+	}
+}
+
+func TestCalendarHandler_CompatGoogle(t *testing.T) {
+	w := httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&days=1&events=sunrise&compat=google", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+
+	if strings.Contains(strings.ReplaceAll(body, "\r\n", ""), "\n") {
+		t.Error("expected every line to end with CRLF")
+	}
+	unfolded := unfoldICal(body)
+	if strings.Contains(unfolded, "X-ALT-DESC") || strings.Contains(unfolded, "\r\nNAME:") {
+		t.Error("expected the properties Google ignores left out")
+	}
+	if !strings.Contains(unfolded, "DESCRIPTION:") || !strings.Contains(unfolded, "X-WR-CALNAME:") {
+		t.Error("expected the plain description and calendar name kept")
+	}
+	for _, line := range strings.Split(unfolded, "\r\n") {
+		if strings.HasPrefix(line, "DTSTART:") && !strings.HasSuffix(line, "Z") {
+			t.Errorf("expected event times in UTC, got %q", line)
+		}
+	}
+
+	// Google refreshes rarely, so the feed covers at least 2 days ahead
+	plain := unfoldICal(calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&days=1&events=sunrise"))
+	if got, want := strings.Count(unfolded, "BEGIN:VEVENT"), strings.Count(plain, "BEGIN:VEVENT")+1; got != want {
+		t.Errorf("expected %d sunrises with compat=google, got %d", want, got)
+	}
+}
+
+func TestCalendarHandler_CompatOnlyICS(t *testing.T) {
+	// Other formats keep the requested days
+	get := func(target string) string {
+		w := httptest.NewRecorder()
+		UnifiedCalendarHandler(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		return w.Body.String()
+	}
+	plain := get("/calendar?lat=55.6761&lng=12.5683&days=1&events=sunrise&format=json")
+	if google := get("/calendar?lat=55.6761&lng=12.5683&days=1&events=sunrise&format=json&compat=google"); google != plain {
+		t.Error("expected compat=google to leave JSON output unchanged")
+	}
+
+	// The minimum stays within a configured maximum below it
+	original := cfg
+	t.Cleanup(func() { Configure(original) })
+	c := config.Default()
+	c.MaxDays = 1
+	Configure(c)
+	params := &calendarParams{days: 1, compat: "google"}
+	params.withICSCompat()
+	if params.days != 1 {
+		t.Errorf("expected days capped at the configured 1, got %d", params.days)
+	}
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"cmp"
+
+	"calsun/config"
+	"calsun/services"
+)
+
+// cfg is the active server configuration, set by Configure at startup
+var cfg = config.Default()
+
+// calendarCache holds serialized calendar responses, sized by the
+// configuration
+var calendarCache = newResponseCache(cfg.CacheSize)
+
+// Configure sets the server configuration used by the handlers
+func Configure(c *config.Config) {
+	cfg = c
+	calendarCache = newResponseCache(c.CacheSize)
+	terrain = services.NewOpenElevation(cmp.Or(c.Terrain.URL, services.OpenElevationURL))
+	horizons = services.NewHorizonProfiles(terrain)
+}
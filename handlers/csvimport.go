@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"time"
+
+	"calsun/services"
+)
+
+// googleCSVHeader lists the columns of Google Calendar's CSV import schema
+var googleCSVHeader = []string{"Subject", "Start Date", "Start Time", "End Date", "End Time", "All Day Event", "Description", "Location", "Private"}
+
+// serializeGoogleCSV writes the events in Google Calendar's CSV import schema,
+// for bulk imports of a fixed range. Google reads dates as MM/DD/YYYY and
+// times as 12-hour clock times in the calendar's timezone, so times are
+// local to the location. All-day events have no times and end on their last
+// day, as Google's end dates are inclusive.
+func serializeGoogleCSV(name string, tz *time.Location, events []services.CalendarEvent, _ time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	cw.Write(googleCSVHeader)
+	for _, event := range events {
+		start, end := event.Start.In(tz), event.End.In(tz)
+		row := []string{event.Summary, start.Format("01/02/2006"), start.Format("03:04 PM"), end.Format("01/02/2006"), end.Format("03:04 PM"), "False", event.Description, event.Location, "False"}
+		if event.AllDay {
+			// Start and End are local midnights already
+			last := event.End.AddDate(0, 0, -1)
+			row[1], row[2], row[3], row[4], row[5] = event.Start.Format("01/02/2006"), "", last.Format("01/02/2006"), "", "True"
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return buf.Bytes(), cw.Error()
+}
+
+// outlookCSVHeader lists the columns of Outlook's CSV import schema that
+// events fill. Outlook matches columns by name, with its own capitalization
+// ("All day event").
+var outlookCSVHeader = []string{"Subject", "Start Date", "Start Time", "End Date", "End Time", "All day event", "Reminder on/off", "Reminder Date", "Reminder Time", "Categories", "Description", "Location", "Priority", "Private", "Sensitivity", "Show time as"}
+
+// outlookShowAsFree is the "Show time as" value of free time (Outlook's
+// OlBusyStatus), so sun events don't block the calendar
+const outlookShowAsFree = "0"
+
+// serializeOutlookCSV writes the events in Outlook's CSV import schema.
+// Dates are M/D/YYYY and times 12-hour with seconds, local to the location.
+// All-day events start and end at midnight, with the end on the day after,
+// as Outlook's end dates are exclusive. Reminders are turned off, since
+// Outlook otherwise adds its default reminder to every imported event.
+func serializeOutlookCSV(name string, tz *time.Location, events []services.CalendarEvent, _ time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	cw.Write(outlookCSVHeader)
+	for _, event := range events {
+		start, end := event.Start.In(tz), event.End.In(tz)
+		allDay := "False"
+		if event.AllDay {
+			// Start and End are local midnights already
+			start, end, allDay = event.Start, event.End, "True"
+		}
+		cw.Write([]string{
+			event.Summary,
+			start.Format("1/2/2006"),
+			start.Format("3:04:05 PM"),
+			end.Format("1/2/2006"),
+			end.Format("3:04:05 PM"),
+			allDay,
+			"False",
+			"",
+			"",
+			strings.Join(event.Categories, ";"),
+			event.Description,
+			event.Location,
+			"Normal",
+			"False",
+			"Normal",
+			outlookShowAsFree,
+		})
+	}
+	cw.Flush()
+	return buf.Bytes(), cw.Error()
+}
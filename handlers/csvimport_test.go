@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+func TestSerializeGoogleCSV(t *testing.T) {
+	tz := services.GetTimezone(55.6761, 12.5683)
+	events := []services.CalendarEvent{
+		{
+			Summary:     "Sunset",
+			Start:       time.Date(2024, 6, 21, 19, 57, 0, 0, time.UTC),
+			End:         time.Date(2024, 6, 21, 19, 58, 0, 0, time.UTC),
+			Description: "Day length: 17h 32m\nLocation: Copenhagen",
+			Location:    "Copenhagen",
+		},
+		{
+			Summary: "Moon at perigee (357,175 km)",
+			Start:   time.Date(2024, 10, 17, 0, 0, 0, 0, tz),
+			End:     time.Date(2024, 10, 18, 0, 0, 0, 0, tz),
+			AllDay:  true,
+		},
+	}
+
+	body, err := serializeGoogleCSV("Sun Times", tz, events, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(string(body))).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(rows) != 3 || strings.Join(rows[0], ",") != "Subject,Start Date,Start Time,End Date,End Time,All Day Event,Description,Location,Private" {
+		t.Fatalf("unexpected header or row count: %v", rows)
+	}
+	// 19:57 UTC is 21:57 in Copenhagen's summer time
+	want := []string{"Sunset", "06/21/2024", "09:57 PM", "06/21/2024", "09:58 PM", "False", "Day length: 17h 32m\nLocation: Copenhagen", "Copenhagen", "False"}
+	if strings.Join(rows[1], "|") != strings.Join(want, "|") {
+		t.Errorf("unexpected timed row:\n got %q\nwant %q", rows[1], want)
+	}
+	// All-day events end on their last day
+	want = []string{"Moon at perigee (357,175 km)", "10/17/2024", "", "10/17/2024", "", "True", "", "", "False"}
+	if strings.Join(rows[2], "|") != strings.Join(want, "|") {
+		t.Errorf("unexpected all-day row:\n got %q\nwant %q", rows[2], want)
+	}
+}
+
+func TestUnifiedCalendarHandler_ImportCSV(t *testing.T) {
+	req := httptest.NewRequest("GET", "/calendar?lat=55.6761&lng=12.5683&days=2&format=google-csv", nil)
+	w := httptest.NewRecorder()
+
+	UnifiedCalendarHandler(w, req)
+
+	if got := w.Header().Get("Content-Disposition"); !strings.Contains(got, "calsun.csv") {
+		t.Errorf("expected a .csv download, got %q", got)
+	}
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil || len(rows) < 2 || rows[0][0] != "Subject" || !strings.HasPrefix(rows[1][0], "Sunrise") {
+		t.Errorf("expected Google CSV rows, got %v (%v)", rows, err)
+	}
+
+	req = httptest.NewRequest("GET", "/calendar?lat=55.6761&lng=12.5683&days=2&format=outlook-csv", nil)
+	w = httptest.NewRecorder()
+
+	UnifiedCalendarHandler(w, req)
+
+	if rows, err := csv.NewReader(w.Body).ReadAll(); err != nil || len(rows) < 2 || rows[0][5] != "All day event" {
+		t.Errorf("expected Outlook CSV rows, got %v (%v)", rows, err)
+	}
+
+	// text/csv in the Accept header keeps the analytical CSV
+	req = httptest.NewRequest("GET", "/calendar?lat=55.6761&lng=12.5683&days=2", nil)
+	req.Header.Set("Accept", "text/csv")
+	w = httptest.NewRecorder()
+
+	UnifiedCalendarHandler(w, req)
+
+	if !strings.HasPrefix(w.Body.String(), strings.Join(csvHeader, ",")) {
+		t.Errorf("expected the analytical CSV for text/csv, got %q", w.Body.String()[:40])
+	}
+}
+
+func TestSerializeOutlookCSV(t *testing.T) {
+	tz := services.GetTimezone(55.6761, 12.5683)
+	events := []services.CalendarEvent{
+		{
+			Summary:    "Sunrise",
+			Start:      time.Date(2024, 12, 21, 7, 37, 5, 0, time.UTC),
+			End:        time.Date(2024, 12, 21, 7, 38, 5, 0, time.UTC),
+			Location:   "Copenhagen",
+			Categories: []string{"Sun", "Sunrise"},
+		},
+		{
+			Summary: "Moon at perigee (357,175 km)",
+			Start:   time.Date(2024, 10, 17, 0, 0, 0, 0, tz),
+			End:     time.Date(2024, 10, 18, 0, 0, 0, 0, tz),
+			AllDay:  true,
+		},
+	}
+
+	body, err := serializeOutlookCSV("Sun Times", tz, events, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(string(body))).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected a header and 2 rows, got %d", len(rows))
+	}
+	column := func(row []string, name string) string {
+		for i, h := range rows[0] {
+			if h == name {
+				return row[i]
+			}
+		}
+		t.Fatalf("missing column %q", name)
+		return ""
+	}
+
+	// 07:37 UTC is 08:37 in Copenhagen's winter time; no leading zeros
+	timed := rows[1]
+	for name, want := range map[string]string{
+		"Start Date":      "12/21/2024",
+		"Start Time":      "8:37:05 AM",
+		"End Time":        "8:38:05 AM",
+		"All day event":   "False",
+		"Reminder on/off": "False",
+		"Categories":      "Sun;Sunrise",
+		"Private":         "False",
+		"Sensitivity":     "Normal",
+		"Show time as":    "0",
+	} {
+		if got := column(timed, name); got != want {
+			t.Errorf("timed %s: expected %q, got %q", name, want, got)
+		}
+	}
+
+	// All-day events end at midnight on the day after
+	allDay := rows[2]
+	for name, want := range map[string]string{
+		"Start Date":    "10/17/2024",
+		"Start Time":    "12:00:00 AM",
+		"End Date":      "10/18/2024",
+		"End Time":      "12:00:00 AM",
+		"All day event": "True",
+	} {
+		if got := column(allDay, name); got != want {
+			t.Errorf("all-day %s: expected %q, got %q", name, want, got)
+		}
+	}
+}
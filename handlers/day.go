@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"calsun/config"
+	"calsun/services"
+)
+
+var (
+	dayIntervalParam = paramDef{
+		Name:        "interval",
+		Type:        paramTypeInteger,
+		Min:         bound(5),
+		Max:         bound(180),
+		Default:     60,
+		Description: "Minutes between rows",
+	}
+	dayHeightParam = paramDef{
+		Name:        "height",
+		Type:        paramTypeNumber,
+		Min:         bound(0.01),
+		Max:         bound(1000),
+		Default:     1.0,
+		Description: "Height in metres of the object whose shadow length is listed",
+	}
+	dayFormatParam = paramDef{
+		Name:        "format",
+		Type:        paramTypeEnum,
+		Values:      []string{"html", "json"},
+		Description: "Response format (default: json if the Accept header asks for it, else html)",
+	}
+)
+
+// dayParamDefs lists the parameters accepted by the sun position table
+var dayParamDefs = []paramDef{
+	latParam,
+	lngParam,
+	nameParam,
+	dateParam,
+	dayIntervalParam,
+	dayHeightParam,
+	dayFormatParam,
+}
+
+var dayTemplate *template.Template
+
+func init() {
+	var err error
+	dayTemplate, err = template.ParseFS(templatesFS, "templates/day.html")
+	if err != nil {
+		panic("failed to parse day template: " + err.Error())
+	}
+}
+
+// dayResponse is the JSON body returned by DayHandler. Times are RFC 3339 in
+// the location's timezone.
+type dayResponse struct {
+	Date            string          `json:"date"`
+	Location        string          `json:"location"`
+	Lat             float64         `json:"lat"`
+	Lng             float64         `json:"lng"`
+	Timezone        string          `json:"timezone"`
+	IntervalMinutes int             `json:"interval_minutes"`
+	HeightMetres    float64         `json:"height_m"`
+	Samples         []daySampleJSON `json:"samples"`
+}
+
+// daySampleJSON is one row of the sun position table
+type daySampleJSON struct {
+	Time           time.Time `json:"time"`
+	Azimuth        float64   `json:"azimuth"`
+	AzimuthCompass string    `json:"azimuth_compass"`
+	Elevation      float64   `json:"elevation"`
+	ShadowLength   *float64  `json:"shadow_length_m"` // null while the sun is down
+}
+
+// dayData is the template data for the sun position table page
+type dayData struct {
+	Site         config.Site
+	BasePath     string
+	Date         string
+	Location     string
+	Timezone     string
+	HeightMetres float64
+	Sunrise      string
+	Sunset       string
+	Rows         []dayRow
+}
+
+// dayRow is a row of the sun position table page
+type dayRow struct {
+	Time      string
+	Azimuth   string
+	Elevation string
+	Shadow    string
+	Up        bool // The sun is above the horizon
+}
+
+// DayHandler lists the sun's azimuth, elevation, and the shadow length of an
+// object at regular intervals through a day (today by default), as an HTML
+// page or JSON. Headless servers only serve JSON.
+func DayHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	interval, errMsg := dayIntervalParam.parseInt(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	height, errMsg := dayHeightParam.parseFloat(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	format, errMsg := dayFormatParam.parseEnum(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	// Headless servers have no web UI, so the table is only served as JSON
+	if cfg.Headless {
+		if format == "html" {
+			DisabledHandler(w, r)
+			return
+		}
+		format = "json"
+	}
+	if format == "" {
+		format = "html"
+		if strings.Contains(r.Header.Get("Accept"), "application/json") {
+			format = "json"
+		}
+	}
+
+	tz := services.GetTimezone(params.lat, params.lng)
+	date := params.now.In(tz)
+	if !params.date.IsZero() {
+		date = params.date
+	}
+	samples := services.DaySunSamples(params.lat, params.lng, date, tz, time.Duration(interval)*time.Minute)
+	dateStr := date.Format("2006-01-02")
+
+	if format == "json" {
+		resp := dayResponse{
+			Date:            dateStr,
+			Location:        locationName(params),
+			Lat:             params.lat,
+			Lng:             params.lng,
+			Timezone:        tz.String(),
+			IntervalMinutes: interval,
+			HeightMetres:    height,
+			Samples:         make([]daySampleJSON, 0, len(samples)),
+		}
+		for _, s := range samples {
+			sample := daySampleJSON{
+				Time:           s.Time.In(tz),
+				Azimuth:        round2(s.Azimuth),
+				AzimuthCompass: services.CompassPoint(s.Azimuth),
+				Elevation:      round2(s.Elevation),
+			}
+			if shadow, ok := services.ShadowLength(height, s.Elevation); ok {
+				shadow = round2(shadow)
+				sample.ShadowLength = &shadow
+			}
+			resp.Samples = append(resp.Samples, sample)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	noon := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, tz)
+	day := services.GetSunTimes(params.lat, params.lng, noon)
+	data := dayData{
+		Site:         cfg.Site,
+		BasePath:     cfg.Server.BasePath,
+		Date:         params.locale.LongDate(date),
+		Location:     locationName(params),
+		Timezone:     tz.String(),
+		HeightMetres: height,
+		Sunrise:      "—",
+		Sunset:       "—",
+		Rows:         make([]dayRow, 0, len(samples)),
+	}
+	if day.Sunrise != nil {
+		data.Sunrise = day.Sunrise.Time.In(tz).Format("15:04")
+	}
+	if day.Sunset != nil {
+		data.Sunset = day.Sunset.Time.In(tz).Format("15:04")
+	}
+	for _, s := range samples {
+		row := dayRow{
+			Time:      s.Time.In(tz).Format("15:04"),
+			Azimuth:   params.locale.Azimuth(s.Azimuth, services.AzimuthBoth),
+			Elevation: params.locale.Degrees(s.Elevation, 1),
+			Shadow:    "—",
+		}
+		if shadow, ok := services.ShadowLength(height, s.Elevation); ok {
+			row.Shadow = formatShadow(shadow, params.locale)
+			row.Up = true
+		}
+		data.Rows = append(data.Rows, row)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dayTemplate.Execute(w, data); err != nil {
+		log.Printf("render day page: %v", err)
+	}
+}
+
+// formatShadow formats a shadow length in metres, with fewer decimals as it
+// grows (e.g., "1.73 m", "57.3 m", "1146 m"), in locale
+func formatShadow(metres float64, locale services.Locale) string {
+	switch {
+	case metres < 10:
+		return locale.Number(metres, 2) + " m"
+	case metres < 100:
+		return locale.Number(metres, 1) + " m"
+	}
+	return locale.Number(metres, 0) + " m"
+}
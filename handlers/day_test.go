@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDayHandler_JSON(t *testing.T) {
+	req := httptest.NewRequest("GET", "/day?lat=55.6761&lng=12.5683&date=2024-06-21&interval=30&height=2&format=json", nil)
+	w := httptest.NewRecorder()
+
+	DayHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp dayResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Date != "2024-06-21" || resp.IntervalMinutes != 30 || resp.HeightMetres != 2 {
+		t.Errorf("unexpected response header fields: %+v", resp)
+	}
+	if len(resp.Samples) != 48 {
+		t.Fatalf("expected 48 half-hourly samples, got %d", len(resp.Samples))
+	}
+	if resp.Samples[0].ShadowLength != nil {
+		t.Error("expected no shadow at midnight")
+	}
+	// 13:00, with the sun about 57.6° high: a 2 m object casts a 1.27 m shadow
+	noon := resp.Samples[26]
+	if noon.ShadowLength == nil || *noon.ShadowLength < 1.2 || *noon.ShadowLength > 1.35 || noon.AzimuthCompass != "S" {
+		t.Errorf("unexpected midday sample %+v", noon)
+	}
+}
+
+func TestDayHandler_AcceptJSON(t *testing.T) {
+	req := httptest.NewRequest("GET", "/day?lat=55.6761&lng=12.5683", nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+
+	DayHandler(w, req)
+
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("expected JSON for an Accept header asking for it, got %s", contentType)
+	}
+}
+
+func TestDayHandler_HTML(t *testing.T) {
+	req := httptest.NewRequest("GET", "/day?lat=55.6761&lng=12.5683&name=Copenhagen&date=2024-12-21", nil)
+	w := httptest.NewRecorder()
+
+	DayHandler(w, req)
+
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+		t.Fatalf("expected HTML by default, got %s", contentType)
+	}
+	body := w.Body.String()
+	for _, want := range []string{"Sun position in Copenhagen", "Saturday, December 21, 2024", "Sunrise 08:38 · Sunset 15:39", "<td>12:00</td>", "S (1"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the page", want)
+		}
+	}
+	if n := strings.Count(body, `<tr class="down">`); n != 17 {
+		t.Errorf("expected 17 rows with the sun down in December, got %d", n)
+	}
+}
+
+func TestDayHandler_Headless(t *testing.T) {
+	configureRoutes(t, false, true)
+
+	w := httptest.NewRecorder()
+	DayHandler(w, httptest.NewRequest("GET", "/day?lat=55.6761&lng=12.5683", nil))
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("expected JSON by default in headless mode, got %s", contentType)
+	}
+
+	w = httptest.NewRecorder()
+	DayHandler(w, httptest.NewRequest("GET", "/day?lat=55.6761&lng=12.5683&format=html", nil))
+	if w.Code != http.StatusGone {
+		t.Errorf("expected status 410 for the HTML page, got %d", w.Code)
+	}
+}
+
+func TestDayHandler_InvalidParams(t *testing.T) {
+	for _, url := range []string{
+		"/day",
+		"/day?lat=55.6761&lng=12.5683&interval=1",
+		"/day?lat=55.6761&lng=12.5683&height=0",
+		"/day?lat=55.6761&lng=12.5683&format=xml",
+	} {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		DayHandler(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", url, w.Code)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"calsun/services"
+)
+
+var deltaSinceParam = paramDef{
+	Name:        "since",
+	Type:        paramTypeString,
+	Description: "Cursor from the previous response; without it, every event is returned",
+}
+
+// deltaParamDefs lists the parameters accepted by the delta feed
+var deltaParamDefs = append(append(append([]paramDef{}, calendarParamDefs...), deltaSinceParam), pageParamDefs...)
+
+// deltaResponse is the JSON body returned by DeltaHandler
+type deltaResponse struct {
+	Cursor string          `json:"cursor"` // Pass as since on the next request
+	Reset  bool            `json:"reset"`  // Events is the whole calendar, replacing what the client has
+	Events []eventDocument `json:"events"`
+	// Cursor of the next page of events, empty on the last
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// deltaCursor identifies the calendar a client last received: the day its
+// date range was generated for and the digest of its events
+type deltaCursor struct {
+	day    time.Time
+	digest string
+}
+
+// DeltaHandler returns the events of the /calendar.ics calendar for the same
+// parameters that were added or changed since the calendar identified by the
+// since cursor, for clients that sync instead of re-downloading the feed.
+// Cursors are stateless: the previous calendar is regenerated from the
+// cursor's day and its events compared with today's. If the regenerated
+// calendar no longer matches the cursor's digest (the parameters or the
+// calculations changed), or there is no cursor, every event is returned
+// with reset set. Events are paginated, defaultPageLimit per page.
+func DeltaHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	page, _, errMsg := parsePageRequest(r.URL.Query())
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	var cursor deltaCursor
+	if since := r.URL.Query().Get(deltaSinceParam.Name); since != "" {
+		var ok bool
+		if cursor, ok = decodeDeltaCursor(since); !ok {
+			http.Error(w, "invalid since parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	tz := services.GetTimezone(params.lat, params.lng)
+	_, events := buildCalendarEvents(r, params, tz)
+	resp := deltaResponse{
+		Cursor: encodeDeltaCursor(deltaCursor{params.now, services.EventsDigest(events)}),
+		Reset:  true,
+	}
+	changed := events
+	if cursor.digest != "" {
+		previousParams := *params
+		previousParams.now = cursor.day
+		_, previous := buildCalendarEvents(r, &previousParams, tz)
+		if services.EventsDigest(previous) == cursor.digest {
+			changed = services.ChangedEvents(previous, events)
+			resp.Reset = false
+		}
+	}
+	changed, resp.NextCursor = paginateEvents(changed, page)
+	resp.Events = newCalendarDocument("", tz, changed).Events
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// encodeDeltaCursor returns an opaque cursor for c (e.g., base64 of
+// "2024-06-21:<digest>")
+func encodeDeltaCursor(c deltaCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.day.UTC().Format(time.DateOnly) + ":" + c.digest))
+}
+
+// decodeDeltaCursor parses a cursor from encodeDeltaCursor
+func decodeDeltaCursor(cursor string) (deltaCursor, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return deltaCursor{}, false
+	}
+	dayStr, digest, ok := strings.Cut(string(data), ":")
+	if !ok || digest == "" {
+		return deltaCursor{}, false
+	}
+	day, err := time.Parse(time.DateOnly, dayStr)
+	if err != nil {
+		return deltaCursor{}, false
+	}
+	return deltaCursor{day, digest}, true
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+func fetchDelta(t *testing.T, at time.Time, since string) deltaResponse {
+	t.Helper()
+	SetClock(services.FixedClock(at))
+	target := "/api/events/delta?lat=55.6761&lng=12.5683&days=3"
+	if since != "" {
+		target += "&since=" + url.QueryEscape(since)
+	}
+	w := httptest.NewRecorder()
+
+	DeltaHandler(w, httptest.NewRequest("GET", target, nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp deltaResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return resp
+}
+
+func TestDeltaHandler(t *testing.T) {
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	day := time.Date(2024, 6, 21, 10, 0, 0, 0, time.UTC)
+
+	// Without a cursor, the whole calendar
+	first := fetchDelta(t, day, "")
+	if !first.Reset || len(first.Events) == 0 || first.Cursor == "" {
+		t.Fatalf("expected a reset with every event, got reset=%v, %d events", first.Reset, len(first.Events))
+	}
+
+	// Nothing changes within the day
+	same := fetchDelta(t, day.Add(5*time.Hour), first.Cursor)
+	if same.Reset || len(same.Events) != 0 {
+		t.Errorf("expected no changes later the same day, got reset=%v, %+v", same.Reset, same.Events)
+	}
+
+	// A day later, the day entering the range is new
+	next := fetchDelta(t, day.AddDate(0, 0, 1), first.Cursor)
+	if next.Reset || len(next.Events) == 0 {
+		t.Fatalf("expected added events, got reset=%v, %d events", next.Reset, len(next.Events))
+	}
+	known := map[string]bool{}
+	for _, event := range first.Events {
+		known[event.UID] = true
+	}
+	added := 0
+	for _, event := range next.Events {
+		if !known[event.UID] {
+			added++
+			if event.Start.Before(time.Date(2024, 6, 24, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("unexpected added event %s at %v", event.Summary, event.Start)
+			}
+		}
+	}
+	if added == 0 {
+		t.Errorf("expected events of the new day, got %+v", next.Events)
+	}
+	if next.Cursor == first.Cursor {
+		t.Error("expected a new cursor")
+	}
+}
+
+func TestDeltaHandler_StaleCursor(t *testing.T) {
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	day := time.Date(2024, 6, 21, 10, 0, 0, 0, time.UTC)
+
+	// A digest that doesn't match the regenerated calendar resets
+	stale := encodeDeltaCursor(deltaCursor{day, "00000000000000000000000000000000"})
+	resp := fetchDelta(t, day, stale)
+	if !resp.Reset || len(resp.Events) == 0 {
+		t.Errorf("expected a reset, got reset=%v, %d events", resp.Reset, len(resp.Events))
+	}
+}
+
+func TestDeltaHandler_InvalidCursor(t *testing.T) {
+	for _, since := range []string{"not base64!", "MjAyNC0wNi0yMQ", encodeDeltaCursor(deltaCursor{time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), ""})} {
+		w := httptest.NewRecorder()
+		DeltaHandler(w, httptest.NewRequest("GET", "/api/events/delta?lat=55.6761&lng=12.5683&since="+url.QueryEscape(since), nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("since=%q: expected 400, got %d", since, w.Code)
+		}
+	}
+}
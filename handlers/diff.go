@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"calsun/services"
+)
+
+var (
+	diffFromParam = paramDef{
+		Name:        "from",
+		Type:        paramTypeString,
+		Description: "Current calendar: its query string or /calendar.ics URL",
+	}
+	diffToParam = paramDef{
+		Name:        "to",
+		Type:        paramTypeString,
+		Description: "Changed calendar: its query string or /calendar.ics URL",
+	}
+)
+
+// diffParamDefs lists the parameters accepted by the diff endpoint
+var diffParamDefs = []paramDef{diffFromParam, diffToParam}
+
+// diffResponse is the JSON body returned by DiffHandler
+type diffResponse struct {
+	From      diffCalendar    `json:"from"`
+	To        diffCalendar    `json:"to"`
+	Added     []eventDocument `json:"added"`
+	Removed   []eventDocument `json:"removed"`
+	Changed   []diffChange    `json:"changed"`
+	Unchanged int             `json:"unchanged"`
+}
+
+// diffCalendar describes one of the compared calendars
+type diffCalendar struct {
+	Name     string `json:"name"`
+	Timezone string `json:"timezone"`
+	Events   int    `json:"events"`
+	Digest   string `json:"digest"` // Equal for calendars with the same events
+}
+
+// diffChange is an event in both calendars whose content differs
+type diffChange struct {
+	UID    string        `json:"uid"`
+	Fields []string      `json:"fields"` // e.g., "start", "description"
+	From   eventDocument `json:"from"`
+	To     eventDocument `json:"to"`
+}
+
+// DiffHandler compares the /calendar.ics calendars of two parameter sets,
+// listing the events the to calendar adds, removes, and changes relative to
+// the from calendar, so the effect of changing a shared feed's options can
+// be checked before its URL is replaced. Events are matched by UID, which
+// includes the location: moving a calendar replaces all its events.
+func DiffHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, errMsg := parseDiffCalendar(q, diffFromParam)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	to, errMsg := parseDiffCalendar(q, diffToParam)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	// Both calendars cover the same dates, even across midnight
+	if !to.fixedNow {
+		to.now = from.now
+	}
+
+	fromTZ := services.GetTimezone(from.lat, from.lng)
+	toTZ := services.GetTimezone(to.lat, to.lng)
+	fromName, fromEvents := buildCalendarEvents(r, from, fromTZ)
+	toName, toEvents := buildCalendarEvents(r, to, toTZ)
+	diff := services.DiffEvents(fromEvents, toEvents)
+
+	resp := diffResponse{
+		From:      diffCalendar{fromName, fromTZ.String(), len(fromEvents), services.EventsDigest(fromEvents)},
+		To:        diffCalendar{toName, toTZ.String(), len(toEvents), services.EventsDigest(toEvents)},
+		Added:     newCalendarDocument("", toTZ, diff.Added).Events,
+		Removed:   newCalendarDocument("", fromTZ, diff.Removed).Events,
+		Changed:   make([]diffChange, len(diff.Changed)),
+		Unchanged: diff.Unchanged,
+	}
+	for i, change := range diff.Changed {
+		docs := newCalendarDocument("", toTZ, []services.CalendarEvent{change.Before, change.After}).Events
+		resp.Changed[i] = diffChange{UID: change.After.UID, Fields: change.Fields, From: docs[0], To: docs[1]}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// parseDiffCalendar parses the calendar parameters in the query string or
+// URL given as p. Errors are prefixed with the parameter's name.
+func parseDiffCalendar(q url.Values, p paramDef) (*calendarParams, string) {
+	value := strings.TrimSpace(q.Get(p.Name))
+	if value == "" {
+		return nil, p.Name + " parameter is required: a calendar query string or URL"
+	}
+	// Everything after "?" of a URL, or the query string with or without "?"
+	if _, query, ok := strings.Cut(value, "?"); ok {
+		value = query
+	}
+	values, err := url.ParseQuery(value)
+	if err != nil {
+		return nil, p.Name + ": invalid query string"
+	}
+	params, errMsg := parseCalendarQuery(values)
+	if errMsg != "" {
+		return nil, p.Name + ": " + errMsg
+	}
+	return params, ""
+}
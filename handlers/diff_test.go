@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+func TestDiffHandler(t *testing.T) {
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	SetClock(services.FixedClock(time.Date(2024, 6, 21, 10, 0, 0, 0, time.UTC)))
+
+	from := "lat=55.6761&lng=12.5683&days=3&events=sunrise,sunset"
+	to := "https://calsun.example/calendar.ics?lat=55.6761&lng=12.5683&days=4&events=sunrise&azformat=compass"
+	target := "/api/diff?from=" + url.QueryEscape(from) + "&to=" + url.QueryEscape(to)
+	w := httptest.NewRecorder()
+
+	DiffHandler(w, httptest.NewRequest("GET", target, nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp diffResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if resp.From.Events != 2*resp.To.Events-2 || resp.From.Timezone != "Europe/Copenhagen" || resp.From.Digest == resp.To.Digest {
+		t.Errorf("unexpected calendars: %+v %+v", resp.From, resp.To)
+	}
+	// The fourth day's sunrise is added and the sunsets removed
+	if len(resp.Added) != 1 || resp.Added[0].Type != "sunrise" || !strings.HasPrefix(resp.Added[0].Start.Format(time.DateOnly), "2024-06-24") {
+		t.Errorf("expected the fourth sunrise added, got %+v", resp.Added)
+	}
+	if len(resp.Removed) != resp.From.Events/2 || resp.Removed[0].Type != "sunset" {
+		t.Errorf("expected every sunset removed, got %+v", resp.Removed)
+	}
+	// The other sunrises show the compass direction
+	if len(resp.Changed) != resp.To.Events-1 || !slices.Equal(resp.Changed[0].Fields, []string{"summary", "description"}) || resp.Changed[0].From.UID != resp.Changed[0].UID {
+		t.Errorf("expected the other sunrises' summaries and descriptions changed, got %+v", resp.Changed)
+	}
+	if resp.Unchanged != 0 {
+		t.Errorf("expected no unchanged events, got %d", resp.Unchanged)
+	}
+}
+
+func TestDiffHandler_Unchanged(t *testing.T) {
+	query := "?lat=55.6761&lng=12.5683&days=2"
+	target := "/api/diff?from=" + url.QueryEscape(query) + "&to=" + url.QueryEscape(query+"&name=")
+	w := httptest.NewRecorder()
+
+	DiffHandler(w, httptest.NewRequest("GET", target, nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp diffResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp.Added)+len(resp.Removed)+len(resp.Changed) != 0 || resp.Unchanged != resp.From.Events || resp.From.Digest != resp.To.Digest {
+		t.Errorf("expected identical calendars, got %+v", resp)
+	}
+}
+
+func TestDiffHandler_Errors(t *testing.T) {
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{"to=lat%3D1%26lng%3D2", "from parameter is required"},
+		{"from=lat%3D1%26lng%3D2", "to parameter is required"},
+		{"from=lat%3D1%26lng%3D2&to=lat%3D100%26lng%3D2", "to: "},
+		{"from=lat%3D1%26lng%3D2&to=%25zz", "to: invalid query string"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		DiffHandler(w, httptest.NewRequest("GET", "/api/diff?"+tt.query, nil))
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tt.err) {
+			t.Errorf("%s: expected 400 with %q, got %d: %s", tt.query, tt.err, w.Code, w.Body.String())
+		}
+	}
+}
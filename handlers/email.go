@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+
+	"calsun/services"
+)
+
+// maxEmailLength is the longest address accepted (RFC 5321 path limit)
+const maxEmailLength = 254
+
+// emailDigester sends email digests; nil when SMTP is not configured
+var emailDigester *services.EmailDigester
+
+// SetEmailDigester sets the email digester used by the email handlers
+func SetEmailDigester(e *services.EmailDigester) {
+	emailDigester = e
+}
+
+var (
+	emailParam = paramDef{
+		Name:        "email",
+		Type:        paramTypeString,
+		Required:    true,
+		Description: "Address to send the sun summary to",
+	}
+	frequencyParam = paramDef{
+		Name:        "frequency",
+		Type:        paramTypeEnum,
+		Values:      []string{"daily", "weekly"},
+		Default:     "daily",
+		Description: "How often the summary is sent (weekly summaries arrive on Mondays)",
+	}
+)
+
+// emailParamDefs lists the parameters accepted by the subscribe endpoint
+var emailParamDefs = []paramDef{latParam, lngParam, nameParam, emailParam, frequencyParam}
+
+// EmailSubscribeHandler creates an unconfirmed email subscription and sends
+// the confirmation email. The location comes from the query string; email
+// and frequency may be sent as form values to keep the address out of URLs.
+func EmailSubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	if emailDigester == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	r.ParseForm()
+	email := r.Form.Get(emailParam.Name)
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email || len(email) > maxEmailLength {
+		http.Error(w, "invalid email parameter", http.StatusBadRequest)
+		return
+	}
+	frequency, errMsg := frequencyParam.parseEnum(r.Form)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	_, err := emailDigester.Subscribe(services.EmailSubscription{
+		Email:     email,
+		Lat:       params.lat,
+		Lng:       params.lng,
+		Name:      params.name,
+		Frequency: frequency,
+		BaseURL:   appURL(r, ""),
+	})
+	if err != nil {
+		log.Printf("email subscribe: %v", err)
+		http.Error(w, "failed to send confirmation email", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"status": "pending_confirmation"})
+}
+
+// EmailConfirmHandler confirms a subscription from the link in the
+// confirmation email
+func EmailConfirmHandler(w http.ResponseWriter, r *http.Request) {
+	if emailDigester == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	sub, ok, err := emailDigester.Confirm(r.URL.Query().Get("token"))
+	if err != nil {
+		log.Printf("email confirm: %v", err)
+		http.Error(w, "failed to confirm subscription", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		renderStatusPage(w, http.StatusNotFound, statusData{
+			Heading: "Link expired",
+			Message: "This confirmation link is no longer valid. Please subscribe again.",
+		})
+		return
+	}
+
+	message := "You will receive a sun summary every morning."
+	if sub.Frequency == "weekly" {
+		message = "You will receive a sun summary every Monday morning."
+	}
+	renderStatusPage(w, http.StatusOK, statusData{
+		Heading: "Subscribed",
+		Message: message + " Every email contains a link to unsubscribe.",
+	})
+}
+
+// EmailUnsubscribeHandler deletes a subscription. GET shows a confirmation
+// button (so link scanners cannot unsubscribe users); POST unsubscribes,
+// which also serves one-click unsubscribe (RFC 8058) from mail clients.
+func EmailUnsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	if emailDigester == nil {
+		http.NotFound(w, r)
+		return
+	}
+	token := r.URL.Query().Get("token")
+
+	if r.Method != http.MethodPost {
+		renderStatusPage(w, http.StatusOK, statusData{
+			Heading: "Unsubscribe",
+			Message: "Stop receiving sun summaries at this address?",
+			Form: &statusForm{
+				Action: appPath("/email/unsubscribe?" + url.Values{"token": {token}}.Encode()),
+				Button: "Unsubscribe",
+			},
+		})
+		return
+	}
+
+	if _, err := emailDigester.Unsubscribe(token); err != nil {
+		log.Printf("email unsubscribe: %v", err)
+		http.Error(w, "failed to unsubscribe", http.StatusInternalServerError)
+		return
+	}
+	renderStatusPage(w, http.StatusOK, statusData{
+		Heading: "Unsubscribed",
+		Message: "You will not receive any more sun summaries.",
+	})
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+// fakeMailer records sent email bodies
+type fakeMailer struct {
+	bodies []string
+}
+
+func (m *fakeMailer) Send(to, subject, body string, headers map[string]string) error {
+	m.bodies = append(m.bodies, body)
+	return nil
+}
+
+// withTestEmailDigester installs an email digester with a fake mailer for the
+// duration of the test
+func withTestEmailDigester(t *testing.T) (*services.EmailDigester, *fakeMailer) {
+	store, err := services.OpenEmailStore(filepath.Join(t.TempDir(), "email.json"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	mailer := &fakeMailer{}
+	e := services.NewEmailDigester(store, mailer, 7*time.Hour, "CalSun")
+
+	prev := emailDigester
+	SetEmailDigester(e)
+	t.Cleanup(func() { SetEmailDigester(prev) })
+	return e, mailer
+}
+
+var confirmTokenPattern = regexp.MustCompile(`/email/confirm\?token=([0-9a-f]+)`)
+
+// subscribeEmail runs the subscribe handler and returns the confirmation
+// token from the email
+func subscribeEmail(t *testing.T, mailer *fakeMailer) string {
+	req := httptest.NewRequest("POST", "http://calsun.example/email/subscribe?lat=55.6761&lng=12.5683&name=Copenhagen", strings.NewReader("email=user%40example.com&frequency=weekly"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	EmailSubscribeHandler(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(mailer.bodies) != 1 {
+		t.Fatalf("expected a confirmation email, got %d", len(mailer.bodies))
+	}
+	match := confirmTokenPattern.FindStringSubmatch(mailer.bodies[0])
+	if match == nil || !strings.Contains(mailer.bodies[0], "http://calsun.example/email/confirm") {
+		t.Fatalf("confirmation email should link back to the server:\n%s", mailer.bodies[0])
+	}
+	return match[1]
+}
+
+func TestEmailSubscribeHandler(t *testing.T) {
+	e, mailer := withTestEmailDigester(t)
+	token := subscribeEmail(t, mailer)
+
+	subs := e.Subscriptions()
+	if len(subs) != 1 || subs[0].ID != token {
+		t.Fatalf("expected the subscription to be stored, got %+v", subs)
+	}
+	sub := subs[0]
+	if sub.Email != "user@example.com" || sub.Frequency != "weekly" || sub.Name != "Copenhagen" || sub.Confirmed {
+		t.Errorf("unexpected subscription: %+v", sub)
+	}
+}
+
+func TestEmailSubscribeHandler_Errors(t *testing.T) {
+	withTestEmailDigester(t)
+
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		body   string
+		status int
+	}{
+		{"missing location", "POST", "", "email=user%40example.com", http.StatusBadRequest},
+		{"missing email", "POST", "lat=55&lng=12", "", http.StatusBadRequest},
+		{"invalid email", "POST", "lat=55&lng=12", "email=not-an-address", http.StatusBadRequest},
+		{"email with display name", "POST", "lat=55&lng=12", "email=Eve+%3Ceve%40example.com%3E", http.StatusBadRequest},
+		{"invalid frequency", "POST", "lat=55&lng=12", "email=user%40example.com&frequency=hourly", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/email/subscribe?"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+
+			EmailSubscribeHandler(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+}
+
+func TestEmailHandlers_NotConfigured(t *testing.T) {
+	prev := emailDigester
+	SetEmailDigester(nil)
+	t.Cleanup(func() { SetEmailDigester(prev) })
+
+	handlers := map[string]http.HandlerFunc{
+		"/email/subscribe?lat=55&lng=12": EmailSubscribeHandler,
+		"/email/confirm?token=abc":       EmailConfirmHandler,
+		"/email/unsubscribe?token=abc":   EmailUnsubscribeHandler,
+	}
+	for path, handler := range handlers {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("POST", path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestEmailConfirmAndUnsubscribe(t *testing.T) {
+	e, mailer := withTestEmailDigester(t)
+	token := subscribeEmail(t, mailer)
+
+	w := httptest.NewRecorder()
+	EmailConfirmHandler(w, httptest.NewRequest("GET", "/email/confirm?token=forged", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("forged token: expected status 404, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	EmailConfirmHandler(w, httptest.NewRequest("GET", "/email/confirm?token="+token, nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "every Monday") {
+		t.Errorf("expected weekly confirmation page, got %d: %s", w.Code, w.Body.String())
+	}
+	if subs := e.Subscriptions(); len(subs) != 1 || !subs[0].Confirmed {
+		t.Fatalf("expected a confirmed subscription, got %+v", subs)
+	}
+
+	// GET only asks for confirmation, so link scanners cannot unsubscribe
+	w = httptest.NewRecorder()
+	EmailUnsubscribeHandler(w, httptest.NewRequest("GET", "/email/unsubscribe?token="+token, nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `action="/email/unsubscribe?token=`+token+`"`) {
+		t.Errorf("expected an unsubscribe form, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(e.Subscriptions()) != 1 {
+		t.Fatal("GET should not unsubscribe")
+	}
+
+	w = httptest.NewRecorder()
+	EmailUnsubscribeHandler(w, httptest.NewRequest("POST", "/email/unsubscribe?token="+token, nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+	if len(e.Subscriptions()) != 0 {
+		t.Error("POST should unsubscribe")
+	}
+}
+
+func TestWebHandler_EmailForm(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	WebHandler(w, req)
+	if strings.Contains(w.Body.String(), `id="emailForm"`) {
+		t.Error("email form should be hidden when SMTP is not configured")
+	}
+
+	withTestEmailDigester(t)
+	w = httptest.NewRecorder()
+	WebHandler(w, req)
+	if !strings.Contains(w.Body.String(), `id="emailForm"`) {
+		t.Error("expected the email subscription form")
+	}
+}
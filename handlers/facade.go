@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"calsun/services"
+)
+
+// facadeParamDefs lists the parameters accepted by FacadeHandler
+var facadeParamDefs = []paramDef{latParam, lngParam, nameParam, dateParam, facadeAzimuthParam, facadeFOVParam}
+
+// facadeResponse is the JSON body returned by FacadeHandler. Times are in
+// the location's timezone (RFC 3339).
+type facadeResponse struct {
+	Date         string       `json:"date"`
+	Location     string       `json:"location"`
+	Timezone     string       `json:"timezone"`
+	Azimuth      float64      `json:"azimuth"`
+	FieldOfView  float64      `json:"field_of_view"`
+	Spans        []facadeSpan `json:"spans"` // Empty if the sun never shines in that day
+	TotalSeconds int          `json:"total_seconds"`
+}
+
+// facadeSpan is a span of direct sun through the window
+type facadeSpan struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds int       `json:"duration_seconds"`
+	MostDirect      time.Time `json:"most_direct"` // When the sun shines most directly in
+	Incidence       float64   `json:"incidence"`   // Degrees between the sun and straight in, at most_direct
+	Elevation       float64   `json:"elevation"`   // Sun elevation at most_direct
+}
+
+// FacadeHandler returns when direct sun shines through a window facing
+// facade_azimuth on a day, as JSON, for plant placement and glare avoidance
+func FacadeHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	if params.facade == nil {
+		http.Error(w, "facade_azimuth parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	tz := services.GetTimezone(params.lat, params.lng)
+	day := params.now.In(tz)
+	if !params.date.IsZero() {
+		day = params.date
+	}
+	noon := time.Date(day.Year(), day.Month(), day.Day(), 12, 0, 0, 0, tz)
+
+	resp := facadeResponse{
+		Date:        noon.Format("2006-01-02"),
+		Location:    locationName(params),
+		Timezone:    tz.String(),
+		Azimuth:     params.facade.Azimuth,
+		FieldOfView: params.facade.FieldOfView,
+		Spans:       []facadeSpan{},
+	}
+	for _, span := range services.FacadeSunSpans(params.lat, params.lng, *params.facade, noon) {
+		seconds := int(span.End.Sub(span.Start).Seconds())
+		resp.Spans = append(resp.Spans, facadeSpan{
+			Start:           span.Start.In(tz),
+			End:             span.End.In(tz),
+			DurationSeconds: seconds,
+			MostDirect:      span.Direct.In(tz),
+			Incidence:       round2(span.Incidence),
+			Elevation:       round2(span.Elevation),
+		})
+		resp.TotalSeconds += seconds
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFacadeHandler(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/facade?lat=55.6761&lng=12.5683&name=Copenhagen&date=2024-12-21&facade_azimuth=180", nil)
+	w := httptest.NewRecorder()
+
+	FacadeHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp facadeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Date != "2024-12-21" || resp.Location != "Copenhagen" || resp.Azimuth != 180 || resp.FieldOfView != 180 {
+		t.Errorf("unexpected window or day: %+v", resp)
+	}
+	if len(resp.Spans) != 1 {
+		t.Fatalf("expected 1 span, got %+v", resp.Spans)
+	}
+	span := resp.Spans[0]
+	if span.Start.Format("15:04") != "08:45" || span.End.Format("15:04") != "15:30" || span.MostDirect.Format("15:04") != "12:07" {
+		t.Errorf("expected sun from 08:45 to 15:30, most direct at 12:07, got %+v", span)
+	}
+	if resp.TotalSeconds != span.DurationSeconds || span.DurationSeconds < 6*3600 {
+		t.Errorf("expected a total of over 6 hours, got %d", resp.TotalSeconds)
+	}
+}
+
+func TestFacadeHandler_NoSun(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/facade?lat=55.6761&lng=12.5683&date=2024-12-21&facade_azimuth=0&facade_fov=90", nil)
+	w := httptest.NewRecorder()
+
+	FacadeHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var resp facadeResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Spans == nil || len(resp.Spans) != 0 || resp.TotalSeconds != 0 {
+		t.Errorf("expected an empty list for a north window in midwinter, got %+v", resp)
+	}
+}
+
+func TestFacadeHandler_InvalidParams(t *testing.T) {
+	for _, url := range []string{
+		"/api/facade?lat=55.6761&lng=12.5683",
+		"/api/facade?lat=55.6761&lng=12.5683&facade_azimuth=400",
+		"/api/facade?lat=55.6761&lng=12.5683&facade_azimuth=180&facade_fov=5",
+		"/api/facade?lng=12.5683&facade_azimuth=180",
+	} {
+		w := httptest.NewRecorder()
+		FacadeHandler(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", url, w.Code)
+		}
+	}
+}
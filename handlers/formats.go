@@ -0,0 +1,310 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"calsun/services"
+)
+
+var formatParam = paramDef{
+	Name:        "format",
+	Type:        paramTypeEnum,
+	Values:      []string{"ics", "json", "csv", "xml", "xcal", "vcs", "google-csv", "outlook-csv"},
+	Description: "Output format (default: chosen from the Accept header, else ics); xcal is xCal (RFC 6321) XML, vcs is vCalendar 1.0 for legacy devices, google-csv and outlook-csv are Google Calendar's and Outlook's CSV import schemas",
+}
+
+// unifiedCalendarParamDefs lists the parameters accepted by /calendar
+var unifiedCalendarParamDefs = append(append(append([]paramDef{}, calendarParamDefs...), formatParam), pageParamDefs...)
+
+// serializer writes a calendar's events in an output format. stamp is the
+// time the calendar is generated for (the request's now), used for DTSTAMP.
+type serializer func(name string, tz *time.Location, events []services.CalendarEvent, stamp time.Time) ([]byte, error)
+
+// calendarFormat is an output format of the calendar endpoints
+type calendarFormat struct {
+	name        string   // Value of the format parameter
+	mediaTypes  []string // Media types requesting the format in an Accept header
+	contentType string
+	extension   string // Extension of downloaded files
+	serialize   serializer
+}
+
+// calendarFormats lists the supported output formats. The first is the
+// default when a request does not prefer any.
+var calendarFormats = []calendarFormat{
+	{
+		name:        "ics",
+		mediaTypes:  []string{"text/calendar"},
+		contentType: "text/calendar; charset=utf-8",
+		extension:   "ics",
+		serialize:   serializeICS,
+	},
+	{
+		name:        "json",
+		mediaTypes:  []string{"application/json"},
+		contentType: "application/json",
+		extension:   "json",
+		serialize:   serializeJSON,
+	},
+	{
+		name:        "csv",
+		mediaTypes:  []string{"text/csv"},
+		contentType: "text/csv; charset=utf-8",
+		extension:   "csv",
+		serialize:   serializeCSV,
+	},
+	{
+		name:        "xml",
+		mediaTypes:  []string{"application/xml", "text/xml"},
+		contentType: "application/xml; charset=utf-8",
+		extension:   "xml",
+		serialize:   serializeXML,
+	},
+	{
+		name:        "xcal",
+		mediaTypes:  []string{"application/calendar+xml"},
+		contentType: "application/calendar+xml; charset=utf-8",
+		extension:   "xcs",
+		serialize:   serializeXCal,
+	},
+	{
+		name:        "vcs",
+		mediaTypes:  []string{"text/x-vcalendar"},
+		contentType: "text/x-vcalendar; charset=utf-8",
+		extension:   "vcs",
+		serialize:   serializeVCal,
+	},
+	{
+		// Import formats are only chosen by name: an Accept header asking
+		// for text/csv gets the analytical CSV
+		name:        "google-csv",
+		contentType: "text/csv; charset=utf-8",
+		extension:   "csv",
+		serialize:   serializeGoogleCSV,
+	},
+	{
+		name:        "outlook-csv",
+		contentType: "text/csv; charset=utf-8",
+		extension:   "csv",
+		serialize:   serializeOutlookCSV,
+	},
+}
+
+// UnifiedCalendarHandler serves the calendar of CalendarHandler as iCal,
+// JSON, CSV, XML, xCal, or vCalendar 1.0, chosen by the format parameter or
+// the Accept header, or in a calendar app's CSV import schema by the format
+// parameter
+func UnifiedCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	name, errMsg := formatParam.parseEnum(r.URL.Query())
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Vary", "Accept")
+	format, ok := formatByName(name)
+	if name == "" {
+		format, ok = negotiateFormat(r.Header.Get("Accept"))
+	}
+	if !ok {
+		http.Error(w, "none of the accepted media types is available; use text/calendar, application/json, text/csv, application/xml, application/calendar+xml, or text/x-vcalendar", http.StatusNotAcceptable)
+		return
+	}
+	page, paged, errMsg := parsePageRequest(r.URL.Query())
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	if paged {
+		if format.name != "json" {
+			http.Error(w, "limit and cursor are only supported for JSON", http.StatusBadRequest)
+			return
+		}
+		params.page = &page
+	}
+	serveCalendar(w, r, params, format)
+}
+
+// formatByName returns the format with the given parameter value
+func formatByName(name string) (calendarFormat, bool) {
+	for _, format := range calendarFormats {
+		if format.name == name {
+			return format, true
+		}
+	}
+	return calendarFormat{}, false
+}
+
+// negotiateFormat returns the format of the media range with the highest
+// quality in an Accept header, preferring earlier ranges on ties. Wildcards
+// match the first format of their type. An empty header accepts the
+// default format; reports false if nothing acceptable is available.
+func negotiateFormat(accept string) (calendarFormat, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return calendarFormats[0], true
+	}
+
+	var best calendarFormat
+	bestQuality := 0.0
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality <= bestQuality {
+			continue
+		}
+		if format, ok := formatForMediaRange(mediaType); ok {
+			best, bestQuality = format, quality
+		}
+	}
+	return best, bestQuality > 0
+}
+
+// formatForMediaRange returns the first format matching a media range such
+// as "application/json", "text/*", or "*/*"
+func formatForMediaRange(mediaRange string) (calendarFormat, bool) {
+	prefix, wildcard := strings.CutSuffix(mediaRange, "*")
+	if mediaRange == "*/*" {
+		prefix = ""
+	}
+	for _, format := range calendarFormats {
+		for _, mediaType := range format.mediaTypes {
+			if mediaType == mediaRange || (wildcard && strings.HasPrefix(mediaType, prefix)) {
+				return format, true
+			}
+		}
+	}
+	return calendarFormat{}, false
+}
+
+// withExtension replaces the .ics extension of a sanitized download file
+// name with ext. Returns "" for an empty name.
+func withExtension(filename, ext string) string {
+	if filename == "" || ext == "ics" {
+		return filename
+	}
+	base := strings.TrimSuffix(filename, ".ics")
+	if n := len(base) - len(ext) - 1; n > 0 && strings.EqualFold(base[n:], "."+ext) {
+		base = base[:n]
+	}
+	return base + "." + ext
+}
+
+// serializeICS writes the events as an iCal calendar
+func serializeICS(name string, tz *time.Location, events []services.CalendarEvent, stamp time.Time) ([]byte, error) {
+	cal := newCalendar(name)
+	for _, event := range events {
+		cal.AddVEvent(toVEvent(event, stamp))
+	}
+	return []byte(cal.Serialize(icsNewLine)), nil
+}
+
+// calendarDocument is the JSON and XML form of a calendar
+type calendarDocument struct {
+	XMLName  xml.Name        `json:"-" xml:"calendar"`
+	Name     string          `json:"name" xml:"name,attr"`
+	Timezone string          `json:"timezone" xml:"timezone,attr"`
+	Events   []eventDocument `json:"events" xml:"event"`
+	// Cursor of the next page of a paginated calendar, empty on the last
+	NextCursor string `json:"next_cursor,omitempty" xml:"-"`
+}
+
+// eventDocument is the JSON and XML form of an event, with local times
+type eventDocument struct {
+	UID         string    `json:"uid" xml:"uid,attr"`
+	Type        string    `json:"type" xml:"type,attr"`
+	AllDay      bool      `json:"all_day,omitempty" xml:"all-day,attr,omitempty"`
+	Start       time.Time `json:"start" xml:"start"`
+	End         time.Time `json:"end" xml:"end"`
+	Summary     string    `json:"summary" xml:"summary"`
+	Description string    `json:"description" xml:"description"`
+	Location    string    `json:"location" xml:"location"`
+	Categories  []string  `json:"categories,omitempty" xml:"category"`
+}
+
+// newCalendarDocument converts events to their document form
+func newCalendarDocument(name string, tz *time.Location, events []services.CalendarEvent) calendarDocument {
+	doc := calendarDocument{Name: name, Timezone: tz.String(), Events: make([]eventDocument, 0, len(events))}
+	for _, event := range events {
+		doc.Events = append(doc.Events, eventDocument{
+			UID:         event.UID,
+			Type:        event.Type,
+			AllDay:      event.AllDay,
+			Start:       event.Start.In(tz),
+			End:         event.End.In(tz),
+			Summary:     event.Summary,
+			Description: event.Description,
+			Location:    event.Location,
+			Categories:  event.Categories,
+		})
+	}
+	return doc
+}
+
+// serializeJSON writes the events as a JSON document
+func serializeJSON(name string, tz *time.Location, events []services.CalendarEvent, _ time.Time) ([]byte, error) {
+	return json.Marshal(newCalendarDocument(name, tz, events))
+}
+
+// serializeJSONPage writes a page of the events as a JSON document, with the
+// cursor of the next page
+func serializeJSONPage(name string, tz *time.Location, events []services.CalendarEvent, nextCursor string) ([]byte, error) {
+	doc := newCalendarDocument(name, tz, events)
+	doc.NextCursor = nextCursor
+	return json.Marshal(doc)
+}
+
+// serializeXML writes the events as an XML document
+func serializeXML(name string, tz *time.Location, events []services.CalendarEvent, _ time.Time) ([]byte, error) {
+	body, err := xml.MarshalIndent(newCalendarDocument(name, tz, events), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// csvHeader lists the columns of CSV calendars
+var csvHeader = []string{"uid", "type", "start", "end", "all_day", "summary", "description", "location", "categories"}
+
+// serializeCSV writes the events as CSV with a header row, one event per
+// row. Times are local RFC 3339 and categories are separated by semicolons.
+func serializeCSV(name string, tz *time.Location, events []services.CalendarEvent, _ time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	cw.Write(csvHeader)
+	for _, event := range events {
+		cw.Write([]string{
+			event.UID,
+			event.Type,
+			event.Start.In(tz).Format(time.RFC3339),
+			event.End.In(tz).Format(time.RFC3339),
+			strconv.FormatBool(event.AllDay),
+			event.Summary,
+			event.Description,
+			event.Location,
+			strings.Join(event.Categories, ";"),
+		})
+	}
+	cw.Flush()
+	return buf.Bytes(), cw.Error()
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"calsun/services"
+)
+
+func TestUnifiedCalendarHandler_Formats(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		accept      string
+		contentType string
+		filename    string
+	}{
+		{"default", "/calendar?lat=55.6761&lng=12.5683&days=3", "", "text/calendar; charset=utf-8", "calsun.ics"},
+		{"accept json", "/calendar?lat=55.6761&lng=12.5683&days=3", "application/json", "application/json", "calsun.json"},
+		{"accept with quality", "/calendar?lat=55.6761&lng=12.5683&days=3", "text/calendar;q=0.5, text/csv", "text/csv; charset=utf-8", "calsun.csv"},
+		{"format parameter wins", "/calendar?lat=55.6761&lng=12.5683&days=3&format=xml", "application/json", "application/xml; charset=utf-8", "calsun.xml"},
+		{"wildcard", "/calendar?lat=55.6761&lng=12.5683&days=3", "*/*", "text/calendar; charset=utf-8", "calsun.ics"},
+		{"file name extension", "/calendar?lat=55.6761&lng=12.5683&days=3&format=json&filename=sun.json", "", "application/json", "sun.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			UnifiedCalendarHandler(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("expected Content-Type %q, got %q", tt.contentType, got)
+			}
+			if got := w.Header().Get("Content-Disposition"); !strings.Contains(got, tt.filename) {
+				t.Errorf("expected file name %s, got %q", tt.filename, got)
+			}
+			if w.Header().Get("Vary") != "Accept" {
+				t.Error("expected Vary: Accept")
+			}
+		})
+	}
+}
+
+func TestUnifiedCalendarHandler_Bodies(t *testing.T) {
+	get := func(format string) string {
+		req := httptest.NewRequest("GET", "/calendar?lat=55.6761&lng=12.5683&name=Copenhagen&days=2&format="+format, nil)
+		w := httptest.NewRecorder()
+		UnifiedCalendarHandler(w, req)
+		return w.Body.String()
+	}
+
+	var doc calendarDocument
+	if err := json.Unmarshal([]byte(get("json")), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.Name != "Sun Times - Copenhagen" || doc.Timezone != "Europe/Copenhagen" || len(doc.Events) == 0 {
+		t.Fatalf("unexpected JSON document: %+v", doc)
+	}
+	first := doc.Events[0]
+	if first.Type != "sunrise" || first.UID == "" {
+		t.Errorf("unexpected first event: %+v", first)
+	}
+	_, offset := first.Start.Zone()
+	if _, want := first.Start.In(services.GetTimezone(55.6761, 12.5683)).Zone(); offset != want {
+		t.Errorf("expected local times, got offset %d", offset)
+	}
+
+	var xmlDoc calendarDocument
+	if err := xml.Unmarshal([]byte(get("xml")), &xmlDoc); err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+	if len(xmlDoc.Events) != len(doc.Events) || xmlDoc.Events[0].UID != first.UID {
+		t.Error("XML and JSON should contain the same events")
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(get("csv"))).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if strings.Join(rows[0], ",") != strings.Join(csvHeader, ",") || len(rows) != len(doc.Events)+1 {
+		t.Fatalf("expected a header and %d rows, got %d rows", len(doc.Events), len(rows))
+	}
+	if rows[1][0] != first.UID {
+		t.Errorf("expected first row UID %s, got %s", first.UID, rows[1][0])
+	}
+
+	if !strings.Contains(get("ics"), "UID:"+first.UID) {
+		t.Error("iCal and JSON should contain the same events")
+	}
+}
+
+func TestUnifiedCalendarHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		accept string
+		status int
+	}{
+		{"invalid format", "/calendar?lat=55.6761&lng=12.5683&format=pdf", "", http.StatusBadRequest},
+		{"nothing acceptable", "/calendar?lat=55.6761&lng=12.5683", "image/png", http.StatusNotAcceptable},
+		{"refused format", "/calendar?lat=55.6761&lng=12.5683", "text/calendar;q=0", http.StatusNotAcceptable},
+		{"missing location", "/calendar?format=json", "", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			UnifiedCalendarHandler(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "ics"},
+		{"text/*", "ics"},
+		{"application/*", "json"},
+		{"text/xml", "xml"},
+		{"application/json;q=0.8, application/xml", "xml"},
+		{"text/html,application/xhtml+xml,*/*;q=0.8", "ics"},
+	}
+
+	for _, tt := range tests {
+		format, ok := negotiateFormat(tt.accept)
+		if !ok || format.name != tt.want {
+			t.Errorf("negotiateFormat(%q) = %s, %v, want %s", tt.accept, format.name, ok, tt.want)
+		}
+	}
+}
+
+func TestWithExtension(t *testing.T) {
+	tests := []struct {
+		filename string
+		ext      string
+		want     string
+	}{
+		{"", "json", ""},
+		{"sun.ics", "ics", "sun.ics"},
+		{"sun.ics", "csv", "sun.csv"},
+		{"sun.JSON.ics", "json", "sun.json"},
+	}
+
+	for _, tt := range tests {
+		if got := withExtension(tt.filename, tt.ext); got != tt.want {
+			t.Errorf("withExtension(%q, %q) = %q, want %q", tt.filename, tt.ext, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"calsun/services"
+)
+
+// minSuggestQueryLength is the shortest query forwarded to the geocoder
+const minSuggestQueryLength = 3
+
+// geocoder is the server-side geocoding proxy shared by all handlers
+var geocoder = services.NewGeocoder(services.NominatimURL)
+
+var (
+	suggestQueryParam = paramDef{
+		Name:        "q",
+		Type:        paramTypeString,
+		Required:    true,
+		Description: "Place name or address to search for",
+	}
+	suggestLimitParam = paramDef{
+		Name:        "limit",
+		Type:        paramTypeInteger,
+		Min:         bound(1),
+		Max:         bound(10),
+		Default:     5,
+		Description: "Maximum number of suggestions",
+	}
+)
+
+// suggestParamDefs lists the parameters accepted by the suggestion endpoint
+var suggestParamDefs = []paramDef{
+	suggestQueryParam,
+	suggestLimitParam,
+}
+
+// suggestResponse is the JSON body returned by GeocodeSuggestHandler
+type suggestResponse struct {
+	Results []services.Place `json:"results"`
+}
+
+// GeocodeSuggestHandler returns location suggestions for a partial query,
+// proxying and caching requests to the geocoding service
+func GeocodeSuggestHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	query := strings.TrimSpace(q.Get(suggestQueryParam.Name))
+	if query == "" {
+		http.Error(w, "q parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	limit, errMsg := suggestLimitParam.parseInt(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	resp := suggestResponse{Results: []services.Place{}}
+	if len([]rune(query)) >= minSuggestQueryLength {
+		places, err := geocoder.Suggest(query, limit)
+		if err != nil {
+			log.Printf("geocode suggest: %v", err)
+			http.Error(w, "geocoding service unavailable", http.StatusBadGateway)
+			return
+		}
+		resp.Results = places
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// SetPlaceStore sets where place names given in calendar URLs are pinned
+func SetPlaceStore(store *services.PlaceStore) {
+	geocoder.UsePlaceStore(store)
+}
+
+// resolvePlace geocodes a place name given in a calendar URL
+func resolvePlace(query string) (services.Place, string) {
+	place, err := geocoder.Resolve(query)
+	var ambiguous *services.AmbiguousPlaceError
+	switch {
+	case errors.Is(err, services.ErrPlaceNotFound):
+		return place, fmt.Sprintf("no place found for %q", query)
+	case errors.As(err, &ambiguous):
+		return place, ambiguous.Error() + "; add a region or country to the place, or use lat and lng"
+	case err != nil:
+		log.Printf("resolve place: %v", err)
+		return place, "geocoding service unavailable; use lat and lng instead"
+	}
+	return place, ""
+}
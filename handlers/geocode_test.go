@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"calsun/services"
+)
+
+// useTestGeocoder points the geocoding proxy at a fake Nominatim server
+func useTestGeocoder(t *testing.T, handler http.HandlerFunc) {
+	server := httptest.NewServer(handler)
+	original := geocoder
+	geocoder = services.NewGeocoder(server.URL)
+	t.Cleanup(func() {
+		geocoder = original
+		server.Close()
+	})
+}
+
+func TestGeocodeSuggestHandler_Results(t *testing.T) {
+	useTestGeocoder(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"lat": "55.6867", "lon": "12.5701", "name": "Copenhagen", "display_name": "Copenhagen, Denmark"}]`))
+	})
+
+	req := httptest.NewRequest("GET", "/api/geocode/suggest?q=Copenh", nil)
+	w := httptest.NewRecorder()
+
+	GeocodeSuggestHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var resp suggestResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].Name != "Copenhagen" {
+		t.Errorf("unexpected results: %+v", resp.Results)
+	}
+}
+
+func TestGeocodeSuggestHandler_ShortQuery(t *testing.T) {
+	useTestGeocoder(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("short queries should not reach the geocoder")
+	})
+
+	req := httptest.NewRequest("GET", "/api/geocode/suggest?q=Co", nil)
+	w := httptest.NewRecorder()
+
+	GeocodeSuggestHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+	if body := w.Body.String(); body != "{\"results\":[]}\n" {
+		t.Errorf("expected empty results, got %s", body)
+	}
+}
+
+func TestGeocodeSuggestHandler_InvalidParams(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"missing q", "/api/geocode/suggest"},
+		{"blank q", "/api/geocode/suggest?q=%20%20"},
+		{"limit too high", "/api/geocode/suggest?q=Copenhagen&limit=50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+
+			GeocodeSuggestHandler(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestGeocodeSuggestHandler_UpstreamError(t *testing.T) {
+	useTestGeocoder(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+
+	req := httptest.NewRequest("GET", "/api/geocode/suggest?q=Copenhagen", nil)
+	w := httptest.NewRecorder()
+
+	GeocodeSuggestHandler(w, req)
+
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected status 502, got %d", w.Code)
+	}
+}
+
+func TestCalendarHandler_Place(t *testing.T) {
+	requests := 0
+	useTestGeocoder(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`[{"lat": "55.68672", "lon": "12.57007", "name": "Copenhagen", "display_name": "Copenhagen, Denmark", "importance": 0.8}]`))
+	})
+
+	for range 2 {
+		w := httptest.NewRecorder()
+		CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?place=Copenhagen&days=1", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		body := unfoldICal(w.Body.String())
+		if !strings.Contains(body, "Sun Times - Copenhagen") {
+			t.Error("expected the place name as calendar name")
+		}
+		if !strings.Contains(body, `Coordinates: 55.6867\, 12.5701`) {
+			t.Error("expected the place coordinates")
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected the place to be geocoded once, got %d requests", requests)
+	}
+}
+
+func TestCalendarHandler_PlaceErrors(t *testing.T) {
+	useTestGeocoder(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("q") {
+		case "Springfield":
+			w.Write([]byte(`[
+				{"lat": "39.799", "lon": "-89.644", "name": "Springfield", "display_name": "Springfield, Illinois, United States", "importance": 0.6},
+				{"lat": "37.209", "lon": "-93.292", "name": "Springfield", "display_name": "Springfield, Missouri, United States", "importance": 0.6}
+			]`))
+		default:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{"with coordinates", "/calendar.ics?place=Paris&lat=48.85&lng=2.35", "cannot be combined"},
+		{"ambiguous", "/calendar.ics?place=Springfield", "Springfield, Missouri"},
+		{"upstream error", "/calendar.ics?place=Unavailable", "use lat and lng"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			CalendarHandler(w, httptest.NewRequest("GET", tt.url, nil))
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.expected) {
+				t.Errorf("expected error containing %q, got %q", tt.expected, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"calsun/services"
+)
+
+const (
+	grafanaDefaultMaxPoints = 1000
+	grafanaMaxPoints        = 10000
+	grafanaMinStep          = time.Minute
+)
+
+// grafanaQueryRequest is the body of a Grafana JSON datasource query
+type grafanaQueryRequest struct {
+	Range struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	} `json:"range"`
+	IntervalMs    int64           `json:"intervalMs"`
+	MaxDataPoints int             `json:"maxDataPoints"`
+	Targets       []grafanaTarget `json:"targets"`
+}
+
+// grafanaTarget is one query of a panel. The location is passed in the
+// target's payload (JSON datasource) or data (SimpleJSON) as lat, lng, and
+// name, falling back to the request's query parameters.
+type grafanaTarget struct {
+	Target  string          `json:"target"`
+	RefID   string          `json:"refId"`
+	Hide    bool            `json:"hide"`
+	Payload json.RawMessage `json:"payload"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// grafanaSeries is a time series in a query response. Each datapoint is
+// [value, unix milliseconds].
+type grafanaSeries struct {
+	Target     string       `json:"target"`
+	RefID      string       `json:"refId,omitempty"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// grafanaMetric is a metric as listed by the JSON datasource's /metrics call
+type grafanaMetric struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// GrafanaTestHandler answers the datasource's connection test
+func GrafanaTestHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("OK"))
+}
+
+// GrafanaSearchHandler lists the available metrics (SimpleJSON /search)
+func GrafanaSearchHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(services.SeriesMetrics)
+}
+
+// GrafanaMetricsHandler lists the available metrics (JSON datasource /metrics)
+func GrafanaMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	metrics := make([]grafanaMetric, len(services.SeriesMetrics))
+	for i, m := range services.SeriesMetrics {
+		metrics[i] = grafanaMetric{Label: m, Value: m}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metrics)
+}
+
+// GrafanaQueryHandler returns the time series for a panel's targets
+func GrafanaQueryHandler(w http.ResponseWriter, r *http.Request) {
+
+	var req grafanaQueryRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		http.Error(w, "invalid query body", http.StatusBadRequest)
+		return
+	}
+	if req.Range.From.IsZero() || req.Range.To.Before(req.Range.From) {
+		http.Error(w, "invalid range", http.StatusBadRequest)
+		return
+	}
+
+	step := grafanaStep(req)
+	series := []grafanaSeries{}
+	for _, target := range req.Targets {
+		if target.Hide || target.Target == "" {
+			continue
+		}
+
+		params, errMsg := grafanaTargetParams(r.URL.Query(), target)
+		if errMsg != "" {
+			http.Error(w, fmt.Sprintf("%s: %s", target.Target, errMsg), http.StatusBadRequest)
+			return
+		}
+
+		points, err := services.Series(target.Target, params.lat, params.lng, req.Range.From, req.Range.To, step)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("%s: %v", target.Target, err), http.StatusBadRequest)
+			return
+		}
+
+		s := grafanaSeries{Target: target.Target, RefID: target.RefID, Datapoints: make([][2]float64, len(points))}
+		if params.name != "" {
+			s.Target = params.name + " " + target.Target
+		}
+		for i, p := range points {
+			s.Datapoints[i] = [2]float64{p.Value, float64(p.Time.UnixMilli())}
+		}
+		series = append(series, s)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(series)
+}
+
+// grafanaStep returns the elevation sampling interval: the panel's interval,
+// widened so the range fits in the requested number of data points
+func grafanaStep(req grafanaQueryRequest) time.Duration {
+	maxPoints := req.MaxDataPoints
+	if maxPoints <= 1 {
+		maxPoints = grafanaDefaultMaxPoints
+	}
+	maxPoints = min(maxPoints, grafanaMaxPoints)
+
+	step := max(time.Duration(req.IntervalMs)*time.Millisecond, grafanaMinStep)
+	span := req.Range.To.Sub(req.Range.From)
+	if minStep := span / time.Duration(maxPoints-1); step < minStep {
+		step = minStep.Truncate(time.Second) + time.Second
+	}
+	return step
+}
+
+// grafanaTargetParams returns the location for a target, from its payload (or
+// data) overlaid on the request's query parameters
+func grafanaTargetParams(defaults url.Values, target grafanaTarget) (*calendarParams, string) {
+	q := url.Values{}
+	for key, values := range defaults {
+		q[key] = values
+	}
+
+	for _, raw := range []json.RawMessage{target.Data, target.Payload} {
+		fields, ok := decodeGrafanaPayload(raw)
+		if !ok {
+			return nil, "invalid payload"
+		}
+		for key, value := range fields {
+			q.Set(key, fmt.Sprint(value))
+		}
+	}
+
+	return parseCalendarQuery(q)
+}
+
+// decodeGrafanaPayload decodes a target payload, which is either a JSON
+// object or a string containing one (as entered in the query editor)
+func decodeGrafanaPayload(raw json.RawMessage) (map[string]any, bool) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, true
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err == nil {
+		return fields, true
+	}
+
+	var str string
+	if err := json.Unmarshal(raw, &str); err != nil {
+		return nil, false
+	}
+	if str == "" {
+		return nil, true
+	}
+	if err := json.Unmarshal([]byte(str), &fields); err != nil {
+		return nil, false
+	}
+	return fields, true
+}
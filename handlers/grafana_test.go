@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGrafanaSearchHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	GrafanaSearchHandler(w, httptest.NewRequest("POST", "/grafana/search", strings.NewReader(`{"target":""}`)))
+
+	var metrics []string
+	if err := json.Unmarshal(w.Body.Bytes(), &metrics); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if strings.Join(metrics, ",") != "day_length,night_length,darkness,sunrise,sunset,elevation" {
+		t.Errorf("unexpected metrics: %v", metrics)
+	}
+
+	w = httptest.NewRecorder()
+	GrafanaMetricsHandler(w, httptest.NewRequest("POST", "/grafana/metrics", strings.NewReader(`{}`)))
+	if !strings.Contains(w.Body.String(), `{"label":"day_length","value":"day_length"}`) {
+		t.Errorf("unexpected metrics response: %s", w.Body.String())
+	}
+}
+
+func TestGrafanaQueryHandler(t *testing.T) {
+	body := `{
+		"range": {"from": "2024-06-01T00:00:00Z", "to": "2024-06-07T23:59:59Z"},
+		"intervalMs": 3600000,
+		"maxDataPoints": 500,
+		"targets": [
+			{"target": "day_length", "refId": "A", "payload": {"lat": 55.6761, "lng": 12.5683, "name": "Copenhagen"}},
+			{"target": "elevation", "refId": "B", "data": "{\"lat\": \"55.6761\", \"lng\": \"12.5683\"}"},
+			{"target": "sunrise", "refId": "C", "hide": true}
+		]
+	}`
+	w := httptest.NewRecorder()
+	GrafanaQueryHandler(w, httptest.NewRequest("POST", "/grafana/query", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var series []grafanaSeries
+	if err := json.Unmarshal(w.Body.Bytes(), &series); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(series) != 2 {
+		t.Fatalf("expected 2 series (hidden target skipped), got %d", len(series))
+	}
+
+	dayLength := series[0]
+	if dayLength.Target != "Copenhagen day_length" || dayLength.RefID != "A" {
+		t.Errorf("unexpected series: %s (%s)", dayLength.Target, dayLength.RefID)
+	}
+	// Local days from May 31 (Copenhagen midnight is 22:00 UTC) to June 7
+	if len(dayLength.Datapoints) != 8 {
+		t.Errorf("expected 8 daily points, got %d", len(dayLength.Datapoints))
+	}
+	for _, p := range dayLength.Datapoints {
+		if p[0] < 17 || p[0] > 18 {
+			t.Errorf("unexpected day length %.2f", p[0])
+		}
+	}
+
+	// Hourly elevation over 7 days: 168 intervals
+	elevation := series[1]
+	if elevation.Target != "elevation" || len(elevation.Datapoints) != 168 {
+		t.Errorf("expected 168 hourly elevation points, got %d", len(elevation.Datapoints))
+	}
+	if first := elevation.Datapoints[0][1]; first != 1717200000000 {
+		t.Errorf("expected first point at the range start, got %v", first)
+	}
+}
+
+func TestGrafanaQueryHandler_LocationFromQuery(t *testing.T) {
+	body := `{"range": {"from": "2024-06-01T12:00:00Z", "to": "2024-06-01T12:00:00Z"}, "targets": [{"target": "sunset"}]}`
+	w := httptest.NewRecorder()
+	GrafanaQueryHandler(w, httptest.NewRequest("POST", "/grafana/query?lat=55.6761&lng=12.5683", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"target":"sunset"`) {
+		t.Errorf("unexpected response: %s", w.Body.String())
+	}
+}
+
+func TestGrafanaQueryHandler_MaxDataPoints(t *testing.T) {
+	body := `{
+		"range": {"from": "2024-01-01T00:00:00Z", "to": "2024-12-31T00:00:00Z"},
+		"intervalMs": 60000,
+		"maxDataPoints": 100,
+		"targets": [{"target": "elevation", "payload": {"lat": 55.6761, "lng": 12.5683}}]
+	}`
+	w := httptest.NewRecorder()
+	GrafanaQueryHandler(w, httptest.NewRequest("POST", "/grafana/query", strings.NewReader(body)))
+
+	var series []grafanaSeries
+	if err := json.Unmarshal(w.Body.Bytes(), &series); err != nil || len(series) != 1 {
+		t.Fatalf("unexpected response: %s", w.Body.String())
+	}
+	if n := len(series[0].Datapoints); n > 100 || n < 90 {
+		t.Errorf("expected at most 100 points, got %d", n)
+	}
+}
+
+func TestGrafanaQueryHandler_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"malformed body", `{"range":`},
+		{"missing range", `{"targets": [{"target": "sunrise", "payload": {"lat": 55, "lng": 12}}]}`},
+		{"missing location", `{"range": {"from": "2024-06-01T00:00:00Z", "to": "2024-06-02T00:00:00Z"}, "targets": [{"target": "sunrise"}]}`},
+		{"invalid latitude", `{"range": {"from": "2024-06-01T00:00:00Z", "to": "2024-06-02T00:00:00Z"}, "targets": [{"target": "sunrise", "payload": {"lat": 95, "lng": 12}}]}`},
+		{"invalid payload", `{"range": {"from": "2024-06-01T00:00:00Z", "to": "2024-06-02T00:00:00Z"}, "targets": [{"target": "sunrise", "payload": "lat=55"}]}`},
+		{"unknown metric", `{"range": {"from": "2024-06-01T00:00:00Z", "to": "2024-06-02T00:00:00Z"}, "targets": [{"target": "uv_index", "payload": {"lat": 55, "lng": 12}}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			GrafanaQueryHandler(w, httptest.NewRequest("POST", "/grafana/query", strings.NewReader(tt.body)))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
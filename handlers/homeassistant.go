@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"time"
+
+	"calsun/services"
+)
+
+// horizonElevation is the sun elevation at sunrise and sunset, accounting for
+// refraction and the sun's radius
+const horizonElevation = -0.833
+
+// homeAssistantParamDefs lists the parameters accepted by
+// HomeAssistantHandler. The sensor reports the current state, so it takes no
+// date.
+var homeAssistantParamDefs = []paramDef{latParam, lngParam, nameParam, azFormatParam}
+
+// homeAssistantResponse is the JSON body returned by HomeAssistantHandler.
+// All keys are flat so a Home Assistant REST sensor can read them with
+// json_attributes. Times are ISO 8601 in the location's timezone and null when
+// the sun does not rise or set.
+type homeAssistantResponse struct {
+	State              string     `json:"state"` // "above_horizon" or "below_horizon"
+	Location           string     `json:"location"`
+	Timezone           string     `json:"timezone"`
+	Elevation          float64    `json:"elevation"`
+	Azimuth            float64    `json:"azimuth"`
+	AzimuthCompass     string     `json:"azimuth_compass,omitempty"` // With azformat=compass or both
+	TodaySunrise       *time.Time `json:"today_sunrise"`
+	TodaySunset        *time.Time `json:"today_sunset"`
+	TomorrowSunrise    *time.Time `json:"tomorrow_sunrise"`
+	TomorrowSunset     *time.Time `json:"tomorrow_sunset"`
+	DayLengthSeconds   int        `json:"day_length_seconds"`
+	NightLengthSeconds *int       `json:"night_length_seconds"` // Tonight, from today's sunset to tomorrow's sunrise
+	DarknessSeconds    *int       `json:"darkness_seconds"`     // Tonight, from astronomical dusk to dawn
+	NextEvent          string     `json:"next_event"`           // "sunrise", "sunset", or "" if neither occurs in the next two days
+	NextEventTime      *time.Time `json:"next_event_time"`
+	NextEventInSeconds int        `json:"next_event_in_seconds"`
+	Updated            time.Time  `json:"updated"`
+}
+
+// HomeAssistantHandler returns the current sun state plus today's and
+// tomorrow's events for a location, shaped for a Home Assistant REST sensor
+func HomeAssistantHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	if !params.date.IsZero() {
+		http.Error(w, "date is not supported: the sensor reports the current state", http.StatusBadRequest)
+		return
+	}
+
+	tz := services.GetTimezone(params.lat, params.lng)
+	now := params.now.In(tz).Truncate(time.Second)
+	noon := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, tz)
+	today := services.GetSunTimes(params.lat, params.lng, noon)
+	tomorrow := services.GetSunTimes(params.lat, params.lng, noon.AddDate(0, 0, 1))
+
+	azimuth, elevation := services.SunPosition(params.lat, params.lng, now)
+
+	resp := homeAssistantResponse{
+		State:           "below_horizon",
+		Location:        locationName(params),
+		Timezone:        tz.String(),
+		Elevation:       round2(elevation),
+		Azimuth:         round2(azimuth),
+		TodaySunrise:    localEventTime(today.Sunrise, tz),
+		TodaySunset:     localEventTime(today.Sunset, tz),
+		TomorrowSunrise: localEventTime(tomorrow.Sunrise, tz),
+		TomorrowSunset:  localEventTime(tomorrow.Sunset, tz),
+		Updated:         now,
+	}
+	if params.azimuthFormat != services.AzimuthDegrees {
+		resp.AzimuthCompass = services.CompassPoint(azimuth)
+	}
+	if elevation > horizonElevation {
+		resp.State = "above_horizon"
+	}
+	if today.Sunrise != nil && today.Sunset != nil {
+		resp.DayLengthSeconds = int(today.Sunset.Time.Sub(today.Sunrise.Time).Seconds())
+	}
+	if nightLength, ok := today.NightLength(tomorrow); ok {
+		seconds := int(nightLength.Seconds())
+		resp.NightLengthSeconds = &seconds
+	}
+	if darkness, ok := today.DarknessLength(tomorrow); ok {
+		seconds := int(darkness.Seconds())
+		resp.DarknessSeconds = &seconds
+	}
+
+	for _, event := range []*services.SunEvent{today.Sunrise, today.Sunset, tomorrow.Sunrise, tomorrow.Sunset} {
+		if event == nil || !event.Time.After(now) {
+			continue
+		}
+		if resp.NextEventTime == nil || event.Time.Before(*resp.NextEventTime) {
+			resp.NextEvent = event.Type
+			resp.NextEventTime = localEventTime(event, tz)
+		}
+	}
+	if resp.NextEventTime != nil {
+		resp.NextEventInSeconds = int(resp.NextEventTime.Sub(now).Seconds())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// localEventTime returns the event's time in tz, or nil if there is no event
+func localEventTime(event *services.SunEvent, tz *time.Location) *time.Time {
+	if event == nil {
+		return nil
+	}
+	t := event.Time.In(tz)
+	return &t
+}
+
+// round2 rounds v to two decimal places
+func round2(v float64) float64 {
+	return roundTo(v, 2)
+}
+
+// roundTo rounds a value to decimals decimal places
+func roundTo(v float64, decimals int) float64 {
+	scale := math.Pow10(decimals)
+	return math.Round(v*scale) / scale
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"calsun/services"
+)
+
+func TestHomeAssistantHandler_ValidRequest(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/homeassistant?lat=55.6761&lng=12.5683&name=Copenhagen", nil)
+	w := httptest.NewRecorder()
+
+	HomeAssistantHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var resp homeAssistantResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if resp.Location != "Copenhagen" || resp.Timezone != "Europe/Copenhagen" {
+		t.Errorf("unexpected location %q / timezone %q", resp.Location, resp.Timezone)
+	}
+	if resp.State != "above_horizon" && resp.State != "below_horizon" {
+		t.Errorf("unexpected state %q", resp.State)
+	}
+	if (resp.State == "above_horizon") != (resp.Elevation > horizonElevation) {
+		t.Errorf("state %q does not match elevation %.2f", resp.State, resp.Elevation)
+	}
+	if resp.TodaySunrise == nil || resp.TodaySunset == nil || resp.TomorrowSunrise == nil || resp.TomorrowSunset == nil {
+		t.Fatal("expected today's and tomorrow's sunrise and sunset")
+	}
+	if !resp.TomorrowSunrise.After(*resp.TodaySunrise) {
+		t.Error("tomorrow's sunrise should be after today's")
+	}
+
+	if resp.NextEvent != "sunrise" && resp.NextEvent != "sunset" {
+		t.Fatalf("expected a next event, got %q", resp.NextEvent)
+	}
+	if resp.NextEventTime == nil || !resp.NextEventTime.After(resp.Updated) {
+		t.Error("next event should be in the future")
+	}
+	if want := int(resp.NextEventTime.Sub(resp.Updated).Seconds()); resp.NextEventInSeconds != want {
+		t.Errorf("expected next_event_in_seconds %d, got %d", want, resp.NextEventInSeconds)
+	}
+}
+
+func TestHomeAssistantHandler_FlatKeys(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/homeassistant?lat=55.6761&lng=12.5683", nil)
+	w := httptest.NewRecorder()
+
+	HomeAssistantHandler(w, req)
+
+	var raw map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &raw); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	for key, value := range raw {
+		switch value.(type) {
+		case map[string]any, []any:
+			t.Errorf("key %q is nested; Home Assistant sensors need flat keys", key)
+		}
+	}
+	for _, key := range []string{"next_event", "night_length_seconds", "darkness_seconds"} {
+		if _, ok := raw[key]; !ok {
+			t.Errorf("expected %s key", key)
+		}
+	}
+}
+
+func TestHomeAssistantHandler_InvalidParams(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/homeassistant?lng=12.5683", nil)
+	w := httptest.NewRecorder()
+
+	HomeAssistantHandler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
+
+func TestHomeAssistantHandler_RejectsDate(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/homeassistant?lat=55.6761&lng=12.5683&date=2024-06-21", nil)
+	w := httptest.NewRecorder()
+
+	HomeAssistantHandler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a date, got %d", w.Code)
+	}
+}
+
+func TestHomeAssistantHandler_AzimuthCompass(t *testing.T) {
+	for _, tt := range []struct {
+		url     string
+		present bool
+	}{
+		{"/api/homeassistant?lat=55.6761&lng=12.5683", false},
+		{"/api/homeassistant?lat=55.6761&lng=12.5683&azformat=compass", true},
+	} {
+		w := httptest.NewRecorder()
+		HomeAssistantHandler(w, httptest.NewRequest("GET", tt.url, nil))
+
+		var resp homeAssistantResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if (resp.AzimuthCompass != "") != tt.present {
+			t.Errorf("%s: unexpected azimuth_compass %q", tt.url, resp.AzimuthCompass)
+		}
+		if tt.present && resp.AzimuthCompass != services.CompassPoint(resp.Azimuth) {
+			t.Errorf("expected compass point of %.2f°, got %s", resp.Azimuth, resp.AzimuthCompass)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	ics "github.com/arran4/golang-ical"
+)
+
+const (
+	// icsLineLength is the longest content line, in octets, RFC 5545 allows
+	// before folding
+	icsLineLength = 75
+	// maxICSIssues caps the errors and warnings reported for a calendar
+	maxICSIssues = 100
+)
+
+// icsPropertyLine matches the start of a content line: a property name
+// followed by parameters or the value
+var icsPropertyLine = regexp.MustCompile(`^[A-Za-z0-9-]+[;:]`)
+
+// icsIssue is an RFC 5545 violation or a client compatibility problem
+type icsIssue struct {
+	Line     int    `json:"line,omitempty"` // Line of the problem, if known
+	Property string `json:"property,omitempty"`
+	Message  string `json:"message"`
+}
+
+// icsReport is the result of linting a calendar. Errors are RFC 5545
+// violations; warnings are valid iCalendar that some clients mishandle.
+type icsReport struct {
+	Valid     bool       `json:"valid"` // No errors
+	Events    int        `json:"events"`
+	Errors    []icsIssue `json:"errors"`
+	Warnings  []icsIssue `json:"warnings"`
+	Truncated bool       `json:"truncated,omitempty"` // More than maxICSIssues of either
+}
+
+func (r *icsReport) error(line int, property, format string, args ...any) {
+	r.add(&r.Errors, icsIssue{line, property, fmt.Sprintf(format, args...)})
+}
+
+func (r *icsReport) warn(line int, property, format string, args ...any) {
+	r.add(&r.Warnings, icsIssue{line, property, fmt.Sprintf(format, args...)})
+}
+
+func (r *icsReport) add(issues *[]icsIssue, issue icsIssue) {
+	if len(*issues) >= maxICSIssues {
+		r.Truncated = true
+		return
+	}
+	*issues = append(*issues, issue)
+}
+
+// lintICS checks a calendar in two passes: the raw content lines (line
+// endings, folding, length), then the components parsed by golang-ical
+// (required properties, dates, UIDs).
+func lintICS(data []byte) icsReport {
+	report := icsReport{Errors: []icsIssue{}, Warnings: []icsIssue{}}
+	eventLines := lintICSLines(data, &report)
+
+	cal, err := ics.ParseCalendar(bytes.NewReader(data))
+	if err != nil {
+		report.error(0, "", "unparseable calendar: %v", err)
+	} else {
+		lintICSCalendar(cal, eventLines, &report)
+	}
+	report.Valid = len(report.Errors) == 0
+	return report
+}
+
+// lintICSLines checks the raw content lines and returns the line numbers of
+// the events' BEGIN:VEVENT lines
+func lintICSLines(data []byte, report *icsReport) []int {
+	lines := strings.Split(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var eventLines []int
+	bareLF, firstBareLF := 0, 0
+	for i, line := range lines {
+		n := i + 1
+		if text, ok := strings.CutSuffix(line, "\r"); ok {
+			line = text
+		} else {
+			if bareLF == 0 {
+				firstBareLF = n
+			}
+			bareLF++
+		}
+		if strings.Contains(line, "\r") {
+			report.error(n, "", "bare CR inside a line")
+		}
+		if len(line) > icsLineLength {
+			report.warn(n, "", "line is %d octets long; lines longer than %d should be folded, and some clients cut them off", len(line), icsLineLength)
+		}
+
+		switch {
+		case line == "":
+			report.error(n, "", "empty line")
+		case line[0] == ' ' || line[0] == '\t':
+			if i == 0 {
+				report.error(n, "", "folded continuation without a line to continue")
+			} else if len(line) > 1 && !utf8.RuneStart(line[1]) {
+				report.warn(n, "", "fold splits a multi-byte UTF-8 character; some clients show garbled text")
+			}
+		case !icsPropertyLine.MatchString(line):
+			report.error(n, "", "naked line fold: not a property or a folded continuation (line breaks inside values must be escaped as \\n, and folds start with a space)")
+		case strings.EqualFold(line, "BEGIN:VEVENT"):
+			eventLines = append(eventLines, n)
+		}
+	}
+	if bareLF > 0 {
+		report.error(firstBareLF, "", "%d of %d lines end with a bare LF instead of CRLF", bareLF, len(lines))
+	}
+	return eventLines
+}
+
+// lintICSCalendar checks the parsed calendar and its events. eventLines
+// gives the line each event starts on, in order.
+func lintICSCalendar(cal *ics.Calendar, eventLines []int, report *icsReport) {
+	calProps := map[string]string{}
+	for _, p := range cal.CalendarProperties {
+		calProps[p.IANAToken] = p.Value
+	}
+	if version, ok := calProps[string(ics.PropertyVersion)]; !ok {
+		report.error(0, "VERSION", "calendar has no VERSION")
+	} else if version != "2.0" {
+		report.error(0, "VERSION", "VERSION is %q, not 2.0", version)
+	}
+	if _, ok := calProps[string(ics.PropertyProductId)]; !ok {
+		report.error(0, "PRODID", "calendar has no PRODID")
+	}
+	_, hasName := calProps[string(ics.PropertyName)]
+	if _, ok := calProps[string(ics.PropertyXWRCalName)]; !ok && !hasName {
+		report.warn(0, "X-WR-CALNAME", "calendar has no name (NAME or X-WR-CALNAME); clients show the feed's URL instead")
+	}
+
+	timezones := map[string]bool{}
+	for _, c := range cal.Components {
+		if tz, ok := c.(*ics.VTimezone); ok {
+			if id := tz.GetProperty(ics.ComponentPropertyTzid); id != nil {
+				timezones[id.Value] = true
+			}
+		}
+	}
+
+	events := cal.Events()
+	report.Events = len(events)
+	uids := map[string]int{}
+	for i, event := range events {
+		line := 0
+		if i < len(eventLines) {
+			line = eventLines[i]
+		}
+
+		uid := event.GetProperty(ics.ComponentPropertyUniqueId)
+		switch {
+		case uid == nil || uid.Value == "":
+			report.error(line, "UID", "event has no UID")
+		case event.GetProperty(ics.ComponentPropertyRecurrenceId) != nil:
+			// Overrides of a recurring event share its UID
+		case uids[uid.Value] != 0:
+			report.error(line, "UID", "duplicate UID %q (also the event on line %d); clients keep only one of them", uid.Value, uids[uid.Value])
+		default:
+			uids[uid.Value] = line
+		}
+		if event.GetProperty(ics.ComponentPropertyDtstamp) == nil {
+			report.error(line, "DTSTAMP", "event has no DTSTAMP")
+		}
+		if event.GetProperty(ics.ComponentPropertySummary) == nil {
+			report.warn(line, "SUMMARY", "event has no SUMMARY; clients show it untitled")
+		}
+
+		startProp := event.GetProperty(ics.ComponentPropertyDtStart)
+		if startProp == nil {
+			report.error(line, "DTSTART", "event has no DTSTART")
+		}
+		start, startDate, startOK := lintICSTime(startProp, timezones, line, report)
+		endProp := event.GetProperty(ics.ComponentPropertyDtEnd)
+		if endProp != nil && event.GetProperty(ics.ComponentPropertyDuration) != nil {
+			report.error(line, "DTEND", "event has both DTEND and DURATION")
+		}
+		end, endDate, endOK := lintICSTime(endProp, timezones, line, report)
+		if startOK && endOK {
+			if startDate != endDate {
+				report.error(line, "DTEND", "DTSTART and DTEND must both be dates or both be date-times")
+			} else if end.Before(start) {
+				report.error(line, "DTEND", "DTEND is before DTSTART")
+			}
+		}
+	}
+}
+
+// lintICSTime parses a DTSTART or DTEND property, reporting invalid values
+// and timezones without a VTIMEZONE. It returns the time, whether it is a
+// date, and whether it was parsed.
+func lintICSTime(prop *ics.IANAProperty, timezones map[string]bool, line int, report *icsReport) (time.Time, bool, bool) {
+	if prop == nil {
+		return time.Time{}, false, false
+	}
+	name := prop.IANAToken
+	isDate := len(prop.ICalParameters["VALUE"]) > 0 && prop.ICalParameters["VALUE"][0] == "DATE"
+	layout := "20060102T150405"
+	switch {
+	case isDate:
+		layout = "20060102"
+	case strings.HasSuffix(prop.Value, "Z"):
+		layout = "20060102T150405Z"
+	}
+	t, err := time.Parse(layout, prop.Value)
+	if err != nil {
+		if isDate {
+			report.error(line, name, "invalid %s date %q (expected YYYYMMDD)", name, prop.Value)
+		} else {
+			report.error(line, name, "invalid %s date-time %q (expected YYYYMMDDTHHMMSS, optionally ending in Z)", name, prop.Value)
+		}
+		return time.Time{}, false, false
+	}
+	if tzid := prop.ICalParameters["TZID"]; len(tzid) > 0 && !timezones[tzid[0]] {
+		report.error(line, name, "TZID %q has no VTIMEZONE", tzid[0])
+	}
+	return t, isDate, true
+}
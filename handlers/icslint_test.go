@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// validICS is a minimal calendar without errors or warnings
+const validICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Test//EN\r\n" +
+	"X-WR-CALNAME:Test\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:a@test\r\n" +
+	"DTSTAMP:20240101T000000Z\r\n" +
+	"DTSTART:20240621T035800Z\r\n" +
+	"DTEND:20240621T035900Z\r\n" +
+	"SUMMARY:Sunrise\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+// hasIssue reports whether issues has one on line whose message contains text
+func hasIssue(issues []icsIssue, line int, text string) bool {
+	for _, issue := range issues {
+		if issue.Line == line && strings.Contains(issue.Message, text) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLintICS_Valid(t *testing.T) {
+	report := lintICS([]byte(validICS))
+	if !report.Valid || len(report.Errors) != 0 || len(report.Warnings) != 0 || report.Events != 1 {
+		t.Errorf("expected a clean report with 1 event, got %+v", report)
+	}
+}
+
+func TestLintICS_Issues(t *testing.T) {
+	tests := []struct {
+		name    string
+		ics     string
+		line    int
+		message string
+		warning bool
+	}{
+		{"bare LF", strings.ReplaceAll(validICS, "\r\n", "\n"), 1, "12 of 12 lines end with a bare LF", false},
+		{"naked fold", strings.Replace(validICS, "SUMMARY:Sunrise\r\n", "SUMMARY:Sunrise\r\nat dawn\r\n", 1), 11, "naked line fold", false},
+		{"empty line", strings.Replace(validICS, "END:VEVENT\r\n", "\r\nEND:VEVENT\r\n", 1), 11, "empty line", false},
+		{"missing DTSTAMP", strings.Replace(validICS, "DTSTAMP:20240101T000000Z\r\n", "", 1), 5, "no DTSTAMP", false},
+		{"missing UID", strings.Replace(validICS, "UID:a@test\r\n", "", 1), 5, "no UID", false},
+		{"missing PRODID", strings.Replace(validICS, "PRODID:-//Test//EN\r\n", "", 1), 0, "no PRODID", false},
+		{"wrong version", strings.Replace(validICS, "VERSION:2.0", "VERSION:1.0", 1), 0, `VERSION is "1.0"`, false},
+		{"invalid date-time", strings.Replace(validICS, "DTEND:20240621T035900Z", "DTEND:2024-06-21", 1), 5, "invalid DTEND date-time", false},
+		{"end before start", strings.Replace(validICS, "DTEND:20240621T035900Z", "DTEND:20240620T035900Z", 1), 5, "DTEND is before DTSTART", false},
+		{"mixed value types", strings.Replace(validICS, "DTEND:20240621T035900Z", "DTEND;VALUE=DATE:20240622", 1), 5, "both be dates", false},
+		{"unknown TZID", strings.Replace(validICS, "DTSTART:20240621T035800Z", "DTSTART;TZID=Europe/Copenhagen:20240621T055800", 1), 5, "has no VTIMEZONE", false},
+		{"duplicate UID", strings.Replace(validICS, "END:VCALENDAR", "BEGIN:VEVENT\r\nUID:a@test\r\nDTSTAMP:20240101T000000Z\r\nDTSTART:20240622T035800Z\r\nSUMMARY:Sunrise\r\nEND:VEVENT\r\nEND:VCALENDAR", 1), 12, "duplicate UID", false},
+		{"unparseable", "BEGIN:VEVENT\r\nEND:VEVENT\r\n", 0, "unparseable calendar", false},
+		{"long line", strings.Replace(validICS, "SUMMARY:Sunrise", "SUMMARY:"+strings.Repeat("x", 80), 1), 10, "88 octets long", true},
+		{"split character", strings.Replace(validICS, "SUMMARY:Sunrise", "SUMMARY:Solopgang \xc3\r\n \xa5", 1), 11, "splits a multi-byte UTF-8 character", true},
+		{"missing name", strings.Replace(validICS, "X-WR-CALNAME:Test\r\n", "", 1), 0, "calendar has no name", true},
+		{"missing summary", strings.Replace(validICS, "SUMMARY:Sunrise\r\n", "", 1), 5, "no SUMMARY", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			report := lintICS([]byte(tc.ics))
+			issues := report.Errors
+			if tc.warning {
+				issues = report.Warnings
+			}
+			if !hasIssue(issues, tc.line, tc.message) {
+				t.Errorf("expected an issue on line %d containing %q, got %+v", tc.line, tc.message, report)
+			}
+			if report.Valid != tc.warning {
+				t.Errorf("valid = %v, want %v", report.Valid, tc.warning)
+			}
+		})
+	}
+}
+
+func TestLintICS_Truncated(t *testing.T) {
+	ics := strings.Replace(validICS, "SUMMARY:Sunrise\r\n", strings.Repeat("SUMMARY:"+strings.Repeat("x", 80)+"\r\n", maxICSIssues+1), 1)
+	report := lintICS([]byte(ics))
+	if len(report.Warnings) != maxICSIssues || !report.Truncated {
+		t.Errorf("expected %d warnings and truncated, got %d (truncated %v)", maxICSIssues, len(report.Warnings), report.Truncated)
+	}
+}
+
+// CalSun's own feeds must be valid and free of compatibility warnings
+func TestLintICS_CalSunFeeds(t *testing.T) {
+	feeds := []struct {
+		path    string
+		handler http.HandlerFunc
+	}{
+		{"/calendar.ics?lat=55.6761&lng=12.5683&name=København&days=7&twilight=civil,nautical&details=longitude,season&apsis=perigee,apogee&summary=weekly,monthly", CalendarHandler},
+		{"/calendar.ics?lat=78.2232&lng=15.6267&name=Longyearbyen&days=30", CalendarHandler},
+		{"/prayer.ics?lat=21.4225&lng=39.8262&name=Makkah&days=3", PrayerCalendarHandler},
+		{"/shabbat.ics?lat=31.7683&lng=35.2137&name=Jerusalem&days=14", ShabbatCalendarHandler},
+		{"/ramadan.ics?lat=21.4225&lng=39.8262&name=Makkah", RamadanCalendarHandler},
+	}
+
+	for _, feed := range feeds {
+		t.Run(feed.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			feed.handler(w, httptest.NewRequest("GET", feed.path, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+
+			report := lintICS(w.Body.Bytes())
+			if !report.Valid || len(report.Warnings) != 0 || report.Events == 0 {
+				t.Errorf("expected a clean report, got %+v", report)
+			}
+		})
+	}
+}
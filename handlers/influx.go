@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"calsun/services"
+)
+
+var influxDaysParam = paramDef{
+	Name:        "days",
+	Type:        paramTypeInteger,
+	Min:         bound(1),
+	Max:         bound(maxDays),
+	Default:     1,
+	Description: "Number of days, starting today",
+}
+
+// influxParamDefs lists the parameters accepted by the InfluxDB export
+var influxParamDefs = []paramDef{latParam, lngParam, nameParam, influxDaysParam}
+
+// InfluxHandler returns daily sun metrics for a location in InfluxDB line
+// protocol, for scraping by Telegraf or similar collectors
+func InfluxHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	days, errMsg := influxDaysParam.parseInt(r.URL.Query())
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	points := services.SunMetricPoints(params.lat, params.lng, params.name, params.now, days)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	services.WriteLineProtocol(w, points)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInfluxHandler(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/influx?lat=55.6761&lng=12.5683&name=Copenhagen&days=3", nil)
+	w := httptest.NewRecorder()
+
+	InfluxHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("expected text/plain, got %s", ct)
+	}
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "sun,lat=55.6761,lng=12.5683,location=Copenhagen ") {
+			t.Errorf("unexpected line: %s", line)
+		}
+		if !strings.Contains(line, "day_length_seconds=") || !strings.Contains(line, "solar_noon_altitude=") {
+			t.Errorf("missing fields: %s", line)
+		}
+	}
+}
+
+func TestInfluxHandler_DefaultDays(t *testing.T) {
+	w := httptest.NewRecorder()
+	InfluxHandler(w, httptest.NewRequest("GET", "/api/influx?lat=55.6761&lng=12.5683", nil))
+
+	if n := strings.Count(w.Body.String(), "\n"); n != 1 {
+		t.Errorf("expected a single line by default, got %d", n)
+	}
+}
+
+func TestInfluxHandler_InvalidParams(t *testing.T) {
+	for _, query := range []string{"lat=abc&lng=12", "lat=55&lng=12&days=0", "lat=55&lng=12&days=1000"} {
+		w := httptest.NewRecorder()
+		InfluxHandler(w, httptest.NewRequest("GET", "/api/influx?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"calsun/services"
+)
+
+// syncer pushes events into users' calendars; nil when no integration is configured
+var syncer *services.Syncer
+
+// SetSyncer sets the sync engine used by the integration handlers
+func SetSyncer(s *services.Syncer) {
+	syncer = s
+}
+
+// integrationLabels are the display names of known push providers
+var integrationLabels = map[string]string{
+	"google":    "Google Calendar",
+	"microsoft": "Outlook",
+}
+
+// integrationLink is a push provider offered in the web UI
+type integrationLink struct {
+	ID    string
+	Label string
+}
+
+// enabledIntegrations returns the configured push providers
+func enabledIntegrations() []integrationLink {
+	if syncer == nil {
+		return nil
+	}
+
+	var links []integrationLink
+	for _, name := range syncer.Providers() {
+		label, ok := integrationLabels[name]
+		if !ok {
+			label = name
+		}
+		links = append(links, integrationLink{ID: name, Label: label})
+	}
+	return links
+}
+
+// integrationProvider returns the push provider named in the request path,
+// writing a 404 if it is not configured
+func integrationProvider(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name := r.PathValue("provider")
+	if syncer == nil {
+		http.NotFound(w, r)
+		return "", false
+	}
+	if _, ok := syncer.Provider(name); !ok {
+		http.NotFound(w, r)
+		return "", false
+	}
+	return name, true
+}
+
+// integrationRedirectURI returns the OAuth callback URL for a provider
+func integrationRedirectURI(r *http.Request, provider string) string {
+	return appURL(r, "/integrations/"+provider+"/callback")
+}
+
+// IntegrationConnectHandler starts the OAuth flow for pushing the calendar
+// described by the query parameters into the user's calendar
+func IntegrationConnectHandler(w http.ResponseWriter, r *http.Request) {
+	provider, ok := integrationProvider(w, r)
+	if !ok {
+		return
+	}
+
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	authURL, err := syncer.BeginConnect(services.SyncSubscription{
+		Provider:       provider,
+		Lat:            params.lat,
+		Lng:            params.lng,
+		Name:           params.name,
+		Days:           params.days,
+		Events:         params.events.Types(),
+		IncludeSunrise: params.events.Has(services.EventSunrise),
+		IncludeSunset:  params.events.Has(services.EventSunset),
+	}, integrationRedirectURI(r, provider))
+	if err != nil {
+		log.Printf("integration connect: %v", err)
+		http.Error(w, "failed to start authorization", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// IntegrationCallbackHandler completes the OAuth flow, stores the
+// subscription, and starts the first sync in the background
+func IntegrationCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	provider, ok := integrationProvider(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	if q.Get("error") != "" {
+		renderStatusPage(w, http.StatusOK, statusData{
+			Heading: "Not connected",
+			Message: "Access was not granted, so no events will be added to your calendar.",
+		})
+		return
+	}
+
+	sub, err := syncer.CompleteConnect(r.Context(), q.Get("state"), q.Get("code"), integrationRedirectURI(r, provider))
+	if errors.Is(err, services.ErrUnknownState) {
+		http.Error(w, "authorization expired, please try again", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("integration callback: %v", err)
+		http.Error(w, "failed to complete authorization", http.StatusBadGateway)
+		return
+	}
+
+	s := syncer
+	go func() {
+		if err := s.Sync(context.Background(), sub.ID); err != nil {
+			log.Printf("initial sync %s: %v", sub.ID[:8], err)
+		}
+	}()
+
+	renderStatusPage(w, http.StatusOK, statusData{
+		Heading:        "Connected",
+		Message:        "Sunrise and sunset events will appear in your calendar shortly and are kept up to date automatically.",
+		SubscriptionID: sub.ID,
+	})
+}
+
+// IntegrationDisconnectHandler stops syncing a subscription. Events already
+// written to the user's calendar are left in place.
+func IntegrationDisconnectHandler(w http.ResponseWriter, r *http.Request) {
+	if syncer == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	deleted, err := syncer.Store().Delete(r.FormValue("id"))
+	if err != nil {
+		log.Printf("integration disconnect: %v", err)
+		http.Error(w, "failed to disconnect", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "unknown subscription", http.StatusNotFound)
+		return
+	}
+
+	renderStatusPage(w, http.StatusOK, statusData{
+		Heading: "Disconnected",
+		Message: "Syncing has stopped. Events already in your calendar were not removed.",
+	})
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+// fakePushProvider accepts all events without contacting a remote service
+type fakePushProvider struct {
+	oauth *services.OAuthConfig
+
+	mu      sync.Mutex
+	upserts int
+}
+
+func (p *fakePushProvider) OAuth() *services.OAuthConfig { return p.oauth }
+func (p *fakePushProvider) DefaultCalendarID() string    { return "primary" }
+
+func (p *fakePushProvider) UpsertEvent(ctx context.Context, accessToken, calendarID string, event services.CalendarEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.upserts++
+	return nil
+}
+
+// withTestSyncer installs a syncer with a fake "google" provider for the
+// duration of the test
+func withTestSyncer(t *testing.T) *services.Syncer {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "good" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"at","refresh_token":"rt","expires_in":3600}`))
+	}))
+	t.Cleanup(tokenServer.Close)
+
+	store, err := services.OpenSyncStore(filepath.Join(t.TempDir(), "sync.json"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+
+	s := services.NewSyncer(store)
+	s.Register("google", &fakePushProvider{
+		oauth: &services.OAuthConfig{ClientID: "client", AuthURL: "https://auth.example/authorize", TokenURL: tokenServer.URL},
+	})
+
+	prev := syncer
+	SetSyncer(s)
+	t.Cleanup(func() { SetSyncer(prev) })
+	return s
+}
+
+// beginConnect runs the connect handler and returns the OAuth state
+func beginConnect(t *testing.T) string {
+	req := httptest.NewRequest("GET", "/integrations/google/connect?lat=55.6761&lng=12.5683&name=Copenhagen", nil)
+	req.SetPathValue("provider", "google")
+	w := httptest.NewRecorder()
+
+	IntegrationConnectHandler(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("expected status 302, got %d: %s", w.Code, w.Body.String())
+	}
+	u, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("invalid redirect: %v", err)
+	}
+	return u.Query().Get("state")
+}
+
+func TestIntegrationConnectHandler_Redirects(t *testing.T) {
+	withTestSyncer(t)
+
+	req := httptest.NewRequest("GET", "http://calsun.example/integrations/google/connect?lat=55.6761&lng=12.5683", nil)
+	req.SetPathValue("provider", "google")
+	w := httptest.NewRecorder()
+
+	IntegrationConnectHandler(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("expected status 302, got %d", w.Code)
+	}
+
+	location := w.Header().Get("Location")
+	if !strings.HasPrefix(location, "https://auth.example/authorize?") {
+		t.Errorf("expected redirect to the authorization URL, got %s", location)
+	}
+	u, _ := url.Parse(location)
+	if got := u.Query().Get("redirect_uri"); got != "http://calsun.example/integrations/google/callback" {
+		t.Errorf("unexpected redirect_uri %q", got)
+	}
+	if u.Query().Get("state") == "" {
+		t.Error("expected a state parameter")
+	}
+}
+
+func TestIntegrationConnectHandler_Errors(t *testing.T) {
+	withTestSyncer(t)
+
+	tests := []struct {
+		name     string
+		provider string
+		query    string
+		status   int
+	}{
+		{"unknown provider", "nope", "lat=55&lng=12", http.StatusNotFound},
+		{"missing coordinates", "google", "", http.StatusBadRequest},
+		{"invalid exclude", "google", "lat=55&lng=12&exclude=noon", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/integrations/"+tt.provider+"/connect?"+tt.query, nil)
+			req.SetPathValue("provider", tt.provider)
+			w := httptest.NewRecorder()
+
+			IntegrationConnectHandler(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+}
+
+func TestIntegrationHandlers_NotConfigured(t *testing.T) {
+	prev := syncer
+	SetSyncer(nil)
+	t.Cleanup(func() { SetSyncer(prev) })
+
+	req := httptest.NewRequest("GET", "/integrations/google/connect?lat=55&lng=12", nil)
+	req.SetPathValue("provider", "google")
+	w := httptest.NewRecorder()
+	IntegrationConnectHandler(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("connect: expected status 404, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/integrations/disconnect?id=abc", nil)
+	w = httptest.NewRecorder()
+	IntegrationDisconnectHandler(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("disconnect: expected status 404, got %d", w.Code)
+	}
+}
+
+func TestIntegrationCallbackHandler_StoresSubscription(t *testing.T) {
+	s := withTestSyncer(t)
+	state := beginConnect(t)
+
+	req := httptest.NewRequest("GET", "/integrations/google/callback?code=good&state="+state, nil)
+	req.SetPathValue("provider", "google")
+	w := httptest.NewRecorder()
+
+	IntegrationCallbackHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	subs := s.Store().List()
+	if len(subs) != 1 {
+		t.Fatalf("expected 1 subscription, got %d", len(subs))
+	}
+
+	// Wait for the initial background sync so it does not outlive the test
+	deadline := time.Now().Add(5 * time.Second)
+	for sub, _ := s.Store().Get(subs[0].ID); sub.LastSync.IsZero(); sub, _ = s.Store().Get(subs[0].ID) {
+		if time.Now().After(deadline) {
+			t.Fatal("initial sync did not run")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if subs[0].Name != "Copenhagen" || subs[0].Days != defaultDays || !subs[0].IncludeSunrise || !subs[0].IncludeSunset {
+		t.Errorf("unexpected subscription: %+v", subs[0])
+	}
+	if !strings.Contains(w.Body.String(), subs[0].ID) {
+		t.Error("status page should include the subscription ID for disconnecting")
+	}
+}
+
+func TestIntegrationCallbackHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  func(state string) string
+		status int
+		body   string
+	}{
+		{"access denied", func(string) string { return "error=access_denied" }, http.StatusOK, "Not connected"},
+		{"unknown state", func(string) string { return "code=good&state=forged" }, http.StatusBadRequest, "expired"},
+		{"rejected code", func(state string) string { return "code=bad&state=" + state }, http.StatusBadGateway, "failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := withTestSyncer(t)
+			state := beginConnect(t)
+
+			req := httptest.NewRequest("GET", "/integrations/google/callback?"+tt.query(state), nil)
+			req.SetPathValue("provider", "google")
+			w := httptest.NewRecorder()
+
+			IntegrationCallbackHandler(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.body) {
+				t.Errorf("expected body to contain %q, got %s", tt.body, w.Body.String())
+			}
+			if n := len(s.Store().List()); n != 0 {
+				t.Errorf("expected no subscriptions, got %d", n)
+			}
+		})
+	}
+}
+
+func TestIntegrationDisconnectHandler(t *testing.T) {
+	s := withTestSyncer(t)
+	if err := s.Store().Put(services.SyncSubscription{ID: "abc", Provider: "google"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/integrations/disconnect", strings.NewReader("id=abc"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	IntegrationDisconnectHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+	if _, ok := s.Store().Get("abc"); ok {
+		t.Error("subscription should have been deleted")
+	}
+
+	req = httptest.NewRequest("POST", "/integrations/disconnect?id=abc", nil)
+	w = httptest.NewRecorder()
+	IntegrationDisconnectHandler(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("second disconnect: expected status 404, got %d", w.Code)
+	}
+}
+
+func TestWebHandler_IntegrationLinks(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	WebHandler(w, req)
+	if strings.Contains(w.Body.String(), `data-integration="`) {
+		t.Error("integration links should be hidden when no provider is configured")
+	}
+
+	withTestSyncer(t)
+	w = httptest.NewRecorder()
+	WebHandler(w, req)
+	if !strings.Contains(w.Body.String(), `data-integration="google"`) || !strings.Contains(w.Body.String(), "Push to Google Calendar") {
+		t.Error("expected a Google Calendar push link")
+	}
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"time"
+
+	"calsun/services"
+)
+
+var (
+	irradianceTiltParam = paramDef{
+		Name:        "tilt",
+		Type:        paramTypeNumber,
+		Min:         bound(0),
+		Max:         bound(90),
+		Description: "Panel tilt in degrees from horizontal (0 = flat, 90 = vertical); required",
+	}
+	irradianceAzimuthParam = paramDef{
+		Name:        "azimuth",
+		Type:        paramTypeNumber,
+		Min:         bound(0),
+		Max:         bound(360),
+		Description: "Direction the panel faces in degrees clockwise from north (180 = south); required",
+	}
+	irradianceTurbidityParam = paramDef{
+		Name:        "turbidity",
+		Type:        paramTypeNumber,
+		Min:         bound(1),
+		Max:         bound(8),
+		Default:     3.0,
+		Description: "Linke turbidity of the air: about 2 for very clean air, 3 to 4 for typical air, 5 and up for haze",
+	}
+	irradianceDaysParam = paramDef{
+		Name:        "days",
+		Type:        paramTypeInteger,
+		Min:         bound(1),
+		Max:         bound(31),
+		Default:     1,
+		Description: "Number of days from date (or today)",
+	}
+	irradianceIntervalParam = paramDef{
+		Name:        "interval",
+		Type:        paramTypeInteger,
+		Min:         bound(5),
+		Max:         bound(180),
+		Default:     60,
+		Description: "Minutes between samples of the irradiance curve",
+	}
+)
+
+// irradianceParamDefs lists the parameters accepted by IrradianceHandler
+var irradianceParamDefs = []paramDef{
+	latParam,
+	lngParam,
+	nameParam,
+	dateParam,
+	irradianceDaysParam,
+	irradianceTiltParam,
+	irradianceAzimuthParam,
+	irradianceTurbidityParam,
+	irradianceIntervalParam,
+}
+
+// irradianceResponse is the JSON body returned by IrradianceHandler.
+// Irradiance is in W/m² and insolation in kWh/m²; times are RFC 3339 in the
+// location's timezone.
+type irradianceResponse struct {
+	Location        string              `json:"location"`
+	Timezone        string              `json:"timezone"`
+	Tilt            float64             `json:"tilt"`
+	Azimuth         float64             `json:"azimuth"`
+	Turbidity       float64             `json:"turbidity"`
+	IntervalMinutes int                 `json:"interval_minutes"`
+	Days            []irradianceDayJSON `json:"days"`
+	KWhM2           float64             `json:"kwh_m2"`            // On the panel over all days
+	HorizontalKWhM2 float64             `json:"horizontal_kwh_m2"` // On a flat surface over all days
+	Ratio           float64             `json:"ratio"`             // kwh_m2 / horizontal_kwh_m2: the orientation's gain over a flat panel
+}
+
+// irradianceDayJSON is a day of clear-sky irradiance on the panel
+type irradianceDayJSON struct {
+	Date            string                 `json:"date"`
+	KWhM2           float64                `json:"kwh_m2"` // Also the day's peak sun hours
+	HorizontalKWhM2 float64                `json:"horizontal_kwh_m2"`
+	PeakWM2         float64                `json:"peak_w_m2"`
+	PeakTime        *time.Time             `json:"peak_time"` // null if the sun doesn't rise
+	Samples         []irradianceSampleJSON `json:"samples"`
+}
+
+// irradianceSampleJSON is the clear-sky irradiance at a moment
+type irradianceSampleJSON struct {
+	Time      time.Time `json:"time"`
+	Elevation float64   `json:"elevation"`
+	GHI       float64   `json:"ghi"`
+	DNI       float64   `json:"dni"`
+	DHI       float64   `json:"dhi"`
+	POA       float64   `json:"poa"` // On the panel
+}
+
+// IrradianceHandler returns the clear-sky irradiance curve and daily
+// insolation on a panel of the given tilt and azimuth as JSON, as a proxy for
+// its production, so orientations can be compared
+func IrradianceHandler(w http.ResponseWriter, r *http.Request) {
+	// days may be combined with date here, so it is parsed separately
+	q := r.URL.Query()
+	days, errMsg := irradianceDaysParam.parseInt(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	q = maps.Clone(q)
+	q.Del(irradianceDaysParam.Name)
+	params, errMsg := parseCalendarQuery(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	if q.Get(irradianceTiltParam.Name) == "" || q.Get(irradianceAzimuthParam.Name) == "" {
+		http.Error(w, "tilt and azimuth parameters are required", http.StatusBadRequest)
+		return
+	}
+	var panel services.PanelOrientation
+	if panel.Tilt, errMsg = irradianceTiltParam.parseFloat(q); errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	if panel.Azimuth, errMsg = irradianceAzimuthParam.parseFloat(q); errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	turbidity, errMsg := irradianceTurbidityParam.parseFloat(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	interval, errMsg := irradianceIntervalParam.parseInt(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	tz := services.GetTimezone(params.lat, params.lng)
+	start := params.now.In(tz)
+	if !params.date.IsZero() {
+		start = params.date
+	}
+	noon := time.Date(start.Year(), start.Month(), start.Day(), 12, 0, 0, 0, tz)
+
+	resp := irradianceResponse{
+		Location:        locationName(params),
+		Timezone:        tz.String(),
+		Tilt:            panel.Tilt,
+		Azimuth:         panel.Azimuth,
+		Turbidity:       turbidity,
+		IntervalMinutes: interval,
+		Days:            make([]irradianceDayJSON, 0, days),
+	}
+	for i := 0; i < days; i++ {
+		day := services.ClearSkyDay(params.lat, params.lng, panel, turbidity, noon.AddDate(0, 0, i), tz, time.Duration(interval)*time.Minute)
+		dayJSON := irradianceDayJSON{
+			Date:            day.Date.Format("2006-01-02"),
+			KWhM2:           round2(day.POA),
+			HorizontalKWhM2: round2(day.GHI),
+			PeakWM2:         roundTo(day.Peak.POA, 1),
+			Samples:         make([]irradianceSampleJSON, 0, len(day.Samples)),
+		}
+		if day.Peak.POA > 0 {
+			peak := day.Peak.Time.In(tz)
+			dayJSON.PeakTime = &peak
+		}
+		for _, s := range day.Samples {
+			dayJSON.Samples = append(dayJSON.Samples, irradianceSampleJSON{
+				Time:      s.Time.In(tz),
+				Elevation: round2(s.Elevation),
+				GHI:       roundTo(s.GHI, 1),
+				DNI:       roundTo(s.DNI, 1),
+				DHI:       roundTo(s.DHI, 1),
+				POA:       roundTo(s.POA, 1),
+			})
+		}
+		resp.Days = append(resp.Days, dayJSON)
+		resp.KWhM2 += day.POA
+		resp.HorizontalKWhM2 += day.GHI
+	}
+	if resp.HorizontalKWhM2 > 0 {
+		resp.Ratio = round2(resp.KWhM2 / resp.HorizontalKWhM2)
+	}
+	resp.KWhM2, resp.HorizontalKWhM2 = round2(resp.KWhM2), round2(resp.HorizontalKWhM2)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIrradianceHandler(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/irradiance?lat=55.6761&lng=12.5683&name=Copenhagen&date=2024-12-20&days=3&tilt=35&azimuth=180&interval=30", nil)
+	w := httptest.NewRecorder()
+
+	IrradianceHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp irradianceResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Location != "Copenhagen" || resp.Tilt != 35 || resp.Azimuth != 180 || resp.Turbidity != 3 || resp.IntervalMinutes != 30 {
+		t.Errorf("unexpected panel: %+v", resp)
+	}
+	if len(resp.Days) != 3 || resp.Days[0].Date != "2024-12-20" || resp.Days[2].Date != "2024-12-22" {
+		t.Fatalf("expected 3 days from the date, got %+v", resp.Days)
+	}
+	day := resp.Days[1]
+	if len(day.Samples) != 48 || day.PeakTime == nil || day.PeakTime.Hour() != 12 {
+		t.Errorf("expected 48 samples and a peak around noon, got %d and %v", len(day.Samples), day.PeakTime)
+	}
+	if day.KWhM2 <= day.HorizontalKWhM2 || day.PeakWM2 <= 0 {
+		t.Errorf("expected the tilted panel to beat a flat one in winter, got %+v", day)
+	}
+	total := resp.Days[0].KWhM2 + resp.Days[1].KWhM2 + resp.Days[2].KWhM2
+	if resp.KWhM2 < total-0.02 || resp.KWhM2 > total+0.02 || resp.Ratio < 2 {
+		t.Errorf("expected totals over the days and a ratio over 2, got %.2f (%.2f) and %.2f", resp.KWhM2, total, resp.Ratio)
+	}
+}
+
+func TestIrradianceHandler_PolarNight(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/irradiance?lat=78.22&lng=15.65&date=2024-12-21&tilt=30&azimuth=180", nil)
+	w := httptest.NewRecorder()
+
+	IrradianceHandler(w, req)
+
+	var resp irradianceResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Days) != 1 || resp.Days[0].PeakTime != nil || resp.KWhM2 != 0 || resp.Ratio != 0 {
+		t.Errorf("expected no sun in the polar night, got %+v", resp)
+	}
+}
+
+func TestIrradianceHandler_InvalidParams(t *testing.T) {
+	for _, url := range []string{
+		"/api/irradiance?lat=55.6761&lng=12.5683",
+		"/api/irradiance?lat=55.6761&lng=12.5683&tilt=35",
+		"/api/irradiance?lat=55.6761&lng=12.5683&tilt=95&azimuth=180",
+		"/api/irradiance?lat=55.6761&lng=12.5683&tilt=35&azimuth=180&turbidity=10",
+		"/api/irradiance?lat=55.6761&lng=12.5683&tilt=35&azimuth=180&days=32",
+		"/api/irradiance?lat=55.6761&lng=12.5683&tilt=35&azimuth=180&interval=1",
+		"/api/irradiance?lng=12.5683&tilt=35&azimuth=180",
+	} {
+		w := httptest.NewRecorder()
+		IrradianceHandler(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", url, w.Code)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"calsun/services"
+)
+
+// locationParams are the alternatives to giving lat and lng
+var locationParams = []paramDef{placeParam, geohashParam, plusCodeParam, utmParam, mgrsParam}
+
+// resolveLocation turns an alternative location parameter into coordinates,
+// returning a copy of q with lat and lng set (and, for a place, name unless
+// given). Returns q unchanged if no alternative is given.
+func resolveLocation(q url.Values) (url.Values, string) {
+	var given *paramDef
+	for i, p := range locationParams {
+		if q.Get(p.Name) == "" {
+			continue
+		}
+		if given != nil || q.Get(latParam.Name) != "" || q.Get(lngParam.Name) != "" {
+			return nil, fmt.Sprintf("%s cannot be combined with other location parameters", p.Name)
+		}
+		given = &locationParams[i]
+	}
+	if given == nil {
+		return q, ""
+	}
+
+	value := q.Get(given.Name)
+	var lat, lng float64
+	var name string
+	switch given.Name {
+	case placeParam.Name:
+		place, errMsg := resolvePlace(value)
+		if errMsg != "" {
+			return nil, errMsg
+		}
+		lat, lng, name = place.Lat, place.Lng, place.Name
+	case geohashParam.Name:
+		var err error
+		if lat, lng, err = services.DecodeGeohash(value); err != nil {
+			return nil, "invalid geohash parameter: " + err.Error()
+		}
+	case plusCodeParam.Name:
+		// An unescaped + in a query string decodes to a space
+		code := strings.ReplaceAll(strings.TrimSpace(value), " ", "+")
+		var err error
+		if lat, lng, err = services.DecodePlusCode(code); err != nil {
+			return nil, "invalid pluscode parameter: " + err.Error()
+		}
+	case utmParam.Name:
+		var err error
+		if lat, lng, err = services.ParseUTM(value); err != nil {
+			return nil, "invalid utm parameter: " + err.Error()
+		}
+	case mgrsParam.Name:
+		var err error
+		if lat, lng, err = services.ParseMGRS(value); err != nil {
+			return nil, "invalid mgrs parameter: " + err.Error()
+		}
+	}
+
+	resolved := maps.Clone(q)
+	resolved.Set(latParam.Name, strconv.FormatFloat(lat, 'f', -1, 64))
+	resolved.Set(lngParam.Name, strconv.FormatFloat(lng, 'f', -1, 64))
+	if name != "" && resolved.Get(nameParam.Name) == "" {
+		resolved.Set(nameParam.Name, name)
+	}
+	return resolved, ""
+}
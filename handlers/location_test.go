@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCalendarHandler_CoordinateFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{"geohash", "/calendar.ics?geohash=u3buz&days=1", `Coordinates: 55.7007\, 12.6343`},
+		{"plus code", "/calendar.ics?pluscode=9F7JMHG9%2BC8&days=1", `Coordinates: 55.6761\, 12.5683`},
+		{"plus code with unescaped separator", "/calendar.ics?pluscode=9F7JMHG9+C8&days=1", `Coordinates: 55.6761\, 12.5683`},
+		{"utm", "/calendar.ics?utm=31U+448252+5411933&days=1", `Coordinates: 48.8582\, 2.2945`},
+		{"mgrs", "/calendar.ics?mgrs=31UDQ4825111932&days=1", `Coordinates: 48.8582\, 2.2945`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			CalendarHandler(w, httptest.NewRequest("GET", tt.url, nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if !strings.Contains(unfoldICal(w.Body.String()), tt.expected) {
+				t.Errorf("expected %s", tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveLocation_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{"geohash with coordinates", "/?geohash=u3buz&lat=1&lng=2", "cannot be combined"},
+		{"geohash and plus code", "/?geohash=u3buz&pluscode=9F7JMHG9%2BC8", "cannot be combined"},
+		{"invalid geohash", "/?geohash=u3bua", "invalid geohash parameter"},
+		{"short plus code", "/?pluscode=MHG9%2BC8", "short plus codes are not supported"},
+		{"utm outside band", "/?utm=31S+448252+5411933", "invalid utm parameter"},
+		{"mgrs and utm", "/?mgrs=31UDQ48251193&utm=31U+448252+5411933", "cannot be combined"},
+		{"invalid mgrs", "/?mgrs=31UDI4825111932", "invalid mgrs parameter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errMsg := resolveLocation(httptest.NewRequest("GET", tt.url, nil).URL.Query())
+			if !strings.Contains(errMsg, tt.expected) {
+				t.Errorf("expected error containing %q, got %q", tt.expected, errMsg)
+			}
+		})
+	}
+}
+
+func TestResolveLocation_Coordinates(t *testing.T) {
+	q := httptest.NewRequest("GET", "/?lat=55.6761&lng=12.5683", nil).URL.Query()
+
+	resolved, errMsg := resolveLocation(q)
+	if errMsg != "" || resolved.Get("lat") != "55.6761" {
+		t.Errorf("expected coordinates unchanged, got %v (%s)", resolved, errMsg)
+	}
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"time"
+
+	"calsun/services"
+)
+
+// moonParamDefs lists the parameters accepted by MoonHandler
+var moonParamDefs = []paramDef{latParam, lngParam, nameParam, dateParam}
+
+// moonEventsParam selects the events of the moon calendar. It shares its
+// name with the sun calendar's events parameter but not its values.
+var moonEventsParam = paramDef{
+	Name:        "events",
+	Type:        paramTypeList,
+	Values:      services.MoonEventTypes,
+	Description: "Comma-separated moon events to include: rise and set (moonrise and moonset), phases (all-day events for the new moon, quarters, and full moon), apsides (all-day events for the perigee and apogee), and eclipses (lunar eclipses); default: all",
+}
+
+// moonCalendarParamDefs lists the parameters accepted by the moon calendar
+var moonCalendarParamDefs = []paramDef{
+	latParam,
+	lngParam,
+	nameParam,
+	daysParam,
+	dateParam,
+	moonEventsParam,
+	homeTimezoneParam,
+}
+
+// moonResponse is the JSON body returned by MoonHandler. Times are in the
+// location's timezone (RFC 3339) and null when the moon does not rise or set
+// that day.
+type moonResponse struct {
+	Date         string       `json:"date"`
+	Location     string       `json:"location"`
+	Timezone     string       `json:"timezone"`
+	Phase        string       `json:"phase"`
+	Illumination float64      `json:"illumination"` // 0 to 1
+	DistanceKm   float64      `json:"distance_km"`  // Between the centres of the Earth and the moon, now (or at noon on date)
+	Rise         *time.Time   `json:"rise"`
+	Set          *time.Time   `json:"set"`
+	Apsis        string       `json:"apsis,omitempty"` // "perigee" or "apogee" if the moon reaches one that day
+	NextPerigee  moonApsisRef `json:"next_perigee"`
+	NextApogee   moonApsisRef `json:"next_apogee"`
+}
+
+// moonApsisRef is an upcoming perigee or apogee
+type moonApsisRef struct {
+	Time       time.Time `json:"time"`
+	DistanceKm float64   `json:"distance_km"`
+}
+
+// MoonHandler returns the moon's phase, distance, and rise and set for a
+// location as JSON, with the next perigee and apogee
+func MoonHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	tz := services.GetTimezone(params.lat, params.lng)
+	now := params.now.In(tz).Truncate(time.Second)
+	if !params.date.IsZero() {
+		now = params.date.Add(12 * time.Hour)
+	}
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, tz)
+	phase := services.GetMoonPhase(now)
+	day := services.GetMoonDay(params.lat, params.lng, now, tz)
+
+	resp := moonResponse{
+		Date:         midnight.Format("2006-01-02"),
+		Location:     locationName(params),
+		Timezone:     tz.String(),
+		Phase:        phase.Name,
+		Illumination: round2(phase.Illumination),
+		DistanceKm:   math.Round(services.MoonDistance(now)),
+		Rise:         localTime(day.Rise, tz),
+		Set:          localTime(day.Set, tz),
+		NextPerigee:  newMoonApsisRef(services.NextMoonApsis(services.ApsisPerigee, now), tz),
+		NextApogee:   newMoonApsisRef(services.NextMoonApsis(services.ApsisApogee, now), tz),
+	}
+	if apsides := services.MoonApsides(midnight, midnight.AddDate(0, 0, 1)); len(apsides) > 0 {
+		resp.Apsis = apsides[0].Type
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// MoonCalendarHandler generates an iCal calendar with only lunar events:
+// moonrise and moonset, phases, apsides, and lunar eclipses
+func MoonCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	events, errMsg := moonEventsParam.parseList(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	if events == nil {
+		events = services.MoonEventTypes
+	}
+	// The remaining parameters are the sun calendar's
+	q = maps.Clone(q)
+	q.Del(moonEventsParam.Name)
+	params, errMsg := parseCalendarQuery(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	opts := services.CalendarOptions{
+		Lat:          params.lat,
+		Lng:          params.lng,
+		Location:     locationName(params),
+		Timezone:     services.GetTimezone(params.lat, params.lng),
+		Precision:    params.precision,
+		HomeTimezone: params.homeTimezone,
+	}
+
+	calName := "Moon Calendar"
+	if params.name != "" {
+		calName = fmt.Sprintf("Moon Calendar - %s", params.name)
+	}
+	cal := newCalendar(calName)
+
+	start, days := params.eventRange()
+	for _, event := range params.onDate(services.BuildMoonEvents(opts, events, start, days)) {
+		cal.AddVEvent(toVEvent(event, params.now))
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", attachment(params.filename, "calsun-moon.ics"))
+	w.Write([]byte(cal.Serialize(icsNewLine)))
+}
+
+// newMoonApsisRef returns an apsis with its time in tz
+func newMoonApsisRef(apsis services.MoonApsis, tz *time.Location) moonApsisRef {
+	return moonApsisRef{Time: apsis.Time.In(tz), DistanceKm: math.Round(apsis.Distance)}
+}
+
+// localTime returns t in tz, or nil if t is zero
+func localTime(t time.Time, tz *time.Location) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	local := t.In(tz)
+	return &local
+}
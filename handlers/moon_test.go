@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMoonHandler_ValidRequest(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/moon?lat=55.6761&lng=12.5683&name=Copenhagen&date=2024-10-17", nil)
+	w := httptest.NewRecorder()
+
+	MoonHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp moonResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if resp.Location != "Copenhagen" || resp.Date != "2024-10-17" {
+		t.Errorf("unexpected location or date: %+v", resp)
+	}
+	// The supermoon of October 2024
+	if resp.Phase != "Full moon" || resp.Apsis != "perigee" {
+		t.Errorf("expected a full moon at perigee, got %s at %q", resp.Phase, resp.Apsis)
+	}
+	if resp.DistanceKm < 357000 || resp.DistanceKm > 358000 {
+		t.Errorf("expected a distance of about 357,200 km, got %.0f", resp.DistanceKm)
+	}
+	if got := resp.NextPerigee.Time.Format("2006-01-02"); got != "2024-11-14" {
+		t.Errorf("expected the next perigee on 2024-11-14, got %s", got)
+	}
+	if got := resp.NextApogee.Time.Format("2006-01-02"); got != "2024-10-29" {
+		t.Errorf("expected the next apogee on 2024-10-29, got %s", got)
+	}
+	if resp.NextApogee.DistanceKm < 406000 {
+		t.Errorf("expected an apogee distance above 406,000 km, got %.0f", resp.NextApogee.DistanceKm)
+	}
+}
+
+func TestMoonHandler_InvalidParams(t *testing.T) {
+	for _, url := range []string{"/api/moon", "/api/moon?lat=100&lng=0", "/api/moon?lat=0&lng=0&date=tomorrow"} {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		MoonHandler(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", url, w.Code)
+		}
+	}
+}
+
+func TestMoonCalendarHandler(t *testing.T) {
+	enableDebug(t)
+
+	req := httptest.NewRequest("GET", "/moon.ics?lat=55.6761&lng=12.5683&name=Copenhagen&days=30&now=2025-03-10T12:00:00Z", nil)
+	w := httptest.NewRecorder()
+
+	MoonCalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), "calsun-moon.ics") {
+		t.Errorf("unexpected Content-Disposition %q", w.Header().Get("Content-Disposition"))
+	}
+	if report := lintICS(w.Body.Bytes()); !report.Valid {
+		t.Errorf("expected a valid calendar, got %+v", report.Errors)
+	}
+	body := unfoldICal(w.Body.String())
+	for _, want := range []string{"X-WR-CALNAME:Moon Calendar - Copenhagen", "SUMMARY:Moonrise ", "SUMMARY:Moonset ", "SUMMARY:Full moon", "SUMMARY:Moon at apogee", "SUMMARY:Total lunar eclipse"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q", want)
+		}
+	}
+	if strings.Contains(body, "SUMMARY:Sunrise") {
+		t.Error("expected no sun events")
+	}
+
+	req = httptest.NewRequest("GET", "/moon.ics?lat=55.6761&lng=12.5683&events=phases,eclipses&days=30&now=2025-03-10T12:00:00Z", nil)
+	w = httptest.NewRecorder()
+	MoonCalendarHandler(w, req)
+	body = w.Body.String()
+	if w.Code != http.StatusOK || strings.Contains(body, "Moonrise") || strings.Contains(body, "apogee") {
+		t.Errorf("expected only phases and eclipses, got %d: %s", w.Code, body)
+	}
+	if n := strings.Count(body, "BEGIN:VEVENT"); n != 7 {
+		t.Errorf("expected 6 phases and an eclipse in 44 days, got %d events", n)
+	}
+
+	for _, url := range []string{"/moon.ics?lat=55.6761&lng=12.5683&events=sunrise", "/moon.ics?lng=12.5683"} {
+		w := httptest.NewRecorder()
+		MoonCalendarHandler(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", url, w.Code)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+)
+
+// endpointOptions describes the parameters accepted by one endpoint
+type endpointOptions struct {
+	Path       string     `json:"path"`
+	Parameters []paramDef `json:"parameters"`
+}
+
+// optionsResponse is the JSON body returned by OptionsHandler
+type optionsResponse struct {
+	Endpoints []endpointOptions `json:"endpoints"`
+}
+
+// OptionsHandler describes every supported query parameter as JSON, so that
+// clients (including the web UI) can build their options from it
+func OptionsHandler(w http.ResponseWriter, r *http.Request) {
+	resp := optionsResponse{
+		Endpoints: []endpointOptions{
+			{Path: "/calendar.ics", Parameters: calendarParamDefs},
+			{Path: "/calendar", Parameters: unifiedCalendarParamDefs},
+			{Path: "/prayer.ics", Parameters: prayerParamDefs},
+			{Path: "/shabbat.ics", Parameters: shabbatParamDefs},
+			{Path: "/ramadan.ics", Parameters: ramadanParamDefs},
+			{Path: "/moon.ics", Parameters: moonCalendarParamDefs},
+			{Path: "/circadian.ics", Parameters: circadianCalendarParamDefs},
+			{Path: "/seasons.ics", Parameters: seasonsCalendarParamDefs},
+			{Path: "/preview/fragment", Parameters: calendarParamDefs},
+			{Path: "/qr", Parameters: append(append([]paramDef{}, calendarParamDefs...), qrParamDefs...)},
+			{Path: "/api/geocode/suggest", Parameters: suggestParamDefs},
+			{Path: "/api/today", Parameters: todayParamDefs},
+			{Path: "/api/homeassistant", Parameters: homeAssistantParamDefs},
+			{Path: "/api/shortcut", Parameters: shortcutParamDefs},
+			{Path: "/api/brief", Parameters: briefParamDefs},
+			{Path: "/api/moon", Parameters: moonParamDefs},
+			{Path: "/api/circadian", Parameters: circadianParamDefs},
+			{Path: "/map", Parameters: mapParamDefs},
+			{Path: "/day", Parameters: dayParamDefs},
+			{Path: "/api/compare", Parameters: compareParamDefs},
+			{Path: "/compare", Parameters: compareParamDefs},
+			{Path: "/api/when", Parameters: whenParamDefs},
+			{Path: "/api/timezone", Parameters: timezoneParamDefs},
+			{Path: "/api/solartime", Parameters: solarTimeParamDefs},
+			{Path: "/solartime", Parameters: solarTimeParamDefs},
+			{Path: "/api/facade", Parameters: facadeParamDefs},
+			{Path: "/api/irradiance", Parameters: irradianceParamDefs},
+			{Path: "/api/seasons/lengths", Parameters: seasonLengthsParamDefs},
+			{Path: "/api/stats", Parameters: statsParamDefs},
+			{Path: "/api/validate", Parameters: calendarParamDefs},
+			{Path: "/api/events/delta", Parameters: deltaParamDefs},
+			{Path: "/api/diff", Parameters: diffParamDefs},
+			{Path: "/archive.zip", Parameters: archiveParamDefs},
+			{Path: "/api/influx", Parameters: influxParamDefs},
+			{Path: "/api/triggers/sun", Parameters: triggerParamDefs},
+			{Path: "/email/subscribe", Parameters: emailParamDefs},
+			{Path: "/push/subscribe", Parameters: pushParamDefs},
+		},
+	}
+	if cfg.Headless {
+		resp.Endpoints = slices.DeleteFunc(resp.Endpoints, func(e endpointOptions) bool {
+			return e.Path == "/compare" || e.Path == "/solartime"
+		})
+	}
+	if cfg.DisablePreview {
+		resp.Endpoints = slices.DeleteFunc(resp.Endpoints, func(e endpointOptions) bool {
+			return e.Path == "/preview/fragment" || e.Path == "/qr"
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
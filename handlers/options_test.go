@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"calsun/config"
+)
+
+func TestOptionsHandler(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/options", nil)
+	w := httptest.NewRecorder()
+
+	OptionsHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+
+	contentType := w.Header().Get("Content-Type")
+	if !strings.Contains(contentType, "application/json") {
+		t.Errorf("expected Content-Type application/json, got %s", contentType)
+	}
+
+	var resp optionsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	var calendar *endpointOptions
+	for i := range resp.Endpoints {
+		if resp.Endpoints[i].Path == "/calendar.ics" {
+			calendar = &resp.Endpoints[i]
+		}
+	}
+	if calendar == nil {
+		t.Fatal("expected /calendar.ics in endpoints")
+	}
+
+	params := make(map[string]paramDef)
+	for _, p := range calendar.Parameters {
+		params[p.Name] = p
+	}
+
+	for _, name := range []string{"lat", "lng", "name", "exclude", "days"} {
+		if _, ok := params[name]; !ok {
+			t.Errorf("expected parameter %s", name)
+		}
+	}
+
+	if !params["lat"].Required {
+		t.Error("lat should be required")
+	}
+	if days := params["days"]; days.Max == nil || *days.Max != maxDays {
+		t.Errorf("expected days max %d", maxDays)
+	}
+
+	// Endpoints list the parameters their handlers use
+	listed := func(path, name string) bool {
+		for _, e := range resp.Endpoints {
+			if e.Path == path {
+				return slices.ContainsFunc(e.Parameters, func(p paramDef) bool { return p.Name == name })
+			}
+		}
+		return false
+	}
+	for _, tt := range []struct {
+		path, param string
+		want        bool
+	}{
+		{"/api/today", "date", true},
+		{"/api/shortcut", "date", true},
+		{"/api/homeassistant", "azformat", true},
+		{"/api/homeassistant", "date", false},
+	} {
+		if listed(tt.path, tt.param) != tt.want {
+			t.Errorf("%s: expected %s listed: %v", tt.path, tt.param, tt.want)
+		}
+	}
+}
+
+func TestOptionsHandler_PreviewDisabled(t *testing.T) {
+	original := cfg
+	t.Cleanup(func() { Configure(original) })
+	headless := config.Default()
+	headless.Headless, headless.DisablePreview = true, true
+	Configure(headless)
+
+	w := httptest.NewRecorder()
+	OptionsHandler(w, httptest.NewRequest("GET", "/api/options", nil))
+
+	var resp optionsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	for _, e := range resp.Endpoints {
+		if e.Path == "/preview/fragment" || e.Path == "/qr" || e.Path == "/compare" || e.Path == "/solartime" {
+			t.Errorf("expected %s to be omitted", e.Path)
+		}
+	}
+	if len(resp.Endpoints) == 0 || resp.Endpoints[0].Path != "/calendar.ics" {
+		t.Error("expected the calendar endpoint to remain")
+	}
+}
+
+func TestParamDef_Validation(t *testing.T) {
+	tests := []struct {
+		name      string
+		param     paramDef
+		url       string
+		expectErr bool
+	}{
+		{"float in range", latParam, "/?lat=55.5", false},
+		{"float out of range", latParam, "/?lat=-91", true},
+		{"float invalid", latParam, "/?lat=north", true},
+		{"int default", daysParam, "/", false},
+		{"int in range", daysParam, "/?days=90", false},
+		{"int out of range", daysParam, "/?days=0", true},
+		{"enum valid", excludeParam, "/?exclude=sunset", false},
+		{"enum invalid", excludeParam, "/?exclude=noon", true},
+		{"list valid", eventsParam, "/?events=dawn,sunset", false},
+		{"list invalid", eventsParam, "/?events=dawn,midnight", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := httptest.NewRequest("GET", tt.url, nil).URL.Query()
+
+			var errMsg string
+			switch tt.param.Type {
+			case paramTypeNumber:
+				_, errMsg = tt.param.parseFloat(q)
+			case paramTypeInteger:
+				_, errMsg = tt.param.parseInt(q)
+			case paramTypeEnum:
+				_, errMsg = tt.param.parseEnum(q)
+			case paramTypeList:
+				_, errMsg = tt.param.parseList(q)
+			}
+
+			if (errMsg != "") != tt.expectErr {
+				t.Errorf("expected error %v, got %q", tt.expectErr, errMsg)
+			}
+		})
+	}
+}
+
+func TestParamDef_IntDefault(t *testing.T) {
+	q := httptest.NewRequest("GET", "/", nil).URL.Query()
+
+	days, errMsg := daysParam.parseInt(q)
+	if errMsg != "" {
+		t.Fatalf("unexpected error: %s", errMsg)
+	}
+	if days != defaultDays {
+		t.Errorf("expected default %d, got %d", defaultDays, days)
+	}
+}
+
+func TestParamDef_ConfiguredMax(t *testing.T) {
+	// Definitions copied into endpoint lists at startup follow the configuration
+	days := moonCalendarParamDefs[slices.IndexFunc(moonCalendarParamDefs, func(p paramDef) bool { return p.Name == "days" })]
+	q := httptest.NewRequest("GET", "/?days=365", nil).URL.Query()
+	if _, errMsg := days.parseInt(q); errMsg == "" {
+		t.Error("expected 365 days to exceed the default limit")
+	}
+
+	original := cfg
+	t.Cleanup(func() { Configure(original) })
+	c := config.Default()
+	c.MaxDays = 365
+	Configure(c)
+
+	if _, errMsg := days.parseInt(q); errMsg != "" {
+		t.Errorf("expected the configured limit to apply, got %s", errMsg)
+	}
+	b, err := json.Marshal(days)
+	if err != nil || !strings.Contains(string(b), `"max":365`) {
+		t.Errorf("expected the configured limit in the options, got %s", b)
+	}
+	if *daysParam.resolved().Max != 365 || daysParam.Max != nil {
+		t.Error("expected the definition itself to stay unchanged")
+	}
+}
+
+func TestParamDef_ParseList(t *testing.T) {
+	q := httptest.NewRequest("GET", "/?events=sunset,+dawn,sunset", nil).URL.Query()
+
+	values, errMsg := eventsParam.parseList(q)
+	if errMsg != "" {
+		t.Fatalf("unexpected error: %s", errMsg)
+	}
+	if got := strings.Join(values, ","); got != "dawn,sunset" {
+		t.Errorf("expected dawn,sunset, got %s", got)
+	}
+}
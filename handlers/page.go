@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"cmp"
+	"encoding/base64"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"calsun/services"
+)
+
+const (
+	// defaultPageLimit is the page size of paginated event lists when the
+	// request doesn't set one
+	defaultPageLimit = 500
+	// maxPageLimit is the largest page size a request may ask for
+	maxPageLimit = 1000
+)
+
+var (
+	pageLimitParam = paramDef{
+		Name:        "limit",
+		Type:        paramTypeInteger,
+		Min:         bound(1),
+		Max:         bound(maxPageLimit),
+		Description: "Maximum number of events per page; next_cursor in the response fetches the next page",
+	}
+	pageCursorParam = paramDef{
+		Name:        "cursor",
+		Type:        paramTypeString,
+		Description: "next_cursor from the previous page",
+	}
+)
+
+// pageParamDefs lists the pagination parameters
+var pageParamDefs = []paramDef{pageLimitParam, pageCursorParam}
+
+// pageRequest is the page of an event list a request asks for
+type pageRequest struct {
+	limit int
+	after *pageKey // Position of the last event of the previous page
+}
+
+// pageKey is an event's position in the page order: by start time, then UID
+type pageKey struct {
+	start time.Time
+	uid   string
+}
+
+// parsePageRequest parses limit and cursor. requested reports whether
+// either was given; without them the limit is defaultPageLimit.
+func parsePageRequest(q url.Values) (page pageRequest, requested bool, errMsg string) {
+	limit, errMsg := pageLimitParam.parseInt(q)
+	if errMsg != "" {
+		return pageRequest{}, false, errMsg
+	}
+	page.limit = limit
+	if limit == 0 {
+		page.limit = defaultPageLimit
+	}
+	if cursor := q.Get(pageCursorParam.Name); cursor != "" {
+		key, ok := decodePageCursor(cursor)
+		if !ok {
+			return pageRequest{}, false, "invalid cursor parameter"
+		}
+		page.after = &key
+	}
+	return page, limit != 0 || page.after != nil, ""
+}
+
+// paginateEvents orders the events by start time and UID, so pages stay
+// consistent when the list is regenerated, and returns the page with the
+// cursor of the next one (empty on the last page)
+func paginateEvents(events []services.CalendarEvent, page pageRequest) ([]services.CalendarEvent, string) {
+	sorted := slices.Clone(events)
+	slices.SortStableFunc(sorted, func(a, b services.CalendarEvent) int {
+		return compareEventKeys(pageKey{a.Start, a.UID}, pageKey{b.Start, b.UID})
+	})
+
+	first := 0
+	if page.after != nil {
+		first, _ = slices.BinarySearchFunc(sorted, *page.after, func(e services.CalendarEvent, key pageKey) int {
+			// Events at the cursor itself were on the previous page
+			if compareEventKeys(pageKey{e.Start, e.UID}, key) <= 0 {
+				return -1
+			}
+			return 1
+		})
+	}
+	if len(sorted)-first <= page.limit {
+		return sorted[first:], ""
+	}
+	last := sorted[first+page.limit-1]
+	return sorted[first : first+page.limit], encodePageCursor(pageKey{last.Start, last.UID})
+}
+
+// compareEventKeys orders page keys by start time, then UID
+func compareEventKeys(a, b pageKey) int {
+	if c := a.start.Compare(b.start); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.uid, b.uid)
+}
+
+// encodePageCursor returns an opaque cursor pointing after key
+func encodePageCursor(key pageKey) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(key.start.UnixNano(), 10) + ":" + key.uid))
+}
+
+// decodePageCursor parses a cursor from encodePageCursor
+func decodePageCursor(cursor string) (pageKey, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return pageKey{}, false
+	}
+	nanosStr, uid, ok := strings.Cut(string(data), ":")
+	if !ok || uid == "" {
+		return pageKey{}, false
+	}
+	nanos, err := strconv.ParseInt(nanosStr, 10, 64)
+	if err != nil {
+		return pageKey{}, false
+	}
+	return pageKey{time.Unix(0, nanos), uid}, true
+}
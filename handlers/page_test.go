@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+func TestPaginateEvents(t *testing.T) {
+	start := time.Date(2024, 6, 21, 3, 25, 12, 345, time.UTC)
+	var events []services.CalendarEvent
+	// Out of order, with two events at the same time
+	for _, i := range []int{4, 0, 3, 1, 2} {
+		events = append(events, services.CalendarEvent{UID: fmt.Sprintf("%d@calsun", i), Start: start.Add(time.Duration(i/2) * time.Hour)})
+	}
+
+	var seen []string
+	page := pageRequest{limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("too many pages")
+		}
+		got, next := paginateEvents(events, page)
+		for _, event := range got {
+			seen = append(seen, event.UID)
+		}
+		if next == "" {
+			break
+		}
+		if len(got) != 2 {
+			t.Errorf("expected full pages before the last, got %d events", len(got))
+		}
+		key, ok := decodePageCursor(next)
+		if !ok {
+			t.Fatalf("invalid cursor %q", next)
+		}
+		page.after = &key
+	}
+	if fmt.Sprint(seen) != "[0@calsun 1@calsun 2@calsun 3@calsun 4@calsun]" {
+		t.Errorf("expected every event once, by start and UID, got %v", seen)
+	}
+}
+
+func TestParsePageRequest(t *testing.T) {
+	if page, requested, errMsg := parsePageRequest(url.Values{}); errMsg != "" || requested || page.limit != defaultPageLimit {
+		t.Errorf("expected the default limit, got %+v %v %q", page, requested, errMsg)
+	}
+	if page, requested, _ := parsePageRequest(url.Values{"limit": {"10"}}); !requested || page.limit != 10 {
+		t.Errorf("expected a limit of 10, got %+v %v", page, requested)
+	}
+	for _, q := range []url.Values{
+		{"limit": {"0"}},
+		{"limit": {"1001"}},
+		{"cursor": {"not base64!"}},
+		{"cursor": {"MTIz"}}, // "123", no UID
+	} {
+		if _, _, errMsg := parsePageRequest(q); errMsg == "" {
+			t.Errorf("%v: expected an error", q)
+		}
+	}
+}
+
+func TestUnifiedCalendarHandler_Pages(t *testing.T) {
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	SetClock(services.FixedClock(time.Date(2024, 6, 21, 10, 0, 0, 0, time.UTC)))
+
+	full := httptest.NewRecorder()
+	UnifiedCalendarHandler(full, httptest.NewRequest("GET", "/calendar?lat=55.6761&lng=12.5683&days=3&format=json", nil))
+	var all calendarDocument
+	if err := json.NewDecoder(full.Body).Decode(&all); err != nil {
+		t.Fatal(err)
+	}
+	if all.NextCursor != "" {
+		t.Errorf("expected no cursor without limit, got %q", all.NextCursor)
+	}
+
+	seen := map[string]bool{}
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > len(all.Events) {
+			t.Fatal("too many pages")
+		}
+		target := "/calendar?lat=55.6761&lng=12.5683&days=3&format=json&limit=7&cursor=" + cursor
+		w := httptest.NewRecorder()
+		UnifiedCalendarHandler(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var doc calendarDocument
+		if err := json.NewDecoder(w.Body).Decode(&doc); err != nil {
+			t.Fatal(err)
+		}
+		for i, event := range doc.Events {
+			if seen[event.UID] {
+				t.Errorf("event %s on more than one page", event.UID)
+			}
+			seen[event.UID] = true
+			if i > 0 && event.Start.Before(doc.Events[i-1].Start) {
+				t.Errorf("events out of order: %v before %v", doc.Events[i-1].Start, event.Start)
+			}
+		}
+		if doc.NextCursor == "" {
+			break
+		}
+		cursor = doc.NextCursor
+	}
+	if len(seen) != len(all.Events) {
+		t.Errorf("expected %d events over all pages, got %d", len(all.Events), len(seen))
+	}
+
+	// Pages are only served as JSON
+	w := httptest.NewRecorder()
+	UnifiedCalendarHandler(w, httptest.NewRequest("GET", "/calendar?lat=55.6761&lng=12.5683&format=ics&limit=7", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a paginated iCal calendar, got %d", w.Code)
+	}
+}
+
+func TestDeltaHandler_Pages(t *testing.T) {
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	day := time.Date(2024, 6, 21, 10, 0, 0, 0, time.UTC)
+
+	SetClock(services.FixedClock(day))
+	w := httptest.NewRecorder()
+	DeltaHandler(w, httptest.NewRequest("GET", "/api/events/delta?lat=55.6761&lng=12.5683&days=3&limit=5", nil))
+	var resp deltaResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Events) != 5 || resp.NextCursor == "" || resp.Cursor == "" {
+		t.Errorf("expected a first page of 5 events with both cursors, got %d events, next %q", len(resp.Events), resp.NextCursor)
+	}
+}
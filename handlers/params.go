@@ -0,0 +1,621 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"calsun/config"
+)
+
+// Parameter types reported by the options endpoint
+const (
+	paramTypeNumber  = "number"
+	paramTypeInteger = "integer"
+	paramTypeString  = "string"
+	paramTypeEnum    = "enum"
+	paramTypeList    = "list" // Comma-separated values from Values
+)
+
+// paramDef declaratively describes a supported query parameter. The same
+// definitions drive validation in the handlers and the options builder in the
+// web UI, so the two cannot drift apart.
+type paramDef struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Required    bool     `json:"required"`
+	Min         *float64 `json:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty"`
+	Default     any      `json:"default,omitempty"`
+	Values      []string `json:"values,omitempty"`
+	Description string   `json:"description"`
+	Advanced    bool     `json:"advanced"` // Shown in the web UI's advanced options section
+
+	// configMax, if set, reads the upper bound from the active configuration
+	// in place of Max, so copies of the definition follow Configure
+	configMax func(c *config.Config) float64
+}
+
+// resolved returns the definition with its limits for the active
+// configuration
+func (p paramDef) resolved() paramDef {
+	if p.configMax != nil {
+		p.Max = bound(p.configMax(cfg))
+	}
+	return p
+}
+
+// MarshalJSON encodes the definition with its configured limits
+func (p paramDef) MarshalJSON() ([]byte, error) {
+	type plain paramDef
+	return json.Marshal(plain(p.resolved()))
+}
+
+// bound returns a pointer to v, for use as a paramDef range limit
+func bound(v float64) *float64 {
+	return &v
+}
+
+var (
+	latParam = paramDef{
+		Name:        "lat",
+		Type:        paramTypeNumber,
+		Required:    true,
+		Min:         bound(-90),
+		Max:         bound(90),
+		Description: "Latitude in decimal degrees",
+	}
+	lngParam = paramDef{
+		Name:        "lng",
+		Type:        paramTypeNumber,
+		Required:    true,
+		Min:         bound(-180),
+		Max:         bound(180),
+		Description: "Longitude in decimal degrees",
+	}
+	placeParam = paramDef{
+		Name:        "place",
+		Type:        paramTypeString,
+		Description: "Place name to geocode instead of giving lat and lng (e.g., Copenhagen)",
+	}
+	geohashParam = paramDef{
+		Name:        "geohash",
+		Type:        paramTypeString,
+		Description: "Geohash to use instead of lat and lng (e.g., u3buz)",
+	}
+	plusCodeParam = paramDef{
+		Name:        "pluscode",
+		Type:        paramTypeString,
+		Description: "Full Plus Code (Open Location Code) to use instead of lat and lng (e.g., 9F7JMHG9+C8)",
+	}
+	utmParam = paramDef{
+		Name:        "utm",
+		Type:        paramTypeString,
+		Description: "UTM zone and band, easting, and northing to use instead of lat and lng (e.g., 31U 448252 5411933)",
+	}
+	mgrsParam = paramDef{
+		Name:        "mgrs",
+		Type:        paramTypeString,
+		Description: "MGRS grid reference to use instead of lat and lng (e.g., 31UDQ4825111932)",
+	}
+	nameParam = paramDef{
+		Name:        "name",
+		Type:        paramTypeString,
+		Description: "Location name shown in event details",
+	}
+	filenameParam = paramDef{
+		Name:        "filename",
+		Type:        paramTypeString,
+		Description: "File name for downloads (e.g., copenhagen-sun.ics)",
+		Advanced:    true,
+	}
+	eventsParam = paramDef{
+		Name:        "events",
+		Type:        paramTypeList,
+		Values:      []string{"dawn", "sunrise", "goldenhour", "noon", "sunset", "dusk"},
+		Description: "Comma-separated event types to include: civil dawn, sunrise, morning and evening golden hour, solar noon, sunset, and civil dusk (default: sunrise,sunset)",
+		Advanced:    true,
+	}
+	excludeParam = paramDef{
+		Name:        "exclude",
+		Type:        paramTypeEnum,
+		Values:      []string{"sunrise", "sunset"},
+		Description: "Event type to leave out of the calendar (kept for older links; prefer events)",
+	}
+	precisionParam = paramDef{
+		Name:        "precision",
+		Type:        paramTypeInteger,
+		Min:         bound(1),
+		Max:         bound(4),
+		Description: "Decimals of the coordinates shown in events and used in event UIDs, e.g. 2 (about 1 km) to keep a home address private (default: the server's setting, usually 4)",
+		Advanced:    true,
+	}
+	azFormatParam = paramDef{
+		Name:        "azformat",
+		Type:        paramTypeEnum,
+		Values:      []string{"degrees", "compass", "both"},
+		Default:     "degrees",
+		Description: "How sunrise and sunset azimuths are shown: degrees, 16-point compass directions (e.g., ENE, also added to event titles), or both",
+		Advanced:    true,
+	}
+	daysParam = paramDef{
+		Name:        "days",
+		Type:        paramTypeInteger,
+		Min:         bound(1),
+		Default:     defaultDays,
+		Description: "Number of days ahead to generate",
+		Advanced:    true,
+		configMax:   func(c *config.Config) float64 { return float64(c.MaxDays) },
+	}
+	dateParam = paramDef{
+		Name:        "date",
+		Type:        paramTypeString,
+		Description: "Generate only the events of one local date (e.g., 2025-06-21) instead of the days ahead",
+		Advanced:    true,
+	}
+	skipParam = paramDef{
+		Name:        "skip",
+		Type:        paramTypeString,
+		Description: "Comma-separated local dates to leave out events on (e.g., 2025-07-04,2025-12-25), such as holidays or travel",
+		Advanced:    true,
+	}
+	skipRangeParam = paramDef{
+		Name:        "skip_range",
+		Type:        paramTypeString,
+		Description: "Comma-separated ranges of local dates, first/last inclusive, to leave out events on (e.g., 2025-12-20/2026-01-02)",
+		Advanced:    true,
+	}
+	weatherParam = paramDef{
+		Name:        "weather",
+		Type:        paramTypeEnum,
+		Values:      []string{"clouds", "aqi", "clouds,aqi"},
+		Description: "Annotate events in the next 7 days with forecast cloud cover and sun visibility, and sunrises with the air quality index",
+		Advanced:    true,
+	}
+	presetParam = paramDef{
+		Name:        "preset",
+		Type:        paramTypeEnum,
+		Values:      []string{"drone", "solunar", "nautical", "aviation", "night"},
+		Description: "Replace sunrise and sunset with events for an activity: drone flight windows, solunar fishing and hunting periods, nautical twilight and moon times for sailors, civil twilight for pilot logbooks, or nights from sunset to sunrise for shift workers and wildlife researchers",
+		Advanced:    true,
+	}
+	flightRuleParam = paramDef{
+		Name:        "flight_rule",
+		Type:        paramTypeEnum,
+		Values:      []string{"civil-twilight", "faa", "daylight"},
+		Default:     "civil-twilight",
+		Description: "Drone flight window with preset=drone: civil dawn to dusk, 30 minutes before sunrise to 30 minutes after sunset (FAA), or sunrise to sunset",
+		Advanced:    true,
+	}
+	flightOffsetParam = paramDef{
+		Name:        "flight_offset",
+		Type:        paramTypeInteger,
+		Min:         bound(-120),
+		Max:         bound(120),
+		Description: "Minutes to extend the drone flight window by on either side, replacing the rule's own (negative narrows it)",
+		Advanced:    true,
+	}
+	windowNameParam = paramDef{
+		Name:        "window_name",
+		Type:        paramTypeString,
+		Default:     "Activity window",
+		Description: "Title of the daily activity window events",
+		Advanced:    true,
+	}
+	windowStartParam = paramDef{
+		Name:        "window_start",
+		Type:        paramTypeString,
+		Description: "Start of a daily activity window, relative to dawn, sunrise, noon, sunset, or dusk (e.g., sunrise+15m)",
+		Advanced:    true,
+	}
+	windowEndParam = paramDef{
+		Name:        "window_end",
+		Type:        paramTypeString,
+		Description: "End of the daily activity window (e.g., sunset-30m)",
+		Advanced:    true,
+	}
+	photoperiodParam = paramDef{
+		Name:        "photoperiod",
+		Type:        paramTypeString,
+		Description: "Comma-separated day lengths in hours (e.g., 12,14) to add all-day events when the day length crosses them, for planting and flowering",
+		Advanced:    true,
+	}
+	dayLengthAlertsParam = paramDef{
+		Name:        "daylength",
+		Type:        paramTypeString,
+		Description: "Comma-separated day length alerts as below:hours or above:hours (e.g., below:9,above:15 or below:8h30m) to add an all-day event when the day length drops below or rises above each",
+		Advanced:    true,
+	}
+	countdownParam = paramDef{
+		Name:        "countdown",
+		Type:        paramTypeList,
+		Values:      []string{"spring", "summer", "autumn", "winter"},
+		Description: "Comma-separated seasons to add weekly all-day countdown events for (e.g., \"63 days until the summer solstice\"), named for the location's hemisphere",
+		Advanced:    true,
+	}
+	apsisParam = paramDef{
+		Name:        "apsis",
+		Type:        paramTypeList,
+		Values:      []string{"perigee", "apogee"},
+		Description: "Comma-separated lunar apsides to add all-day events for, on the days the moon is closest to (perigee) or farthest from (apogee) the Earth, with its distance",
+		Advanced:    true,
+	}
+	planetsParam = paramDef{
+		Name:        "planets",
+		Type:        paramTypeList,
+		Values:      []string{"venus", "mars", "jupiter", "saturn"},
+		Description: "Comma-separated planets to add rise and set events for, with the bearing and how dark the sky is, for stargazing",
+		Advanced:    true,
+	}
+	satellitesParam = paramDef{
+		Name:        "satellites",
+		Type:        paramTypeList,
+		Values:      []string{"iss"},
+		Description: "Comma-separated satellites to add events for their passes visible in the night sky, with the highest elevation and directions, predicted from Celestrak orbital elements for about a week ahead",
+		Advanced:    true,
+	}
+	auroraParam = paramDef{
+		Name:        "aurora",
+		Type:        paramTypeEnum,
+		Values:      []string{"auto", "1", "2", "3", "4", "5", "6", "7", "8", "9"},
+		Description: "Add \"Aurora possible tonight (Kp 6)\" events on nights NOAA's 3-day Kp forecast reaches this index while the sky is dark; auto uses the location's threshold from its geomagnetic latitude (none far from the poles)",
+		Advanced:    true,
+	}
+	eclipsesParam = paramDef{
+		Name:        "eclipses",
+		Type:        paramTypeList,
+		Values:      []string{"solar"},
+		Description: "Comma-separated eclipses to add events for: solar adds the solar eclipses visible from the location, lasting while the sun is up, with the contact times and how much of the sun is covered",
+		Advanced:    true,
+	}
+	summaryParam = paramDef{
+		Name:        "summary",
+		Type:        paramTypeList,
+		Values:      []string{"weekly", "monthly"},
+		Description: "Comma-separated periods to add all-day daylight summary events for: weekly (on Sundays), with the daylight gained or lost and the change since the last solstice, and monthly (on the first), with the month's sunrise and day length extremes, full moons, equinoxes, and solstices",
+		Advanced:    true,
+	}
+	detailsParam = paramDef{
+		Name:        "details",
+		Type:        paramTypeList,
+		Values:      []string{"longitude", "season"},
+		Description: "Comma-separated extra lines for sunrise and sunset descriptions: the sun's ecliptic longitude, and progress through the astronomical season (e.g., \"42% through astronomical spring\")",
+		Advanced:    true,
+	}
+	altDatesParam = paramDef{
+		Name:        "alt_dates",
+		Type:        paramTypeList,
+		Values:      []string{"hijri", "hebrew"},
+		Description: "Comma-separated calendars to add each event's date in to its description: hijri (tabular Islamic, e.g. \"15 Rajab 1446 AH\") and hebrew (e.g. \"15 Tevet 5785\")",
+		Advanced:    true,
+	}
+	altitudeParam = paramDef{
+		Name:        "altitude",
+		Type:        paramTypeNumber,
+		Min:         bound(0),
+		Max:         bound(9000),
+		Description: "Observer height in metres above the horizon (e.g., on a hill by the sea), which lowers the horizon so the sun rises earlier and sets later; default 0, or the terrain elevation where the server looks it up",
+		Advanced:    true,
+	}
+	skylineParam = paramDef{
+		Name:        "skyline",
+		Type:        paramTypeEnum,
+		Values:      []string{"terrain"},
+		Description: "terrain moves sunrise and sunset to when the sun clears and drops behind the surrounding mountains and valley sides, from elevation data sampled up to 25 km around the location; needs the server's elevation lookup",
+		Advanced:    true,
+	}
+	groupParam = paramDef{
+		Name:        "group",
+		Type:        paramTypeEnum,
+		Values:      []string{"civil", "solar"},
+		Default:     "civil",
+		Description: "Days sun events are grouped by: civil calendar days, or solar days from sunset to sunset (the day starting at Friday's sunset is Saturday), which dates event UIDs by solar day, keeps one event of each kind per solar day, and makes date select a solar day",
+		Advanced:    true,
+	}
+	prefixParam = paramDef{
+		Name:        "prefix",
+		Type:        paramTypeString,
+		Description: "Text before every event title (e.g., [CPH] for \"[CPH] Sunrise 06:42\"), up to 24 characters, to tell calendars of several locations apart",
+		Advanced:    true,
+	}
+	suffixParam = paramDef{
+		Name:        "suffix",
+		Type:        paramTypeString,
+		Description: "Text after every event title (e.g., (cabin)), up to 24 characters",
+		Advanced:    true,
+	}
+	uidVersionParam = paramDef{
+		Name:        "uidv",
+		Type:        paramTypeInteger,
+		Min:         bound(1),
+		Max:         bound(2),
+		Default:     1,
+		Description: "Event UID scheme: 1 from the date, location, and event type, stable across option changes; 2 also from the event options, so changing them makes calendar apps replace every event instead of keeping stale copies",
+		Advanced:    true,
+	}
+	localeParam = paramDef{
+		Name:        "locale",
+		Type:        paramTypeEnum,
+		Values:      []string{"en-US", "en-GB", "en-AU", "en-IE", "en-IN", "da", "de", "es", "fi", "fr", "it", "nb", "nl", "pl", "pt", "pt-BR", "sv"},
+		Default:     "en-US",
+		Description: "Locale of dates and numbers in event titles and descriptions: day-first dates (21 June), decimal commas (94,4°), and thousands separators. Labels stay in English, and coordinates keep a decimal point",
+		Advanced:    true,
+	}
+	homeTimezoneParam = paramDef{
+		Name:        "home_tz",
+		Type:        paramTypeString,
+		Description: "IANA timezone (e.g., America/New_York) to also show event times in, after the location's (\"Sunrise 06:42 CET / 00:42 EST\"), for planning from another timezone",
+		Advanced:    true,
+	}
+	solarAzimuthParam = paramDef{
+		Name:        "solar_azimuth",
+		Type:        paramTypeNumber,
+		Min:         bound(0),
+		Max:         bound(360),
+		Description: "Direction a solar panel faces in degrees clockwise from north (180 = south), to add daily peak production window events",
+		Advanced:    true,
+	}
+	solarSpreadParam = paramDef{
+		Name:        "solar_spread",
+		Type:        paramTypeNumber,
+		Min:         bound(5),
+		Max:         bound(180),
+		Default:     60.0,
+		Description: "Degrees either side of the panel direction the sun may be during the production window",
+		Advanced:    true,
+	}
+	solarElevationParam = paramDef{
+		Name:        "solar_elevation",
+		Type:        paramTypeNumber,
+		Min:         bound(0),
+		Max:         bound(90),
+		Default:     15.0,
+		Description: "Minimum sun elevation in degrees during the production window",
+		Advanced:    true,
+	}
+	facadeAzimuthParam = paramDef{
+		Name:        "facade_azimuth",
+		Type:        paramTypeNumber,
+		Min:         bound(0),
+		Max:         bound(360),
+		Description: "Direction a window faces in degrees clockwise from north (180 = south), to add events spanning the direct sun through it each day",
+		Advanced:    true,
+	}
+	facadeFOVParam = paramDef{
+		Name:        "facade_fov",
+		Type:        paramTypeNumber,
+		Min:         bound(10),
+		Max:         bound(180),
+		Default:     180.0,
+		Description: "Degrees of azimuth the window admits direct sun from, centred on its direction (narrower for deep reveals or neighbouring buildings)",
+		Advanced:    true,
+	}
+)
+
+// calendarParamDefs lists the parameters accepted by the calendar endpoints
+var calendarParamDefs = []paramDef{
+	latParam,
+	lngParam,
+	placeParam,
+	geohashParam,
+	plusCodeParam,
+	utmParam,
+	mgrsParam,
+	nameParam,
+	eventsParam,
+	excludeParam,
+	precisionParam,
+	azFormatParam,
+	filenameParam,
+	daysParam,
+	dateParam,
+	skipParam,
+	skipRangeParam,
+	weatherParam,
+	presetParam,
+	flightRuleParam,
+	flightOffsetParam,
+	windowNameParam,
+	windowStartParam,
+	windowEndParam,
+	photoperiodParam,
+	dayLengthAlertsParam,
+	countdownParam,
+	apsisParam,
+	planetsParam,
+	satellitesParam,
+	auroraParam,
+	eclipsesParam,
+	summaryParam,
+	detailsParam,
+	altDatesParam,
+	altitudeParam,
+	skylineParam,
+	groupParam,
+	localeParam,
+	homeTimezoneParam,
+	solarAzimuthParam,
+	solarSpreadParam,
+	solarElevationParam,
+	facadeAzimuthParam,
+	facadeFOVParam,
+	compatParam,
+	uidVersionParam,
+	prefixParam,
+	suffixParam,
+}
+
+// uidIgnoredParams are the calendar parameters left out of version 2 UIDs:
+// the location, which every UID already hashes, and those that only change
+// the range, name, or packaging of the calendar
+var uidIgnoredParams = []paramDef{
+	latParam,
+	lngParam,
+	placeParam,
+	geohashParam,
+	plusCodeParam,
+	utmParam,
+	mgrsParam,
+	nameParam,
+	precisionParam,
+	filenameParam,
+	daysParam,
+	dateParam,
+	skipParam,
+	skipRangeParam,
+	compatParam,
+	uidVersionParam,
+}
+
+// validate checks the parameter's value in the query against the definition,
+// returning an error message if it is invalid. Absent values are valid.
+func (p paramDef) validate(q url.Values) string {
+	var errMsg string
+	switch p.Type {
+	case paramTypeNumber:
+		_, errMsg = p.parseFloat(q)
+	case paramTypeInteger:
+		_, errMsg = p.parseInt(q)
+	case paramTypeEnum:
+		_, errMsg = p.parseEnum(q)
+	case paramTypeList:
+		_, errMsg = p.parseList(q)
+	}
+	return errMsg
+}
+
+// parseFloat parses the parameter from the query, validating it against the
+// definition's range. Returns the default (or zero) value if the parameter is
+// absent, and an error message if validation fails.
+func (p paramDef) parseFloat(q url.Values) (float64, string) {
+	p = p.resolved()
+	str := q.Get(p.Name)
+	if str == "" {
+		if def, ok := p.Default.(float64); ok {
+			return def, ""
+		}
+		return 0, ""
+	}
+
+	v, err := strconv.ParseFloat(str, 64)
+	if err != nil || (p.Min != nil && v < *p.Min) || (p.Max != nil && v > *p.Max) {
+		return 0, fmt.Sprintf("invalid %s parameter", p.Name)
+	}
+	return v, ""
+}
+
+// parseInt parses the parameter from the query, validating it against the
+// definition's range. Returns the default (or zero) value if the parameter is
+// absent, and an error message if validation fails.
+func (p paramDef) parseInt(q url.Values) (int, string) {
+	p = p.resolved()
+	str := q.Get(p.Name)
+	if str == "" {
+		if def, ok := p.Default.(int); ok {
+			return def, ""
+		}
+		return 0, ""
+	}
+
+	v, err := strconv.Atoi(str)
+	if err != nil || (p.Min != nil && float64(v) < *p.Min) || (p.Max != nil && float64(v) > *p.Max) {
+		if p.Min != nil && p.Max != nil {
+			return 0, fmt.Sprintf("%s must be between %g and %g", p.Name, *p.Min, *p.Max)
+		}
+		return 0, fmt.Sprintf("invalid %s parameter", p.Name)
+	}
+	return v, ""
+}
+
+// parseEnum parses the parameter from the query, validating it against the
+// definition's allowed values. Returns the default (or empty) value if the
+// parameter is absent, and an error message if validation fails.
+func (p paramDef) parseEnum(q url.Values) (string, string) {
+	str := q.Get(p.Name)
+	if str == "" {
+		if def, ok := p.Default.(string); ok {
+			return def, ""
+		}
+		return "", ""
+	}
+
+	for _, v := range p.Values {
+		if str == v {
+			return str, ""
+		}
+	}
+
+	quoted := make([]string, len(p.Values))
+	for i, v := range p.Values {
+		quoted[i] = "'" + v + "'"
+	}
+	if len(quoted) == 2 {
+		return "", fmt.Sprintf("%s must be %s", p.Name, strings.Join(quoted, " or "))
+	}
+	return "", fmt.Sprintf("%s must be one of %s", p.Name, strings.Join(quoted, ", "))
+}
+
+// parseList parses the parameter from the query as comma-separated values,
+// validating each against the definition's allowed values. Duplicates are
+// dropped and the values are returned in the order of Values. Returns nil if
+// the parameter is absent, and an error message if validation fails.
+func (p paramDef) parseList(q url.Values) ([]string, string) {
+	str := q.Get(p.Name)
+	if str == "" {
+		return nil, ""
+	}
+
+	given := make(map[string]bool)
+	for _, v := range strings.Split(str, ",") {
+		v = strings.TrimSpace(v)
+		if !slices.Contains(p.Values, v) {
+			return nil, fmt.Sprintf("invalid %s value %q; use a comma-separated list of %s", p.Name, v, strings.Join(p.Values, ", "))
+		}
+		given[v] = true
+	}
+
+	var values []string
+	for _, v := range p.Values {
+		if given[v] {
+			values = append(values, v)
+		}
+	}
+	return values, ""
+}
+
+// canonicalQuery returns a normalized form of the query's values for the
+// given parameters, for use as a cache key. Unknown parameters are dropped,
+// absent parameters take their defaults, and numbers are formatted
+// canonically, so equivalent queries map to the same key. The query must
+// already be validated.
+func canonicalQuery(defs []paramDef, q url.Values) string {
+	canonical := url.Values{}
+	for _, p := range defs {
+		v := q.Get(p.Name)
+		if v == "" && p.Default == nil {
+			continue
+		}
+		switch p.Type {
+		case paramTypeNumber:
+			f, _ := p.parseFloat(q)
+			v = strconv.FormatFloat(f, 'f', -1, 64)
+		case paramTypeInteger:
+			n, _ := p.parseInt(q)
+			v = strconv.Itoa(n)
+		case paramTypeEnum:
+			v, _ = p.parseEnum(q)
+		case paramTypeList:
+			values, _ := p.parseList(q)
+			v = strings.Join(values, ",")
+		default:
+			if v == "" {
+				v = fmt.Sprint(p.Default)
+			}
+		}
+		canonical.Set(p.Name, v)
+	}
+	return canonical.Encode()
+}
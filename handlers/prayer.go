@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"calsun/services"
+)
+
+var (
+	prayerMethodParam = paramDef{
+		Name:        "method",
+		Type:        paramTypeEnum,
+		Values:      []string{"mwl", "isna", "umm-al-qura"},
+		Default:     "mwl",
+		Description: "Calculation method: Muslim World League, Islamic Society of North America, or Umm al-Qura",
+	}
+	prayerAsrParam = paramDef{
+		Name:        "asr",
+		Type:        paramTypeEnum,
+		Values:      []string{"standard", "hanafi"},
+		Default:     "standard",
+		Description: "Asr juristic method: standard (Shafi'i, Maliki, Hanbali) or Hanafi",
+	}
+)
+
+// prayerParamDefs lists the parameters accepted by the prayer times calendar
+var prayerParamDefs = []paramDef{
+	latParam,
+	lngParam,
+	nameParam,
+	daysParam,
+	prayerMethodParam,
+	prayerAsrParam,
+}
+
+// PrayerCalendarHandler generates an iCal calendar with the five daily
+// Islamic prayer times
+func PrayerCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	method, errMsg := prayerMethodParam.parseEnum(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	asr, errMsg := prayerAsrParam.parseEnum(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	opts := services.PrayerOptions{
+		Lat:       params.lat,
+		Lng:       params.lng,
+		Location:  locationName(params),
+		Timezone:  services.GetTimezone(params.lat, params.lng),
+		MethodID:  method,
+		AsrFactor: services.AsrStandard,
+		Precision: params.precision,
+	}
+	if asr == "hanafi" {
+		opts.AsrFactor = services.AsrHanafi
+	}
+
+	calName := "Prayer Times"
+	if params.name != "" {
+		calName = fmt.Sprintf("Prayer Times - %s", params.name)
+	}
+	cal := newCalendar(calName)
+
+	start, days := params.eventRange()
+	for _, event := range params.onDate(services.BuildPrayerEvents(opts, start, days)) {
+		cal.AddVEvent(toVEvent(event, params.now))
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", attachment(params.filename, "calsun-prayer.ics"))
+	w.Write([]byte(cal.Serialize(icsNewLine)))
+}
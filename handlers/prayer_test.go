@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPrayerCalendarHandler(t *testing.T) {
+	req := httptest.NewRequest("GET", "/prayer.ics?lat=21.4225&lng=39.8262&name=Makkah&days=3&method=umm-al-qura&asr=hanafi", nil)
+	w := httptest.NewRecorder()
+
+	PrayerCalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/calendar; charset=utf-8" {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+
+	body := unfoldICal(w.Body.String())
+	if !strings.Contains(body, "X-WR-CALNAME:Prayer Times - Makkah") {
+		t.Error("expected calendar name")
+	}
+	// Five prayers for 3 days plus the past 14 days
+	if n := strings.Count(body, "BEGIN:VEVENT"); n != 5*(3+pastDays) {
+		t.Errorf("expected %d events, got %d", 5*(3+pastDays), n)
+	}
+	for _, prayer := range []string{"Fajr", "Dhuhr", "Asr", "Maghrib", "Isha"} {
+		if !strings.Contains(body, "SUMMARY:"+prayer+" ") {
+			t.Errorf("missing %s events", prayer)
+		}
+	}
+	if !strings.Contains(body, "Method: Umm al-Qura\\, Makkah") || !strings.Contains(body, "Asr: Hanafi") {
+		t.Error("expected method and madhab in descriptions")
+	}
+}
+
+func TestPrayerCalendarHandler_Defaults(t *testing.T) {
+	w := httptest.NewRecorder()
+	PrayerCalendarHandler(w, httptest.NewRequest("GET", "/prayer.ics?lat=51.5074&lng=-0.1278", nil))
+
+	body := unfoldICal(w.Body.String())
+	if !strings.Contains(body, "Method: Muslim World League") || !strings.Contains(body, "Asr: standard") {
+		t.Error("expected MWL and standard Asr by default")
+	}
+	if !strings.Contains(body, "X-WR-CALNAME:Prayer Times\r\n") && !strings.Contains(body, "X-WR-CALNAME:Prayer Times\n") {
+		t.Error("expected default calendar name")
+	}
+}
+
+func TestPrayerCalendarHandler_InvalidParams(t *testing.T) {
+	tests := []string{
+		"lat=abc&lng=12",
+		"lat=55&lng=12&method=egypt",
+		"lat=55&lng=12&asr=maliki",
+		"lat=55&lng=12&days=500",
+	}
+
+	for _, query := range tests {
+		w := httptest.NewRecorder()
+		PrayerCalendarHandler(w, httptest.NewRequest("GET", "/prayer.ics?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+
+	"calsun/services"
+)
+
+const previewDays = 7
+
+var previewTemplate *template.Template
+
+func init() {
+	var err error
+	previewTemplate, err = template.ParseFS(templatesFS, "templates/preview.html")
+	if err != nil {
+		panic("failed to parse preview template: " + err.Error())
+	}
+}
+
+// previewRow is a single day in the preview table
+type previewRow struct {
+	Date      string
+	Sunrise   string
+	Sunset    string
+	DayLength string
+}
+
+// previewData is the template data for the preview fragment
+type previewData struct {
+	Location       string
+	Timezone       string
+	IncludeSunrise bool
+	IncludeSunset  bool
+	Rows           []previewRow
+}
+
+// PreviewFragmentHandler renders an HTML table of the upcoming days' events
+// for the same parameters accepted by CalendarHandler
+func PreviewFragmentHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	tz := services.GetTimezone(params.lat, params.lng)
+	startDate := params.now.Truncate(24 * time.Hour)
+	sunTimes := services.GetSunTimesRange(params.lat, params.lng, startDate, previewDays)
+
+	data := previewData{
+		Location:       locationName(params),
+		Timezone:       tz.String(),
+		IncludeSunrise: params.events.Has(services.EventSunrise),
+		IncludeSunset:  params.events.Has(services.EventSunset),
+		Rows:           make([]previewRow, 0, len(sunTimes)),
+	}
+
+	for _, day := range sunTimes {
+		row := previewRow{
+			Date:      day.Date.Format("Mon Jan 2"),
+			Sunrise:   "—",
+			Sunset:    "—",
+			DayLength: "—",
+		}
+		if day.Sunrise != nil {
+			row.Sunrise = day.Sunrise.Time.In(tz).Format("15:04")
+		}
+		if day.Sunset != nil {
+			row.Sunset = day.Sunset.Time.In(tz).Format("15:04")
+		}
+		if day.Sunrise != nil && day.Sunset != nil {
+			row.DayLength = services.FormatDuration(day.Sunset.Time.Sub(day.Sunrise.Time))
+		}
+		data.Rows = append(data.Rows, row)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := previewTemplate.Execute(w, data); err != nil {
+		http.Error(w, "failed to render template", http.StatusInternalServerError)
+	}
+}
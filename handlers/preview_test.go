@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPreviewFragmentHandler_ValidRequest(t *testing.T) {
+	req := httptest.NewRequest("GET", "/preview/fragment?lat=55.6761&lng=12.5683&name=Copenhagen", nil)
+	w := httptest.NewRecorder()
+
+	PreviewFragmentHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+
+	contentType := w.Header().Get("Content-Type")
+	if !strings.Contains(contentType, "text/html") {
+		t.Errorf("expected Content-Type text/html, got %s", contentType)
+	}
+
+	body := w.Body.String()
+
+	if strings.Contains(body, "<!DOCTYPE html>") {
+		t.Error("fragment should not be a full HTML document")
+	}
+	if !strings.Contains(body, "Copenhagen") {
+		t.Error("fragment should contain the location name")
+	}
+	if !strings.Contains(body, "Europe/Copenhagen") {
+		t.Error("fragment should contain the detected timezone")
+	}
+
+	rows := strings.Count(body, "<tr>") - 1 // exclude header row
+	if rows != previewDays {
+		t.Errorf("expected %d rows, got %d", previewDays, rows)
+	}
+}
+
+func TestPreviewFragmentHandler_Exclude(t *testing.T) {
+	req := httptest.NewRequest("GET", "/preview/fragment?lat=55.6761&lng=12.5683&exclude=sunset", nil)
+	w := httptest.NewRecorder()
+
+	PreviewFragmentHandler(w, req)
+
+	body := w.Body.String()
+
+	if !strings.Contains(body, "<th>Sunrise</th>") {
+		t.Error("fragment should contain sunrise column")
+	}
+	if strings.Contains(body, "<th>Sunset</th>") {
+		t.Error("fragment should not contain sunset column when excluded")
+	}
+}
+
+func TestPreviewFragmentHandler_InvalidParams(t *testing.T) {
+	req := httptest.NewRequest("GET", "/preview/fragment?lat=100&lng=12.5683", nil)
+	w := httptest.NewRecorder()
+
+	PreviewFragmentHandler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"calsun/services"
+)
+
+// maxPushBody bounds the size of a browser's subscription JSON
+const maxPushBody = 8 << 10
+
+// pushNotifier sends push alerts; nil when CALSUN_PUSH_SUBJECT is not set
+var pushNotifier *services.PushNotifier
+
+// SetPushNotifier sets the push notifier used by the push handlers
+func SetPushNotifier(p *services.PushNotifier) {
+	pushNotifier = p
+}
+
+var (
+	alertsParam = paramDef{
+		Name:        "alerts",
+		Type:        paramTypeList,
+		Values:      services.PushEvents,
+		Default:     "sunset",
+		Description: "Comma-separated events to send a browser notification before: sunrise, sunset",
+	}
+	beforeParam = paramDef{
+		Name:        "before",
+		Type:        paramTypeInteger,
+		Min:         bound(0),
+		Max:         bound(services.MaxPushBefore),
+		Default:     15,
+		Description: "Minutes before the event the notification is sent",
+	}
+)
+
+// pushParamDefs lists the parameters accepted by the push subscribe endpoint
+var pushParamDefs = []paramDef{latParam, lngParam, nameParam, alertsParam, beforeParam}
+
+// browserSubscription is the JSON of a browser's PushSubscription
+type browserSubscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// PushKeyHandler returns the VAPID public key browsers subscribe with
+func PushKeyHandler(w http.ResponseWriter, r *http.Request) {
+	if pushNotifier == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"public_key": pushNotifier.PublicKey()})
+}
+
+// PushSubscribeHandler stores a browser's push subscription (the JSON body)
+// with alerts before sunrise or sunset at the location in the query string
+func PushSubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	if pushNotifier == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	alerts, errMsg := alertsParam.parseList(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	if alerts == nil {
+		alerts = []string{alertsParam.Default.(string)}
+	}
+	before, errMsg := beforeParam.parseInt(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	var browser browserSubscription
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPushBody)).Decode(&browser); err != nil {
+		http.Error(w, "invalid subscription JSON", http.StatusBadRequest)
+		return
+	}
+	sub := services.PushSubscription{
+		Endpoint: browser.Endpoint,
+		P256dh:   browser.Keys.P256dh,
+		Auth:     browser.Keys.Auth,
+		Lat:      params.lat,
+		Lng:      params.lng,
+		Name:     params.name,
+		Events:   alerts,
+		Before:   before,
+		BaseURL:  appURL(r, ""),
+	}
+	if err := sub.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := pushNotifier.Subscribe(sub); err != nil {
+		if errors.Is(err, services.ErrTooManyPushSubscriptions) {
+			http.Error(w, "push notifications are not accepting new subscriptions", http.StatusServiceUnavailable)
+			return
+		}
+		log.Printf("push subscribe: %v", err)
+		http.Error(w, "failed to subscribe", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"status": "subscribed"})
+}
+
+// PushUnsubscribeHandler deletes the subscription with the endpoint in the
+// JSON body. Knowing the endpoint, a secret URL, proves the subscription is
+// the caller's.
+func PushUnsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	if pushNotifier == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var browser browserSubscription
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPushBody)).Decode(&browser); err != nil || browser.Endpoint == "" {
+		http.Error(w, "invalid subscription JSON", http.StatusBadRequest)
+		return
+	}
+	if _, err := pushNotifier.Unsubscribe(browser.Endpoint); err != nil {
+		log.Printf("push unsubscribe: %v", err)
+		http.Error(w, "failed to unsubscribe", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
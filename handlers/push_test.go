@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+// fakePushSender is a PushSender that sends nothing
+type fakePushSender struct{}
+
+func (fakePushSender) PublicKey() string { return "BPublicKey" }
+
+func (fakePushSender) Send(ctx context.Context, sub services.PushSubscription, payload []byte, ttl time.Duration) error {
+	return nil
+}
+
+// withTestPushNotifier installs a push notifier with a fake sender for the
+// duration of the test
+func withTestPushNotifier(t *testing.T) *services.PushNotifier {
+	store, err := services.OpenPushStore(filepath.Join(t.TempDir(), "push.json"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	p := services.NewPushNotifier(store, fakePushSender{})
+
+	prev := pushNotifier
+	SetPushNotifier(p)
+	t.Cleanup(func() { SetPushNotifier(prev) })
+	return p
+}
+
+// browserSubscriptionJSON returns a browser's subscription JSON with a fresh
+// key
+func browserSubscriptionJSON(t *testing.T, endpoint string) string {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(map[string]any{
+		"endpoint":       endpoint,
+		"expirationTime": nil,
+		"keys": map[string]string{
+			"p256dh": base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+			"auth":   "BTBZMqHH6r4Tts7J_aSIgg",
+		},
+	})
+	return string(body)
+}
+
+func TestPushKeyHandler(t *testing.T) {
+	withTestPushNotifier(t)
+
+	w := httptest.NewRecorder()
+	PushKeyHandler(w, httptest.NewRequest("GET", "/push/key", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"public_key":"BPublicKey"`) {
+		t.Errorf("expected the public key, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestPushSubscribeHandler(t *testing.T) {
+	p := withTestPushNotifier(t)
+	endpoint := "https://push.example/send/abc"
+
+	req := httptest.NewRequest("POST", "http://calsun.example/push/subscribe?lat=55.6761&lng=12.5683&name=Copenhagen&alerts=sunrise,sunset&before=30", strings.NewReader(browserSubscriptionJSON(t, endpoint)))
+	w := httptest.NewRecorder()
+	PushSubscribeHandler(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	subs := p.Subscriptions()
+	if len(subs) != 1 {
+		t.Fatalf("expected one subscription, got %d", len(subs))
+	}
+	sub := subs[0]
+	if sub.Endpoint != endpoint || sub.Name != "Copenhagen" || len(sub.Events) != 2 || sub.Before != 30 || sub.BaseURL != "http://calsun.example" {
+		t.Errorf("unexpected subscription: %+v", sub)
+	}
+
+	w = httptest.NewRecorder()
+	PushUnsubscribeHandler(w, httptest.NewRequest("POST", "/push/unsubscribe", strings.NewReader(`{"endpoint": "`+endpoint+`"}`)))
+	if w.Code != http.StatusNoContent || len(p.Subscriptions()) != 0 {
+		t.Errorf("expected the subscription deleted, got %d", w.Code)
+	}
+}
+
+func TestPushSubscribeHandler_Defaults(t *testing.T) {
+	p := withTestPushNotifier(t)
+
+	req := httptest.NewRequest("POST", "/push/subscribe?lat=55.6761&lng=12.5683", strings.NewReader(browserSubscriptionJSON(t, "https://push.example/send/abc")))
+	w := httptest.NewRecorder()
+	PushSubscribeHandler(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if sub := p.Subscriptions()[0]; len(sub.Events) != 1 || sub.Events[0] != "sunset" || sub.Before != 15 {
+		t.Errorf("expected 15 minutes before sunset by default, got %+v", sub)
+	}
+}
+
+func TestPushSubscribeHandler_Errors(t *testing.T) {
+	withTestPushNotifier(t)
+	valid := browserSubscriptionJSON(t, "https://push.example/send/abc")
+
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		body   string
+		status int
+	}{
+		{"missing location", "POST", "", valid, http.StatusBadRequest},
+		{"invalid alerts", "POST", "lat=55&lng=12&alerts=noon", valid, http.StatusBadRequest},
+		{"invalid before", "POST", "lat=55&lng=12&before=180", valid, http.StatusBadRequest},
+		{"invalid JSON", "POST", "lat=55&lng=12", "{", http.StatusBadRequest},
+		{"http endpoint", "POST", "lat=55&lng=12", browserSubscriptionJSON(t, "http://push.example/abc"), http.StatusBadRequest},
+		{"missing keys", "POST", "lat=55&lng=12", `{"endpoint": "https://push.example/abc"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			PushSubscribeHandler(w, httptest.NewRequest(tt.method, "/push/subscribe?"+tt.query, strings.NewReader(tt.body)))
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestPushHandlers_NotConfigured(t *testing.T) {
+	prev := pushNotifier
+	SetPushNotifier(nil)
+	t.Cleanup(func() { SetPushNotifier(prev) })
+
+	handlers := map[string]http.HandlerFunc{
+		"/push/key":                     PushKeyHandler,
+		"/push/subscribe?lat=55&lng=12": PushSubscribeHandler,
+		"/push/unsubscribe":             PushUnsubscribeHandler,
+	}
+	for path, handler := range handlers {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("POST", path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404, got %d", path, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	WebHandler(w, httptest.NewRequest("GET", "/", nil))
+	if strings.Contains(w.Body.String(), `id="pushForm"`) {
+		t.Error("expected no push form without push configured")
+	}
+}
+
+func TestWebHandler_PushForm(t *testing.T) {
+	withTestPushNotifier(t)
+
+	w := httptest.NewRecorder()
+	WebHandler(w, httptest.NewRequest("GET", "/", nil))
+	if !strings.Contains(w.Body.String(), `id="pushForm"`) {
+		t.Error("expected the push form")
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+
+	"calsun/services"
+)
+
+var (
+	qrSizeParam = paramDef{
+		Name:        "size",
+		Type:        paramTypeInteger,
+		Min:         bound(64),
+		Max:         bound(1024),
+		Default:     256,
+		Description: "QR code image size in pixels",
+	}
+	qrFormatParam = paramDef{
+		Name:        "format",
+		Type:        paramTypeEnum,
+		Values:      []string{"svg", "png"},
+		Default:     "svg",
+		Description: "QR code image format",
+	}
+)
+
+// qrParamDefs lists the parameters accepted by the QR endpoint in addition
+// to the calendar parameters
+var qrParamDefs = []paramDef{
+	qrSizeParam,
+	qrFormatParam,
+}
+
+// QRHandler renders the webcal:// subscription URL for the given calendar
+// parameters as a QR code (SVG by default, PNG with format=png)
+func QRHandler(w http.ResponseWriter, r *http.Request) {
+	if _, errMsg := parseCalendarParams(r); errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+
+	size, errMsg := qrSizeParam.parseInt(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	format, errMsg := qrFormatParam.parseEnum(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	// The QR-specific parameters are not part of the calendar URL
+	for _, p := range qrParamDefs {
+		q.Del(p.Name)
+	}
+	webcalURL := toWebcalURL(calendarURL(r, q))
+
+	if format == "png" {
+		png, err := services.QRCodePNG(webcalURL, size)
+		if err != nil {
+			http.Error(w, "failed to generate QR code", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+		return
+	}
+
+	svg, err := services.QRCodeSVG(webcalURL, size)
+	if err != nil {
+		http.Error(w, "failed to generate QR code", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Write([]byte(svg))
+}
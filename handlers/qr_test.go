@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestQRHandler_SVG(t *testing.T) {
+	req := httptest.NewRequest("GET", "/qr?lat=55.6761&lng=12.5683&name=Copenhagen", nil)
+	w := httptest.NewRecorder()
+
+	QRHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+
+	contentType := w.Header().Get("Content-Type")
+	if contentType != "image/svg+xml" {
+		t.Errorf("expected Content-Type image/svg+xml, got %s", contentType)
+	}
+	if !strings.HasPrefix(w.Body.String(), "<svg") {
+		t.Error("response should be an SVG image")
+	}
+}
+
+func TestQRHandler_PNG(t *testing.T) {
+	req := httptest.NewRequest("GET", "/qr?lat=55.6761&lng=12.5683&format=png&size=128", nil)
+	w := httptest.NewRecorder()
+
+	QRHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+
+	contentType := w.Header().Get("Content-Type")
+	if contentType != "image/png" {
+		t.Errorf("expected Content-Type image/png, got %s", contentType)
+	}
+
+	img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("response should be a valid PNG: %v", err)
+	}
+	if img.Bounds().Dx() != 128 {
+		t.Errorf("expected 128px image, got %dpx", img.Bounds().Dx())
+	}
+}
+
+func TestQRHandler_InvalidParams(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"missing lat", "/qr?lng=12.5683"},
+		{"invalid calendar param", "/qr?lat=55.6761&lng=12.5683&exclude=invalid"},
+		{"invalid format", "/qr?lat=55.6761&lng=12.5683&format=gif"},
+		{"size too small", "/qr?lat=55.6761&lng=12.5683&size=10"},
+		{"size too large", "/qr?lat=55.6761&lng=12.5683&size=5000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+
+			QRHandler(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
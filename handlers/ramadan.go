@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"calsun/services"
+)
+
+var hijriAdjustParam = paramDef{
+	Name:        "hijri_adjust",
+	Type:        paramTypeInteger,
+	Min:         bound(-2),
+	Max:         bound(2),
+	Default:     0,
+	Description: "Days to shift the calculated Hijri calendar by, to match local moon sighting",
+}
+
+// ramadanParamDefs lists the parameters accepted by the Ramadan calendar
+var ramadanParamDefs = []paramDef{
+	latParam,
+	lngParam,
+	nameParam,
+	prayerMethodParam,
+	hijriAdjustParam,
+}
+
+// RamadanCalendarHandler generates an iCal calendar with daily suhoor and
+// iftar times for the current or upcoming Ramadan
+func RamadanCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	method, errMsg := prayerMethodParam.parseEnum(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	adjust, errMsg := hijriAdjustParam.parseInt(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	opts := services.RamadanOptions{
+		Lat:         params.lat,
+		Lng:         params.lng,
+		Location:    locationName(params),
+		Timezone:    services.GetTimezone(params.lat, params.lng),
+		MethodID:    method,
+		HijriAdjust: adjust,
+		Precision:   params.precision,
+	}
+
+	calName := "Ramadan"
+	if params.name != "" {
+		calName = fmt.Sprintf("Ramadan - %s", params.name)
+	}
+	cal := newCalendar(calName)
+
+	for _, event := range services.BuildRamadanEvents(opts, params.now) {
+		cal.AddVEvent(toVEvent(event, params.now))
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", attachment(params.filename, "calsun-ramadan.ics"))
+	w.Write([]byte(cal.Serialize(icsNewLine)))
+}
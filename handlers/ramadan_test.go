@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRamadanCalendarHandler(t *testing.T) {
+	req := httptest.NewRequest("GET", "/ramadan.ics?lat=21.4225&lng=39.8262&name=Makkah&method=umm-al-qura", nil)
+	w := httptest.NewRecorder()
+
+	RamadanCalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := unfoldICal(w.Body.String())
+	if !strings.Contains(body, "X-WR-CALNAME:Ramadan - Makkah") {
+		t.Error("expected calendar name")
+	}
+
+	// A Hijri month has 29 or 30 days, each with a suhoor and an iftar
+	suhoor, iftar := strings.Count(body, "SUMMARY:Suhoor ends "), strings.Count(body, "SUMMARY:Iftar ")
+	if suhoor < 29 || suhoor > 30 || iftar != suhoor {
+		t.Errorf("expected 29 or 30 suhoor and iftar events, got %d and %d", suhoor, iftar)
+	}
+	if !strings.Contains(body, "Day 1 of Ramadan") || !strings.Contains(body, "Eid al-Fitr is tomorrow") {
+		t.Error("expected countdown in descriptions")
+	}
+}
+
+func TestRamadanCalendarHandler_InvalidParams(t *testing.T) {
+	for _, query := range []string{"lat=abc&lng=12", "lat=55&lng=12&method=jafari", "lat=55&lng=12&hijri_adjust=3", "lat=55&lng=12&hijri_adjust=one"} {
+		w := httptest.NewRecorder()
+		RamadanCalendarHandler(w, httptest.NewRequest("GET", "/ramadan.ics?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+)
+
+// NewMux returns the server's routes for the active configuration. Routes
+// name their methods, so the mux answers other methods with 405 and an Allow
+// header, and GET routes also serve HEAD with the same headers and no body,
+// as some calendar clients probe feeds with HEAD before fetching them.
+// Endpoints of features that are turned off are not routed (404).
+func NewMux() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /calendar.ics", CalendarHandler)
+	mux.HandleFunc("POST /calendar.ics", CalendarHandler)
+	mux.HandleFunc("GET /cal/{location}", PathCalendarHandler)
+	mux.HandleFunc("GET /cal/{location}/{file}", PathCalendarHandler)
+	mux.HandleFunc("GET /calendar", UnifiedCalendarHandler)
+	mux.HandleFunc("GET /prayer.ics", PrayerCalendarHandler)
+	mux.HandleFunc("GET /shabbat.ics", ShabbatCalendarHandler)
+	mux.HandleFunc("GET /ramadan.ics", RamadanCalendarHandler)
+	mux.HandleFunc("GET /moon.ics", MoonCalendarHandler)
+	mux.HandleFunc("GET /circadian.ics", CircadianCalendarHandler)
+	mux.HandleFunc("GET /seasons.ics", SeasonsCalendarHandler)
+	mux.HandleFunc("GET /api/options", OptionsHandler)
+	mux.HandleFunc("GET /api/geocode/suggest", GeocodeSuggestHandler)
+	mux.HandleFunc("GET /api/today", TodayHandler)
+	mux.HandleFunc("GET /api/homeassistant", HomeAssistantHandler)
+	mux.HandleFunc("GET /api/shortcut", ShortcutHandler)
+	mux.HandleFunc("GET /api/brief", BriefHandler)
+	mux.HandleFunc("GET /api/moon", MoonHandler)
+	mux.HandleFunc("GET /api/circadian", CircadianHandler)
+	mux.HandleFunc("GET /map", TerminatorMapHandler)
+	mux.HandleFunc("GET /day", DayHandler)
+	mux.HandleFunc("GET /api/compare", CompareHandler)
+	mux.HandleFunc("GET /api/when", WhenHandler)
+	mux.HandleFunc("GET /api/timezone", TimezoneHandler)
+	mux.HandleFunc("GET /api/solartime", SolarTimeHandler)
+	mux.HandleFunc("GET /api/facade", FacadeHandler)
+	mux.HandleFunc("GET /api/irradiance", IrradianceHandler)
+	mux.HandleFunc("GET /api/seasons/lengths", SeasonLengthsHandler)
+	mux.HandleFunc("GET /api/stats", StatsHandler)
+	mux.HandleFunc("GET /api/validate", ValidateHandler)
+	mux.HandleFunc("POST /api/validate", ValidateHandler)
+	mux.HandleFunc("GET /api/events/delta", DeltaHandler)
+	mux.HandleFunc("GET /api/diff", DiffHandler)
+	mux.HandleFunc("GET /archive.zip", ArchiveHandler)
+	mux.HandleFunc("GET /api/influx", InfluxHandler)
+	mux.HandleFunc("GET /api/triggers/sun", SunTriggerHandler)
+
+	// Grafana's SimpleJSON and JSON datasources differ in the methods they
+	// list metrics with
+	mux.HandleFunc("GET /grafana/{$}", GrafanaTestHandler)
+	mux.HandleFunc("GET /grafana/search", GrafanaSearchHandler)
+	mux.HandleFunc("POST /grafana/search", GrafanaSearchHandler)
+	mux.HandleFunc("GET /grafana/metrics", GrafanaMetricsHandler)
+	mux.HandleFunc("POST /grafana/metrics", GrafanaMetricsHandler)
+	mux.HandleFunc("POST /grafana/query", GrafanaQueryHandler)
+	mux.HandleFunc("GET /ifttt/v1/status", IFTTTStatusHandler)
+	mux.HandleFunc("POST /ifttt/v1/test/setup", IFTTTTestSetupHandler)
+	mux.HandleFunc("POST /ifttt/v1/triggers/sun_event", IFTTTSunEventHandler)
+
+	// Web UI and its pages. In headless mode they are gone (410) for every
+	// method and other paths are not found (404).
+	if cfg.Headless {
+		for _, pattern := range []string{"/{$}", "/static/", "/manifest.webmanifest", "/sw.js", "/compare", "/solartime"} {
+			mux.HandleFunc(pattern, DisabledHandler)
+		}
+	} else {
+		mux.HandleFunc("GET /{$}", WebHandler)
+		mux.Handle("GET /static/", StaticHandler)
+		mux.HandleFunc("GET /manifest.webmanifest", ManifestHandler)
+		mux.HandleFunc("GET /sw.js", ServiceWorkerHandler)
+		mux.HandleFunc("GET /compare", ComparePageHandler)
+		mux.HandleFunc("GET /solartime", SolarTimePageHandler)
+	}
+	if cfg.DisablePreview {
+		mux.HandleFunc("/preview/fragment", DisabledHandler)
+		mux.HandleFunc("/qr", DisabledHandler)
+	} else {
+		mux.HandleFunc("GET /preview/fragment", PreviewFragmentHandler)
+		mux.HandleFunc("GET /qr", QRHandler)
+	}
+
+	if cfg.Sync.GoogleEnabled() || cfg.Sync.MicrosoftEnabled() {
+		mux.HandleFunc("GET /integrations/{provider}/connect", IntegrationConnectHandler)
+		mux.HandleFunc("GET /integrations/{provider}/callback", IntegrationCallbackHandler)
+		mux.HandleFunc("POST /integrations/disconnect", IntegrationDisconnectHandler)
+	}
+	if cfg.SMTP.Enabled() {
+		mux.HandleFunc("POST /email/subscribe", EmailSubscribeHandler)
+		mux.HandleFunc("GET /email/confirm", EmailConfirmHandler)
+		// GET asks for confirmation; POST also serves one-click unsubscribe
+		mux.HandleFunc("GET /email/unsubscribe", EmailUnsubscribeHandler)
+		mux.HandleFunc("POST /email/unsubscribe", EmailUnsubscribeHandler)
+	}
+	if cfg.Push.Enabled() {
+		mux.HandleFunc("GET /push/key", PushKeyHandler)
+		mux.HandleFunc("POST /push/subscribe", PushSubscribeHandler)
+		mux.HandleFunc("POST /push/unsubscribe", PushUnsubscribeHandler)
+	}
+	if cfg.Admin.Enabled() {
+		mux.HandleFunc("GET /admin", AdminHandler)
+		mux.HandleFunc("GET /admin/analytics", AdminAnalyticsHandler)
+		mux.HandleFunc("GET /admin/cache", AdminCacheHandler)
+		mux.HandleFunc("POST /admin/cache/flush", AdminCacheFlushHandler)
+	}
+	return mux
+}
+
+// routePath returns the path of a route pattern, without its method (e.g.,
+// "/calendar.ics" for "GET /calendar.ics")
+func routePath(pattern string) string {
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return path
+	}
+	return pattern
+}
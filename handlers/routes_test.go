@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"calsun/config"
+)
+
+// configureRoutes sets a configuration for the test, with every optional
+// feature turned on if all is set
+func configureRoutes(t *testing.T, all, headless bool) {
+	t.Helper()
+	original := cfg
+	t.Cleanup(func() { Configure(original) })
+	c := config.Default()
+	c.Headless = headless
+	if all {
+		c.Admin.Token = "secret"
+		c.SMTP.Host, c.SMTP.From = "smtp.example", "calsun@example.com"
+		c.Push.Subject = "mailto:admin@example.com"
+		c.Sync.GoogleClientID, c.Sync.GoogleClientSecret = "id", "secret"
+	}
+	Configure(c)
+}
+
+func TestNewMux_Methods(t *testing.T) {
+	configureRoutes(t, true, false)
+	mux := NewMux()
+
+	tests := []struct {
+		method, target string
+		status         int
+		allow          string
+	}{
+		{"DELETE", "/calendar.ics", http.StatusMethodNotAllowed, "GET, HEAD, POST"},
+		{"POST", "/api/today", http.StatusMethodNotAllowed, "GET, HEAD"},
+		{"PUT", "/api/validate", http.StatusMethodNotAllowed, "GET, HEAD, POST"},
+		{"GET", "/admin/cache/flush", http.StatusMethodNotAllowed, "POST"},
+		{"GET", "/email/subscribe", http.StatusMethodNotAllowed, "POST"},
+		{"GET", "/push/subscribe", http.StatusMethodNotAllowed, "POST"},
+		{"GET", "/integrations/disconnect", http.StatusMethodNotAllowed, "POST"},
+		{"GET", "/grafana/query", http.StatusMethodNotAllowed, "POST"},
+		{"POST", "/", http.StatusMethodNotAllowed, "GET, HEAD"},
+		{"GET", "/unknown", http.StatusNotFound, ""},
+		{"GET", "/api/today?lat=55.6761&lng=12.5683", http.StatusOK, ""},
+		{"POST", "/grafana/search", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.target, tt.status, w.Code)
+		}
+		if allow := w.Header().Get("Allow"); allow != tt.allow {
+			t.Errorf("%s %s: expected Allow %q, got %q", tt.method, tt.target, tt.allow, allow)
+		}
+	}
+}
+
+func TestNewMux_Features(t *testing.T) {
+	configureRoutes(t, false, true)
+	mux := NewMux()
+
+	tests := []struct {
+		method, target string
+		status         int
+	}{
+		{"GET", "/admin", http.StatusNotFound},
+		{"GET", "/push/key", http.StatusNotFound},
+		{"POST", "/email/subscribe", http.StatusNotFound},
+		// Pages of the web UI are gone in headless mode, whatever the method
+		{"GET", "/", http.StatusGone},
+		{"POST", "/", http.StatusGone},
+		{"GET", "/sw.js", http.StatusGone},
+		{"GET", "/compare", http.StatusGone},
+		{"GET", "/solartime", http.StatusGone},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.target, tt.status, w.Code)
+		}
+	}
+}
+
+func TestNewMux_Head(t *testing.T) {
+	configureRoutes(t, false, false)
+	srv := httptest.NewServer(NewMux())
+	t.Cleanup(srv.Close)
+	target := srv.URL + "/calendar.ics?lat=55.6761&lng=12.5683&days=2"
+
+	get, err := http.Get(target)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	body, _ := io.ReadAll(get.Body)
+	get.Body.Close()
+
+	head, err := http.Head(target)
+	if err != nil {
+		t.Fatalf("HEAD failed: %v", err)
+	}
+	defer head.Body.Close()
+	if head.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", head.StatusCode)
+	}
+	if ct := head.Header.Get("Content-Type"); ct != get.Header.Get("Content-Type") || !strings.HasPrefix(ct, "text/calendar") {
+		t.Errorf("expected the calendar's content type, got %q", ct)
+	}
+	if length := head.Header.Get("Content-Length"); length != strconv.Itoa(len(body)) {
+		t.Errorf("expected Content-Length %d, got %s", len(body), length)
+	}
+	if head.Header.Get("Content-Disposition") == "" || head.Header.Get("X-Cache") != "HIT" {
+		t.Errorf("expected the calendar's headers from the cache, got %v", head.Header)
+	}
+	if n, _ := io.Copy(io.Discard, head.Body); n != 0 {
+		t.Errorf("expected no body, got %d bytes", n)
+	}
+}
+
+func TestRoutePath(t *testing.T) {
+	for pattern, want := range map[string]string{
+		"GET /calendar.ics":     "/calendar.ics",
+		"/calendar.ics":         "/calendar.ics",
+		"POST /admin/cache/{$}": "/admin/cache/{$}",
+	} {
+		if got := routePath(pattern); got != want {
+			t.Errorf("%s: expected %s, got %s", pattern, want, got)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"calsun/services"
+)
+
+// satelliteTimeout bounds how long a calendar request waits for orbital
+// elements
+const satelliteTimeout = 3 * time.Second
+
+// orbits is the TLE provider shared by all handlers
+var orbits services.TLEProvider = services.NewCelestrak(services.CelestrakURL)
+
+// satelliteCatalog maps the satellites parameter's values to their NORAD
+// catalog numbers and event labels
+var satelliteCatalog = map[string]struct {
+	catalogNumber int
+	label         string
+}{
+	"iss": {services.ISSCatalogNumber, "ISS"},
+}
+
+// buildSatelliteEvents returns the visible pass events of the satellites
+// requested by the satellites parameter. Satellites whose orbits are
+// unavailable are skipped, so the calendar is still served.
+func buildSatelliteEvents(ctx context.Context, params *calendarParams, opts services.CalendarOptions, startDate time.Time, days int) []services.CalendarEvent {
+	ctx, cancel := context.WithTimeout(ctx, satelliteTimeout)
+	defer cancel()
+
+	var events []services.CalendarEvent
+	for _, name := range params.satellites {
+		satellite := satelliteCatalog[name]
+		tle, err := orbits.TLE(ctx, satellite.catalogNumber)
+		if err != nil {
+			log.Printf("%s orbital elements: %v", satellite.label, err)
+			continue
+		}
+		sat, err := services.ParseTLE(tle)
+		if err != nil {
+			log.Printf("%s orbital elements: %v", satellite.label, err)
+			continue
+		}
+		events = append(events, services.BuildSatellitePassEvents(opts, sat, satellite.label, startDate, days)...)
+	}
+	return events
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"calsun/services"
+)
+
+// fakeOrbits is a TLEProvider returning a fixed ISS-like element set with its
+// epoch at noon UTC on 16 March 2025, or an error
+type fakeOrbits struct {
+	err error
+}
+
+func (f fakeOrbits) TLE(ctx context.Context, catalogNumber int) (services.TLE, error) {
+	if f.err != nil {
+		return services.TLE{}, f.err
+	}
+	return services.TLE{
+		Name:  "ISS (ZARYA)",
+		Line1: "1 25544U 98067A   25075.50000000  .00016717  00000-0  30000-3 0  9992",
+		Line2: "2 25544  51.6416  30.4627 0006703 130.5360 325.0288 15.49815308432002",
+	}, nil
+}
+
+// useTestOrbits replaces the TLE provider for the duration of the test
+func useTestOrbits(t *testing.T, provider services.TLEProvider) {
+	original := orbits
+	orbits = provider
+	t.Cleanup(func() { orbits = original })
+}
+
+func TestCalendarHandler_Satellites(t *testing.T) {
+	enableDebug(t)
+	useTestOrbits(t, fakeOrbits{})
+
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&satellites=iss&days=2&now=2025-03-16T08:00:00Z", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := unfoldICal(w.Body.String())
+	// The past 14 days are limited to a week before the orbit's epoch
+	if n := strings.Count(body, "SUMMARY:ISS pass "); n != 12 {
+		t.Errorf("expected 12 visible passes from March 9 to 17, got %d", n)
+	}
+	for _, want := range []string{"SUMMARY:ISS pass 19:01\\, max 31° (WSW to ESE)", "Highest: 19:07:05 in the SSE\\, 31° up", "SUMMARY:Sunrise"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q", want)
+		}
+	}
+	if w.Header().Get("X-Cache") != "MISS" {
+		t.Error("expected satellite passes not to be served from the cache")
+	}
+
+	w = httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&satellites=hubble", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown satellite, got %d", w.Code)
+	}
+}
+
+func TestCalendarHandler_SatellitesUnavailable(t *testing.T) {
+	useTestOrbits(t, fakeOrbits{err: errors.New("upstream down")})
+
+	w := httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&satellites=iss", nil))
+
+	body := w.Body.String()
+	if w.Code != http.StatusOK || strings.Contains(body, "ISS pass") || !strings.Contains(body, "SUMMARY:Sunrise") {
+		t.Error("expected the calendar without satellite passes")
+	}
+}
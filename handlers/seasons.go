@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"calsun/services"
+)
+
+// seasonsYearParam is the year whose seasons are given
+var seasonsYearParam = paramDef{
+	Name:        "year",
+	Type:        paramTypeInteger,
+	Min:         bound(1000),
+	Max:         bound(3000),
+	Description: "Year the seasons start in (default: the current year at the location; the calendar also adds the next year)",
+}
+
+// seasonLengthsParamDefs lists the parameters accepted by
+// SeasonLengthsHandler
+var seasonLengthsParamDefs = []paramDef{latParam, lngParam, nameParam, seasonsYearParam}
+
+// seasonsCalendarParamDefs lists the parameters accepted by the season
+// lengths calendar
+var seasonsCalendarParamDefs = []paramDef{latParam, lngParam, nameParam, seasonsYearParam, localeParam}
+
+// seasonLengthsResponse is the JSON body returned by SeasonLengthsHandler.
+// Times are in the location's timezone (RFC 3339).
+type seasonLengthsResponse struct {
+	Location   string             `json:"location"`
+	Timezone   string             `json:"timezone"`
+	Year       int                `json:"year"`
+	Hemisphere string             `json:"hemisphere"` // "northern" or "southern", which names the seasons
+	Seasons    []seasonLengthJSON `json:"seasons"`
+	Longest    string             `json:"longest"`
+	Shortest   string             `json:"shortest"`
+}
+
+// seasonLengthJSON is an astronomical season and its length
+type seasonLengthJSON struct {
+	Season          string    `json:"season"`
+	Event           string    `json:"event"` // The equinox or solstice starting it, e.g. "March equinox"
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds int       `json:"duration_seconds"`
+	Days            float64   `json:"days"`
+	Length          string    `json:"length"` // e.g. "92d 18h"
+}
+
+// SeasonLengthsHandler returns the exact length of each astronomical season
+// starting in a year as JSON, named for the location's hemisphere
+func SeasonLengthsHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	tz := services.GetTimezone(params.lat, params.lng)
+	year, errMsg := seasonsYear(r, params, tz)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	resp := seasonLengthsResponse{
+		Location:   locationName(params),
+		Timezone:   tz.String(),
+		Year:       year,
+		Hemisphere: "northern",
+		Seasons:    []seasonLengthJSON{},
+	}
+	if params.lat < 0 {
+		resp.Hemisphere = "southern"
+	}
+	var longest, shortest time.Duration
+	for _, s := range services.SeasonLengths(params.lat, year) {
+		d := s.Duration()
+		resp.Seasons = append(resp.Seasons, seasonLengthJSON{
+			Season:          s.Season,
+			Event:           s.Event.Name(),
+			Start:           s.Start.In(tz),
+			End:             s.End.In(tz),
+			DurationSeconds: int(d.Seconds()),
+			Days:            round2(d.Hours() / 24),
+			Length:          services.FormatSeasonLength(d),
+		})
+		if d > longest {
+			longest, resp.Longest = d, s.Season
+		}
+		if shortest == 0 || d < shortest {
+			shortest, resp.Shortest = d, s.Season
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// SeasonsCalendarHandler generates an iCal calendar with an all-day event
+// for the start of each astronomical season and its length, for the current
+// and next year or the requested year
+func SeasonsCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	tz := services.GetTimezone(params.lat, params.lng)
+	first, errMsg := seasonsYear(r, params, tz)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	last := first
+	if r.URL.Query().Get(seasonsYearParam.Name) == "" {
+		last++
+	}
+
+	opts := services.CalendarOptions{
+		Lat:       params.lat,
+		Lng:       params.lng,
+		Location:  locationName(params),
+		Timezone:  tz,
+		Precision: params.precision,
+		Locale:    params.locale,
+	}
+
+	calName := "Season Lengths"
+	if params.name != "" {
+		calName = fmt.Sprintf("Season Lengths - %s", params.name)
+	}
+	cal := newCalendar(calName)
+	for _, event := range services.BuildSeasonLengthEvents(opts, first, last) {
+		cal.AddVEvent(toVEvent(event, params.now))
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", attachment(params.filename, "calsun-seasons.ics"))
+	w.Write([]byte(cal.Serialize(icsNewLine)))
+}
+
+// seasonsYear returns the requested year, or the current year at the
+// location
+func seasonsYear(r *http.Request, params *calendarParams, tz *time.Location) (int, string) {
+	year, errMsg := seasonsYearParam.parseInt(r.URL.Query())
+	if errMsg != "" {
+		return 0, errMsg
+	}
+	if year == 0 {
+		year = params.now.In(tz).Year()
+	}
+	return year, ""
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+func TestSeasonLengthsHandler(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/seasons/lengths?lat=55.6761&lng=12.5683&year=2025", nil)
+	w := httptest.NewRecorder()
+
+	SeasonLengthsHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp seasonLengthsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Year != 2025 || resp.Hemisphere != "northern" || resp.Timezone != "Europe/Copenhagen" || len(resp.Seasons) != 4 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	spring := resp.Seasons[0]
+	if spring.Season != "spring" || spring.Event != "March equinox" || spring.Length != "92d 18h" || spring.Days != 92.74 {
+		t.Errorf("unexpected spring: %+v", spring)
+	}
+	if spring.Start.Format("2006-01-02T15:04Z07:00") != "2025-03-20T10:01+01:00" || !spring.End.Equal(resp.Seasons[1].Start) {
+		t.Errorf("expected spring from the local equinox to the solstice, got %s to %s", spring.Start, spring.End)
+	}
+	if resp.Longest != "summer" || resp.Shortest != "winter" {
+		t.Errorf("expected summer longest and winter shortest, got %s and %s", resp.Longest, resp.Shortest)
+	}
+}
+
+func TestSeasonLengthsHandler_Southern(t *testing.T) {
+	w := httptest.NewRecorder()
+	SeasonLengthsHandler(w, httptest.NewRequest("GET", "/api/seasons/lengths?lat=-33.87&lng=151.21&year=2025", nil))
+
+	var resp seasonLengthsResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Hemisphere != "southern" || resp.Seasons[0].Season != "autumn" || resp.Longest != "winter" {
+		t.Errorf("expected seasons named for the southern hemisphere, got %+v", resp)
+	}
+}
+
+func TestSeasonLengthsHandler_CurrentYear(t *testing.T) {
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	// New Year's Eve in UTC is already next year in Tokyo
+	SetClock(services.FixedClock(time.Date(2025, 12, 31, 20, 0, 0, 0, time.UTC)))
+
+	w := httptest.NewRecorder()
+	SeasonLengthsHandler(w, httptest.NewRequest("GET", "/api/seasons/lengths?lat=35.68&lng=139.69", nil))
+	var resp seasonLengthsResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Year != 2026 {
+		t.Errorf("expected the local year, got %d", resp.Year)
+	}
+}
+
+func TestSeasonLengthsHandler_InvalidYear(t *testing.T) {
+	for _, url := range []string{
+		"/api/seasons/lengths?lat=55.6761&lng=12.5683&year=999",
+		"/api/seasons/lengths?lat=55.6761&lng=12.5683&year=abc",
+		"/seasons.ics?lat=55.6761&lng=12.5683&year=3001",
+		"/api/seasons/lengths?lng=12.5683",
+	} {
+		w := httptest.NewRecorder()
+		NewMux().ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", url, w.Code)
+		}
+	}
+}
+
+func TestSeasonsCalendarHandler(t *testing.T) {
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	SetClock(services.FixedClock(time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)))
+
+	req := httptest.NewRequest("GET", "/seasons.ics?lat=55.6761&lng=12.5683&name=Copenhagen", nil)
+	w := httptest.NewRecorder()
+	SeasonsCalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.Contains(cd, "calsun-seasons.ics") {
+		t.Errorf("unexpected Content-Disposition: %s", cd)
+	}
+	if report := lintICS(w.Body.Bytes()); !report.Valid {
+		t.Errorf("expected a valid calendar, got %+v", report.Errors)
+	}
+	body := unfoldICal(w.Body.String())
+	if !strings.Contains(body, "X-WR-CALNAME:Season Lengths - Copenhagen") {
+		t.Error("expected the calendar name")
+	}
+	// This year and next
+	if n := strings.Count(body, "BEGIN:VEVENT"); n != 8 {
+		t.Errorf("expected 8 events, got %d", n)
+	}
+	if !strings.Contains(body, "DTSTART;VALUE=DATE:20250320") || !strings.Contains(body, "SUMMARY:Spring begins (lasts 92d 18h)") {
+		t.Error("expected an all-day spring event on the equinox")
+	}
+
+	w = httptest.NewRecorder()
+	SeasonsCalendarHandler(w, httptest.NewRequest("GET", "/seasons.ics?lat=55.6761&lng=12.5683&year=2030", nil))
+	if body := w.Body.String(); strings.Count(body, "BEGIN:VEVENT") != 4 || !strings.Contains(body, "DTSTART;VALUE=DATE:20300320") {
+		t.Error("expected only the requested year's seasons")
+	}
+}
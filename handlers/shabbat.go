@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"calsun/services"
+)
+
+var (
+	shabbatCandlesParam = paramDef{
+		Name:        "candles",
+		Type:        paramTypeInteger,
+		Min:         bound(0),
+		Max:         bound(90),
+		Default:     18,
+		Description: "Candle lighting, in minutes before Friday sunset",
+	}
+	shabbatHavdalahParam = paramDef{
+		Name:        "havdalah",
+		Type:        paramTypeInteger,
+		Min:         bound(1),
+		Max:         bound(120),
+		Description: "Havdalah, in minutes after Saturday sunset (default: nightfall, sun 8.5° below the horizon)",
+	}
+)
+
+// shabbatParamDefs lists the parameters accepted by the Shabbat calendar
+var shabbatParamDefs = []paramDef{
+	latParam,
+	lngParam,
+	nameParam,
+	daysParam,
+	shabbatCandlesParam,
+	shabbatHavdalahParam,
+}
+
+// ShabbatCalendarHandler generates an iCal calendar with weekly candle
+// lighting and havdalah times
+func ShabbatCalendarHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	candles, errMsg := shabbatCandlesParam.parseInt(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	havdalah, errMsg := shabbatHavdalahParam.parseInt(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	opts := services.ShabbatOptions{
+		Lat:             params.lat,
+		Lng:             params.lng,
+		Location:        locationName(params),
+		Timezone:        services.GetTimezone(params.lat, params.lng),
+		CandleMinutes:   candles,
+		HavdalahMinutes: havdalah,
+		Precision:       params.precision,
+	}
+
+	calName := "Shabbat Times"
+	if params.name != "" {
+		calName = fmt.Sprintf("Shabbat Times - %s", params.name)
+	}
+	cal := newCalendar(calName)
+
+	start, days := params.eventRange()
+	for _, event := range params.onDate(services.BuildShabbatEvents(opts, start, days)) {
+		cal.AddVEvent(toVEvent(event, params.now))
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", attachment(params.filename, "calsun-shabbat.ics"))
+	w.Write([]byte(cal.Serialize(icsNewLine)))
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestShabbatCalendarHandler(t *testing.T) {
+	req := httptest.NewRequest("GET", "/shabbat.ics?lat=31.7683&lng=35.2137&name=Jerusalem&candles=40&days=28", nil)
+	w := httptest.NewRecorder()
+
+	ShabbatCalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := unfoldICal(w.Body.String())
+	if !strings.Contains(body, "X-WR-CALNAME:Shabbat Times - Jerusalem") {
+		t.Error("expected calendar name")
+	}
+
+	// 42 days (including the past 14) hold exactly 6 Fridays and 6 Saturdays
+	if n := strings.Count(body, "SUMMARY:Candle lighting "); n != 6 {
+		t.Errorf("expected 6 candle lighting events, got %d", n)
+	}
+	if n := strings.Count(body, "SUMMARY:Havdalah "); n != 6 {
+		t.Errorf("expected 6 havdalah events, got %d", n)
+	}
+	if !strings.Contains(body, "40 minutes before sunset") || !strings.Contains(body, "Nightfall") {
+		t.Error("expected candle lighting and havdalah rules in descriptions")
+	}
+}
+
+func TestShabbatCalendarHandler_HavdalahMinutes(t *testing.T) {
+	w := httptest.NewRecorder()
+	ShabbatCalendarHandler(w, httptest.NewRequest("GET", "/shabbat.ics?lat=40.7128&lng=-74.0060&havdalah=72", nil))
+
+	body := unfoldICal(w.Body.String())
+	if !strings.Contains(body, "72 minutes after sunset") || !strings.Contains(body, "18 minutes before sunset") {
+		t.Error("expected fixed havdalah and default candle lighting")
+	}
+}
+
+func TestShabbatCalendarHandler_InvalidParams(t *testing.T) {
+	for _, query := range []string{"lat=abc&lng=12", "lat=55&lng=12&candles=-5", "lat=55&lng=12&havdalah=0", "lat=55&lng=12&havdalah=soon"} {
+		w := httptest.NewRecorder()
+		ShabbatCalendarHandler(w, httptest.NewRequest("GET", "/shabbat.ics?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"calsun/services"
+)
+
+// shortcutParamDefs lists the parameters accepted by ShortcutHandler
+var shortcutParamDefs = []paramDef{latParam, lngParam, nameParam, dateParam}
+
+// shortcutResponse is the JSON body returned by ShortcutHandler. It is kept
+// flat and string-only because Siri Shortcuts handles nested objects, nulls,
+// and numbers poorly. Values are empty when the event does not occur.
+type shortcutResponse struct {
+	Location         string `json:"location"`
+	Date             string `json:"date"`
+	Sunrise          string `json:"sunrise"` // RFC 3339, local time
+	SunriseSpoken    string `json:"sunrise_spoken"`
+	Sunset           string `json:"sunset"`
+	SunsetSpoken     string `json:"sunset_spoken"`
+	GoldenHour       string `json:"golden_hour"` // Start of the evening golden hour
+	GoldenHourSpoken string `json:"golden_hour_spoken"`
+	DayLength        string `json:"day_length"` // ISO 8601 duration
+	DayLengthSpoken  string `json:"day_length_spoken"`
+	Summary          string `json:"summary"` // A sentence suitable for "Speak Text"
+}
+
+// ShortcutHandler returns today's (or the requested date's) sun times for a
+// location as a compact, flat JSON object with spoken-text variants, for
+// Apple Shortcuts
+func ShortcutHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	tz := services.GetTimezone(params.lat, params.lng)
+	now := params.now.In(tz)
+	if !params.date.IsZero() {
+		now = params.date
+	}
+	noon := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, tz)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buildShortcutResponse(locationName(params), params.lat, params.lng, noon))
+}
+
+// buildShortcutResponse computes the response for the day containing noon,
+// which must be in the location's timezone
+func buildShortcutResponse(location string, lat, lng float64, noon time.Time) shortcutResponse {
+	tz := noon.Location()
+	day := services.GetSunTimes(lat, lng, noon)
+	times := day.Times
+
+	resp := shortcutResponse{
+		Location: location,
+		Date:     noon.Format("2006-01-02"),
+	}
+	setShortcutTime(&resp.Sunrise, &resp.SunriseSpoken, times.Sunrise, tz)
+	setShortcutTime(&resp.Sunset, &resp.SunsetSpoken, times.Sunset, tz)
+	setShortcutTime(&resp.GoldenHour, &resp.GoldenHourSpoken, times.GoldenHour, tz)
+
+	switch {
+	case !times.Sunrise.IsZero() && !times.Sunset.IsZero():
+		dayLength := times.Sunset.Sub(times.Sunrise)
+		resp.DayLength = services.ISODuration(dayLength)
+		resp.DayLengthSpoken = services.SpokenDuration(dayLength)
+		resp.Summary = "Sunrise is at " + resp.SunriseSpoken + " and sunset at " + resp.SunsetSpoken +
+			", with " + resp.DayLengthSpoken + " of daylight."
+	case !times.Sunrise.IsZero():
+		resp.Summary = "Sunrise is at " + resp.SunriseSpoken + ". The sun does not set today."
+	case !times.Sunset.IsZero():
+		resp.Summary = "The sun does not rise today. Sunset is at " + resp.SunsetSpoken + "."
+	case day.PolarDay():
+		resp.Summary = "The sun stays up all day today."
+	default:
+		resp.Summary = "The sun does not rise today."
+	}
+
+	return resp
+}
+
+// setShortcutTime sets the RFC 3339 and spoken forms of t, leaving both empty
+// if t is zero
+func setShortcutTime(iso, spoken *string, t time.Time, tz *time.Location) {
+	if t.IsZero() {
+		return
+	}
+	local := t.In(tz)
+	*iso = local.Format(time.RFC3339)
+	*spoken = services.SpokenTime(local)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+func TestShortcutHandler_ValidRequest(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/shortcut?lat=55.6761&lng=12.5683&name=Copenhagen", nil)
+	w := httptest.NewRecorder()
+
+	ShortcutHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	// Every value must be a string for Shortcuts
+	var raw map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &raw); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	for key, value := range raw {
+		if _, ok := value.(string); !ok {
+			t.Errorf("key %q is %T, expected a string", key, value)
+		}
+	}
+	if raw["location"] != "Copenhagen" {
+		t.Errorf("expected location Copenhagen, got %v", raw["location"])
+	}
+}
+
+func TestBuildShortcutResponse(t *testing.T) {
+	tz := services.GetTimezone(55.6761, 12.5683)
+	resp := buildShortcutResponse("Copenhagen", 55.6761, 12.5683, time.Date(2024, 3, 10, 12, 0, 0, 0, tz))
+
+	if !strings.HasPrefix(resp.Sunrise, "2024-03-10T06:") || !strings.HasSuffix(resp.Sunrise, "+01:00") {
+		t.Errorf("expected local RFC 3339 sunrise, got %s", resp.Sunrise)
+	}
+	if !strings.HasSuffix(resp.SunriseSpoken, "in the morning") || !strings.HasSuffix(resp.SunsetSpoken, "in the evening") {
+		t.Errorf("unexpected spoken times: %q, %q", resp.SunriseSpoken, resp.SunsetSpoken)
+	}
+	if resp.GoldenHour == "" || resp.GoldenHour >= resp.Sunset {
+		t.Errorf("golden hour should start before sunset, got %s (sunset %s)", resp.GoldenHour, resp.Sunset)
+	}
+	if !strings.HasPrefix(resp.DayLength, "PT11H") || !strings.HasPrefix(resp.DayLengthSpoken, "11 hours") {
+		t.Errorf("unexpected day length: %s / %s", resp.DayLength, resp.DayLengthSpoken)
+	}
+	if !strings.Contains(resp.Summary, resp.SunriseSpoken) || !strings.Contains(resp.Summary, "of daylight") {
+		t.Errorf("unexpected summary: %s", resp.Summary)
+	}
+}
+
+func TestBuildShortcutResponse_PolarNight(t *testing.T) {
+	tz := services.GetTimezone(78.2232, 15.6267)
+	resp := buildShortcutResponse("Longyearbyen", 78.2232, 15.6267, time.Date(2024, 12, 21, 12, 0, 0, 0, tz))
+
+	if resp.Sunrise != "" || resp.Sunset != "" || resp.DayLength != "" {
+		t.Errorf("expected empty times during polar night, got %+v", resp)
+	}
+	if resp.Summary != "The sun does not rise today." {
+		t.Errorf("unexpected summary: %s", resp.Summary)
+	}
+}
+
+func TestShortcutHandler_Date(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/shortcut?lat=55.6761&lng=12.5683&date=2024-12-21", nil)
+	w := httptest.NewRecorder()
+
+	ShortcutHandler(w, req)
+
+	var resp shortcutResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Date != "2024-12-21" || !strings.HasPrefix(resp.Sunrise, "2024-12-21T08:") {
+		t.Errorf("expected the requested date's times, got %+v", resp)
+	}
+}
+
+func TestShortcutHandler_InvalidParams(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/shortcut?lat=abc&lng=12", nil)
+	w := httptest.NewRecorder()
+
+	ShortcutHandler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	"calsun/config"
+	"calsun/services"
+)
+
+// solarTimeParamDefs lists the parameters accepted by SolarTimeHandler and
+// the sundial page
+var solarTimeParamDefs = []paramDef{latParam, lngParam, nameParam}
+
+var solarTimeTemplate *template.Template
+
+func init() {
+	var err error
+	solarTimeTemplate, err = template.ParseFS(templatesFS, "templates/solartime.html")
+	if err != nil {
+		panic("failed to parse solar time template: " + err.Error())
+	}
+}
+
+// solarTimeResponse is the JSON body returned by SolarTimeHandler. Solar
+// times are wall clock readings (HH:MM:SS); the offset is how far the
+// sundial is ahead of the clock, negative when it is behind.
+type solarTimeResponse struct {
+	Location              string    `json:"location"`
+	Lat                   float64   `json:"lat"`
+	Lng                   float64   `json:"lng"`
+	Timezone              string    `json:"timezone"`
+	Time                  time.Time `json:"time"` // Now, RFC 3339 in the location's timezone
+	ApparentSolarTime     string    `json:"apparent_solar_time"`
+	MeanSolarTime         string    `json:"mean_solar_time"`
+	OffsetSeconds         int       `json:"offset_seconds"`
+	Offset                string    `json:"offset"` // e.g., "7m ahead of the clock"
+	EquationOfTimeSeconds int       `json:"equation_of_time_seconds"`
+	HourAngle             float64   `json:"hour_angle"` // Degrees from solar noon, negative in the morning
+	SolarNoon             time.Time `json:"solar_noon"`
+	SunUp                 bool      `json:"sun_up"`
+}
+
+// solarTimeData is the template data for the sundial page
+type solarTimeData struct {
+	Site     config.Site
+	BasePath string
+	solarTimeResponse
+	Clock string
+	Noon  string
+	Dial  sundial
+}
+
+// SolarTimeHandler returns the apparent solar time at a location now, as a
+// sundial would show it, with its offset from the clock as JSON
+func SolarTimeHandler(w http.ResponseWriter, r *http.Request) {
+	resp, errMsg := buildSolarTime(r.URL.Query())
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// SolarTimePageHandler shows the solar time of SolarTimeHandler as an HTML
+// page with a sundial
+func SolarTimePageHandler(w http.ResponseWriter, r *http.Request) {
+	resp, errMsg := buildSolarTime(r.URL.Query())
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	data := solarTimeData{
+		Site:              cfg.Site,
+		BasePath:          cfg.Server.BasePath,
+		solarTimeResponse: *resp,
+		Clock:             resp.Time.Format("15:04:05"),
+		Noon:              resp.SolarNoon.Format("15:04"),
+		Dial:              newSundial(resp.HourAngle, resp.SunUp),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := solarTimeTemplate.Execute(w, data); err != nil {
+		log.Printf("render solar time page: %v", err)
+	}
+}
+
+// buildSolarTime parses the location from q and computes its solar time now
+func buildSolarTime(q url.Values) (*solarTimeResponse, string) {
+	params, errMsg := parseCalendarQuery(q)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	tz := services.GetTimezone(params.lat, params.lng)
+	now := params.now.In(tz).Truncate(time.Second)
+	solar := services.GetSolarTime(now, params.lng)
+	offset := solar.Offset(tz)
+	_, elevation := services.SunPosition(params.lat, params.lng, now)
+	noon := services.GetDayTimes(params.lat, params.lng, time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, tz)).SolarNoon
+
+	return &solarTimeResponse{
+		Location:              locationName(params),
+		Lat:                   params.lat,
+		Lng:                   params.lng,
+		Timezone:              tz.String(),
+		Time:                  now,
+		ApparentSolarTime:     solar.Apparent.Format("15:04:05"),
+		MeanSolarTime:         solar.Mean.Format("15:04:05"),
+		OffsetSeconds:         int(offset.Seconds()),
+		Offset:                services.FormatDifference(offset, "ahead of the clock", "behind the clock"),
+		EquationOfTimeSeconds: int(solar.EquationOfTime.Seconds()),
+		HourAngle:             round2(solar.HourAngle()),
+		SolarNoon:             noon.In(tz).Truncate(time.Second),
+		SunUp:                 elevation > 0,
+	}, ""
+}
+
+// sundial is a dial face drawn as SVG: hour lines from 6 to 18 fanning out
+// above its centre, and the gnomon's shadow on the current hour
+type sundial struct {
+	Width  int
+	Height int
+	Centre int // Both coordinates of the gnomon's foot
+	Hours  []sundialHour
+	Shadow sundialPoint
+	Lit    bool // The sun is up and casts the shadow on the dial
+}
+
+// sundialHour is an hour line of the dial with its label
+type sundialHour struct {
+	Inner, Outer, Label sundialPoint
+	Hour                int
+}
+
+// sundialPoint is a point on the dial in SVG coordinates
+type sundialPoint struct {
+	X, Y float64
+}
+
+// sundialRadius is the length of the hour lines and the shadow
+const sundialRadius = 80
+
+// newSundial returns the dial with the shadow at hourAngle degrees from noon
+func newSundial(hourAngle float64, sunUp bool) sundial {
+	dial := sundial{Width: 2*sundialRadius + 40, Height: sundialRadius + 30, Centre: sundialRadius + 20}
+	for hour := 6; hour <= 18; hour++ {
+		angle := float64(hour-12) * 15
+		dial.Hours = append(dial.Hours, sundialHour{
+			Inner: dial.point(angle, sundialRadius*0.8),
+			Outer: dial.point(angle, sundialRadius),
+			Label: dial.point(angle, sundialRadius+12),
+			Hour:  hour,
+		})
+	}
+	dial.Shadow = dial.point(hourAngle, sundialRadius*0.9)
+	dial.Lit = sunUp && math.Abs(hourAngle) <= 90
+	return dial
+}
+
+// point returns the point at distance from the centre, angle degrees
+// clockwise from straight up (noon)
+func (d sundial) point(angle, distance float64) sundialPoint {
+	rad := angle * math.Pi / 180
+	return sundialPoint{
+		X: roundTo(float64(d.Centre)+distance*math.Sin(rad), 1),
+		Y: roundTo(float64(d.Centre)-distance*math.Cos(rad), 1),
+	}
+}
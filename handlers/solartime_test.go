@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+func TestSolarTimeHandler(t *testing.T) {
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	SetClock(services.FixedClock(time.Date(2025, 11, 3, 9, 0, 0, 0, time.UTC)))
+
+	req := httptest.NewRequest("GET", "/api/solartime?lat=55.6761&lng=12.5683&name=Copenhagen", nil)
+	w := httptest.NewRecorder()
+
+	SolarTimeHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp solarTimeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Location != "Copenhagen" || resp.Timezone != "Europe/Copenhagen" || resp.Time.Format(time.RFC3339) != "2025-11-03T10:00:00+01:00" {
+		t.Errorf("unexpected location or time: %+v", resp)
+	}
+	// 9m44s behind CET by longitude, 16m26s ahead by the equation of time
+	if resp.MeanSolarTime != "09:50:16" || !strings.HasPrefix(resp.ApparentSolarTime, "10:06:") {
+		t.Errorf("expected mean 09:50:16 and apparent 10:06, got %s and %s", resp.MeanSolarTime, resp.ApparentSolarTime)
+	}
+	if resp.OffsetSeconds < 360 || resp.OffsetSeconds > 420 || resp.Offset != "7m ahead of the clock" {
+		t.Errorf("expected the sundial about 7 minutes ahead, got %d (%s)", resp.OffsetSeconds, resp.Offset)
+	}
+	if resp.EquationOfTimeSeconds < 960 || resp.EquationOfTimeSeconds > 1000 {
+		t.Errorf("expected an equation of time of about 16m26s, got %ds", resp.EquationOfTimeSeconds)
+	}
+	if !resp.SunUp || resp.HourAngle > -28 || resp.HourAngle < -28.7 {
+		t.Errorf("expected the sun up about 28.3° before noon, got %+v", resp)
+	}
+	if noon := resp.SolarNoon.Format("15:04"); noon != "11:53" && noon != "11:54" {
+		t.Errorf("expected solar noon around 11:53, got %s", noon)
+	}
+}
+
+func TestSolarTimePageHandler(t *testing.T) {
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	SetClock(services.FixedClock(time.Date(2025, 11, 3, 9, 0, 0, 0, time.UTC)))
+
+	req := httptest.NewRequest("GET", "/solartime?lat=55.6761&lng=12.5683&name=Copenhagen", nil)
+	w := httptest.NewRecorder()
+
+	SolarTimePageHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("expected HTML, got %s", ct)
+	}
+	body := w.Body.String()
+	for _, want := range []string{"Solar time in Copenhagen", "Clock time 10:00:00", "7m ahead of the clock", `<text x="8" y="100">6</text>`, `class="shadow"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the page", want)
+		}
+	}
+
+	// At night the dial casts no shadow
+	SetClock(services.FixedClock(time.Date(2025, 11, 3, 22, 0, 0, 0, time.UTC)))
+	w = httptest.NewRecorder()
+	SolarTimePageHandler(w, req)
+	if body := w.Body.String(); strings.Contains(body, `class="shadow"`) || !strings.Contains(body, "The sun is down") {
+		t.Error("expected no shadow at night")
+	}
+}
+
+func TestSolarTimeHandler_InvalidParams(t *testing.T) {
+	for _, url := range []string{"/api/solartime", "/api/solartime?lat=100&lng=0", "/api/solartime?lat=0&lng=200"} {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		SolarTimeHandler(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", url, w.Code)
+		}
+	}
+}
+
+func TestNewSundial(t *testing.T) {
+	dial := newSundial(0, true)
+	if len(dial.Hours) != 13 || dial.Hours[6].Hour != 12 {
+		t.Fatalf("expected hour lines from 6 to 18, got %+v", dial.Hours)
+	}
+	if noon := dial.Hours[6].Outer; noon.X != 100 || noon.Y != 20 {
+		t.Errorf("expected the noon line straight up, got %+v", noon)
+	}
+	if !dial.Lit || dial.Shadow.X != 100 || dial.Shadow.Y != 28 {
+		t.Errorf("expected the noon shadow straight up, got %+v", dial)
+	}
+	if dial := newSundial(45, true); dial.Shadow.X <= 100 {
+		t.Errorf("expected the afternoon shadow to the right, got %+v", dial.Shadow)
+	}
+	if newSundial(-120, true).Lit || newSundial(10, false).Lit {
+		t.Error("expected no shadow off the dial or with the sun down")
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+)
+
+//go:embed static/*
+var staticFS embed.FS
+
+// staticFiles is the embedded static directory, rooted at static/
+var staticFiles = func() fs.FS {
+	sub, err := fs.Sub(staticFS, "static")
+	if err != nil {
+		panic("failed to load static files: " + err.Error())
+	}
+	return sub
+}()
+
+// rootStaticFiles are the static files served only from the site root, by
+// ManifestHandler and ServiceWorkerHandler: under /static/ the manifest
+// would lack its branding and the worker's scope would be /static/
+var rootStaticFiles = []string{"manifest.webmanifest", "sw.js"}
+
+// StaticHandler serves embedded static assets (icons, etc.) under /static/
+var StaticHandler = http.StripPrefix("/static/", cacheControl("public, max-age=86400", exceptFiles(rootStaticFiles, http.FileServer(http.FS(staticFiles)))))
+
+// exceptFiles wraps a file server, answering 404 for the named files
+func exceptFiles(names []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slices.Contains(names, strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")) {
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ManifestHandler serves the PWA web app manifest, branded with the
+// configured site title and accent color
+func ManifestHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := fs.ReadFile(staticFiles, "manifest.webmanifest")
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var manifest map[string]any
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		http.Error(w, "invalid manifest", http.StatusInternalServerError)
+		return
+	}
+	manifest["short_name"] = cfg.Site.Title
+	manifest["name"] = cfg.Site.Title + " - Sunrise & Sunset Calendar"
+	manifest["description"] = cfg.Site.Tagline
+	if cfg.Site.AccentColor != "" {
+		manifest["theme_color"] = cfg.Site.AccentColor
+	}
+	if base := cfg.Server.BasePath; base != "" {
+		manifest["start_url"] = base + "/"
+		manifest["scope"] = base + "/"
+		if icons, ok := manifest["icons"].([]any); ok {
+			for _, icon := range icons {
+				if icon, ok := icon.(map[string]any); ok {
+					icon["src"] = base + icon["src"].(string)
+				}
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/manifest+json")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	json.NewEncoder(w).Encode(manifest)
+}
+
+// ServiceWorkerHandler serves the service worker from the site root so that
+// its scope covers the whole app. It is never cached by the browser so that
+// updates are picked up immediately.
+func ServiceWorkerHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	serveStaticFile(w, r, "sw.js")
+}
+
+// serveStaticFile writes an embedded static file to the response
+func serveStaticFile(w http.ResponseWriter, r *http.Request, name string) {
+	data, err := fs.ReadFile(staticFiles, name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write(data)
+}
+
+// cacheControl wraps a handler, setting the Cache-Control header on responses
+func cacheControl(value string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", value)
+		next.ServeHTTP(w, r)
+	})
+}
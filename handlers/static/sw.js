@@ -0,0 +1,75 @@
+// CalSun service worker: caches the app shell and the latest "today" data
+// so the configurator can be installed and the today view works offline.
+const CACHE_NAME = 'calsun-v1';
+// Prefix of the app's paths when served under a sub-path (e.g., "/calsun")
+const BASE = self.location.pathname.replace(/\/sw\.js$/, '');
+const APP_SHELL = [
+    '/',
+    '/manifest.webmanifest',
+    '/static/icon.svg',
+    '/static/icon-192.png',
+    '/static/icon-512.png'
+].map(path => BASE + path);
+
+self.addEventListener('install', event => {
+    event.waitUntil(
+        caches.open(CACHE_NAME).then(cache => cache.addAll(APP_SHELL))
+    );
+    self.skipWaiting();
+});
+
+self.addEventListener('activate', event => {
+    event.waitUntil(
+        caches.keys().then(keys => Promise.all(
+            keys.filter(key => key !== CACHE_NAME).map(key => caches.delete(key))
+        ))
+    );
+    self.clients.claim();
+});
+
+// Network first, falling back to the cache when offline
+async function networkFirst(request) {
+    const cache = await caches.open(CACHE_NAME);
+    try {
+        const response = await fetch(request);
+        if (response.ok) {
+            cache.put(request, response.clone());
+        }
+        return response;
+    } catch (error) {
+        const cached = await cache.match(request);
+        if (cached) {
+            return cached;
+        }
+        throw error;
+    }
+}
+
+self.addEventListener('fetch', event => {
+    const url = new URL(event.request.url);
+    if (event.request.method !== 'GET' || url.origin !== self.location.origin) {
+        return;
+    }
+
+    if (url.pathname === BASE + '/api/today' || APP_SHELL.includes(url.pathname)) {
+        event.respondWith(networkFirst(event.request));
+    }
+});
+
+// Sunrise and sunset alerts sent by the server (see /push/subscribe)
+self.addEventListener('push', event => {
+    const alert = event.data ? event.data.json() : {};
+    event.waitUntil(
+        self.registration.showNotification(alert.title || 'CalSun', {
+            body: alert.body,
+            tag: alert.tag,
+            icon: BASE + '/static/icon-192.png',
+            data: { url: alert.url || BASE + '/' }
+        })
+    );
+});
+
+self.addEventListener('notificationclick', event => {
+    event.notification.close();
+    event.waitUntil(self.clients.openWindow(event.notification.data.url));
+});
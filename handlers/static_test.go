@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"calsun/config"
+)
+
+func TestManifestHandler(t *testing.T) {
+	req := httptest.NewRequest("GET", "/manifest.webmanifest", nil)
+	w := httptest.NewRecorder()
+
+	ManifestHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/manifest+json" {
+		t.Errorf("expected Content-Type application/manifest+json, got %s", ct)
+	}
+
+	var manifest struct {
+		Name     string `json:"name"`
+		StartURL string `json:"start_url"`
+		Display  string `json:"display"`
+		Icons    []struct {
+			Src string `json:"src"`
+		} `json:"icons"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &manifest); err != nil {
+		t.Fatalf("manifest should be valid JSON: %v", err)
+	}
+	if manifest.StartURL != "/" || manifest.Display != "standalone" {
+		t.Errorf("unexpected manifest: %+v", manifest)
+	}
+
+	// Every icon referenced by the manifest must be served
+	for _, icon := range manifest.Icons {
+		req := httptest.NewRequest("GET", icon.Src, nil)
+		w := httptest.NewRecorder()
+		StaticHandler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("expected icon %s to be served, got status %d", icon.Src, w.Code)
+		}
+	}
+}
+
+func TestServiceWorkerHandler(t *testing.T) {
+	req := httptest.NewRequest("GET", "/sw.js", nil)
+	w := httptest.NewRecorder()
+
+	ServiceWorkerHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, "javascript") {
+		t.Errorf("expected JavaScript Content-Type, got %s", ct)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("expected Cache-Control no-cache, got %s", cc)
+	}
+	if !strings.Contains(w.Body.String(), "/api/today") {
+		t.Error("service worker should cache today data")
+	}
+}
+
+func TestStaticHandler_NotFound(t *testing.T) {
+	// The manifest and service worker are only served from the site root
+	for _, target := range []string{"/static/missing.js", "/static/manifest.webmanifest", "/static/sw.js"} {
+		w := httptest.NewRecorder()
+		StaticHandler.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404, got %d", target, w.Code)
+		}
+	}
+}
+
+func TestManifestHandler_Branding(t *testing.T) {
+	original := cfg
+	t.Cleanup(func() { Configure(original) })
+
+	branded := config.Default()
+	branded.Site.Title = "Acme Sun"
+	branded.Site.AccentColor = "#ff6600"
+	Configure(branded)
+
+	req := httptest.NewRequest("GET", "/manifest.webmanifest", nil)
+	w := httptest.NewRecorder()
+
+	ManifestHandler(w, req)
+
+	var manifest map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &manifest); err != nil {
+		t.Fatalf("manifest should be valid JSON: %v", err)
+	}
+	if manifest["short_name"] != "Acme Sun" {
+		t.Errorf("expected branded short_name, got %v", manifest["short_name"])
+	}
+	if manifest["theme_color"] != "#ff6600" {
+		t.Errorf("expected accent theme_color, got %v", manifest["theme_color"])
+	}
+}
+
+func TestManifestHandler_BasePath(t *testing.T) {
+	original := cfg
+	t.Cleanup(func() { Configure(original) })
+	mounted := config.Default()
+	mounted.Server.BasePath = "/calsun"
+	Configure(mounted)
+
+	w := httptest.NewRecorder()
+	ManifestHandler(w, httptest.NewRequest("GET", "/manifest.webmanifest", nil))
+
+	var manifest struct {
+		StartURL string `json:"start_url"`
+		Scope    string `json:"scope"`
+		Icons    []struct {
+			Src string `json:"src"`
+		} `json:"icons"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &manifest); err != nil {
+		t.Fatalf("manifest should be valid JSON: %v", err)
+	}
+	if manifest.StartURL != "/calsun/" || manifest.Scope != "/calsun/" {
+		t.Errorf("expected start_url and scope under the base path, got %s and %s", manifest.StartURL, manifest.Scope)
+	}
+	for _, icon := range manifest.Icons {
+		if !strings.HasPrefix(icon.Src, "/calsun/static/") {
+			t.Errorf("expected icon under the base path, got %s", icon.Src)
+		}
+	}
+}
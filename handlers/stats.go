@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"time"
+
+	"calsun/services"
+)
+
+var (
+	statsYearParam = paramDef{
+		Name:        "year",
+		Type:        paramTypeInteger,
+		Min:         bound(1000),
+		Max:         bound(3000),
+		Description: "Year to aggregate (default: the current year at the location)",
+	}
+	statsSeasonParam = paramDef{
+		Name:        "season",
+		Type:        paramTypeEnum,
+		Values:      services.Seasons,
+		Description: "Aggregate only the astronomical season starting in the year, named for the location's hemisphere",
+	}
+)
+
+// statsParamDefs lists the parameters accepted by StatsHandler
+var statsParamDefs = []paramDef{
+	latParam,
+	lngParam,
+	nameParam,
+	statsYearParam,
+	statsSeasonParam,
+}
+
+// statsCacheTTL is how long aggregated statistics are cached; they only
+// change with the sun calculation itself
+const statsCacheTTL = 24 * time.Hour
+
+// statsResponse is the JSON body returned by StatsHandler. From and To are
+// the first and last local dates aggregated.
+type statsResponse struct {
+	Location           string         `json:"location"`
+	Lat                float64        `json:"lat"`
+	Lng                float64        `json:"lng"`
+	Timezone           string         `json:"timezone"`
+	Year               int            `json:"year"`
+	Season             string         `json:"season,omitempty"`
+	From               string         `json:"from"`
+	To                 string         `json:"to"`
+	Days               int            `json:"days"`
+	DayLength          statsDayLength `json:"day_length"`
+	TotalDaylightHours float64        `json:"total_daylight_hours"`
+	Sunrise            statsClock     `json:"sunrise"`
+	Sunset             statsClock     `json:"sunset"`
+}
+
+// statsDayLength holds the day length extremes and mean; extremes are null if
+// no day has a length
+type statsDayLength struct {
+	Shortest    *statsValue `json:"shortest"`
+	Longest     *statsValue `json:"longest"`
+	Mean        string      `json:"mean"`
+	MeanSeconds int         `json:"mean_seconds"`
+}
+
+// statsValue is a day length extreme and its date
+type statsValue struct {
+	Date      string `json:"date"`
+	DayLength string `json:"day_length"`
+	Seconds   int    `json:"seconds"`
+}
+
+// statsClock holds the earliest and latest local clock time of sunrise or
+// sunset, null if the sun never rises or sets
+type statsClock struct {
+	Earliest *time.Time `json:"earliest"`
+	Latest   *time.Time `json:"latest"`
+}
+
+// StatsHandler aggregates a location's sun times over a year, or one of its
+// astronomical seasons, as JSON: day length extremes, mean, and total, and
+// the earliest and latest sunrise and sunset
+func StatsHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	tz := services.GetTimezone(params.lat, params.lng)
+	year, errMsg := statsYearParam.parseInt(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	if year == 0 {
+		year = params.now.In(tz).Year()
+	}
+	season, errMsg := statsSeasonParam.parseEnum(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	name := locationName(params)
+	key := fmt.Sprintf("stats:%s:%s:%d:%s", services.FormatCoordinates(params.lat, params.lng, services.DefaultPrecision), name, year, season)
+	if resp, ok := calendarCache.Get(key, params.now); ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Cache", "HIT")
+		w.Write(resp.body)
+		return
+	}
+
+	from, to := statsRange(params.lat, year, season, tz)
+	days := int(math.Round(to.Sub(from).Hours() / 24))
+	noon := time.Date(from.Year(), from.Month(), from.Day(), 12, 0, 0, 0, tz)
+	stats := services.SummarizeSunTimes(services.GetSunTimesRange(params.lat, params.lng, noon, days), tz)
+
+	resp := statsResponse{
+		Location: name,
+		Lat:      params.lat,
+		Lng:      params.lng,
+		Timezone: tz.String(),
+		Year:     year,
+		Season:   season,
+		From:     from.Format("2006-01-02"),
+		To:       to.AddDate(0, 0, -1).Format("2006-01-02"),
+		Days:     stats.Days,
+		DayLength: statsDayLength{
+			Shortest:    newStatsValue(stats.ShortestDay),
+			Longest:     newStatsValue(stats.LongestDay),
+			Mean:        services.FormatDuration(stats.MeanDayLength),
+			MeanSeconds: int(stats.MeanDayLength.Seconds()),
+		},
+		TotalDaylightHours: round2(stats.TotalDaylight.Hours()),
+		Sunrise:            statsClock{Earliest: extremeTime(stats.EarliestSunrise, tz), Latest: extremeTime(stats.LatestSunrise, tz)},
+		Sunset:             statsClock{Earliest: extremeTime(stats.EarliestSunset, tz), Latest: extremeTime(stats.LatestSunset, tz)},
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, "failed to encode statistics", http.StatusInternalServerError)
+		return
+	}
+	body = append(body, '\n')
+	calendarCache.Set(key, body, params.now.Add(statsCacheTTL))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Cache", "MISS")
+	w.Write(body)
+}
+
+// statsRange returns the local midnights starting and ending (exclusive) the
+// year, or the season starting in it at a latitude
+func statsRange(lat float64, year int, season string, tz *time.Location) (time.Time, time.Time) {
+	if season == "" {
+		from := time.Date(year, time.January, 1, 0, 0, 0, 0, tz)
+		return from, from.AddDate(1, 0, 0)
+	}
+	start := services.SeasonStart(season, lat).Time(year)
+	next := services.Seasons[(slices.Index(services.Seasons, season)+1)%len(services.Seasons)]
+	end := services.NextSeasonStart(next, lat, start)
+	return localMidnight(start, tz), localMidnight(end, tz)
+}
+
+// localMidnight returns the start of t's date in tz
+func localMidnight(t time.Time, tz *time.Location) time.Time {
+	t = t.In(tz)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, tz)
+}
+
+// newStatsValue returns a day length extreme, or nil if there is none
+func newStatsValue(e *services.SunExtreme) *statsValue {
+	if e == nil {
+		return nil
+	}
+	return &statsValue{
+		Date:      e.Date.Format("2006-01-02"),
+		DayLength: services.FormatDuration(e.Value),
+		Seconds:   int(e.Value.Seconds()),
+	}
+}
+
+// extremeTime returns a sunrise or sunset extreme's time in tz, or nil if
+// there is none
+func extremeTime(e *services.SunExtreme, tz *time.Location) *time.Time {
+	if e == nil {
+		return nil
+	}
+	t := e.Time.In(tz)
+	return &t
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+func decodeStats(t *testing.T, query string) (statsResponse, *httptest.ResponseRecorder) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/api/stats?"+query, nil)
+	w := httptest.NewRecorder()
+
+	StatsHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp statsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp, w
+}
+
+func TestStatsHandler_Year(t *testing.T) {
+	resp, _ := decodeStats(t, "lat=55.6761&lng=12.5683&name=Copenhagen&year=2025")
+
+	if resp.From != "2025-01-01" || resp.To != "2025-12-31" || resp.Days != 365 {
+		t.Errorf("unexpected range %s to %s (%d days)", resp.From, resp.To, resp.Days)
+	}
+	if resp.DayLength.Shortest == nil || !strings.HasPrefix(resp.DayLength.Shortest.Date, "2025-12-2") {
+		t.Errorf("expected the shortest day around the December solstice, got %+v", resp.DayLength.Shortest)
+	}
+	if resp.DayLength.Longest == nil || !strings.HasPrefix(resp.DayLength.Longest.Date, "2025-06-2") {
+		t.Errorf("expected the longest day around the June solstice, got %+v", resp.DayLength.Longest)
+	}
+	// Away from the equator, a year still averages about 12 hours of day
+	// (a little more, as sunrise and sunset count the sun's upper limb)
+	if resp.DayLength.MeanSeconds < 12*3600 || resp.DayLength.MeanSeconds > 12*3600+20*60 {
+		t.Errorf("expected a mean day length just over 12h, got %s", resp.DayLength.Mean)
+	}
+	if resp.TotalDaylightHours < 4380 || resp.TotalDaylightHours > 4500 {
+		t.Errorf("unexpected total daylight %.2f hours", resp.TotalDaylightHours)
+	}
+	if resp.Sunrise.Earliest == nil || resp.Sunrise.Earliest.Month() != time.June {
+		t.Errorf("expected the earliest sunrise in June, got %v", resp.Sunrise.Earliest)
+	}
+	if resp.Sunset.Earliest == nil || resp.Sunset.Earliest.Month() != time.December {
+		t.Errorf("expected the earliest sunset in December, got %v", resp.Sunset.Earliest)
+	}
+}
+
+func TestStatsHandler_Season(t *testing.T) {
+	// Summer in the southern hemisphere runs from the December solstice into
+	// the next year
+	resp, _ := decodeStats(t, "lat=-33.8688&lng=151.2093&year=2024&season=summer")
+
+	if resp.Season != "summer" || resp.From != "2024-12-21" || resp.To != "2025-03-19" {
+		t.Errorf("unexpected season range %s to %s", resp.From, resp.To)
+	}
+	if resp.DayLength.Longest == nil || !strings.HasPrefix(resp.DayLength.Longest.Date, "2024-12-2") {
+		t.Errorf("expected the longest day around the solstice, got %+v", resp.DayLength.Longest)
+	}
+}
+
+func TestStatsHandler_PolarNight(t *testing.T) {
+	// The winter starting in 2025 runs into 2026; the sun returns to
+	// Svalbard in mid-February
+	resp, _ := decodeStats(t, "lat=78.2232&lng=15.6267&year=2025&season=winter")
+
+	if resp.From != "2025-12-21" || !strings.HasPrefix(resp.To, "2026-03-") {
+		t.Errorf("unexpected season range %s to %s", resp.From, resp.To)
+	}
+	if resp.DayLength.Shortest == nil || resp.DayLength.Shortest.Seconds != 0 || resp.DayLength.Shortest.Date != "2025-12-21" {
+		t.Errorf("expected polar night from the start, got %+v", resp.DayLength.Shortest)
+	}
+	if resp.Sunrise.Latest == nil || resp.Sunrise.Latest.Month() != time.February {
+		t.Errorf("expected the latest sunrise as the sun returns in February, got %v", resp.Sunrise.Latest)
+	}
+}
+
+func TestStatsHandler_DefaultYearAndCache(t *testing.T) {
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	SetClock(services.FixedClock(time.Date(2030, 5, 1, 12, 0, 0, 0, time.UTC)))
+
+	resp, w := decodeStats(t, "lat=40.7128&lng=-74.0060")
+	if resp.Year != 2030 || w.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("expected an uncached 2030, got %d (%s)", resp.Year, w.Header().Get("X-Cache"))
+	}
+	_, w = decodeStats(t, "lat=40.7128&lng=-74.0060")
+	if w.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("expected the second request from the cache, got %s", w.Header().Get("X-Cache"))
+	}
+}
+
+func TestStatsHandler_Validation(t *testing.T) {
+	for _, query := range []string{
+		"lat=55.6761&lng=12.5683&year=999",
+		"lat=55.6761&lng=12.5683&year=abc",
+		"lat=55.6761&lng=12.5683&season=monsoon",
+		"lng=12.5683",
+	} {
+		req := httptest.NewRequest("GET", "/api/stats?"+query, nil)
+		w := httptest.NewRecorder()
+
+		StatsHandler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"calsun/services"
+)
+
+var (
+	mapSizeParam = paramDef{
+		Name:        "size",
+		Type:        paramTypeInteger,
+		Min:         bound(180),
+		Max:         bound(2048),
+		Default:     720,
+		Description: "Map width in pixels; the height is half of it",
+	}
+	mapFormatParam = paramDef{
+		Name:        "format",
+		Type:        paramTypeEnum,
+		Values:      []string{"svg", "png"},
+		Default:     "svg",
+		Description: "Map image format",
+	}
+)
+
+// mapParamDefs lists the parameters accepted by the terminator map
+var mapParamDefs = []paramDef{
+	latParam,
+	lngParam,
+	mapSizeParam,
+	mapFormatParam,
+}
+
+// mapInterval is how long a rendered map is served for; the terminator moves
+// about 1° of longitude in 4 minutes
+const mapInterval = 5 * time.Minute
+
+// TerminatorMapHandler renders a world map with the day/night terminator and
+// twilight bands, marking the requested location (SVG by default, PNG with
+// format=png). Maps are rendered for the start of each 5-minute interval, so
+// they are cached until the interval ends.
+func TerminatorMapHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	size, errMsg := mapSizeParam.parseInt(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	format, errMsg := mapFormatParam.parseEnum(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	at := params.now.Truncate(mapInterval)
+	expires := at.Add(mapInterval)
+	key := fmt.Sprintf("map:%s:%d:%s:%d", format, size, services.FormatCoordinates(params.lat, params.lng, services.DefaultPrecision), at.Unix())
+	if resp, ok := calendarCache.Get(key, params.now); ok {
+		writeMapResponse(w, resp.body, format, at, expires.Sub(params.now), "HIT")
+		return
+	}
+
+	var body []byte
+	if format == "png" {
+		png, err := services.TerminatorPNG(at, params.lat, params.lng, size)
+		if err != nil {
+			http.Error(w, "failed to render map", http.StatusInternalServerError)
+			return
+		}
+		body = png
+	} else {
+		body = []byte(services.TerminatorSVG(at, params.lat, params.lng, size))
+	}
+	if !params.fixedNow {
+		calendarCache.Set(key, body, expires)
+	}
+	writeMapResponse(w, body, format, at, expires.Sub(params.now), "MISS")
+}
+
+// writeMapResponse writes a rendered map for the time at, cacheable by
+// clients for maxAge
+func writeMapResponse(w http.ResponseWriter, body []byte, format string, at time.Time, maxAge time.Duration, cacheStatus string) {
+	contentType := "image/svg+xml"
+	if format == "png" {
+		contentType = "image/png"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	w.Header().Set("Last-Modified", at.UTC().Format(http.TimeFormat))
+	w.Header().Set("X-Cache", cacheStatus)
+	w.Write(body)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+func TestTerminatorMapHandler_SVG(t *testing.T) {
+	req := httptest.NewRequest("GET", "/map?lat=55.6761&lng=12.5683", nil)
+	w := httptest.NewRecorder()
+
+	TerminatorMapHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "image/svg+xml" {
+		t.Errorf("expected Content-Type image/svg+xml, got %s", contentType)
+	}
+	if !strings.HasPrefix(w.Body.String(), "<svg") || !strings.Contains(w.Body.String(), `width="720"`) {
+		t.Error("expected a 720 pixel wide SVG map")
+	}
+}
+
+func TestTerminatorMapHandler_PNG(t *testing.T) {
+	req := httptest.NewRequest("GET", "/map?lat=55.6761&lng=12.5683&format=png&size=400", nil)
+	w := httptest.NewRecorder()
+
+	TerminatorMapHandler(w, req)
+
+	if contentType := w.Header().Get("Content-Type"); contentType != "image/png" {
+		t.Fatalf("expected Content-Type image/png, got %s", contentType)
+	}
+	img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("response should be a valid PNG: %v", err)
+	}
+	if img.Bounds().Dx() != 400 || img.Bounds().Dy() != 200 {
+		t.Errorf("expected a 400x200 image, got %dx%d", img.Bounds().Dx(), img.Bounds().Dy())
+	}
+}
+
+func TestTerminatorMapHandler_Interval(t *testing.T) {
+	original, originalClock := calendarCache, clock
+	calendarCache = newResponseCache(1 << 20)
+	t.Cleanup(func() {
+		calendarCache = original
+		SetClock(originalClock)
+	})
+
+	get := func(now time.Time) *httptest.ResponseRecorder {
+		SetClock(services.FixedClock(now))
+		req := httptest.NewRequest("GET", "/map?lat=55.6761&lng=12.5683", nil)
+		w := httptest.NewRecorder()
+		TerminatorMapHandler(w, req)
+		return w
+	}
+
+	first := get(time.Date(2024, 6, 21, 12, 1, 0, 0, time.UTC))
+	if cacheControl := first.Header().Get("Cache-Control"); cacheControl != "public, max-age=240" {
+		t.Errorf("expected caching until the interval ends, got %q", cacheControl)
+	}
+	if lastModified := first.Header().Get("Last-Modified"); lastModified != "Fri, 21 Jun 2024 12:00:00 GMT" {
+		t.Errorf("expected the map of the interval's start, got %q", lastModified)
+	}
+
+	// Later in the same interval the map is served from the cache
+	second := get(time.Date(2024, 6, 21, 12, 4, 0, 0, time.UTC))
+	if second.Header().Get("X-Cache") != "HIT" || second.Body.String() != first.Body.String() {
+		t.Error("expected the cached map within the interval")
+	}
+	if third := get(time.Date(2024, 6, 21, 12, 5, 0, 0, time.UTC)); third.Header().Get("X-Cache") != "MISS" {
+		t.Error("expected a new map in the next interval")
+	}
+}
+
+func TestTerminatorMapHandler_InvalidParams(t *testing.T) {
+	for _, url := range []string{"/map", "/map?lat=55.6761&lng=12.5683&size=10", "/map?lat=55.6761&lng=12.5683&format=gif"} {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		TerminatorMapHandler(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", url, w.Code)
+		}
+	}
+}
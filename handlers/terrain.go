@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"calsun/services"
+)
+
+// elevationTimeout bounds how long a calendar request waits for the terrain
+// elevation
+const elevationTimeout = 3 * time.Second
+
+// horizonTimeout bounds how long a calendar request waits for a horizon
+// profile, which takes thousands of elevations
+const horizonTimeout = 10 * time.Second
+
+// terrain is the elevation provider shared by all handlers, used when
+// CALSUN_ELEVATION_LOOKUP is enabled
+var terrain services.ElevationProvider = services.NewOpenElevation(services.OpenElevationURL)
+
+// horizons builds and caches the horizon profiles of skyline=terrain
+// calendars from terrain
+var horizons = services.NewHorizonProfiles(terrain)
+
+// observerAltitude returns the altitude sunrise and sunset are calculated
+// for: the altitude parameter, else the terrain elevation when lookups are
+// enabled, else 0. Locations below sea level and unavailable elevations get 0,
+// so the calendar is still served.
+func observerAltitude(ctx context.Context, params *calendarParams) float64 {
+	if params.altitude != nil {
+		return *params.altitude
+	}
+	if !cfg.Terrain.Lookup {
+		return 0
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, elevationTimeout)
+	defer cancel()
+
+	elevation, err := terrain.Elevation(ctx, params.lat, params.lng)
+	if err != nil {
+		log.Printf("terrain elevation: %v", err)
+		return 0
+	}
+	return math.Max(elevation, 0)
+}
+
+// applyTerrainHorizon moves sunrise and sunset to the terrain skyline for
+// skyline=terrain when lookups are enabled. Without a profile the days keep
+// their flat-horizon times, so the calendar is still served.
+func applyTerrainHorizon(ctx context.Context, params *calendarParams, days []services.DaySunTimes) {
+	if params.skyline != "terrain" || !cfg.Terrain.Lookup {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, horizonTimeout)
+	defer cancel()
+
+	profile, err := horizons.Profile(ctx, params.lat, params.lng)
+	if err != nil {
+		log.Printf("terrain horizon: %v", err)
+		return
+	}
+	services.ApplyHorizonProfile(days, profile)
+}
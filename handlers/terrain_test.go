@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"calsun/services"
+)
+
+// fakeTerrain is an ElevationProvider returning a fixed elevation at the
+// looked-up location and another for the terrain around it, or an error. It
+// counts its calls.
+type fakeTerrain struct {
+	elevation   float64
+	surrounding float64
+	err         error
+	calls       int
+}
+
+func (f *fakeTerrain) Elevation(ctx context.Context, lat, lng float64) (float64, error) {
+	f.calls++
+	return f.elevation, f.err
+}
+
+func (f *fakeTerrain) Elevations(ctx context.Context, points []services.GeoPoint) ([]float64, error) {
+	f.calls++
+	elevations := make([]float64, len(points))
+	for i := range elevations {
+		elevations[i] = f.surrounding
+	}
+	elevations[0] = f.elevation
+	return elevations, f.err
+}
+
+// useTestTerrain enables elevation lookups with a replaced provider for the
+// duration of the test
+func useTestTerrain(t *testing.T, provider *fakeTerrain) {
+	enableDebug(t)
+	cfg.Terrain.Lookup = true
+	original, originalHorizons := terrain, horizons
+	terrain, horizons = provider, services.NewHorizonProfiles(provider)
+	t.Cleanup(func() { terrain, horizons = original, originalHorizons })
+}
+
+// calendarBody requests a calendar and returns its unfolded body
+func calendarBody(t *testing.T, url string) string {
+	w := httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", url, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	return unfoldICal(w.Body.String())
+}
+
+func TestCalendarHandler_Altitude(t *testing.T) {
+	enableDebug(t)
+
+	ground := calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&days=1&events=sunrise&now=2024-06-21T00:00:00Z")
+	hill := calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&days=1&events=sunrise&altitude=500&now=2024-06-21T00:00:00Z")
+	if !strings.Contains(ground, "SUMMARY:Sunrise 04:2") || !strings.Contains(hill, "SUMMARY:Sunrise 04:1") {
+		t.Error("expected sunrise several minutes earlier from 500 m")
+	}
+	if !strings.Contains(hill, "Altitude: 500 m (horizon 0.77° lower)") || strings.Contains(ground, "Altitude:") {
+		t.Error("expected the altitude only in the elevated calendar")
+	}
+
+	w := httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&altitude=-5", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a negative altitude, got %d", w.Code)
+	}
+}
+
+func TestCalendarHandler_ElevationLookup(t *testing.T) {
+	provider := &fakeTerrain{elevation: 500}
+	useTestTerrain(t, provider)
+
+	body := calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&days=1&events=sunrise&now=2024-06-21T00:00:00Z")
+	if !strings.Contains(body, "Altitude: 500 m") || provider.calls != 1 {
+		t.Errorf("expected the looked-up elevation, got %d lookups", provider.calls)
+	}
+
+	// A given altitude wins, and presets don't look it up
+	body = calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&days=1&events=sunrise&altitude=0&now=2024-06-21T00:00:00Z")
+	calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&days=1&preset=solunar&now=2024-06-21T00:00:00Z")
+	if strings.Contains(body, "Altitude:") || provider.calls != 1 {
+		t.Errorf("expected no lookup, got %d lookups", provider.calls)
+	}
+}
+
+func TestCalendarHandler_ElevationLookupFallback(t *testing.T) {
+	for name, provider := range map[string]*fakeTerrain{
+		"unavailable":     {err: errors.New("service down")},
+		"below sea level": {elevation: -400},
+	} {
+		t.Run(name, func(t *testing.T) {
+			useTestTerrain(t, provider)
+			body := calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&days=1&events=sunrise&now=2024-06-21T00:00:00Z")
+			if !strings.Contains(body, "SUMMARY:Sunrise 04:2") || strings.Contains(body, "Altitude:") {
+				t.Error("expected sunrise at ground level")
+			}
+		})
+	}
+}
+
+func TestCalendarHandler_ElevationLookupDisabled(t *testing.T) {
+	enableDebug(t)
+	provider := &fakeTerrain{elevation: 500}
+	original := terrain
+	terrain = provider
+	t.Cleanup(func() { terrain = original })
+
+	calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&days=1&now=2024-06-21T00:00:00Z")
+	if provider.calls != 0 {
+		t.Errorf("expected no lookups without CALSUN_ELEVATION_LOOKUP, got %d", provider.calls)
+	}
+}
+
+func TestCalendarHandler_TerrainHorizon(t *testing.T) {
+	// Ground rising 10 m nearby puts the skyline over 4° up all around
+	provider := &fakeTerrain{surrounding: 10}
+	useTestTerrain(t, provider)
+
+	body := calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&days=1&events=sunrise,sunset&skyline=terrain&now=2024-06-21T00:00:00Z")
+	if !strings.Contains(body, "SUMMARY:Sunrise 05:") || !strings.Contains(body, "SUMMARY:Sunset 21:") {
+		t.Errorf("expected sunrise and sunset over the skyline, got:\n%s", body)
+	}
+	if !strings.Contains(body, "(sunrise over a flat horizon 04:2") || !strings.Contains(body, "Skyline: ") {
+		t.Error("expected the skyline and the flat-horizon sunrise in the description")
+	}
+
+	calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&days=1&skyline=terrain&now=2024-06-22T00:00:00Z")
+	if provider.calls != 3 {
+		t.Errorf("expected the profile built once besides 2 point lookups, got %d calls", provider.calls)
+	}
+}
+
+func TestCalendarHandler_TerrainHorizonFallback(t *testing.T) {
+	useTestTerrain(t, &fakeTerrain{err: errors.New("unavailable")})
+
+	body := calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&days=1&events=sunrise&skyline=terrain&now=2024-06-21T00:00:00Z")
+	if !strings.Contains(body, "SUMMARY:Sunrise 04:2") || strings.Contains(body, "Skyline:") {
+		t.Error("expected the flat-horizon sunrise when the profile is unavailable")
+	}
+}
+
+func TestCalendarHandler_TerrainHorizonDisabled(t *testing.T) {
+	provider := &fakeTerrain{surrounding: 10}
+	useTestTerrain(t, provider)
+	cfg.Terrain.Lookup = false
+
+	body := calendarBody(t, "/calendar.ics?lat=55.6761&lng=12.5683&days=1&events=sunrise&skyline=terrain&now=2024-06-21T00:00:00Z")
+	if strings.Contains(body, "Skyline:") || provider.calls != 0 {
+		t.Errorf("expected no terrain horizon without lookups, got %d calls", provider.calls)
+	}
+
+	w := httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&skyline=mountains", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown skyline, got %d", w.Code)
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"calsun/services"
+)
+
+var timezoneTransitionsParam = paramDef{
+	Name:        "transitions",
+	Type:        paramTypeInteger,
+	Min:         bound(0),
+	Max:         bound(10),
+	Default:     2,
+	Description: "Number of upcoming offset transitions (daylight saving time changes) to list",
+}
+
+// timezoneParamDefs lists the parameters accepted by TimezoneHandler
+var timezoneParamDefs = []paramDef{latParam, lngParam, nameParam, timezoneTransitionsParam}
+
+// timezoneResponse is the JSON body returned by TimezoneHandler. Locations
+// whose zone cannot be detected (e.g., at sea) get UTC, with detected false.
+type timezoneResponse struct {
+	Location      string                   `json:"location"`
+	Lat           float64                  `json:"lat"`
+	Lng           float64                  `json:"lng"`
+	Timezone      string                   `json:"timezone"`
+	Detected      bool                     `json:"detected"`
+	Time          time.Time                `json:"time"` // Now, RFC 3339 in the zone
+	Abbreviation  string                   `json:"abbreviation"`
+	Offset        string                   `json:"offset"` // e.g., "+02:00"
+	OffsetSeconds int                      `json:"offset_seconds"`
+	DST           bool                     `json:"dst"`
+	Transitions   []timezoneTransitionJSON `json:"transitions"`
+}
+
+// timezoneTransitionJSON is an upcoming offset transition
+type timezoneTransitionJSON struct {
+	Time          time.Time `json:"time"` // RFC 3339 with the offset from the transition
+	Abbreviation  string    `json:"abbreviation"`
+	Offset        string    `json:"offset"`
+	OffsetSeconds int       `json:"offset_seconds"`
+	ChangeSeconds int       `json:"change_seconds"` // Positive when clocks go forward
+	DST           bool      `json:"dst"`
+}
+
+// TimezoneHandler returns the IANA zone detected for a location, its current
+// offset, and its upcoming transitions as JSON, using the same detection as
+// the calendars
+func TimezoneHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	n, errMsg := timezoneTransitionsParam.parseInt(r.URL.Query())
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	tz := services.GetTimezone(params.lat, params.lng)
+	now := params.now.In(tz)
+	abbreviation, offset := now.Zone()
+	resp := timezoneResponse{
+		Location:      locationName(params),
+		Lat:           params.lat,
+		Lng:           params.lng,
+		Timezone:      tz.String(),
+		Detected:      services.TimezoneName(params.lat, params.lng) != "",
+		Time:          now,
+		Abbreviation:  abbreviation,
+		Offset:        formatUTCOffset(offset),
+		OffsetSeconds: offset,
+		DST:           now.IsDST(),
+		Transitions:   []timezoneTransitionJSON{},
+	}
+	for _, t := range services.ZoneTransitions(tz, now, n) {
+		resp.Transitions = append(resp.Transitions, timezoneTransitionJSON{
+			Time:          t.Time,
+			Abbreviation:  t.Abbreviation,
+			Offset:        formatUTCOffset(t.OffsetAfter),
+			OffsetSeconds: t.OffsetAfter,
+			ChangeSeconds: t.OffsetAfter - t.OffsetBefore,
+			DST:           t.DST,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// formatUTCOffset formats seconds east of UTC as an ISO 8601 offset (e.g.,
+// "+05:30")
+func formatUTCOffset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign, seconds = '-', -seconds
+	}
+	return fmt.Sprintf("%c%02d:%02d", sign, seconds/3600, seconds%3600/60)
+}
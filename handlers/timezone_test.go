@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+// getTimezone requests the timezone of target and decodes the response
+func getTimezone(t *testing.T, target string) timezoneResponse {
+	t.Helper()
+	w := httptest.NewRecorder()
+	TimezoneHandler(w, httptest.NewRequest("GET", target, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp timezoneResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func TestTimezoneHandler(t *testing.T) {
+	original := clock
+	t.Cleanup(func() { SetClock(original) })
+	SetClock(services.FixedClock(time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)))
+
+	resp := getTimezone(t, "/api/timezone?lat=55.6761&lng=12.5683")
+	if resp.Timezone != "Europe/Copenhagen" || !resp.Detected {
+		t.Errorf("expected Europe/Copenhagen detected, got %s (%v)", resp.Timezone, resp.Detected)
+	}
+	if resp.Offset != "+02:00" || resp.OffsetSeconds != 7200 || resp.Abbreviation != "CEST" || !resp.DST {
+		t.Errorf("expected summer time, got %s %s (dst %v)", resp.Offset, resp.Abbreviation, resp.DST)
+	}
+	if len(resp.Transitions) != 2 {
+		t.Fatalf("expected 2 transitions by default, got %d", len(resp.Transitions))
+	}
+	autumn := resp.Transitions[0]
+	if autumn.Time.Format(time.RFC3339) != "2025-10-26T02:00:00+01:00" || autumn.Offset != "+01:00" || autumn.ChangeSeconds != -3600 || autumn.DST {
+		t.Errorf("expected clocks back an hour on 2025-10-26, got %+v", autumn)
+	}
+	if spring := resp.Transitions[1]; spring.ChangeSeconds != 3600 || !spring.DST {
+		t.Errorf("expected clocks forward in spring, got %+v", spring)
+	}
+
+	if resp := getTimezone(t, "/api/timezone?lat=-34.9285&lng=138.6007&transitions=0"); resp.Offset != "+09:30" || len(resp.Transitions) != 0 {
+		t.Errorf("expected Adelaide's half-hour offset and no transitions, got %s %+v", resp.Offset, resp.Transitions)
+	}
+	if resp := getTimezone(t, "/api/timezone?lat=30&lng=-40"); resp.Timezone != "UTC" || resp.Detected || resp.Offset != "+00:00" {
+		t.Errorf("expected UTC undetected at sea, got %+v", resp)
+	}
+}
+
+func TestTimezoneHandler_InvalidParams(t *testing.T) {
+	for _, target := range []string{"/api/timezone", "/api/timezone?lat=100&lng=0", "/api/timezone?lat=0&lng=0&transitions=11"} {
+		w := httptest.NewRecorder()
+		TimezoneHandler(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", target, w.Code)
+		}
+	}
+}
+
+func TestFormatUTCOffset(t *testing.T) {
+	for seconds, want := range map[int]string{0: "+00:00", 7200: "+02:00", 19800: "+05:30", -12600: "-03:30"} {
+		if got := formatUTCOffset(seconds); got != want {
+			t.Errorf("formatUTCOffset(%d) = %s, want %s", seconds, got, want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"calsun/services"
+)
+
+// todayParamDefs lists the parameters accepted by TodayHandler
+var todayParamDefs = []paramDef{latParam, lngParam, nameParam, dateParam}
+
+// todayResponse is the JSON body returned by TodayHandler. Times are in the
+// location's timezone (RFC 3339) and null when the sun does not rise or set.
+// The night and darkness lengths are of the night following the date, and left
+// out when it starts or ends a polar day or night.
+type todayResponse struct {
+	Date               string     `json:"date"`
+	Location           string     `json:"location"`
+	Lat                float64    `json:"lat"`
+	Lng                float64    `json:"lng"`
+	Timezone           string     `json:"timezone"`
+	Sunrise            *time.Time `json:"sunrise"`
+	Sunset             *time.Time `json:"sunset"`
+	DayLength          string     `json:"day_length,omitempty"`
+	DayLengthSeconds   int        `json:"day_length_seconds,omitempty"`
+	NightLength        string     `json:"night_length,omitempty"`
+	NightLengthSeconds *int       `json:"night_length_seconds,omitempty"`
+	Darkness           string     `json:"darkness,omitempty"` // Astronomical dusk to dawn
+	DarknessSeconds    *int       `json:"darkness_seconds,omitempty"`
+	SolarLongitude     float64    `json:"solar_longitude"` // Ecliptic, in degrees at noon
+	Season             string     `json:"season"`          // Astronomical season, named for the hemisphere
+	SeasonProgress     float64    `json:"season_progress"` // Fraction of the season passed at noon, 0 to 1
+}
+
+// TodayHandler returns today's (or the requested date's) sunrise and sunset
+// for a location as JSON
+func TodayHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	tz := services.GetTimezone(params.lat, params.lng)
+	now := params.now.In(tz)
+	if !params.date.IsZero() {
+		now = params.date
+	}
+	noon := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, tz)
+	day := services.GetSunTimes(params.lat, params.lng, noon)
+
+	season := services.GetSeasonProgress(params.lat, noon)
+	resp := todayResponse{
+		Date:           noon.Format("2006-01-02"),
+		Location:       locationName(params),
+		Lat:            params.lat,
+		Lng:            params.lng,
+		Timezone:       tz.String(),
+		SolarLongitude: round2(services.SolarLongitude(noon)),
+		Season:         season.Season,
+		SeasonProgress: roundTo(season.Fraction, 3),
+	}
+	if day.Sunrise != nil {
+		sunrise := day.Sunrise.Time.In(tz)
+		resp.Sunrise = &sunrise
+	}
+	if day.Sunset != nil {
+		sunset := day.Sunset.Time.In(tz)
+		resp.Sunset = &sunset
+	}
+	if day.Sunrise != nil && day.Sunset != nil {
+		dayLength := day.Sunset.Time.Sub(day.Sunrise.Time)
+		resp.DayLength = services.FormatDuration(dayLength)
+		resp.DayLengthSeconds = int(dayLength.Seconds())
+	}
+
+	next := services.GetSunTimes(params.lat, params.lng, noon.AddDate(0, 0, 1))
+	if nightLength, ok := day.NightLength(next); ok {
+		seconds := int(nightLength.Seconds())
+		resp.NightLength = services.FormatDuration(nightLength)
+		resp.NightLengthSeconds = &seconds
+	}
+	if darkness, ok := day.DarknessLength(next); ok {
+		seconds := int(darkness.Seconds())
+		resp.Darkness = services.FormatDuration(darkness)
+		resp.DarknessSeconds = &seconds
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTodayHandler_ValidRequest(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/today?lat=55.6761&lng=12.5683&name=Copenhagen", nil)
+	w := httptest.NewRecorder()
+
+	TodayHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	contentType := w.Header().Get("Content-Type")
+	if !strings.Contains(contentType, "application/json") {
+		t.Errorf("expected Content-Type application/json, got %s", contentType)
+	}
+
+	var resp todayResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if resp.Location != "Copenhagen" {
+		t.Errorf("expected location Copenhagen, got %s", resp.Location)
+	}
+	if resp.Timezone != "Europe/Copenhagen" {
+		t.Errorf("expected timezone Europe/Copenhagen, got %s", resp.Timezone)
+	}
+	if resp.Sunrise == nil || resp.Sunset == nil {
+		t.Fatal("expected sunrise and sunset")
+	}
+	if !resp.Sunrise.Before(*resp.Sunset) {
+		t.Error("sunrise should be before sunset")
+	}
+	if resp.DayLengthSeconds <= 0 {
+		t.Errorf("expected positive day length, got %d", resp.DayLengthSeconds)
+	}
+	if resp.NightLengthSeconds == nil || resp.DarknessSeconds == nil {
+		t.Fatal("expected night and darkness lengths")
+	}
+	if *resp.DarknessSeconds > *resp.NightLengthSeconds {
+		t.Errorf("darkness %ds should not exceed the night %ds", *resp.DarknessSeconds, *resp.NightLengthSeconds)
+	}
+}
+
+func TestTodayHandler_NightLength(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/today?lat=55.6761&lng=12.5683&date=2024-12-21", nil)
+	w := httptest.NewRecorder()
+
+	TodayHandler(w, req)
+
+	var resp todayResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	// The night runs past midnight, into the next day's sunrise
+	if resp.NightLengthSeconds == nil || resp.DayLengthSeconds+*resp.NightLengthSeconds < 23*3600+55*60 {
+		t.Errorf("expected the day and the night to add up to about 24 hours, got %+v", resp)
+	}
+	if resp.Darkness != "12h 17m" {
+		t.Errorf("expected 12h 17m of darkness, got %q", resp.Darkness)
+	}
+}
+
+func TestTodayHandler_Season(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/today?lat=-33.8688&lng=151.2093&date=2024-05-01", nil)
+	w := httptest.NewRecorder()
+
+	TodayHandler(w, req)
+
+	var resp todayResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Season != "autumn" || resp.SeasonProgress < 0.44 || resp.SeasonProgress > 0.46 {
+		t.Errorf("expected about 45%% through autumn in Sydney, got %s %.3f", resp.Season, resp.SeasonProgress)
+	}
+	if resp.SolarLongitude < 40 || resp.SolarLongitude > 42 {
+		t.Errorf("expected a solar longitude of about 41°, got %.2f", resp.SolarLongitude)
+	}
+}
+
+func TestTodayHandler_Date(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/today?lat=-33.8688&lng=151.2093&date=2025-06-21", nil)
+	w := httptest.NewRecorder()
+
+	TodayHandler(w, req)
+
+	var resp todayResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Date != "2025-06-21" || resp.Sunrise == nil || resp.Sunrise.Format("2006-01-02") != "2025-06-21" {
+		t.Errorf("expected the sun times of 2025-06-21, got %+v", resp)
+	}
+}
+
+func TestTodayHandler_InvalidParams(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/today?lat=55.6761", nil)
+	w := httptest.NewRecorder()
+
+	TodayHandler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"calsun/services"
+)
+
+var (
+	triggerLimitParam = paramDef{
+		Name:        "limit",
+		Type:        paramTypeInteger,
+		Min:         bound(1),
+		Max:         bound(100),
+		Default:     50,
+		Description: "Maximum number of events per page",
+	}
+	triggerSinceParam = paramDef{
+		Name:        "since",
+		Type:        paramTypeString,
+		Description: "Only return events after this time (RFC 3339 or Unix seconds)",
+	}
+	triggerCursorParam = paramDef{
+		Name:        "cursor",
+		Type:        paramTypeString,
+		Description: "Opaque cursor from X-Next-Cursor, for the next (older) page",
+	}
+)
+
+// triggerParamDefs lists the parameters accepted by the trigger feed
+var triggerParamDefs = []paramDef{
+	latParam,
+	lngParam,
+	nameParam,
+	excludeParam,
+	triggerLimitParam,
+	triggerSinceParam,
+	triggerCursorParam,
+}
+
+// triggerItem is a sun event that has happened, as consumed by automation
+// platforms. The ID is the event's stable UID, used for deduplication.
+type triggerItem struct {
+	ID          string     `json:"id"`
+	Event       string     `json:"event"`
+	Time        time.Time  `json:"time"`      // Local time, RFC 3339
+	Timestamp   int64      `json:"timestamp"` // Unix seconds
+	Summary     string     `json:"summary"`
+	Description string     `json:"description"`
+	Location    string     `json:"location"`
+	Lat         float64    `json:"lat"`
+	Lng         float64    `json:"lng"`
+	Meta        *iftttMeta `json:"meta,omitempty"`
+}
+
+// iftttMeta is the metadata IFTTT requires on each trigger item
+type iftttMeta struct {
+	ID        string `json:"id"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// triggerPage is a page of the trigger feed
+type triggerPage struct {
+	Items      []triggerItem
+	NextCursor string // Empty on the last page
+}
+
+// triggerFeed returns the events that have happened in the last pastDays
+// days, newest first, after since (if set) and before the cursor (if set)
+func triggerFeed(params *calendarParams, now, since time.Time, cursor string, limit int) (triggerPage, string) {
+	var before time.Time
+	if cursor != "" {
+		var ok bool
+		if before, ok = decodeTriggerCursor(cursor); !ok {
+			return triggerPage{}, "invalid cursor parameter"
+		}
+	}
+
+	tz := services.GetTimezone(params.lat, params.lng)
+	events := services.PastSunEvents(services.CalendarOptions{
+		Lat:           params.lat,
+		Lng:           params.lng,
+		Location:      locationName(params),
+		Timezone:      tz,
+		Events:        params.events,
+		AzimuthFormat: params.azimuthFormat,
+		Precision:     params.precision,
+		Details:       params.details,
+	}, now, pastDays)
+
+	page := triggerPage{Items: []triggerItem{}}
+	if limit == 0 {
+		return page, ""
+	}
+	for _, event := range events {
+		if !before.IsZero() && !event.Start.Before(before) {
+			continue
+		}
+		if !event.Start.After(since) {
+			break // Events are newest first
+		}
+		if len(page.Items) == limit {
+			page.NextCursor = encodeTriggerCursor(page.Items[len(page.Items)-1].Time)
+			break
+		}
+		page.Items = append(page.Items, triggerItem{
+			ID:          event.UID,
+			Event:       event.Type,
+			Time:        event.Start.In(tz),
+			Timestamp:   event.Start.Unix(),
+			Summary:     event.Summary,
+			Description: event.Description,
+			Location:    event.Location,
+			Lat:         roundTo(params.lat, params.precision),
+			Lng:         roundTo(params.lng, params.precision),
+		})
+	}
+	return page, ""
+}
+
+// encodeTriggerCursor returns an opaque cursor pointing before t
+func encodeTriggerCursor(t time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(t.Unix(), 10)))
+}
+
+// decodeTriggerCursor parses a cursor from encodeTriggerCursor
+func decodeTriggerCursor(cursor string) (time.Time, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(unix, 0), true
+}
+
+// parseSince parses an RFC 3339 time or Unix seconds. Empty means no lower
+// bound.
+func parseSince(s string) (time.Time, string) {
+	if s == "" {
+		return time.Time{}, ""
+	}
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(unix, 0), ""
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, ""
+	}
+	return time.Time{}, "invalid since parameter"
+}
+
+// SunTriggerHandler is a polling trigger for Zapier-style automation
+// platforms: it returns the sun events that have happened recently as a JSON
+// array, newest first, each with a stable id for deduplication
+func SunTriggerHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	params, errMsg := parseCalendarQuery(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	limit, errMsg := triggerLimitParam.parseInt(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	since, errMsg := parseSince(q.Get(triggerSinceParam.Name))
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	page, errMsg := triggerFeed(params, params.now, since, q.Get(triggerCursorParam.Name), limit)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	if page.NextCursor != "" {
+		w.Header().Set("X-Next-Cursor", page.NextCursor)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page.Items)
+}
+
+// iftttTriggerRequest is the body IFTTT posts to a trigger endpoint
+type iftttTriggerRequest struct {
+	TriggerFields map[string]string `json:"triggerFields"`
+	Limit         *int              `json:"limit"`
+	Cursor        string            `json:"cursor"`
+}
+
+// iftttAuthorized checks the IFTTT-Service-Key header, writing an error
+// response if IFTTT is not configured or the key is wrong
+func iftttAuthorized(w http.ResponseWriter, r *http.Request) bool {
+	if cfg.IFTTTServiceKey == "" {
+		http.NotFound(w, r)
+		return false
+	}
+	key := r.Header.Get("IFTTT-Service-Key")
+	if subtle.ConstantTimeCompare([]byte(key), []byte(cfg.IFTTTServiceKey)) != 1 {
+		writeIFTTTError(w, http.StatusUnauthorized, "invalid service key")
+		return false
+	}
+	return true
+}
+
+// writeIFTTTError writes an error in IFTTT's format
+func writeIFTTTError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"errors": []map[string]string{{"message": message}},
+	})
+}
+
+// writeIFTTTData writes a successful response in IFTTT's format
+func writeIFTTTData(w http.ResponseWriter, body map[string]any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(body)
+}
+
+// IFTTTStatusHandler reports service availability to IFTTT
+func IFTTTStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if !iftttAuthorized(w, r) {
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// IFTTTTestSetupHandler returns sample trigger fields for IFTTT's endpoint
+// tests
+func IFTTTTestSetupHandler(w http.ResponseWriter, r *http.Request) {
+	if !iftttAuthorized(w, r) {
+		return
+	}
+	writeIFTTTData(w, map[string]any{
+		"data": map[string]any{
+			"samples": map[string]any{
+				"triggers": map[string]any{
+					"sun_event": map[string]string{
+						latParam.Name:  "55.6761",
+						lngParam.Name:  "12.5683",
+						nameParam.Name: "Copenhagen",
+					},
+				},
+			},
+		},
+	})
+}
+
+// IFTTTSunEventHandler is the IFTTT "sun_event" trigger. Trigger fields take
+// the same values as the calendar parameters (lat, lng, name, exclude).
+func IFTTTSunEventHandler(w http.ResponseWriter, r *http.Request) {
+	if !iftttAuthorized(w, r) {
+		return
+	}
+
+	var req iftttTriggerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeIFTTTError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.TriggerFields == nil {
+		writeIFTTTError(w, http.StatusBadRequest, "triggerFields is required")
+		return
+	}
+
+	fields := url.Values{}
+	for key, value := range req.TriggerFields {
+		fields.Set(key, value)
+	}
+	params, errMsg := parseCalendarQuery(fields)
+	if errMsg != "" {
+		writeIFTTTError(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	limit := 50 // IFTTT's default when the limit is omitted
+	if req.Limit != nil {
+		limit = max(*req.Limit, 0)
+	}
+
+	page, errMsg := triggerFeed(params, params.now, time.Time{}, req.Cursor, limit)
+	if errMsg != "" {
+		writeIFTTTError(w, http.StatusBadRequest, errMsg)
+		return
+	}
+	for i := range page.Items {
+		page.Items[i].Meta = &iftttMeta{ID: page.Items[i].ID, Timestamp: page.Items[i].Timestamp}
+	}
+
+	body := map[string]any{"data": page.Items}
+	if page.NextCursor != "" {
+		body["cursor"] = page.NextCursor
+	}
+	writeIFTTTData(w, body)
+}
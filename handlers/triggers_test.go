@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+var triggerTestParams = &calendarParams{
+	lat:    55.6761,
+	lng:    12.5683,
+	name:   "Copenhagen",
+	events: services.DefaultEvents(),
+}
+
+func TestTriggerFeed_Pagination(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	seen := make(map[string]bool)
+	var last time.Time
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("pagination did not terminate")
+		}
+		page, errMsg := triggerFeed(triggerTestParams, now, time.Time{}, cursor, 5)
+		if errMsg != "" {
+			t.Fatalf("unexpected error: %s", errMsg)
+		}
+		for _, item := range page.Items {
+			if seen[item.ID] {
+				t.Errorf("event %s returned twice", item.ID)
+			}
+			seen[item.ID] = true
+			if !last.IsZero() && !item.Time.Before(last) {
+				t.Error("events should be newest first across pages")
+			}
+			last = item.Time
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	// 14 full days plus today's sunrise
+	if len(seen) != 2*pastDays+1 {
+		t.Errorf("expected %d events across all pages, got %d", 2*pastDays+1, len(seen))
+	}
+}
+
+func TestTriggerFeed_Since(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	since := time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)
+
+	page, _ := triggerFeed(triggerTestParams, now, since, "", 50)
+
+	// March 9 sunrise and sunset, March 10 sunrise
+	if len(page.Items) != 3 {
+		t.Fatalf("expected 3 events since March 9, got %d", len(page.Items))
+	}
+	if page.Items[0].Event != "sunrise" || page.Items[0].Time.Day() != 10 {
+		t.Errorf("expected today's sunrise first, got %+v", page.Items[0])
+	}
+	if page.Items[0].Time.Location().String() != "Europe/Copenhagen" {
+		t.Errorf("expected local time, got %s", page.Items[0].Time.Location())
+	}
+}
+
+func TestSunTriggerHandler(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/triggers/sun?lat=55.6761&lng=12.5683&exclude=sunrise&limit=3", nil)
+	w := httptest.NewRecorder()
+
+	SunTriggerHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var items []triggerItem
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("response should be a JSON array: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("expected 3 items, got %d", len(items))
+	}
+	for _, item := range items {
+		if item.ID == "" || item.Event != "sunset" || item.Timestamp != item.Time.Unix() {
+			t.Errorf("unexpected item: %+v", item)
+		}
+		if item.Time.After(time.Now()) {
+			t.Errorf("trigger returned a future event: %s", item.Time)
+		}
+	}
+	if w.Header().Get("X-Next-Cursor") == "" {
+		t.Error("expected a cursor for the next page")
+	}
+}
+
+func TestSunTriggerHandler_InvalidParams(t *testing.T) {
+	tests := []string{
+		"lat=55",
+		"lat=55&lng=12&limit=0",
+		"lat=55&lng=12&since=yesterday",
+		"lat=55&lng=12&cursor=!!!",
+	}
+
+	for _, query := range tests {
+		t.Run(query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/triggers/sun?"+query, nil)
+			w := httptest.NewRecorder()
+
+			SunTriggerHandler(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+// withIFTTTKey configures an IFTTT service key for the duration of the test
+func withIFTTTKey(t *testing.T, key string) {
+	original := cfg
+	t.Cleanup(func() { Configure(original) })
+
+	c := *original
+	c.IFTTTServiceKey = key
+	Configure(&c)
+}
+
+func iftttRequest(method, path, key, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("IFTTT-Service-Key", key)
+	}
+	return req
+}
+
+func TestIFTTTHandlers_Auth(t *testing.T) {
+	w := httptest.NewRecorder()
+	IFTTTStatusHandler(w, iftttRequest("GET", "/ifttt/v1/status", "secret", ""))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 when IFTTT is not configured, got %d", w.Code)
+	}
+
+	withIFTTTKey(t, "secret")
+
+	w = httptest.NewRecorder()
+	IFTTTStatusHandler(w, iftttRequest("GET", "/ifttt/v1/status", "wrong", ""))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a wrong key, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	IFTTTStatusHandler(w, iftttRequest("GET", "/ifttt/v1/status", "secret", ""))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	IFTTTTestSetupHandler(w, iftttRequest("POST", "/ifttt/v1/test/setup", "secret", ""))
+	if !strings.Contains(w.Body.String(), `"sun_event"`) {
+		t.Errorf("expected sample trigger fields, got %s", w.Body.String())
+	}
+}
+
+func TestIFTTTSunEventHandler(t *testing.T) {
+	withIFTTTKey(t, "secret")
+
+	body := `{"triggerFields": {"lat": "55.6761", "lng": "12.5683", "name": "Copenhagen"}, "limit": 2}`
+	w := httptest.NewRecorder()
+	IFTTTSunEventHandler(w, iftttRequest("POST", "/ifttt/v1/triggers/sun_event", "secret", body))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Data   []triggerItem `json:"data"`
+		Cursor string        `json:"cursor"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Data) != 2 || resp.Cursor == "" {
+		t.Fatalf("expected 2 items and a cursor, got %d items (cursor %q)", len(resp.Data), resp.Cursor)
+	}
+	for _, item := range resp.Data {
+		if item.Meta == nil || item.Meta.ID != item.ID || item.Meta.Timestamp != item.Timestamp {
+			t.Errorf("expected IFTTT meta on every item, got %+v", item)
+		}
+	}
+}
+
+func TestIFTTTSunEventHandler_Errors(t *testing.T) {
+	withIFTTTKey(t, "secret")
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"limit zero", `{"triggerFields": {"lat": "55", "lng": "12"}, "limit": 0}`, http.StatusOK},
+		{"missing trigger fields", `{"limit": 5}`, http.StatusBadRequest},
+		{"invalid trigger fields", `{"triggerFields": {"lat": "95", "lng": "12"}}`, http.StatusBadRequest},
+		{"invalid body", `{`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			IFTTTSunEventHandler(w, iftttRequest("POST", "/ifttt/v1/triggers/sun_event", "secret", tt.body))
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+			if tt.status == http.StatusOK {
+				if !strings.Contains(w.Body.String(), `"data":[]`) {
+					t.Errorf("expected empty data, got %s", w.Body.String())
+				}
+			} else if !strings.Contains(w.Body.String(), `"errors"`) {
+				t.Errorf("expected IFTTT error format, got %s", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// requestBaseURL returns the externally visible scheme and host of the request
+// (e.g., "https://calsun.example.com"), honouring the Forwarded and
+// X-Forwarded-* headers set by reverse proxies
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	host := r.Host
+
+	if proto := firstHeaderValue(r.Header.Get("X-Forwarded-Proto")); proto != "" {
+		scheme = proto
+	}
+	if fwdHost := firstHeaderValue(r.Header.Get("X-Forwarded-Host")); fwdHost != "" {
+		host = fwdHost
+	}
+
+	// RFC 7239 Forwarded header takes precedence over the de-facto headers
+	if fwd := firstHeaderValue(r.Header.Get("Forwarded")); fwd != "" {
+		for _, pair := range strings.Split(fwd, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				continue
+			}
+			value = strings.Trim(value, `"`)
+			switch strings.ToLower(key) {
+			case "proto":
+				scheme = value
+			case "host":
+				host = value
+			}
+		}
+	}
+
+	scheme = strings.ToLower(scheme)
+	if scheme != "http" && scheme != "https" {
+		scheme = "http"
+	}
+	if strings.ContainsAny(host, "/\\ @?#") {
+		host = r.Host
+	}
+
+	return scheme + "://" + host
+}
+
+// firstHeaderValue returns the first element of a comma-separated header value
+func firstHeaderValue(value string) string {
+	first, _, _ := strings.Cut(value, ",")
+	return strings.TrimSpace(first)
+}
+
+// appPath returns the path of an app route (e.g., "/calendar.ics") under the
+// configured base path (e.g., "/calsun/calendar.ics")
+func appPath(route string) string {
+	return cfg.Server.BasePath + route
+}
+
+// appURL returns the absolute URL of an app route for the request
+func appURL(r *http.Request, route string) string {
+	return requestBaseURL(r) + appPath(route)
+}
+
+// calendarURL builds the absolute calendar.ics URL for the given query
+func calendarURL(r *http.Request, query url.Values) string {
+	return appURL(r, "/calendar.ics") + "?" + query.Encode()
+}
+
+// toWebcalURL converts an HTTP(S) URL to a webcal URL for calendar subscription
+func toWebcalURL(u string) string {
+	if rest, ok := strings.CutPrefix(u, "https://"); ok {
+		return "webcal://" + rest
+	}
+	if rest, ok := strings.CutPrefix(u, "http://"); ok {
+		return "webcal://" + rest
+	}
+	return u
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"calsun/config"
+)
+
+func TestRequestBaseURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		tls      bool
+		expected string
+	}{
+		{"plain request", nil, false, "http://example.com"},
+		{"tls request", nil, true, "https://example.com"},
+		{
+			"x-forwarded headers",
+			map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "calsun.example.org"},
+			false,
+			"https://calsun.example.org",
+		},
+		{
+			"multiple proxies",
+			map[string]string{"X-Forwarded-Proto": "https, http", "X-Forwarded-Host": "outer.example.org, inner"},
+			false,
+			"https://outer.example.org",
+		},
+		{
+			"forwarded header",
+			map[string]string{"Forwarded": `for=192.0.2.60;proto=https;host="cal.example.net"`},
+			false,
+			"https://cal.example.net",
+		},
+		{
+			"invalid proto ignored",
+			map[string]string{"X-Forwarded-Proto": "javascript"},
+			false,
+			"http://example.com",
+		},
+		{
+			"invalid host ignored",
+			map[string]string{"X-Forwarded-Host": "evil.com/path"},
+			false,
+			"http://example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/qr", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+
+			if got := requestBaseURL(req); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestToWebcalURL(t *testing.T) {
+	req := httptest.NewRequest("GET", "https://example.com/qr", nil)
+	got := toWebcalURL(calendarURL(req, url.Values{"lat": {"55.6761"}, "lng": {"12.5683"}}))
+
+	expected := "webcal://example.com/calendar.ics?lat=55.6761&lng=12.5683"
+	if got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
+
+func TestCalendarURL_BasePath(t *testing.T) {
+	original := cfg
+	t.Cleanup(func() { Configure(original) })
+	mounted := config.Default()
+	mounted.Server.BasePath = "/calsun"
+	Configure(mounted)
+
+	req := httptest.NewRequest("GET", "https://example.com/calsun/qr", nil)
+	got := calendarURL(req, url.Values{"lat": {"55.6761"}, "lng": {"12.5683"}})
+
+	expected := "https://example.com/calsun/calendar.ics?lat=55.6761&lng=12.5683"
+	if got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+	if got := appPath("/email/unsubscribe"); got != "/calsun/email/unsubscribe" {
+		t.Errorf("expected prefixed path, got %s", got)
+	}
+}
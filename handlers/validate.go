@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"calsun/services"
+)
+
+// maxValidateSize is the largest calendar ValidateHandler accepts
+const maxValidateSize = 4 << 20
+
+// ValidateHandler lints an iCalendar feed and reports RFC 5545 violations and
+// client compatibility warnings as JSON. POST a calendar as the request body
+// or as the "file" field of a form upload; GET with calendar parameters
+// checks the /calendar.ics feed CalSun generates for them.
+func ValidateHandler(w http.ResponseWriter, r *http.Request) {
+	var data []byte
+	if r.Method == http.MethodPost {
+		body, errMsg, status := readUploadedCalendar(w, r)
+		if errMsg != "" {
+			http.Error(w, errMsg, status)
+			return
+		}
+		data = body
+	} else {
+		params, errMsg := parseCalendarParams(r)
+		if errMsg != "" {
+			http.Error(w, errMsg, http.StatusBadRequest)
+			return
+		}
+		tz := services.GetTimezone(params.lat, params.lng)
+		calName, events := buildCalendarEvents(r, params, tz)
+		body, err := serializeICS(calName, tz, events, params.now)
+		if err != nil {
+			http.Error(w, "failed to serialize calendar", http.StatusInternalServerError)
+			return
+		}
+		data = body
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lintICS(data))
+}
+
+// readUploadedCalendar reads the calendar posted to ValidateHandler, either
+// the "file" field of a multipart form or the whole body
+func readUploadedCalendar(w http.ResponseWriter, r *http.Request) ([]byte, string, int) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxValidateSize)
+
+	var src io.Reader = r.Body
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if strings.HasPrefix(mediaType, "multipart/") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			if tooLarge(err) {
+				return nil, "calendar too large (max 4 MiB)", http.StatusRequestEntityTooLarge
+			}
+			return nil, `expected the calendar in the "file" field`, http.StatusBadRequest
+		}
+		defer file.Close()
+		src = file
+	}
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		if tooLarge(err) {
+			return nil, "calendar too large (max 4 MiB)", http.StatusRequestEntityTooLarge
+		}
+		return nil, "failed to read calendar", http.StatusBadRequest
+	}
+	if len(data) == 0 {
+		return nil, "no calendar to validate: POST an iCalendar file, or GET with calendar parameters", http.StatusBadRequest
+	}
+	return data, "", 0
+}
+
+// tooLarge reports whether err is from reading past a MaxBytesReader limit
+func tooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
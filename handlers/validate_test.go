@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func decodeReport(t *testing.T, w *httptest.ResponseRecorder) icsReport {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected application/json, got %q", ct)
+	}
+	var report icsReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return report
+}
+
+func TestValidateHandler_Body(t *testing.T) {
+	body := strings.Replace(validICS, "DTSTAMP:20240101T000000Z\r\n", "", 1)
+	req := httptest.NewRequest("POST", "/api/validate", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/calendar")
+	w := httptest.NewRecorder()
+
+	ValidateHandler(w, req)
+
+	report := decodeReport(t, w)
+	if report.Valid || !hasIssue(report.Errors, 5, "no DTSTAMP") || report.Events != 1 {
+		t.Errorf("expected a missing DTSTAMP error, got %+v", report)
+	}
+}
+
+func TestValidateHandler_Upload(t *testing.T) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, _ := mw.CreateFormFile("file", "feed.ics")
+	fw.Write([]byte(validICS))
+	mw.Close()
+	req := httptest.NewRequest("POST", "/api/validate", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+
+	ValidateHandler(w, req)
+
+	if report := decodeReport(t, w); !report.Valid || len(report.Warnings) != 0 {
+		t.Errorf("expected a clean report, got %+v", report)
+	}
+}
+
+func TestValidateHandler_Generated(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/validate?lat=55.6761&lng=12.5683&days=3", nil)
+	w := httptest.NewRecorder()
+
+	ValidateHandler(w, req)
+
+	if report := decodeReport(t, w); !report.Valid || len(report.Warnings) != 0 || report.Events == 0 {
+		t.Errorf("expected CalSun's calendar to be clean, got %+v", report)
+	}
+}
+
+func TestValidateHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"empty body", "POST", "/api/validate", "", http.StatusBadRequest},
+		{"too large", "POST", "/api/validate", strings.Repeat("x", maxValidateSize+1), http.StatusRequestEntityTooLarge},
+		{"invalid calendar params", "GET", "/api/validate?lat=100&lng=0", "", http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ValidateHandler(w, httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body)))
+			if w.Code != tc.status {
+				t.Errorf("expected %d, got %d: %s", tc.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"bytes"
+	"mime/quotedprintable"
+	"strings"
+	"time"
+
+	"calsun/services"
+)
+
+// vcalLineLength is the length plain vCalendar lines are folded at
+const vcalLineLength = 75
+
+// serializeVCal writes the events as a vCalendar 1.0 calendar, for legacy
+// devices (older phones, in-car and embedded systems) that can't parse
+// iCalendar 2.0. Times are UTC, all-day events run from local midnight to
+// 23:59 in floating time, and text with line breaks or non-ASCII characters
+// is quoted-printable UTF-8.
+func serializeVCal(name string, tz *time.Location, events []services.CalendarEvent, _ time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	writeVCalLine(&buf, "BEGIN:VCALENDAR")
+	writeVCalLine(&buf, "VERSION:1.0")
+	writeVCalLine(&buf, "PRODID:"+calendarProductID)
+	for _, event := range events {
+		writeVCalLine(&buf, "BEGIN:VEVENT")
+		writeVCalLine(&buf, "UID:"+event.UID)
+		if event.AllDay {
+			// Start and End are local midnights already
+			writeVCalLine(&buf, "DTSTART:"+event.Start.Format("20060102T150405"))
+			writeVCalLine(&buf, "DTEND:"+event.End.Add(-time.Minute).Format("20060102T150405"))
+		} else {
+			writeVCalLine(&buf, "DTSTART:"+event.Start.UTC().Format("20060102T150405Z"))
+			writeVCalLine(&buf, "DTEND:"+event.End.UTC().Format("20060102T150405Z"))
+		}
+		if err := writeVCalText(&buf, "SUMMARY", event.Summary); err != nil {
+			return nil, err
+		}
+		if err := writeVCalText(&buf, "DESCRIPTION", event.Description); err != nil {
+			return nil, err
+		}
+		if err := writeVCalText(&buf, "LOCATION", event.Location); err != nil {
+			return nil, err
+		}
+		if len(event.Categories) > 0 {
+			// vCalendar separates multiple values with semicolons
+			writeVCalLine(&buf, "CATEGORIES:"+strings.Join(event.Categories, ";"))
+		}
+		writeVCalLine(&buf, "END:VEVENT")
+	}
+	writeVCalLine(&buf, "END:VCALENDAR")
+	return buf.Bytes(), nil
+}
+
+// writeVCalText writes a text property, quoted-printable if the value has
+// line breaks or non-ASCII characters. Empty values are left out.
+func writeVCalText(buf *bytes.Buffer, name, value string) error {
+	if value == "" {
+		return nil
+	}
+	if isPlainVCalText(value) {
+		writeVCalLine(buf, name+":"+value)
+		return nil
+	}
+
+	// Line breaks are encoded as =0D=0A; soft line breaks ("=" at the end
+	// of a line) keep lines short
+	buf.WriteString(name + ";ENCODING=QUOTED-PRINTABLE;CHARSET=UTF-8:")
+	qp := quotedprintable.NewWriter(buf)
+	qp.Binary = true
+	if _, err := qp.Write([]byte(strings.ReplaceAll(value, "\n", "\r\n"))); err != nil {
+		return err
+	}
+	if err := qp.Close(); err != nil {
+		return err
+	}
+	buf.WriteString("\r\n")
+	return nil
+}
+
+// isPlainVCalText reports whether s can be written without encoding:
+// printable ASCII only
+func isPlainVCalText(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < ' ' || s[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// writeVCalLine writes a line, folding it at vcalLineLength with a space
+// starting each continuation
+func writeVCalLine(buf *bytes.Buffer, line string) {
+	for len(line) > vcalLineLength {
+		buf.WriteString(line[:vcalLineLength] + "\r\n ")
+		line = line[vcalLineLength:]
+	}
+	buf.WriteString(line + "\r\n")
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+func TestSerializeVCal(t *testing.T) {
+	tz := services.GetTimezone(55.6761, 12.5683)
+	events := []services.CalendarEvent{
+		{
+			UID:         "sunset@calsun",
+			Summary:     "Sunset",
+			Start:       time.Date(2024, 6, 21, 19, 57, 0, 0, time.UTC),
+			End:         time.Date(2024, 6, 21, 19, 58, 0, 0, time.UTC),
+			Description: "Day length: 17h 32m\nAzimuth: 311°",
+			Location:    "Copenhagen",
+			Categories:  []string{"Sun", "Sunset"},
+		},
+		{
+			UID:     "perigee@calsun",
+			Summary: "Moon at perigee (357,175 km)",
+			Start:   time.Date(2024, 10, 17, 0, 0, 0, 0, tz),
+			End:     time.Date(2024, 10, 18, 0, 0, 0, 0, tz),
+			AllDay:  true,
+		},
+	}
+
+	body, err := serializeVCal("Sun Times", tz, events, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(body)
+	if !strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:1.0\r\n") || !strings.HasSuffix(out, "END:VCALENDAR\r\n") {
+		t.Fatalf("expected a vCalendar 1.0 calendar with CRLF lines, got:\n%s", out)
+	}
+	if strings.Count(out, "BEGIN:VEVENT") != 2 {
+		t.Errorf("expected 2 events, got:\n%s", out)
+	}
+	for _, want := range []string{
+		"UID:sunset@calsun\r\n",
+		"DTSTART:20240621T195700Z\r\n",
+		"DTEND:20240621T195800Z\r\n",
+		"SUMMARY:Sunset\r\n",
+		// Line breaks and non-ASCII are quoted-printable UTF-8
+		"DESCRIPTION;ENCODING=QUOTED-PRINTABLE;CHARSET=UTF-8:Day length: 17h 32m=0D=0AAzimuth: 311=C2=B0\r\n",
+		"LOCATION:Copenhagen\r\n",
+		"CATEGORIES:Sun;Sunset\r\n",
+		// All-day events cover the day in floating local time
+		"DTSTART:20241017T000000\r\n",
+		"DTEND:20241017T235900\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "VALUE=DATE") || strings.Contains(out, "\n\n") {
+		t.Errorf("unexpected iCalendar 2.0 syntax or blank lines:\n%s", out)
+	}
+}
+
+func TestWriteVCalLine_Folds(t *testing.T) {
+	var buf bytes.Buffer
+	line := "SUMMARY:" + strings.Repeat("x", 100)
+	writeVCalLine(&buf, line)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	if len(lines) != 2 || len(lines[0]) != vcalLineLength || !strings.HasPrefix(lines[1], " ") {
+		t.Fatalf("expected a folded line, got %q", lines)
+	}
+	if unfolded := lines[0] + strings.TrimPrefix(lines[1], " "); unfolded != line {
+		t.Errorf("unfolded line = %q, want %q", unfolded, line)
+	}
+}
+
+func TestUnifiedCalendarHandler_VCal(t *testing.T) {
+	req := httptest.NewRequest("GET", "/calendar?lat=55.6761&lng=12.5683&days=2", nil)
+	req.Header.Set("Accept", "text/x-vcalendar")
+	w := httptest.NewRecorder()
+
+	UnifiedCalendarHandler(w, req)
+
+	if got := w.Header().Get("Content-Type"); got != "text/x-vcalendar; charset=utf-8" {
+		t.Errorf("expected text/x-vcalendar, got %q", got)
+	}
+	if got := w.Header().Get("Content-Disposition"); !strings.Contains(got, "calsun.vcs") {
+		t.Errorf("expected a .vcs download, got %q", got)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "VERSION:1.0") || !strings.Contains(body, "SUMMARY:Sunrise") {
+		t.Errorf("expected a vCalendar with sunrise events, got:\n%s", body)
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"calsun/services"
+)
+
+// weatherTimeout bounds how long a calendar request waits for forecasts
+const weatherTimeout = 3 * time.Second
+
+// weather is the forecast provider shared by all handlers
+var weather services.WeatherProvider = services.NewOpenMeteo(services.OpenMeteoURL, services.OpenMeteoAirQualityURL)
+
+// annotateWeather adds the forecasts requested by the weather parameter to
+// events. Forecasts that are unavailable are skipped, so the calendar is
+// still served.
+func annotateWeather(ctx context.Context, events []services.CalendarEvent, params *calendarParams) {
+	ctx, cancel := context.WithTimeout(ctx, weatherTimeout)
+	defer cancel()
+
+	for _, kind := range strings.Split(params.weather, ",") {
+		switch kind {
+		case "clouds":
+			forecast, err := weather.CloudCover(ctx, params.lat, params.lng)
+			if err != nil {
+				log.Printf("cloud cover forecast: %v", err)
+				continue
+			}
+			services.AnnotateCloudCover(events, forecast)
+		case "aqi":
+			forecast, err := weather.AirQuality(ctx, params.lat, params.lng)
+			if err != nil {
+				log.Printf("air quality forecast: %v", err)
+				continue
+			}
+			services.AnnotateAirQuality(events, forecast)
+		}
+	}
+}
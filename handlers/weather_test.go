@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+// fakeWeather is a WeatherProvider returning fixed values for every hour of
+// the next week, or an error
+type fakeWeather struct {
+	cover int
+	aqi   int
+	err   error
+}
+
+func (f fakeWeather) CloudCover(ctx context.Context, lat, lng float64) (services.HourlyForecast, error) {
+	return f.forecast(f.cover)
+}
+
+func (f fakeWeather) AirQuality(ctx context.Context, lat, lng float64) (services.HourlyForecast, error) {
+	if f.aqi == 0 {
+		return nil, errors.New("no air quality data")
+	}
+	return f.forecast(f.aqi)
+}
+
+func (f fakeWeather) forecast(value int) (services.HourlyForecast, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	forecast := services.HourlyForecast{}
+	start := time.Now().Truncate(time.Hour)
+	for h := 0; h < services.ForecastDays*24; h++ {
+		forecast[start.Add(time.Duration(h)*time.Hour).Unix()] = value
+	}
+	return forecast, nil
+}
+
+// useTestWeather replaces the forecast provider for the duration of the test
+func useTestWeather(t *testing.T, provider services.WeatherProvider) {
+	original := weather
+	weather = provider
+	t.Cleanup(func() { weather = original })
+}
+
+func TestCalendarHandler_CloudCover(t *testing.T) {
+	useTestWeather(t, fakeWeather{cover: 85})
+
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&weather=clouds", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := unfoldICal(w.Body.String())
+	// Roughly two events per day are within the forecast; the rest of the
+	// 44 days are not annotated
+	annotated := strings.Count(body, "CATEGORIES:Visibility: unlikely")
+	if annotated < 12 || annotated > 16 {
+		t.Errorf("expected about 14 annotated events, got %d", annotated)
+	}
+	if !strings.Contains(body, `Cloud cover: 85% (forecast)`) {
+		t.Error("expected cloud cover in event descriptions")
+	}
+}
+
+func TestCalendarHandler_AirQuality(t *testing.T) {
+	useTestWeather(t, fakeWeather{cover: 10, aqi: 120})
+
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&weather=clouds,aqi", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	body := unfoldICal(w.Body.String())
+	// Only sunrises within the forecast get the air quality
+	annotated := strings.Count(body, "Air quality: AQI 120\\, unhealthy for sensitive groups (forecast)")
+	if annotated < 6 || annotated > 8 {
+		t.Errorf("expected about 7 sunrises with air quality, got %d", annotated)
+	}
+	if !strings.Contains(body, "CATEGORIES:Visibility: likely") {
+		t.Error("expected cloud cover annotations too")
+	}
+}
+
+func TestCalendarHandler_AirQualityUnavailable(t *testing.T) {
+	useTestWeather(t, fakeWeather{cover: 10})
+
+	w := httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&weather=clouds,aqi", nil))
+
+	body := w.Body.String()
+	if w.Code != http.StatusOK || strings.Contains(body, "Air quality") || !strings.Contains(body, "CATEGORIES:Visibility: likely") {
+		t.Error("expected cloud cover annotations without air quality")
+	}
+}
+
+func TestCalendarHandler_CloudCoverUnavailable(t *testing.T) {
+	useTestWeather(t, fakeWeather{err: errors.New("upstream down")})
+
+	req := httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&weather=clouds", nil)
+	w := httptest.NewRecorder()
+
+	CalendarHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected calendar without forecast, got status %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "Cloud cover") || !strings.Contains(w.Body.String(), "BEGIN:VEVENT") {
+		t.Error("expected unannotated events")
+	}
+}
+
+func TestCalendarHandler_CloudCoverNotRequested(t *testing.T) {
+	useTestWeather(t, fakeWeather{err: errors.New("should not be called")})
+
+	w := httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683", nil))
+
+	if strings.Contains(w.Body.String(), "CATEGORIES") {
+		t.Error("expected no annotations without weather=clouds")
+	}
+
+	w = httptest.NewRecorder()
+	CalendarHandler(w, httptest.NewRequest("GET", "/calendar.ics?lat=55.6761&lng=12.5683&weather=rain", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for unknown weather option, got %d", w.Code)
+	}
+}
@@ -3,13 +3,19 @@ package handlers
 import (
 	"embed"
 	"html/template"
+	"log"
 	"net/http"
+
+	"calsun/config"
 )
 
 //go:embed templates/*
 var templatesFS embed.FS
 
-var indexTemplate *template.Template
+var (
+	indexTemplate  *template.Template
+	statusTemplate *template.Template
+)
 
 func init() {
 	var err error
@@ -17,17 +23,126 @@ func init() {
 	if err != nil {
 		panic("failed to parse index template: " + err.Error())
 	}
+	statusTemplate, err = template.ParseFS(templatesFS, "templates/status.html")
+	if err != nil {
+		panic("failed to parse status template: " + err.Error())
+	}
 }
 
-// WebHandler serves the main web UI
+// indexData is the template data for the web UI
+type indexData struct {
+	Site         config.Site
+	BasePath     string // Prefix for the app's own URLs (e.g., "/calsun")
+	Prefill      *prefillData
+	Integrations []integrationLink // Push providers offered in the result panel
+	EmailEnabled bool              // Offer email digest subscriptions
+	PushEnabled  bool              // Offer browser push alerts
+}
+
+// statusData is the template data for status pages shown after following a
+// link from an email or an OAuth consent screen
+type statusData struct {
+	Site           config.Site
+	BasePath       string
+	Heading        string
+	Message        string
+	SubscriptionID string // Calendar push subscription, shown with a "Stop syncing" button
+	Form           *statusForm
+}
+
+// statusForm is a single-button form on a status page
+type statusForm struct {
+	Action string
+	Button string
+}
+
+// prefillData holds the configuration read from the page's own query string,
+// used to prefill the form. It is rendered into the page as JSON.
+type prefillData struct {
+	Lat     float64           `json:"lat"`
+	Lng     float64           `json:"lng"`
+	Name    string            `json:"name,omitempty"`
+	Exclude string            `json:"exclude,omitempty"`
+	Options map[string]string `json:"options,omitempty"` // Advanced options by parameter name
+}
+
+// parsePrefill reads a configuration from the query string. Invalid values are
+// ignored rather than rejected, since the form lets the user correct them.
+// Returns nil if no valid location was given.
+func parsePrefill(r *http.Request) *prefillData {
+	q := r.URL.Query()
+	if q.Get(latParam.Name) == "" || q.Get(lngParam.Name) == "" {
+		return nil
+	}
+
+	lat, errMsg := latParam.parseFloat(q)
+	if errMsg != "" {
+		return nil
+	}
+	lng, errMsg := lngParam.parseFloat(q)
+	if errMsg != "" {
+		return nil
+	}
+
+	prefill := &prefillData{
+		Lat:     lat,
+		Lng:     lng,
+		Name:    q.Get(nameParam.Name),
+		Options: make(map[string]string),
+	}
+
+	if exclude, errMsg := excludeParam.parseEnum(q); errMsg == "" {
+		prefill.Exclude = exclude
+	}
+
+	for _, p := range calendarParamDefs {
+		if !p.Advanced || q.Get(p.Name) == "" {
+			continue
+		}
+		if errMsg := p.validate(q); errMsg == "" {
+			prefill.Options[p.Name] = q.Get(p.Name)
+		}
+	}
+
+	return prefill
+}
+
+// WebHandler serves the main web UI. The page's query string accepts the
+// calendar parameters to prefill the form (see parsePrefill).
 func WebHandler(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
 		http.NotFound(w, r)
 		return
 	}
 
+	data := indexData{
+		Site:         cfg.Site,
+		BasePath:     cfg.Server.BasePath,
+		Prefill:      parsePrefill(r),
+		Integrations: enabledIntegrations(),
+		EmailEnabled: emailDigester != nil,
+		PushEnabled:  pushNotifier != nil,
+	}
+
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := indexTemplate.Execute(w, nil); err != nil {
+	if err := indexTemplate.Execute(w, data); err != nil {
 		http.Error(w, "failed to render template", http.StatusInternalServerError)
 	}
 }
+
+// DisabledHandler responds 410 Gone for pages turned off on this server
+// (the web UI in headless mode), so clients stop requesting them
+func DisabledHandler(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, "this page is disabled on this server; see /api/options for the available endpoints", http.StatusGone)
+}
+
+// renderStatusPage writes a status page
+func renderStatusPage(w http.ResponseWriter, status int, data statusData) {
+	data.Site = cfg.Site
+	data.BasePath = cfg.Server.BasePath
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := statusTemplate.Execute(w, data); err != nil {
+		log.Printf("render status page: %v", err)
+	}
+}
@@ -3,8 +3,11 @@ package handlers
 import (
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"strings"
 	"testing"
+
+	"calsun/config"
 )
 
 func TestWebHandler_ServesHTML(t *testing.T) {
@@ -58,13 +61,18 @@ func TestWebHandler_ContainsRequiredElements(t *testing.T) {
 	body := w.Body.String()
 
 	requiredElements := []string{
-		`id="address"`,        // Location input
-		`id="calForm"`,        // Form
-		`id="result"`,         // Result section
-		`id="copyBtn"`,        // Copy button
-		`id="subscribeBtn"`,   // Subscribe button
-		`name="events"`,       // Radio buttons
-		`nominatim`,           // Geocoding reference
+		`id="address"`,          // Location input
+		`id="calForm"`,          // Form
+		`id="result"`,           // Result section
+		`id="copyBtn"`,          // Copy button
+		`id="subscribeBtn"`,     // Subscribe button
+		`name="events"`,         // Radio buttons
+		`id="advancedFields"`,   // Advanced options built from /api/options
+		`id="suggestions"`,      // Location suggestion list
+		`/api/geocode/suggest`,  // Server-side geocoding proxy
+		`id="today"`,            // Today view
+		`/manifest.webmanifest`, // PWA manifest
+		`/sw.js`,                // Service worker registration
 	}
 
 	for _, elem := range requiredElements {
@@ -73,3 +81,159 @@ func TestWebHandler_ContainsRequiredElements(t *testing.T) {
 		}
 	}
 }
+
+func TestWebHandler_Prefill(t *testing.T) {
+	req := httptest.NewRequest("GET", "/?lat=55.6761&lng=12.5683&name=Copenhagen&exclude=sunset&days=7", nil)
+	w := httptest.NewRecorder()
+
+	WebHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", w.Code)
+	}
+
+	body := w.Body.String()
+	expected := `const PREFILL = {"lat":55.6761,"lng":12.5683,"name":"Copenhagen","exclude":"sunset","options":{"days":"7"}};`
+	if !strings.Contains(body, expected) {
+		t.Errorf("expected prefill data %s", expected)
+	}
+}
+
+func TestWebHandler_NoPrefill(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+
+	WebHandler(w, req)
+
+	if !regexp.MustCompile(`const PREFILL =\s*null\s*;`).MatchString(w.Body.String()) {
+		t.Error("expected null prefill without query parameters")
+	}
+}
+
+func TestParsePrefill(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		expected *prefillData
+	}{
+		{"no location", "/?name=Copenhagen", nil},
+		{"invalid lat", "/?lat=100&lng=12.5", nil},
+		{
+			"invalid options ignored",
+			"/?lat=55.5&lng=12.5&exclude=noon&days=1000",
+			&prefillData{Lat: 55.5, Lng: 12.5, Options: map[string]string{}},
+		},
+		{
+			"full configuration",
+			"/?lat=55.5&lng=12.5&name=Home&exclude=sunrise&days=60",
+			&prefillData{Lat: 55.5, Lng: 12.5, Name: "Home", Exclude: "sunrise", Options: map[string]string{"days": "60"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parsePrefill(httptest.NewRequest("GET", tt.url, nil))
+
+			if (got == nil) != (tt.expected == nil) {
+				t.Fatalf("expected %+v, got %+v", tt.expected, got)
+			}
+			if got == nil {
+				return
+			}
+			if got.Lat != tt.expected.Lat || got.Lng != tt.expected.Lng || got.Name != tt.expected.Name || got.Exclude != tt.expected.Exclude {
+				t.Errorf("expected %+v, got %+v", tt.expected, got)
+			}
+			if len(got.Options) != len(tt.expected.Options) || got.Options["days"] != tt.expected.Options["days"] {
+				t.Errorf("expected options %v, got %v", tt.expected.Options, got.Options)
+			}
+		})
+	}
+}
+
+func TestWebHandler_PrefillEscaping(t *testing.T) {
+	req := httptest.NewRequest("GET", "/?lat=1&lng=2&name=%3C%2Fscript%3E%3Cscript%3Ealert(1)%3C%2Fscript%3E", nil)
+	w := httptest.NewRecorder()
+
+	WebHandler(w, req)
+
+	if strings.Contains(w.Body.String(), "<script>alert(1)") {
+		t.Error("prefill name must be escaped")
+	}
+}
+
+func TestWebHandler_Branding(t *testing.T) {
+	original := cfg
+	t.Cleanup(func() { Configure(original) })
+
+	branded := config.Default()
+	branded.Site = config.Site{
+		Title:       "Acme Sun",
+		Tagline:     "Sun times for Acme staff",
+		LogoURL:     "https://acme.example/logo.svg",
+		AccentColor: "#ff6600",
+		FooterLinks: []config.Link{{Label: "Privacy", URL: "https://acme.example/privacy"}},
+		Message:     "<b>Maintenance</b> on Sunday",
+	}
+	Configure(branded)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+
+	WebHandler(w, req)
+
+	body := w.Body.String()
+
+	expected := []string{
+		"<title>Acme Sun - Sunrise & Sunset Calendar</title>",
+		"<h1>Acme Sun</h1>",
+		"Sun times for Acme staff",
+		`src="https://acme.example/logo.svg"`,
+		"--color-primary: #ff6600;",
+		`<a href="https://acme.example/privacy">Privacy</a>`,
+		"&lt;b&gt;Maintenance&lt;/b&gt; on Sunday",
+	}
+	for _, elem := range expected {
+		if !strings.Contains(body, elem) {
+			t.Errorf("response should contain '%s'", elem)
+		}
+	}
+}
+
+func TestWebHandler_BasePath(t *testing.T) {
+	original := cfg
+	t.Cleanup(func() { Configure(original) })
+	mounted := config.Default()
+	mounted.Server.BasePath = "/calsun"
+	Configure(mounted)
+
+	w := httptest.NewRecorder()
+	WebHandler(w, httptest.NewRequest("GET", "/", nil))
+
+	body := w.Body.String()
+	for _, elem := range []string{
+		`href="/calsun/manifest.webmanifest"`,
+		`href="/calsun/static/icon.svg"`,
+		`const BASE_PATH = "/calsun";`,
+		"return `${window.location.origin}${BASE_PATH}/?",
+	} {
+		if !strings.Contains(body, elem) {
+			t.Errorf("response should contain '%s'", elem)
+		}
+	}
+	// Share links point at the mounted app, not the proxy root
+	if strings.Contains(body, "${window.location.origin}/") {
+		t.Error("generated URLs should include the base path")
+	}
+}
+
+func TestDisabledHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	DisabledHandler(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusGone {
+		t.Errorf("expected status 410, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "/api/options") {
+		t.Error("expected a pointer to the available endpoints")
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"calsun/services"
+)
+
+var (
+	whenEventParam = paramDef{
+		Name:        "event",
+		Type:        paramTypeEnum,
+		Values:      []string{services.EventSunrise, services.EventSunset},
+		Default:     services.EventSunrise,
+		Description: "Event whose local time to search for",
+	}
+	whenTimeParam = paramDef{
+		Name:        "time",
+		Type:        paramTypeString,
+		Required:    true,
+		Description: "Local clock time to reach (HH:MM, e.g., 06:30)",
+	}
+	whenDirectionParam = paramDef{
+		Name:        "direction",
+		Type:        paramTypeEnum,
+		Values:      []string{"before", "after"},
+		Default:     "before",
+		Description: "Find the first date the event is at or before the time, or at or after it",
+	}
+	whenHorizonParam = paramDef{
+		Name:        "horizon",
+		Type:        paramTypeInteger,
+		Min:         bound(1),
+		Max:         bound(services.WhenSearchDays),
+		Default:     services.WhenSearchDays,
+		Description: "Number of days to search ahead",
+	}
+)
+
+// whenParamDefs lists the parameters accepted by WhenHandler
+var whenParamDefs = []paramDef{
+	latParam,
+	lngParam,
+	nameParam,
+	whenEventParam,
+	whenTimeParam,
+	whenDirectionParam,
+	whenHorizonParam,
+	dateParam,
+}
+
+// whenResponse is the JSON body returned by WhenHandler. Date, EventTime, and
+// DaysUntil are left out if no date within the horizon matches.
+type whenResponse struct {
+	Location    string     `json:"location"`
+	Timezone    string     `json:"timezone"`
+	Event       string     `json:"event"`
+	Time        string     `json:"time"`
+	Direction   string     `json:"direction"`
+	From        string     `json:"from"`
+	HorizonDays int        `json:"horizon_days"`
+	Found       bool       `json:"found"`
+	Date        string     `json:"date,omitempty"`
+	EventTime   *time.Time `json:"event_time,omitempty"` // RFC 3339 in the location's timezone
+	DaysUntil   *int       `json:"days_until,omitempty"` // 0 if the date searched from already matches
+}
+
+// WhenHandler answers on which date the sunrise or sunset first reaches a
+// local clock time, e.g. "when is sunrise first at or before 06:30?", by
+// searching forward from today (or date) up to a year ahead
+func WhenHandler(w http.ResponseWriter, r *http.Request) {
+	params, errMsg := parseCalendarParams(r)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	event, errMsg := whenEventParam.parseEnum(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	clockStr := q.Get(whenTimeParam.Name)
+	if clockStr == "" {
+		http.Error(w, "time is required (e.g., 06:30)", http.StatusBadRequest)
+		return
+	}
+	clock, err := time.Parse("15:04", clockStr)
+	if err != nil {
+		http.Error(w, "time must be a local time like 06:30", http.StatusBadRequest)
+		return
+	}
+	direction, errMsg := whenDirectionParam.parseEnum(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	horizon, errMsg := whenHorizonParam.parseInt(q)
+	if errMsg != "" {
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+
+	tz := services.GetTimezone(params.lat, params.lng)
+	from := params.now.In(tz)
+	if !params.date.IsZero() {
+		from = params.date
+	}
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, tz)
+	sinceMidnight := time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute
+
+	resp := whenResponse{
+		Location:    locationName(params),
+		Timezone:    tz.String(),
+		Event:       event,
+		Time:        clock.Format("15:04"),
+		Direction:   direction,
+		From:        from.Format("2006-01-02"),
+		HorizonDays: horizon,
+	}
+	day, offset, ok := services.FindSunEventDate(params.lat, params.lng, event, sinceMidnight, direction == "after", from, horizon)
+	if ok {
+		match := day.Sunrise
+		if event == services.EventSunset {
+			match = day.Sunset
+		}
+		resp.Found = true
+		resp.Date = from.AddDate(0, 0, offset).Format("2006-01-02")
+		resp.EventTime = localEventTime(match, tz)
+		resp.DaysUntil = &offset
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func decodeWhen(t *testing.T, query string) whenResponse {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/api/when?"+query, nil)
+	w := httptest.NewRecorder()
+
+	WhenHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp whenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func TestWhenHandler_SunriseBefore(t *testing.T) {
+	resp := decodeWhen(t, "lat=55.6761&lng=12.5683&time=06:30&date=2025-01-01")
+
+	if !resp.Found || resp.Event != "sunrise" || resp.Direction != "before" {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	// Copenhagen's sunrise reaches 06:30 in March, before the clocks change
+	if !strings.HasPrefix(resp.Date, "2025-03-") {
+		t.Errorf("expected a date in March, got %s", resp.Date)
+	}
+	if resp.EventTime == nil || resp.EventTime.Format("15:04") > "06:30" {
+		t.Errorf("expected a sunrise at or before 06:30, got %v", resp.EventTime)
+	}
+	if resp.DaysUntil == nil || *resp.DaysUntil < 59 || *resp.DaysUntil > 89 {
+		t.Errorf("unexpected days until %v", resp.DaysUntil)
+	}
+}
+
+func TestWhenHandler_SunsetAfter(t *testing.T) {
+	resp := decodeWhen(t, "lat=55.6761&lng=12.5683&event=sunset&time=21:00&direction=after&date=2025-01-01")
+
+	if !resp.Found || resp.EventTime == nil || resp.EventTime.Format("15:04") < "21:00" {
+		t.Fatalf("expected a sunset at or after 21:00, got %+v", resp)
+	}
+	if !strings.HasPrefix(resp.Date, "2025-05-") {
+		t.Errorf("expected a date in May, got %s", resp.Date)
+	}
+}
+
+func TestWhenHandler_AlreadyMatching(t *testing.T) {
+	resp := decodeWhen(t, "lat=55.6761&lng=12.5683&time=06:30&date=2025-06-21")
+
+	if !resp.Found || resp.Date != "2025-06-21" || resp.DaysUntil == nil || *resp.DaysUntil != 0 {
+		t.Errorf("expected the start date to match, got %+v", resp)
+	}
+}
+
+func TestWhenHandler_NotFound(t *testing.T) {
+	// Copenhagen's sunrise is never as early as 03:00
+	resp := decodeWhen(t, "lat=55.6761&lng=12.5683&time=03:00&horizon=30")
+
+	if resp.Found || resp.Date != "" || resp.EventTime != nil || resp.HorizonDays != 30 {
+		t.Errorf("expected no match, got %+v", resp)
+	}
+}
+
+func TestWhenHandler_Validation(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"lat=55.6761&lng=12.5683", "time is required"},
+		{"lat=55.6761&lng=12.5683&time=6.30", "time must be"},
+		{"lat=55.6761&lng=12.5683&time=25:00", "time must be"},
+		{"lat=55.6761&lng=12.5683&time=06:30&event=noon", "event must be"},
+		{"lat=55.6761&lng=12.5683&time=06:30&direction=around", "direction must be"},
+		{"lat=55.6761&lng=12.5683&time=06:30&horizon=400", "horizon must be between"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/when?"+tt.query, nil)
+		w := httptest.NewRecorder()
+
+		WhenHandler(w, req)
+
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s: expected 400 with %q, got %d: %s", tt.query, tt.want, w.Code, w.Body.String())
+		}
+	}
+}
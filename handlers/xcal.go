@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"time"
+
+	"calsun/services"
+)
+
+// xcalNamespace is the XML namespace of xCal documents (RFC 6321)
+const xcalNamespace = "urn:ietf:params:xml:ns:icalendar-2.0"
+
+// xcalDocument is an xCal calendar: the iCalendar object model in XML, with
+// each property an element holding typed value elements
+type xcalDocument struct {
+	XMLName  xml.Name     `xml:"urn:ietf:params:xml:ns:icalendar-2.0 icalendar"`
+	Calendar xcalCalendar `xml:"vcalendar"`
+}
+
+// xcalCalendar is the vcalendar component with its events
+type xcalCalendar struct {
+	Properties xcalProperties `xml:"properties"`
+	Events     []xcalEvent    `xml:"components>vevent"`
+}
+
+// xcalEvent is a vevent component
+type xcalEvent struct {
+	Properties xcalProperties `xml:"properties"`
+}
+
+// xcalProperties lists a component's properties, each named by its XMLName
+type xcalProperties struct {
+	List []xcalProperty
+}
+
+// xcalProperty is a property, e.g., <summary><text>Sunrise</text></summary>
+type xcalProperty struct {
+	XMLName xml.Name
+	Values  []xcalValue
+}
+
+// xcalValue is a property value, named by its type (text, date, date-time)
+type xcalValue struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+// add appends a property with values of one type, leaving out empty values
+func (p *xcalProperties) add(name, valueType string, values ...string) {
+	prop := xcalProperty{XMLName: xml.Name{Local: name}}
+	for _, v := range values {
+		if v != "" {
+			prop.Values = append(prop.Values, xcalValue{XMLName: xml.Name{Local: valueType}, Value: v})
+		}
+	}
+	if len(prop.Values) > 0 {
+		p.List = append(p.List, prop)
+	}
+}
+
+// serializeXCal writes the events as an xCal (RFC 6321) document with the
+// same properties as the iCal output: UTC date-times, and dates for all-day
+// events
+func serializeXCal(name string, tz *time.Location, events []services.CalendarEvent, stamp time.Time) ([]byte, error) {
+	doc := xcalDocument{}
+	cal := &doc.Calendar
+	cal.Properties.add("prodid", "text", calendarProductID)
+	cal.Properties.add("version", "text", "2.0")
+	cal.Properties.add("method", "text", "PUBLISH")
+	cal.Properties.add("name", "text", name)
+	// Non-standard properties have values of unknown type
+	cal.Properties.add("x-wr-calname", "unknown", name)
+
+	dtstamp := stamp.UTC().Format("2006-01-02T15:04:05Z")
+	cal.Events = make([]xcalEvent, 0, len(events))
+	for _, event := range events {
+		var e xcalEvent
+		e.Properties.add("uid", "text", event.UID)
+		e.Properties.add("dtstamp", "date-time", dtstamp)
+		if event.AllDay {
+			e.Properties.add("dtstart", "date", event.Start.Format(time.DateOnly))
+			e.Properties.add("dtend", "date", event.End.Format(time.DateOnly))
+		} else {
+			e.Properties.add("dtstart", "date-time", event.Start.UTC().Format("2006-01-02T15:04:05Z"))
+			e.Properties.add("dtend", "date-time", event.End.UTC().Format("2006-01-02T15:04:05Z"))
+		}
+		e.Properties.add("summary", "text", event.Summary)
+		e.Properties.add("description", "text", event.Description)
+		e.Properties.add("location", "text", event.Location)
+		e.Properties.add("categories", "text", event.Categories...)
+		cal.Events = append(cal.Events, e)
+	}
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"calsun/services"
+)
+
+// xcalTestDocument reads back the parts of an xCal document the tests check
+type xcalTestDocument struct {
+	XMLName    xml.Name
+	Properties xcalTestProperties `xml:"vcalendar>properties"`
+	Events     []struct {
+		Properties xcalTestProperties `xml:"properties"`
+	} `xml:"vcalendar>components>vevent"`
+}
+
+type xcalTestProperties struct {
+	List []struct {
+		XMLName xml.Name
+		Values  []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	} `xml:",any"`
+}
+
+// get returns a property's values as "type:value"
+func (p xcalTestProperties) get(name string) []string {
+	var values []string
+	for _, prop := range p.List {
+		if prop.XMLName.Local == name {
+			for _, v := range prop.Values {
+				values = append(values, v.XMLName.Local+":"+v.Value)
+			}
+		}
+	}
+	return values
+}
+
+func TestSerializeXCal(t *testing.T) {
+	tz := services.GetTimezone(55.6761, 12.5683)
+	events := []services.CalendarEvent{
+		{
+			UID:         "sunset@calsun",
+			Summary:     "Sunset",
+			Start:       time.Date(2024, 6, 21, 19, 57, 0, 0, time.UTC),
+			End:         time.Date(2024, 6, 21, 19, 58, 0, 0, time.UTC),
+			Description: "Day length: 17h 32m\nAzimuth: 311°",
+			Location:    "Copenhagen",
+			Categories:  []string{"Sun", "Sunset"},
+		},
+		{
+			UID:     "perigee@calsun",
+			Summary: "Moon at perigee (357,175 km)",
+			Start:   time.Date(2024, 10, 17, 0, 0, 0, 0, tz),
+			End:     time.Date(2024, 10, 18, 0, 0, 0, 0, tz),
+			AllDay:  true,
+		},
+	}
+
+	body, err := serializeXCal("Sun Times", tz, events, time.Date(2024, 6, 1, 8, 30, 0, 0, tz))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc xcalTestDocument
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, body)
+	}
+	if doc.XMLName.Space != xcalNamespace || doc.XMLName.Local != "icalendar" {
+		t.Errorf("unexpected root element %v", doc.XMLName)
+	}
+	if got := doc.Properties.get("version"); strings.Join(got, ",") != "text:2.0" {
+		t.Errorf("version = %v, want text:2.0", got)
+	}
+	if got := doc.Properties.get("x-wr-calname"); strings.Join(got, ",") != "unknown:Sun Times" {
+		t.Errorf("x-wr-calname = %v, want unknown:Sun Times", got)
+	}
+	if len(doc.Events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(doc.Events))
+	}
+
+	timed := doc.Events[0].Properties
+	for _, tc := range []struct {
+		name string
+		want string
+	}{
+		{"uid", "text:sunset@calsun"},
+		{"dtstamp", "date-time:2024-06-01T06:30:00Z"},
+		{"dtstart", "date-time:2024-06-21T19:57:00Z"},
+		{"dtend", "date-time:2024-06-21T19:58:00Z"},
+		{"description", "text:Day length: 17h 32m\nAzimuth: 311°"},
+		{"location", "text:Copenhagen"},
+		{"categories", "text:Sun,text:Sunset"},
+	} {
+		if got := strings.Join(timed.get(tc.name), ","); got != tc.want {
+			t.Errorf("%s = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	// All-day events have date values, and empty properties are left out
+	allDay := doc.Events[1].Properties
+	if got := strings.Join(allDay.get("dtstart"), ","); got != "date:2024-10-17" {
+		t.Errorf("all-day dtstart = %q, want date:2024-10-17", got)
+	}
+	if got := strings.Join(allDay.get("dtend"), ","); got != "date:2024-10-18" {
+		t.Errorf("all-day dtend = %q, want date:2024-10-18", got)
+	}
+	if got := allDay.get("location"); got != nil {
+		t.Errorf("expected no location, got %v", got)
+	}
+}
+
+func TestUnifiedCalendarHandler_XCal(t *testing.T) {
+	req := httptest.NewRequest("GET", "/calendar?lat=55.6761&lng=12.5683&days=2", nil)
+	req.Header.Set("Accept", "application/calendar+xml")
+	w := httptest.NewRecorder()
+
+	UnifiedCalendarHandler(w, req)
+
+	if got := w.Header().Get("Content-Type"); got != "application/calendar+xml; charset=utf-8" {
+		t.Errorf("expected application/calendar+xml, got %q", got)
+	}
+	if got := w.Header().Get("Content-Disposition"); !strings.Contains(got, "calsun.xcs") {
+		t.Errorf("expected a .xcs download, got %q", got)
+	}
+	var doc xcalTestDocument
+	if err := xml.Unmarshal(w.Body.Bytes(), &doc); err != nil || len(doc.Events) == 0 {
+		t.Fatalf("expected xCal events, got %v:\n%s", err, w.Body.String())
+	}
+	if got := doc.Events[0].Properties.get("summary"); len(got) != 1 || !strings.HasPrefix(got[0], "text:Sunrise") {
+		t.Errorf("expected a sunrise first, got %v", got)
+	}
+}
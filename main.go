@@ -1,27 +1,191 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
+	"calsun/config"
 	"calsun/handlers"
+	"calsun/server"
+	"calsun/services"
 )
 
+// shutdownTimeout bounds how long in-flight requests may take to finish on
+// shutdown
+const shutdownTimeout = 10 * time.Second
+
 func main() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+	handlers.Configure(cfg)
+
+	// Event UIDs keep their domain unless the operator migrates it, so
+	// subscribed calendars don't show every event twice
+	if err := services.CheckUIDDomain(filepath.Join(cfg.DataDir, "uid.json"), cfg.UIDDomain, cfg.UIDMigrate); err != nil {
+		log.Fatalf("invalid UID domain: %v", err)
+	}
+	services.SetUIDDomain(cfg.UIDDomain)
+
+	// Place names in calendar URLs resolve to the same coordinates across restarts
+	places, err := services.OpenPlaceStore(filepath.Join(cfg.DataDir, "places.json"))
+	if err != nil {
+		log.Fatalf("failed to open place store: %v", err)
+	}
+	handlers.SetPlaceStore(places)
+
+	// Calendar push integrations
+	if cfg.Sync.GoogleEnabled() || cfg.Sync.MicrosoftEnabled() {
+		store, err := services.OpenSyncStore(filepath.Join(cfg.DataDir, "sync.json"))
+		if err != nil {
+			log.Fatalf("failed to open sync store: %v", err)
+		}
+		syncer := services.NewSyncer(store)
+		if cfg.Sync.GoogleEnabled() {
+			syncer.Register("google", services.NewGoogleCalendar(cfg.Sync.GoogleClientID, cfg.Sync.GoogleClientSecret))
+		}
+		if cfg.Sync.MicrosoftEnabled() {
+			syncer.Register("microsoft", services.NewOutlookCalendar(cfg.Sync.MicrosoftClientID, cfg.Sync.MicrosoftClientSecret, cfg.Sync.MicrosoftTenant))
+		}
+		handlers.SetSyncer(syncer)
+
+		services.RunEvery(context.Background(), cfg.Sync.Interval, syncer.SyncAll)
+		log.Printf("Calendar push enabled for %v, syncing every %s", syncer.Providers(), cfg.Sync.Interval)
+	}
+
+	// Daily digest webhooks
+	if len(cfg.Digest.Webhooks) > 0 {
+		targets := make([]services.DigestTarget, len(cfg.Digest.Webhooks))
+		for i, w := range cfg.Digest.Webhooks {
+			targets[i] = services.DigestTarget{Platform: w.Platform, URL: w.URL, Lat: w.Lat, Lng: w.Lng, Name: w.Name}
+		}
+		digests, err := services.OpenDigestSender(filepath.Join(cfg.DataDir, "digest.json"), targets, cfg.Digest.Time)
+		if err != nil {
+			log.Fatalf("failed to open digest state: %v", err)
+		}
+		services.RunEvery(context.Background(), time.Minute, digests.Tick)
+		log.Printf("Daily digest enabled for %d webhook(s)", len(targets))
+	}
+
+	// Aurora alert webhooks
+	if len(cfg.Aurora.Webhooks) > 0 {
+		targets := make([]services.DigestTarget, len(cfg.Aurora.Webhooks))
+		for i, w := range cfg.Aurora.Webhooks {
+			targets[i] = services.DigestTarget{Platform: w.Platform, URL: w.URL, Lat: w.Lat, Lng: w.Lng, Name: w.Name}
+		}
+		alerter := services.NewAuroraAlerter(targets, cfg.Aurora.Kp, services.NewSWPC(services.SWPCURL))
+		services.RunEvery(context.Background(), cfg.Aurora.Interval, alerter.Tick)
+		log.Printf("Aurora alerts enabled for %d webhook(s), polling every %s", len(targets), cfg.Aurora.Interval)
+	}
+
+	// Email digest subscriptions
+	if cfg.SMTP.Enabled() {
+		store, err := services.OpenEmailStore(filepath.Join(cfg.DataDir, "email.json"))
+		if err != nil {
+			log.Fatalf("failed to open email store: %v", err)
+		}
+		mailer := services.NewSMTPMailer(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.SMTP.From)
+		emails := services.NewEmailDigester(store, mailer, cfg.Digest.Time, cfg.Site.Title)
+		handlers.SetEmailDigester(emails)
+
+		services.RunEvery(context.Background(), time.Minute, emails.Tick)
+		log.Printf("Email digests enabled via %s", cfg.SMTP.Host)
 	}
 
-	// Routes
-	http.HandleFunc("/", handlers.WebHandler)
-	http.HandleFunc("/calendar.ics", handlers.CalendarHandler)
+	// Browser push alerts
+	if cfg.Push.Enabled() {
+		key, err := services.LoadOrCreateVAPIDKey(filepath.Join(cfg.DataDir, "vapid.json"))
+		if err != nil {
+			log.Fatalf("failed to load VAPID key: %v", err)
+		}
+		store, err := services.OpenPushStore(filepath.Join(cfg.DataDir, "push.json"))
+		if err != nil {
+			log.Fatalf("failed to open push store: %v", err)
+		}
+		notifier := services.NewPushNotifier(store, services.NewWebPush(key, cfg.Push.Subject))
+		handlers.SetPushNotifier(notifier)
 
-	log.Printf("CalSun server starting on port %s", port)
-	log.Printf("Open http://localhost:%s in your browser", port)
+		services.RunEvery(context.Background(), time.Minute, notifier.Tick)
+		log.Printf("Browser push alerts enabled")
+	}
+
+	// InfluxDB metrics push
+	if cfg.Influx.Enabled() {
+		locations := make([]services.InfluxLocation, len(cfg.Influx.Locations))
+		for i, l := range cfg.Influx.Locations {
+			locations[i] = services.InfluxLocation{Lat: l.Lat, Lng: l.Lng, Name: l.Name}
+		}
+		influx := services.NewInfluxPusher(cfg.Influx.URL, cfg.Influx.Token, locations)
+		services.RunEvery(context.Background(), cfg.Influx.Interval, influx.Tick)
+		log.Printf("InfluxDB push enabled for %d location(s), every %s", len(locations), cfg.Influx.Interval)
+	}
+
+	// Routes of the endpoints, and of the features set up above
+	var handler http.Handler = handlers.NewMux()
 
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
+	// Usage analytics
+	var analytics *services.Analytics
+	if cfg.Analytics.Enabled {
+		analytics, err = services.OpenAnalytics(filepath.Join(cfg.DataDir, "analytics.json"), cfg.Analytics.Retention)
+		if err != nil {
+			log.Fatalf("failed to open analytics: %v", err)
+		}
+		handlers.SetAnalytics(analytics)
+		handler = handlers.WithAnalytics(handler)
+		services.RunEvery(context.Background(), 5*time.Minute, analytics.Flush)
+		log.Printf("Usage analytics enabled, keeping %d days", cfg.Analytics.Retention)
+	}
+
+	srv, err := server.New(":"+cfg.Port, cfg.Server, handler)
+	if err != nil {
+		log.Fatalf("invalid server configuration: %v", err)
+	}
+	listeners, err := srv.Listen()
+	if err != nil {
 		log.Fatal(err)
 	}
+
+	scheme := "http"
+	if cfg.Server.TLSEnabled() {
+		scheme = "https"
+	}
+	if listeners.Activated {
+		log.Printf("CalSun server starting on socket-activated %s (%s)", listeners.TCP.Addr(), strings.Join(srv.Protocols(), ", "))
+	} else {
+		log.Printf("CalSun server starting on port %s (%s)", cfg.Port, strings.Join(srv.Protocols(), ", "))
+		log.Printf("Open %s://localhost:%s in your browser", scheme, cfg.Port)
+	}
+
+	// Finish in-flight requests on shutdown, so a restart (with the socket
+	// held by systemd) drops no connections
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("shutdown: %v", err)
+		}
+	}()
+
+	if err := srv.Serve(listeners); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+	<-stopped
+	if analytics != nil {
+		analytics.Flush(context.Background())
+	}
+	log.Printf("CalSun server stopped")
 }
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"calsun/config"
+)
+
+// clfTimeFormat is the timestamp format of the Common Log Format
+const clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// accessLogger writes one line per request to an access log, in the Common
+// Log Format or as JSON. Successful requests are sampled; errors are always
+// logged.
+type accessLogger struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format string
+	sample float64
+	random func() float64 // Returns a value in [0, 1), for sampling
+}
+
+// newAccessLogger creates an access logger for cfg, opening the log file for
+// appending if a path is set
+func newAccessLogger(cfg config.AccessLog) (*accessLogger, error) {
+	var w io.Writer = os.Stdout
+	if cfg.Path != "" {
+		f, err := os.OpenFile(cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open access log: %w", err)
+		}
+		w = f
+	}
+	return &accessLogger{w: w, format: cfg.Format, sample: cfg.Sample, random: rand.Float64}, nil
+}
+
+// accessLogEntry is a logged request, as written in the JSON format
+type accessLogEntry struct {
+	Time       time.Time `json:"time"`
+	Remote     string    `json:"remote"`
+	Method     string    `json:"method"`
+	URI        string    `json:"uri"`
+	Proto      string    `json:"proto"`
+	Status     int       `json:"status"`
+	Bytes      int64     `json:"bytes"`
+	DurationMS float64   `json:"duration_ms"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+}
+
+// wrap logs the requests served by next
+func (l *accessLogger) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if rec.status < 400 && l.random() >= l.sample {
+			return
+		}
+		remote, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			remote = r.RemoteAddr
+		}
+		l.write(accessLogEntry{
+			Time:       start,
+			Remote:     remote,
+			Method:     r.Method,
+			URI:        r.RequestURI,
+			Proto:      r.Proto,
+			Status:     rec.status,
+			Bytes:      rec.bytes,
+			DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+			UserAgent:  r.UserAgent(),
+		})
+	})
+}
+
+// write formats and writes a log line
+func (l *accessLogger) write(e accessLogEntry) {
+	var line []byte
+	if l.format == "json" {
+		line, _ = json.Marshal(e)
+		line = append(line, '\n')
+	} else {
+		size := "-"
+		if e.Bytes > 0 {
+			size = fmt.Sprint(e.Bytes)
+		}
+		line = fmt.Appendf(nil, "%s - - [%s] %q %d %s\n",
+			e.Remote, e.Time.Format(clfTimeFormat), e.Method+" "+e.URI+" "+e.Proto, e.Status, size)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(line)
+}
+
+// statusRecorder records the status code and body size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap returns the underlying response writer, so http.ResponseController
+// can reach its optional interfaces (e.g., flushing)
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
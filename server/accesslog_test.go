@@ -0,0 +1,101 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"calsun/config"
+)
+
+// logRequests serves targets through an access logger and returns the log
+func logRequests(t *testing.T, logger *accessLogger, targets ...string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	logger.w = &buf
+	handler := logger.wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	for _, target := range targets {
+		req := httptest.NewRequest("GET", target, nil)
+		req.RemoteAddr = "203.0.113.7:51234"
+		req.Header.Set("User-Agent", "Calendar/1.0")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	return buf.String()
+}
+
+func TestAccessLogger_Common(t *testing.T) {
+	logger := &accessLogger{format: "common", sample: 1, random: func() float64 { return 0.5 }}
+	got := logRequests(t, logger, "/calendar.ics?lat=55.6761&lng=12.5683")
+
+	pattern := regexp.MustCompile(`^203\.0\.113\.7 - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /calendar\.ics\?lat=55\.6761&lng=12\.5683 HTTP/1\.1" 200 5\n$`)
+	if !pattern.MatchString(got) {
+		t.Errorf("unexpected Common Log Format line %q", got)
+	}
+}
+
+func TestAccessLogger_JSON(t *testing.T) {
+	logger := &accessLogger{format: "json", sample: 1, random: func() float64 { return 0.5 }}
+	got := logRequests(t, logger, "/missing")
+
+	var entry accessLogEntry
+	if err := json.Unmarshal([]byte(got), &entry); err != nil {
+		t.Fatalf("expected a JSON line, got %q: %v", got, err)
+	}
+	if entry.Remote != "203.0.113.7" || entry.Method != "GET" || entry.URI != "/missing" || entry.Status != http.StatusNotFound {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if entry.UserAgent != "Calendar/1.0" || entry.Bytes == 0 || entry.Time.IsZero() {
+		t.Errorf("expected user agent, size, and time, got %+v", entry)
+	}
+}
+
+func TestAccessLogger_Sampling(t *testing.T) {
+	// A random value above the rate skips successful requests, not errors
+	logger := &accessLogger{format: "common", sample: 0.1, random: func() float64 { return 0.5 }}
+	got := logRequests(t, logger, "/calendar.ics", "/missing", "/calendar.ics")
+
+	if lines := strings.Count(got, "\n"); lines != 1 || !strings.Contains(got, "/missing") {
+		t.Errorf("expected only the error to be logged, got %q", got)
+	}
+
+	logger.random = func() float64 { return 0.05 }
+	if got := logRequests(t, logger, "/calendar.ics"); got == "" {
+		t.Error("expected a sampled request to be logged")
+	}
+}
+
+func TestNew_AccessLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	cfg := config.Server{AccessLog: config.AccessLog{Format: "common", Path: path, Sample: 1}}
+	srv, err := New("127.0.0.1:0", cfg, protoHandler)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	srv.http.Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/today", nil))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"GET /today HTTP/1.1" 200`) {
+		t.Errorf("expected the request in the log file, got %q", data)
+	}
+
+	cfg.AccessLog.Path = filepath.Join(t.TempDir(), "missing", "access.log")
+	if _, err := New("127.0.0.1:0", cfg, protoHandler); err == nil {
+		t.Error("expected error for an unwritable access log")
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket
+// activation (SD_LISTEN_FDS_START)
+const listenFDsStart = 3
+
+// activationCount returns the number of sockets passed to process pid by
+// systemd socket activation, read from the LISTEN_PID and LISTEN_FDS
+// variables. Returns 0 if the process was not socket-activated.
+func activationCount(getenv func(string) string, pid int) (int, error) {
+	if getenv("LISTEN_PID") == "" {
+		return 0, nil
+	}
+	listenPID, err := strconv.Atoi(getenv("LISTEN_PID"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid LISTEN_PID %q", getenv("LISTEN_PID"))
+	}
+	if listenPID != pid {
+		// Meant for another process (e.g., a parent shell)
+		return 0, nil
+	}
+	count, err := strconv.Atoi(getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return 0, fmt.Errorf("invalid LISTEN_FDS %q", getenv("LISTEN_FDS"))
+	}
+	return count, nil
+}
+
+// activationFiles opens the count sockets passed by systemd
+func activationFiles(count int) []*os.File {
+	files := make([]*os.File, count)
+	for i := range files {
+		fd := listenFDsStart + i
+		files[i] = os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+	}
+	return files
+}
+
+// activationListeners turns activated socket files into the first stream
+// listener (TCP) and, if present, the first datagram socket (UDP, for
+// HTTP/3). The files are closed; the returned sockets hold duplicates.
+func activationListeners(files []*os.File) (net.Listener, net.PacketConn, error) {
+	var tcp net.Listener
+	var udp net.PacketConn
+	for _, f := range files {
+		if l, err := net.FileListener(f); err == nil {
+			if tcp == nil {
+				tcp = l
+			} else {
+				l.Close()
+			}
+		} else if c, err := net.FilePacketConn(f); err == nil {
+			if udp == nil {
+				udp = c
+			} else {
+				c.Close()
+			}
+		}
+		f.Close()
+	}
+
+	if tcp == nil {
+		if udp != nil {
+			udp.Close()
+		}
+		return nil, nil, fmt.Errorf("no stream socket among %d activated sockets", len(files))
+	}
+	return tcp, udp, nil
+}
+
+// unsetActivationEnv removes the socket activation variables, so child
+// processes do not mistake the sockets for their own
+func unsetActivationEnv() {
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"net"
+	"os"
+	"testing"
+)
+
+func TestActivationCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		vars    map[string]string
+		want    int
+		wantErr bool
+	}{
+		{"not activated", nil, 0, false},
+		{"other process", map[string]string{"LISTEN_PID": "1", "LISTEN_FDS": "1"}, 0, false},
+		{"two sockets", map[string]string{"LISTEN_PID": "42", "LISTEN_FDS": "2"}, 2, false},
+		{"invalid pid", map[string]string{"LISTEN_PID": "me", "LISTEN_FDS": "1"}, 0, true},
+		{"missing count", map[string]string{"LISTEN_PID": "42"}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := activationCount(func(key string) string { return tt.vars[key] }, 42)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if count != tt.want {
+				t.Errorf("expected %d sockets, got %d", tt.want, count)
+			}
+		})
+	}
+}
+
+func TestActivationListeners(t *testing.T) {
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcp.Close()
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udp.Close()
+
+	// systemd passes the sockets as file descriptors, datagram socket first
+	// if listed first in the socket unit
+	udpFile, _ := udp.(*net.UDPConn).File()
+	tcpFile, _ := tcp.(*net.TCPListener).File()
+	gotTCP, gotUDP, err := activationListeners([]*os.File{udpFile, tcpFile})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer gotTCP.Close()
+	defer gotUDP.Close()
+
+	if gotTCP.Addr().String() != tcp.Addr().String() {
+		t.Errorf("expected stream listener on %s, got %s", tcp.Addr(), gotTCP.Addr())
+	}
+	if gotUDP.LocalAddr().String() != udp.LocalAddr().String() {
+		t.Errorf("expected datagram socket on %s, got %s", udp.LocalAddr(), gotUDP.LocalAddr())
+	}
+
+	// Inherited sockets accept connections
+	go net.Dial("tcp", tcp.Addr().String())
+	conn, err := gotTCP.Accept()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conn.Close()
+}
+
+func TestActivationListeners_NoStreamSocket(t *testing.T) {
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udp.Close()
+
+	udpFile, _ := udp.(*net.UDPConn).File()
+	if _, _, err := activationListeners([]*os.File{udpFile}); err == nil {
+		t.Error("expected error without a stream socket")
+	}
+}
@@ -0,0 +1,197 @@
+// Package server runs the HTTP server with the protocols enabled in the
+// configuration: HTTP/1.1, HTTP/2 (over TLS or cleartext h2c), and HTTP/3.
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/quic-go/quic-go/http3"
+
+	"calsun/config"
+)
+
+// Server serves a handler over TCP and, with HTTP/3 enabled, over QUIC on
+// the same UDP port
+type Server struct {
+	addr  string
+	tls   bool
+	http  *http.Server
+	http3 *http3.Server // nil unless HTTP/3 is enabled
+}
+
+// New creates a server listening on addr with the protocols enabled in cfg.
+// Returns an error if the TLS certificate or access log cannot be opened.
+func New(addr string, cfg config.Server, handler http.Handler) (*Server, error) {
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(cfg.TLSEnabled())
+	protocols.SetUnencryptedHTTP2(cfg.H2C)
+
+	handler = mount(cfg.BasePath, handler)
+	if cfg.AccessLog.Enabled() {
+		logger, err := newAccessLogger(cfg.AccessLog)
+		if err != nil {
+			return nil, err
+		}
+		handler = logger.wrap(handler)
+	}
+
+	s := &Server{
+		addr: addr,
+		tls:  cfg.TLSEnabled(),
+		http: &http.Server{Addr: addr, Handler: handler, Protocols: &protocols},
+	}
+	if !s.tls {
+		return s, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("load TLS certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	s.http.TLSConfig = tlsConfig
+
+	if cfg.HTTP3 {
+		s.http3 = &http3.Server{
+			Addr:      addr,
+			Handler:   handler,
+			TLSConfig: http3.ConfigureTLSConfig(tlsConfig),
+		}
+		s.http.Handler = s.advertiseHTTP3(handler)
+	}
+	return s, nil
+}
+
+// Protocols lists the protocols the server speaks, for logging
+func (s *Server) Protocols() []string {
+	protocols := []string{"HTTP/1.1"}
+	if s.http.Protocols.HTTP2() {
+		protocols = append(protocols, "HTTP/2")
+	}
+	if s.http.Protocols.UnencryptedHTTP2() {
+		protocols = append(protocols, "h2c")
+	}
+	if s.http3 != nil {
+		protocols = append(protocols, "HTTP/3")
+	}
+	return protocols
+}
+
+// Listeners are the sockets a server serves on
+type Listeners struct {
+	TCP       net.Listener
+	UDP       net.PacketConn // nil unless HTTP/3 is enabled
+	Activated bool           // Inherited from systemd socket activation
+}
+
+// Listen returns the sockets passed by systemd socket activation, if the
+// process was socket-activated, or else binds the server's address. An
+// activated stream socket serves HTTP/1.1 and HTTP/2, and an activated
+// datagram socket is required for HTTP/3.
+func (s *Server) Listen() (*Listeners, error) {
+	count, err := activationCount(os.Getenv, os.Getpid())
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		unsetActivationEnv()
+		tcp, udp, err := activationListeners(activationFiles(count))
+		if err != nil {
+			return nil, err
+		}
+		if s.http3 == nil && udp != nil {
+			udp.Close()
+			udp = nil
+		}
+		if s.http3 != nil && udp == nil {
+			tcp.Close()
+			return nil, errors.New("HTTP/3 requires an activated datagram socket (ListenDatagram=)")
+		}
+		return &Listeners{TCP: tcp, UDP: udp, Activated: true}, nil
+	}
+
+	tcp, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return nil, err
+	}
+	var udp net.PacketConn
+	if s.http3 != nil {
+		if udp, err = net.ListenPacket("udp", s.addr); err != nil {
+			tcp.Close()
+			return nil, err
+		}
+	}
+	return &Listeners{TCP: tcp, UDP: udp}, nil
+}
+
+// Serve serves HTTP/1.1 and HTTP/2 on the TCP listener and, if HTTP/3 is
+// enabled, HTTP/3 on the UDP socket. It returns when either stops, closing
+// the other unless the server is being shut down, in which case the error
+// is http.ErrServerClosed.
+func (s *Server) Serve(l *Listeners) error {
+	errs := make(chan error, 2)
+	if s.http3 != nil {
+		go func() { errs <- s.http3.Serve(l.UDP) }()
+	}
+	go func() {
+		if s.tls {
+			errs <- s.http.ServeTLS(l.TCP, "", "")
+		} else {
+			errs <- s.http.Serve(l.TCP)
+		}
+	}()
+
+	err := <-errs
+	if !errors.Is(err, http.ErrServerClosed) {
+		s.Close()
+	}
+	return err
+}
+
+// Shutdown gracefully stops the server: it stops accepting connections and
+// waits for active requests to finish until ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.http.Shutdown(ctx)
+	if s.http3 != nil {
+		err = errors.Join(err, s.http3.Shutdown(ctx))
+	}
+	return err
+}
+
+// Close immediately closes the server's listeners and connections
+func (s *Server) Close() error {
+	err := s.http.Close()
+	if s.http3 != nil {
+		err = errors.Join(err, s.http3.Close())
+	}
+	return err
+}
+
+// mount serves handler under basePath, with the prefix stripped from request
+// paths. The bare prefix redirects to its trailing slash form, and paths
+// outside it are not found.
+func mount(basePath string, handler http.Handler) http.Handler {
+	if basePath == "" {
+		return handler
+	}
+	mux := http.NewServeMux()
+	mux.Handle(basePath+"/", http.StripPrefix(basePath, handler))
+	return mux
+}
+
+// advertiseHTTP3 wraps handler to announce the HTTP/3 endpoint in the
+// Alt-Svc header of TCP responses, so clients can upgrade
+func (s *Server) advertiseHTTP3(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fails only until the QUIC listener is up; the header is then omitted
+		s.http3.SetQUICHeaders(w.Header())
+		handler.ServeHTTP(w, r)
+	})
+}
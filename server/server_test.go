@@ -0,0 +1,265 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+
+	"calsun/config"
+)
+
+// protoHandler responds with the protocol of the request
+var protoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	io.WriteString(w, r.Proto)
+})
+
+// startServer serves protoHandler on random local ports with cfg, returning
+// the TCP and UDP addresses
+func startServer(t *testing.T, cfg config.Server) (*Server, string, string) {
+	t.Helper()
+	srv, err := New("127.0.0.1:0", cfg, protoHandler)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var udp net.PacketConn
+	udpAddr := ""
+	if cfg.HTTP3 {
+		if udp, err = net.ListenPacket("udp", "127.0.0.1:0"); err != nil {
+			t.Fatal(err)
+		}
+		udpAddr = udp.LocalAddr().String()
+	}
+
+	go srv.Serve(&Listeners{TCP: tcp, UDP: udp})
+	t.Cleanup(func() { srv.Close() })
+	return srv, tcp.Addr().String(), udpAddr
+}
+
+// writeCert writes a self-signed certificate for 127.0.0.1 and its key to
+// files, returning the server config and a client TLS config trusting it
+func writeCert(t *testing.T) (config.Server, *tls.Config) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	cfg := config.Server{TLSCert: filepath.Join(dir, "cert.pem"), TLSKey: filepath.Join(dir, "key.pem")}
+	os.WriteFile(cfg.TLSCert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(cfg.TLSKey, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+
+	cert, _ := x509.ParseCertificate(der)
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	return cfg, &tls.Config{RootCAs: roots}
+}
+
+// get requests url with transport, returning the response body
+func get(t *testing.T, transport http.RoundTripper, url string) (*http.Response, string, error) {
+	t.Helper()
+	client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body), nil
+}
+
+// h2cTransport speaks only HTTP/2 with prior knowledge over cleartext
+func h2cTransport() *http.Transport {
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	return &http.Transport{Protocols: &protocols}
+}
+
+func TestServer_HTTP1(t *testing.T) {
+	srv, addr, _ := startServer(t, config.Server{})
+
+	if _, body, err := get(t, http.DefaultTransport, "http://"+addr); err != nil || body != "HTTP/1.1" {
+		t.Errorf("expected HTTP/1.1, got %q (%v)", body, err)
+	}
+	if _, _, err := get(t, h2cTransport(), "http://"+addr); err == nil {
+		t.Error("expected h2c to be refused when disabled")
+	}
+	if got := srv.Protocols(); !slices.Equal(got, []string{"HTTP/1.1"}) {
+		t.Errorf("unexpected protocols %v", got)
+	}
+}
+
+func TestServer_H2C(t *testing.T) {
+	srv, addr, _ := startServer(t, config.Server{H2C: true})
+
+	if _, body, err := get(t, h2cTransport(), "http://"+addr); err != nil || body != "HTTP/2.0" {
+		t.Errorf("expected HTTP/2.0 over cleartext, got %q (%v)", body, err)
+	}
+	// HTTP/1.1 clients are still served
+	if _, body, err := get(t, http.DefaultTransport, "http://"+addr); err != nil || body != "HTTP/1.1" {
+		t.Errorf("expected HTTP/1.1, got %q (%v)", body, err)
+	}
+	if got := srv.Protocols(); !slices.Equal(got, []string{"HTTP/1.1", "h2c"}) {
+		t.Errorf("unexpected protocols %v", got)
+	}
+}
+
+func TestServer_TLS(t *testing.T) {
+	cfg, clientTLS := writeCert(t)
+	_, addr, _ := startServer(t, cfg)
+
+	transport := &http.Transport{TLSClientConfig: clientTLS, ForceAttemptHTTP2: true}
+	resp, body, err := get(t, transport, "https://"+addr)
+	if err != nil || body != "HTTP/2.0" {
+		t.Fatalf("expected HTTP/2.0 negotiated over TLS, got %q (%v)", body, err)
+	}
+	if resp.Header.Get("Alt-Svc") != "" {
+		t.Error("expected no HTTP/3 advertisement when disabled")
+	}
+}
+
+func TestServer_HTTP3(t *testing.T) {
+	cfg, clientTLS := writeCert(t)
+	cfg.HTTP3 = true
+	srv, addr, udpAddr := startServer(t, cfg)
+
+	h3 := &http3.Transport{TLSClientConfig: clientTLS}
+	t.Cleanup(func() { h3.Close() })
+	if _, body, err := get(t, h3, "https://"+udpAddr); err != nil || body != "HTTP/3.0" {
+		t.Fatalf("expected HTTP/3.0 over QUIC, got %q (%v)", body, err)
+	}
+
+	// TCP responses advertise the QUIC endpoint
+	transport := &http.Transport{TLSClientConfig: clientTLS, ForceAttemptHTTP2: true}
+	resp, _, err := get(t, transport, "https://"+addr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, port, _ := net.SplitHostPort(udpAddr)
+	if got := resp.Header.Get("Alt-Svc"); got != `h3=":`+port+`"; ma=2592000` {
+		t.Errorf("unexpected Alt-Svc header %q", got)
+	}
+	if got := srv.Protocols(); !slices.Equal(got, []string{"HTTP/1.1", "HTTP/2", "HTTP/3"}) {
+		t.Errorf("unexpected protocols %v", got)
+	}
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})
+	srv, err := New("127.0.0.1:0", config.Server{}, handler)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(&Listeners{TCP: tcp}) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		_, body, err := get(t, http.DefaultTransport, "http://"+tcp.Addr().String())
+		results <- result{body, err}
+	}()
+	<-started
+
+	// The in-flight request finishes after the shutdown begins
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- srv.Shutdown(context.Background()) }()
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("expected ErrServerClosed, got %v", err)
+	}
+	close(release)
+	if r := <-results; r.err != nil || r.body != "done" {
+		t.Errorf("expected in-flight request to complete, got %q (%v)", r.body, r.err)
+	}
+	if err := <-shutdown; err != nil {
+		t.Errorf("unexpected shutdown error: %v", err)
+	}
+}
+
+func TestServer_BasePath(t *testing.T) {
+	pathHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Path)
+	})
+	srv, err := New("127.0.0.1:0", config.Server{BasePath: "/calsun"}, pathHandler)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		target string
+		status int
+		body   string
+	}{
+		{"/calsun/calendar.ics?lat=1", http.StatusOK, "/calendar.ics"},
+		{"/calsun/", http.StatusOK, "/"},
+		{"/calsun", http.StatusTemporaryRedirect, ""},
+		{"/calendar.ics", http.StatusNotFound, ""},
+		{"/calsunrise/", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		srv.http.Handler.ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.target, tt.status, w.Code)
+		}
+		if tt.body != "" && w.Body.String() != tt.body {
+			t.Errorf("%s: expected path %q, got %q", tt.target, tt.body, w.Body.String())
+		}
+	}
+}
+
+func TestNew_InvalidCertificate(t *testing.T) {
+	cfg := config.Server{TLSCert: "missing-cert.pem", TLSKey: "missing-key.pem"}
+	if _, err := New(":0", cfg, protoHandler); err == nil {
+		t.Error("expected error for missing certificate")
+	}
+}
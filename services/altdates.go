@@ -0,0 +1,28 @@
+package services
+
+import (
+	"slices"
+	"time"
+)
+
+// Alternate calendars events can be annotated with the date in
+const (
+	AltDateHijri  = "hijri"  // Tabular Islamic calendar
+	AltDateHebrew = "hebrew" // Hebrew calendar
+)
+
+// AnnotateAltDates adds the date of each event's local start in tz to its
+// description in the given alternate calendars (e.g., "Hebrew date: 15
+// Tevet 5785"). Dates are civil: an event after sunset is still given the
+// date whose daylight it follows, although the religious day has changed.
+func AnnotateAltDates(events []CalendarEvent, tz *time.Location, calendars []string) {
+	for i := range events {
+		local := events[i].Start.In(tz)
+		if slices.Contains(calendars, AltDateHijri) {
+			events[i].Description += "\nHijri date: " + ToHijri(local).String()
+		}
+		if slices.Contains(calendars, AltDateHebrew) {
+			events[i].Description += "\nHebrew date: " + ToHebrew(local).String()
+		}
+	}
+}
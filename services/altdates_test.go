@@ -0,0 +1,31 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnnotateAltDates(t *testing.T) {
+	tz := GetTimezone(55.6761, 12.5683)
+	events := []CalendarEvent{
+		{Start: time.Date(2025, 1, 15, 7, 30, 0, 0, time.UTC), Description: "Time: 08:30"},
+		// Local midnight of 16 January, still 15 January in UTC
+		{Start: time.Date(2025, 1, 15, 23, 0, 0, 0, time.UTC), Description: "Day", AllDay: true},
+	}
+	AnnotateAltDates(events, tz, []string{AltDateHebrew, AltDateHijri})
+
+	want := "Time: 08:30\nHijri date: 15 Rajab 1446 AH\nHebrew date: 15 Tevet 5785"
+	if events[0].Description != want {
+		t.Errorf("expected %q, got %q", want, events[0].Description)
+	}
+	if !strings.HasSuffix(events[1].Description, "Hebrew date: 16 Tevet 5785") {
+		t.Errorf("expected the local date, got %q", events[1].Description)
+	}
+
+	events = []CalendarEvent{{Start: time.Date(2025, 1, 15, 7, 30, 0, 0, time.UTC)}}
+	AnnotateAltDates(events, tz, []string{AltDateHebrew})
+	if strings.Contains(events[0].Description, "Hijri") {
+		t.Errorf("expected only the Hebrew date, got %q", events[0].Description)
+	}
+}
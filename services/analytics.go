@@ -0,0 +1,206 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// usageCellDegrees is the size of the location cells requests are counted
+// in, coarse enough (about 1,100 km) not to identify anyone
+const usageCellDegrees = 10
+
+// UsageDay holds the aggregate usage counts of a UTC date. Nothing about
+// individual requests is kept.
+type UsageDay struct {
+	Date      string         `json:"date"`
+	Endpoints map[string]int `json:"endpoints"` // Route pattern, e.g. "/calendar.ics"
+	Locations map[string]int `json:"locations"` // Location cell, see UsageCell
+	Options   map[string]int `json:"options"`   // Parameter, with the value for fixed choices (e.g., "events=sunset")
+}
+
+// UsageRecord is a served request to count. Options must come from a fixed
+// set (parameter names and their allowed values), never from user input.
+type UsageRecord struct {
+	Endpoint    string
+	HasLocation bool
+	Lat         float64
+	Lng         float64
+	Options     []string
+}
+
+// UsageCount is a counted key
+type UsageCount struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// UsageSummary is the usage over a range of days, with the counts ordered
+// most first
+type UsageSummary struct {
+	From      string       `json:"from"`
+	To        string       `json:"to"`
+	Requests  int          `json:"requests"`
+	Daily     []UsageCount `json:"daily"` // Requests per date, oldest first
+	Endpoints []UsageCount `json:"endpoints"`
+	Locations []UsageCount `json:"locations"`
+	Options   []UsageCount `json:"options"`
+}
+
+// Analytics aggregates usage counts per day in memory and saves them to a
+// JSON file on Flush. Days older than the retention are deleted.
+type Analytics struct {
+	mu        sync.Mutex
+	path      string
+	retention int
+	days      map[string]*UsageDay
+	dirty     bool
+	now       Clock
+}
+
+// OpenAnalytics loads the usage counts at path, keeping retention days
+func OpenAnalytics(path string, retention int) (*Analytics, error) {
+	a := &Analytics{path: path, retention: retention, days: make(map[string]*UsageDay), now: time.Now}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var days []*UsageDay
+	if err := json.Unmarshal(data, &days); err != nil {
+		return nil, fmt.Errorf("invalid analytics store %s: %w", path, err)
+	}
+	for _, day := range days {
+		a.days[day.Date] = day
+	}
+	return a, nil
+}
+
+// Record counts a request on today's date
+func (a *Analytics) Record(rec UsageRecord) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	date := a.now().UTC().Format(time.DateOnly)
+	day, ok := a.days[date]
+	if !ok {
+		day = &UsageDay{Date: date, Endpoints: map[string]int{}, Locations: map[string]int{}, Options: map[string]int{}}
+		a.days[date] = day
+	}
+	day.Endpoints[rec.Endpoint]++
+	if rec.HasLocation {
+		day.Locations[UsageCell(rec.Lat, rec.Lng)]++
+	}
+	for _, option := range rec.Options {
+		day.Options[option]++
+	}
+	a.dirty = true
+}
+
+// Flush deletes the days past the retention and saves the counts if they
+// changed. Run it regularly and on shutdown.
+func (a *Analytics) Flush(ctx context.Context) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	oldest := a.oldestDate()
+	for date := range a.days {
+		if date < oldest {
+			delete(a.days, date)
+			a.dirty = true
+		}
+	}
+	if !a.dirty {
+		return
+	}
+
+	days := make([]*UsageDay, 0, len(a.days))
+	for _, day := range a.days {
+		days = append(days, day)
+	}
+	slices.SortFunc(days, func(x, y *UsageDay) int { return cmp.Compare(x.Date, y.Date) })
+	if err := writeJSONAtomic(a.path, days); err != nil {
+		log.Printf("save analytics: %v", err)
+		return
+	}
+	a.dirty = false
+}
+
+// Summary totals the usage of the last days days, including today
+func (a *Analytics) Summary(days int) UsageSummary {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	today := a.now().UTC()
+	from := today.AddDate(0, 0, 1-days).Format(time.DateOnly)
+	summary := UsageSummary{From: max(from, a.oldestDate()), To: today.Format(time.DateOnly), Daily: []UsageCount{}}
+	endpoints, locations, options := map[string]int{}, map[string]int{}, map[string]int{}
+	for date, day := range a.days {
+		if date < summary.From {
+			continue
+		}
+		requests := 0
+		for endpoint, n := range day.Endpoints {
+			endpoints[endpoint] += n
+			requests += n
+		}
+		for cell, n := range day.Locations {
+			locations[cell] += n
+		}
+		for option, n := range day.Options {
+			options[option] += n
+		}
+		summary.Requests += requests
+		summary.Daily = append(summary.Daily, UsageCount{date, requests})
+	}
+	slices.SortFunc(summary.Daily, func(x, y UsageCount) int { return cmp.Compare(x.Key, y.Key) })
+	summary.Endpoints = sortedCounts(endpoints)
+	summary.Locations = sortedCounts(locations)
+	summary.Options = sortedCounts(options)
+	return summary
+}
+
+// oldestDate returns the first date within the retention. The caller must
+// hold a.mu.
+func (a *Analytics) oldestDate() string {
+	return a.now().UTC().AddDate(0, 0, 1-a.retention).Format(time.DateOnly)
+}
+
+// sortedCounts returns counts ordered most first, then by key
+func sortedCounts(counts map[string]int) []UsageCount {
+	sorted := make([]UsageCount, 0, len(counts))
+	for key, n := range counts {
+		sorted = append(sorted, UsageCount{key, n})
+	}
+	slices.SortFunc(sorted, func(x, y UsageCount) int {
+		return cmp.Or(cmp.Compare(y.Count, x.Count), cmp.Compare(x.Key, y.Key))
+	})
+	return sorted
+}
+
+// UsageCell returns the location cell of a point, named by its south-west
+// corner (e.g., "50N 10E" for Copenhagen)
+func UsageCell(lat, lng float64) string {
+	south := int(math.Floor(min(lat, 89.9)/usageCellDegrees)) * usageCellDegrees
+	west := int(math.Floor(min(lng, 179.9)/usageCellDegrees)) * usageCellDegrees
+	ns, ew := "N", "E"
+	if south < 0 {
+		ns, south = "S", -south
+	}
+	if west < 0 {
+		ew, west = "W", -west
+	}
+	return fmt.Sprintf("%d%s %d%s", south, ns, west, ew)
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAnalytics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "analytics.json")
+	a, err := OpenAnalytics(path, 30)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	day := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+	a.now = FixedClock(day.AddDate(0, 0, -1))
+	a.Record(UsageRecord{Endpoint: "/calendar.ics", HasLocation: true, Lat: 55.68, Lng: 12.57, Options: []string{"events=sunset"}})
+	a.now = FixedClock(day)
+	a.Record(UsageRecord{Endpoint: "/calendar.ics", HasLocation: true, Lat: 51.51, Lng: -0.13, Options: []string{"events=sunset", "days"}})
+	a.Record(UsageRecord{Endpoint: "/api/today"})
+
+	summary := a.Summary(7)
+	if summary.From != "2024-06-15" || summary.To != "2024-06-21" || summary.Requests != 3 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if len(summary.Daily) != 2 || summary.Daily[0] != (UsageCount{"2024-06-20", 1}) || summary.Daily[1] != (UsageCount{"2024-06-21", 2}) {
+		t.Errorf("unexpected daily counts: %+v", summary.Daily)
+	}
+	if summary.Endpoints[0] != (UsageCount{"/calendar.ics", 2}) || summary.Options[0] != (UsageCount{"events=sunset", 2}) {
+		t.Errorf("expected the most used first, got %+v %+v", summary.Endpoints, summary.Options)
+	}
+	if len(summary.Locations) != 2 || summary.Locations[0] != (UsageCount{"50N 10E", 1}) || summary.Locations[1] != (UsageCount{"50N 10W", 1}) {
+		t.Errorf("unexpected locations: %+v", summary.Locations)
+	}
+	if got := a.Summary(1); got.Requests != 2 {
+		t.Errorf("expected today's 2 requests, got %d", got.Requests)
+	}
+
+	// Saved counts are loaded again
+	a.Flush(context.Background())
+	loaded, err := OpenAnalytics(path, 30)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	loaded.now = a.now
+	if got := loaded.Summary(7); got.Requests != 3 {
+		t.Errorf("expected the saved counts, got %+v", got)
+	}
+
+	// Days past the retention are deleted
+	loaded.now = FixedClock(day.AddDate(0, 0, 30))
+	loaded.Flush(context.Background())
+	if len(loaded.days) != 0 {
+		t.Errorf("expected old days deleted, got %d", len(loaded.days))
+	}
+	if got := loaded.Summary(365); got.From != "2024-06-22" {
+		t.Errorf("expected the summary limited to the retention, got %s", got.From)
+	}
+}
+
+func TestUsageCell(t *testing.T) {
+	tests := []struct {
+		lat, lng float64
+		want     string
+	}{
+		{55.6761, 12.5683, "50N 10E"},
+		{51.5074, -0.1278, "50N 10W"},
+		{-33.8688, 151.2093, "40S 150E"},
+		{90, 180, "80N 170E"},
+		{0, 0, "0N 0E"},
+	}
+	for _, tt := range tests {
+		if got := UsageCell(tt.lat, tt.lng); got != tt.want {
+			t.Errorf("UsageCell(%v, %v) = %q, want %q", tt.lat, tt.lng, got, tt.want)
+		}
+	}
+}
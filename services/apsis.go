@@ -0,0 +1,55 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// BuildMoonApsisEvents generates an all-day event on each day the moon
+// reaches one of the apsis types (e.g., "Moon at perigee (357,175 km)"), with
+// the local time, distance, and moon phase in the description
+func BuildMoonApsisEvents(opts CalendarOptions, types []string, start time.Time, days int) []CalendarEvent {
+	local := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, opts.Timezone)
+
+	var events []CalendarEvent
+	for _, apsis := range MoonApsides(local, local.AddDate(0, 0, days)) {
+		if slices.Contains(types, apsis.Type) {
+			events = append(events, newApsisEvent(apsis, opts))
+		}
+	}
+	return events
+}
+
+// newApsisEvent creates an all-day event on the local date of an apsis
+func newApsisEvent(apsis MoonApsis, opts CalendarOptions) CalendarEvent {
+	local := apsis.Time.In(opts.Timezone)
+	date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, opts.Timezone)
+	phase := GetMoonPhase(apsis.Time)
+
+	lines := []string{
+		fmt.Sprintf("%s: %s", strings.ToUpper(apsis.Type[:1])+apsis.Type[1:], opts.Clock(apsis.Time, "15:04 MST")),
+		fmt.Sprintf("Distance: %s", opts.Locale.Kilometres(apsis.Distance)),
+		fmt.Sprintf("Location: %s", opts.Location),
+		"",
+		fmt.Sprintf("Moon: %s (%d%% illuminated)", phase.Name, int(phase.Illumination*100+0.5)),
+	}
+
+	return CalendarEvent{
+		UID:         locationUID(date, opts.Lat, opts.Lng, opts.Precision, "moon-"+apsis.Type),
+		Type:        "moon_" + apsis.Type,
+		Start:       date,
+		End:         date.AddDate(0, 0, 1),
+		Summary:     fmt.Sprintf("Moon at %s (%s)", apsis.Type, opts.Locale.Kilometres(apsis.Distance)),
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+		AllDay:      true,
+	}
+}
+
+// FormatKilometres formats a distance rounded to whole kilometres, with
+// thousands separators (e.g., "357,175 km")
+func FormatKilometres(km float64) string {
+	return DefaultLocale.Kilometres(km)
+}
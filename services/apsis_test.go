@@ -0,0 +1,48 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildMoonApsisEvents(t *testing.T) {
+	opts := testCalendarOptions()
+	start := time.Date(2024, 10, 10, 0, 0, 0, 0, time.UTC)
+
+	events := BuildMoonApsisEvents(opts, ApsisTypes, start, 30)
+	if len(events) != 2 {
+		t.Fatalf("expected a perigee and an apogee, got %d events", len(events))
+	}
+
+	perigee := events[0]
+	if perigee.Summary != "Moon at perigee (357,175 km)" {
+		t.Errorf("unexpected summary %q", perigee.Summary)
+	}
+	if !perigee.AllDay || perigee.Start.Format("2006-01-02") != "2024-10-17" || perigee.Type != "moon_perigee" {
+		t.Errorf("expected an all-day perigee event on 2024-10-17, got %+v", perigee)
+	}
+	// The supermoon of October 2024
+	if !strings.Contains(perigee.Description, "Perigee: 02:5") || !strings.Contains(perigee.Description, "Full moon") {
+		t.Errorf("unexpected description %q", perigee.Description)
+	}
+
+	apogees := BuildMoonApsisEvents(opts, []string{ApsisApogee}, start, 30)
+	if len(apogees) != 1 || apogees[0].Type != "moon_apogee" {
+		t.Errorf("expected only the apogee, got %+v", apogees)
+	}
+}
+
+func TestFormatKilometres(t *testing.T) {
+	tests := map[float64]string{
+		357175.4: "357,175 km",
+		406999.6: "407,000 km",
+		1234:     "1,234 km",
+		999:      "999 km",
+	}
+	for km, want := range tests {
+		if got := FormatKilometres(km); got != want {
+			t.Errorf("FormatKilometres(%v) = %q, want %q", km, got, want)
+		}
+	}
+}
@@ -0,0 +1,440 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SWPCURL is NOAA's Space Weather Prediction Center data service
+	SWPCURL = "https://services.swpc.noaa.gov"
+
+	// The forecast is issued a few times a day
+	kpCacheTTL = 30 * time.Minute
+
+	// kpPeriod is the length of a Kp index period
+	kpPeriod = 3 * time.Hour
+
+	// auroraDarkElevation is the sun elevation (degrees) below which the sky
+	// is dark enough to see aurora: nautical twilight's end
+	auroraDarkElevation = -12
+
+	// auroraSampleStep is the sampling interval when finding the dark part of
+	// a Kp period
+	auroraSampleStep = 10 * time.Minute
+)
+
+// Geomagnetic north pole of the dipole field (IGRF-13, epoch 2020)
+const (
+	geomagneticPoleLat = 80.65
+	geomagneticPoleLng = -72.68
+)
+
+// KpPeriod is a 3-hour period of the planetary Kp index of geomagnetic
+// activity (0 to 9, in thirds)
+type KpPeriod struct {
+	Start    time.Time
+	Kp       float64
+	Observed bool // Measured rather than forecast
+}
+
+// End returns when the period ends
+func (p KpPeriod) End() time.Time {
+	return p.Start.Add(kpPeriod)
+}
+
+// KpProvider fetches the Kp index forecast
+type KpProvider interface {
+	// KpForecast returns the Kp index periods of the past days and the next
+	// three, in order
+	KpForecast(ctx context.Context) ([]KpPeriod, error)
+}
+
+// SWPC is a KpProvider backed by NOAA SWPC's planetary K-index forecast. The
+// forecast is cached for 30 minutes.
+type SWPC struct {
+	baseURL string
+	client  *http.Client
+	cache   *ttlCache[[]KpPeriod]
+}
+
+// NewSWPC creates a Kp provider for the SWPC data service at baseURL
+func NewSWPC(baseURL string) *SWPC {
+	return &SWPC{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+		cache:   newTTLCache[[]KpPeriod](kpCacheTTL, 1),
+	}
+}
+
+// Cache returns the cached Kp forecast
+func (s *SWPC) Cache() Cache {
+	return s.cache
+}
+
+// KpForecast returns the Kp index forecast
+func (s *SWPC) KpForecast(ctx context.Context) ([]KpPeriod, error) {
+	if forecast, ok := s.cache.Get("kp"); ok {
+		return forecast, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", s.baseURL+"/products/noaa-planetary-k-index-forecast.json", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Kp forecast request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Kp forecast request failed: status %d", resp.StatusCode)
+	}
+
+	var rows []json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("invalid Kp forecast response: %w", err)
+	}
+	forecast, err := parseKpForecast(rows)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Kp forecast response: %w", err)
+	}
+
+	s.cache.Set("kp", forecast)
+	return forecast, nil
+}
+
+// parseKpForecast parses the forecast's rows: arrays of values after a header
+// row of field names, or objects
+func parseKpForecast(rows []json.RawMessage) ([]KpPeriod, error) {
+	var header []string
+	var forecast []KpPeriod
+	for _, raw := range rows {
+		fields := map[string]any{}
+		if strings.HasPrefix(strings.TrimSpace(string(raw)), "[") {
+			var values []any
+			if err := json.Unmarshal(raw, &values); err != nil {
+				return nil, err
+			}
+			if header == nil {
+				for _, v := range values {
+					name, _ := v.(string)
+					header = append(header, name)
+				}
+				continue
+			}
+			for i, v := range values {
+				if i < len(header) {
+					fields[header[i]] = v
+				}
+			}
+		} else if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, err
+		}
+
+		timeTag, _ := fields["time_tag"].(string)
+		start, err := time.Parse("2006-01-02 15:04:05", strings.Replace(timeTag, "T", " ", 1))
+		if err != nil {
+			return nil, fmt.Errorf("time %q", timeTag)
+		}
+		var kp float64
+		switch v := fields["kp"].(type) {
+		case float64:
+			kp = v
+		case string:
+			if kp, err = strconv.ParseFloat(v, 64); err != nil {
+				return nil, fmt.Errorf("Kp %q", v)
+			}
+		default:
+			return nil, fmt.Errorf("Kp %v", v)
+		}
+		forecast = append(forecast, KpPeriod{Start: start, Kp: kp, Observed: fields["observed"] == "observed"})
+	}
+	sort.Slice(forecast, func(i, j int) bool { return forecast[i].Start.Before(forecast[j].Start) })
+	return forecast, nil
+}
+
+// GeomagneticLatitude returns a location's latitude relative to the
+// geomagnetic dipole, in degrees
+func GeomagneticLatitude(lat, lng float64) float64 {
+	phi, poleLat := degToRad(lat), degToRad(geomagneticPoleLat)
+	return radToDeg(math.Asin(math.Sin(phi)*math.Sin(poleLat) + math.Cos(phi)*math.Cos(poleLat)*math.Cos(degToRad(lng-geomagneticPoleLng))))
+}
+
+// AuroraKpThreshold returns the lowest Kp index at which aurora may be seen
+// from a location, low on the poleward horizon, and false if even a Kp 9
+// storm is unlikely to reach it. The auroral oval is overhead at a
+// geomagnetic latitude of about 66.5° - 2.05° × Kp (NOAA SWPC) and visible
+// from about 2.5° further equatorward.
+func AuroraKpThreshold(lat, lng float64) (int, bool) {
+	kp := int(math.Ceil((64 - math.Abs(GeomagneticLatitude(lat, lng))) / 2.05))
+	if kp > 9 {
+		return 0, false
+	}
+	return max(kp, 1), true
+}
+
+// kpLevel rounds a Kp index to its whole level (e.g., 5.67, "6-", is 6)
+func kpLevel(kp float64) int {
+	return int(math.Round(kp))
+}
+
+// geomagneticStorm names the NOAA G-scale storm of a Kp level, if any
+func geomagneticStorm(level int) string {
+	if level < 5 {
+		return ""
+	}
+	return fmt.Sprintf("G%d", min(level, 9)-4)
+}
+
+// AuroraNight is a night when the Kp forecast reaches a location's threshold
+// while the sky is dark
+type AuroraNight struct {
+	Date    time.Time  // Local midnight of the evening's date
+	Start   time.Time  // The first dark time in a period at the threshold
+	End     time.Time  // The last
+	MaxKp   float64    // Highest Kp of the periods
+	Periods []KpPeriod // The periods at the threshold with dark sky, in order
+}
+
+// AuroraNights returns the nights when the forecast reaches threshold while
+// the sun is at least 12° below the horizon at a location, in order. Nights
+// run from noon to noon local time in tz.
+func AuroraNights(lat, lng float64, tz *time.Location, forecast []KpPeriod, threshold int) []AuroraNight {
+	var nights []AuroraNight
+	for _, period := range forecast {
+		if kpLevel(period.Kp) < threshold {
+			continue
+		}
+		var darkStart, darkEnd time.Time
+		for t := period.Start; t.Before(period.End()); t = t.Add(auroraSampleStep) {
+			if _, elevation := SunPosition(lat, lng, t); elevation < auroraDarkElevation {
+				if darkStart.IsZero() {
+					darkStart = t
+				}
+				darkEnd = t.Add(auroraSampleStep)
+			}
+		}
+		if darkStart.IsZero() {
+			continue
+		}
+
+		evening := darkStart.In(tz).Add(-12 * time.Hour)
+		date := time.Date(evening.Year(), evening.Month(), evening.Day(), 0, 0, 0, 0, tz)
+		if len(nights) == 0 || !nights[len(nights)-1].Date.Equal(date) {
+			nights = append(nights, AuroraNight{Date: date, Start: darkStart})
+		}
+		night := &nights[len(nights)-1]
+		night.End = darkEnd
+		night.MaxKp = max(night.MaxKp, period.Kp)
+		night.Periods = append(night.Periods, period)
+	}
+	return nights
+}
+
+// poleward returns the direction of the nearer geomagnetic pole
+func poleward(lat, lng float64) string {
+	if GeomagneticLatitude(lat, lng) < 0 {
+		return "south"
+	}
+	return "north"
+}
+
+// BuildAuroraEvents generates an event for each night the Kp forecast
+// reaches threshold in the dark at the location (e.g., "Aurora possible
+// tonight (Kp 6)"), for the nights of the local days starting at start. Events
+// last from the first to the last dark time at the threshold.
+func BuildAuroraEvents(opts CalendarOptions, forecast []KpPeriod, threshold int, start time.Time, days int) []CalendarEvent {
+	from := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, opts.Timezone)
+	to := from.AddDate(0, 0, days)
+
+	var events []CalendarEvent
+	for _, night := range AuroraNights(opts.Lat, opts.Lng, opts.Timezone, forecast, threshold) {
+		if !night.Date.Before(from) && night.Date.Before(to) {
+			events = append(events, newAuroraEvent(night, threshold, opts))
+		}
+	}
+	return events
+}
+
+// newAuroraEvent creates an aurora event for a night, listing its periods
+func newAuroraEvent(night AuroraNight, threshold int, opts CalendarOptions) CalendarEvent {
+	tz := opts.Timezone
+	level := kpLevel(night.MaxKp)
+	title := fmt.Sprintf("Aurora possible tonight (Kp %d)", level)
+
+	var lines []string
+	for _, period := range night.Periods {
+		kind := "forecast"
+		if period.Observed {
+			kind = "observed"
+		}
+		line := fmt.Sprintf("%s to %s: Kp %s (%s)", period.Start.In(tz).Format("15:04"), period.End().In(tz).Format("15:04"), opts.Locale.Number(period.Kp, 2), kind)
+		if storm := geomagneticStorm(kpLevel(period.Kp)); storm != "" {
+			line += ", " + storm + " geomagnetic storm"
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines,
+		fmt.Sprintf("Dark: %s to %s", night.Start.In(tz).Format("15:04"), night.End.In(tz).Format("15:04")),
+		fmt.Sprintf("Location: %s", opts.Location),
+		fmt.Sprintf("Threshold: Kp %d (geomagnetic latitude %s)", threshold, opts.Locale.Degrees(GeomagneticLatitude(opts.Lat, opts.Lng), 1)),
+		"",
+		fmt.Sprintf("Look %s, away from city lights. Kp forecast from NOAA's Space Weather Prediction Center.", poleward(opts.Lat, opts.Lng)),
+	)
+
+	return CalendarEvent{
+		UID:         locationUID(night.Date, opts.Lat, opts.Lng, opts.Precision, "aurora"),
+		Type:        "aurora",
+		Start:       night.Start,
+		End:         night.End,
+		Summary:     title,
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+	}
+}
+
+// AuroraAlerter posts to chat webhooks when the Kp forecast for a night
+// reaches the threshold at their location. Call Tick regularly (e.g., every 30
+// minutes); each target is alerted once per night, and again if the forecast
+// rises to a higher Kp level.
+type AuroraAlerter struct {
+	targets    []DigestTarget
+	threshold  int // 0 for each location's AuroraKpThreshold
+	provider   KpProvider
+	client     *http.Client
+	now        Clock
+	retryDelay time.Duration
+
+	mu      sync.Mutex
+	alerted map[auroraAlertKey]auroraAlert
+}
+
+// auroraAlertKey identifies a target's night
+type auroraAlertKey struct {
+	target int
+	date   string
+}
+
+// auroraAlert records the Kp level last alerted for a night
+type auroraAlert struct {
+	level int
+	end   time.Time
+}
+
+// NewAuroraAlerter creates an alerter for targets, fetching the forecast from
+// provider. A threshold of 0 uses each location's AuroraKpThreshold; targets
+// that never see aurora are then skipped.
+func NewAuroraAlerter(targets []DigestTarget, threshold int, provider KpProvider) *AuroraAlerter {
+	return &AuroraAlerter{
+		targets:    targets,
+		threshold:  threshold,
+		provider:   provider,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+		retryDelay: 2 * time.Second,
+		alerted:    make(map[auroraAlertKey]auroraAlert),
+	}
+}
+
+// Tick fetches the forecast and alerts the targets whose threshold is newly
+// reached for a night that has not ended
+func (a *AuroraAlerter) Tick(ctx context.Context) {
+	forecast, err := a.provider.KpForecast(ctx)
+	if err != nil {
+		log.Printf("aurora alerts: %v", err)
+		return
+	}
+	now := a.now()
+	a.forget(now)
+
+	for i, target := range a.targets {
+		threshold := a.threshold
+		if threshold == 0 {
+			var ok bool
+			if threshold, ok = AuroraKpThreshold(target.Lat, target.Lng); !ok {
+				continue
+			}
+		}
+		tz := GetTimezone(target.Lat, target.Lng)
+		for _, night := range AuroraNights(target.Lat, target.Lng, tz, forecast, threshold) {
+			level := kpLevel(night.MaxKp)
+			key := auroraAlertKey{i, night.Date.Format("2006-01-02")}
+			if !night.End.After(now) || !a.raise(key, level, night.End) {
+				continue
+			}
+			// Recorded even on failure: an alert that could not be delivered
+			// after retries is dropped rather than retried every tick
+			payload := FormatAuroraAlert(night, locationLabel(target.Name, target.Lat, target.Lng), now.In(tz), target.Platform)
+			if err := postWebhook(ctx, a.client, a.retryDelay, target.URL, payload); err != nil {
+				log.Printf("aurora %s webhook for %s: %v", target.Platform, locationLabel(target.Name, target.Lat, target.Lng), err)
+			}
+		}
+	}
+}
+
+// raise records level for a night, reporting whether it is higher than the
+// level last alerted
+func (a *AuroraAlerter) raise(key auroraAlertKey, level int, end time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if level <= a.alerted[key].level {
+		return false
+	}
+	a.alerted[key] = auroraAlert{level: level, end: end}
+	return true
+}
+
+// forget drops the alerts of nights that have ended
+func (a *AuroraAlerter) forget(now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for key, alert := range a.alerted {
+		if alert.end.Before(now) {
+			delete(a.alerted, key)
+		}
+	}
+}
+
+// FormatAuroraAlert returns the webhook payload for the platform ("slack" or
+// "discord") announcing aurora possible on a night at a location, as of now
+// (in the location's timezone)
+func FormatAuroraAlert(night AuroraNight, location string, now time.Time, platform string) any {
+	bold := "*"
+	if platform == "discord" {
+		bold = "**"
+	}
+
+	tz := now.Location()
+	level := kpLevel(night.MaxKp)
+	when := "tonight"
+	// Until 6:00, "tonight" is the night in progress
+	if evening := now.Add(-6 * time.Hour); night.Date.Format("2006-01-02") != evening.Format("2006-01-02") {
+		when = night.Date.Format("Monday") + " night"
+	}
+	lines := []string{
+		fmt.Sprintf("%sAurora possible %s at %s%s (Kp %d)", bold, when, location, bold, level),
+		fmt.Sprintf("Forecast Kp %.2f between %s and %s", night.MaxKp, night.Start.In(tz).Format("15:04"), night.End.In(tz).Format("15:04")),
+	}
+	if storm := geomagneticStorm(level); storm != "" {
+		lines = append(lines, storm+" geomagnetic storm")
+	}
+
+	text := strings.Join(lines, "\n")
+	if platform == "discord" {
+		return map[string]string{"content": text}
+	}
+	return map[string]string{"text": text}
+}
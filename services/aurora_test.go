@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testKpForecast returns Kp periods from noon UTC on 15 March 2025: a storm
+// reaching Kp 6- (5.67) from 18:00 UTC that evening, quiet by day
+func testKpForecast() []KpPeriod {
+	start := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	kp := []float64{3.33, 4, 5.67, 5, 4.33, 2.67, 2, 2.33}
+	forecast := make([]KpPeriod, len(kp))
+	for i, v := range kp {
+		forecast[i] = KpPeriod{Start: start.Add(time.Duration(i) * kpPeriod), Kp: v}
+	}
+	return forecast
+}
+
+// fakeKp is a KpProvider returning a fixed forecast
+type fakeKp struct {
+	forecast []KpPeriod
+}
+
+func (f *fakeKp) KpForecast(ctx context.Context) ([]KpPeriod, error) {
+	return f.forecast, nil
+}
+
+func TestSWPC_KpForecast(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path != "/products/noaa-planetary-k-index-forecast.json" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		w.Write([]byte(`[["time_tag","kp","observed","noaa_scale"],
+			["2025-03-15 21:00:00","5.67","predicted","G2"],
+			["2025-03-15 18:00:00","4.00","observed",null]]`))
+	}))
+	defer server.Close()
+
+	s := NewSWPC(server.URL)
+	forecast, err := s.KpForecast(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []KpPeriod{
+		{Start: time.Date(2025, 3, 15, 18, 0, 0, 0, time.UTC), Kp: 4, Observed: true},
+		{Start: time.Date(2025, 3, 15, 21, 0, 0, 0, time.UTC), Kp: 5.67},
+	}
+	if len(forecast) != 2 || forecast[0] != want[0] || forecast[1] != want[1] {
+		t.Errorf("expected %+v in order, got %+v", want, forecast)
+	}
+
+	if _, err := s.KpForecast(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a cached forecast, got %d requests", calls.Load())
+	}
+}
+
+func TestParseKpForecast_Objects(t *testing.T) {
+	var rows []json.RawMessage
+	json.Unmarshal([]byte(`[{"time_tag":"2025-03-15T21:00:00","kp":5.67,"observed":"predicted","noaa_scale":"G2"}]`), &rows)
+	forecast, err := parseKpForecast(rows)
+	if err != nil || len(forecast) != 1 || forecast[0].Kp != 5.67 || forecast[0].Start.Hour() != 21 {
+		t.Errorf("expected one Kp 5.67 period at 21:00, got %+v (%v)", forecast, err)
+	}
+
+	json.Unmarshal([]byte(`[{"time_tag":"2025-03-15T21:00:00","kp":"high"}]`), &rows)
+	if _, err := parseKpForecast(rows); err == nil {
+		t.Error("expected an error for an invalid Kp")
+	}
+}
+
+func TestAuroraKpThreshold(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lng float64
+		kp       int
+		ok       bool
+	}{
+		{"Tromsø", 69.6492, 18.9553, 1, true},
+		{"Oslo", 59.9139, 10.7522, 3, true},
+		{"Copenhagen", 55.6761, 12.5683, 5, true},
+		{"Minneapolis", 44.9778, -93.2650, 6, true},
+		{"Hobart", -42.8821, 147.3272, 7, true},
+		{"Madrid", 40.4168, -3.7038, 0, false},
+	}
+	for _, tt := range tests {
+		kp, ok := AuroraKpThreshold(tt.lat, tt.lng)
+		if kp != tt.kp || ok != tt.ok {
+			t.Errorf("%s: expected Kp %d (%v), got %d (%v)", tt.name, tt.kp, tt.ok, kp, ok)
+		}
+	}
+	// North America sits closer to the geomagnetic pole than Europe
+	if GeomagneticLatitude(45, -93) <= GeomagneticLatitude(45, 12) {
+		t.Error("expected a higher geomagnetic latitude in North America")
+	}
+}
+
+func TestAuroraNights(t *testing.T) {
+	opts := testCalendarOptions()
+	nights := AuroraNights(opts.Lat, opts.Lng, opts.Timezone, testKpForecast(), 5)
+	if len(nights) != 1 {
+		t.Fatalf("expected one night, got %d", len(nights))
+	}
+	night := nights[0]
+	if night.Date.Format("2006-01-02") != "2025-03-15" || night.MaxKp != 5.67 || len(night.Periods) != 2 {
+		t.Errorf("expected the night of March 15 at Kp 5.67 over two periods, got %+v", night)
+	}
+	// Dark from about 19:40 local in the 18:00 UTC period, to the end of the
+	// 21:00 UTC period
+	if got := night.Start.In(opts.Timezone).Format("15:04"); got < "19:30" || got > "20:00" {
+		t.Errorf("expected the night to start at dark, got %s", got)
+	}
+	if !night.End.Equal(time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the night to end with the storm, got %v", night.End)
+	}
+
+	// Activity by day is not seen, and a low threshold covers the night
+	if nights := AuroraNights(opts.Lat, opts.Lng, opts.Timezone, testKpForecast()[:2], 3); len(nights) != 0 {
+		t.Errorf("expected no aurora in daylight, got %+v", nights)
+	}
+	if nights := AuroraNights(opts.Lat, opts.Lng, opts.Timezone, testKpForecast(), 2); len(nights) != 1 || len(nights[0].Periods) != 4 {
+		t.Errorf("expected one night of four periods, got %+v", nights)
+	}
+}
+
+func TestBuildAuroraEvents(t *testing.T) {
+	opts := testCalendarOptions()
+	start := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+	events := BuildAuroraEvents(opts, testKpForecast(), 5, start, 7)
+	if len(events) != 1 {
+		t.Fatalf("expected one event, got %d", len(events))
+	}
+	event := events[0]
+	if event.Summary != "Aurora possible tonight (Kp 6)" || event.Type != "aurora" {
+		t.Errorf("unexpected event: %s %q", event.Type, event.Summary)
+	}
+	for _, want := range []string{
+		"19:00 to 22:00: Kp 5.67 (forecast), G2 geomagnetic storm",
+		"22:00 to 01:00: Kp 5.00 (forecast), G1 geomagnetic storm",
+		"Dark: 19:40 to 01:00",
+		"Threshold: Kp 5 (geomagnetic latitude 55.3°)",
+		"Look north",
+	} {
+		if !strings.Contains(event.Description, want) {
+			t.Errorf("expected description to contain %q, got:\n%s", want, event.Description)
+		}
+	}
+
+	// Only the nights of the days asked for
+	if events := BuildAuroraEvents(opts, testKpForecast(), 5, start.AddDate(0, 0, 2), 7); len(events) != 0 {
+		t.Errorf("expected no events after the night, got %d", len(events))
+	}
+}
+
+func TestAuroraAlerter_Tick(t *testing.T) {
+	var calls atomic.Int32
+	var last atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		last.Store(body["text"])
+	}))
+	defer server.Close()
+
+	targets := []DigestTarget{
+		{Platform: "slack", URL: server.URL, Lat: 55.6761, Lng: 12.5683, Name: "Copenhagen"},
+		{Platform: "slack", URL: server.URL, Lat: 40.4168, Lng: -3.7038, Name: "Madrid"}, // Too far south
+	}
+	provider := &fakeKp{forecast: testKpForecast()}
+	a := NewAuroraAlerter(targets, 0, provider)
+	a.retryDelay = time.Millisecond
+
+	a.now = FixedClock(time.Date(2025, 3, 15, 14, 0, 0, 0, time.UTC))
+	a.Tick(context.Background())
+	if calls.Load() != 1 {
+		t.Fatalf("expected one alert, got %d", calls.Load())
+	}
+	if text := last.Load().(string); !strings.HasPrefix(text, "*Aurora possible tonight at Copenhagen* (Kp 6)") || !strings.Contains(text, "G2 geomagnetic storm") {
+		t.Errorf("unexpected alert: %q", text)
+	}
+
+	// Alerted once, until the forecast rises
+	a.Tick(context.Background())
+	if calls.Load() != 1 {
+		t.Errorf("expected no repeated alert, got %d", calls.Load())
+	}
+	provider.forecast = testKpForecast()
+	provider.forecast[2].Kp = 7
+	a.Tick(context.Background())
+	if calls.Load() != 2 {
+		t.Errorf("expected an alert for the higher forecast, got %d", calls.Load())
+	}
+
+	// Nights that have ended are not alerted
+	a = NewAuroraAlerter(targets, 0, &fakeKp{forecast: testKpForecast()})
+	a.now = FixedClock(time.Date(2025, 3, 16, 8, 0, 0, 0, time.UTC))
+	a.Tick(context.Background())
+	if calls.Load() != 2 {
+		t.Errorf("expected no alert after the night, got %d", calls.Load())
+	}
+}
+
+func TestFormatAuroraAlert(t *testing.T) {
+	opts := testCalendarOptions()
+	night := AuroraNights(opts.Lat, opts.Lng, opts.Timezone, testKpForecast(), 4)[0]
+
+	// A day ahead the night is named, and Discord uses its own bold
+	now := time.Date(2025, 3, 14, 20, 0, 0, 0, opts.Timezone)
+	text := FormatAuroraAlert(night, "Copenhagen", now, "discord").(map[string]string)["content"]
+	if !strings.HasPrefix(text, "**Aurora possible Saturday night at Copenhagen** (Kp 6)") {
+		t.Errorf("unexpected alert: %q", text)
+	}
+	// After midnight, it is still tonight
+	now = time.Date(2025, 3, 16, 0, 30, 0, 0, opts.Timezone)
+	if text := FormatAuroraAlert(night, "Copenhagen", now, "slack").(map[string]string)["text"]; !strings.Contains(text, "tonight") {
+		t.Errorf("expected tonight, got %q", text)
+	}
+}
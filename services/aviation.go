@@ -0,0 +1,84 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// aviationEvent is one of the day's events for the aviation preset
+type aviationEvent struct {
+	eventType string
+	title     string
+	time      time.Time
+	rule      string // What the event means for logbook and lighting rules
+}
+
+// BuildAviationEvents generates morning civil twilight, sunrise, sunset,
+// and end of evening civil twilight events for pilot logbooks, with local and
+// UTC (Zulu) times. Night follows the FAA definition (14 CFR 1.1): from the
+// end of evening civil twilight to the beginning of morning civil twilight.
+func BuildAviationEvents(opts CalendarOptions, start time.Time, days int) []CalendarEvent {
+	var events []CalendarEvent
+	for i := 0; i < days; i++ {
+		sun := GetDayTimes(opts.Lat, opts.Lng, start.AddDate(0, 0, i))
+
+		var day []aviationEvent
+		for _, e := range []aviationEvent{
+			{"civil_dawn", "Night ends", sun.Dawn, "Morning civil twilight begins; night time logging ends"},
+			{"sunrise", "Sunrise", sun.Sunrise, "Position lights no longer required"},
+			{"sunset", "Sunset", sun.Sunset, "Position lights required from sunset to sunrise"},
+			{"civil_dusk", "Night begins", sun.Dusk, "Evening civil twilight ends; night time logging begins"},
+		} {
+			if !e.time.IsZero() {
+				day = append(day, e)
+			}
+		}
+		sort.Slice(day, func(i, j int) bool { return day[i].time.Before(day[j].time) })
+
+		for _, e := range day {
+			events = append(events, newAviationEvent(e, sun, opts))
+		}
+	}
+	return events
+}
+
+// newAviationEvent creates a 1-minute event for the aviation preset
+func newAviationEvent(e aviationEvent, sun DayTimes, opts CalendarOptions) CalendarEvent {
+	lines := []string{
+		fmt.Sprintf("Time: %s", dualTime(e.time, opts.Timezone)),
+		fmt.Sprintf("Location: %s", opts.Location),
+		"Coordinates: " + FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+		"",
+		e.rule,
+	}
+	if !sun.Sunrise.IsZero() && !sun.Sunset.IsZero() {
+		lines = append(lines,
+			fmt.Sprintf("Night currency landings (sunset + 1h to sunrise - 1h): from %s", dualTime(sun.Sunset.Add(time.Hour), opts.Timezone)))
+	}
+	if !sun.Dawn.IsZero() && !sun.Dusk.IsZero() {
+		lines = append(lines,
+			fmt.Sprintf("Civil twilight: %s to %s", dualTime(sun.Dawn, opts.Timezone), dualTime(sun.Dusk, opts.Timezone)))
+	}
+
+	return CalendarEvent{
+		UID:         locationUID(e.time, opts.Lat, opts.Lng, opts.Precision, "aviation-"+e.eventType),
+		Type:        e.eventType,
+		Start:       e.time,
+		End:         e.time.Add(time.Minute),
+		Summary:     fmt.Sprintf("%s %s (%s)", e.title, opts.Clock(e.time, "15:04"), zuluTime(e.time)),
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+	}
+}
+
+// zuluTime formats t as UTC in aviation style (e.g., "1742Z")
+func zuluTime(t time.Time) string {
+	return t.UTC().Format("1504") + "Z"
+}
+
+// dualTime formats t in local time and UTC (e.g., "18:42 local, 1742Z")
+func dualTime(t time.Time, tz *time.Location) string {
+	return fmt.Sprintf("%s local, %s", t.In(tz).Format("15:04"), zuluTime(t))
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildAviationEvents(t *testing.T) {
+	tz := GetTimezone(40.7128, -74.0060)
+	opts := CalendarOptions{Lat: 40.7128, Lng: -74.0060, Location: "New York", Timezone: tz}
+	date := time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)
+
+	events := BuildAviationEvents(opts, date, 1)
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(events))
+	}
+	wantTypes := []string{"civil_dawn", "sunrise", "sunset", "civil_dusk"}
+	for i, event := range events {
+		if event.Type != wantTypes[i] {
+			t.Errorf("event %d: expected %s, got %s", i, wantTypes[i], event.Type)
+		}
+	}
+
+	dusk := events[3]
+	sun := GetDayTimes(40.7128, -74.0060, date)
+	want := "Night begins " + sun.Dusk.In(tz).Format("15:04") + " (" + sun.Dusk.UTC().Format("1504") + "Z)"
+	if dusk.Summary != want {
+		t.Errorf("expected %q, got %q", want, dusk.Summary)
+	}
+	if !strings.Contains(dusk.Description, "night time logging begins") {
+		t.Errorf("unexpected description: %s", dusk.Description)
+	}
+	if !strings.Contains(events[2].Description, "Night currency landings (sunset + 1h to sunrise - 1h): from "+dualTime(sun.Sunset.Add(time.Hour), tz)) {
+		t.Errorf("unexpected description: %s", events[2].Description)
+	}
+	if events[1].UID == EventUID(events[1].Start, 40.7128, -74.0060, "sunrise") {
+		t.Error("expected UIDs distinct from the default calendar")
+	}
+}
+
+func TestDualTime(t *testing.T) {
+	tz := time.FixedZone("EST", -5*3600)
+	tm := time.Date(2024, 3, 9, 23, 5, 0, 0, time.UTC)
+	if got := dualTime(tm, tz); got != "18:05 local, 2305Z" {
+		t.Errorf("unexpected dual time: %s", got)
+	}
+}
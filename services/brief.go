@@ -0,0 +1,134 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// BriefDay is the day a spoken brief describes
+type BriefDay struct {
+	Location  string // Spoken name; "" leaves it out
+	Today     DaySunTimes
+	Yesterday DaySunTimes
+	Timezone  *time.Location
+	Now       time.Time // Events before it are told in the past tense; zero for none
+}
+
+// numberWords spell out the small numbers a brief reads aloud
+var numberWords = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten"}
+
+// Brief returns a paragraph describing the day for text-to-speech: sunrise
+// and sunset compared with yesterday, the day length and its change, solar
+// noon, and the moon. Dates follow locale; the text stays English.
+func Brief(day BriefDay, locale Locale) string {
+	tz := day.Timezone
+	noon := day.Today.Times.SolarNoon.In(tz)
+	opening := "Today is " + locale.WeekdayDate(noon)
+	if day.Location != "" {
+		opening += " in " + day.Location
+	}
+	sentences := []string{opening + "."}
+
+	sunrise, sunset := day.Today.Sunrise, day.Today.Sunset
+	switch {
+	case sunrise != nil && sunset != nil:
+		sentences = append(sentences,
+			fmt.Sprintf("The sun %s at %s%s, and %s at %s%s.",
+				day.tense(sunrise.Time, "rises", "rose"), spokenToday(sunrise.Time.In(tz)), briefShift(sunrise.Time, day.Yesterday.Sunrise, tz),
+				day.tense(sunset.Time, "sets", "set"), spokenToday(sunset.Time.In(tz)), briefShift(sunset.Time, day.Yesterday.Sunset, tz)))
+		dayLength := sunset.Time.Sub(sunrise.Time)
+		sentence := fmt.Sprintf("That gives %s of daylight", SpokenDuration(dayLength))
+		if yesterday, ok := day.Yesterday.DayLength(); ok {
+			sentence += ", " + briefChange(dayLength-yesterday, "more", "less")
+		}
+		sentences = append(sentences, sentence+".")
+	case sunrise != nil:
+		sentences = append(sentences, fmt.Sprintf("The sun %s at %s and does not set today.", day.tense(sunrise.Time, "rises", "rose"), spokenToday(sunrise.Time.In(tz))))
+	case sunset != nil:
+		sentences = append(sentences, fmt.Sprintf("The sun %s at %s and does not rise again today.", day.tense(sunset.Time, "sets", "set"), spokenToday(sunset.Time.In(tz))))
+	case day.Today.PolarDay():
+		sentences = append(sentences, "The sun stays up all day today.")
+	default:
+		sentences = append(sentences, "The sun does not rise today.")
+	}
+
+	if elevation := day.Today.NoonElevation; elevation > 0 {
+		sentences = append(sentences, fmt.Sprintf("The sun is highest at %s, %s above the horizon.",
+			spokenToday(noon), plural(int(math.Round(elevation)), "degree")))
+	}
+
+	moon := GetMoonPhase(noon)
+	sentences = append(sentences, briefMoon(moon))
+	return strings.Join(sentences, " ")
+}
+
+// tense returns the verb for an event at t: past once it has happened
+func (d BriefDay) tense(t time.Time, present, past string) string {
+	if !d.Now.IsZero() && t.Before(d.Now) {
+		return past
+	}
+	return present
+}
+
+// spokenToday formats a clock time today as it is read aloud, e.g. "6:42
+// this morning" or "9:57 tonight"
+func spokenToday(t time.Time) string {
+	spoken := SpokenTime(t)
+	if t.Hour() < 5 {
+		return strings.Replace(spoken, "at night", "early this morning", 1)
+	}
+	return strings.NewReplacer("in the morning", "this morning", "in the afternoon", "this afternoon",
+		"in the evening", "this evening", "at night", "tonight").Replace(spoken)
+}
+
+// briefShift describes how much later or earlier an event is than the same
+// event yesterday, e.g. ", two minutes earlier than yesterday", or "" if
+// there was none
+func briefShift(t time.Time, yesterday *SunEvent, tz *time.Location) string {
+	if yesterday == nil {
+		return ""
+	}
+	today, before := t.In(tz), yesterday.Time.In(tz)
+	clock := func(t time.Time) time.Duration {
+		return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	return ", " + briefChange(clock(today)-clock(before), "later", "earlier")
+}
+
+// briefChange describes a change since yesterday in whole minutes, e.g. "two
+// minutes later than yesterday" or "the same as yesterday"
+func briefChange(d time.Duration, positive, negative string) string {
+	word := positive
+	if d < 0 {
+		d, word = -d, negative
+	}
+	minutes := int(d.Round(time.Minute).Minutes())
+	if minutes == 0 {
+		return "the same as yesterday"
+	}
+	amount := SpokenDuration(d)
+	if minutes < len(numberWords) {
+		amount = numberWords[minutes] + " minute"
+		if minutes != 1 {
+			amount += "s"
+		}
+	}
+	return fmt.Sprintf("%s %s than yesterday", amount, word)
+}
+
+// briefMoon describes the moon's phase, e.g. "The moon is a waxing
+// crescent, 23 percent lit."
+func briefMoon(moon MoonPhase) string {
+	lit := int(math.Round(moon.Illumination * 100))
+	switch moon.Name {
+	case "New moon":
+		return "The moon is new."
+	case "Full moon":
+		return "The moon is full."
+	case "First quarter", "Last quarter":
+		return fmt.Sprintf("The moon is at its %s, %d percent lit.", strings.ToLower(moon.Name), lit)
+	}
+	return fmt.Sprintf("The moon is a %s, %d percent lit.", strings.ToLower(moon.Name), lit)
+}
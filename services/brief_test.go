@@ -0,0 +1,87 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// briefDay returns the day of noon at a location, with yesterday
+func briefDay(lat, lng float64, noon time.Time) BriefDay {
+	return BriefDay{
+		Today:     GetSunTimes(lat, lng, noon),
+		Yesterday: GetSunTimes(lat, lng, noon.AddDate(0, 0, -1)),
+		Timezone:  noon.Location(),
+	}
+}
+
+func TestBrief(t *testing.T) {
+	copenhagen, _ := time.LoadLocation("Europe/Copenhagen")
+	day := briefDay(55.6761, 12.5683, time.Date(2025, 11, 3, 12, 0, 0, 0, copenhagen))
+	day.Location = "Copenhagen"
+
+	want := "Today is Monday, November 3 in Copenhagen. " +
+		"The sun rises at 7:20 this morning, two minutes later than yesterday, and sets at 4:28 this afternoon, two minutes earlier than yesterday. " +
+		"That gives 9 hours and 7 minutes of daylight, four minutes less than yesterday. " +
+		"The sun is highest at 11:54 this morning, 19 degrees above the horizon. " +
+		"The moon is a waxing gibbous, 94 percent lit."
+	if got := Brief(day, DefaultLocale); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+
+	// Past events in the past tense, dates in the locale's order
+	day.Now = time.Date(2025, 11, 3, 10, 0, 0, 0, copenhagen)
+	got := Brief(day, Locale{DayFirst: true})
+	if !strings.HasPrefix(got, "Today is Monday 3 November in Copenhagen. The sun rose at 7:20 this morning") || !strings.Contains(got, "and sets at 4:28") {
+		t.Errorf("expected sunrise in the past tense and a day-first date, got %q", got)
+	}
+}
+
+func TestBrief_Polar(t *testing.T) {
+	longyearbyen, _ := time.LoadLocation("Arctic/Longyearbyen")
+	got := Brief(briefDay(78.2232, 15.6267, time.Date(2025, 6, 21, 12, 0, 0, 0, longyearbyen)), DefaultLocale)
+	if !strings.HasPrefix(got, "Today is Saturday, June 21. The sun stays up all day today. The sun is highest at") {
+		t.Errorf("unexpected polar day brief %q", got)
+	}
+	got = Brief(briefDay(78.2232, 15.6267, time.Date(2025, 12, 21, 12, 0, 0, 0, longyearbyen)), DefaultLocale)
+	if !strings.Contains(got, "The sun does not rise today.") || strings.Contains(got, "highest") {
+		t.Errorf("unexpected polar night brief %q", got)
+	}
+}
+
+func TestBriefChange(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{2 * time.Minute, "two minutes later than yesterday"},
+		{-time.Minute, "one minute earlier than yesterday"},
+		{20 * time.Second, "the same as yesterday"},
+		{-12 * time.Minute, "12 minutes earlier than yesterday"},
+		{time.Hour + 3*time.Minute, "1 hour and 3 minutes later than yesterday"},
+	}
+	for _, tt := range tests {
+		if got := briefChange(tt.d, "later", "earlier"); got != tt.want {
+			t.Errorf("%v: expected %q, got %q", tt.d, tt.want, got)
+		}
+	}
+}
+
+func TestSpokenToday(t *testing.T) {
+	tests := []struct {
+		hour, minute int
+		want         string
+	}{
+		{3, 40, "3:40 early this morning"},
+		{6, 42, "6:42 this morning"},
+		{12, 0, "noon"},
+		{16, 28, "4:28 this afternoon"},
+		{18, 5, "6:05 this evening"},
+		{22, 0, "10 tonight"},
+	}
+	for _, tt := range tests {
+		if got := spokenToday(time.Date(2025, 6, 21, tt.hour, tt.minute, 0, 0, time.UTC)); got != tt.want {
+			t.Errorf("%02d:%02d: expected %q, got %q", tt.hour, tt.minute, tt.want, got)
+		}
+	}
+}
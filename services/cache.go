@@ -0,0 +1,139 @@
+package services
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache is a cache whose entries can be listed and removed, for the admin
+// endpoints
+type Cache interface {
+	// Entries lists the cached entries by key
+	Entries() []CacheEntry
+	// Delete removes the entry for key, reporting whether there was one
+	Delete(key string) bool
+	// Clear removes every entry, returning how many there were
+	Clear() int
+}
+
+// CacheEntry describes a cached entry
+type CacheEntry struct {
+	Key     string    `json:"key"`
+	Size    int       `json:"size"` // Bytes; the size of the JSON encoding for values that aren't bytes
+	Expires time.Time `json:"expires"`
+}
+
+// cacheEntry is a cached value with its expiry time
+type cacheEntry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// ttlCache is a concurrency-safe in-memory cache whose entries expire after a
+// fixed TTL. When full, the entry closest to expiry is evicted.
+type ttlCache[V any] struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]cacheEntry[V]
+	now        Clock
+}
+
+// newTTLCache creates a cache holding up to maxEntries values for ttl each
+func newTTLCache[V any](ttl time.Duration, maxEntries int) *ttlCache[V] {
+	return &ttlCache[V]{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]cacheEntry[V]),
+		now:        time.Now,
+	}
+}
+
+// Get returns the cached value for key, if present and not expired
+func (c *ttlCache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || c.now().After(entry.expires) {
+		delete(c.entries, key)
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// Set stores value under key, evicting an entry if the cache is full
+func (c *ttlCache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxEntries {
+		c.evictLocked()
+	}
+	c.entries[key] = cacheEntry[V]{value: value, expires: c.now().Add(c.ttl)}
+}
+
+// Delete removes the entry for key, reporting whether it was present
+func (c *ttlCache[V]) Delete(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.entries[key]
+	delete(c.entries, key)
+	return ok
+}
+
+// Entries lists the unexpired entries by key
+func (c *ttlCache[V]) Entries() []CacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	entries := make([]CacheEntry, 0, len(c.entries))
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			continue
+		}
+		size := 0
+		if b, err := json.Marshal(entry.value); err == nil {
+			size = len(b)
+		}
+		entries = append(entries, CacheEntry{Key: key, Size: size, Expires: entry.expires})
+	}
+	slices.SortFunc(entries, func(a, b CacheEntry) int { return strings.Compare(a.Key, b.Key) })
+	return entries
+}
+
+// Clear removes every entry, returning how many there were
+func (c *ttlCache[V]) Clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := len(c.entries)
+	clear(c.entries)
+	return n
+}
+
+// evictLocked removes expired entries, or the oldest entry if none have
+// expired. The caller must hold c.mu.
+func (c *ttlCache[V]) evictLocked() {
+	now := c.now()
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		if oldestKey == "" || entry.expires.Before(oldest) {
+			oldestKey, oldest = key, entry.expires
+		}
+	}
+	if len(c.entries) >= c.maxEntries && oldestKey != "" {
+		delete(c.entries, oldestKey)
+	}
+}
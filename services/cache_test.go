@@ -0,0 +1,76 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLCache_GetSet(t *testing.T) {
+	c := newTTLCache[int](time.Minute, 10)
+
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected miss on empty cache")
+	}
+
+	c.Set("a", 1)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("expected hit with 1, got %d (ok=%v)", v, ok)
+	}
+}
+
+func TestTTLCache_Expiry(t *testing.T) {
+	c := newTTLCache[int](time.Millisecond, 10)
+
+	c.Set("a", 1)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected entry to have expired")
+	}
+}
+
+func TestTTLCache_Eviction(t *testing.T) {
+	c := newTTLCache[int](time.Minute, 2)
+
+	c.Set("a", 1)
+	time.Sleep(time.Millisecond)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected oldest entry to be evicted")
+	}
+	if _, ok := c.Get("b"); !ok {
+		t.Error("expected b to remain")
+	}
+	if _, ok := c.Get("c"); !ok {
+		t.Error("expected c to be stored")
+	}
+}
+
+func TestTTLCache_EntriesDeleteClear(t *testing.T) {
+	c := newTTLCache[string](time.Minute, 10)
+	c.Set("b", "two")
+	c.Set("a", "one")
+
+	entries := c.Entries()
+	if len(entries) != 2 || entries[0].Key != "a" || entries[1].Key != "b" {
+		t.Fatalf("expected entries a and b by key, got %+v", entries)
+	}
+	if entries[0].Size != len(`"one"`) {
+		t.Errorf("expected the size of the JSON encoding, got %d", entries[0].Size)
+	}
+	if time.Until(entries[0].Expires) <= 0 || time.Until(entries[0].Expires) > time.Minute {
+		t.Errorf("expected expiry within the TTL, got %v", entries[0].Expires)
+	}
+
+	if !c.Delete("a") || c.Delete("a") {
+		t.Error("expected Delete to report whether the entry was present")
+	}
+	if n := c.Clear(); n != 1 {
+		t.Errorf("expected Clear to remove 1 entry, got %d", n)
+	}
+	if len(c.Entries()) != 0 {
+		t.Error("expected no entries after Clear")
+	}
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// CelestrakURL is the public Celestrak orbital data service
+	CelestrakURL = "https://celestrak.org"
+
+	// ISSCatalogNumber is the NORAD catalog number of the International Space
+	// Station
+	ISSCatalogNumber = 25544
+
+	// Celestrak updates element sets a few times a day and asks clients not to
+	// fetch them more often
+	tleCacheTTL  = 6 * time.Hour
+	tleCacheSize = 100
+)
+
+// TLEProvider fetches current two-line element sets of satellites
+type TLEProvider interface {
+	// TLE returns the latest element set of the satellite with a NORAD
+	// catalog number
+	TLE(ctx context.Context, catalogNumber int) (TLE, error)
+}
+
+// Celestrak is a TLEProvider backed by Celestrak's GP element sets. Element
+// sets are cached for six hours.
+type Celestrak struct {
+	baseURL string
+	client  *http.Client
+	cache   *ttlCache[TLE]
+}
+
+// NewCelestrak creates a TLE provider for the Celestrak service at baseURL
+func NewCelestrak(baseURL string) *Celestrak {
+	return &Celestrak{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+		cache:   newTTLCache[TLE](tleCacheTTL, tleCacheSize),
+	}
+}
+
+// Cache returns the cached element sets
+func (c *Celestrak) Cache() Cache {
+	return c.cache
+}
+
+// TLE returns the latest element set of a satellite
+func (c *Celestrak) TLE(ctx context.Context, catalogNumber int) (TLE, error) {
+	key := strconv.Itoa(catalogNumber)
+	if tle, ok := c.cache.Get(key); ok {
+		return tle, nil
+	}
+
+	params := url.Values{}
+	params.Set("CATNR", key)
+	params.Set("FORMAT", "TLE")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/NORAD/elements/gp.php?"+params.Encode(), nil)
+	if err != nil {
+		return TLE{}, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return TLE{}, fmt.Errorf("TLE request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return TLE{}, fmt.Errorf("TLE request failed: status %d", resp.StatusCode)
+	}
+
+	// The response is the name line and the two element lines, or a message
+	// such as "No GP data found"
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() && len(lines) < 3 {
+		if line := strings.TrimRight(scanner.Text(), " \r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return TLE{}, fmt.Errorf("TLE request failed: %w", err)
+	}
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "1 ") || !strings.HasPrefix(lines[2], "2 ") {
+		return TLE{}, fmt.Errorf("no TLE for catalog number %d", catalogNumber)
+	}
+
+	tle := TLE{Name: strings.TrimSpace(lines[0]), Line1: lines[1], Line2: lines[2]}
+	c.cache.Set(key, tle)
+	return tle, nil
+}
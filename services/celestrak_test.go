@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCelestrak_TLE(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		q := r.URL.Query()
+		if r.URL.Path != "/NORAD/elements/gp.php" || q.Get("CATNR") != "25544" || q.Get("FORMAT") != "TLE" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		w.Write([]byte("ISS (ZARYA)             \r\n" + testISS.Line1 + "\r\n" + testISS.Line2 + "\r\n"))
+	}))
+	defer server.Close()
+
+	c := NewCelestrak(server.URL)
+	tle, err := c.TLE(context.Background(), ISSCatalogNumber)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tle != testISS {
+		t.Errorf("expected %+v, got %+v", testISS, tle)
+	}
+
+	if _, err := c.TLE(context.Background(), ISSCatalogNumber); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a cached TLE, got %d requests", calls.Load())
+	}
+}
+
+func TestCelestrak_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		message string
+	}{
+		{"server error", http.StatusInternalServerError, ``, "status 500"},
+		{"unknown satellite", http.StatusOK, "No GP data found\n", "no TLE for catalog number 25544"},
+		{"truncated", http.StatusOK, "ISS (ZARYA)\n" + testISS.Line1 + "\n", "no TLE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := NewCelestrak(server.URL).TLE(context.Background(), ISSCatalogNumber)
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
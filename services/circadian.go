@@ -0,0 +1,106 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sixdouglas/suncalc"
+)
+
+// CircadianTransition is a change of lighting in a circadian schedule, when
+// the sun crosses an elevation
+type CircadianTransition struct {
+	Phase      string    // "wake", "morning", "daylight", "evening", "sunset", "wind_down", or "night"
+	Title      string    // e.g. "Wake light"
+	Time       time.Time // When the lights should reach the new setting
+	Elevation  float64   // Sun elevation at Time, in degrees
+	Rising     bool      // The sun is rising (morning) rather than setting
+	Kelvin     int       // Color temperature
+	Brightness int       // Percent
+}
+
+// circadianPhases are the lighting settings of a circadian schedule, in the
+// order of the day, each starting when the sun crosses its elevation
+var circadianPhases = []CircadianTransition{
+	{Phase: "wake", Title: "Wake light", Elevation: -6, Rising: true, Kelvin: 2700, Brightness: 40},
+	{Phase: "morning", Title: "Morning light", Elevation: -0.833, Rising: true, Kelvin: 4000, Brightness: 80},
+	{Phase: "daylight", Title: "Daylight", Elevation: 6, Rising: true, Kelvin: 5500, Brightness: 100},
+	{Phase: "evening", Title: "Evening light", Elevation: 6, Kelvin: 4000, Brightness: 80},
+	{Phase: "sunset", Title: "Sunset light", Elevation: -0.833, Kelvin: 2700, Brightness: 60},
+	{Phase: "wind_down", Title: "Wind-down", Elevation: -6, Kelvin: 2200, Brightness: 30},
+	{Phase: "night", Title: "Night light", Elevation: -12, Kelvin: 1800, Brightness: 10},
+}
+
+// CircadianSchedule returns the lighting transitions of the solar day
+// containing date: a warm wake light from civil dawn, brightening through
+// sunrise to cool daylight once the sun is 6° up, and warming again in the
+// evening to a dim wind-down after civil dusk and night light after nautical
+// dusk. Transitions the sun does not reach that day (e.g., near the poles)
+// are left out, so the previous setting holds.
+func CircadianSchedule(lat, lng float64, date time.Time) []CircadianTransition {
+	noon := suncalc.GetTimes(date, lat, lng)[suncalc.SolarNoon].Value
+	_, noonElevation := SunPosition(lat, lng, noon)
+
+	var schedule []CircadianTransition
+	for _, phase := range circadianPhases {
+		rising, setting := altitudeTimes(lat, lng, noon, noonElevation, phase.Elevation)
+		phase.Time = setting
+		if phase.Rising {
+			phase.Time = rising
+		}
+		if !phase.Time.IsZero() {
+			schedule = append(schedule, phase)
+		}
+	}
+	sort.SliceStable(schedule, func(i, j int) bool { return schedule[i].Time.Before(schedule[j].Time) })
+	return schedule
+}
+
+// BuildCircadianEvents generates an event for each transition of the
+// circadian schedules of the days starting at start
+func BuildCircadianEvents(opts CalendarOptions, start time.Time, days int) []CalendarEvent {
+	var events []CalendarEvent
+	for i := 0; i < days; i++ {
+		for _, t := range CircadianSchedule(opts.Lat, opts.Lng, start.AddDate(0, 0, i)) {
+			events = append(events, newCircadianEvent(t, opts))
+		}
+	}
+	return events
+}
+
+// newCircadianEvent creates a 1-minute event for a lighting transition
+func newCircadianEvent(t CircadianTransition, opts CalendarOptions) CalendarEvent {
+	direction := "setting"
+	if t.Rising {
+		direction = "rising"
+	}
+	sun := fmt.Sprintf("%g° above the horizon", math.Round(t.Elevation))
+	switch {
+	case math.Abs(t.Elevation) < 1:
+		sun = "on the horizon"
+	case t.Elevation < 0:
+		sun = fmt.Sprintf("%g° below the horizon", math.Round(-t.Elevation))
+	}
+	lines := []string{
+		fmt.Sprintf("Time: %s", opts.Clock(t.Time, "15:04")),
+		fmt.Sprintf("Location: %s", opts.Location),
+		"Coordinates: " + FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+		"",
+		fmt.Sprintf("Color temperature: %dK", t.Kelvin),
+		fmt.Sprintf("Brightness: %d%%", t.Brightness),
+		fmt.Sprintf("Sun: %s, %s", sun, direction),
+	}
+
+	return CalendarEvent{
+		UID:         locationUID(t.Time, opts.Lat, opts.Lng, opts.Precision, "circadian-"+t.Phase),
+		Type:        "circadian_" + t.Phase,
+		Start:       t.Time,
+		End:         t.Time.Add(time.Minute),
+		Summary:     fmt.Sprintf("%s %s, %dK %d%%", t.Title, opts.Clock(t.Time, "15:04"), t.Kelvin, t.Brightness),
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCircadianSchedule(t *testing.T) {
+	tz := GetTimezone(55.6761, 12.5683)
+	schedule := CircadianSchedule(55.6761, 12.5683, time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC))
+
+	var phases []string
+	for i, transition := range schedule {
+		phases = append(phases, transition.Phase)
+		if i > 0 && !transition.Time.After(schedule[i-1].Time) {
+			t.Error("expected transitions in chronological order")
+		}
+	}
+	if got := strings.Join(phases, ","); got != "wake,morning,daylight,evening,sunset,wind_down,night" {
+		t.Fatalf("unexpected phases %s", got)
+	}
+	// Sunrise and sunset match the sun calendar's
+	if morning := schedule[1].Time.In(tz).Format("15:04"); morning != "08:37" {
+		t.Errorf("expected morning light at sunrise (08:37), got %s", morning)
+	}
+	if sunset := schedule[4].Time.In(tz).Format("15:04"); sunset != "15:38" {
+		t.Errorf("expected sunset light at sunset (15:38), got %s", sunset)
+	}
+	if daylight := schedule[2]; daylight.Kelvin <= schedule[1].Kelvin || daylight.Brightness != 100 {
+		t.Errorf("expected the coolest, brightest light in daylight, got %+v", daylight)
+	}
+	if night := schedule[6]; night.Kelvin >= schedule[5].Kelvin || night.Brightness >= schedule[5].Brightness {
+		t.Errorf("expected night light warmer and dimmer than wind-down, got %+v", night)
+	}
+}
+
+func TestCircadianSchedule_Polar(t *testing.T) {
+	// Tromsø: the sun never sets at midsummer and never rises at midwinter
+	phases := func(date time.Time) string {
+		var names []string
+		for _, transition := range CircadianSchedule(69.65, 18.96, date) {
+			names = append(names, transition.Phase)
+		}
+		return strings.Join(names, ",")
+	}
+	if got := phases(time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)); got != "daylight,evening" {
+		t.Errorf("expected only daylight at midsummer, got %s", got)
+	}
+	if got := phases(time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC)); got != "wake,wind_down,night" {
+		t.Errorf("expected only twilight at midwinter, got %s", got)
+	}
+}
+
+func TestBuildCircadianEvents(t *testing.T) {
+	tz := GetTimezone(55.6761, 12.5683)
+	opts := CalendarOptions{Lat: 55.6761, Lng: 12.5683, Location: "Copenhagen", Timezone: tz}
+
+	events := BuildCircadianEvents(opts, time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC), 2)
+	if len(events) != 14 {
+		t.Fatalf("expected 7 transitions a day, got %d", len(events))
+	}
+	morning := events[1]
+	if morning.Type != "circadian_morning" || morning.Summary != "Morning light 08:37, 4000K 80%" {
+		t.Errorf("unexpected event %s: %s", morning.Type, morning.Summary)
+	}
+	for _, want := range []string{"Color temperature: 4000K", "Brightness: 80%", "Sun: on the horizon, rising"} {
+		if !strings.Contains(morning.Description, want) {
+			t.Errorf("expected %q in description:\n%s", want, morning.Description)
+		}
+	}
+	if !strings.Contains(events[5].Description, "Sun: 6° below the horizon, setting") {
+		t.Errorf("expected wind-down at civil dusk, got:\n%s", events[5].Description)
+	}
+	if events[1].UID == events[8].UID {
+		t.Error("expected distinct UIDs each day")
+	}
+}
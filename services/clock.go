@@ -0,0 +1,14 @@
+package services
+
+import "time"
+
+// Clock returns the current time. Components generating time dependent
+// output take a Clock instead of calling time.Now, so tests can fix the time.
+type Clock func() time.Time
+
+// FixedClock returns a Clock that always reports t
+func FixedClock(t time.Time) Clock {
+	return func() time.Time {
+		return t
+	}
+}
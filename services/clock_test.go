@@ -0,0 +1,15 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFixedClock(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := FixedClock(at)
+
+	if !clock().Equal(at) || !clock().Equal(at) {
+		t.Errorf("expected %s on every call", at)
+	}
+}
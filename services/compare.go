@@ -0,0 +1,61 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+// SunComparison holds the differences between a second location's sun times
+// and a first's on the same date. Sunrise and sunset differences compare
+// local clock times, so locations in different timezones are compared as
+// their residents see them. A nil difference means either location has no
+// such event (or, for the day length, only one of them).
+type SunComparison struct {
+	Sunrise   *time.Duration // Positive if the second location's sunrise is later
+	Sunset    *time.Duration // Positive if the second location's sunset is later
+	DayLength *time.Duration // Positive if the second location's day is longer
+}
+
+// CompareSunTimes compares b's sun times in tzB to a's in tzA
+func CompareSunTimes(a DaySunTimes, tzA *time.Location, b DaySunTimes, tzB *time.Location) SunComparison {
+	var c SunComparison
+	if a.Sunrise != nil && b.Sunrise != nil {
+		d := clockTime(b.Sunrise.Time.In(tzB)) - clockTime(a.Sunrise.Time.In(tzA))
+		c.Sunrise = &d
+	}
+	if a.Sunset != nil && b.Sunset != nil {
+		d := clockTime(b.Sunset.Time.In(tzB)) - clockTime(a.Sunset.Time.In(tzA))
+		c.Sunset = &d
+	}
+	dayA, okA := a.DayLength()
+	dayB, okB := b.DayLength()
+	if okA && okB {
+		d := dayB - dayA
+		c.DayLength = &d
+	}
+	return c
+}
+
+// clockTime returns the time of day of t on its clock, as the time since
+// midnight
+func clockTime(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
+
+// FormatDifference describes a difference rounded to the minute with the word
+// for a positive or a negative one (e.g., "1h 12m later", "38m shorter", or
+// "same" below half a minute)
+func FormatDifference(d time.Duration, positive, negative string) string {
+	word := positive
+	if d < 0 {
+		d, word = -d, negative
+	}
+	minutes := int(d.Round(time.Minute).Minutes())
+	switch {
+	case minutes == 0:
+		return "same"
+	case minutes < 60:
+		return fmt.Sprintf("%dm %s", minutes, word)
+	}
+	return fmt.Sprintf("%dh %dm %s", minutes/60, minutes%60, word)
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompareSunTimes_AcrossTimezones(t *testing.T) {
+	copenhagen, _ := time.LoadLocation("Europe/Copenhagen")
+	london, _ := time.LoadLocation("Europe/London")
+	a := GetSunTimes(55.6761, 12.5683, time.Date(2024, 6, 21, 12, 0, 0, 0, copenhagen))
+	b := GetSunTimes(51.5074, -0.1278, time.Date(2024, 6, 21, 12, 0, 0, 0, london))
+
+	c := CompareSunTimes(a, copenhagen, b, london)
+	if c.Sunrise == nil || c.Sunset == nil || c.DayLength == nil {
+		t.Fatalf("expected all differences, got %+v", c)
+	}
+	// London's clock is an hour behind, so its sunrise is later on the
+	// clock than Copenhagen's (04:43 vs 04:25) and its sunset earlier
+	// (21:21 vs 21:57), while its day is shorter
+	if *c.Sunrise < 10*time.Minute || *c.Sunrise > 25*time.Minute {
+		t.Errorf("expected London's sunrise about 18m later, got %v", *c.Sunrise)
+	}
+	if *c.Sunset > -30*time.Minute || *c.Sunset < -45*time.Minute {
+		t.Errorf("expected London's sunset about 36m earlier, got %v", *c.Sunset)
+	}
+	if *c.DayLength > -45*time.Minute || *c.DayLength < -65*time.Minute {
+		t.Errorf("expected London's day about 55m shorter, got %v", *c.DayLength)
+	}
+}
+
+func TestCompareSunTimes_PolarDay(t *testing.T) {
+	a := GetSunTimes(55.6761, 12.5683, time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC))
+	b := GetSunTimes(78.2232, 15.6267, time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC))
+
+	c := CompareSunTimes(a, time.UTC, b, time.UTC)
+	if c.Sunrise != nil || c.Sunset != nil {
+		t.Errorf("expected no sunrise or sunset differences in polar day, got %+v", c)
+	}
+	if c.DayLength == nil || *c.DayLength <= 0 {
+		t.Errorf("expected a longer day in polar day, got %v", c.DayLength)
+	}
+}
+
+func TestFormatDifference(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "same"},
+		{20 * time.Second, "same"},
+		{38 * time.Minute, "38m later"},
+		{-(72*time.Minute + 20*time.Second), "1h 12m earlier"},
+		{2 * time.Hour, "2h 0m later"},
+	}
+	for _, tt := range tests {
+		if got := FormatDifference(tt.d, "later", "earlier"); got != tt.want {
+			t.Errorf("FormatDifference(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package services
+
+// Azimuth formats accepted by FormatAzimuth
+const (
+	AzimuthDegrees = "degrees" // "67.5°"
+	AzimuthCompass = "compass" // "ENE"
+	AzimuthBoth    = "both"    // "ENE (67.5°)"
+)
+
+// compassPoints are the 16 compass directions, clockwise from north
+var compassPoints = []string{
+	"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE",
+	"S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW",
+}
+
+// CompassPoint returns the 16-point compass direction (e.g., "ENE") closest
+// to an azimuth in degrees clockwise from north
+func CompassPoint(azimuth float64) string {
+	return compassPoints[int(azimuth/22.5+0.5)%len(compassPoints)]
+}
+
+// FormatAzimuth formats an azimuth in degrees clockwise from north as degrees,
+// a compass point, or both. Unknown formats (including "") use degrees.
+func FormatAzimuth(azimuth float64, format string) string {
+	return DefaultLocale.Azimuth(azimuth, format)
+}
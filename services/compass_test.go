@@ -0,0 +1,44 @@
+package services
+
+import "testing"
+
+func TestCompassPoint(t *testing.T) {
+	tests := []struct {
+		azimuth float64
+		want    string
+	}{
+		{0, "N"},
+		{11.2, "N"},
+		{11.3, "NNE"},
+		{67.5, "ENE"},
+		{90, "E"},
+		{180, "S"},
+		{247, "WSW"},
+		{349, "N"},
+		{360, "N"},
+	}
+
+	for _, tt := range tests {
+		if got := CompassPoint(tt.azimuth); got != tt.want {
+			t.Errorf("CompassPoint(%g) = %s, want %s", tt.azimuth, got, tt.want)
+		}
+	}
+}
+
+func TestFormatAzimuth(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{AzimuthDegrees, "67.5°"},
+		{"", "67.5°"},
+		{AzimuthCompass, "ENE"},
+		{AzimuthBoth, "ENE (67.5°)"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatAzimuth(67.5, tt.format); got != tt.want {
+			t.Errorf("FormatAzimuth(67.5, %q) = %s, want %s", tt.format, got, tt.want)
+		}
+	}
+}
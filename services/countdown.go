@@ -0,0 +1,60 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// countdownInterval is the number of days between countdown events
+const countdownInterval = 7
+
+// BuildSeasonCountdownEvents generates weekly all-day events counting down
+// the days until the next start of each season (e.g., "63 days until the
+// summer solstice"), named for the location's hemisphere
+func BuildSeasonCountdownEvents(opts CalendarOptions, seasons []string, start time.Time, days int) []CalendarEvent {
+	var events []CalendarEvent
+	for i := 0; i < days; i++ {
+		date := start.AddDate(0, 0, i)
+		local := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, opts.Timezone)
+		for _, season := range seasons {
+			// The first start on a later local date
+			target := NextSeasonStart(season, opts.Lat, local.AddDate(0, 0, 1).Add(-time.Nanosecond))
+			if left := daysBetween(local, target.In(opts.Timezone)); left%countdownInterval == 0 {
+				events = append(events, newCountdownEvent(local, left, season, target, opts))
+			}
+		}
+	}
+	return events
+}
+
+// daysBetween returns the number of calendar days from a's date to b's
+func daysBetween(a, b time.Time) int {
+	dateA := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	dateB := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(dateB.Sub(dateA).Hours() / 24)
+}
+
+// newCountdownEvent creates an all-day countdown event on date
+func newCountdownEvent(date time.Time, daysLeft int, season string, target time.Time, opts CalendarOptions) CalendarEvent {
+	name := SeasonStartName(season)
+	local := target.In(opts.Timezone)
+
+	lines := []string{
+		fmt.Sprintf("%s: %s", strings.ToUpper(name[:1])+name[1:], opts.Locale.LongDate(local)+local.Format(" at 15:04 MST")),
+		fmt.Sprintf("Location: %s", opts.Location),
+		"",
+		plural(daysLeft/countdownInterval, "week") + " to go",
+	}
+
+	return CalendarEvent{
+		UID:         locationUID(date, opts.Lat, opts.Lng, opts.Precision, "countdown-"+season),
+		Type:        "season_countdown",
+		Start:       date,
+		End:         date.AddDate(0, 0, 1),
+		Summary:     fmt.Sprintf("%d days until the %s", daysLeft, name),
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+		AllDay:      true,
+	}
+}
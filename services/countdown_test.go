@@ -0,0 +1,51 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildSeasonCountdownEvents(t *testing.T) {
+	opts := testCalendarOptions()
+	start := time.Date(2025, 5, 20, 0, 0, 0, 0, time.UTC)
+
+	events := BuildSeasonCountdownEvents(opts, []string{SeasonSummer}, start, 32)
+
+	// The 2025 June solstice is on June 21 in Copenhagen
+	want := []string{"28 days until the summer solstice", "21 days until the summer solstice", "14 days until the summer solstice", "7 days until the summer solstice"}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d", len(want), len(events))
+	}
+	for i, event := range events {
+		if event.Summary != want[i] {
+			t.Errorf("event %d: expected %q, got %q", i, want[i], event.Summary)
+		}
+		if !event.AllDay || event.Start.Weekday() != time.Saturday {
+			t.Errorf("expected all-day events on the solstice's weekday, got %s", event.Start)
+		}
+	}
+	if got := events[3].Start.Format("2006-01-02"); got != "2025-06-14" {
+		t.Errorf("expected the last countdown on 2025-06-14, got %s", got)
+	}
+	if !strings.Contains(events[3].Description, "1 week to go") {
+		t.Errorf("unexpected description: %q", events[3].Description)
+	}
+
+	// The solstice itself and the next year's countdown begin the next day
+	after := BuildSeasonCountdownEvents(opts, []string{SeasonSummer}, time.Date(2025, 6, 21, 0, 0, 0, 0, time.UTC), 8)
+	if len(after) != 1 || after[0].Summary != "364 days until the summer solstice" {
+		t.Errorf("expected the next year's countdown to start, got %+v", after)
+	}
+}
+
+func TestBuildSeasonCountdownEvents_SouthernHemisphere(t *testing.T) {
+	opts := CalendarOptions{Lat: -33.8688, Lng: 151.2093, Location: "Sydney", Timezone: GetTimezone(-33.8688, 151.2093)}
+	start := time.Date(2025, 12, 10, 0, 0, 0, 0, time.UTC)
+
+	// The December solstice (15:03 UTC) is on December 22 in Sydney
+	events := BuildSeasonCountdownEvents(opts, []string{SeasonSummer}, start, 10)
+	if len(events) != 1 || events[0].Summary != "7 days until the summer solstice" || events[0].Start.Day() != 15 {
+		t.Errorf("expected a countdown to the December solstice on December 15, got %+v", events)
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"sort"
+)
+
+// EventsDigest returns a digest of a set of events, covering each event's
+// UID and content but not their order. Two sets have the same digest only if
+// they hold the same events, unchanged.
+func EventsDigest(events []CalendarEvent) string {
+	entries := make([]string, len(events))
+	for i, event := range events {
+		entries[i] = event.UID + "\x00" + eventHash(event) + "\n"
+	}
+	sort.Strings(entries)
+
+	h := sha256.New()
+	for _, entry := range entries {
+		h.Write([]byte(entry))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// ChangedEvents returns the events of current that are not in previous, or
+// whose content differs there, keeping their order
+func ChangedEvents(previous, current []CalendarEvent) []CalendarEvent {
+	known := make(map[string]string, len(previous))
+	for _, event := range previous {
+		known[event.UID] = eventHash(event)
+	}
+
+	changed := []CalendarEvent{}
+	for _, event := range current {
+		if hash, ok := known[event.UID]; !ok || hash != eventHash(event) {
+			changed = append(changed, event)
+		}
+	}
+	return changed
+}
+
+// EventChange is an event whose content differs between two sets, with the
+// names of the fields that differ (e.g., "start", "description")
+type EventChange struct {
+	Before CalendarEvent
+	After  CalendarEvent
+	Fields []string
+}
+
+// EventDiff is the difference between two sets of events, matched by UID
+type EventDiff struct {
+	Added     []CalendarEvent // Only in the second set
+	Removed   []CalendarEvent // Only in the first set
+	Changed   []EventChange
+	Unchanged int
+}
+
+// DiffEvents compares two sets of events by UID. Added and changed events
+// keep their order in after, removed events their order in before. Times
+// are compared as instants, so the same event in another timezone is
+// unchanged.
+func DiffEvents(before, after []CalendarEvent) EventDiff {
+	previous := make(map[string]CalendarEvent, len(before))
+	for _, event := range before {
+		previous[event.UID] = event
+	}
+
+	diff := EventDiff{Added: []CalendarEvent{}, Removed: []CalendarEvent{}, Changed: []EventChange{}}
+	current := make(map[string]bool, len(after))
+	for _, event := range after {
+		current[event.UID] = true
+		old, ok := previous[event.UID]
+		switch fields := changedFields(old, event); {
+		case !ok:
+			diff.Added = append(diff.Added, event)
+		case len(fields) > 0:
+			diff.Changed = append(diff.Changed, EventChange{Before: old, After: event, Fields: fields})
+		default:
+			diff.Unchanged++
+		}
+	}
+	for _, event := range before {
+		if !current[event.UID] {
+			diff.Removed = append(diff.Removed, event)
+		}
+	}
+	return diff
+}
+
+// changedFields returns the names of the fields that differ between two
+// versions of an event
+func changedFields(a, b CalendarEvent) []string {
+	var fields []string
+	if a.Type != b.Type {
+		fields = append(fields, "type")
+	}
+	if a.AllDay != b.AllDay {
+		fields = append(fields, "all_day")
+	}
+	if !a.Start.Equal(b.Start) {
+		fields = append(fields, "start")
+	}
+	if !a.End.Equal(b.End) {
+		fields = append(fields, "end")
+	}
+	if a.Summary != b.Summary {
+		fields = append(fields, "summary")
+	}
+	if a.Description != b.Description {
+		fields = append(fields, "description")
+	}
+	if a.Location != b.Location {
+		fields = append(fields, "location")
+	}
+	if !slices.Equal(a.Categories, b.Categories) {
+		fields = append(fields, "categories")
+	}
+	return fields
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func deltaTestEvents() []CalendarEvent {
+	start := time.Date(2024, 6, 21, 3, 25, 0, 0, time.UTC)
+	return []CalendarEvent{
+		{UID: "a@calsun", Start: start, End: start.Add(time.Minute), Summary: "Sunrise"},
+		{UID: "b@calsun", Start: start.Add(18 * time.Hour), End: start.Add(18*time.Hour + time.Minute), Summary: "Sunset"},
+	}
+}
+
+func TestEventsDigest(t *testing.T) {
+	events := deltaTestEvents()
+	digest := EventsDigest(events)
+	if len(digest) != 32 {
+		t.Errorf("expected a 32 character digest, got %q", digest)
+	}
+
+	reversed := []CalendarEvent{events[1], events[0]}
+	if got := EventsDigest(reversed); got != digest {
+		t.Errorf("digest depends on order: %s != %s", got, digest)
+	}
+
+	changed := deltaTestEvents()
+	changed[1].Description = "Day length: 18h"
+	if EventsDigest(changed) == digest {
+		t.Error("expected a changed description to change the digest")
+	}
+	if EventsDigest(events[:1]) == digest {
+		t.Error("expected a removed event to change the digest")
+	}
+}
+
+func TestChangedEvents(t *testing.T) {
+	previous := deltaTestEvents()
+	current := deltaTestEvents()
+	current[1].Summary = "Sunset (changed)"
+	current = append(current, CalendarEvent{UID: "c@calsun", Summary: "Sunrise"})
+
+	changed := ChangedEvents(previous, current)
+	if len(changed) != 2 || changed[0].UID != "b@calsun" || changed[1].UID != "c@calsun" {
+		t.Errorf("expected b and c, got %+v", changed)
+	}
+	if changed := ChangedEvents(previous, previous); len(changed) != 0 {
+		t.Errorf("expected no changes, got %+v", changed)
+	}
+}
+
+func TestDiffEvents(t *testing.T) {
+	before := deltaTestEvents()
+	after := deltaTestEvents()
+	after[1].Start = after[1].Start.Add(time.Minute)
+	after[1].Description = "Day length: 18h"
+	after[0].Start = after[0].Start.In(time.FixedZone("CEST", 2*3600))
+	after = append(after, CalendarEvent{UID: "c@calsun", Summary: "Solar noon"})
+	before = append(before, CalendarEvent{UID: "d@calsun", Summary: "Golden hour"})
+
+	diff := DiffEvents(before, after)
+	if len(diff.Added) != 1 || diff.Added[0].UID != "c@calsun" {
+		t.Errorf("expected c added, got %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].UID != "d@calsun" {
+		t.Errorf("expected d removed, got %+v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].After.UID != "b@calsun" || !slices.Equal(diff.Changed[0].Fields, []string{"start", "description"}) {
+		t.Errorf("expected b's start and description changed, got %+v", diff.Changed)
+	}
+	// The same instant in another timezone is unchanged
+	if diff.Unchanged != 1 {
+		t.Errorf("expected one unchanged event, got %d", diff.Unchanged)
+	}
+
+	if diff := DiffEvents(before, before); len(diff.Added)+len(diff.Removed)+len(diff.Changed) != 0 || diff.Unchanged != len(before) {
+		t.Errorf("expected no differences, got %+v", diff)
+	}
+}
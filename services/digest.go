@@ -0,0 +1,291 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// digestWindow is how long after the configured time a missed digest is
+	// still sent (e.g., after a restart), so late starts don't post stale news
+	digestWindow = time.Hour
+
+	webhookAttempts = 3
+	maxRetryAfter   = time.Minute
+)
+
+// DigestTarget is a chat incoming webhook that receives the daily digest for
+// a location
+type DigestTarget struct {
+	Platform string // "slack" or "discord"
+	URL      string
+	Lat      float64
+	Lng      float64
+	Name     string
+}
+
+// Digest summarizes a day's sun and moon for a location
+type Digest struct {
+	Location       string
+	Date           time.Time  // Local midnight of the day
+	Sunrise        *time.Time // Local time; nil if the sun does not rise
+	Sunset         *time.Time // Local time; nil if the sun does not set
+	PolarDay       bool       // The sun stays above the horizon all day
+	DayLength      time.Duration
+	DayLengthDelta time.Duration // Change in day length since yesterday
+	HasDelta       bool
+	Moon           MoonPhase
+}
+
+// BuildDigest computes the digest for the day containing date, which should
+// be in the location's timezone
+func BuildDigest(lat, lng float64, location string, date time.Time) Digest {
+	tz := date.Location()
+	noon := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, tz)
+	today := GetSunTimes(lat, lng, noon)
+	yesterday := GetSunTimes(lat, lng, noon.AddDate(0, 0, -1))
+
+	digest := Digest{
+		Location: location,
+		Date:     time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, tz),
+		Moon:     GetMoonPhase(noon),
+	}
+	if today.Sunrise != nil {
+		sunrise := today.Sunrise.Time.In(tz)
+		digest.Sunrise = &sunrise
+	}
+	if today.Sunset != nil {
+		sunset := today.Sunset.Time.In(tz)
+		digest.Sunset = &sunset
+	}
+	digest.PolarDay = today.PolarDay()
+
+	if today.Sunrise != nil && today.Sunset != nil {
+		digest.DayLength = today.Sunset.Time.Sub(today.Sunrise.Time)
+		if yesterday.Sunrise != nil && yesterday.Sunset != nil {
+			digest.DayLengthDelta = digest.DayLength - yesterday.Sunset.Time.Sub(yesterday.Sunrise.Time)
+			digest.HasDelta = true
+		}
+	}
+
+	return digest
+}
+
+// FormatDigest returns the webhook payload for the platform ("slack" or
+// "discord")
+func FormatDigest(d Digest, platform string) any {
+	bold := "*"
+	if platform == "discord" {
+		bold = "**"
+	}
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("%sSun digest for %s%s - %s", bold, d.Location, bold, d.Date.Format("Monday, 2 January")))
+
+	switch {
+	case d.Sunrise == nil && d.Sunset == nil && d.PolarDay:
+		lines = append(lines, "The sun stays above the horizon all day")
+	case d.Sunrise == nil && d.Sunset == nil:
+		lines = append(lines, "The sun stays below the horizon all day")
+	default:
+		lines = append(lines, fmt.Sprintf("Sunrise %s · Sunset %s", digestClock(d.Sunrise), digestClock(d.Sunset)))
+	}
+
+	if d.DayLength > 0 {
+		line := "Day length " + FormatDuration(d.DayLength)
+		if d.HasDelta {
+			line += " (" + formatDayLengthDelta(d.DayLengthDelta) + ")"
+		}
+		lines = append(lines, line)
+	}
+
+	lines = append(lines, fmt.Sprintf("Moon: %s (%d%% illuminated)", d.Moon.Name, int(d.Moon.Illumination*100+0.5)))
+
+	text := strings.Join(lines, "\n")
+	if platform == "discord" {
+		return map[string]string{"content": text}
+	}
+	return map[string]string{"text": text}
+}
+
+// digestClock formats a local time as HH:MM, or a dash if there is none
+func digestClock(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format("15:04")
+}
+
+// formatDayLengthDelta describes the change in day length since yesterday
+func formatDayLengthDelta(delta time.Duration) string {
+	minutes := int(delta.Round(time.Minute).Minutes())
+	switch {
+	case minutes > 0:
+		return fmt.Sprintf("%dm longer than yesterday", minutes)
+	case minutes < 0:
+		return fmt.Sprintf("%dm shorter than yesterday", -minutes)
+	default:
+		return "same as yesterday"
+	}
+}
+
+// DigestSender posts the daily digest to chat webhooks. Call Tick regularly
+// (e.g., every minute); each target receives one digest per local day, at the
+// configured time of day in the target location's timezone. The date of each
+// target's last digest is saved to a JSON file, so a restart within the
+// digest window does not post it again.
+type DigestSender struct {
+	targets    []DigestTarget
+	at         time.Duration // Offset from local midnight
+	client     *http.Client
+	now        Clock
+	retryDelay time.Duration
+
+	mu   sync.Mutex
+	path string
+	sent map[string]string // Target key -> local date of the last digest
+}
+
+// OpenDigestSender creates a sender that posts to targets at the given offset
+// from local midnight, loading the dates digests were last sent from path
+// (created on first save)
+func OpenDigestSender(path string, targets []DigestTarget, at time.Duration) (*DigestSender, error) {
+	d := &DigestSender{
+		targets:    targets,
+		at:         at,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+		retryDelay: 2 * time.Second,
+		path:       path,
+		sent:       make(map[string]string),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return d, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &d.sent); err != nil {
+		return nil, fmt.Errorf("invalid digest state %s: %w", path, err)
+	}
+	return d, nil
+}
+
+// digestTargetKey identifies a target in the saved state across changes to
+// the configured list, without storing its webhook URL
+func digestTargetKey(t DigestTarget) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%.4f,%.4f", t.Platform, t.URL, t.Lat, t.Lng)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Tick sends the digest to every target that is due
+func (d *DigestSender) Tick(ctx context.Context) {
+	for _, target := range d.targets {
+		key := digestTargetKey(target)
+		local := d.now().In(GetTimezone(target.Lat, target.Lng))
+		date := local.Format("2006-01-02")
+		midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+		sinceMidnight := local.Sub(midnight)
+
+		if sinceMidnight < d.at || sinceMidnight >= d.at+digestWindow || d.alreadySent(key, date) {
+			continue
+		}
+
+		// Mark as sent even on failure: a digest that could not be delivered
+		// after retries is dropped rather than retried every tick
+		if err := d.markSent(key, date); err != nil {
+			log.Printf("digest state: %v", err)
+		}
+		if err := d.Send(ctx, target, local); err != nil {
+			log.Printf("digest %s webhook for %s: %v", target.Platform, locationLabel(target.Name, target.Lat, target.Lng), err)
+		}
+	}
+}
+
+// Send posts the digest for the day containing date to the target
+func (d *DigestSender) Send(ctx context.Context, target DigestTarget, date time.Time) error {
+	digest := BuildDigest(target.Lat, target.Lng, locationLabel(target.Name, target.Lat, target.Lng), date)
+	return d.post(ctx, target.URL, FormatDigest(digest, target.Platform))
+}
+
+// post sends the payload to a webhook
+func (d *DigestSender) post(ctx context.Context, u string, payload any) error {
+	return postWebhook(ctx, d.client, d.retryDelay, u, payload)
+}
+
+// postWebhook sends the payload as JSON, retrying on network errors, rate
+// limiting, and server errors with exponential backoff from retryDelay
+// (honouring Retry-After)
+func postWebhook(ctx context.Context, client *http.Client, retryDelay time.Duration, u string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	delay := retryDelay
+	var lastErr error
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", u, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		wait := delay
+		if err != nil {
+			lastErr = err
+		} else {
+			resp.Body.Close()
+			if resp.StatusCode < 300 {
+				return nil
+			}
+			lastErr = fmt.Errorf("status %d", resp.StatusCode)
+			if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+				return lastErr // Not retryable
+			}
+			if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+				wait = min(time.Duration(secs)*time.Second, maxRetryAfter)
+			}
+		}
+
+		if attempt == webhookAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", webhookAttempts, lastErr)
+}
+
+func (d *DigestSender) alreadySent(key, date string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.sent[key] == date
+}
+
+// markSent records the date of a target's digest and saves the state
+func (d *DigestSender) markSent(key, date string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sent[key] = date
+	return writeJSONAtomic(d.path, d.sent)
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBuildDigest(t *testing.T) {
+	tz := GetTimezone(55.6761, 12.5683)
+	d := BuildDigest(55.6761, 12.5683, "Copenhagen", time.Date(2024, 3, 1, 7, 0, 0, 0, tz))
+
+	if d.Sunrise == nil || d.Sunset == nil {
+		t.Fatal("expected sunrise and sunset")
+	}
+	if d.Sunrise.Location() != tz || d.Sunrise.Day() != 1 {
+		t.Errorf("expected local sunrise on March 1, got %s", d.Sunrise)
+	}
+	if !d.HasDelta || d.DayLengthDelta < 3*time.Minute || d.DayLengthDelta > 5*time.Minute {
+		t.Errorf("expected days to grow by about 4 minutes in early March, got %s", d.DayLengthDelta)
+	}
+	if d.Moon.Name == "" {
+		t.Error("expected a moon phase")
+	}
+}
+
+func TestBuildDigest_PolarNight(t *testing.T) {
+	tz := GetTimezone(78.2232, 15.6267)
+	d := BuildDigest(78.2232, 15.6267, "Longyearbyen", time.Date(2024, 12, 21, 7, 0, 0, 0, tz))
+
+	if d.Sunrise != nil || d.Sunset != nil || d.PolarDay || d.HasDelta {
+		t.Errorf("expected polar night, got %+v", d)
+	}
+	if text := FormatDigest(d, "slack").(map[string]string)["text"]; !strings.Contains(text, "below the horizon all day") {
+		t.Errorf("expected polar night message, got %q", text)
+	}
+}
+
+func TestFormatDigest(t *testing.T) {
+	sunrise := time.Date(2024, 3, 1, 7, 1, 0, 0, time.UTC)
+	sunset := time.Date(2024, 3, 1, 17, 45, 0, 0, time.UTC)
+	d := Digest{
+		Location:       "Copenhagen",
+		Date:           time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Sunrise:        &sunrise,
+		Sunset:         &sunset,
+		DayLength:      sunset.Sub(sunrise),
+		DayLengthDelta: -3*time.Minute - 40*time.Second,
+		HasDelta:       true,
+		Moon:           MoonPhase{Name: "Waning gibbous", Illumination: 0.734},
+	}
+
+	slack := FormatDigest(d, "slack").(map[string]string)["text"]
+	for _, want := range []string{
+		"*Sun digest for Copenhagen* - Friday, 1 March",
+		"Sunrise 07:01 · Sunset 17:45",
+		"Day length 10h 44m (4m shorter than yesterday)",
+		"Moon: Waning gibbous (73% illuminated)",
+	} {
+		if !strings.Contains(slack, want) {
+			t.Errorf("slack message missing %q:\n%s", want, slack)
+		}
+	}
+
+	discord := FormatDigest(d, "discord").(map[string]string)
+	if !strings.HasPrefix(discord["content"], "**Sun digest for Copenhagen**") {
+		t.Errorf("expected discord bold markup, got %q", discord["content"])
+	}
+}
+
+func newTestDigestSender(t *testing.T, targets []DigestTarget, now time.Time) *DigestSender {
+	d, err := OpenDigestSender(filepath.Join(t.TempDir(), "digest.json"), targets, 7*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d.now = func() time.Time { return now }
+	d.retryDelay = time.Millisecond
+	return d
+}
+
+func TestDigestSender_Retries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusInternalServerError)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if !strings.Contains(body["text"], "Sun digest") {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	d := newTestDigestSender(t, nil, time.Now())
+	target := DigestTarget{Platform: "slack", URL: server.URL, Lat: 55.6761, Lng: 12.5683}
+	if err := d.Send(context.Background(), target, time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestDigestSender_NoRetryOnClientError(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	d := newTestDigestSender(t, nil, time.Now())
+	target := DigestTarget{Platform: "discord", URL: server.URL, Lat: 55.6761, Lng: 12.5683}
+	if err := d.Send(context.Background(), target, time.Now()); err == nil {
+		t.Error("expected error for a deleted webhook")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a single attempt, got %d", calls.Load())
+	}
+}
+
+func TestDigestSender_Tick(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	targets := []DigestTarget{
+		{Platform: "slack", URL: server.URL, Lat: 55.6761, Lng: 12.5683, Name: "Copenhagen"}, // UTC+1 in March
+		{Platform: "slack", URL: server.URL, Lat: 40.7128, Lng: -74.0060, Name: "New York"},  // UTC-5 in March
+	}
+
+	tests := []struct {
+		name  string
+		now   time.Time
+		calls int32
+	}{
+		{"before digest time", time.Date(2024, 3, 1, 5, 59, 0, 0, time.UTC), 0},
+		{"Copenhagen morning", time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC), 1},
+		{"Copenhagen already sent", time.Date(2024, 3, 1, 6, 30, 0, 0, time.UTC), 1},
+		{"New York morning", time.Date(2024, 3, 1, 12, 5, 0, 0, time.UTC), 2},
+		{"past the window", time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC), 2},
+		{"next day", time.Date(2024, 3, 3, 6, 10, 0, 0, time.UTC), 3},
+	}
+
+	d := newTestDigestSender(t, targets, time.Time{})
+	for _, tt := range tests {
+		d.now = func() time.Time { return tt.now }
+		d.Tick(context.Background())
+		if calls.Load() != tt.calls {
+			t.Errorf("%s: expected %d digests sent, got %d", tt.name, tt.calls, calls.Load())
+		}
+	}
+}
+
+func TestDigestSender_Restart(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	targets := []DigestTarget{{Platform: "slack", URL: server.URL, Lat: 55.6761, Lng: 12.5683, Name: "Copenhagen"}}
+	path := filepath.Join(t.TempDir(), "digest.json")
+	open := func(now time.Time) *DigestSender {
+		d, err := OpenDigestSender(path, targets, 7*time.Hour)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		d.now = func() time.Time { return now }
+		return d
+	}
+
+	open(time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC)).Tick(context.Background())
+	// A restart within the digest window doesn't post the day's digest again
+	open(time.Date(2024, 3, 1, 6, 30, 0, 0, time.UTC)).Tick(context.Background())
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 digest across the restart, got %d", calls.Load())
+	}
+
+	open(time.Date(2024, 3, 2, 6, 5, 0, 0, time.UTC)).Tick(context.Background())
+	if calls.Load() != 2 {
+		t.Errorf("expected the next day's digest, got %d", calls.Load())
+	}
+}
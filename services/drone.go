@@ -0,0 +1,96 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FlightRule describes when a jurisdiction allows drone flights: between
+// morning and evening civil twilight, or between sunrise and sunset, extended
+// by a number of minutes on either side
+type FlightRule struct {
+	Name     string
+	Twilight bool // Bounded by civil dawn and dusk instead of sunrise and sunset
+	Minutes  int  // Minutes the window extends before the morning bound and after the evening bound
+}
+
+// FlightRules lists the supported drone flight rules by ID
+var FlightRules = map[string]FlightRule{
+	"civil-twilight": {Name: "Civil twilight", Twilight: true},
+	"faa":            {Name: "FAA 30-minute rule", Minutes: 30},
+	"daylight":       {Name: "Daylight only"},
+}
+
+// BuildDroneEvents generates "flight window opens" and "flight window
+// closes" events under the given rule. Opening times are rounded up and
+// closing times down to the minute, so the window never exceeds what the rule
+// allows. Days without a bound (e.g., midnight sun) have no event for it.
+func BuildDroneEvents(opts CalendarOptions, rule FlightRule, start time.Time, days int) []CalendarEvent {
+	var events []CalendarEvent
+	for i := 0; i < days; i++ {
+		times := GetDayTimes(opts.Lat, opts.Lng, start.AddDate(0, 0, i))
+		opens, closes := times.Sunrise, times.Sunset
+		if rule.Twilight {
+			opens, closes = times.Dawn, times.Dusk
+		}
+		if !opens.IsZero() {
+			opens = opens.Add(-time.Duration(rule.Minutes) * time.Minute)
+			if rounded := opens.Truncate(time.Minute); rounded.Before(opens) {
+				opens = rounded.Add(time.Minute)
+			}
+		}
+		if !closes.IsZero() {
+			closes = closes.Add(time.Duration(rule.Minutes) * time.Minute).Truncate(time.Minute)
+		}
+
+		if opts.Events.Has(EventSunrise) && !opens.IsZero() {
+			events = append(events, newDroneEvent("flight_window_open", "Flight window opens", opens, opens, closes, rule, opts))
+		}
+		if opts.Events.Has(EventSunset) && !closes.IsZero() {
+			events = append(events, newDroneEvent("flight_window_close", "Flight window closes", closes, opens, closes, rule, opts))
+		}
+	}
+	return events
+}
+
+// newDroneEvent creates a 1-minute event at t, describing the day's window
+func newDroneEvent(eventType, title string, t, opens, closes time.Time, rule FlightRule, opts CalendarOptions) CalendarEvent {
+	lines := []string{
+		fmt.Sprintf("Time: %s", opts.Clock(t, "15:04")),
+		fmt.Sprintf("Location: %s", opts.Location),
+		"Coordinates: " + FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+		"",
+		fmt.Sprintf("Rule: %s", describeFlightRule(rule)),
+	}
+	if !opens.IsZero() && !closes.IsZero() {
+		lines = append(lines, fmt.Sprintf("Window: %s to %s (%s)",
+			opens.In(opts.Timezone).Format("15:04"), closes.In(opts.Timezone).Format("15:04"), FormatDuration(closes.Sub(opens))))
+	}
+
+	return CalendarEvent{
+		UID:         locationUID(t, opts.Lat, opts.Lng, opts.Precision, eventType),
+		Type:        eventType,
+		Start:       t,
+		End:         t.Add(time.Minute),
+		Summary:     fmt.Sprintf("%s %s", title, opts.Clock(t, "15:04")),
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+	}
+}
+
+// describeFlightRule explains a rule's window bounds (e.g., "FAA 30-minute
+// rule, 30 minutes before sunrise to 30 minutes after sunset")
+func describeFlightRule(rule FlightRule) string {
+	morning, evening := "sunrise", "sunset"
+	if rule.Twilight {
+		morning, evening = "civil dawn", "civil dusk"
+	}
+	switch {
+	case rule.Minutes > 0:
+		return fmt.Sprintf("%s, %d minutes before %s to %d minutes after %s", rule.Name, rule.Minutes, morning, rule.Minutes, evening)
+	case rule.Minutes < 0:
+		return fmt.Sprintf("%s, %d minutes after %s to %d minutes before %s", rule.Name, -rule.Minutes, morning, -rule.Minutes, evening)
+	}
+	return fmt.Sprintf("%s, %s to %s", rule.Name, morning, evening)
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildDroneEvents(t *testing.T) {
+	tz := GetTimezone(40.7128, -74.0060)
+	opts := CalendarOptions{Lat: 40.7128, Lng: -74.0060, Location: "New York", Timezone: tz, Events: DefaultEvents()}
+	date := time.Date(2024, 3, 9, 0, 0, 0, 0, tz)
+	times := GetDayTimes(40.7128, -74.0060, date)
+
+	tests := []struct {
+		rule         string
+		opens, close time.Time
+	}{
+		{"civil-twilight", times.Dawn, times.Dusk},
+		{"faa", times.Sunrise.Add(-30 * time.Minute), times.Sunset.Add(30 * time.Minute)},
+		{"daylight", times.Sunrise, times.Sunset},
+	}
+
+	for _, tt := range tests {
+		events := BuildDroneEvents(opts, FlightRules[tt.rule], date, 1)
+		if len(events) != 2 {
+			t.Fatalf("%s: expected 2 events, got %d", tt.rule, len(events))
+		}
+		opens, closes := events[0], events[1]
+		if opens.Type != "flight_window_open" || closes.Type != "flight_window_close" {
+			t.Errorf("%s: unexpected types: %s, %s", tt.rule, opens.Type, closes.Type)
+		}
+
+		// Rounded inwards to whole minutes
+		if d := opens.Start.Sub(tt.opens); d < 0 || d >= time.Minute || opens.Start.Second() != 0 {
+			t.Errorf("%s: expected window to open just after %s, got %s", tt.rule, tt.opens, opens.Start)
+		}
+		if d := tt.close.Sub(closes.Start); d < 0 || d >= time.Minute || closes.Start.Second() != 0 {
+			t.Errorf("%s: expected window to close just before %s, got %s", tt.rule, tt.close, closes.Start)
+		}
+		if !strings.HasPrefix(opens.Summary, "Flight window opens ") || !strings.HasPrefix(closes.Summary, "Flight window closes ") {
+			t.Errorf("%s: unexpected summaries: %s, %s", tt.rule, opens.Summary, closes.Summary)
+		}
+		if !strings.Contains(closes.Description, "Window: "+opens.Start.In(tz).Format("15:04")+" to "+closes.Start.In(tz).Format("15:04")) {
+			t.Errorf("%s: unexpected description: %s", tt.rule, closes.Description)
+		}
+	}
+}
+
+func TestBuildDroneEvents_PolarDay(t *testing.T) {
+	// Civil twilight never ends in Tromsø in June
+	opts := CalendarOptions{Lat: 69.6492, Lng: 18.9553, Timezone: time.UTC, Events: DefaultEvents()}
+	events := BuildDroneEvents(opts, FlightRules["civil-twilight"], time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), 3)
+	if len(events) != 0 {
+		t.Errorf("expected no events, got %d", len(events))
+	}
+}
+
+func TestDescribeFlightRule(t *testing.T) {
+	tests := []struct {
+		rule FlightRule
+		want string
+	}{
+		{FlightRules["civil-twilight"], "Civil twilight, civil dawn to civil dusk"},
+		{FlightRules["faa"], "FAA 30-minute rule, 30 minutes before sunrise to 30 minutes after sunset"},
+		{FlightRule{Name: "Custom", Minutes: -15}, "Custom, 15 minutes after sunrise to 15 minutes before sunset"},
+	}
+	for _, tt := range tests {
+		if got := describeFlightRule(tt.rule); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
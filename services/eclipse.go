@@ -0,0 +1,387 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Eclipse kinds: lunar eclipses by the deepest shadow the moon enters, and
+// solar eclipses by how the moon covers the sun from a location (partial,
+// annular, or total)
+const (
+	EclipsePenumbral = "penumbral"
+	EclipsePartial   = "partial"
+	EclipseAnnular   = "annular"
+	EclipseTotal     = "total"
+)
+
+// LunarEclipse is an eclipse of the moon. Contacts that don't happen in an
+// eclipse of its kind are zero times.
+type LunarEclipse struct {
+	Kind         string // EclipsePenumbral, EclipsePartial, or EclipseTotal
+	Maximum      time.Time
+	Start, End   time.Time // The moon enters and leaves the penumbra
+	PartialStart time.Time // The moon enters the umbra
+	PartialEnd   time.Time
+	TotalStart   time.Time // The moon is entirely in the umbra
+	TotalEnd     time.Time
+	Magnitude    float64 // Umbral magnitude, or penumbral magnitude for a penumbral eclipse
+}
+
+// lunarEclipseTerms holds the periodic terms of the instant of greatest
+// lunar eclipse (Meeus, Astronomical Algorithms, chapter 54), like
+// newMoonTerms with F corrected for the longitude of the node (F1)
+var lunarEclipseTerms = [][6]float64{
+	{-0.4065, 0, 0, 1, 0, 0}, {0.1727, 1, 1, 0, 0, 0}, {0.0161, 0, 0, 2, 0, 0},
+	{-0.0097, 0, 0, 0, 2, 0}, {0.0073, 1, -1, 1, 0, 0}, {-0.0050, 1, 1, 1, 0, 0},
+	{-0.0023, 0, 0, 1, -2, 0}, {0.0021, 1, 2, 0, 0, 0}, {0.0012, 0, 0, 1, 2, 0},
+	{0.0006, 1, 1, 2, 0, 0}, {-0.0004, 0, 0, 3, 0, 0}, {-0.0003, 1, 1, 0, 2, 0},
+	{-0.0002, 1, 1, 0, -2, 0}, {-0.0002, 1, -1, 2, 0, 0}, {-0.0002, 0, 0, 0, 0, 1},
+}
+
+// lunarEclipse returns the eclipse at the full moon of lunation k, if any
+func lunarEclipse(k int) (LunarEclipse, bool) {
+	kf := float64(k) + 0.5
+	a := newLunationArguments(kf)
+
+	// The moon must be near a node of its orbit
+	f := degToRad(a.f)
+	if math.Abs(math.Sin(f)) > 0.36 {
+		return LunarEclipse{}, false
+	}
+	m, mp, omega := degToRad(a.m), degToRad(a.mp), degToRad(a.omega)
+	f1 := f - degToRad(0.02665*math.Sin(omega))
+	a1 := degToRad(299.77 + 0.107408*kf - 0.009173*a.c*a.c)
+
+	jde := a.jde + 0.0003*math.Sin(a1)
+	for _, term := range lunarEclipseTerms {
+		jde += term[0] * math.Pow(a.e, term[1]) * math.Sin(term[2]*m+term[3]*mp+term[4]*f1+term[5]*omega)
+	}
+
+	// γ is the least distance of the moon's centre from the shadow axis and
+	// u the radius of the umbral cone, in Earth radii
+	p := 0.2070*a.e*math.Sin(m) + 0.0024*a.e*math.Sin(2*m) - 0.0392*math.Sin(mp) + 0.0116*math.Sin(2*mp) -
+		0.0073*a.e*math.Sin(mp+m) + 0.0067*a.e*math.Sin(mp-m) + 0.0118*math.Sin(2*f1)
+	q := 5.2207 - 0.0048*a.e*math.Cos(m) + 0.0020*a.e*math.Cos(2*m) - 0.3299*math.Cos(mp) -
+		0.0060*a.e*math.Cos(mp+m) + 0.0041*a.e*math.Cos(mp-m)
+	gamma := (p*math.Cos(f1) + q*math.Sin(f1)) * (1 - 0.0048*math.Abs(math.Cos(f1)))
+	u := 0.0059 + 0.0046*a.e*math.Cos(m) - 0.0182*math.Cos(mp) + 0.0004*math.Cos(2*mp) - 0.0005*math.Cos(m+mp)
+
+	penumbral := (1.5573 + u - math.Abs(gamma)) / 0.5450
+	if penumbral <= 0 {
+		return LunarEclipse{}, false
+	}
+	umbral := (1.0128 - u - math.Abs(gamma)) / 0.5450
+
+	maximum := julianEphemerisTime(jde, 2000+int(kf/12.3685))
+	// Half the time the moon takes to cross a shadow of radius r
+	n := 0.5458 + 0.0400*math.Cos(mp)
+	semiduration := func(r float64) time.Duration {
+		return time.Duration(60 / n * math.Sqrt(r*r-gamma*gamma) * float64(time.Minute)).Round(time.Second)
+	}
+
+	eclipse := LunarEclipse{Kind: EclipsePenumbral, Maximum: maximum, Magnitude: penumbral}
+	d := semiduration(1.5573 + u)
+	eclipse.Start, eclipse.End = maximum.Add(-d), maximum.Add(d)
+	if umbral > 0 {
+		eclipse.Kind, eclipse.Magnitude = EclipsePartial, umbral
+		d = semiduration(1.0128 - u)
+		eclipse.PartialStart, eclipse.PartialEnd = maximum.Add(-d), maximum.Add(d)
+	}
+	if umbral >= 1 {
+		eclipse.Kind = EclipseTotal
+		d = semiduration(0.4678 - u)
+		eclipse.TotalStart, eclipse.TotalEnd = maximum.Add(-d), maximum.Add(d)
+	}
+	return eclipse, true
+}
+
+// LunarEclipses returns the lunar eclipses with their maximum from from until
+// to, in order
+func LunarEclipses(from, to time.Time) []LunarEclipse {
+	var eclipses []LunarEclipse
+	for k := firstLunation(from); ; k++ {
+		// Eclipses are within a few hours of the full moon
+		if moonPhaseTime(k, QuarterFull).After(to.Add(24 * time.Hour)) {
+			return eclipses
+		}
+		eclipse, ok := lunarEclipse(k)
+		if ok && !eclipse.Maximum.Before(from) && eclipse.Maximum.Before(to) {
+			eclipses = append(eclipses, eclipse)
+		}
+	}
+}
+
+// LocalSolarEclipse is a solar eclipse as seen from a location. The central
+// phase is totality or annularity, with zero times in a partial eclipse. The
+// visible part is while the sun is above the horizon, when an eclipse is in
+// progress at sunrise or sunset.
+type LocalSolarEclipse struct {
+	Kind                     string    // EclipsePartial, EclipseAnnular, or EclipseTotal
+	Start, End               time.Time // First and last contact of the discs
+	Maximum                  time.Time
+	CentralStart, CentralEnd time.Time
+	Magnitude                float64 // Fraction of the sun's diameter covered at the maximum
+	Obscuration              float64 // Fraction of the sun's disc covered at the maximum
+	VisibleStart, VisibleEnd time.Time
+}
+
+// Radii of the sun and the moon, in km
+const (
+	sunRadius  = 696000
+	moonRadius = 1737.4
+)
+
+// solarEclipseStep is the sampling interval when searching for the local
+// circumstances of a solar eclipse; the moon takes about two hours to cross
+// the sun
+const solarEclipseStep = 10 * time.Minute
+
+// solarEclipseWindow is how far from the new moon a local eclipse can be:
+// parallax shifts the moon by up to a degree, two hours of its motion
+const solarEclipseWindow = 4 * time.Hour
+
+// solarEclipseGeometry returns the angular distance between the centres of
+// the sun and the moon and their angular radii (radians), seen from a
+// location at t
+func solarEclipseGeometry(lat, lng float64, t time.Time) (separation, sunSize, moonSize float64) {
+	// Julian centuries since J2000.0 in Terrestrial Time
+	c := julianCenturies(t) + deltaT(t.UTC().Year())/86400/36525
+	epsilon := degToRad(23.439291 - 0.0130042*c)
+	equatorial := func(longitude, latitude, distance float64) [3]float64 {
+		l, b := degToRad(longitude), degToRad(latitude)
+		return [3]float64{
+			distance * math.Cos(b) * math.Cos(l),
+			distance * (math.Cos(b)*math.Sin(l)*math.Cos(epsilon) - math.Sin(b)*math.Sin(epsilon)),
+			distance * (math.Cos(b)*math.Sin(l)*math.Sin(epsilon) + math.Sin(b)*math.Cos(epsilon)),
+		}
+	}
+
+	// The sun's position (Meeus, chapter 25), corrected for aberration
+	m := 357.52911 + 35999.05029*c - 0.0001537*c*c
+	center := (1.914602-0.004817*c-0.000014*c*c)*math.Sin(degToRad(m)) +
+		(0.019993-0.000101*c)*math.Sin(degToRad(2*m)) + 0.000289*math.Sin(degToRad(3*m))
+	e := 0.016708634 - 0.000042037*c
+	au := 1.000001018 * (1 - e*e) / (1 + e*math.Cos(degToRad(m+center)))
+	sun := equatorial(280.46646+36000.76983*c+0.0003032*c*c+center-0.00569, 0, au*149597870.7)
+
+	longitude, latitude := moonEcliptic(t)
+	moon := equatorial(longitude, latitude, MoonDistance(t))
+
+	// The observer on the WGS 84 ellipsoid, rotated with the Earth
+	const a, f = 6378.137, 1 / 298.257223563
+	phi, theta := degToRad(lat), greenwichSiderealTime(t)+degToRad(lng)
+	e2 := f * (2 - f)
+	n := a / math.Sqrt(1-e2*math.Sin(phi)*math.Sin(phi))
+	observer := [3]float64{n * math.Cos(phi) * math.Cos(theta), n * math.Cos(phi) * math.Sin(theta), n * (1 - e2) * math.Sin(phi)}
+
+	var dot, sunDistance, moonDistance float64
+	for i := range observer {
+		sun[i] -= observer[i]
+		moon[i] -= observer[i]
+		dot += sun[i] * moon[i]
+		sunDistance += sun[i] * sun[i]
+		moonDistance += moon[i] * moon[i]
+	}
+	sunDistance, moonDistance = math.Sqrt(sunDistance), math.Sqrt(moonDistance)
+	separation = math.Acos(math.Min(dot/(sunDistance*moonDistance), 1))
+	return separation, math.Asin(sunRadius / sunDistance), math.Asin(moonRadius / moonDistance)
+}
+
+// discOverlap returns the fraction of a disc of radius r1 covered by a disc
+// of radius r2 with their centres d apart
+func discOverlap(d, r1, r2 float64) float64 {
+	switch {
+	case d >= r1+r2:
+		return 0
+	case d <= math.Abs(r1-r2):
+		return math.Min(r2*r2/(r1*r1), 1)
+	}
+	a1 := r1 * r1 * math.Acos((d*d+r1*r1-r2*r2)/(2*d*r1))
+	a2 := r2 * r2 * math.Acos((d*d+r2*r2-r1*r1)/(2*d*r2))
+	a3 := math.Sqrt((-d+r1+r2)*(d+r1-r2)*(d-r1+r2)*(d+r1+r2)) / 2
+	return (a1 + a2 - a3) / (math.Pi * r1 * r1)
+}
+
+// localSolarEclipse returns the solar eclipse seen from a location at the
+// new moon of lunation k, if any. Contacts are accurate to a minute or two.
+func localSolarEclipse(lat, lng float64, k int) (LocalSolarEclipse, bool) {
+	// The moon must be near a node of its orbit
+	if math.Abs(math.Sin(degToRad(newLunationArguments(float64(k)).f))) > 0.36 {
+		return LocalSolarEclipse{}, false
+	}
+
+	// The gap between the discs' edges, negative while they overlap, and
+	// between the edges of the smaller disc and the larger, negative while
+	// one is inside the other
+	outer := func(t time.Time) float64 {
+		separation, sunSize, moonSize := solarEclipseGeometry(lat, lng, t)
+		return separation - sunSize - moonSize
+	}
+	inner := func(t time.Time) float64 {
+		separation, sunSize, moonSize := solarEclipseGeometry(lat, lng, t)
+		return separation - math.Abs(sunSize-moonSize)
+	}
+
+	newMoon := moonPhaseTime(k, QuarterNew)
+	closest, least := newMoon, math.Inf(1)
+	for t := newMoon.Add(-solarEclipseWindow); !t.After(newMoon.Add(solarEclipseWindow)); t = t.Add(solarEclipseStep) {
+		if gap := outer(t); gap < least {
+			closest, least = t, gap
+		}
+	}
+	maximum := findMoonExtreme(outer, closest.Add(-solarEclipseStep), closest.Add(solarEclipseStep), -1)
+	if outer(maximum) >= 0 {
+		return LocalSolarEclipse{}, false
+	}
+
+	// Step out from the maximum to bracket the first and last contacts
+	contact := func(value func(time.Time) float64, step time.Duration) time.Time {
+		t := maximum
+		for value(t.Add(step)) < 0 {
+			t = t.Add(step)
+		}
+		if step < 0 {
+			return bisectCrossing(value, t.Add(step), t)
+		}
+		return bisectCrossing(value, t, t.Add(step))
+	}
+	separation, sunSize, moonSize := solarEclipseGeometry(lat, lng, maximum)
+	eclipse := LocalSolarEclipse{
+		Kind:        EclipsePartial,
+		Start:       contact(outer, -solarEclipseStep),
+		End:         contact(outer, solarEclipseStep),
+		Maximum:     maximum,
+		Magnitude:   (sunSize + moonSize - separation) / (2 * sunSize),
+		Obscuration: discOverlap(separation, sunSize, moonSize),
+	}
+	if inner(maximum) < 0 {
+		eclipse.Kind = EclipseAnnular
+		if moonSize > sunSize {
+			eclipse.Kind = EclipseTotal
+		}
+		// Central phases last at most a few minutes
+		eclipse.CentralStart, eclipse.CentralEnd = contact(inner, -time.Minute), contact(inner, time.Minute)
+	}
+	eclipse.VisibleStart, eclipse.VisibleEnd = sunUpSpan(lat, lng, eclipse.Start, eclipse.End)
+	return eclipse, true
+}
+
+// sunUpSpan returns the first and last time from from until to that the sun
+// is above the horizon (its upper limb, with refraction), or zero times
+func sunUpSpan(lat, lng float64, from, to time.Time) (start, end time.Time) {
+	altitude := func(t time.Time) float64 {
+		_, elevation := SunPosition(lat, lng, t)
+		return elevation + 0.833
+	}
+	prev := altitude(from)
+	if prev >= 0 {
+		start, end = from, from
+	}
+	for t := from; t.Before(to); t = t.Add(solarEclipseStep) {
+		next := t.Add(solarEclipseStep)
+		if next.After(to) {
+			next = to
+		}
+		cur := altitude(next)
+		switch {
+		case prev < 0 && cur >= 0:
+			if start.IsZero() {
+				start = bisectCrossing(altitude, t, next)
+			}
+			end = next
+		case prev >= 0 && cur < 0:
+			end = bisectCrossing(altitude, t, next)
+		case cur >= 0:
+			end = next
+		}
+		prev = cur
+	}
+	return start, end
+}
+
+// LocalSolarEclipses returns the solar eclipses visible from a location with
+// their maximum from from until to, in order. Eclipses with the sun below the
+// horizon throughout are left out.
+func LocalSolarEclipses(lat, lng float64, from, to time.Time) []LocalSolarEclipse {
+	var eclipses []LocalSolarEclipse
+	for k := firstLunation(from); ; k++ {
+		if moonPhaseTime(k, QuarterNew).After(to.Add(solarEclipseWindow)) {
+			return eclipses
+		}
+		eclipse, ok := localSolarEclipse(lat, lng, k)
+		if ok && !eclipse.VisibleStart.IsZero() && !eclipse.Maximum.Before(from) && eclipse.Maximum.Before(to) {
+			eclipses = append(eclipses, eclipse)
+		}
+	}
+}
+
+// BuildSolarEclipseEvents generates an event for each solar eclipse visible
+// from the location with its maximum on the local days starting at start,
+// lasting while it is visible
+func BuildSolarEclipseEvents(opts CalendarOptions, start time.Time, days int) []CalendarEvent {
+	from := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, opts.Timezone)
+	var events []CalendarEvent
+	for _, eclipse := range LocalSolarEclipses(opts.Lat, opts.Lng, from, from.AddDate(0, 0, days)) {
+		events = append(events, newSolarEclipseEvent(eclipse, opts))
+	}
+	return events
+}
+
+// newSolarEclipseEvent creates an event for the visible part of a solar
+// eclipse (e.g., "Partial solar eclipse (21% of the sun covered)"), with the
+// contacts and where the sun is
+func newSolarEclipseEvent(eclipse LocalSolarEclipse, opts CalendarOptions) CalendarEvent {
+	tz := opts.Timezone
+	sun := func(t time.Time) string {
+		azimuth, elevation := SunPosition(opts.Lat, opts.Lng, t)
+		if elevation < -0.833 {
+			return "sun below the horizon"
+		}
+		return fmt.Sprintf("sun %.0f° up in the %s", math.Max(elevation, 0), CompassPoint(azimuth))
+	}
+	title := fmt.Sprintf("%s solar eclipse", strings.ToUpper(eclipse.Kind[:1])+eclipse.Kind[1:])
+	covered := fmt.Sprintf("%d%% of the sun covered", int(eclipse.Obscuration*100+0.5))
+
+	begins := fmt.Sprintf("Begins: %s (%s)", eclipse.Start.In(tz).Format("15:04"), sun(eclipse.Start))
+	if eclipse.VisibleStart.After(eclipse.Start) {
+		begins = fmt.Sprintf("Begins: %s, before sunrise; in progress at sunrise %s", eclipse.Start.In(tz).Format("15:04"), eclipse.VisibleStart.In(tz).Format("15:04"))
+	}
+	lines := []string{begins}
+	if !eclipse.CentralStart.IsZero() {
+		phase := "Totality"
+		if eclipse.Kind == EclipseAnnular {
+			phase = "Annularity"
+		}
+		d := eclipse.CentralEnd.Sub(eclipse.CentralStart)
+		lines = append(lines, fmt.Sprintf("%s: %s to %s (%dm %02ds)", phase, eclipse.CentralStart.In(tz).Format("15:04:05"), eclipse.CentralEnd.In(tz).Format("15:04:05"), int(d.Minutes()), int(d.Seconds())%60))
+	}
+	ends := fmt.Sprintf("Ends: %s (%s)", eclipse.End.In(tz).Format("15:04"), sun(eclipse.End))
+	if eclipse.VisibleEnd.Before(eclipse.End) {
+		ends = fmt.Sprintf("Ends: %s, after sunset at %s", eclipse.End.In(tz).Format("15:04"), eclipse.VisibleEnd.In(tz).Format("15:04"))
+	}
+	lines = append(lines,
+		fmt.Sprintf("Greatest eclipse: %s (magnitude %s, %s, %s)", eclipse.Maximum.In(tz).Format("15:04"), opts.Locale.Number(eclipse.Magnitude, 2), covered, sun(eclipse.Maximum)),
+		ends,
+		fmt.Sprintf("Location: %s", opts.Location),
+		"Coordinates: "+FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+		"",
+		"Never look at the sun without eclipse glasses or a solar filter.",
+	)
+
+	summary := title
+	if eclipse.Kind == EclipsePartial {
+		summary += " (" + covered + ")"
+	}
+	return CalendarEvent{
+		UID:         locationUID(eclipse.Maximum, opts.Lat, opts.Lng, opts.Precision, "solar-eclipse"),
+		Type:        "solar_eclipse",
+		Start:       eclipse.VisibleStart,
+		End:         eclipse.VisibleEnd,
+		Summary:     summary,
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+	}
+}
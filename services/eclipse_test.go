@@ -0,0 +1,136 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLunarEclipses(t *testing.T) {
+	eclipses := LunarEclipses(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	// Published greatest eclipses and magnitudes (umbral, penumbral for the
+	// penumbral eclipse)
+	want := []struct {
+		kind      string
+		maximum   time.Time
+		magnitude float64
+	}{
+		{EclipsePenumbral, time.Date(2024, 3, 25, 7, 12, 51, 0, time.UTC), 0.956},
+		{EclipsePartial, time.Date(2024, 9, 18, 2, 44, 18, 0, time.UTC), 0.085},
+		{EclipseTotal, time.Date(2025, 3, 14, 6, 58, 43, 0, time.UTC), 1.178},
+		{EclipseTotal, time.Date(2025, 9, 7, 18, 11, 47, 0, time.UTC), 1.362},
+	}
+	if len(eclipses) != len(want) {
+		t.Fatalf("expected %d eclipses, got %+v", len(want), eclipses)
+	}
+	for i, e := range eclipses {
+		if e.Kind != want[i].kind || e.Maximum.Sub(want[i].maximum).Abs() > 2*time.Minute || math.Abs(e.Magnitude-want[i].magnitude) > 0.01 {
+			t.Errorf("eclipse %d: expected %s at %s (%.3f), got %s at %s (%.3f)", i, want[i].kind, want[i].maximum, want[i].magnitude, e.Kind, e.Maximum, e.Magnitude)
+		}
+	}
+
+	// Published contacts of March 14, 2025: penumbral 03:57 to 09:59, partial
+	// 05:09 to 08:47, total 06:26 to 07:31 UTC
+	total := eclipses[2]
+	for name, got := range map[string][2]time.Time{
+		"penumbral": {total.Start, time.Date(2025, 3, 14, 3, 57, 0, 0, time.UTC)},
+		"partial":   {total.PartialStart, time.Date(2025, 3, 14, 5, 9, 0, 0, time.UTC)},
+		"total":     {total.TotalStart, time.Date(2025, 3, 14, 6, 26, 0, 0, time.UTC)},
+		"total end": {total.TotalEnd, time.Date(2025, 3, 14, 7, 31, 0, 0, time.UTC)},
+		"end":       {total.End, time.Date(2025, 3, 14, 9, 59, 0, 0, time.UTC)},
+	} {
+		if got[0].Sub(got[1]).Abs() > 3*time.Minute {
+			t.Errorf("%s: expected about %s, got %s", name, got[1], got[0])
+		}
+	}
+	if !eclipses[0].PartialStart.IsZero() || !eclipses[1].TotalStart.IsZero() {
+		t.Error("expected no umbral contacts in a penumbral eclipse and no totality in a partial one")
+	}
+}
+
+func TestLocalSolarEclipses(t *testing.T) {
+	// Dallas saw the total eclipse of April 8, 2024
+	eclipses := LocalSolarEclipses(32.7767, -96.7970, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	if len(eclipses) != 1 || eclipses[0].Kind != EclipseTotal {
+		t.Fatalf("expected a total eclipse, got %+v", eclipses)
+	}
+	e := eclipses[0]
+	if math.Abs(e.Magnitude-1.015) > 0.01 || e.Obscuration != 1 {
+		t.Errorf("expected magnitude about 1.015 and the sun covered, got %.3f and %.3f", e.Magnitude, e.Obscuration)
+	}
+
+	// Published contacts: 17:23:21, totality 18:40:43 to 18:44:35, greatest
+	// eclipse 18:42:37, and 20:01:58 UTC
+	for name, got := range map[string][2]time.Time{
+		"start":         {e.Start, time.Date(2024, 4, 8, 17, 23, 21, 0, time.UTC)},
+		"totality":      {e.CentralStart, time.Date(2024, 4, 8, 18, 40, 43, 0, time.UTC)},
+		"maximum":       {e.Maximum, time.Date(2024, 4, 8, 18, 42, 37, 0, time.UTC)},
+		"totality end":  {e.CentralEnd, time.Date(2024, 4, 8, 18, 44, 35, 0, time.UTC)},
+		"end":           {e.End, time.Date(2024, 4, 8, 20, 1, 58, 0, time.UTC)},
+		"visible start": {e.VisibleStart, e.Start},
+		"visible end":   {e.VisibleEnd, e.End},
+	} {
+		if got[0].Sub(got[1]).Abs() > 90*time.Second {
+			t.Errorf("%s: expected about %s, got %s", name, got[1], got[0])
+		}
+	}
+
+	// Madrid was just outside the path of totality on August 12, 2026, with
+	// the sun setting before the end
+	eclipses = LocalSolarEclipses(40.4168, -3.7038, time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC))
+	if len(eclipses) != 1 || eclipses[0].Kind != EclipsePartial || eclipses[0].Magnitude < 0.98 || !eclipses[0].CentralStart.IsZero() {
+		t.Fatalf("expected a deep partial eclipse, got %+v", eclipses)
+	}
+	if !eclipses[0].VisibleEnd.Before(eclipses[0].End) {
+		t.Errorf("expected the sun to set during the eclipse, got %+v", eclipses[0])
+	}
+
+	// The April 2024 eclipse happened during Sydney's night
+	if eclipses := LocalSolarEclipses(-33.87, 151.21, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)); len(eclipses) != 0 {
+		t.Errorf("expected no eclipse with the sun down, got %+v", eclipses)
+	}
+}
+
+func TestDiscOverlap(t *testing.T) {
+	tests := []struct {
+		d, r1, r2, want float64
+	}{
+		{2, 1, 1, 0},
+		{0, 1, 1.1, 1},
+		{0, 1, 0.5, 0.25},
+		{1, 1, 1, 0.391},
+	}
+	for _, tt := range tests {
+		if got := discOverlap(tt.d, tt.r1, tt.r2); math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("discOverlap(%v, %v, %v) = %.3f, expected %.3f", tt.d, tt.r1, tt.r2, got, tt.want)
+		}
+	}
+}
+
+func TestBuildSolarEclipseEvents(t *testing.T) {
+	opts := testCalendarOptions()
+	events := BuildSolarEclipseEvents(opts, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), 730)
+	if len(events) != 2 {
+		t.Fatalf("expected the eclipses of March 2025 and August 2026, got %+v", events)
+	}
+
+	partial := events[0]
+	if partial.Summary != "Partial solar eclipse (21% of the sun covered)" || partial.Type != "solar_eclipse" {
+		t.Errorf("unexpected event %+v", partial)
+	}
+	if partial.Start.Format("2006-01-02") != "2025-03-29" || partial.End.Sub(partial.Start) < 90*time.Minute {
+		t.Errorf("expected the eclipse to last from first to last contact, got %s to %s", partial.Start, partial.End)
+	}
+	for _, want := range []string{"Begins: 11:3", "Greatest eclipse: 12:2", "magnitude 0.3", "Ends: 13:1", "Location: Copenhagen", "solar filter"} {
+		if !strings.Contains(partial.Description, want) {
+			t.Errorf("expected description to contain %q, got %q", want, partial.Description)
+		}
+	}
+
+	// The sun sets during the eclipse of August 12, 2026
+	if !strings.Contains(events[1].Description, "after sunset at 20:5") || events[1].End.In(opts.Timezone).Format("15:04") != "20:53" {
+		t.Errorf("expected the event to end at sunset, got %s: %q", events[1].End, events[1].Description)
+	}
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// OpenElevationURL is the public Open-Elevation API
+	OpenElevationURL = "https://api.open-elevation.com"
+
+	// Terrain doesn't change, so elevations are cached for a month
+	elevationCacheTTL  = 30 * 24 * time.Hour
+	elevationCacheSize = 10000
+
+	// elevationBatchSize is the most locations looked up in one request
+	elevationBatchSize = 500
+)
+
+// GeoPoint is a location on the Earth's surface
+type GeoPoint struct {
+	Lat, Lng float64
+}
+
+// ElevationProvider looks up the terrain elevation of locations from a
+// digital elevation model
+type ElevationProvider interface {
+	// Elevation returns the terrain elevation at a location, in metres above
+	// sea level
+	Elevation(ctx context.Context, lat, lng float64) (float64, error)
+	// Elevations returns the terrain elevations at several locations, in
+	// order, without caching them
+	Elevations(ctx context.Context, points []GeoPoint) ([]float64, error)
+}
+
+// OpenElevation is an ElevationProvider backed by the Open-Elevation lookup
+// API (or a compatible self-hosted instance). Elevations are cached per
+// location, rounded to about 10 m.
+type OpenElevation struct {
+	baseURL string
+	client  *http.Client
+	cache   *ttlCache[float64]
+}
+
+// NewOpenElevation creates an elevation provider for the Open-Elevation API at
+// baseURL
+func NewOpenElevation(baseURL string) *OpenElevation {
+	return &OpenElevation{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 10 * time.Second},
+		cache:   newTTLCache[float64](elevationCacheTTL, elevationCacheSize),
+	}
+}
+
+// Cache returns the cached elevations
+func (o *OpenElevation) Cache() Cache {
+	return o.cache
+}
+
+// Elevation returns the terrain elevation at a location
+func (o *OpenElevation) Elevation(ctx context.Context, lat, lng float64) (float64, error) {
+	key := strconv.FormatFloat(lat, 'f', 4, 64) + "," + strconv.FormatFloat(lng, 'f', 4, 64)
+	if elevation, ok := o.cache.Get(key); ok {
+		return elevation, nil
+	}
+
+	params := url.Values{}
+	params.Set("locations", key)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", o.baseURL+"/api/v1/lookup?"+params.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("elevation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("elevation request failed: status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Results []struct {
+			Elevation *float64 `json:"elevation"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("invalid elevation response: %w", err)
+	}
+	if len(body.Results) != 1 || body.Results[0].Elevation == nil {
+		return 0, fmt.Errorf("no elevation for %s", key)
+	}
+
+	elevation := *body.Results[0].Elevation
+	o.cache.Set(key, elevation)
+	return elevation, nil
+}
+
+// Elevations looks up the terrain elevations at several locations, in
+// batches
+func (o *OpenElevation) Elevations(ctx context.Context, points []GeoPoint) ([]float64, error) {
+	elevations := make([]float64, 0, len(points))
+	for start := 0; start < len(points); start += elevationBatchSize {
+		batch := points[start:min(start+elevationBatchSize, len(points))]
+		results, err := o.lookup(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		elevations = append(elevations, results...)
+	}
+	return elevations, nil
+}
+
+// lookup posts one batch of locations to the lookup API
+func (o *OpenElevation) lookup(ctx context.Context, points []GeoPoint) ([]float64, error) {
+	type location struct {
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	}
+	var request struct {
+		Locations []location `json:"locations"`
+	}
+	for _, p := range points {
+		request.Locations = append(request.Locations, location{p.Lat, p.Lng})
+	}
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/v1/lookup", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("elevation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("elevation request failed: status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Results []struct {
+			Elevation *float64 `json:"elevation"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid elevation response: %w", err)
+	}
+	if len(body.Results) != len(points) {
+		return nil, fmt.Errorf("expected %d elevations, got %d", len(points), len(body.Results))
+	}
+	elevations := make([]float64, len(points))
+	for i, result := range body.Results {
+		if result.Elevation == nil {
+			return nil, fmt.Errorf("no elevation for %.4f,%.4f", points[i].Lat, points[i].Lng)
+		}
+		elevations[i] = *result.Elevation
+	}
+	return elevations, nil
+}
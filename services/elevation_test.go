@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestOpenElevation_Elevation(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path != "/api/v1/lookup" || r.URL.Query().Get("locations") != "46.5580,7.8351" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		w.Write([]byte(`{"results": [{"latitude": 46.558, "longitude": 7.8351, "elevation": 2970}]}`))
+	}))
+	defer server.Close()
+
+	o := NewOpenElevation(server.URL + "/")
+	elevation, err := o.Elevation(context.Background(), 46.558, 7.8351)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elevation != 2970 {
+		t.Errorf("expected 2970 m, got %v", elevation)
+	}
+
+	if _, err := o.Elevation(context.Background(), 46.55801, 7.83509); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a cached elevation, got %d requests", calls.Load())
+	}
+}
+
+func TestOpenElevation_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		message string
+	}{
+		{"server error", http.StatusInternalServerError, ``, "status 500"},
+		{"invalid JSON", http.StatusOK, `<html>`, "invalid elevation response"},
+		{"no results", http.StatusOK, `{"results": []}`, "no elevation for 55.6761,12.5683"},
+		{"null elevation", http.StatusOK, `{"results": [{"elevation": null}]}`, "no elevation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := NewOpenElevation(server.URL).Elevation(context.Background(), 55.6761, 12.5683)
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestOpenElevation_Elevations(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var request struct {
+			Locations []struct{ Latitude, Longitude float64 } `json:"locations"`
+		}
+		if r.Method != "POST" || json.NewDecoder(r.Body).Decode(&request) != nil {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+		}
+		// Echo each latitude back as the elevation
+		var results []string
+		for _, l := range request.Locations {
+			results = append(results, fmt.Sprintf(`{"elevation": %v}`, l.Latitude))
+		}
+		fmt.Fprintf(w, `{"results": [%s]}`, strings.Join(results, ","))
+	}))
+	defer server.Close()
+
+	points := make([]GeoPoint, elevationBatchSize+1)
+	for i := range points {
+		points[i] = GeoPoint{Lat: float64(i), Lng: 10}
+	}
+	elevations, err := NewOpenElevation(server.URL).Elevations(context.Background(), points)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(elevations) != len(points) || elevations[0] != 0 || elevations[elevationBatchSize] != elevationBatchSize {
+		t.Errorf("expected the elevations in order, got %d ending %v", len(elevations), elevations[len(elevations)-1])
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 batches, got %d requests", calls.Load())
+	}
+}
+
+func TestOpenElevation_ElevationsMissing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results": [{"elevation": 12}]}`))
+	}))
+	defer server.Close()
+
+	_, err := NewOpenElevation(server.URL).Elevations(context.Background(), []GeoPoint{{1, 2}, {3, 4}})
+	if err == nil || !strings.Contains(err.Error(), "expected 2 elevations, got 1") {
+		t.Errorf("expected a count error, got %v", err)
+	}
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// pendingEmailTTL is how long an unconfirmed email subscription is kept
+const pendingEmailTTL = 7 * 24 * time.Hour
+
+//go:embed templates/*.txt
+var emailTemplatesFS embed.FS
+
+var emailTemplates = template.Must(template.New("").Funcs(template.FuncMap{
+	"clock":    digestClock,
+	"duration": FormatDuration,
+	"delta":    formatDayLengthDelta,
+	"percent":  func(f float64) string { return fmt.Sprintf("%d%%", int(f*100+0.5)) },
+}).ParseFS(emailTemplatesFS, "templates/*.txt"))
+
+// EmailSubscription is a double opt-in email digest subscription. The ID
+// doubles as the secret token in confirmation and unsubscribe links.
+type EmailSubscription struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Lat       float64   `json:"lat"`
+	Lng       float64   `json:"lng"`
+	Name      string    `json:"name"`
+	Frequency string    `json:"frequency"` // "daily" or "weekly" (sent on Mondays)
+	BaseURL   string    `json:"base_url"`  // Public URL of the server, for links in emails
+	Confirmed bool      `json:"confirmed"`
+	CreatedAt time.Time `json:"created_at"`
+	LastSent  string    `json:"last_sent"` // Local date of the last digest
+}
+
+// EmailStore persists email subscriptions to a JSON file
+type EmailStore struct {
+	mu   sync.Mutex
+	path string
+	subs map[string]EmailSubscription
+}
+
+// OpenEmailStore loads the store at path, creating it on first save
+func OpenEmailStore(path string) (*EmailStore, error) {
+	s := &EmailStore{path: path, subs: make(map[string]EmailSubscription)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var subs []EmailSubscription
+	if err := json.Unmarshal(data, &subs); err != nil {
+		return nil, fmt.Errorf("invalid email store %s: %w", path, err)
+	}
+	for _, sub := range subs {
+		s.subs[sub.ID] = sub
+	}
+	return s, nil
+}
+
+// Get returns the subscription with the given ID
+func (s *EmailStore) Get(id string) (EmailSubscription, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subs[id]
+	return sub, ok
+}
+
+// List returns all subscriptions, ordered by ID
+func (s *EmailStore) List() []EmailSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sortedLocked()
+}
+
+// Put adds or replaces a subscription and saves the store
+func (s *EmailStore) Put(sub EmailSubscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subs[sub.ID] = sub
+	return writeJSONAtomic(s.path, s.sortedLocked())
+}
+
+// Delete removes a subscription and saves the store. Returns false if no
+// subscription had the given ID.
+func (s *EmailStore) Delete(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[id]; !ok {
+		return false, nil
+	}
+	delete(s.subs, id)
+	return true, writeJSONAtomic(s.path, s.sortedLocked())
+}
+
+// sortedLocked returns the subscriptions ordered by ID. The caller must hold
+// s.mu.
+func (s *EmailStore) sortedLocked() []EmailSubscription {
+	subs := make([]EmailSubscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].ID < subs[j].ID })
+	return subs
+}
+
+// EmailDigester manages email subscriptions and sends their digests. Call
+// Tick regularly (e.g., every minute); digests go out at the configured time
+// of day in each subscription's timezone.
+type EmailDigester struct {
+	store  *EmailStore
+	mailer Mailer
+	at     time.Duration // Offset from local midnight
+	site   string        // Site title used in emails
+	now    Clock
+}
+
+// NewEmailDigester creates a digester backed by store
+func NewEmailDigester(store *EmailStore, mailer Mailer, at time.Duration, site string) *EmailDigester {
+	return &EmailDigester{store: store, mailer: mailer, at: at, site: site, now: time.Now}
+}
+
+// Subscriptions returns all stored subscriptions, confirmed or not
+func (e *EmailDigester) Subscriptions() []EmailSubscription {
+	return e.store.List()
+}
+
+// Subscribe stores an unconfirmed subscription and emails the confirmation
+// link
+func (e *EmailDigester) Subscribe(sub EmailSubscription) (EmailSubscription, error) {
+	id, err := randomToken()
+	if err != nil {
+		return EmailSubscription{}, err
+	}
+	sub.ID = id
+	sub.Confirmed = false
+	sub.CreatedAt = e.now()
+
+	if err := e.store.Put(sub); err != nil {
+		return EmailSubscription{}, err
+	}
+
+	subject := fmt.Sprintf("Confirm your %s sun summary for %s", sub.Frequency, locationLabel(sub.Name, sub.Lat, sub.Lng))
+	if err := e.send(sub, "email_confirm.txt", subject, nil); err != nil {
+		e.store.Delete(sub.ID)
+		return EmailSubscription{}, err
+	}
+	return sub, nil
+}
+
+// Confirm activates the subscription with the given token
+func (e *EmailDigester) Confirm(token string) (EmailSubscription, bool, error) {
+	sub, ok := e.store.Get(token)
+	if !ok {
+		return EmailSubscription{}, false, nil
+	}
+	if sub.Confirmed {
+		return sub, true, nil
+	}
+	sub.Confirmed = true
+	return sub, true, e.store.Put(sub)
+}
+
+// Unsubscribe deletes the subscription with the given token
+func (e *EmailDigester) Unsubscribe(token string) (bool, error) {
+	return e.store.Delete(token)
+}
+
+// Tick sends due digests and removes expired unconfirmed subscriptions
+func (e *EmailDigester) Tick(ctx context.Context) {
+	for _, sub := range e.store.List() {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if !sub.Confirmed {
+			if e.now().Sub(sub.CreatedAt) > pendingEmailTTL {
+				e.store.Delete(sub.ID)
+			}
+			continue
+		}
+
+		local := e.now().In(GetTimezone(sub.Lat, sub.Lng))
+		date := local.Format("2006-01-02")
+		midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+		sinceMidnight := local.Sub(midnight)
+
+		if sinceMidnight < e.at || sinceMidnight >= e.at+digestWindow || sub.LastSent == date {
+			continue
+		}
+		if sub.Frequency == "weekly" && local.Weekday() != time.Monday {
+			continue
+		}
+
+		// Record the send first so a failing mail server doesn't cause a
+		// retry every tick
+		sub.LastSent = date
+		if err := e.store.Put(sub); err != nil {
+			log.Printf("email digest %s: %v", sub.ID[:8], err)
+			continue
+		}
+		if err := e.SendDigest(sub, local); err != nil {
+			log.Printf("email digest %s: %v", sub.ID[:8], err)
+		}
+	}
+}
+
+// SendDigest emails the subscription's digest for the day (or week starting
+// on the day) containing date
+func (e *EmailDigester) SendDigest(sub EmailSubscription, date time.Time) error {
+	location := locationLabel(sub.Name, sub.Lat, sub.Lng)
+
+	if sub.Frequency == "weekly" {
+		week := make([]Digest, 7)
+		for i := range week {
+			week[i] = BuildDigest(sub.Lat, sub.Lng, location, date.AddDate(0, 0, i))
+		}
+		subject := fmt.Sprintf("Sun this week in %s", location)
+		return e.send(sub, "email_weekly.txt", subject, map[string]any{"Week": week})
+	}
+
+	day := BuildDigest(sub.Lat, sub.Lng, location, date)
+	subject := fmt.Sprintf("Sunrise %s, sunset %s in %s", digestClock(day.Sunrise), digestClock(day.Sunset), location)
+	return e.send(sub, "email_daily.txt", subject, map[string]any{"Day": day})
+}
+
+// send renders an email template and sends it to the subscriber. Digest
+// emails carry one-click unsubscribe headers (RFC 8058).
+func (e *EmailDigester) send(sub EmailSubscription, name, subject string, extra map[string]any) error {
+	token := url.Values{"token": {sub.ID}}.Encode()
+	data := map[string]any{
+		"Site":           e.site,
+		"Sub":            sub,
+		"Location":       locationLabel(sub.Name, sub.Lat, sub.Lng),
+		"ConfirmURL":     sub.BaseURL + "/email/confirm?" + token,
+		"UnsubscribeURL": sub.BaseURL + "/email/unsubscribe?" + token,
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+
+	var body bytes.Buffer
+	if err := emailTemplates.ExecuteTemplate(&body, name, data); err != nil {
+		return err
+	}
+
+	var headers map[string]string
+	if sub.Confirmed {
+		headers = map[string]string{
+			"List-Unsubscribe":      "<" + data["UnsubscribeURL"].(string) + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		}
+	}
+	return e.mailer.Send(sub.Email, subject, body.String(), headers)
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sentEmail is a message captured by fakeMailer
+type sentEmail struct {
+	to, subject, body string
+	headers           map[string]string
+}
+
+// fakeMailer records sent messages
+type fakeMailer struct {
+	sent []sentEmail
+}
+
+func (m *fakeMailer) Send(to, subject, body string, headers map[string]string) error {
+	m.sent = append(m.sent, sentEmail{to, subject, body, headers})
+	return nil
+}
+
+func newTestEmailDigester(t *testing.T) (*EmailDigester, *fakeMailer) {
+	store, err := OpenEmailStore(filepath.Join(t.TempDir(), "email.json"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	mailer := &fakeMailer{}
+	e := NewEmailDigester(store, mailer, 7*time.Hour, "CalSun")
+	e.now = func() time.Time { return time.Date(2024, 3, 4, 5, 0, 0, 0, time.UTC) } // A Monday
+	return e, mailer
+}
+
+func subscribe(t *testing.T, e *EmailDigester, frequency string) EmailSubscription {
+	sub, err := e.Subscribe(EmailSubscription{
+		Email: "user@example.com", Lat: 55.6761, Lng: 12.5683, Name: "Copenhagen",
+		Frequency: frequency, BaseURL: "https://calsun.example",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return sub
+}
+
+func TestEmailDigester_DoubleOptIn(t *testing.T) {
+	e, mailer := newTestEmailDigester(t)
+	sub := subscribe(t, e, "daily")
+
+	if len(mailer.sent) != 1 {
+		t.Fatalf("expected a confirmation email, got %d emails", len(mailer.sent))
+	}
+	confirm := mailer.sent[0]
+	if confirm.to != "user@example.com" || !strings.Contains(confirm.subject, "Confirm") {
+		t.Errorf("unexpected confirmation email: %+v", confirm)
+	}
+	if !strings.Contains(confirm.body, "https://calsun.example/email/confirm?token="+sub.ID) {
+		t.Errorf("confirmation email should contain the confirm link:\n%s", confirm.body)
+	}
+
+	// Unconfirmed subscriptions receive nothing
+	e.now = func() time.Time { return time.Date(2024, 3, 4, 6, 5, 0, 0, time.UTC) } // 07:05 in Copenhagen
+	e.Tick(context.Background())
+	if len(mailer.sent) != 1 {
+		t.Fatalf("unconfirmed subscription should not receive digests")
+	}
+
+	if _, ok, err := e.Confirm(sub.ID); !ok || err != nil {
+		t.Fatalf("expected confirmation to succeed (ok=%v, err=%v)", ok, err)
+	}
+	if _, ok, _ := e.Confirm("forged"); ok {
+		t.Error("unknown token should not confirm")
+	}
+
+	e.Tick(context.Background())
+	if len(mailer.sent) != 2 {
+		t.Fatalf("expected a digest after confirming, got %d emails", len(mailer.sent))
+	}
+	digest := mailer.sent[1]
+	if !strings.HasPrefix(digest.subject, "Sunrise ") || !strings.Contains(digest.body, "Sun summary for Copenhagen - Monday, 4 March") {
+		t.Errorf("unexpected digest %q:\n%s", digest.subject, digest.body)
+	}
+	if digest.headers["List-Unsubscribe"] != "<https://calsun.example/email/unsubscribe?token="+sub.ID+">" {
+		t.Errorf("expected one-click unsubscribe header, got %v", digest.headers)
+	}
+
+	// Only one digest per day
+	e.Tick(context.Background())
+	if len(mailer.sent) != 2 {
+		t.Errorf("expected no second digest on the same day, got %d emails", len(mailer.sent))
+	}
+
+	if ok, err := e.Unsubscribe(sub.ID); !ok || err != nil {
+		t.Fatalf("expected unsubscribe to succeed (ok=%v, err=%v)", ok, err)
+	}
+	e.now = func() time.Time { return time.Date(2024, 3, 5, 6, 5, 0, 0, time.UTC) }
+	e.Tick(context.Background())
+	if len(mailer.sent) != 2 {
+		t.Error("unsubscribed address should not receive digests")
+	}
+}
+
+func TestEmailDigester_Weekly(t *testing.T) {
+	e, mailer := newTestEmailDigester(t)
+	sub := subscribe(t, e, "weekly")
+	e.Confirm(sub.ID)
+
+	// Tuesday: nothing
+	e.now = func() time.Time { return time.Date(2024, 3, 5, 6, 5, 0, 0, time.UTC) }
+	e.Tick(context.Background())
+	if len(mailer.sent) != 1 {
+		t.Fatalf("weekly digest should only be sent on Mondays")
+	}
+
+	// Next Monday
+	e.now = func() time.Time { return time.Date(2024, 3, 11, 6, 5, 0, 0, time.UTC) }
+	e.Tick(context.Background())
+	if len(mailer.sent) != 2 {
+		t.Fatalf("expected a weekly digest on Monday, got %d emails", len(mailer.sent))
+	}
+
+	body := mailer.sent[1].body
+	for _, want := range []string{"week of 11 March", "Mon 11 Mar", "Sun 17 Mar", "Unsubscribe: https://calsun.example/email/unsubscribe?token="} {
+		if !strings.Contains(body, want) {
+			t.Errorf("weekly digest missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestEmailDigester_ExpiresUnconfirmed(t *testing.T) {
+	e, _ := newTestEmailDigester(t)
+	sub := subscribe(t, e, "daily")
+
+	e.now = func() time.Time { return sub.CreatedAt.Add(pendingEmailTTL + time.Hour) }
+	e.Tick(context.Background())
+
+	if _, ok := e.store.Get(sub.ID); ok {
+		t.Error("expired unconfirmed subscription should be removed")
+	}
+}
+
+func TestEmailStore_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "email.json")
+	store, err := OpenEmailStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Put(EmailSubscription{ID: "abc", Email: "user@example.com", Confirmed: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reopened, err := OpenEmailStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sub, ok := reopened.Get("abc"); !ok || sub.Email != "user@example.com" || !sub.Confirmed {
+		t.Errorf("expected subscription to persist, got %+v (ok=%v)", sub, ok)
+	}
+}
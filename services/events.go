@@ -0,0 +1,434 @@
+package services
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Event types generated by BuildSunEvents
+const (
+	EventDawn       = "dawn"       // Civil twilight begins
+	EventSunrise    = "sunrise"    //
+	EventGoldenHour = "goldenhour" // Morning and evening golden hour spans
+	EventNoon       = "noon"       // Solar noon
+	EventSunset     = "sunset"     //
+	EventDusk       = "dusk"       // Civil twilight ends
+)
+
+// SunEventTypes lists the event types BuildSunEvents can generate, in the
+// order they occur during a day
+var SunEventTypes = []string{EventDawn, EventSunrise, EventGoldenHour, EventNoon, EventSunset, EventDusk}
+
+// EventSet is a set of enabled event types
+type EventSet map[string]bool
+
+// NewEventSet returns a set of the given event types
+func NewEventSet(types ...string) EventSet {
+	set := make(EventSet, len(types))
+	for _, t := range types {
+		set[t] = true
+	}
+	return set
+}
+
+// DefaultEvents returns the event types of a calendar that does not choose
+// any: sunrise and sunset
+func DefaultEvents() EventSet {
+	return NewEventSet(EventSunrise, EventSunset)
+}
+
+// Has reports whether an event type is enabled
+func (s EventSet) Has(eventType string) bool {
+	return s[eventType]
+}
+
+// Types returns the enabled event types in the order of SunEventTypes,
+// followed by any others in sorted order
+func (s EventSet) Types() []string {
+	var types []string
+	for _, t := range SunEventTypes {
+		if s[t] {
+			types = append(types, t)
+		}
+	}
+	var others []string
+	for t, enabled := range s {
+		if enabled && !slices.Contains(SunEventTypes, t) {
+			others = append(others, t)
+		}
+	}
+	slices.Sort(others)
+	return append(types, others...)
+}
+
+// CalendarOptions configures the events generated for a calendar
+type CalendarOptions struct {
+	Lat           float64
+	Lng           float64
+	Location      string         // Location name shown in event details
+	Timezone      *time.Location // Timezone for local times in titles and descriptions
+	Events        EventSet       // Event types to generate (see SunEventTypes)
+	AzimuthFormat string         // How azimuths are shown (see FormatAzimuth); compass formats also add the direction to summaries
+	Precision     int            // Decimals of coordinates in descriptions and UIDs (1-4); 0 uses DefaultPrecision
+	Details       []string       // Optional sunrise and sunset description lines (DetailSolarLongitude, DetailSeason)
+	Grouping      string         // Days sun events are grouped by (GroupCivil or GroupSolar); empty is GroupCivil
+	Locale        Locale         // Number and date formats in titles and descriptions
+	HomeTimezone  *time.Location // Subscriber's own timezone, shown after local times in titles and descriptions; nil for none
+}
+
+// Clock formats t in the local timezone with layout, followed by the time in
+// HomeTimezone if set, each with its zone (e.g., "06:42 CET / 00:42 EST")
+func (o CalendarOptions) Clock(t time.Time, layout string) string {
+	if o.HomeTimezone == nil {
+		return t.In(o.Timezone).Format(layout)
+	}
+	if !strings.Contains(layout, "MST") {
+		layout += " MST"
+	}
+	return t.In(o.Timezone).Format(layout) + " / " + t.In(o.HomeTimezone).Format(layout)
+}
+
+// ClockSpan formats the times from start to end joined by sep, like Clock
+// (e.g., "06:42 - 07:30 CET / 00:42 - 01:30 EST")
+func (o CalendarOptions) ClockSpan(start, end time.Time, sep string) string {
+	span := func(tz *time.Location) string {
+		return start.In(tz).Format("15:04") + sep + end.In(tz).Format("15:04")
+	}
+	if o.HomeTimezone == nil {
+		return span(o.Timezone)
+	}
+	return span(o.Timezone) + " " + end.In(o.Timezone).Format("MST") + " / " + span(o.HomeTimezone) + " " + end.In(o.HomeTimezone).Format("MST")
+}
+
+// Optional details in sunrise and sunset descriptions
+const (
+	DetailSolarLongitude = "longitude" // The sun's ecliptic longitude
+	DetailSeason         = "season"    // Progress through the astronomical season
+)
+
+// CalendarEvent is a generated calendar event, independent of the output
+// format (iCal, calendar APIs, ...)
+type CalendarEvent struct {
+	UID         string // Stable across requests (based on date + location + type)
+	Type        string // One of SunEventTypes, or a preset's own type
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	Categories  []string // Optional tags (e.g., forecast visibility)
+	AllDay      bool     // Start and End are local midnights, and only their dates are used
+}
+
+// BuildSunEvents generates the enabled event types for the given days, in
+// the order they occur. Phases that do not happen on a day (e.g., near the
+// poles) are skipped. With GroupSolar, UIDs are dated by solar day and only
+// the first event of a type in each solar day is kept.
+func BuildSunEvents(sunTimes []DaySunTimes, opts CalendarOptions) []CalendarEvent {
+	types := opts.Events.Types()
+	events := make([]CalendarEvent, 0, len(sunTimes)*len(types))
+	grouper := newDayGrouper(sunTimes, opts)
+	add := func(event CalendarEvent, uidType string) {
+		if grouper == nil || grouper.group(&event, uidType, opts) {
+			events = append(events, event)
+		}
+	}
+
+	var prevDay *DaySunTimes
+	for i := range sunTimes {
+		day := &sunTimes[i]
+		var nextDay *DaySunTimes
+		if i+1 < len(sunTimes) {
+			nextDay = &sunTimes[i+1]
+		}
+		dayStart := len(events)
+		for _, eventType := range types {
+			switch eventType {
+			case EventSunrise:
+				if day.Sunrise != nil {
+					add(newSunCalendarEvent(day.Sunrise, day, prevDay, nextDay, opts), EventSunrise)
+				}
+			case EventSunset:
+				if day.Sunset != nil {
+					add(newSunCalendarEvent(day.Sunset, day, prevDay, nextDay, opts), EventSunset)
+				}
+			default:
+				for _, phase := range dayPhases(eventType, day, opts.Locale) {
+					if phase.occurs() {
+						add(newPhaseEvent(phase, opts), phase.uidType)
+					}
+				}
+			}
+		}
+		// Spans interleave with the moments (e.g., the evening golden hour
+		// starts before sunset); ties keep the order of SunEventTypes
+		slices.SortStableFunc(events[dayStart:], func(a, b CalendarEvent) int {
+			return a.Start.Compare(b.Start)
+		})
+		prevDay = day
+	}
+
+	return events
+}
+
+// PastSunEvents returns the events of the last days days that have already
+// happened at now, newest first
+func PastSunEvents(opts CalendarOptions, now time.Time, days int) []CalendarEvent {
+	start := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -days)
+	// Start a day early so the oldest event's "Yesterday" delta is present
+	sunTimes := GetSunTimesRange(opts.Lat, opts.Lng, start.AddDate(0, 0, -1), days+2)
+
+	var past []CalendarEvent
+	for _, event := range BuildSunEvents(sunTimes, opts) {
+		if !event.Start.Before(start) && !event.Start.After(now) {
+			past = append(past, event)
+		}
+	}
+	slices.Reverse(past)
+	return past
+}
+
+// sunPhase is a daily sun phase shown as an event
+type sunPhase struct {
+	eventType string
+	uidType   string // Distinguishes phases sharing a type on the same day
+	title     string
+	detail    string
+	start     time.Time
+	end       time.Time // Unused for moments, which get 1-minute events
+	instant   bool      // The phase is a moment rather than a span
+}
+
+// dayPhases returns the phases of an event type other than sunrise and
+// sunset on a day, described in locale. Phases that do not occur have a zero
+// start or end.
+func dayPhases(eventType string, day *DaySunTimes, locale Locale) []sunPhase {
+	t := day.Times
+	switch eventType {
+	case EventDawn:
+		return []sunPhase{{eventType: EventDawn, uidType: EventDawn, title: "Dawn", start: t.Dawn, instant: true,
+			detail: "Civil twilight begins (sun 6° below the horizon)"}}
+	case EventDusk:
+		return []sunPhase{{eventType: EventDusk, uidType: EventDusk, title: "Dusk", start: t.Dusk, instant: true,
+			detail: "Civil twilight ends (sun 6° below the horizon)"}}
+	case EventNoon:
+		if t.SolarNoon.IsZero() {
+			return nil
+		}
+		return []sunPhase{{eventType: EventNoon, uidType: EventNoon, title: "Solar noon", start: t.SolarNoon, instant: true,
+			detail: fmt.Sprintf("Sun at its highest: %s elevation", locale.Degrees(day.NoonElevation, 1))}}
+	case EventGoldenHour:
+		detail := "Soft, warm light while the sun is less than 6° above the horizon"
+		return []sunPhase{
+			{eventType: EventGoldenHour, uidType: "goldenhour-morning", title: "Morning golden hour", start: t.Sunrise, end: t.GoldenHourEnd, detail: detail},
+			{eventType: EventGoldenHour, uidType: "goldenhour-evening", title: "Evening golden hour", start: t.GoldenHour, end: t.Sunset, detail: detail},
+		}
+	}
+	return nil
+}
+
+// occurs reports whether the phase happens: a moment needs its start, and a
+// span both its start and end
+func (p sunPhase) occurs() bool {
+	return !p.start.IsZero() && (p.instant || !p.end.IsZero())
+}
+
+// newPhaseEvent creates an event for a sun phase, titled with its local
+// start time (e.g., "Dawn 05:58")
+func newPhaseEvent(phase sunPhase, opts CalendarOptions) CalendarEvent {
+	timeLine := fmt.Sprintf("Time: %s", opts.Clock(phase.start, "15:04"))
+	end := phase.end
+	if phase.instant {
+		end = phase.start.Add(time.Minute)
+	} else {
+		timeLine = fmt.Sprintf("Time: %s", opts.ClockSpan(phase.start, end, " - "))
+	}
+
+	lines := []string{
+		timeLine,
+		fmt.Sprintf("Location: %s", opts.Location),
+		"Coordinates: " + FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+		"",
+		phase.detail,
+	}
+
+	return CalendarEvent{
+		UID:         locationUID(phase.start, opts.Lat, opts.Lng, opts.Precision, phase.uidType),
+		Type:        phase.eventType,
+		Start:       phase.start,
+		End:         end,
+		Summary:     fmt.Sprintf("%s %s", phase.title, opts.Clock(phase.start, "15:04")),
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+	}
+}
+
+// newSunCalendarEvent creates a 1-minute event marking a sunrise or sunset
+func newSunCalendarEvent(event *SunEvent, day, prevDay, nextDay *DaySunTimes, opts CalendarOptions) CalendarEvent {
+	// Title with local time (e.g., "Sunrise 06:42", or "Sunrise 06:42 ENE")
+	eventTitle := strings.ToUpper(event.Type[:1]) + event.Type[1:]
+	summary := fmt.Sprintf("%s %s", eventTitle, opts.Clock(event.Time, "15:04"))
+	if opts.AzimuthFormat == AzimuthCompass || opts.AzimuthFormat == AzimuthBoth {
+		summary += " " + opts.Locale.Azimuth(event.Azimuth, opts.AzimuthFormat)
+	}
+
+	return CalendarEvent{
+		UID:         locationUID(event.Time, opts.Lat, opts.Lng, opts.Precision, event.Type),
+		Type:        event.Type,
+		Start:       event.Time,
+		End:         event.Time.Add(time.Minute),
+		Summary:     summary,
+		Description: buildDescription(event, day, prevDay, nextDay, opts),
+		Location:    opts.Location,
+	}
+}
+
+func buildDescription(event *SunEvent, day, prevDay, nextDay *DaySunTimes, opts CalendarOptions) string {
+	var lines []string
+
+	// Basic info (show local time)
+	localTime := event.Time.In(opts.Timezone)
+	lines = append(lines, fmt.Sprintf("Time: %s", opts.Clock(event.Time, "15:04:05")))
+	lines = append(lines, fmt.Sprintf("Location: %s", opts.Location))
+	lines = append(lines, "Coordinates: "+FormatCoordinates(opts.Lat, opts.Lng, opts.Precision))
+	lines = append(lines, "Azimuth: "+opts.Locale.Azimuth(event.Azimuth, opts.AzimuthFormat))
+	if day.Altitude > 0 {
+		lines = append(lines, fmt.Sprintf("Altitude: %.0f m (horizon %s lower)", day.Altitude, opts.Locale.Degrees(HorizonDip(day.Altitude), 2)))
+	}
+	if day.Skyline != nil {
+		skyline := "Skyline: " + opts.Locale.Degrees(event.Skyline, 1)
+		if !event.FlatTime.IsZero() {
+			skyline += fmt.Sprintf(" (%s over a flat horizon %s)", event.Type, event.FlatTime.In(opts.Timezone).Format("15:04"))
+		}
+		lines = append(lines, skyline)
+	}
+	lines = append(lines, "") // blank line
+
+	// Day length (only if both sunrise and sunset exist)
+	if day.Sunrise != nil && day.Sunset != nil {
+		dayLength := day.Sunset.Time.Sub(day.Sunrise.Time)
+		lines = append(lines, fmt.Sprintf("Day length: %s", FormatDuration(dayLength)))
+	}
+
+	// The night ending at sunrise, or starting at sunset
+	evening, morning := prevDay, day
+	if event.Type == "sunset" {
+		evening, morning = day, nextDay
+	}
+	if evening != nil && morning != nil {
+		if nightLength, ok := evening.NightLength(*morning); ok {
+			lines = append(lines, fmt.Sprintf("Night length: %s", FormatDuration(nightLength)))
+		}
+		if darkness, ok := evening.DarknessLength(*morning); ok {
+			lines = append(lines, fmt.Sprintf("Darkness: %s", formatDarkness(darkness)))
+		}
+	}
+
+	// Delta from yesterday
+	if prevDay != nil {
+		var prevEvent *SunEvent
+		if event.Type == "sunrise" {
+			prevEvent = prevDay.Sunrise
+		} else {
+			prevEvent = prevDay.Sunset
+		}
+
+		if prevEvent != nil {
+			// Compare times by extracting just hour/minute/second in local timezone
+			prevLocalTime := prevEvent.Time.In(opts.Timezone)
+			todaySeconds := localTime.Hour()*3600 + localTime.Minute()*60 + localTime.Second()
+			yesterdaySeconds := prevLocalTime.Hour()*3600 + prevLocalTime.Minute()*60 + prevLocalTime.Second()
+			deltaSeconds := todaySeconds - yesterdaySeconds
+			deltaMinutes := deltaSeconds / 60
+
+			if deltaMinutes != 0 {
+				direction := "later"
+				if deltaMinutes < 0 {
+					direction = "earlier"
+					deltaMinutes = -deltaMinutes
+				}
+				lines = append(lines, fmt.Sprintf("Yesterday: %dm %s", deltaMinutes, direction))
+			} else {
+				lines = append(lines, "Yesterday: same time")
+			}
+		}
+	}
+
+	// Days until next solstice
+	days, solsticeType := DaysUntilNextSolstice(event.Time)
+	if days == 0 {
+		lines = append(lines, fmt.Sprintf("Today is the %s solstice!", solsticeType))
+	} else {
+		lines = append(lines, fmt.Sprintf("Next solstice: %d days (%s)", days, solsticeType))
+	}
+
+	if slices.Contains(opts.Details, DetailSolarLongitude) {
+		lines = append(lines, "Solar longitude: "+opts.Locale.Degrees(SolarLongitude(event.Time), 1))
+	}
+	if slices.Contains(opts.Details, DetailSeason) {
+		lines = append(lines, FormatSeasonProgress(GetSeasonProgress(opts.Lat, event.Time)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// locationLabel returns the location's display name, falling back to its
+// coordinates if it has no name
+func locationLabel(name string, lat, lng float64) string {
+	if name != "" {
+		return name
+	}
+	return FormatCoordinates(lat, lng, DefaultPrecision)
+}
+
+// formatDarkness formats the length of astronomical night, which is zero
+// where twilight lasts all night
+func formatDarkness(d time.Duration) string {
+	if d == 0 {
+		return "none (twilight all night)"
+	}
+	return FormatDuration(d)
+}
+
+// FormatDuration formats a duration as hours and minutes (e.g., "7h 32m")
+func FormatDuration(d time.Duration) string {
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}
+
+// DefaultPrecision is the number of decimals coordinates are shown with
+// (about 11 m) and hashed into event UIDs with
+const DefaultPrecision = 4
+
+// FormatCoordinates formats coordinates with precision decimals (0 uses
+// DefaultPrecision), e.g. "55.68, 12.57"
+func FormatCoordinates(lat, lng float64, precision int) string {
+	if precision == 0 {
+		precision = DefaultPrecision
+	}
+	return fmt.Sprintf("%.*f, %.*f", precision, lat, precision, lng)
+}
+
+// locationUID returns the event UID for coordinates rounded to precision
+// decimals, so a coarser precision hides the exact location in UIDs too. The
+// default precision gives the same UIDs as EventUID.
+func locationUID(t time.Time, lat, lng float64, precision int, eventType string) string {
+	if precision == 0 || precision >= DefaultPrecision {
+		return EventUID(t, lat, lng, eventType)
+	}
+	scale := math.Pow10(precision)
+	return EventUID(t, math.Round(lat*scale)/scale, math.Round(lng*scale)/scale, eventType)
+}
+
+// EventUID returns a stable event UID based on date + location + type, at the
+// configured UID domain
+func EventUID(t time.Time, lat, lng float64, eventType string) string {
+	data := fmt.Sprintf("%s-%.4f-%.4f-%s", t.Format("2006-01-02"), lat, lng, eventType)
+	hash := sha256.Sum256([]byte(data))
+	return fmt.Sprintf("%x@%s", hash[:8], uidDomain)
+}
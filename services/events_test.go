@@ -0,0 +1,281 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testCalendarOptions() CalendarOptions {
+	return CalendarOptions{
+		Lat:      55.6761,
+		Lng:      12.5683,
+		Location: "Copenhagen",
+		Timezone: GetTimezone(55.6761, 12.5683),
+		Events:   DefaultEvents(),
+	}
+}
+
+func TestBuildSunEvents(t *testing.T) {
+	sunTimes := GetSunTimesRange(55.6761, 12.5683, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 3)
+	events := BuildSunEvents(sunTimes, testCalendarOptions())
+
+	if len(events) != 6 {
+		t.Fatalf("expected 6 events, got %d", len(events))
+	}
+	if events[0].Type != "sunrise" || events[1].Type != "sunset" {
+		t.Errorf("expected sunrise then sunset, got %s, %s", events[0].Type, events[1].Type)
+	}
+	if events[0].End.Sub(events[0].Start) != time.Minute {
+		t.Error("events should be 1 minute long")
+	}
+
+	opts := testCalendarOptions()
+	opts.Events = NewEventSet(EventSunrise)
+	for _, event := range BuildSunEvents(sunTimes, opts) {
+		if event.Type != "sunrise" {
+			t.Errorf("expected only sunrises, got %s", event.Type)
+		}
+	}
+}
+
+func TestBuildSunEvents_NightLength(t *testing.T) {
+	sunTimes := GetSunTimesRange(55.6761, 12.5683, time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC), 3)
+	events := BuildSunEvents(sunTimes, testCalendarOptions())
+
+	// The first sunrise has no previous night, and the last sunset no next one
+	if strings.Contains(events[0].Description, "Night length:") || strings.Contains(events[5].Description, "Night length:") {
+		t.Error("expected no night length at the ends of the range")
+	}
+	// The sunset and the next sunrise describe the same night
+	for _, event := range events[1:3] {
+		if !strings.Contains(event.Description, "Night length: 6h 2") {
+			t.Errorf("expected the midsummer night length, got %q", event.Description)
+		}
+		if !strings.Contains(event.Description, "Darkness: none (twilight all night)") {
+			t.Errorf("expected no astronomical darkness at midsummer, got %q", event.Description)
+		}
+	}
+}
+
+func TestBuildSunEvents_Details(t *testing.T) {
+	sunTimes := GetSunTimesRange(55.6761, 12.5683, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), 1)
+	opts := testCalendarOptions()
+
+	if events := BuildSunEvents(sunTimes, opts); strings.Contains(events[0].Description, "Solar longitude") {
+		t.Error("expected no details by default")
+	}
+
+	opts.Details = []string{DetailSolarLongitude, DetailSeason}
+	events := BuildSunEvents(sunTimes, opts)
+	if !strings.Contains(events[0].Description, "Solar longitude: 41.") {
+		t.Errorf("expected the solar longitude, got %q", events[0].Description)
+	}
+	if !strings.Contains(events[0].Description, "45% through astronomical spring") {
+		t.Errorf("expected the season progress, got %q", events[0].Description)
+	}
+}
+
+func TestBuildSunEvents_Phases(t *testing.T) {
+	sunTimes := GetSunTimesRange(55.6761, 12.5683, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), 2)
+	opts := testCalendarOptions()
+	opts.Events = NewEventSet(SunEventTypes...)
+	events := BuildSunEvents(sunTimes, opts)
+
+	want := []string{EventDawn, EventSunrise, EventGoldenHour, EventNoon, EventGoldenHour, EventSunset, EventDusk}
+	if len(events) != 2*len(want) {
+		t.Fatalf("expected %d events, got %d", 2*len(want), len(events))
+	}
+	for i, event := range events[:len(want)] {
+		if event.Type != want[i] {
+			t.Errorf("event %d: expected %s, got %s", i, want[i], event.Type)
+		}
+	}
+
+	times := sunTimes[0].Times
+	morning, evening := events[2], events[4]
+	if !morning.Start.Equal(times.Sunrise) || !morning.End.Equal(times.GoldenHourEnd) {
+		t.Errorf("morning golden hour should span sunrise to its end, got %v - %v", morning.Start, morning.End)
+	}
+	if !evening.Start.Equal(times.GoldenHour) || !evening.End.Equal(times.Sunset) {
+		t.Errorf("evening golden hour should span its start to sunset, got %v - %v", evening.Start, evening.End)
+	}
+	if morning.UID == evening.UID {
+		t.Error("golden hour spans on the same day should have distinct UIDs")
+	}
+	if !strings.HasPrefix(events[3].Summary, "Solar noon ") || !strings.Contains(events[3].Description, "elevation") {
+		t.Errorf("unexpected noon event: %q / %q", events[3].Summary, events[3].Description)
+	}
+
+	// Enabling more types leaves sunrise and sunset events unchanged
+	defaults := BuildSunEvents(sunTimes, testCalendarOptions())
+	if events[1].UID != defaults[0].UID || events[1].Description != defaults[0].Description {
+		t.Error("sunrise event changed when other types were enabled")
+	}
+}
+
+func TestBuildSunEvents_PolarPhases(t *testing.T) {
+	// Tromsø at midsummer: no sunrise, sunset, dawn or dusk
+	sunTimes := GetSunTimesRange(69.6492, 18.9553, time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), 1)
+	opts := CalendarOptions{Lat: 69.6492, Lng: 18.9553, Timezone: time.UTC, Events: NewEventSet(SunEventTypes...)}
+
+	events := BuildSunEvents(sunTimes, opts)
+	if len(events) != 1 || events[0].Type != EventNoon {
+		t.Errorf("expected only solar noon, got %d events", len(events))
+	}
+}
+
+func TestEventSet_Types(t *testing.T) {
+	set := NewEventSet(EventDusk, "custom", EventSunrise)
+	got := strings.Join(set.Types(), ",")
+	if got != "sunrise,dusk,custom" {
+		t.Errorf("expected sunrise,dusk,custom, got %s", got)
+	}
+	if !set.Has(EventDusk) || set.Has(EventSunset) {
+		t.Error("unexpected Has result")
+	}
+}
+
+func TestBuildSunEvents_AzimuthFormat(t *testing.T) {
+	sunTimes := GetSunTimesRange(55.6761, 12.5683, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 1)
+	sunrise := sunTimes[0].Sunrise
+	point := CompassPoint(sunrise.Azimuth)
+
+	tests := []struct {
+		format      string
+		summary     string
+		description string
+	}{
+		{"", "", fmt.Sprintf("Azimuth: %.1f°", sunrise.Azimuth)},
+		{AzimuthDegrees, "", fmt.Sprintf("Azimuth: %.1f°", sunrise.Azimuth)},
+		{AzimuthCompass, " " + point, "Azimuth: " + point + "\n"},
+		{AzimuthBoth, fmt.Sprintf(" %s (%.1f°)", point, sunrise.Azimuth), fmt.Sprintf("Azimuth: %s (%.1f°)", point, sunrise.Azimuth)},
+	}
+	for _, tt := range tests {
+		opts := testCalendarOptions()
+		opts.AzimuthFormat = tt.format
+		event := BuildSunEvents(sunTimes, opts)[0]
+
+		summary := "Sunrise " + sunrise.Time.In(opts.Timezone).Format("15:04") + tt.summary
+		if event.Summary != summary {
+			t.Errorf("%q: expected summary %q, got %q", tt.format, summary, event.Summary)
+		}
+		if !strings.Contains(event.Description, tt.description) {
+			t.Errorf("%q: expected description to contain %q", tt.format, tt.description)
+		}
+	}
+}
+
+func TestCalendarOptions_Clock(t *testing.T) {
+	opts := testCalendarOptions()
+	start := time.Date(2024, 1, 15, 5, 42, 30, 0, time.UTC)
+	end := start.Add(48 * time.Minute)
+	if got := opts.Clock(start, "15:04"); got != "06:42" {
+		t.Errorf("expected the local time alone, got %q", got)
+	}
+	if got := opts.ClockSpan(start, end, " - "); got != "06:42 - 07:30" {
+		t.Errorf("expected the local span alone, got %q", got)
+	}
+
+	opts.HomeTimezone, _ = LoadZone("America/New_York")
+	tests := []struct {
+		got, want string
+	}{
+		{opts.Clock(start, "15:04"), "06:42 CET / 00:42 EST"},
+		{opts.Clock(start, "15:04:05"), "06:42:30 CET / 00:42:30 EST"},
+		{opts.Clock(start, "15:04 MST"), "06:42 CET / 00:42 EST"},
+		{opts.ClockSpan(start, end, " to "), "06:42 to 07:30 CET / 00:42 to 01:30 EST"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("expected %q, got %q", tt.want, tt.got)
+		}
+	}
+}
+
+func TestBuildSunEvents_HomeTimezone(t *testing.T) {
+	sunTimes := GetSunTimesRange(55.6761, 12.5683, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 1)
+	opts := testCalendarOptions()
+	opts.HomeTimezone, _ = LoadZone("America/New_York")
+	event := BuildSunEvents(sunTimes, opts)[0]
+
+	sunrise := sunTimes[0].Sunrise.Time
+	summary := fmt.Sprintf("Sunrise %s CET / %s EST", sunrise.In(opts.Timezone).Format("15:04"), sunrise.In(opts.HomeTimezone).Format("15:04"))
+	if event.Summary != summary {
+		t.Errorf("expected summary %q, got %q", summary, event.Summary)
+	}
+	if !strings.Contains(event.Description, "Time: "+sunrise.In(opts.Timezone).Format("15:04:05")+" CET / ") {
+		t.Errorf("expected both times in the description, got %q", event.Description)
+	}
+}
+
+func TestPastSunEvents(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC) // After sunrise, before sunset in Copenhagen
+	events := PastSunEvents(testCalendarOptions(), now, 3)
+
+	// Three full days plus today's sunrise
+	if len(events) != 7 {
+		t.Fatalf("expected 7 events, got %d", len(events))
+	}
+	if events[0].Type != "sunrise" || events[0].Start.Day() != 10 {
+		t.Errorf("expected today's sunrise first, got %s on %s", events[0].Type, events[0].Start)
+	}
+	for i, event := range events {
+		if event.Start.After(now) {
+			t.Errorf("event %d is in the future: %s", i, event.Start)
+		}
+		if i > 0 && !event.Start.Before(events[i-1].Start) {
+			t.Errorf("events should be newest first")
+		}
+	}
+}
+
+func TestFormatCoordinates(t *testing.T) {
+	tests := []struct {
+		precision int
+		want      string
+	}{
+		{0, "55.6761, 12.5683"},
+		{4, "55.6761, 12.5683"},
+		{2, "55.68, 12.57"},
+		{1, "55.7, 12.6"},
+	}
+	for _, tt := range tests {
+		if got := FormatCoordinates(55.67612, 12.56834, tt.precision); got != tt.want {
+			t.Errorf("FormatCoordinates(precision %d) = %s, want %s", tt.precision, got, tt.want)
+		}
+	}
+}
+
+func TestBuildSunEvents_Precision(t *testing.T) {
+	sunTimes := GetSunTimesRange(55.6761, 12.5683, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 1)
+	event := BuildSunEvents(sunTimes, testCalendarOptions())[0]
+
+	// The default precision keeps existing UIDs
+	if event.UID != EventUID(sunTimes[0].Sunrise.Time, 55.6761, 12.5683, "sunrise") {
+		t.Error("expected the default precision to keep UIDs unchanged")
+	}
+
+	opts := testCalendarOptions()
+	opts.Precision = 2
+	rounded := BuildSunEvents(sunTimes, opts)[0]
+	if !strings.Contains(rounded.Description, "Coordinates: 55.68, 12.57") {
+		t.Errorf("expected rounded coordinates in description:\n%s", rounded.Description)
+	}
+	if rounded.UID != EventUID(sunTimes[0].Sunrise.Time, 55.68, 12.57, "sunrise") {
+		t.Error("expected the UID to use the rounded coordinates")
+	}
+	// Times are still computed from the exact location
+	if !rounded.Start.Equal(event.Start) {
+		t.Errorf("expected the same sunrise, got %v and %v", rounded.Start, event.Start)
+	}
+}
+
+func BenchmarkBuildSunEvents(b *testing.B) {
+	opts := CalendarOptions{Lat: 55.6761, Lng: 12.5683, Location: "Copenhagen", Timezone: time.UTC, Events: DefaultEvents()}
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < b.N; i++ {
+		BuildSunEvents(GetSunTimesRange(opts.Lat, opts.Lng, startDate, 104), opts)
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Facade describes a window in a vertical wall and the directions it
+// admits direct sun from
+type Facade struct {
+	Azimuth     float64 // Direction the window faces, in degrees clockwise from north
+	FieldOfView float64 // Degrees of azimuth the window sees, centred on Azimuth (at most 180)
+}
+
+// Lit reports whether direct sun shines through the window at the given sun
+// position: the sun is above the horizon and within the field of view
+func (f Facade) Lit(azimuth, elevation float64) bool {
+	return elevation > 0 && math.Abs(f.offset(azimuth)) <= f.FieldOfView/2
+}
+
+// offset returns the sun's azimuth relative to the window's direction, from
+// -180 to 180 degrees (negative to the left when facing out)
+func (f Facade) offset(azimuth float64) float64 {
+	return math.Mod(azimuth-f.Azimuth+540, 360) - 180
+}
+
+// incidence returns the angle between the sun and the window's normal, in
+// degrees: 0 when the sun shines straight in
+func (f Facade) incidence(azimuth, elevation float64) float64 {
+	cos := math.Cos(degToRad(elevation)) * math.Cos(degToRad(f.offset(azimuth)))
+	return radToDeg(math.Acos(math.Max(-1, math.Min(1, cos))))
+}
+
+// FacadeSun is a span of direct sun through a window
+type FacadeSun struct {
+	TimeRange
+	Direct    time.Time // When the sun shines most directly in, to the minute
+	Incidence float64   // Angle between the sun and the window's normal then, in degrees
+	Elevation float64   // Sun elevation then, in degrees
+}
+
+// FacadeSunSpans returns the spans of direct sun through the window during
+// the solar day containing date
+func FacadeSunSpans(lat, lng float64, facade Facade, date time.Time) []FacadeSun {
+	noon := GetDayTimes(lat, lng, date).SolarNoon
+	var spans []FacadeSun
+	for _, w := range SunWindows(lat, lng, noon.Add(-12*time.Hour), noon.Add(12*time.Hour), facade.Lit) {
+		span := FacadeSun{TimeRange: w, Incidence: 90}
+		for t := w.Start; !t.After(w.End); t = t.Add(time.Minute) {
+			azimuth, elevation := SunPosition(lat, lng, t)
+			if !facade.Lit(azimuth, elevation) {
+				continue // At an edge rounded outside the span
+			}
+			if incidence := facade.incidence(azimuth, elevation); incidence < span.Incidence {
+				span.Direct, span.Incidence, span.Elevation = t, incidence, elevation
+			}
+		}
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+// BuildFacadeEvents generates "Direct sun on window" events spanning the
+// times each day that the sun shines through the window
+func BuildFacadeEvents(opts CalendarOptions, facade Facade, start time.Time, days int) []CalendarEvent {
+	var events []CalendarEvent
+	for i := 0; i < days; i++ {
+		for j, span := range FacadeSunSpans(opts.Lat, opts.Lng, facade, start.AddDate(0, 0, i)) {
+			events = append(events, newFacadeEvent(span, j, facade, opts))
+		}
+	}
+	return events
+}
+
+// newFacadeEvent creates an event spanning the day's index'th span of
+// direct sun
+func newFacadeEvent(span FacadeSun, index int, facade Facade, opts CalendarOptions) CalendarEvent {
+	lines := []string{
+		fmt.Sprintf("Time: %s (%s)", opts.ClockSpan(span.Start, span.End, " to "), FormatDuration(span.End.Sub(span.Start))),
+		fmt.Sprintf("Location: %s", opts.Location),
+		"Coordinates: " + FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+		"",
+		fmt.Sprintf("Window: facing %s (%.0f°), %.0f° field of view", CompassPoint(facade.Azimuth), facade.Azimuth, facade.FieldOfView),
+	}
+	if !span.Direct.IsZero() {
+		lines = append(lines, fmt.Sprintf("Most direct: %s, %s off straight in (sun at %s)",
+			span.Direct.In(opts.Timezone).Format("15:04"), opts.Locale.Degrees(span.Incidence, 0), opts.Locale.Degrees(span.Elevation, 0)))
+	}
+
+	return CalendarEvent{
+		UID:         locationUID(span.Start, opts.Lat, opts.Lng, opts.Precision, fmt.Sprintf("facade_sun-%d", index)),
+		Type:        "facade_sun",
+		Start:       span.Start,
+		End:         span.End,
+		Summary:     "Direct sun on window",
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+	}
+}
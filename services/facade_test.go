@@ -0,0 +1,87 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFacadeLit(t *testing.T) {
+	south := Facade{Azimuth: 180, FieldOfView: 90}
+	tests := []struct {
+		azimuth, elevation float64
+		want               bool
+	}{
+		{180, 20, true},
+		{225, 20, true},
+		{226, 20, false},
+		{135, 20, true},
+		{180, -1, false},
+	}
+	for _, tt := range tests {
+		if got := south.Lit(tt.azimuth, tt.elevation); got != tt.want {
+			t.Errorf("Lit(%v, %v) = %v, want %v", tt.azimuth, tt.elevation, got, tt.want)
+		}
+	}
+	// The field of view wraps around north
+	north := Facade{Azimuth: 0, FieldOfView: 60}
+	if !north.Lit(350, 10) || !north.Lit(20, 10) || north.Lit(40, 10) {
+		t.Error("expected a north-facing window to see 330° to 30°")
+	}
+}
+
+func TestFacadeSunSpans(t *testing.T) {
+	tz := GetTimezone(55.6761, 12.5683)
+	south := Facade{Azimuth: 180, FieldOfView: 180}
+
+	// In midwinter a south window in Copenhagen has sun from sunrise to sunset
+	spans := FacadeSunSpans(55.6761, 12.5683, south, time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC))
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if start, end := span.Start.In(tz).Format("15:04"), span.End.In(tz).Format("15:04"); start != "08:45" || end != "15:30" {
+		t.Errorf("expected sun from 08:45 to 15:30, got %s to %s", start, end)
+	}
+	// The low sun shines almost straight in at solar noon
+	if direct := span.Direct.In(tz).Format("15:04"); direct != "12:07" || span.Incidence > 12 || span.Incidence < 10 {
+		t.Errorf("expected the most direct sun at 12:07, 11° off, got %s, %.1f°", direct, span.Incidence)
+	}
+
+	// In midsummer the sun rises and sets in the north, behind the window
+	spans = FacadeSunSpans(55.6761, 12.5683, south, time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC))
+	if len(spans) != 1 || spans[0].Start.In(tz).Hour() != 8 || spans[0].End.In(tz).Hour() != 18 {
+		t.Errorf("expected sun from about 08:20 to 18:03, got %+v", spans)
+	}
+	// ... so a north window gets the morning and the evening
+	north := Facade{Azimuth: 0, FieldOfView: 180}
+	spans = FacadeSunSpans(55.6761, 12.5683, north, time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC))
+	if len(spans) != 2 || spans[0].End.In(tz).Hour() != 8 || spans[1].Start.In(tz).Hour() != 18 {
+		t.Errorf("expected morning and evening sun, got %+v", spans)
+	}
+	if spans[0].Elevation <= 0 || spans[1].Elevation <= 0 {
+		t.Error("expected the most direct sun above the horizon")
+	}
+	if len(FacadeSunSpans(55.6761, 12.5683, north, time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC))) != 0 {
+		t.Error("expected no sun through a north window in midwinter")
+	}
+}
+
+func TestBuildFacadeEvents(t *testing.T) {
+	tz := GetTimezone(55.6761, 12.5683)
+	opts := CalendarOptions{Lat: 55.6761, Lng: 12.5683, Location: "Copenhagen", Timezone: tz}
+
+	events := BuildFacadeEvents(opts, Facade{Azimuth: 180, FieldOfView: 180}, time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC), 3)
+	if len(events) != 3 {
+		t.Fatalf("expected an event a day, got %d", len(events))
+	}
+	event := events[0]
+	if event.Type != "facade_sun" || event.Summary != "Direct sun on window" {
+		t.Errorf("unexpected event %s: %s", event.Type, event.Summary)
+	}
+	for _, want := range []string{"Time: 08:45 to 15:30 (6h 44m)", "Window: facing S (180°), 180° field of view", "Most direct: 12:07, 11° off straight in (sun at 11°)"} {
+		if !strings.Contains(event.Description, want) {
+			t.Errorf("expected %q in description:\n%s", want, event.Description)
+		}
+	}
+}
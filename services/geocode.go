@@ -0,0 +1,166 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// userAgent identifies CalSun to upstream APIs
+const userAgent = "CalSun/1.0 (+https://github.com/madsbacha/calsun)"
+
+const (
+	// NominatimURL is the public OpenStreetMap Nominatim instance
+	NominatimURL = "https://nominatim.openstreetmap.org"
+
+	geocodeCacheTTL   = 24 * time.Hour
+	geocodeCacheSize  = 10000
+	geocodeMinRequest = time.Second // Nominatim usage policy: max 1 request per second
+)
+
+// Place is a geocoding result
+type Place struct {
+	Name        string  `json:"name"`         // Short name (e.g., "Copenhagen")
+	DisplayName string  `json:"display_name"` // Full address as returned by the provider
+	Lat         float64 `json:"lat"`
+	Lng         float64 `json:"lng"`
+	Importance  float64 `json:"-"` // Provider's prominence ranking (0-1), used to detect ambiguous names
+}
+
+// Geocoder resolves place names to coordinates using a Nominatim-compatible
+// API, caching results and throttling upstream requests
+type Geocoder struct {
+	baseURL string
+	client  *http.Client
+	cache   *ttlCache[[]Place]
+	pins    *PlaceStore // Places resolved for calendar URLs
+
+	mu          sync.Mutex
+	lastRequest time.Time // Slot reserved by the latest upstream request
+	minInterval time.Duration
+	now         Clock
+}
+
+// NewGeocoder creates a geocoder for the Nominatim API at baseURL
+func NewGeocoder(baseURL string) *Geocoder {
+	return &Geocoder{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		client:      &http.Client{Timeout: 10 * time.Second},
+		cache:       newTTLCache[[]Place](geocodeCacheTTL, geocodeCacheSize),
+		pins:        &PlaceStore{places: make(map[string]Place)},
+		minInterval: geocodeMinRequest,
+		now:         time.Now,
+	}
+}
+
+// Cache returns the cached search results
+func (g *Geocoder) Cache() Cache {
+	return g.cache
+}
+
+// Suggest returns up to limit places matching query
+func (g *Geocoder) Suggest(query string, limit int) ([]Place, error) {
+	query = normalizePlaceQuery(query)
+	key := fmt.Sprintf("%d:%s", limit, strings.ToLower(query))
+	if places, ok := g.cache.Get(key); ok {
+		return places, nil
+	}
+
+	places, err := g.search(query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	g.cache.Set(key, places)
+	return places, nil
+}
+
+// normalizePlaceQuery collapses whitespace in a place query
+func normalizePlaceQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// search queries the upstream API
+func (g *Geocoder) search(query string, limit int) ([]Place, error) {
+	params := url.Values{}
+	params.Set("format", "json")
+	params.Set("q", query)
+	params.Set("limit", strconv.Itoa(limit))
+
+	req, err := http.NewRequest("GET", g.baseURL+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	g.throttle()
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("geocoding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("geocoding request failed: status %d", resp.StatusCode)
+	}
+
+	var results []struct {
+		Lat         string  `json:"lat"`
+		Lon         string  `json:"lon"`
+		Name        string  `json:"name"`
+		DisplayName string  `json:"display_name"`
+		Importance  float64 `json:"importance"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("invalid geocoding response: %w", err)
+	}
+
+	places := make([]Place, 0, len(results))
+	for _, res := range results {
+		lat, err := strconv.ParseFloat(res.Lat, 64)
+		if err != nil {
+			continue
+		}
+		lng, err := strconv.ParseFloat(res.Lon, 64)
+		if err != nil {
+			continue
+		}
+
+		name := res.Name
+		if name == "" {
+			name, _, _ = strings.Cut(res.DisplayName, ",")
+		}
+
+		places = append(places, Place{
+			Name:        strings.TrimSpace(name),
+			DisplayName: res.DisplayName,
+			Lat:         lat,
+			Lng:         lng,
+			Importance:  res.Importance,
+		})
+	}
+
+	return places, nil
+}
+
+// throttle blocks until the minimum interval since the previous upstream
+// request has passed. The request's slot is reserved under the lock and the
+// wait happens outside it, so concurrent callers each sleep until their own
+// slot instead of queueing on the mutex.
+func (g *Geocoder) throttle() {
+	g.mu.Lock()
+	now := g.now()
+	next := g.lastRequest.Add(g.minInterval)
+	if next.Before(now) {
+		next = now
+	}
+	g.lastRequest = next
+	g.mu.Unlock()
+
+	time.Sleep(next.Sub(now))
+}
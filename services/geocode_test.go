@@ -0,0 +1,135 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const nominatimResponse = `[
+	{"lat": "55.6867243", "lon": "12.5700724", "name": "Copenhagen", "display_name": "Copenhagen, Capital Region of Denmark, Denmark"},
+	{"lat": "not-a-number", "lon": "0", "name": "Broken", "display_name": "Broken"},
+	{"lat": "55.6761", "lon": "12.5683", "name": "", "display_name": "Copenhagen Municipality, Denmark"}
+]`
+
+func newTestGeocoder(t *testing.T, requests *int32) *Geocoder {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		if r.URL.Path != "/search" || r.URL.Query().Get("q") == "" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("User-Agent") == "" {
+			http.Error(w, "missing user agent", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(nominatimResponse))
+	}))
+	t.Cleanup(server.Close)
+
+	g := NewGeocoder(server.URL)
+	g.minInterval = 0
+	return g
+}
+
+func TestGeocoder_Suggest(t *testing.T) {
+	var requests int32
+	g := newTestGeocoder(t, &requests)
+
+	places, err := g.Suggest("Copenhagen", 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(places) != 2 {
+		t.Fatalf("expected 2 valid places, got %d", len(places))
+	}
+	if places[0].Name != "Copenhagen" || places[0].Lat != 55.6867243 {
+		t.Errorf("unexpected first place: %+v", places[0])
+	}
+	if places[1].Name != "Copenhagen Municipality" {
+		t.Errorf("expected name derived from display name, got %q", places[1].Name)
+	}
+}
+
+func TestGeocoder_Caching(t *testing.T) {
+	var requests int32
+	g := newTestGeocoder(t, &requests)
+
+	for _, q := range []string{"Copenhagen", "copenhagen", "  Copenhagen  "} {
+		if _, err := g.Suggest(q, 5); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("expected 1 upstream request, got %d", requests)
+	}
+}
+
+func TestGeocoder_Throttle(t *testing.T) {
+	g := NewGeocoder("http://geocoder.invalid")
+	g.minInterval = 50 * time.Millisecond
+
+	// Concurrent callers get consecutive slots a minimum interval apart
+	var mu sync.Mutex
+	var done []time.Time
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.throttle()
+			mu.Lock()
+			done = append(done, time.Now())
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	slices.SortFunc(done, func(a, b time.Time) int { return a.Compare(b) })
+	for i := 1; i < len(done); i++ {
+		if gap := done[i].Sub(done[i-1]); gap < 40*time.Millisecond {
+			t.Errorf("expected requests at least the minimum interval apart, got %s", gap)
+		}
+	}
+}
+
+func TestGeocoder_CachedDuringThrottle(t *testing.T) {
+	var requests int32
+	g := newTestGeocoder(t, &requests)
+	if _, err := g.Suggest("Copenhagen", 5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// An uncached search waits for its slot; cached ones must not wait for it
+	g.minInterval = 500 * time.Millisecond
+	go g.Suggest("Aarhus", 5)
+	time.Sleep(10 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := g.Suggest("Copenhagen", 5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("cached suggestion took %s while another search was throttled", elapsed)
+	}
+}
+
+func TestGeocoder_UpstreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	g := NewGeocoder(server.URL)
+	g.minInterval = 0
+
+	if _, err := g.Suggest("Copenhagen", 5); err == nil {
+		t.Error("expected error for upstream failure")
+	}
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"errors"
+	"strings"
+)
+
+// geohashAlphabet is the geohash base32 alphabet (no a, i, l, or o)
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// maxGeohashLength bounds geohash input; 12 characters is below 4 cm
+const maxGeohashLength = 12
+
+// DecodeGeohash returns the center of the cell a geohash (e.g., "u3buz")
+// identifies
+func DecodeGeohash(hash string) (lat, lng float64, err error) {
+	hash = strings.ToLower(hash)
+	if hash == "" || len(hash) > maxGeohashLength {
+		return 0, 0, errors.New("geohash must be 1 to 12 characters")
+	}
+
+	latRange := [2]float64{-90, 90}
+	lngRange := [2]float64{-180, 180}
+	isLng := true // Bits alternate, starting with longitude
+	for _, c := range hash {
+		value := strings.IndexRune(geohashAlphabet, c)
+		if value < 0 {
+			return 0, 0, errors.New("geohash contains invalid character " + string(c))
+		}
+		for bit := 4; bit >= 0; bit-- {
+			r := &latRange
+			if isLng {
+				r = &lngRange
+			}
+			mid := (r[0] + r[1]) / 2
+			if value>>bit&1 == 1 {
+				r[0] = mid
+			} else {
+				r[1] = mid
+			}
+			isLng = !isLng
+		}
+	}
+
+	return (latRange[0] + latRange[1]) / 2, (lngRange[0] + lngRange[1]) / 2, nil
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDecodeGeohash(t *testing.T) {
+	tests := []struct {
+		hash     string
+		lat, lng float64
+	}{
+		{"ezs42", 42.60498046875, -5.60302734375},
+		{"EZS42", 42.60498046875, -5.60302734375},
+		{"u3buz", 55.70068359375, 12.63427734375},
+		{"s", 22.5, 22.5},
+	}
+	for _, tt := range tests {
+		lat, lng, err := DecodeGeohash(tt.hash)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.hash, err)
+			continue
+		}
+		if math.Abs(lat-tt.lat) > 1e-9 || math.Abs(lng-tt.lng) > 1e-9 {
+			t.Errorf("%s: expected %v,%v, got %v,%v", tt.hash, tt.lat, tt.lng, lat, lng)
+		}
+	}
+}
+
+func TestDecodeGeohash_Invalid(t *testing.T) {
+	for _, hash := range []string{"", "u3bua", "u3b-z", "u3buzu3buzu3b"} {
+		if _, _, err := DecodeGeohash(hash); err == nil {
+			t.Errorf("%q: expected error", hash)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const googleCalendarAPI = "https://www.googleapis.com/calendar/v3"
+
+// GoogleCalendar pushes events into Google Calendar via the Calendar API
+type GoogleCalendar struct {
+	oauth   *OAuthConfig
+	client  *http.Client
+	baseURL string
+}
+
+// NewGoogleCalendar creates a Google Calendar provider for the given OAuth
+// client credentials
+func NewGoogleCalendar(clientID, clientSecret string) *GoogleCalendar {
+	return &GoogleCalendar{
+		oauth: &OAuthConfig{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			AuthURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+			TokenURL:     "https://oauth2.googleapis.com/token",
+			Scopes:       []string{"https://www.googleapis.com/auth/calendar.events"},
+			// Request a refresh token so events can be synced without the user present
+			AuthParams: url.Values{"access_type": {"offline"}, "prompt": {"consent"}},
+		},
+		client:  &http.Client{Timeout: 30 * time.Second},
+		baseURL: googleCalendarAPI,
+	}
+}
+
+// OAuth returns the Google OAuth client configuration
+func (g *GoogleCalendar) OAuth() *OAuthConfig {
+	return g.oauth
+}
+
+// DefaultCalendarID returns the user's primary calendar
+func (g *GoogleCalendar) DefaultCalendarID() string {
+	return "primary"
+}
+
+// googleEvent is the Calendar API event resource (subset)
+type googleEvent struct {
+	ID          string          `json:"id,omitempty"`
+	Summary     string          `json:"summary"`
+	Description string          `json:"description"`
+	Location    string          `json:"location"`
+	Start       googleEventTime `json:"start"`
+	End         googleEventTime `json:"end"`
+}
+
+type googleEventTime struct {
+	DateTime string `json:"dateTime"`
+}
+
+// UpsertEvent updates the event with the ID derived from its UID, inserting
+// it if it does not exist yet
+func (g *GoogleCalendar) UpsertEvent(ctx context.Context, accessToken, calendarID string, event CalendarEvent) error {
+	body := googleEvent{
+		ID:          googleEventID(event.UID),
+		Summary:     event.Summary,
+		Description: event.Description,
+		Location:    event.Location,
+		Start:       googleEventTime{DateTime: event.Start.UTC().Format(time.RFC3339)},
+		End:         googleEventTime{DateTime: event.End.UTC().Format(time.RFC3339)},
+	}
+
+	eventsURL := fmt.Sprintf("%s/calendars/%s/events", g.baseURL, url.PathEscape(calendarID))
+
+	status, err := g.do(ctx, "PUT", eventsURL+"/"+body.ID, accessToken, body)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusNotFound {
+		status, err = g.do(ctx, "POST", eventsURL, accessToken, body)
+		if err != nil {
+			return err
+		}
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("google calendar: status %d", status)
+	}
+	return nil
+}
+
+// do sends an authenticated JSON request and returns the response status
+func (g *GoogleCalendar) do(ctx context.Context, method, u, accessToken string, body any) (int, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("google calendar: %w", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// googleEventID derives a Calendar API event ID from a UID. IDs must use
+// base32hex characters (a-v, 0-9), which lowercase hex satisfies.
+func googleEventID(uid string) string {
+	hash := sha256.Sum256([]byte(uid))
+	return "calsun" + hex.EncodeToString(hash[:16])
+}
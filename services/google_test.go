@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGoogleEventID(t *testing.T) {
+	id := googleEventID("abc@calsun")
+
+	if id != googleEventID("abc@calsun") {
+		t.Error("event IDs must be stable")
+	}
+	if len(id) < 5 || len(id) > 1024 {
+		t.Errorf("event ID length out of range: %d", len(id))
+	}
+	for _, c := range id {
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'v') {
+			t.Errorf("event ID contains non-base32hex character %q", c)
+		}
+	}
+}
+
+func TestGoogleCalendar_UpsertEvent(t *testing.T) {
+	event := CalendarEvent{
+		UID:     "abc@calsun",
+		Start:   time.Date(2024, 6, 21, 2, 25, 0, 0, time.UTC),
+		End:     time.Date(2024, 6, 21, 2, 26, 0, 0, time.UTC),
+		Summary: "Sunrise 04:25",
+	}
+	id := googleEventID(event.UID)
+
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var body googleEvent
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Summary != "Sunrise 04:25" || body.Start.DateTime != "2024-06-21T02:25:00Z" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch {
+		case r.Method == "PUT" && strings.HasSuffix(r.URL.Path, "/calendars/primary/events/"+id):
+			w.WriteHeader(http.StatusNotFound) // Not created yet
+		case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/calendars/primary/events") && body.ID == id:
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	g := NewGoogleCalendar("client", "secret")
+	g.baseURL = server.URL
+
+	if err := g.UpsertEvent(context.Background(), "token", "primary", event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(methods, ",") != "PUT,POST" {
+		t.Errorf("expected update then insert, got %v", methods)
+	}
+
+	if err := g.UpsertEvent(context.Background(), "wrong", "primary", event); err == nil {
+		t.Error("expected error for unauthorized request")
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"slices"
+	"time"
+)
+
+// Day groupings of sun events, which decide the date in their UIDs and which
+// events count as duplicates
+const (
+	GroupCivil = "civil" // Days of the event generator, midnight to midnight UTC
+	GroupSolar = "solar" // Sunset to sunset, named for the date the evening precedes
+)
+
+// SolarDays divides time into solar days, each running from a sunset to the
+// next, as in the Hebrew and Islamic calendars. A solar day is named for the
+// civil date that starts during it: the day starting at Friday's sunset is
+// Saturday. Days without a sunset (polar day and night) end at local
+// midnight instead.
+type SolarDays struct {
+	starts []time.Time // Start of each solar day, ascending
+	dates  []time.Time // Local midnight of the date each day is named for
+}
+
+// NewSolarDays returns the solar days starting in sunTimes, which must be
+// consecutive days, with dates local to tz
+func NewSolarDays(sunTimes []DaySunTimes, tz *time.Location) SolarDays {
+	var days SolarDays
+	for _, day := range sunTimes {
+		noon := day.Times.SolarNoon.In(tz)
+		date := time.Date(noon.Year(), noon.Month(), noon.Day()+1, 0, 0, 0, 0, tz)
+		start := date
+		if day.Sunset != nil {
+			start = day.Sunset.Time
+		}
+		if n := len(days.starts); n > 0 && !start.After(days.starts[n-1]) {
+			continue // Sunset times bisected around the same evening
+		}
+		days.starts = append(days.starts, start)
+		days.dates = append(days.dates, date)
+	}
+	return days
+}
+
+// Date returns the local midnight of the date of the solar day containing t.
+// Times before the first sunset belong to the day before it.
+func (s SolarDays) Date(t time.Time) time.Time {
+	if len(s.starts) == 0 {
+		return time.Time{}
+	}
+	i, found := slices.BinarySearchFunc(s.starts, t, func(start, t time.Time) int { return start.Compare(t) })
+	if found {
+		return s.dates[i]
+	}
+	if i == 0 {
+		return s.dates[0].AddDate(0, 0, -1)
+	}
+	return s.dates[i-1]
+}
+
+// Span returns when the solar day named for date (a local midnight) starts
+// and ends. Reports false if the days don't cover all of it.
+func (s SolarDays) Span(date time.Time) (start, end time.Time, ok bool) {
+	for i := range len(s.starts) - 1 {
+		if s.dates[i].Equal(date) {
+			return s.starts[i], s.starts[i+1], true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// dayGrouper assigns events to days for their UIDs and drops duplicates
+type dayGrouper struct {
+	solar SolarDays
+	seen  map[string]bool
+}
+
+// newDayGrouper returns the grouper of opts.Grouping, or nil for civil days,
+// whose UIDs use the event's UTC date and which keep every event
+func newDayGrouper(sunTimes []DaySunTimes, opts CalendarOptions) *dayGrouper {
+	if opts.Grouping != GroupSolar {
+		return nil
+	}
+	return &dayGrouper{solar: NewSolarDays(sunTimes, opts.Timezone), seen: make(map[string]bool)}
+}
+
+// group sets the UID of event from its solar day, reporting false if the
+// day already has an event of uidType
+func (g *dayGrouper) group(event *CalendarEvent, uidType string, opts CalendarOptions) bool {
+	date := g.solar.Date(event.Start)
+	key := uidType + " " + date.Format(time.DateOnly)
+	if g.seen[key] {
+		return false
+	}
+	g.seen[key] = true
+	event.UID = locationUID(date, opts.Lat, opts.Lng, opts.Precision, uidType)
+	return true
+}
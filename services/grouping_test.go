@@ -0,0 +1,85 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSolarDays(t *testing.T) {
+	tz, _ := time.LoadLocation("Europe/Copenhagen")
+	sunTimes := GetSunTimesRange(55.6761, 12.5683, time.Date(2025, 6, 19, 0, 0, 0, 0, time.UTC), 3)
+	days := NewSolarDays(sunTimes, tz)
+
+	sunset := sunTimes[1].Sunset.Time // Friday, June 20
+	saturday := time.Date(2025, 6, 21, 0, 0, 0, 0, tz)
+	if got := days.Date(sunset.Add(-time.Minute)); !got.Equal(saturday.AddDate(0, 0, -1)) {
+		t.Errorf("expected Friday afternoon in Friday, got %v", got)
+	}
+	if got := days.Date(sunset); !got.Equal(saturday) {
+		t.Errorf("expected Friday's sunset to start Saturday, got %v", got)
+	}
+	if got := days.Date(sunTimes[2].Sunrise.Time); !got.Equal(saturday) {
+		t.Errorf("expected Saturday's sunrise in Saturday, got %v", got)
+	}
+	if got := days.Date(sunTimes[0].Sunrise.Time); !got.Equal(time.Date(2025, 6, 19, 0, 0, 0, 0, tz)) {
+		t.Errorf("expected times before the first sunset in the day before it, got %v", got)
+	}
+
+	start, end, ok := days.Span(saturday)
+	if !ok || !start.Equal(sunset) || !end.Equal(sunTimes[2].Sunset.Time) {
+		t.Errorf("expected Saturday from Friday's to Saturday's sunset, got %v to %v (%v)", start, end, ok)
+	}
+	if _, _, ok := days.Span(time.Date(2025, 6, 22, 0, 0, 0, 0, tz)); ok {
+		t.Error("expected no span for a day the sun times don't end")
+	}
+}
+
+func TestSolarDays_PolarDay(t *testing.T) {
+	tz, _ := time.LoadLocation("Europe/Oslo")
+	sunTimes := GetSunTimesRange(69.6492, 18.9553, time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC), 3)
+	days := NewSolarDays(sunTimes, tz)
+
+	// Without sunsets, days end at local midnight
+	start, end, ok := days.Span(time.Date(2025, 6, 22, 0, 0, 0, 0, tz))
+	if !ok || !start.Equal(time.Date(2025, 6, 22, 0, 0, 0, 0, tz)) || end.Sub(start) != 24*time.Hour {
+		t.Errorf("expected a midnight-to-midnight day, got %v to %v (%v)", start, end, ok)
+	}
+}
+
+func TestBuildSunEvents_SolarGrouping(t *testing.T) {
+	// Near the start and end of the midnight sun, Tromsø's sunrise and sunset
+	// drift across midnight, so a UTC date can have two of them
+	tz, _ := time.LoadLocation("Europe/Oslo")
+	sunTimes := GetSunTimesRange(69.6492, 18.9553, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), 365)
+	opts := CalendarOptions{Lat: 69.6492, Lng: 18.9553, Timezone: tz, Events: NewEventSet(EventSunrise, EventSunset, EventGoldenHour)}
+
+	duplicates := func(events []CalendarEvent) int {
+		seen := make(map[string]bool)
+		n := 0
+		for _, event := range events {
+			if seen[event.UID] {
+				n++
+			}
+			seen[event.UID] = true
+		}
+		return n
+	}
+	if n := duplicates(BuildSunEvents(sunTimes, opts)); n == 0 {
+		t.Fatal("expected duplicate UIDs by civil day, the case solar grouping exists for")
+	}
+
+	opts.Grouping = GroupSolar
+	events := BuildSunEvents(sunTimes, opts)
+	if n := duplicates(events); n != 0 {
+		t.Errorf("expected no duplicate UIDs by solar day, got %d", n)
+	}
+	days := NewSolarDays(sunTimes, tz)
+	for _, event := range events {
+		if event.Type == EventGoldenHour {
+			continue // UIDs of the morning and evening spans differ by more than the type
+		}
+		if want := locationUID(days.Date(event.Start), opts.Lat, opts.Lng, 0, event.Type); event.UID != want {
+			t.Fatalf("expected the %s at %v dated by its solar day", event.Type, event.Start)
+		}
+	}
+}
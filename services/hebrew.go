@@ -0,0 +1,145 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+// hebrewEpoch is the Julian Day Number of 1 Tishrei AM 1 (7 October
+// 3761 BCE, Julian)
+const hebrewEpoch = 347998
+
+// Hebrew month numbers, counted from Nisan as in the Torah; the year starts
+// at Tishrei
+const (
+	Nisan   = 1
+	Tishrei = 7
+	Adar    = 12
+	AdarII  = 13 // Only in leap years
+)
+
+// hebrewMonths are the names of the Hebrew months, from Nisan
+var hebrewMonths = [13]string{
+	"Nisan", "Iyar", "Sivan", "Tammuz", "Av", "Elul",
+	"Tishrei", "Cheshvan", "Kislev", "Tevet", "Shevat", "Adar", "Adar II",
+}
+
+// HebrewDate is a date in the Hebrew calendar. The day begins at the
+// previous sunset; the date is that of the civil day's daylight hours.
+type HebrewDate struct {
+	Year  int // Anno Mundi
+	Month int // 1 (Nisan) to 13 (Adar II)
+	Day   int
+}
+
+// String formats the date, e.g. "15 Tevet 5785" or "14 Adar I 5784"
+func (h HebrewDate) String() string {
+	return fmt.Sprintf("%d %s %d", h.Day, h.MonthName(), h.Year)
+}
+
+// MonthName returns the name of the month; Adar is "Adar I" in leap years
+func (h HebrewDate) MonthName() string {
+	if h.Month == Adar && hebrewLeapYear(h.Year) {
+		return "Adar I"
+	}
+	return hebrewMonths[h.Month-1]
+}
+
+// ToHebrew converts the calendar date of t (in its location) to the Hebrew
+// calendar
+func ToHebrew(t time.Time) HebrewDate {
+	jd := gregorianToJDN(t.Year(), int(t.Month()), t.Day())
+
+	// Estimate the year from the mean year length, then correct it
+	year := int(float64(jd-hebrewEpoch)/365.2468) + 1
+	for hebrewNewYear(year+1) <= jd {
+		year++
+	}
+	for hebrewNewYear(year) > jd {
+		year--
+	}
+
+	day := jd - hebrewNewYear(year) + 1
+	month := Tishrei
+	for day > hebrewMonthDays(year, month) {
+		day -= hebrewMonthDays(year, month)
+		month = hebrewNextMonth(year, month)
+	}
+	return HebrewDate{Year: year, Month: month, Day: day}
+}
+
+// hebrewLeapYear reports whether the year has a thirteenth month (Adar II),
+// as 7 years in each 19-year cycle do
+func hebrewLeapYear(year int) bool {
+	return (7*year+1)%19 < 7
+}
+
+// hebrewElapsedDays returns the days from the epoch to the molad of Tishrei
+// of year, postponed a day if it falls on a Sunday, Wednesday, or Friday
+func hebrewElapsedDays(year int) int {
+	months := (235*year - 234) / 19
+	parts := 12084 + 13753*months
+	days := 29*months + parts/25920
+	if (3*(days+1))%7 < 3 {
+		days++
+	}
+	return days
+}
+
+// hebrewNewYear returns the Julian Day Number of 1 Tishrei of year, applying
+// the postponements that keep every year's length valid
+func hebrewNewYear(year int) int {
+	before, this, after := hebrewElapsedDays(year-1), hebrewElapsedDays(year), hebrewElapsedDays(year+1)
+	delay := 0
+	if after-this == 356 {
+		delay = 2
+	} else if this-before == 382 {
+		delay = 1
+	}
+	return hebrewEpoch + this + delay
+}
+
+// hebrewYearDays returns the number of days in year (353-355, or 383-385 in
+// leap years)
+func hebrewYearDays(year int) int {
+	return hebrewNewYear(year+1) - hebrewNewYear(year)
+}
+
+// hebrewMonthDays returns the number of days in a month of year
+func hebrewMonthDays(year, month int) int {
+	switch month {
+	case 2, 4, 6, 10, AdarII:
+		return 29
+	case Adar:
+		if hebrewLeapYear(year) {
+			return 30
+		}
+		return 29
+	case 8: // Cheshvan is long in complete years
+		if hebrewYearDays(year)%10 == 5 {
+			return 30
+		}
+		return 29
+	case 9: // Kislev is short in deficient years
+		if hebrewYearDays(year)%10 == 3 {
+			return 29
+		}
+		return 30
+	}
+	return 30
+}
+
+// hebrewNextMonth returns the month after month within year, which runs
+// from Tishrei to Elul
+func hebrewNextMonth(year, month int) int {
+	switch month {
+	case Adar:
+		if hebrewLeapYear(year) {
+			return AdarII
+		}
+		return Nisan
+	case AdarII:
+		return Nisan
+	}
+	return month + 1
+}
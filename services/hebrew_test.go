@@ -0,0 +1,75 @@
+package services
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestToHebrew(t *testing.T) {
+	tests := []struct {
+		date string
+		want HebrewDate
+	}{
+		{"2024-10-03", HebrewDate{5785, Tishrei, 1}}, // Rosh Hashanah
+		{"2025-01-15", HebrewDate{5785, 10, 15}},
+		{"2024-12-01", HebrewDate{5785, 8, 30}}, // Cheshvan is long in a complete year
+		{"2024-02-10", HebrewDate{5784, Adar, 1}},
+		{"2024-03-24", HebrewDate{5784, AdarII, 14}}, // Purim in a leap year
+		{"2025-04-13", HebrewDate{5785, Nisan, 15}},  // Passover
+		{"2000-01-01", HebrewDate{5760, 10, 23}},
+	}
+
+	for _, tt := range tests {
+		date, _ := time.Parse("2006-01-02", tt.date)
+		if got := ToHebrew(date); got != tt.want {
+			t.Errorf("ToHebrew(%s) = %+v, want %+v", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestToHebrew_Continuous(t *testing.T) {
+	start := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+	prev := ToHebrew(start)
+	for i := 1; i < 60*365; i++ {
+		date := start.AddDate(0, 0, i)
+		got := ToHebrew(date)
+		switch {
+		case got.Year == prev.Year && got.Month == prev.Month && got.Day == prev.Day+1:
+		case got.Day == 1 && prev.Day >= 29 && (got.Month == hebrewNextMonth(prev.Year, prev.Month) || got.Month == Tishrei && got.Year == prev.Year+1):
+		default:
+			t.Fatalf("%s is %+v after %+v", date.Format("2006-01-02"), got, prev)
+		}
+		prev = got
+	}
+}
+
+func TestHebrewYearDays(t *testing.T) {
+	for year := 5700; year < 5900; year++ {
+		days := hebrewYearDays(year)
+		valid := []int{353, 354, 355}
+		if hebrewLeapYear(year) {
+			valid = []int{383, 384, 385}
+		}
+		if !slices.Contains(valid, days) {
+			t.Errorf("year %d has %d days", year, days)
+		}
+	}
+}
+
+func TestHebrewDate_String(t *testing.T) {
+	tests := []struct {
+		date HebrewDate
+		want string
+	}{
+		{HebrewDate{5785, 10, 15}, "15 Tevet 5785"},
+		{HebrewDate{5784, Adar, 1}, "1 Adar I 5784"},
+		{HebrewDate{5784, AdarII, 14}, "14 Adar II 5784"},
+		{HebrewDate{5785, Adar, 14}, "14 Adar 5785"},
+	}
+	for _, tt := range tests {
+		if got := tt.date.String(); got != tt.want {
+			t.Errorf("String() = %s, want %s", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// hijriEpoch is the Julian Day Number of 1 Muharram 1 AH (16 July 622, Julian)
+const hijriEpoch = 1948440
+
+// HijriMonths are the names of the Islamic months
+var HijriMonths = [12]string{
+	"Muharram", "Safar", "Rabi' al-Awwal", "Rabi' al-Thani", "Jumada al-Ula", "Jumada al-Akhirah",
+	"Rajab", "Sha'ban", "Ramadan", "Shawwal", "Dhu al-Qa'dah", "Dhu al-Hijjah",
+}
+
+// Ramadan is the number of the month of Ramadan
+const Ramadan = 9
+
+// HijriDate is a date in the tabular (arithmetic) Islamic calendar. Actual
+// month starts depend on moon sighting and may differ by a day or two.
+type HijriDate struct {
+	Year  int
+	Month int // 1-12
+	Day   int
+}
+
+// String formats the date, e.g. "9 Ramadan 1445 AH"
+func (h HijriDate) String() string {
+	return fmt.Sprintf("%d %s %d AH", h.Day, HijriMonths[h.Month-1], h.Year)
+}
+
+// ToHijri converts the calendar date of t (in its location) to the Hijri
+// calendar
+func ToHijri(t time.Time) HijriDate {
+	jd := gregorianToJDN(t.Year(), int(t.Month()), t.Day())
+
+	year := int(math.Floor(float64(30*(jd-hijriEpoch)+10646) / 10631))
+	month := min(12, int(math.Ceil(float64(jd-(29+hijriToJDN(year, 1, 1)))/29.5))+1)
+	day := jd - hijriToJDN(year, month, 1) + 1
+	return HijriDate{Year: year, Month: month, Day: day}
+}
+
+// FromHijri returns the Gregorian date of a Hijri date, at midnight UTC
+func FromHijri(h HijriDate) time.Time {
+	jd := hijriToJDN(h.Year, h.Month, h.Day)
+	// JDN 2440588 is 1 January 1970
+	return time.Unix(int64(jd-2440588)*24*60*60, 0).UTC()
+}
+
+// hijriToJDN returns the Julian Day Number of a tabular Hijri date
+func hijriToJDN(year, month, day int) int {
+	return day + int(math.Ceil(29.5*float64(month-1))) + (year-1)*354 + (3+11*year)/30 + hijriEpoch - 1
+}
+
+// gregorianToJDN returns the Julian Day Number of a Gregorian date
+func gregorianToJDN(year, month, day int) int {
+	a := (14 - month) / 12
+	y := year + 4800 - a
+	m := month + 12*a - 3
+	return day + (153*m+2)/5 + 365*y + y/4 - y/100 + y/400 - 32045
+}
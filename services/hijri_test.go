@@ -0,0 +1,42 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestToHijri(t *testing.T) {
+	tests := []struct {
+		date string
+		want HijriDate
+	}{
+		{"2024-03-11", HijriDate{1445, 9, 1}},
+		{"2024-04-10", HijriDate{1445, 10, 1}},
+		{"2025-03-01", HijriDate{1446, 9, 1}},
+		{"2024-07-08", HijriDate{1446, 1, 1}}, // Umm al-Qura: July 7 (sighting differs from the tabular calendar)
+		{"2000-01-01", HijriDate{1420, 9, 24}},
+	}
+
+	for _, tt := range tests {
+		date, _ := time.Parse("2006-01-02", tt.date)
+		if got := ToHijri(date); got != tt.want {
+			t.Errorf("ToHijri(%s) = %+v, want %+v", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestFromHijri_RoundTrip(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3000; i++ {
+		date := start.AddDate(0, 0, i)
+		if got := FromHijri(ToHijri(date)); !got.Equal(date) {
+			t.Fatalf("round trip of %s gave %s", date.Format("2006-01-02"), got.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestHijriDate_String(t *testing.T) {
+	if got := (HijriDate{1445, 9, 9}).String(); got != "9 Ramadan 1445 AH" {
+		t.Errorf("unexpected string: %s", got)
+	}
+}
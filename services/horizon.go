@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// horizonAzimuthStep is the spacing of a horizon profile's rays, in degrees
+	horizonAzimuthStep = 2
+
+	// horizonNearDistance and horizonFarDistance bound the terrain sampled
+	// along each ray, in metres: nearer ground is the observer's own
+	// surroundings, and farther mountains rarely rise above the sea horizon
+	horizonNearDistance = 100
+	horizonFarDistance  = 25000
+
+	// horizonSamples is the number of elevations sampled along each ray,
+	// spaced geometrically so nearby terrain is sampled more densely
+	horizonSamples = 16
+
+	// horizonEyeHeight is the observer's eye height above the ground, in metres
+	horizonEyeHeight = 2
+
+	// terrainRefraction is the refraction coefficient of light grazing the
+	// terrain: the sight line curves with 13% of the Earth's curvature
+	terrainRefraction = 0.13
+
+	// sunSemiDiameter is the sun's apparent radius, in degrees
+	sunSemiDiameter = 0.2667
+
+	// skylineSearchStep is the sampling interval when searching for the sun
+	// crossing a skyline; shorter glimpses between peaks are missed
+	skylineSearchStep = 2 * time.Minute
+
+	// Terrain doesn't change, so profiles are cached for a month
+	horizonCacheTTL  = 30 * 24 * time.Hour
+	horizonCacheSize = 1000
+)
+
+// HorizonProfile is the skyline around a location: the elevation of the
+// terrain's highest point above the astronomical horizon, in degrees, on rays
+// every horizonAzimuthStep degrees of azimuth clockwise from north
+type HorizonProfile struct {
+	Angles []float64
+}
+
+// At returns the skyline's elevation at an azimuth, interpolated between rays
+func (p *HorizonProfile) At(azimuth float64) float64 {
+	n := len(p.Angles)
+	position := math.Mod(math.Mod(azimuth, 360)+360, 360) / horizonAzimuthStep
+	i := int(position)
+	frac := position - float64(i)
+	return p.Angles[i%n]*(1-frac) + p.Angles[(i+1)%n]*frac
+}
+
+// BuildHorizonProfile samples the terrain along rays in every direction
+// from a location and returns its skyline, seen from eye height above the
+// ground. Sight lines bend with the Earth's curvature less refraction, and
+// the skyline is never lower than the sea horizon.
+func BuildHorizonProfile(ctx context.Context, provider ElevationProvider, lat, lng float64) (*HorizonProfile, error) {
+	rays := 360 / horizonAzimuthStep
+	distances := make([]float64, horizonSamples)
+	for i := range distances {
+		distances[i] = horizonNearDistance * math.Pow(horizonFarDistance/horizonNearDistance, float64(i)/(horizonSamples-1))
+	}
+
+	points := []GeoPoint{{lat, lng}}
+	for ray := range rays {
+		for _, d := range distances {
+			points = append(points, destinationPoint(lat, lng, float64(ray*horizonAzimuthStep), d))
+		}
+	}
+	elevations, err := provider.Elevations(ctx, points)
+	if err != nil {
+		return nil, err
+	}
+
+	eye := math.Max(elevations[0], 0) + horizonEyeHeight
+	seaHorizon := -radToDeg(math.Sqrt(2 * eye * (1 - terrainRefraction) / earthRadiusMetres))
+	profile := &HorizonProfile{Angles: make([]float64, rays)}
+	for ray := range rays {
+		highest := seaHorizon
+		for i, d := range distances {
+			drop := d * d * (1 - terrainRefraction) / (2 * earthRadiusMetres)
+			height := elevations[1+ray*horizonSamples+i] - eye - drop
+			highest = math.Max(highest, radToDeg(math.Atan2(height, d)))
+		}
+		profile.Angles[ray] = highest
+	}
+	return profile, nil
+}
+
+// earthRadiusMetres is the Earth's mean radius
+const earthRadiusMetres = 6371000
+
+// destinationPoint returns the location distance metres from a location along
+// a great circle starting at bearing degrees clockwise from north
+func destinationPoint(lat, lng, bearing, distance float64) GeoPoint {
+	phi, lambda, theta := degToRad(lat), degToRad(lng), degToRad(bearing)
+	delta := distance / earthRadiusMetres
+
+	phi2 := math.Asin(math.Sin(phi)*math.Cos(delta) + math.Cos(phi)*math.Sin(delta)*math.Cos(theta))
+	lambda2 := lambda + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(phi), math.Cos(delta)-math.Sin(phi)*math.Sin(phi2))
+	return GeoPoint{Lat: radToDeg(phi2), Lng: math.Mod(radToDeg(lambda2)+540, 360) - 180}
+}
+
+// skylineClearance returns how far the sun's upper limb is above a skyline
+// at t, in degrees, with refraction lifting the sun
+func skylineClearance(p *HorizonProfile, lat, lng float64, t time.Time) float64 {
+	azimuth, elevation := SunPosition(lat, lng, t)
+	return elevation + sunSemiDiameter + refraction(elevation) - p.At(azimuth)
+}
+
+// refraction returns how far the atmosphere lifts a body at a true elevation
+// in degrees (Sæmundsson), levelling off just below the horizon
+func refraction(elevation float64) float64 {
+	elevation = math.Max(elevation, -1)
+	return 1.02 / math.Tan(degToRad(elevation+10.3/(elevation+5.11))) / 60
+}
+
+// SkylineTimes returns when the sun clears a skyline and drops behind it on
+// the solar day around noon: the first rise and the last set, ignoring
+// glimpses between peaks shorter than skylineSearchStep. A zero time means the
+// sun doesn't cross the skyline.
+func (p *HorizonProfile) SkylineTimes(lat, lng float64, noon time.Time) (rise, set time.Time) {
+	clearance := func(t time.Time) float64 { return skylineClearance(p, lat, lng, t) }
+
+	start, end := noon.Add(-12*time.Hour), noon.Add(12*time.Hour)
+	prev := clearance(start)
+	for t := start; t.Before(end); t = t.Add(skylineSearchStep) {
+		next := t.Add(skylineSearchStep)
+		cur := clearance(next)
+		switch {
+		case prev < 0 && cur >= 0 && rise.IsZero():
+			rise = bisectCrossing(clearance, t, next)
+		case prev >= 0 && cur < 0:
+			set = bisectCrossing(clearance, t, next)
+		}
+		prev = cur
+	}
+	return rise, set
+}
+
+// ApplyHorizonProfile moves each day's sunrise and sunset to when the sun
+// clears and drops behind a skyline, keeping the times over a flat horizon in
+// FlatTime. A day the sun doesn't clear the skyline gets neither.
+func ApplyHorizonProfile(days []DaySunTimes, profile *HorizonProfile) {
+	for i := range days {
+		day := &days[i]
+		rise, set := profile.SkylineTimes(day.Lat, day.Lng, day.Times.SolarNoon)
+		day.Sunrise = newSkylineEvent("sunrise", rise, day.Times.Sunrise, day, profile)
+		day.Sunset = newSkylineEvent("sunset", set, day.Times.Sunset, day, profile)
+		day.Times.Sunrise, day.Times.Sunset = rise, set
+		day.Skyline = profile
+	}
+}
+
+// newSkylineEvent creates a sunrise or sunset over a skyline, noting the
+// skyline's elevation and the flat-horizon time
+func newSkylineEvent(eventType string, t, flat time.Time, day *DaySunTimes, profile *HorizonProfile) *SunEvent {
+	event := newSunEvent(eventType, t, day.Lat, day.Lng)
+	if event != nil {
+		event.Skyline = profile.At(event.Azimuth)
+		event.FlatTime = flat
+	}
+	return event
+}
+
+// HorizonProfiles builds horizon profiles from an elevation provider,
+// caching them per location rounded to about 10 m
+type HorizonProfiles struct {
+	provider ElevationProvider
+	cache    *ttlCache[*HorizonProfile]
+}
+
+// NewHorizonProfiles creates a horizon profile source for an elevation
+// provider
+func NewHorizonProfiles(provider ElevationProvider) *HorizonProfiles {
+	return &HorizonProfiles{
+		provider: provider,
+		cache:    newTTLCache[*HorizonProfile](horizonCacheTTL, horizonCacheSize),
+	}
+}
+
+// Cache returns the cached horizon profiles
+func (h *HorizonProfiles) Cache() Cache {
+	return h.cache
+}
+
+// Profile returns the horizon profile of a location
+func (h *HorizonProfiles) Profile(ctx context.Context, lat, lng float64) (*HorizonProfile, error) {
+	key := fmt.Sprintf("%.4f,%.4f", lat, lng)
+	if profile, ok := h.cache.Get(key); ok {
+		return profile, nil
+	}
+
+	profile, err := BuildHorizonProfile(ctx, h.provider, lat, lng)
+	if err != nil {
+		return nil, err
+	}
+	h.cache.Set(key, profile)
+	return profile, nil
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+// terrainFunc is an ElevationProvider computing elevations from coordinates.
+// It counts its batch lookups.
+type terrainFunc struct {
+	elevation func(p GeoPoint) float64
+	calls     int
+}
+
+func (f *terrainFunc) Elevation(ctx context.Context, lat, lng float64) (float64, error) {
+	return f.elevation(GeoPoint{lat, lng}), nil
+}
+
+func (f *terrainFunc) Elevations(ctx context.Context, points []GeoPoint) ([]float64, error) {
+	f.calls++
+	elevations := make([]float64, len(points))
+	for i, p := range points {
+		elevations[i] = f.elevation(p)
+	}
+	return elevations, nil
+}
+
+// flatProfile returns a horizon profile at the same elevation all around
+func flatProfile(angle float64) *HorizonProfile {
+	p := &HorizonProfile{Angles: make([]float64, 360/horizonAzimuthStep)}
+	for i := range p.Angles {
+		p.Angles[i] = angle
+	}
+	return p
+}
+
+func TestDestinationPoint(t *testing.T) {
+	north := destinationPoint(0, 0, 0, 111195)
+	if math.Abs(north.Lat-1) > 0.001 || math.Abs(north.Lng) > 1e-9 {
+		t.Errorf("expected 1° north, got %+v", north)
+	}
+	west := destinationPoint(0, -179.99, 270, 111195)
+	if math.Abs(west.Lng-179.01) > 0.001 {
+		t.Errorf("expected to wrap across the antimeridian, got %+v", west)
+	}
+}
+
+func TestHorizonProfile_At(t *testing.T) {
+	p := flatProfile(0)
+	p.Angles[0], p.Angles[1], p.Angles[len(p.Angles)-1] = 4, 2, 6
+	tests := []struct {
+		azimuth, want float64
+	}{
+		{0, 4},
+		{1, 3},
+		{2, 2},
+		{359, 5},
+		{360, 4},
+		{-1, 5},
+	}
+	for _, tt := range tests {
+		if got := p.At(tt.azimuth); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("At(%v) = %v, want %v", tt.azimuth, got, tt.want)
+		}
+	}
+}
+
+func TestBuildHorizonProfile(t *testing.T) {
+	// A 1000 m ridge about 3 km east of an observer at sea level
+	const lat, lng = 55.6761, 12.5683
+	terrain := &terrainFunc{elevation: func(p GeoPoint) float64 {
+		if p.Lng > lng+0.05 {
+			return 1000
+		}
+		return 0
+	}}
+	profile, err := BuildHorizonProfile(context.Background(), terrain, lat, lng)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The nearest sample on the ridge is a little beyond its edge
+	if east := profile.At(90); east < 12 || east > 18 {
+		t.Errorf("expected the ridge 12-18° up in the east, got %.2f°", east)
+	}
+	// Over the sea, the horizon dips below eye level
+	if west := profile.At(270); west > -0.04 || west < -0.05 {
+		t.Errorf("expected the sea horizon about 0.04° down in the west, got %.3f°", west)
+	}
+}
+
+func TestSkylineTimes(t *testing.T) {
+	const lat, lng = 55.6761, 12.5683
+	day := GetSunTimes(lat, lng, time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC))
+
+	rise, set := flatProfile(0).SkylineTimes(lat, lng, day.Times.SolarNoon)
+	if d := rise.Sub(day.Sunrise.Time).Abs(); d > time.Minute {
+		t.Errorf("expected sunrise over a flat skyline near %v, got %v", day.Sunrise.Time, rise)
+	}
+	if d := set.Sub(day.Sunset.Time).Abs(); d > time.Minute {
+		t.Errorf("expected sunset over a flat skyline near %v, got %v", day.Sunset.Time, set)
+	}
+
+	// The equinox sun rises at a slant, about 0.14° a minute, so 5° of
+	// skyline delays sunrise by over half an hour
+	rise, set = flatProfile(5).SkylineTimes(lat, lng, day.Times.SolarNoon)
+	if d := rise.Sub(day.Sunrise.Time); d < 30*time.Minute || d > 50*time.Minute {
+		t.Errorf("expected sunrise over 5° of skyline 30-50 minutes later, got %v", d)
+	}
+	if d := day.Sunset.Time.Sub(set); d < 30*time.Minute || d > 50*time.Minute {
+		t.Errorf("expected sunset behind 5° of skyline 30-50 minutes earlier, got %v", d)
+	}
+}
+
+func TestApplyHorizonProfile(t *testing.T) {
+	const lat, lng = 55.6761, 12.5683
+	days := GetSunTimesRange(lat, lng, time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC), 1)
+	flatSunrise := days[0].Sunrise.Time
+
+	ApplyHorizonProfile(days, flatProfile(3))
+	sunrise := days[0].Sunrise
+	if sunrise == nil || !sunrise.Time.After(flatSunrise) || days[0].Times.Sunrise != sunrise.Time {
+		t.Fatalf("expected a later sunrise over the skyline, got %+v", sunrise)
+	}
+	if sunrise.Skyline != 3 || !sunrise.FlatTime.Equal(flatSunrise) {
+		t.Errorf("expected the skyline and flat-horizon time, got %+v", sunrise)
+	}
+
+	// The midwinter sun stays below 11° in Copenhagen
+	ApplyHorizonProfile(days, flatProfile(15))
+	if days[0].Sunrise != nil || days[0].Sunset != nil {
+		t.Errorf("expected no sunrise or sunset behind a high skyline, got %+v %+v", days[0].Sunrise, days[0].Sunset)
+	}
+	if days[0].PolarDay() {
+		t.Error("expected a day hidden behind the skyline not to be a polar day")
+	}
+	if length, ok := days[0].DayLength(); !ok || length != 0 {
+		t.Errorf("expected no daylight, got %v", length)
+	}
+}
+
+func TestHorizonProfiles_Cached(t *testing.T) {
+	terrain := &terrainFunc{elevation: func(GeoPoint) float64 { return 0 }}
+	profiles := NewHorizonProfiles(terrain)
+	for range 2 {
+		if _, err := profiles.Profile(context.Background(), 55.6761, 12.5683); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if terrain.calls != 1 {
+		t.Errorf("expected a cached profile, got %d lookups", terrain.calls)
+	}
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// mapURL is the map coordinates in HTML descriptions link to
+const mapURL = "https://www.openstreetmap.org/"
+
+// BuildHTMLDescription returns the event's description as HTML, for clients
+// that show X-ALT-DESC (Outlook, Apple Calendar): "Label: value" lines become
+// rows of a small table, other lines span both columns, and blank lines start
+// a new table. Coordinates link to a map. Returns "" for an event without a
+// description.
+func BuildHTMLDescription(event CalendarEvent) string {
+	if event.Description == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html><html><body>")
+	for _, section := range strings.Split(event.Description, "\n\n") {
+		b.WriteString("<table>")
+		for _, line := range strings.Split(section, "\n") {
+			label, value, ok := strings.Cut(line, ": ")
+			if !ok {
+				b.WriteString(`<tr><td colspan="2">` + html.EscapeString(line) + "</td></tr>")
+				continue
+			}
+			b.WriteString("<tr><th align=\"left\">" + html.EscapeString(label) + "</th><td>" + htmlDescriptionValue(label, value) + "</td></tr>")
+		}
+		b.WriteString("</table>")
+	}
+	b.WriteString("</body></html>")
+	return b.String()
+}
+
+// htmlDescriptionValue returns a description line's value as HTML, linking
+// coordinates to a map
+func htmlDescriptionValue(label, value string) string {
+	escaped := html.EscapeString(value)
+	lat, lng, ok := strings.Cut(value, ", ")
+	if label != "Coordinates" || !ok || !isNumber(lat) || !isNumber(lng) {
+		return escaped
+	}
+	link := mapURL + "?" + url.Values{"mlat": {lat}, "mlon": {lng}}.Encode() + "#map=12/" + url.PathEscape(lat) + "/" + url.PathEscape(lng)
+	return `<a href="` + html.EscapeString(link) + `">` + escaped + "</a>"
+}
+
+// isNumber reports whether s is a decimal number
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildHTMLDescription(t *testing.T) {
+	event := CalendarEvent{Description: "Time: 06:42:13\nLocation: Home <Ørsted & Co>\nCoordinates: 55.6761, 12.5683\n\nDay length: 11h 35m\nToday is the summer solstice!"}
+	got := BuildHTMLDescription(event)
+
+	for _, want := range []string{
+		"<!DOCTYPE html><html><body><table>",
+		`<tr><th align="left">Time</th><td>06:42:13</td></tr>`,
+		`<td>Home &lt;Ørsted &amp; Co&gt;</td>`,
+		`<a href="https://www.openstreetmap.org/?mlat=55.6761&amp;mlon=12.5683#map=12/55.6761/12.5683">55.6761, 12.5683</a>`,
+		"</table><table>",
+		`<tr><td colspan="2">Today is the summer solstice!</td></tr>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "<Ørsted") {
+		t.Error("expected the location to be escaped")
+	}
+	if got := BuildHTMLDescription(CalendarEvent{}); got != "" {
+		t.Errorf("expected no HTML without a description, got %q", got)
+	}
+}
+
+func TestHTMLDescriptionValue(t *testing.T) {
+	tests := []struct {
+		label, value, want string
+	}{
+		{"Coordinates", "55.68, 12.57", `<a href="https://www.openstreetmap.org/?mlat=55.68&amp;mlon=12.57#map=12/55.68/12.57">55.68, 12.57</a>`},
+		{"Coordinates", "<b>, x", "&lt;b&gt;, x"},
+		{"Location", "55.68, 12.57", "55.68, 12.57"},
+		{"Azimuth", "94° \"E\"", "94° &#34;E&#34;"},
+	}
+	for _, tt := range tests {
+		if got := htmlDescriptionValue(tt.label, tt.value); got != tt.want {
+			t.Errorf("htmlDescriptionValue(%q, %q) = %q, want %q", tt.label, tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sunMeasurement is the InfluxDB measurement sun metrics are written to
+const sunMeasurement = "sun"
+
+// LinePoint is a point in InfluxDB line protocol. Field values may be
+// float64, int, int64, bool, or string.
+type LinePoint struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]any
+	Time        time.Time
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(`,`, `\,`, ` `, `\ `)
+	keyEscaper         = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `)
+	stringFieldEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+)
+
+// String encodes the point as a line of line protocol (without the trailing
+// newline), with tags and fields sorted by key and a nanosecond timestamp
+func (p LinePoint) String() string {
+	var b strings.Builder
+	b.WriteString(measurementEscaper.Replace(p.Measurement))
+
+	for _, key := range sortedKeys(p.Tags) {
+		if p.Tags[key] == "" {
+			continue // Empty tag values are not allowed
+		}
+		b.WriteByte(',')
+		b.WriteString(keyEscaper.Replace(key))
+		b.WriteByte('=')
+		b.WriteString(keyEscaper.Replace(p.Tags[key]))
+	}
+
+	for i, key := range sortedKeys(p.Fields) {
+		if i == 0 {
+			b.WriteByte(' ')
+		} else {
+			b.WriteByte(',')
+		}
+		b.WriteString(keyEscaper.Replace(key))
+		b.WriteByte('=')
+		b.WriteString(formatFieldValue(p.Fields[key]))
+	}
+
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatInt(p.Time.UnixNano(), 10))
+	return b.String()
+}
+
+// formatFieldValue encodes a field value with its line protocol type suffix
+func formatFieldValue(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v) + "i"
+	case int64:
+		return strconv.FormatInt(v, 10) + "i"
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return `"` + stringFieldEscaper.Replace(fmt.Sprint(v)) + `"`
+	}
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteLineProtocol writes the points to w, one per line
+func WriteLineProtocol(w io.Writer, points []LinePoint) error {
+	for _, p := range points {
+		if _, err := io.WriteString(w, p.String()+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SunMetricPoints returns one point per day, timestamped at local midnight,
+// for days days starting with the day containing from. Each point has the
+// day length in seconds, sunrise and sunset as Unix seconds (left out if they
+// don't occur), and the sun's altitude at solar noon in degrees.
+func SunMetricPoints(lat, lng float64, name string, from time.Time, days int) []LinePoint {
+	tz := GetTimezone(lat, lng)
+	start := from.In(tz)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, tz)
+	tags := map[string]string{
+		"location": locationLabel(name, lat, lng),
+		"lat":      strconv.FormatFloat(lat, 'f', 4, 64),
+		"lng":      strconv.FormatFloat(lng, 'f', 4, 64),
+	}
+
+	points := make([]LinePoint, 0, days)
+	for i := 0; i < days; i, day = i+1, day.AddDate(0, 0, 1) {
+		times := GetDayTimes(lat, lng, day.Add(12*time.Hour))
+		_, noonAltitude := SunPosition(lat, lng, times.SolarNoon)
+
+		fields := map[string]any{
+			"solar_noon_altitude": math.Round(noonAltitude*100) / 100,
+		}
+		switch {
+		case !times.Sunrise.IsZero() && !times.Sunset.IsZero():
+			fields["day_length_seconds"] = int64(times.Sunset.Sub(times.Sunrise).Seconds())
+		case times.Sunrise.IsZero() && times.Sunset.IsZero():
+			// Polar day or night
+			if noonAltitude > 0 {
+				fields["day_length_seconds"] = int64(24 * 60 * 60)
+			} else {
+				fields["day_length_seconds"] = int64(0)
+			}
+		}
+		if !times.Sunrise.IsZero() {
+			fields["sunrise"] = times.Sunrise.Unix()
+		}
+		if !times.Sunset.IsZero() {
+			fields["sunset"] = times.Sunset.Unix()
+		}
+
+		points = append(points, LinePoint{Measurement: sunMeasurement, Tags: tags, Fields: fields, Time: day})
+	}
+	return points
+}
+
+// InfluxLocation is a location whose sun metrics are pushed to InfluxDB
+type InfluxLocation struct {
+	Lat  float64
+	Lng  float64
+	Name string
+}
+
+// InfluxPusher periodically writes sun metrics to an InfluxDB write endpoint
+// (v2 /api/v2/write or v1 /write). Today's and tomorrow's points are written
+// on every push; rewriting a point with the same timestamp and tags replaces
+// it, so pushes are idempotent.
+type InfluxPusher struct {
+	url       string
+	token     string
+	locations []InfluxLocation
+	client    *http.Client
+	now       Clock
+}
+
+// NewInfluxPusher creates a pusher writing to url, authenticating with token
+// (if set) as "Authorization: Token <token>"
+func NewInfluxPusher(url, token string, locations []InfluxLocation) *InfluxPusher {
+	return &InfluxPusher{
+		url:       url,
+		token:     token,
+		locations: locations,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		now:       time.Now,
+	}
+}
+
+// Tick pushes the metrics, logging failures
+func (p *InfluxPusher) Tick(ctx context.Context) {
+	if err := p.Push(ctx); err != nil {
+		log.Printf("influx push: %v", err)
+	}
+}
+
+// Push writes today's and tomorrow's metrics for every location
+func (p *InfluxPusher) Push(ctx context.Context) error {
+	var body strings.Builder
+	for _, loc := range p.locations {
+		WriteLineProtocol(&body, SunMetricPoints(loc.Lat, loc.Lng, loc.Name, p.now(), 2))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.url, strings.NewReader(body.String()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Token "+p.token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
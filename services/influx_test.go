@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLinePoint_String(t *testing.T) {
+	p := LinePoint{
+		Measurement: "sun metrics",
+		Tags:        map[string]string{"location": "Sankt Peter, Ording", "empty": "", "a=b": "c"},
+		Fields: map[string]any{
+			"day_length_seconds": int64(40000),
+			"altitude":           12.5,
+			"polar":              false,
+			"note":               `say "hi" \o/`,
+		},
+		Time: time.Unix(1700000000, 5),
+	}
+
+	want := `sun\ metrics,a\=b=c,location=Sankt\ Peter\,\ Ording altitude=12.5,day_length_seconds=40000i,note="say \"hi\" \\o/",polar=false 1700000000000000005`
+	if got := p.String(); got != want {
+		t.Errorf("unexpected line:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestSunMetricPoints(t *testing.T) {
+	from := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+	points := SunMetricPoints(55.6761, 12.5683, "Copenhagen", from, 2)
+	if len(points) != 2 {
+		t.Fatalf("expected 2 points, got %d", len(points))
+	}
+
+	p := points[0]
+	if p.Time.In(GetTimezone(55.6761, 12.5683)).Format("2006-01-02 15:04") != "2024-03-10 00:00" {
+		t.Errorf("expected local midnight, got %s", p.Time)
+	}
+	if p.Tags["location"] != "Copenhagen" || p.Tags["lat"] != "55.6761" {
+		t.Errorf("unexpected tags: %v", p.Tags)
+	}
+
+	sunrise, sunset := p.Fields["sunrise"].(int64), p.Fields["sunset"].(int64)
+	if p.Fields["day_length_seconds"] != sunset-sunrise {
+		t.Errorf("day length %v does not match sunrise %d and sunset %d", p.Fields["day_length_seconds"], sunrise, sunset)
+	}
+	if altitude := p.Fields["solar_noon_altitude"].(float64); altitude < 28 || altitude > 32 {
+		t.Errorf("unexpected solar noon altitude %.2f", altitude)
+	}
+	if points[1].Time.Sub(p.Time) != 24*time.Hour {
+		t.Errorf("expected consecutive days, got %s and %s", p.Time, points[1].Time)
+	}
+}
+
+func TestSunMetricPoints_PolarNight(t *testing.T) {
+	points := SunMetricPoints(78.2232, 15.6267, "", time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC), 1)
+
+	fields := points[0].Fields
+	if _, ok := fields["sunrise"]; ok {
+		t.Error("expected no sunrise during polar night")
+	}
+	if fields["day_length_seconds"] != int64(0) {
+		t.Errorf("expected zero day length, got %v", fields["day_length_seconds"])
+	}
+	if points[0].Tags["location"] != "78.2232, 15.6267" {
+		t.Errorf("expected coordinates as location, got %s", points[0].Tags["location"])
+	}
+}
+
+func TestInfluxPusher_Push(t *testing.T) {
+	var auth, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	p := NewInfluxPusher(server.URL+"/api/v2/write?org=acme&bucket=sun", "secret", []InfluxLocation{
+		{Lat: 55.6761, Lng: 12.5683, Name: "Copenhagen"},
+		{Lat: -33.8688, Lng: 151.2093, Name: "Sydney"},
+	})
+	if err := p.Push(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if auth != "Token secret" {
+		t.Errorf("expected token auth, got %q", auth)
+	}
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines (2 days for 2 locations), got %d:\n%s", len(lines), body)
+	}
+	if !strings.HasPrefix(lines[0], "sun,lat=55.6761,lng=12.5683,location=Copenhagen ") || !strings.HasPrefix(lines[2], "sun,lat=-33.8688,lng=151.2093,location=Sydney ") {
+		t.Errorf("unexpected lines:\n%s", body)
+	}
+}
+
+func TestInfluxPusher_PushError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"code":"unauthorized"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	p := NewInfluxPusher(server.URL, "", []InfluxLocation{{Lat: 55.6761, Lng: 12.5683}})
+	err := p.Push(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("expected unauthorized error, got %v", err)
+	}
+}
+
+func BenchmarkSunMetricPoints(b *testing.B) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < b.N; i++ {
+		SunMetricPoints(55.6761, 12.5683, "Copenhagen", from, 365)
+	}
+}
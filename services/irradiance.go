@@ -0,0 +1,110 @@
+package services
+
+import (
+	"math"
+	"time"
+)
+
+// solarConstant is the mean extraterrestrial irradiance, in W/m²
+const solarConstant = 1367.0
+
+// groundAlbedo is the fraction of irradiance the ground reflects onto a
+// tilted panel (grass and typical surroundings)
+const groundAlbedo = 0.2
+
+// irradianceStep is the interval daily insolation is integrated over
+const irradianceStep = 5 * time.Minute
+
+// ClearSky is the irradiance under a cloudless sky, in W/m²
+type ClearSky struct {
+	GHI float64 // Global horizontal irradiance
+	DNI float64 // Direct normal irradiance
+	DHI float64 // Diffuse horizontal irradiance
+}
+
+// ClearSkyIrradiance estimates the irradiance at sea level with the sun at
+// elevation (degrees) on t's day of the year, using the simplified Ineichen
+// and Perez model with a Linke turbidity of turbidity (about 2 for very
+// clean air, 3 to 4 for typical mid-latitude air, 5 and up for haze). The
+// sun below the horizon gives zero.
+func ClearSkyIrradiance(elevation float64, t time.Time, turbidity float64) ClearSky {
+	if elevation <= 0 {
+		return ClearSky{}
+	}
+	zenith := 90 - elevation
+	cosZenith := math.Cos(degToRad(zenith))
+	// Kasten and Young's relative air mass
+	airMass := 1 / (cosZenith + 0.50572*math.Pow(96.07995-zenith, -1.6364))
+	extraterrestrial := solarConstant * (1 + 0.033*math.Cos(2*math.Pi*float64(t.YearDay())/365))
+
+	// Sea level coefficients (cg1 = 0.868, cg2 = 0.0387, fh1 = fh2 = 1)
+	ghi := 0.868 * extraterrestrial * cosZenith * math.Exp(-0.0387*airMass*turbidity) * math.Exp(0.01*math.Pow(airMass, 1.8))
+	dni := 0.827 * extraterrestrial * math.Exp(-0.09*airMass*(turbidity-1))
+	// The beam cannot exceed the global irradiance it is part of
+	dni = math.Min(dni, ghi/cosZenith)
+	return ClearSky{GHI: ghi, DNI: dni, DHI: ghi - dni*cosZenith}
+}
+
+// PanelOrientation is the direction a flat panel faces
+type PanelOrientation struct {
+	Tilt    float64 // Degrees from horizontal (0 = flat, 90 = vertical)
+	Azimuth float64 // Direction the panel faces, in degrees clockwise from north
+}
+
+// Irradiance returns the irradiance on the panel (plane of array) in W/m²
+// for the sky and the sun's position: the beam at its angle of incidence,
+// isotropic sky diffuse, and ground-reflected light
+func (p PanelOrientation) Irradiance(sky ClearSky, azimuth, elevation float64) float64 {
+	if sky.GHI == 0 {
+		return 0
+	}
+	tilt, zenith := degToRad(p.Tilt), degToRad(90-elevation)
+	cosIncidence := math.Cos(zenith)*math.Cos(tilt) + math.Sin(zenith)*math.Sin(tilt)*math.Cos(degToRad(azimuth-p.Azimuth))
+	beam := sky.DNI * math.Max(0, cosIncidence)
+	diffuse := sky.DHI * (1 + math.Cos(tilt)) / 2
+	reflected := sky.GHI * groundAlbedo * (1 - math.Cos(tilt)) / 2
+	return beam + diffuse + reflected
+}
+
+// IrradianceSample is the clear-sky irradiance at a moment
+type IrradianceSample struct {
+	Time      time.Time
+	Elevation float64 // Sun elevation, in degrees
+	ClearSky
+	POA float64 // On the panel, in W/m²
+}
+
+// IrradianceDay is a local day of clear-sky irradiance on a panel
+type IrradianceDay struct {
+	Date    time.Time          // Local midnight
+	Samples []IrradianceSample // Every interval from midnight
+	POA     float64            // Insolation on the panel, in kWh/m² (equal to peak sun hours)
+	GHI     float64            // Insolation on a horizontal surface, in kWh/m²
+	Peak    IrradianceSample   // Highest irradiance on the panel, to irradianceStep
+}
+
+// ClearSkyDay estimates the clear-sky irradiance on the panel through the
+// local day of date in tz, sampled every interval, with the day's insolation
+// integrated over 5-minute steps
+func ClearSkyDay(lat, lng float64, panel PanelOrientation, turbidity float64, date time.Time, tz *time.Location, interval time.Duration) IrradianceDay {
+	sample := func(s SunSample) IrradianceSample {
+		sky := ClearSkyIrradiance(s.Elevation, s.Time, turbidity)
+		return IrradianceSample{Time: s.Time, Elevation: s.Elevation, ClearSky: sky, POA: panel.Irradiance(sky, s.Azimuth, s.Elevation)}
+	}
+
+	positions := DaySunSamples(lat, lng, date, tz, irradianceStep)
+	day := IrradianceDay{Date: positions[0].Time}
+	hours := irradianceStep.Hours()
+	for _, s := range positions {
+		irradiance := sample(s)
+		day.POA += irradiance.POA * hours / 1000
+		day.GHI += irradiance.GHI * hours / 1000
+		if irradiance.POA > day.Peak.POA {
+			day.Peak = irradiance
+		}
+	}
+	for _, s := range DaySunSamples(lat, lng, date, tz, interval) {
+		day.Samples = append(day.Samples, sample(s))
+	}
+	return day
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestClearSkyIrradiance(t *testing.T) {
+	june := time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC)
+
+	// The sun 60° up in typical air: about 880 W/m² global, mostly beam
+	sky := ClearSkyIrradiance(60, june, 3)
+	if sky.GHI < 850 || sky.GHI > 910 || sky.DNI < 850 || sky.DNI > 930 {
+		t.Errorf("expected about 880 W/m² global and 890 W/m² direct, got %+v", sky)
+	}
+	if closure := sky.DNI*math.Sin(degToRad(60)) + sky.DHI; math.Abs(closure-sky.GHI) > 0.01 {
+		t.Errorf("expected beam and diffuse to add up to global, got %.2f and %.2f", closure, sky.GHI)
+	}
+	// Hazier air lets less through, and more of it is diffuse
+	hazy := ClearSkyIrradiance(60, june, 6)
+	if hazy.GHI >= sky.GHI || hazy.DNI >= sky.DNI || hazy.DHI <= sky.DHI {
+		t.Errorf("expected less and more diffuse light in haze, got %+v", hazy)
+	}
+	if low := ClearSkyIrradiance(5, june, 3); low.GHI <= 0 || low.GHI >= 100 {
+		t.Errorf("expected weak light with the sun low, got %+v", low)
+	}
+	if night := ClearSkyIrradiance(-5, june, 3); night != (ClearSky{}) {
+		t.Errorf("expected no light at night, got %+v", night)
+	}
+}
+
+func TestPanelOrientationIrradiance(t *testing.T) {
+	sky := ClearSky{GHI: 800, DNI: 850, DHI: 100}
+	flat := PanelOrientation{Tilt: 0, Azimuth: 180}
+	if got := flat.Irradiance(sky, 180, 60); math.Abs(got-(850*math.Sin(degToRad(60))+100)) > 0.01 {
+		t.Errorf("expected a flat panel to get the global irradiance, got %.2f", got)
+	}
+	// Facing the sun head on, a panel gets the whole beam
+	facing := PanelOrientation{Tilt: 30, Azimuth: 180}
+	if got := facing.Irradiance(sky, 180, 60); got < 850 {
+		t.Errorf("expected the full beam, got %.2f", got)
+	}
+	// With the sun behind it, only diffuse and reflected light
+	away := PanelOrientation{Tilt: 90, Azimuth: 0}
+	if got := away.Irradiance(sky, 180, 30); got != 100*0.5+800*groundAlbedo*0.5 {
+		t.Errorf("expected diffuse and reflected light only, got %.2f", got)
+	}
+}
+
+func TestClearSkyDay(t *testing.T) {
+	tz := GetTimezone(55.6761, 12.5683)
+	june := time.Date(2024, 6, 21, 12, 0, 0, 0, tz)
+	december := time.Date(2024, 12, 21, 12, 0, 0, 0, tz)
+
+	south := PanelOrientation{Tilt: 35, Azimuth: 180}
+	day := ClearSkyDay(55.6761, 12.5683, south, 3, june, tz, time.Hour)
+	if len(day.Samples) != 24 || day.Samples[0].Time.In(tz).Hour() != 0 || day.Samples[0].POA != 0 {
+		t.Errorf("expected 24 hourly samples from a dark midnight, got %d", len(day.Samples))
+	}
+	if day.POA < 8 || day.POA > 9 || day.GHI < 8 || day.GHI > 9 {
+		t.Errorf("expected about 8.5 kWh/m² at midsummer, got %.2f on the panel and %.2f flat", day.POA, day.GHI)
+	}
+	// The peak is around solar noon, 13:10 in summer time
+	if peak := day.Peak.Time.In(tz); peak.Hour() != 13 || day.Peak.POA < 950 {
+		t.Errorf("expected a peak of about 1000 W/m² around 13:10, got %.0f at %s", day.Peak.POA, peak)
+	}
+
+	// In winter tilting towards the low sun more than doubles the insolation
+	winter := ClearSkyDay(55.6761, 12.5683, south, 3, december, tz, time.Hour)
+	if winter.POA < 2*winter.GHI {
+		t.Errorf("expected the tilted panel to more than double a flat one in winter, got %.2f and %.2f", winter.POA, winter.GHI)
+	}
+	// ... and a north-facing panel gets the least
+	north := ClearSkyDay(55.6761, 12.5683, PanelOrientation{Tilt: 35, Azimuth: 0}, 3, december, tz, time.Hour)
+	if north.POA >= winter.GHI {
+		t.Errorf("expected a north-facing panel to get less than a flat one, got %.2f", north.POA)
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale formats numbers and dates in event descriptions and summaries. It
+// only changes how they are written: labels and month names stay English,
+// and coordinates keep a decimal point so they still work in map links. The
+// zero value formats as DefaultLocale.
+type Locale struct {
+	Tag      string // BCP 47 tag (e.g., "de")
+	Decimal  string // Decimal separator; "" is "."
+	Group    string // Thousands separator; "" is ","
+	DayFirst bool   // "21 June" rather than "June 21"
+}
+
+// DefaultLocale formats as US English, the output before locales existed
+var DefaultLocale = Locale{Tag: "en-US"}
+
+// locales are the supported locales, in the order they are listed
+var locales = []Locale{
+	DefaultLocale,
+	{Tag: "en-GB", DayFirst: true},
+	{Tag: "en-AU", DayFirst: true},
+	{Tag: "en-IE", DayFirst: true},
+	{Tag: "en-IN", DayFirst: true},
+	{Tag: "da", Decimal: ",", Group: ".", DayFirst: true},
+	{Tag: "de", Decimal: ",", Group: ".", DayFirst: true},
+	{Tag: "es", Decimal: ",", Group: ".", DayFirst: true},
+	{Tag: "fi", Decimal: ",", Group: "\u00a0", DayFirst: true},
+	{Tag: "fr", Decimal: ",", Group: "\u00a0", DayFirst: true},
+	{Tag: "it", Decimal: ",", Group: ".", DayFirst: true},
+	{Tag: "nb", Decimal: ",", Group: "\u00a0", DayFirst: true},
+	{Tag: "nl", Decimal: ",", Group: ".", DayFirst: true},
+	{Tag: "pl", Decimal: ",", Group: "\u00a0", DayFirst: true},
+	{Tag: "pt", Decimal: ",", Group: "\u00a0", DayFirst: true},
+	{Tag: "pt-BR", Decimal: ",", Group: ".", DayFirst: true},
+	{Tag: "sv", Decimal: ",", Group: "\u00a0", DayFirst: true},
+}
+
+// LocaleTags returns the tags of the supported locales
+func LocaleTags() []string {
+	tags := make([]string, len(locales))
+	for i, l := range locales {
+		tags[i] = l.Tag
+	}
+	return tags
+}
+
+// LookupLocale returns the supported locale with the tag, ignoring case
+func LookupLocale(tag string) (Locale, bool) {
+	for _, l := range locales {
+		if strings.EqualFold(l.Tag, tag) {
+			return l, true
+		}
+	}
+	return Locale{}, false
+}
+
+// Number formats v with decimals digits after the decimal separator
+func (l Locale) Number(v float64, decimals int) string {
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	if l.Decimal != "" {
+		s = strings.Replace(s, ".", l.Decimal, 1)
+	}
+	return s
+}
+
+// Degrees formats an angle (e.g., "94.4°" or "94,4°")
+func (l Locale) Degrees(v float64, decimals int) string {
+	return l.Number(v, decimals) + "°"
+}
+
+// Integer formats v rounded to a whole number, with thousands separators
+// (e.g., "357,175" or "357.175")
+func (l Locale) Integer(v float64) string {
+	group := l.Group
+	if group == "" {
+		group = ","
+	}
+	digits := fmt.Sprintf("%.0f", v)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(group)
+		}
+		b.WriteRune(digit)
+	}
+	return b.String()
+}
+
+// Kilometres formats a distance rounded to whole kilometres (e.g.,
+// "357,175 km")
+func (l Locale) Kilometres(km float64) string {
+	return l.Integer(km) + " km"
+}
+
+// MonthDay formats a date without the year (e.g., "June 21" or "21 June")
+func (l Locale) MonthDay(t time.Time) string {
+	if l.DayFirst {
+		return t.Format("2 January")
+	}
+	return t.Format("January 2")
+}
+
+// WeekdayDate formats a date with its weekday (e.g., "Saturday, June 21"
+// or "Saturday 21 June")
+func (l Locale) WeekdayDate(t time.Time) string {
+	if l.DayFirst {
+		return t.Format("Monday 2 January")
+	}
+	return t.Format("Monday, January 2")
+}
+
+// LongDate formats a date with its weekday and year (e.g., "Saturday, June
+// 21, 2025" or "Saturday 21 June 2025")
+func (l Locale) LongDate(t time.Time) string {
+	if l.DayFirst {
+		return t.Format("Monday 2 January 2006")
+	}
+	return t.Format("Monday, January 2, 2006")
+}
+
+// Azimuth formats an azimuth as FormatAzimuth does, with the locale's
+// decimal separator
+func (l Locale) Azimuth(azimuth float64, format string) string {
+	switch format {
+	case AzimuthCompass:
+		return CompassPoint(azimuth)
+	case AzimuthBoth:
+		return fmt.Sprintf("%s (%s)", CompassPoint(azimuth), l.Degrees(azimuth, 1))
+	default:
+		return l.Degrees(azimuth, 1)
+	}
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLocaleNumbers(t *testing.T) {
+	de, _ := LookupLocale("de")
+	fr, _ := LookupLocale("FR")
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"default number", DefaultLocale.Number(94.44, 1), "94.4"},
+		{"zero value number", Locale{}.Number(94.44, 1), "94.4"},
+		{"decimal comma", de.Number(94.44, 1), "94,4"},
+		{"whole number", de.Number(94.44, 0), "94"},
+		{"degrees", de.Degrees(-0.833, 2), "-0,83°"},
+		{"azimuth", de.Azimuth(67.5, AzimuthBoth), "ENE (67,5°)"},
+		{"compass azimuth", de.Azimuth(67.5, AzimuthCompass), "ENE"},
+		{"default kilometres", DefaultLocale.Kilometres(357175.4), "357,175 km"},
+		{"grouped kilometres", de.Kilometres(405504), "405.504 km"},
+		{"spaced kilometres", fr.Kilometres(1234567), "1\u00a0234\u00a0567 km"},
+		{"negative integer", DefaultLocale.Integer(-1234), "-1,234"},
+		{"short integer", de.Integer(999), "999"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLocaleDates(t *testing.T) {
+	date := time.Date(2025, 6, 21, 0, 0, 0, 0, time.UTC)
+	gb, _ := LookupLocale("en-GB")
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"month day", DefaultLocale.MonthDay(date), "June 21"},
+		{"day first", gb.MonthDay(date), "21 June"},
+		{"weekday date", DefaultLocale.WeekdayDate(date), "Saturday, June 21"},
+		{"day-first weekday date", gb.WeekdayDate(date), "Saturday 21 June"},
+		{"long date", Locale{}.LongDate(date), "Saturday, June 21, 2025"},
+		{"day-first long date", gb.LongDate(date), "Saturday 21 June 2025"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLookupLocale(t *testing.T) {
+	if l, ok := LookupLocale("pt-br"); !ok || l.Tag != "pt-BR" {
+		t.Errorf("expected pt-BR regardless of case, got %+v", l)
+	}
+	if _, ok := LookupLocale("tlh"); ok {
+		t.Error("expected an unsupported locale not found")
+	}
+	if tags := LocaleTags(); tags[0] != DefaultLocale.Tag || !slices.Contains(tags, "sv") {
+		t.Errorf("expected the default locale first, got %v", tags)
+	}
+}
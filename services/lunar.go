@@ -0,0 +1,146 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sixdouglas/suncalc"
+)
+
+// Moon calendar event types (see BuildMoonEvents)
+const (
+	MoonEventRise     = "rise"
+	MoonEventSet      = "set"
+	MoonEventPhases   = "phases"
+	MoonEventApsides  = "apsides"
+	MoonEventEclipses = "eclipses"
+)
+
+// MoonEventTypes lists the moon calendar event types
+var MoonEventTypes = []string{MoonEventRise, MoonEventSet, MoonEventPhases, MoonEventApsides, MoonEventEclipses}
+
+// BuildMoonEvents generates the moon calendar for the local days starting at
+// start: moonrise and moonset, all-day events for the principal phases and
+// apsides, and lunar eclipses, of the given types. Events are in order.
+func BuildMoonEvents(opts CalendarOptions, types []string, start time.Time, days int) []CalendarEvent {
+	local := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, opts.Timezone)
+	end := local.AddDate(0, 0, days)
+
+	var events []CalendarEvent
+	if slices.Contains(types, MoonEventRise) || slices.Contains(types, MoonEventSet) {
+		for _, day := range GetMoonDaysRange(opts.Lat, opts.Lng, start, days, opts.Timezone) {
+			if slices.Contains(types, MoonEventRise) && !day.Rise.IsZero() {
+				events = append(events, newMoonriseEvent("moonrise", "Moonrise", day.Rise, opts))
+			}
+			if slices.Contains(types, MoonEventSet) && !day.Set.IsZero() {
+				events = append(events, newMoonriseEvent("moonset", "Moonset", day.Set, opts))
+			}
+		}
+	}
+	if slices.Contains(types, MoonEventPhases) {
+		for _, phase := range MoonPhases(local, end) {
+			events = append(events, newLunarPhaseEvent(phase, opts))
+		}
+	}
+	if slices.Contains(types, MoonEventApsides) {
+		events = append(events, BuildMoonApsisEvents(opts, ApsisTypes, start, days)...)
+	}
+	if slices.Contains(types, MoonEventEclipses) {
+		for _, eclipse := range LunarEclipses(local, end) {
+			events = append(events, newLunarEclipseEvent(eclipse, opts))
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+	return events
+}
+
+// newMoonriseEvent creates a 1-minute moonrise or moonset event
+func newMoonriseEvent(eventType, title string, t time.Time, opts CalendarOptions) CalendarEvent {
+	azimuth := radToDeg(suncalc.GetMoonPosition(t, opts.Lat, opts.Lng).Azimuth) + 180
+	phase := GetMoonPhase(t)
+
+	lines := []string{
+		fmt.Sprintf("Time: %s", opts.Clock(t, "15:04")),
+		fmt.Sprintf("Location: %s", opts.Location),
+		"Coordinates: " + FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+		fmt.Sprintf("Bearing: %s (%.0f°)", CompassPoint(azimuth), azimuth),
+		"",
+		fmt.Sprintf("Moon: %s (%d%% illuminated)", phase.Name, int(phase.Illumination*100+0.5)),
+	}
+
+	return CalendarEvent{
+		UID:         locationUID(t, opts.Lat, opts.Lng, opts.Precision, "moon-"+eventType), // Distinct from the nautical preset's
+		Type:        eventType,
+		Start:       t,
+		End:         t.Add(time.Minute),
+		Summary:     fmt.Sprintf("%s %s %s", title, opts.Clock(t, "15:04"), CompassPoint(azimuth)),
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+	}
+}
+
+// newLunarPhaseEvent creates an all-day event on the local date of a
+// principal phase (e.g., "Full moon")
+func newLunarPhaseEvent(phase LunarPhase, opts CalendarOptions) CalendarEvent {
+	local := phase.Time.In(opts.Timezone)
+	date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, opts.Timezone)
+	eventType := "moon_" + strings.ReplaceAll(strings.ToLower(phase.Name()), " ", "_")
+
+	lines := []string{
+		fmt.Sprintf("%s: %s", phase.Name(), opts.Clock(phase.Time, "15:04 MST")),
+		fmt.Sprintf("Location: %s", opts.Location),
+	}
+
+	return CalendarEvent{
+		UID:         locationUID(date, opts.Lat, opts.Lng, opts.Precision, eventType),
+		Type:        eventType,
+		Start:       date,
+		End:         date.AddDate(0, 0, 1),
+		Summary:     phase.Name(),
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+		AllDay:      true,
+	}
+}
+
+// newLunarEclipseEvent creates an event lasting from the start to the end of
+// a lunar eclipse, noting whether the moon is above the horizon at the
+// location at the greatest eclipse
+func newLunarEclipseEvent(eclipse LunarEclipse, opts CalendarOptions) CalendarEvent {
+	tz := opts.Timezone
+	title := fmt.Sprintf("%s lunar eclipse", strings.ToUpper(eclipse.Kind[:1])+eclipse.Kind[1:])
+
+	lines := []string{
+		fmt.Sprintf("Penumbral: %s to %s", eclipse.Start.In(tz).Format("15:04"), eclipse.End.In(tz).Format("15:04")),
+	}
+	if !eclipse.PartialStart.IsZero() {
+		lines = append(lines, fmt.Sprintf("Partial: %s to %s", eclipse.PartialStart.In(tz).Format("15:04"), eclipse.PartialEnd.In(tz).Format("15:04")))
+	}
+	if !eclipse.TotalStart.IsZero() {
+		lines = append(lines, fmt.Sprintf("Total: %s to %s", eclipse.TotalStart.In(tz).Format("15:04"), eclipse.TotalEnd.In(tz).Format("15:04")))
+	}
+	lines = append(lines,
+		fmt.Sprintf("Greatest eclipse: %s (magnitude %s)", opts.Clock(eclipse.Maximum, "15:04 MST"), opts.Locale.Number(eclipse.Magnitude, 2)),
+		fmt.Sprintf("Location: %s", opts.Location),
+		"",
+	)
+	if suncalc.GetMoonPosition(eclipse.Maximum, opts.Lat, opts.Lng).Altitude > 0 {
+		lines = append(lines, "The moon is above the horizon at the greatest eclipse.")
+	} else {
+		lines = append(lines, "The moon is below the horizon at the greatest eclipse, so it may not be visible here.")
+	}
+
+	return CalendarEvent{
+		UID:         locationUID(eclipse.Maximum, opts.Lat, opts.Lng, opts.Precision, "lunar-eclipse"),
+		Type:        "lunar_eclipse",
+		Start:       eclipse.Start,
+		End:         eclipse.End,
+		Summary:     title,
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+	}
+}
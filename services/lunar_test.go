@@ -0,0 +1,56 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildMoonEvents(t *testing.T) {
+	opts := testCalendarOptions()
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	events := BuildMoonEvents(opts, MoonEventTypes, start, 31)
+	counts := map[string]int{}
+	for i, e := range events {
+		counts[e.Type]++
+		if i > 0 && e.Start.Before(events[i-1].Start) {
+			t.Errorf("event %d (%s) out of order", i, e.Summary)
+		}
+	}
+	// A moonrise or moonset skips a day about once a month
+	if counts["moonrise"] < 29 || counts["moonset"] < 29 {
+		t.Errorf("expected a moonrise and moonset on most days, got %v", counts)
+	}
+	for _, phase := range []string{"moon_first_quarter", "moon_full_moon", "moon_last_quarter", "moon_new_moon"} {
+		if counts[phase] != 1 {
+			t.Errorf("expected one %s, got %v", phase, counts)
+		}
+	}
+	if counts["moon_perigee"] != 2 || counts["moon_apogee"] != 1 {
+		t.Errorf("expected the perigees of March 1 and 30 and the apogee of March 17, got %v", counts)
+	}
+
+	// The total lunar eclipse of March 14, 2025 is below the horizon at
+	// Copenhagen at its greatest, at 07:59 CET
+	var eclipse *CalendarEvent
+	for i := range events {
+		if events[i].Type == "lunar_eclipse" {
+			eclipse = &events[i]
+		}
+	}
+	if eclipse == nil {
+		t.Fatal("expected the lunar eclipse of March 14, 2025")
+	}
+	if eclipse.Summary != "Total lunar eclipse" || eclipse.AllDay || eclipse.End.Sub(eclipse.Start) < 5*time.Hour {
+		t.Errorf("unexpected eclipse event %+v", eclipse)
+	}
+	if !strings.Contains(eclipse.Description, "Total: 07:2") || !strings.Contains(eclipse.Description, "below the horizon") {
+		t.Errorf("unexpected eclipse description %q", eclipse.Description)
+	}
+
+	phases := BuildMoonEvents(opts, []string{MoonEventPhases}, start, 31)
+	if len(phases) != 4 || !phases[0].AllDay || phases[0].Summary != "First quarter" {
+		t.Errorf("expected only the four phases, got %+v", phases)
+	}
+}
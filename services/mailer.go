@@ -0,0 +1,116 @@
+package services
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Mailer sends plain-text email
+type Mailer interface {
+	// Send delivers a message. headers holds extra headers (e.g.,
+	// List-Unsubscribe).
+	Send(to, subject, body string, headers map[string]string) error
+}
+
+// SMTPMailer sends email through an SMTP server. Port 465 uses implicit TLS;
+// other ports use STARTTLS when the server offers it.
+type SMTPMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+	now      Clock
+}
+
+// NewSMTPMailer creates a mailer for the given server and sender address.
+// Authentication is skipped when username is empty.
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{host: host, port: port, username: username, password: password, from: from, now: time.Now}
+}
+
+// Send delivers a message through the SMTP server
+func (m *SMTPMailer) Send(to, subject, body string, headers map[string]string) error {
+	from, err := mail.ParseAddress(m.from)
+	if err != nil {
+		return fmt.Errorf("invalid sender: %w", err)
+	}
+	msg := buildMessage(m.from, to, subject, body, headers, m.now())
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	addr := net.JoinHostPort(m.host, m.port)
+	if m.port != "465" {
+		return smtp.SendMail(addr, auth, from.Address, []string{to}, msg)
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", addr, &tls.Config{ServerName: m.host})
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildMessage formats a plain-text RFC 5322 message dated date
+func buildMessage(from, to, subject, body string, headers map[string]string, date time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, headers[key])
+	}
+
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return buf.Bytes()
+}
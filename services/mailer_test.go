@@ -0,0 +1,34 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildMessage(t *testing.T) {
+	msg := string(buildMessage("CalSun <sun@example.com>", "user@example.com", "Sunrise 07:01 ☀", "Line one\nLine two\n", map[string]string{
+		"List-Unsubscribe": "<https://calsun.example/email/unsubscribe?token=abc>",
+	}, time.Date(2024, 6, 21, 7, 1, 0, 0, time.UTC)))
+
+	headers, body, ok := strings.Cut(msg, "\r\n\r\n")
+	if !ok {
+		t.Fatal("expected blank line between headers and body")
+	}
+
+	for _, want := range []string{
+		"From: CalSun <sun@example.com>\r\n",
+		"To: user@example.com\r\n",
+		"Subject: =?utf-8?q?",
+		"Date: Fri, 21 Jun 2024 07:01:00 +0000\r\n",
+		"List-Unsubscribe: <https://calsun.example/email/unsubscribe?token=abc>\r\n",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+	} {
+		if !strings.Contains(headers+"\r\n", want) {
+			t.Errorf("headers missing %q:\n%s", want, headers)
+		}
+	}
+	if body != "Line one\r\nLine two\r\n" {
+		t.Errorf("expected CRLF line endings in body, got %q", body)
+	}
+}
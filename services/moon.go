@@ -0,0 +1,465 @@
+package services
+
+import (
+	"math"
+	"time"
+
+	"github.com/sixdouglas/suncalc"
+)
+
+// moonPhaseNames are the eight conventional phases, starting at new moon
+var moonPhaseNames = []string{
+	"New moon",
+	"Waxing crescent",
+	"First quarter",
+	"Waxing gibbous",
+	"Full moon",
+	"Waning gibbous",
+	"Last quarter",
+	"Waning crescent",
+}
+
+// MoonPhase describes the moon's phase at a point in time
+type MoonPhase struct {
+	Name         string  // e.g., "Waxing gibbous"
+	Phase        float64 // 0 = new moon, 0.25 = first quarter, 0.5 = full moon, 0.75 = last quarter
+	Illumination float64 // Illuminated fraction of the disc, 0 to 1
+}
+
+// GetMoonPhase returns the moon's phase at t
+func GetMoonPhase(t time.Time) MoonPhase {
+	illum := suncalc.GetMoonIllumination(t)
+
+	// Each named phase covers an eighth of the cycle, centred on its phase value
+	index := int(illum.Phase*8+0.5) % len(moonPhaseNames)
+
+	return MoonPhase{
+		Name:         moonPhaseNames[index],
+		Phase:        illum.Phase,
+		Illumination: illum.Fraction,
+	}
+}
+
+// MoonDay holds the moon's rise, set, and meridian transits during a local
+// day. A zero time means the event does not happen that day (a lunar day is
+// about 50 minutes longer than a solar one, so each event skips a day now and
+// then).
+type MoonDay struct {
+	Rise         time.Time
+	Set          time.Time
+	Transit      time.Time // Highest in the sky (upper transit, "moon overhead")
+	UnderTransit time.Time // Lowest below the horizon (lower transit, "moon underfoot")
+}
+
+// moonTransitStep is the sampling interval when searching for transits
+const moonTransitStep = 10 * time.Minute
+
+// GetMoonDay calculates the moon's rise, set, and transits for the local
+// day of date in tz
+func GetMoonDay(lat, lng float64, date time.Time, tz *time.Location) MoonDay {
+	local := date.In(tz)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, tz)
+	end := start.AddDate(0, 0, 1)
+
+	times := suncalc.GetMoonTimesWithObserver(start, suncalc.Observer{Latitude: lat, Longitude: lng, Location: tz})
+	day := MoonDay{Rise: times.Rise, Set: times.Set}
+
+	// Transits are the moon's altitude extremes. Sample a step beyond either
+	// end of the day so extremes right at midnight are found too.
+	altitude := func(t time.Time) float64 {
+		return suncalc.GetMoonPosition(t, lat, lng).Altitude
+	}
+	prev, cur := altitude(start.Add(-moonTransitStep)), altitude(start)
+	for t := start; t.Before(end); t = t.Add(moonTransitStep) {
+		next := altitude(t.Add(moonTransitStep))
+		switch {
+		case cur >= prev && cur > next:
+			if peak := findMoonExtreme(altitude, t.Add(-moonTransitStep), t.Add(moonTransitStep), 1); !peak.Before(start) && peak.Before(end) {
+				day.Transit = peak
+			}
+		case cur <= prev && cur < next:
+			if low := findMoonExtreme(altitude, t.Add(-moonTransitStep), t.Add(moonTransitStep), -1); !low.Before(start) && low.Before(end) {
+				day.UnderTransit = low
+			}
+		}
+		prev, cur = cur, next
+	}
+	return day
+}
+
+// GetMoonDaysRange calculates the moon's rise, set, and transits for a range
+// of local days starting at the day of start, in date order. Long ranges are
+// computed concurrently, like GetSunTimesRange.
+func GetMoonDaysRange(lat, lng float64, start time.Time, days int, tz *time.Location) []MoonDay {
+	compute := func(i int) MoonDay {
+		return GetMoonDay(lat, lng, start.AddDate(0, 0, i), tz)
+	}
+	if days < parallelRangeThreshold {
+		results := make([]MoonDay, days)
+		for i := range results {
+			results[i] = compute(i)
+		}
+		return results
+	}
+	return computeDays(days, compute)
+}
+
+// findMoonExtreme narrows [low, high] down to the time of the maximum (sign
+// 1) or minimum (sign -1) of value, to within a second
+func findMoonExtreme(value func(time.Time) float64, low, high time.Time, sign float64) time.Time {
+	for high.Sub(low) > time.Second {
+		third := high.Sub(low) / 3
+		a, b := low.Add(third), high.Add(-third)
+		if sign*value(a) < sign*value(b) {
+			low = a
+		} else {
+			high = b
+		}
+	}
+	return low.Add(high.Sub(low) / 2).Round(time.Second)
+}
+
+// synodicMonth is the average time between two full moons, in days
+const synodicMonth = 29.530588861
+
+// Principal phases of the moon, as quarters of a lunation
+const (
+	QuarterNew = iota
+	QuarterFirst
+	QuarterFull
+	QuarterLast
+)
+
+// LunarPhase is the instant of a principal phase of the moon
+type LunarPhase struct {
+	Quarter int // QuarterNew, QuarterFirst, QuarterFull, or QuarterLast
+	Time    time.Time
+}
+
+// Name returns the phase's name (e.g., "First quarter")
+func (p LunarPhase) Name() string {
+	return moonPhaseNames[2*p.Quarter]
+}
+
+// newMoonTerms holds the periodic terms of the new moon instant (Meeus,
+// Astronomical Algorithms, chapter 49): the amplitude in days, the power of
+// the eccentricity factor, and multiples of the arguments M, M', F, and Ω
+var newMoonTerms = [][6]float64{
+	{-0.40720, 0, 0, 1, 0, 0}, {0.17241, 1, 1, 0, 0, 0}, {0.01608, 0, 0, 2, 0, 0},
+	{0.01039, 0, 0, 0, 2, 0}, {0.00739, 1, -1, 1, 0, 0}, {-0.00514, 1, 1, 1, 0, 0},
+	{0.00208, 2, 2, 0, 0, 0}, {-0.00111, 0, 0, 1, -2, 0}, {-0.00057, 0, 0, 1, 2, 0},
+	{0.00056, 1, 1, 2, 0, 0}, {-0.00042, 0, 0, 3, 0, 0}, {0.00042, 1, 1, 0, 2, 0},
+	{0.00038, 1, 1, 0, -2, 0}, {-0.00024, 1, -1, 2, 0, 0}, {-0.00017, 0, 0, 0, 0, 1},
+	{-0.00007, 0, 2, 1, 0, 0}, {0.00004, 0, 0, 2, -2, 0}, {0.00004, 0, 3, 0, 0, 0},
+	{0.00003, 0, 1, 1, -2, 0}, {0.00003, 0, 0, 2, 2, 0}, {-0.00003, 0, 1, 1, 2, 0},
+	{0.00003, 0, -1, 1, 2, 0}, {-0.00002, 0, -1, 1, -2, 0}, {-0.00002, 0, 1, 3, 0, 0},
+	{0.00002, 0, 0, 4, 0, 0},
+}
+
+// fullMoonTerms holds the periodic terms of the full moon instant, like
+// newMoonTerms
+var fullMoonTerms = [][6]float64{
+	{-0.40614, 0, 0, 1, 0, 0}, {0.17302, 1, 1, 0, 0, 0}, {0.01614, 0, 0, 2, 0, 0},
+	{0.01043, 0, 0, 0, 2, 0}, {0.00734, 1, -1, 1, 0, 0}, {-0.00514, 1, 1, 1, 0, 0},
+	{0.00209, 2, 2, 0, 0, 0}, {-0.00111, 0, 0, 1, -2, 0}, {-0.00057, 0, 0, 1, 2, 0},
+	{0.00056, 1, 1, 2, 0, 0}, {-0.00042, 0, 0, 3, 0, 0}, {0.00042, 1, 1, 0, 2, 0},
+	{0.00038, 1, 1, 0, -2, 0}, {-0.00024, 1, -1, 2, 0, 0}, {-0.00017, 0, 0, 0, 0, 1},
+	{-0.00007, 0, 2, 1, 0, 0}, {0.00004, 0, 0, 2, -2, 0}, {0.00004, 0, 3, 0, 0, 0},
+	{0.00003, 0, 1, 1, -2, 0}, {0.00003, 0, 0, 2, 2, 0}, {-0.00003, 0, 1, 1, 2, 0},
+	{0.00003, 0, -1, 1, 2, 0}, {-0.00002, 0, -1, 1, -2, 0}, {-0.00002, 0, 1, 3, 0, 0},
+	{0.00002, 0, 0, 4, 0, 0},
+}
+
+// quarterMoonTerms holds the periodic terms of the first and last quarter
+// instants, like newMoonTerms
+var quarterMoonTerms = [][6]float64{
+	{-0.62801, 0, 0, 1, 0, 0}, {0.17172, 1, 1, 0, 0, 0}, {-0.01183, 1, 1, 1, 0, 0},
+	{0.00862, 0, 0, 2, 0, 0}, {0.00804, 0, 0, 0, 2, 0}, {0.00454, 1, -1, 1, 0, 0},
+	{0.00204, 2, 2, 0, 0, 0}, {-0.00180, 0, 0, 1, -2, 0}, {-0.00070, 0, 0, 1, 2, 0},
+	{-0.00040, 0, 0, 3, 0, 0}, {-0.00034, 1, -1, 2, 0, 0}, {0.00032, 1, 1, 0, 2, 0},
+	{0.00032, 1, 1, 0, -2, 0}, {-0.00028, 2, 2, 1, 0, 0}, {0.00027, 1, 1, 2, 0, 0},
+	{-0.00017, 0, 0, 0, 0, 1}, {-0.00005, 0, -1, 1, -2, 0}, {0.00004, 0, 0, 2, 2, 0},
+	{-0.00004, 0, 1, 1, 2, 0}, {0.00004, 0, -2, 1, 0, 0}, {0.00003, 0, 1, 1, -2, 0},
+	{0.00003, 0, 3, 0, 0, 0}, {0.00002, 0, 0, 2, -2, 0}, {0.00002, 0, -1, 1, 2, 0},
+	{-0.00002, 0, 1, 3, 0, 0},
+}
+
+// lunationArguments holds the mean instant and arguments of a phase of a
+// lunation (Meeus, chapter 49), in Julian ephemeris days and degrees
+type lunationArguments struct {
+	jde, c, e, m, mp, f, omega float64
+}
+
+// newLunationArguments returns the arguments for lunation k, where the
+// fraction of k selects the phase (0 is the new moon of January 6, 2000, and
+// 0.5 the following full moon)
+func newLunationArguments(k float64) lunationArguments {
+	c := k / 1236.85
+	return lunationArguments{
+		jde:   2451550.09766 + synodicMonth*k + 0.00015437*c*c - 0.000000150*c*c*c + 0.00000000073*c*c*c*c,
+		c:     c,
+		e:     1 - 0.002516*c - 0.0000074*c*c,
+		m:     2.5534 + 29.10535670*k - 0.0000014*c*c - 0.00000011*c*c*c,
+		mp:    201.5643 + 385.81693528*k + 0.0107582*c*c + 0.00001238*c*c*c - 0.000000058*c*c*c*c,
+		f:     160.7108 + 390.67050284*k - 0.0016118*c*c - 0.00000227*c*c*c + 0.000000011*c*c*c*c,
+		omega: 124.7746 - 1.56375588*k + 0.0020672*c*c + 0.00000215*c*c*c,
+	}
+}
+
+// julianEphemerisTime converts a Julian ephemeris day of year to a UTC time,
+// rounded to the second
+func julianEphemerisTime(jde float64, year int) time.Time {
+	// Julian day 2440587.5 is the Unix epoch; JDE counts Terrestrial Time
+	seconds := (jde-2440587.5)*86400 - deltaT(year)
+	return time.Unix(0, int64(seconds*float64(time.Second))).UTC().Round(time.Second)
+}
+
+// moonPhaseTime returns the instant of a principal phase (QuarterNew, ...)
+// of lunation k (0 is the new moon of January 6, 2000), accurate to about a
+// minute
+func moonPhaseTime(k, quarter int) time.Time {
+	kf := float64(k) + float64(quarter)/4
+	a := newLunationArguments(kf)
+
+	terms := quarterMoonTerms
+	switch quarter {
+	case QuarterNew:
+		terms = newMoonTerms
+	case QuarterFull:
+		terms = fullMoonTerms
+	}
+	jde := a.jde
+	for _, term := range terms {
+		jde += term[0] * math.Pow(a.e, term[1]) * math.Sin(degToRad(term[2]*a.m+term[3]*a.mp+term[4]*a.f+term[5]*a.omega))
+	}
+	if quarter == QuarterFirst || quarter == QuarterLast {
+		m, mp, f := degToRad(a.m), degToRad(a.mp), degToRad(a.f)
+		w := 0.00306 - 0.00038*a.e*math.Cos(m) + 0.00026*math.Cos(mp) - 0.00002*math.Cos(mp-m) + 0.00002*math.Cos(mp+m) + 0.00002*math.Cos(2*f)
+		if quarter == QuarterLast {
+			w = -w
+		}
+		jde += w
+	}
+
+	return julianEphemerisTime(jde, 2000+int(kf/12.3685))
+}
+
+// firstLunation returns a lunation number starting no later than t
+func firstLunation(t time.Time) int {
+	days := t.Sub(time.Date(2000, 1, 6, 18, 14, 0, 0, time.UTC)).Hours() / 24
+	return int(math.Floor(days/synodicMonth)) - 1
+}
+
+// MoonPhases returns the instants of the principal phases of the moon from
+// from until to, in order
+func MoonPhases(from, to time.Time) []LunarPhase {
+	var phases []LunarPhase
+	for k := firstLunation(from); ; k++ {
+		for quarter := QuarterNew; quarter <= QuarterLast; quarter++ {
+			t := moonPhaseTime(k, quarter)
+			if !t.Before(to) {
+				return phases
+			}
+			if !t.Before(from) {
+				phases = append(phases, LunarPhase{Quarter: quarter, Time: t})
+			}
+		}
+	}
+}
+
+// FullMoons returns the instants of full moon from from until to, in order
+func FullMoons(from, to time.Time) []time.Time {
+	var fullMoons []time.Time
+	for k := firstLunation(from); ; k++ {
+		full := moonPhaseTime(k, QuarterFull)
+		if !full.Before(to) {
+			return fullMoons
+		}
+		if !full.Before(from) {
+			fullMoons = append(fullMoons, full)
+		}
+	}
+}
+
+// Apsis types: the closest and farthest points of the moon's orbit
+const (
+	ApsisPerigee = "perigee"
+	ApsisApogee  = "apogee"
+)
+
+// ApsisTypes lists the apsis types
+var ApsisTypes = []string{ApsisPerigee, ApsisApogee}
+
+// MoonApsis is a time the moon is closest to (perigee) or farthest from
+// (apogee) the Earth
+type MoonApsis struct {
+	Type     string // ApsisPerigee or ApsisApogee
+	Time     time.Time
+	Distance float64 // Between the centres of the Earth and the moon, in km
+}
+
+// moonDistanceTerms holds the largest periodic terms of the moon's distance
+// (Meeus, Astronomical Algorithms, table 47.A): multiples of the arguments D,
+// M, M', and F, and the amplitude in metres
+var moonDistanceTerms = [][5]float64{
+	{0, 0, 1, 0, -20905355}, {2, 0, -1, 0, -3699111}, {2, 0, 0, 0, -2955968},
+	{0, 0, 2, 0, -569925}, {0, 1, 0, 0, 48888}, {0, 0, 0, 2, -3149},
+	{2, 0, -2, 0, 246158}, {2, -1, -1, 0, -152138}, {2, 0, 1, 0, -170733},
+	{2, -1, 0, 0, -204586}, {0, 1, -1, 0, -129620}, {1, 0, 0, 0, 108743},
+	{0, 1, 1, 0, 104755}, {2, 0, 0, -2, 10321}, {0, 0, 1, -2, 79661},
+	{4, 0, -1, 0, -34782}, {0, 0, 3, 0, -23210}, {4, 0, -2, 0, -21636},
+	{2, 1, -1, 0, 24208}, {2, 1, 0, 0, 30824}, {1, 0, -1, 0, -8379},
+	{1, 1, 0, 0, -16675}, {2, -1, 1, 0, -12831}, {2, 0, 2, 0, -10445},
+	{4, 0, 0, 0, -11650}, {2, 0, -3, 0, 14403}, {0, 1, -2, 0, -7003},
+	{2, -1, -2, 0, 10056}, {1, 0, 1, 0, 6322}, {2, -2, 0, 0, -9884},
+	{0, 1, 2, 0, 5751}, {2, -2, -1, 0, -4950}, {2, 0, 1, -2, 4130},
+	{4, -1, -1, 0, -3958}, {3, 0, -1, 0, 3258}, {2, 1, 1, 0, 2616},
+}
+
+// MoonDistance returns the distance between the centres of the Earth and the
+// moon at t, in km, accurate to about 10 km
+func MoonDistance(t time.Time) float64 {
+	// Julian centuries since J2000.0 in Terrestrial Time
+	jde := float64(t.UnixNano())/float64(24*time.Hour) + 2440587.5 + deltaT(t.UTC().Year())/86400
+	c := (jde - 2451545.0) / 36525
+
+	d := 297.8501921 + 445267.1114034*c - 0.0018819*c*c + c*c*c/545868 - c*c*c*c/113065000
+	m := 357.5291092 + 35999.0502909*c - 0.0001536*c*c + c*c*c/24490000
+	mp := 134.9633964 + 477198.8675055*c + 0.0087414*c*c + c*c*c/69699 - c*c*c*c/14712000
+	f := 93.2720950 + 483202.0175233*c - 0.0036539*c*c - c*c*c/3526000 + c*c*c*c/863310000
+	// Terms with the sun's mean anomaly shrink with the Earth's orbital eccentricity
+	e := 1 - 0.002516*c - 0.0000074*c*c
+
+	var sum float64
+	for _, term := range moonDistanceTerms {
+		amplitude := term[4] * math.Pow(e, math.Abs(term[1]))
+		sum += amplitude * math.Cos(degToRad(term[0]*d+term[1]*m+term[2]*mp+term[3]*f))
+	}
+	return 385000.56 + sum/1000
+}
+
+// moonLongitudeTerms holds the periodic terms of the moon's longitude (Meeus,
+// table 47.A; the distance terms are in moonDistanceTerms): multiples of D, M,
+// M', and F, and the amplitude in millionths of a degree
+var moonLongitudeTerms = [][5]float64{
+	{0, 0, 1, 0, 6288774}, {2, 0, -1, 0, 1274027}, {2, 0, 0, 0, 658314},
+	{0, 0, 2, 0, 213618}, {0, 1, 0, 0, -185116}, {0, 0, 0, 2, -114332},
+	{2, 0, -2, 0, 58793}, {2, -1, -1, 0, 57066}, {2, 0, 1, 0, 53322},
+	{2, -1, 0, 0, 45758}, {0, 1, -1, 0, -40923}, {1, 0, 0, 0, -34720},
+	{0, 1, 1, 0, -30383}, {2, 0, 0, -2, 15327}, {0, 0, 1, 2, -12528},
+	{0, 0, 1, -2, 10980}, {4, 0, -1, 0, 10675}, {0, 0, 3, 0, 10034},
+	{4, 0, -2, 0, 8548}, {2, 1, -1, 0, -7888}, {2, 1, 0, 0, -6766},
+	{1, 0, -1, 0, -5163}, {1, 1, 0, 0, 4987}, {2, -1, 1, 0, 4036},
+	{2, 0, 2, 0, 3994}, {4, 0, 0, 0, 3861}, {2, 0, -3, 0, 3665},
+	{0, 1, -2, 0, -2689}, {2, 0, -1, 2, -2602}, {2, -1, -2, 0, 2390},
+	{1, 0, 1, 0, -2348}, {2, -2, 0, 0, 2236}, {0, 1, 2, 0, -2120},
+	{0, 2, 0, 0, -2069}, {2, -2, -1, 0, 2048}, {2, 0, 1, -2, -1773},
+	{2, 0, 0, 2, -1595}, {4, -1, -1, 0, 1215}, {0, 0, 2, 2, -1110},
+	{3, 0, -1, 0, -892}, {2, 1, 1, 0, -810}, {4, -1, -2, 0, 759},
+	{0, 2, -1, 0, -713}, {2, 2, -1, 0, -700}, {2, 1, -2, 0, 691},
+	{2, -1, 0, -2, 596}, {4, 0, 1, 0, 549}, {0, 0, 4, 0, 537},
+	{4, -1, 0, 0, 520}, {1, 0, -2, 0, -487}, {2, 1, 0, -2, -399},
+	{0, 0, 2, -2, -381}, {1, 1, 1, 0, 351}, {3, 0, -2, 0, -340},
+	{4, 0, -3, 0, 330}, {2, -1, 2, 0, 327}, {0, 2, 1, 0, -323},
+	{1, 1, -1, 0, 299}, {2, 0, 3, 0, 294},
+}
+
+// moonLatitudeTerms holds the periodic terms of the moon's latitude (Meeus,
+// table 47.B): multiples of D, M, M', and F, and the amplitude in millionths
+// of a degree
+var moonLatitudeTerms = [][5]float64{
+	{0, 0, 0, 1, 5128122}, {0, 0, 1, 1, 280602}, {0, 0, 1, -1, 277693}, {2, 0, 0, -1, 173237},
+	{2, 0, -1, 1, 55413}, {2, 0, -1, -1, 46271}, {2, 0, 0, 1, 32573}, {0, 0, 2, 1, 17198},
+	{2, 0, 1, -1, 9266}, {0, 0, 2, -1, 8822}, {2, -1, 0, -1, 8216}, {2, 0, -2, -1, 4324},
+	{2, 0, 1, 1, 4200}, {2, 1, 0, -1, -3359}, {2, -1, -1, 1, 2463}, {2, -1, 0, 1, 2211},
+	{2, -1, -1, -1, 2065}, {0, 1, -1, -1, -1870}, {4, 0, -1, -1, 1828}, {0, 1, 0, 1, -1794},
+	{0, 0, 0, 3, -1749}, {0, 1, -1, 1, -1565}, {1, 0, 0, 1, -1491}, {0, 1, 1, 1, -1475},
+	{0, 1, 1, -1, -1410}, {0, 1, 0, -1, -1344}, {1, 0, 0, -1, -1335}, {0, 0, 3, 1, 1107},
+	{4, 0, 0, -1, 1021}, {4, 0, -1, 1, 833}, {0, 0, 1, -3, 777}, {4, 0, -2, 1, 671},
+	{2, 0, 0, -3, 607}, {2, 0, 2, -1, 596}, {2, -1, 1, -1, 491}, {2, 0, -2, 1, -451},
+	{0, 0, 3, -1, 439}, {2, 0, 2, 1, 422}, {2, 0, -3, -1, 421}, {2, 1, -1, 1, -366},
+	{2, 1, 0, 1, -351}, {4, 0, 0, 1, 331}, {2, -1, 1, 1, 315}, {2, -2, 0, -1, 302},
+	{0, 0, 1, 3, -283}, {2, 1, 1, -1, -229}, {1, 1, 0, -1, 223}, {1, 1, 0, 1, 223},
+	{0, 1, -2, -1, -220}, {2, 1, -1, -1, -220}, {1, 0, 1, 1, -185}, {2, -1, -2, -1, 181},
+	{0, 1, 2, 1, -177}, {4, 0, -2, -1, 176}, {4, -1, -1, -1, 166}, {1, 0, 1, -1, -164},
+	{4, 0, 1, -1, 132}, {1, 0, -1, -1, -119}, {4, -1, 0, -1, 115}, {2, -2, 0, 1, 107},
+}
+
+// moonEcliptic returns the moon's geocentric ecliptic longitude and latitude
+// at t, in degrees for the mean equinox of date, accurate to about 10
+// arcseconds (Meeus, chapter 47)
+func moonEcliptic(t time.Time) (longitude, latitude float64) {
+	// Julian centuries since J2000.0 in Terrestrial Time
+	jde := float64(t.UnixNano())/float64(24*time.Hour) + 2440587.5 + deltaT(t.UTC().Year())/86400
+	c := (jde - 2451545.0) / 36525
+
+	lp := 218.3164477 + 481267.88123421*c - 0.0015786*c*c + c*c*c/538841 - c*c*c*c/65194000
+	d := 297.8501921 + 445267.1114034*c - 0.0018819*c*c + c*c*c/545868 - c*c*c*c/113065000
+	m := 357.5291092 + 35999.0502909*c - 0.0001536*c*c + c*c*c/24490000
+	mp := 134.9633964 + 477198.8675055*c + 0.0087414*c*c + c*c*c/69699 - c*c*c*c/14712000
+	f := 93.2720950 + 483202.0175233*c - 0.0036539*c*c - c*c*c/3526000 + c*c*c*c/863310000
+	a1, a2, a3 := 119.75+131.849*c, 53.09+479264.290*c, 313.45+481266.484*c
+	e := 1 - 0.002516*c - 0.0000074*c*c
+
+	var sl, sb float64
+	for _, term := range moonLongitudeTerms {
+		sl += term[4] * math.Pow(e, math.Abs(term[1])) * math.Sin(degToRad(term[0]*d+term[1]*m+term[2]*mp+term[3]*f))
+	}
+	for _, term := range moonLatitudeTerms {
+		sb += term[4] * math.Pow(e, math.Abs(term[1])) * math.Sin(degToRad(term[0]*d+term[1]*m+term[2]*mp+term[3]*f))
+	}
+	// Venus, Jupiter, and the flattening of the Earth
+	sin := func(deg float64) float64 { return math.Sin(degToRad(deg)) }
+	sl += 3958*sin(a1) + 1962*sin(lp-f) + 318*sin(a2)
+	sb += -2235*sin(lp) + 382*sin(a3) + 175*sin(a1-f) + 175*sin(a1+f) + 127*sin(lp-mp) - 115*sin(lp+mp)
+
+	longitude = math.Mod(lp+sl/1e6, 360)
+	if longitude < 0 {
+		longitude += 360
+	}
+	return longitude, sb / 1e6
+}
+
+// apsisStep is the sampling interval when searching for apsides
+const apsisStep = 6 * time.Hour
+
+// MoonApsides returns the moon's perigees and apogees from from until to, in
+// order
+func MoonApsides(from, to time.Time) []MoonApsis {
+	var apsides []MoonApsis
+	prev, cur := MoonDistance(from.Add(-apsisStep)), MoonDistance(from)
+	for t := from; t.Before(to.Add(apsisStep)); t = t.Add(apsisStep) {
+		next := MoonDistance(t.Add(apsisStep))
+		apsis := MoonApsis{Type: ApsisApogee}
+		sign := 1.0
+		switch {
+		case cur >= prev && cur > next:
+		case cur <= prev && cur < next:
+			apsis.Type, sign = ApsisPerigee, -1
+		default:
+			prev, cur = cur, next
+			continue
+		}
+		apsis.Time = findMoonExtreme(MoonDistance, t.Add(-apsisStep), t.Add(apsisStep), sign)
+		if !apsis.Time.Before(from) && apsis.Time.Before(to) {
+			apsis.Distance = MoonDistance(apsis.Time)
+			apsides = append(apsides, apsis)
+		}
+		prev, cur = cur, next
+	}
+	return apsides
+}
+
+// anomalisticMonth is the average time between two perigees
+const anomalisticMonth = 27*24*time.Hour + 13*time.Hour + 18*time.Minute
+
+// NextMoonApsis returns the moon's first perigee or apogee after t
+func NextMoonApsis(apsisType string, t time.Time) MoonApsis {
+	// Perturbations move apsides by up to a few days from the average
+	for _, apsis := range MoonApsides(t.Add(time.Nanosecond), t.Add(anomalisticMonth+3*24*time.Hour)) {
+		if apsis.Type == apsisType {
+			return apsis
+		}
+	}
+	return MoonApsis{}
+}
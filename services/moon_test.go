@@ -0,0 +1,178 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/sixdouglas/suncalc"
+)
+
+func TestGetMoonPhase(t *testing.T) {
+	tests := []struct {
+		name string
+		time time.Time
+		want string
+	}{
+		{"new moon", time.Date(2024, 1, 11, 11, 57, 0, 0, time.UTC), "New moon"},
+		{"first quarter", time.Date(2024, 1, 18, 3, 53, 0, 0, time.UTC), "First quarter"},
+		{"full moon", time.Date(2024, 1, 25, 17, 54, 0, 0, time.UTC), "Full moon"},
+		{"last quarter", time.Date(2024, 2, 2, 23, 18, 0, 0, time.UTC), "Last quarter"},
+		{"waxing gibbous", time.Date(2024, 1, 21, 12, 0, 0, 0, time.UTC), "Waxing gibbous"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			phase := GetMoonPhase(tt.time)
+			if phase.Name != tt.want {
+				t.Errorf("expected %s, got %s (phase %.3f)", tt.want, phase.Name, phase.Phase)
+			}
+		})
+	}
+
+	if full := GetMoonPhase(time.Date(2024, 1, 25, 17, 54, 0, 0, time.UTC)); full.Illumination < 0.99 {
+		t.Errorf("expected full illumination at full moon, got %.2f", full.Illumination)
+	}
+}
+
+func TestGetMoonDay(t *testing.T) {
+	tz := GetTimezone(40.7128, -74.0060)
+	date := time.Date(2024, 1, 22, 12, 0, 0, 0, tz)
+	day := GetMoonDay(40.7128, -74.0060, date, tz)
+
+	start := time.Date(2024, 1, 22, 0, 0, 0, 0, tz)
+	for name, tm := range map[string]time.Time{"rise": day.Rise, "set": day.Set, "transit": day.Transit, "under transit": day.UnderTransit} {
+		if tm.Before(start) || !tm.Before(start.AddDate(0, 0, 1)) {
+			t.Errorf("expected %s on January 22, got %s", name, tm.In(tz))
+		}
+	}
+
+	// At the transits the moon is due south and due north (suncalc measures
+	// azimuth from south)
+	if az := suncalc.GetMoonPosition(day.Transit, 40.7128, -74.0060).Azimuth; math.Abs(az) > 0.02 {
+		t.Errorf("expected the moon due south at transit, got azimuth %.3f", az)
+	}
+	if az := suncalc.GetMoonPosition(day.UnderTransit, 40.7128, -74.0060).Azimuth; math.Pi-math.Abs(az) > 0.02 {
+		t.Errorf("expected the moon due north at under transit, got azimuth %.3f", az)
+	}
+
+	// The transit is about halfway between rise and set
+	if mid := day.Rise.Add(day.Set.AddDate(0, 0, 1).Sub(day.Rise) / 2); mid.Sub(day.Transit).Abs() > 30*time.Minute {
+		t.Errorf("expected transit near %s, got %s", mid.In(tz), day.Transit.In(tz))
+	}
+}
+
+func TestGetMoonDaysRange(t *testing.T) {
+	tz := GetTimezone(55.6761, 12.5683)
+	start := time.Date(2024, 12, 1, 12, 0, 0, 0, tz)
+
+	// Long ranges are computed concurrently, but must match day by day
+	// results in order, across the year boundary
+	got := GetMoonDaysRange(55.6761, 12.5683, start, 62, tz)
+	if len(got) != 62 {
+		t.Fatalf("expected 62 days, got %d", len(got))
+	}
+	for i, day := range got {
+		if want := GetMoonDay(55.6761, 12.5683, start.AddDate(0, 0, i), tz); day != want {
+			t.Errorf("day %d: expected %+v, got %+v", i, want, day)
+		}
+	}
+}
+
+func TestMoonDistance(t *testing.T) {
+	// Meeus, example 47.a: 368,409.7 km at 1992 April 12, 0h TT
+	got := MoonDistance(time.Date(1992, 4, 11, 23, 59, 1, 0, time.UTC))
+	if math.Abs(got-368409.7) > 20 {
+		t.Errorf("expected about 368,410 km, got %.1f", got)
+	}
+}
+
+func TestMoonEcliptic(t *testing.T) {
+	// Meeus, example 47.a: 1992 April 12, 0h TD
+	longitude, latitude := moonEcliptic(time.Date(1992, 4, 11, 23, 59, 1, 0, time.UTC))
+	if math.Abs(longitude-133.162655) > 0.001 || math.Abs(latitude+3.229126) > 0.001 {
+		t.Errorf("expected 133.1627°, -3.2291°, got %.4f°, %.4f°", longitude, latitude)
+	}
+}
+
+func TestMoonApsides(t *testing.T) {
+	apsides := MoonApsides(time.Date(2024, 10, 10, 0, 0, 0, 0, time.UTC), time.Date(2024, 11, 20, 0, 0, 0, 0, time.UTC))
+
+	// Published: perigee Oct 17 00:51 UTC (357,175 km), apogee Oct 29 22:50
+	// (406,161 km), perigee Nov 14 11:16 (360,109 km)
+	want := []MoonApsis{
+		{ApsisPerigee, time.Date(2024, 10, 17, 0, 51, 0, 0, time.UTC), 357175},
+		{ApsisApogee, time.Date(2024, 10, 29, 22, 50, 0, 0, time.UTC), 406161},
+		{ApsisPerigee, time.Date(2024, 11, 14, 11, 16, 0, 0, time.UTC), 360109},
+	}
+	if len(apsides) != len(want) {
+		t.Fatalf("expected %d apsides, got %+v", len(want), apsides)
+	}
+	for i, apsis := range apsides {
+		if apsis.Type != want[i].Type {
+			t.Errorf("apsis %d: expected %s, got %s", i, want[i].Type, apsis.Type)
+		}
+		if apsis.Time.Sub(want[i].Time).Abs() > time.Hour {
+			t.Errorf("apsis %d: expected about %s, got %s", i, want[i].Time, apsis.Time)
+		}
+		if math.Abs(apsis.Distance-want[i].Distance) > 20 {
+			t.Errorf("apsis %d: expected about %.0f km, got %.0f", i, want[i].Distance, apsis.Distance)
+		}
+	}
+}
+
+func TestNextMoonApsis(t *testing.T) {
+	from := time.Date(2024, 10, 17, 12, 0, 0, 0, time.UTC)
+	if next := NextMoonApsis(ApsisPerigee, from); next.Time.Format("2006-01-02") != "2024-11-14" {
+		t.Errorf("expected the next perigee on 2024-11-14, got %s", next.Time)
+	}
+	if next := NextMoonApsis(ApsisApogee, from); next.Time.Format("2006-01-02") != "2024-10-29" {
+		t.Errorf("expected the next apogee on 2024-10-29, got %s", next.Time)
+	}
+}
+
+func TestFullMoons(t *testing.T) {
+	fullMoons := FullMoons(time.Date(2024, 10, 17, 11, 0, 0, 0, time.UTC), time.Date(2025, 3, 14, 6, 55, 0, 0, time.UTC))
+
+	// Published: Oct 17 11:26, Nov 15 21:28, Dec 15 09:02, Jan 13 22:27,
+	// Feb 12 13:53 UTC; Mar 14 06:55 is just past the end
+	want := []time.Time{
+		time.Date(2024, 10, 17, 11, 26, 0, 0, time.UTC),
+		time.Date(2024, 11, 15, 21, 28, 0, 0, time.UTC),
+		time.Date(2024, 12, 15, 9, 2, 0, 0, time.UTC),
+		time.Date(2025, 1, 13, 22, 27, 0, 0, time.UTC),
+		time.Date(2025, 2, 12, 13, 53, 0, 0, time.UTC),
+	}
+	if len(fullMoons) != len(want) {
+		t.Fatalf("expected %d full moons, got %v", len(want), fullMoons)
+	}
+	for i, full := range fullMoons {
+		if full.Sub(want[i]).Abs() > 2*time.Minute {
+			t.Errorf("full moon %d: expected about %s, got %s", i, want[i], full)
+		}
+	}
+}
+
+func TestMoonPhases(t *testing.T) {
+	phases := MoonPhases(time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 9, 0, 0, 0, 0, time.UTC))
+
+	// Published: new moon Jan 11 11:57, first quarter Jan 18 03:53, full
+	// moon Jan 25 17:54, last quarter Feb 2 23:18 UTC
+	want := []LunarPhase{
+		{QuarterNew, time.Date(2024, 1, 11, 11, 57, 0, 0, time.UTC)},
+		{QuarterFirst, time.Date(2024, 1, 18, 3, 53, 0, 0, time.UTC)},
+		{QuarterFull, time.Date(2024, 1, 25, 17, 54, 0, 0, time.UTC)},
+		{QuarterLast, time.Date(2024, 2, 2, 23, 18, 0, 0, time.UTC)},
+	}
+	if len(phases) != len(want) {
+		t.Fatalf("expected %d phases, got %v", len(want), phases)
+	}
+	for i, phase := range phases {
+		if phase.Quarter != want[i].Quarter || phase.Time.Sub(want[i].Time).Abs() > 2*time.Minute {
+			t.Errorf("phase %d: expected %s about %s, got %s at %s", i, want[i].Name(), want[i].Time, phase.Name(), phase.Time)
+		}
+	}
+	if name := phases[1].Name(); name != "First quarter" {
+		t.Errorf("expected First quarter, got %s", name)
+	}
+}
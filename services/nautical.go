@@ -0,0 +1,104 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sixdouglas/suncalc"
+)
+
+// nauticalEvent is one of the day's events for the nautical preset
+type nauticalEvent struct {
+	eventType string
+	title     string
+	time      time.Time
+	azimuth   float64 // Degrees clockwise from north
+}
+
+// BuildNauticalEvents generates nautical dawn, sunrise, sunset, nautical
+// dusk, moonrise, and moonset events for passage planning. Descriptions give
+// azimuths as compass points, the day's nautical twilight and daylight, and
+// the moon phase.
+func BuildNauticalEvents(opts CalendarOptions, start time.Time, days int) []CalendarEvent {
+	var events []CalendarEvent
+	sunDays := GetSunTimesRange(opts.Lat, opts.Lng, start, days)
+	moonDays := GetMoonDaysRange(opts.Lat, opts.Lng, start, days, opts.Timezone)
+	for i, day := range sunDays {
+		sun := day.Times
+		moon := moonDays[i]
+
+		var dayEvents []nauticalEvent
+		for _, e := range []nauticalEvent{
+			{eventType: "nautical_dawn", title: "Nautical dawn", time: sun.NauticalDawn},
+			{eventType: "nautical_dusk", title: "Nautical dusk", time: sun.NauticalDusk},
+		} {
+			if !e.time.IsZero() {
+				e.azimuth, _ = SunPosition(opts.Lat, opts.Lng, e.time)
+				dayEvents = append(dayEvents, e)
+			}
+		}
+		for _, e := range []*SunEvent{day.Sunrise, day.Sunset} {
+			if e != nil {
+				title := strings.ToUpper(e.Type[:1]) + e.Type[1:]
+				dayEvents = append(dayEvents, nauticalEvent{eventType: e.Type, title: title, time: e.Time, azimuth: e.Azimuth})
+			}
+		}
+		for _, e := range []nauticalEvent{
+			{eventType: "moonrise", title: "Moonrise", time: moon.Rise},
+			{eventType: "moonset", title: "Moonset", time: moon.Set},
+		} {
+			if !e.time.IsZero() {
+				e.azimuth = radToDeg(suncalc.GetMoonPosition(e.time, opts.Lat, opts.Lng).Azimuth) + 180
+				dayEvents = append(dayEvents, e)
+			}
+		}
+		sort.Slice(dayEvents, func(i, j int) bool { return dayEvents[i].time.Before(dayEvents[j].time) })
+
+		summary := nauticalDaySummary(sun, opts.Timezone)
+		for _, e := range dayEvents {
+			events = append(events, newNauticalEvent(e, summary, opts))
+		}
+	}
+	return events
+}
+
+// nauticalDaySummary describes the day's nautical twilight and daylight
+func nauticalDaySummary(sun DayTimes, tz *time.Location) []string {
+	var lines []string
+	if !sun.NauticalDawn.IsZero() && !sun.NauticalDusk.IsZero() {
+		lines = append(lines, fmt.Sprintf("Nautical twilight: %s to %s",
+			sun.NauticalDawn.In(tz).Format("15:04"), sun.NauticalDusk.In(tz).Format("15:04")))
+	}
+	if !sun.Sunrise.IsZero() && !sun.Sunset.IsZero() {
+		lines = append(lines, fmt.Sprintf("Daylight: %s to %s (%s)",
+			sun.Sunrise.In(tz).Format("15:04"), sun.Sunset.In(tz).Format("15:04"), FormatDuration(sun.Sunset.Sub(sun.Sunrise))))
+	}
+	return lines
+}
+
+// newNauticalEvent creates a 1-minute event for the nautical preset
+func newNauticalEvent(e nauticalEvent, daySummary []string, opts CalendarOptions) CalendarEvent {
+	phase := GetMoonPhase(e.time)
+
+	lines := []string{
+		fmt.Sprintf("Time: %s", opts.Clock(e.time, "15:04")),
+		fmt.Sprintf("Location: %s", opts.Location),
+		"Coordinates: " + FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+		fmt.Sprintf("Bearing: %s (%.0f°)", CompassPoint(e.azimuth), e.azimuth),
+		"",
+	}
+	lines = append(lines, daySummary...)
+	lines = append(lines, fmt.Sprintf("Moon: %s (%d%% illuminated)", phase.Name, int(phase.Illumination*100+0.5)))
+
+	return CalendarEvent{
+		UID:         locationUID(e.time, opts.Lat, opts.Lng, opts.Precision, "nautical-"+e.eventType), // Distinct from the default calendar's sunrise and sunset
+		Type:        e.eventType,
+		Start:       e.time,
+		End:         e.time.Add(time.Minute),
+		Summary:     fmt.Sprintf("%s %s %s", e.title, opts.Clock(e.time, "15:04"), CompassPoint(e.azimuth)),
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+	}
+}
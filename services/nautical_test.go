@@ -0,0 +1,59 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildNauticalEvents(t *testing.T) {
+	tz := GetTimezone(50.8, -1.1) // Portsmouth
+	opts := CalendarOptions{Lat: 50.8, Lng: -1.1, Location: "Portsmouth", Timezone: tz}
+
+	events := BuildNauticalEvents(opts, time.Date(2024, 1, 22, 0, 0, 0, 0, time.UTC), 1)
+	if len(events) != 6 {
+		t.Fatalf("expected 6 events, got %d", len(events))
+	}
+
+	types := make(map[string]CalendarEvent)
+	for i, event := range events {
+		types[event.Type] = event
+		if i > 0 && event.Start.Before(events[i-1].Start) {
+			t.Error("expected events in chronological order")
+		}
+	}
+	for _, want := range []string{"nautical_dawn", "sunrise", "sunset", "nautical_dusk", "moonrise", "moonset"} {
+		if _, ok := types[want]; !ok {
+			t.Errorf("missing %s event", want)
+		}
+	}
+
+	// In January the sun rises south of east and sets south of west
+	if s := types["sunrise"].Summary; !strings.HasPrefix(s, "Sunrise 0") || !strings.HasSuffix(s, " SE") && !strings.HasSuffix(s, " ESE") {
+		t.Errorf("unexpected sunrise summary: %s", s)
+	}
+	if s := types["sunset"].Summary; !strings.HasSuffix(s, " SW") && !strings.HasSuffix(s, " WSW") {
+		t.Errorf("unexpected sunset summary: %s", s)
+	}
+
+	desc := types["moonrise"].Description
+	for _, want := range []string{"Bearing: ", "Nautical twilight: ", "Daylight: ", "Moon: Waxing gibbous"} {
+		if !strings.Contains(desc, want) {
+			t.Errorf("expected %q in description: %s", want, desc)
+		}
+	}
+	if types["sunrise"].UID == EventUID(types["sunrise"].Start, 50.8, -1.1, "sunrise") {
+		t.Error("expected UIDs distinct from the default calendar")
+	}
+}
+
+func TestBuildNauticalEvents_PolarNight(t *testing.T) {
+	// No sunrise or sunset in Longyearbyen in December, though the sun still
+	// rises above -12° (nautical twilight) around noon
+	opts := CalendarOptions{Lat: 78.2232, Lng: 15.6267, Timezone: time.UTC}
+	for _, event := range BuildNauticalEvents(opts, time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC), 3) {
+		if event.Type == "sunrise" || event.Type == "sunset" {
+			t.Errorf("unexpected %s event in polar night", event.Type)
+		}
+	}
+}
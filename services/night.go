@@ -0,0 +1,82 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// BuildNightEvents generates an event for each night starting on the days
+// from start, spanning from sunset to the following sunrise across midnight.
+// Descriptions give the night's length, its darkest moment (the sun's lowest
+// point, midway between the solar noons around it) and how far below the
+// horizon the sun is then, any full (astronomical) darkness, and the moon.
+// Days without a sunset (midnight sun) have no night; a night running into
+// polar night lasts until the next sunrise, if it comes within a few days.
+func BuildNightEvents(opts CalendarOptions, start time.Time, days int) []CalendarEvent {
+	const lookahead = 3 // Days searched for the sunrise ending the last nights
+	sunDays := GetSunTimesRange(opts.Lat, opts.Lng, start, days+lookahead)
+
+	var events []CalendarEvent
+	for i := 0; i < days; i++ {
+		sunset := sunDays[i].Sunset
+		if sunset == nil {
+			continue
+		}
+		for j := i; j < len(sunDays); j++ {
+			if sunrise := sunDays[j].Sunrise; sunrise != nil && sunrise.Time.After(sunset.Time) {
+				events = append(events, newNightEvent(sunDays[i], sunDays[j], opts))
+				break
+			}
+		}
+	}
+	return events
+}
+
+// newNightEvent creates an event spanning the night from evening's sunset
+// to morning's sunrise
+func newNightEvent(evening, morning DaySunTimes, opts CalendarOptions) CalendarEvent {
+	tz := opts.Timezone
+	sunset, sunrise := evening.Sunset.Time, morning.Sunrise.Time
+	start, end := sunset.Truncate(time.Minute), sunrise.Truncate(time.Minute)
+
+	lines := []string{
+		fmt.Sprintf("Time: %s", opts.ClockSpan(start, end, " to ")),
+		fmt.Sprintf("Location: %s", opts.Location),
+		"Coordinates: " + FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+		"",
+		fmt.Sprintf("Night length: %s", FormatDuration(sunrise.Sub(sunset))),
+	}
+	if darkest, ok := darkestMoment(evening, morning); ok {
+		_, elevation := SunPosition(opts.Lat, opts.Lng, darkest)
+		lines = append(lines, fmt.Sprintf("Darkest: %s (sun %.0f° below the horizon)", darkest.In(tz).Format("15:04"), math.Abs(elevation)))
+	}
+	if dark, light := evening.Times.Night, morning.Times.NightEnd; !dark.IsZero() && !light.IsZero() && light.After(dark) {
+		lines = append(lines, fmt.Sprintf("Full darkness: %s to %s (%s)", dark.In(tz).Format("15:04"), light.In(tz).Format("15:04"), FormatDuration(light.Sub(dark))))
+	} else {
+		lines = append(lines, "Full darkness: none (astronomical twilight all night)")
+	}
+	phase := GetMoonPhase(sunset.Add(sunrise.Sub(sunset) / 2))
+	lines = append(lines, fmt.Sprintf("Moon: %s (%d%% illuminated)", phase.Name, int(phase.Illumination*100+0.5)))
+
+	return CalendarEvent{
+		UID:         locationUID(sunset, opts.Lat, opts.Lng, opts.Precision, "night"),
+		Type:        "night",
+		Start:       start,
+		End:         end,
+		Summary:     fmt.Sprintf("Night %s (%s)", opts.ClockSpan(start, end, " to "), FormatDuration(sunrise.Sub(sunset))),
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+	}
+}
+
+// darkestMoment returns the solar midnight between two days: midway between
+// their solar noons if they are consecutive
+func darkestMoment(evening, morning DaySunTimes) (time.Time, bool) {
+	noon, next := evening.Times.SolarNoon, morning.Times.SolarNoon
+	if noon.IsZero() || next.IsZero() || next.Sub(noon) > 36*time.Hour {
+		return time.Time{}, false
+	}
+	return noon.Add(next.Sub(noon) / 2), true
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildNightEvents(t *testing.T) {
+	tz := GetTimezone(55.6761, 12.5683)
+	opts := CalendarOptions{Lat: 55.6761, Lng: 12.5683, Location: "Copenhagen", Timezone: tz}
+
+	events := BuildNightEvents(opts, time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC), 2)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 nights, got %d", len(events))
+	}
+	night := events[0]
+	start, end := night.Start.In(tz), night.End.In(tz)
+	if start.Day() != 21 || start.Hour() != 15 || end.Day() != 22 || end.Hour() != 8 {
+		t.Errorf("expected the night from the sunset on the 21st to the sunrise on the 22nd, got %s to %s", start, end)
+	}
+	if night.Type != "night" || night.Summary != "Night 15:39 to 08:39 (16h 59m)" {
+		t.Errorf("unexpected night: %s %s", night.Type, night.Summary)
+	}
+	for _, want := range []string{"Night length: 16h 59m", "Darkest: 00:09 (sun 58° below the horizon)", "Full darkness: 18:00 to 06:18", "Moon: "} {
+		if !strings.Contains(night.Description, want) {
+			t.Errorf("expected %q in description:\n%s", want, night.Description)
+		}
+	}
+	if events[1].Start.Before(night.End) || events[1].UID == night.UID {
+		t.Error("expected the second night after the first, with its own UID")
+	}
+}
+
+func TestBuildNightEvents_Summer(t *testing.T) {
+	tz := GetTimezone(55.6761, 12.5683)
+	opts := CalendarOptions{Lat: 55.6761, Lng: 12.5683, Location: "Copenhagen", Timezone: tz}
+
+	events := BuildNightEvents(opts, time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), 1)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 night, got %d", len(events))
+	}
+	for _, want := range []string{"Darkest: 01:12 (sun 11° below the horizon)", "Full darkness: none"} {
+		if !strings.Contains(events[0].Description, want) {
+			t.Errorf("expected %q in description:\n%s", want, events[0].Description)
+		}
+	}
+}
+
+func TestBuildNightEvents_Polar(t *testing.T) {
+	tz := GetTimezone(69.65, 18.96) // Tromsø
+	opts := CalendarOptions{Lat: 69.65, Lng: 18.96, Location: "Tromsø", Timezone: tz}
+
+	if events := BuildNightEvents(opts, time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), 3); len(events) != 0 {
+		t.Errorf("expected no nights under the midnight sun, got %d", len(events))
+	}
+	// The last sunset before polar night has no sunrise for weeks
+	events := BuildNightEvents(opts, time.Date(2024, 11, 24, 0, 0, 0, 0, time.UTC), 4)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 nights before polar night, got %d", len(events))
+	}
+	if length := events[2].End.Sub(events[2].Start); length < 23*time.Hour {
+		t.Errorf("expected a night of over 23 hours, got %s", length)
+	}
+}
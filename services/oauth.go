@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrTokenRevoked is returned when the provider rejects a refresh token, i.e.
+// the user has revoked access
+var ErrTokenRevoked = errors.New("oauth token revoked")
+
+// OAuthConfig describes an OAuth 2.0 authorization code flow client
+type OAuthConfig struct {
+	ClientID     string
+	ClientSecret string
+	AuthURL      string
+	TokenURL     string
+	Scopes       []string
+	AuthParams   url.Values // Provider-specific authorization parameters
+}
+
+// OAuthToken is an access token with its refresh token
+type OAuthToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// Expired reports whether the access token has expired at now (with a
+// safety margin)
+func (t *OAuthToken) Expired(now time.Time) bool {
+	return now.Add(time.Minute).After(t.Expiry)
+}
+
+// AuthCodeURL returns the URL to send the user to for consent
+func (c *OAuthConfig) AuthCodeURL(state, redirectURI string) string {
+	params := url.Values{}
+	params.Set("client_id", c.ClientID)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("response_type", "code")
+	params.Set("scope", strings.Join(c.Scopes, " "))
+	params.Set("state", state)
+	for key, values := range c.AuthParams {
+		params[key] = values
+	}
+	return c.AuthURL + "?" + params.Encode()
+}
+
+// Exchange trades an authorization code for a token issued at now
+func (c *OAuthConfig) Exchange(ctx context.Context, client *http.Client, code, redirectURI string, now time.Time) (*OAuthToken, error) {
+	params := url.Values{}
+	params.Set("grant_type", "authorization_code")
+	params.Set("code", code)
+	params.Set("redirect_uri", redirectURI)
+	return c.requestToken(ctx, client, params, now)
+}
+
+// Refresh obtains a new access token, issued at now, using the token's
+// refresh token
+func (c *OAuthConfig) Refresh(ctx context.Context, client *http.Client, token *OAuthToken, now time.Time) (*OAuthToken, error) {
+	params := url.Values{}
+	params.Set("grant_type", "refresh_token")
+	params.Set("refresh_token", token.RefreshToken)
+
+	refreshed, err := c.requestToken(ctx, client, params, now)
+	if err != nil {
+		return nil, err
+	}
+	// Providers may omit the refresh token when it is unchanged
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = token.RefreshToken
+	}
+	return refreshed, nil
+}
+
+// requestToken posts to the token endpoint and decodes the response. The
+// token's expiry counts from now.
+func (c *OAuthConfig) requestToken(ctx context.Context, client *http.Client, params url.Values, now time.Time) (*OAuthToken, error) {
+	params.Set("client_id", c.ClientID)
+	params.Set("client_secret", c.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.TokenURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		Error        string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid token response: %w", err)
+	}
+
+	if body.Error == "invalid_grant" {
+		return nil, ErrTokenRevoked
+	}
+	if resp.StatusCode != http.StatusOK || body.AccessToken == "" {
+		return nil, fmt.Errorf("token request failed: status %d %s", resp.StatusCode, body.Error)
+	}
+
+	return &OAuthToken{
+		AccessToken:  body.AccessToken,
+		RefreshToken: body.RefreshToken,
+		Expiry:       now.Add(time.Duration(body.ExpiresIn) * time.Second),
+	}, nil
+}
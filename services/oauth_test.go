@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newTestOAuth(t *testing.T, handler http.HandlerFunc) *OAuthConfig {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return &OAuthConfig{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		AuthURL:      "https://auth.example/authorize",
+		TokenURL:     server.URL,
+		Scopes:       []string{"calendar", "offline"},
+		AuthParams:   url.Values{"prompt": {"consent"}},
+	}
+}
+
+func TestOAuthConfig_AuthCodeURL(t *testing.T) {
+	c := newTestOAuth(t, nil)
+
+	u, err := url.Parse(c.AuthCodeURL("state123", "https://calsun.example/callback"))
+	if err != nil {
+		t.Fatalf("invalid URL: %v", err)
+	}
+
+	q := u.Query()
+	expected := map[string]string{
+		"client_id":     "client",
+		"redirect_uri":  "https://calsun.example/callback",
+		"response_type": "code",
+		"scope":         "calendar offline",
+		"state":         "state123",
+		"prompt":        "consent",
+	}
+	for key, value := range expected {
+		if q.Get(key) != value {
+			t.Errorf("expected %s=%s, got %s", key, value, q.Get(key))
+		}
+	}
+}
+
+func TestOAuthConfig_Exchange(t *testing.T) {
+	c := newTestOAuth(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("grant_type") != "authorization_code" || r.Form.Get("code") != "abc" || r.Form.Get("client_secret") != "secret" {
+			http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"access_token":"at","refresh_token":"rt","expires_in":3600}`))
+	})
+
+	issued := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+	token, err := c.Exchange(context.Background(), http.DefaultClient, "abc", "https://calsun.example/callback", issued)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token.AccessToken != "at" || token.RefreshToken != "rt" {
+		t.Errorf("unexpected token: %+v", token)
+	}
+	if token.Expired(issued) {
+		t.Error("fresh token should not be expired")
+	}
+	if !token.Expired(issued.Add(time.Hour)) {
+		t.Error("token should expire after expires_in")
+	}
+}
+
+func TestOAuthConfig_Refresh(t *testing.T) {
+	c := newTestOAuth(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.Form.Get("refresh_token") {
+		case "valid":
+			w.Write([]byte(`{"access_token":"new","expires_in":3600}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+		}
+	})
+
+	token, err := c.Refresh(context.Background(), http.DefaultClient, &OAuthToken{RefreshToken: "valid"}, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token.AccessToken != "new" || token.RefreshToken != "valid" {
+		t.Errorf("expected new access token and kept refresh token, got %+v", token)
+	}
+
+	_, err = c.Refresh(context.Background(), http.DefaultClient, &OAuthToken{RefreshToken: "revoked"}, time.Now())
+	if err != ErrTokenRevoked {
+		t.Errorf("expected ErrTokenRevoked, got %v", err)
+	}
+}
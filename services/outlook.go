@@ -0,0 +1,194 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	microsoftGraphAPI   = "https://graph.microsoft.com/v1.0"
+	microsoftLoginURL   = "https://login.microsoftonline.com"
+	graphDateTimeLayout = "2006-01-02T15:04:05"
+
+	// outlookUIDProperty is the single-value extended property that tags
+	// events with their CalSun UID, since Graph assigns event IDs itself
+	outlookUIDProperty = "String {5f0e6a4c-1d2b-4c8e-9a57-3b6d0c2f8e41} Name CalSunUID"
+)
+
+// OutlookCalendar pushes events into Outlook calendars via Microsoft Graph
+type OutlookCalendar struct {
+	oauth   *OAuthConfig
+	client  *http.Client
+	baseURL string
+}
+
+// NewOutlookCalendar creates an Outlook provider for the given Entra ID app
+// registration. tenant is "organizations", "common", or a tenant ID.
+func NewOutlookCalendar(clientID, clientSecret, tenant string) *OutlookCalendar {
+	return &OutlookCalendar{
+		oauth: &OAuthConfig{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			AuthURL:      fmt.Sprintf("%s/%s/oauth2/v2.0/authorize", microsoftLoginURL, tenant),
+			TokenURL:     fmt.Sprintf("%s/%s/oauth2/v2.0/token", microsoftLoginURL, tenant),
+			// offline_access returns a refresh token so events can be synced without the user present
+			Scopes: []string{"offline_access", "Calendars.ReadWrite"},
+		},
+		client:  &http.Client{Timeout: 30 * time.Second},
+		baseURL: microsoftGraphAPI,
+	}
+}
+
+// OAuth returns the Microsoft identity platform client configuration
+func (o *OutlookCalendar) OAuth() *OAuthConfig {
+	return o.oauth
+}
+
+// DefaultCalendarID returns "", meaning the user's default calendar
+func (o *OutlookCalendar) DefaultCalendarID() string {
+	return ""
+}
+
+// graphEvent is the Graph event resource (subset)
+type graphEvent struct {
+	Subject                     string                `json:"subject"`
+	Body                        graphItemBody         `json:"body"`
+	Location                    graphLocation         `json:"location"`
+	Start                       graphDateTimeZone     `json:"start"`
+	End                         graphDateTimeZone     `json:"end"`
+	ShowAs                      string                `json:"showAs"`
+	IsReminderOn                bool                  `json:"isReminderOn"`
+	SingleValueExtendedProperty []graphExtendedString `json:"singleValueExtendedProperties,omitempty"`
+}
+
+type graphItemBody struct {
+	ContentType string `json:"contentType"`
+	Content     string `json:"content"`
+}
+
+type graphLocation struct {
+	DisplayName string `json:"displayName"`
+}
+
+type graphDateTimeZone struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
+}
+
+type graphExtendedString struct {
+	ID    string `json:"id"`
+	Value string `json:"value"`
+}
+
+// UpsertEvent updates the event tagged with the event's UID, creating it if
+// no such event exists yet
+func (o *OutlookCalendar) UpsertEvent(ctx context.Context, accessToken, calendarID string, event CalendarEvent) error {
+	body := graphEvent{
+		Subject:      event.Summary,
+		Body:         graphItemBody{ContentType: "text", Content: event.Description},
+		Location:     graphLocation{DisplayName: event.Location},
+		Start:        graphDateTimeZone{DateTime: event.Start.UTC().Format(graphDateTimeLayout), TimeZone: "UTC"},
+		End:          graphDateTimeZone{DateTime: event.End.UTC().Format(graphDateTimeLayout), TimeZone: "UTC"},
+		ShowAs:       "free",
+		IsReminderOn: false,
+	}
+
+	eventsURL := o.baseURL + "/me/calendar/events"
+	if calendarID != "" {
+		eventsURL = fmt.Sprintf("%s/me/calendars/%s/events", o.baseURL, url.PathEscape(calendarID))
+	}
+
+	id, err := o.findEvent(ctx, accessToken, eventsURL, event.UID)
+	if err != nil {
+		return err
+	}
+
+	if id != "" {
+		status, err := o.do(ctx, "PATCH", o.baseURL+"/me/events/"+url.PathEscape(id), accessToken, body, nil)
+		if err != nil {
+			return err
+		}
+		if status != http.StatusOK {
+			return fmt.Errorf("microsoft graph: update: status %d", status)
+		}
+		return nil
+	}
+
+	body.SingleValueExtendedProperty = []graphExtendedString{{ID: outlookUIDProperty, Value: event.UID}}
+	status, err := o.do(ctx, "POST", eventsURL, accessToken, body, nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusCreated {
+		return fmt.Errorf("microsoft graph: create: status %d", status)
+	}
+	return nil
+}
+
+// findEvent returns the Graph ID of the event tagged with uid, or "" if there
+// is none
+func (o *OutlookCalendar) findEvent(ctx context.Context, accessToken, eventsURL, uid string) (string, error) {
+	params := url.Values{}
+	params.Set("$filter", fmt.Sprintf("singleValueExtendedProperties/Any(ep: ep/id eq '%s' and ep/value eq '%s')",
+		outlookUIDProperty, strings.ReplaceAll(uid, "'", "''")))
+	params.Set("$select", "id")
+
+	var result struct {
+		Value []struct {
+			ID string `json:"id"`
+		} `json:"value"`
+	}
+	status, err := o.do(ctx, "GET", eventsURL+"?"+params.Encode(), accessToken, nil, &result)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("microsoft graph: lookup: status %d", status)
+	}
+	if len(result.Value) == 0 {
+		return "", nil
+	}
+	return result.Value[0].ID, nil
+}
+
+// do sends an authenticated JSON request, decoding a successful response into
+// out if it is non-nil, and returns the response status
+func (o *OutlookCalendar) do(ctx context.Context, method, u, accessToken string, body, out any) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("microsoft graph: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return 0, fmt.Errorf("microsoft graph: invalid response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
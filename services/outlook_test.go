@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeGraph is an in-memory Graph events API keyed by the CalSun UID property
+type fakeGraph struct {
+	events  map[string]graphEvent // Graph ID -> event
+	methods []string
+}
+
+func (g *fakeGraph) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.methods = append(g.methods, r.Method)
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/me/calendar/events":
+		filter := r.URL.Query().Get("$filter")
+		var matches []map[string]string
+		for id, event := range g.events {
+			uid := event.SingleValueExtendedProperty[0].Value
+			if strings.Contains(filter, outlookUIDProperty) && strings.Contains(filter, "ep/value eq '"+uid+"'") {
+				matches = append(matches, map[string]string{"id": id})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"value": matches})
+	case r.Method == "POST" && r.URL.Path == "/me/calendar/events":
+		var event graphEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		if len(event.SingleValueExtendedProperty) != 1 || event.SingleValueExtendedProperty[0].ID != outlookUIDProperty {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		g.events["AAMk"+event.SingleValueExtendedProperty[0].Value] = event
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == "PATCH" && strings.HasPrefix(r.URL.Path, "/me/events/"):
+		id := strings.TrimPrefix(r.URL.Path, "/me/events/")
+		existing, ok := g.events[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var event graphEvent
+		json.NewDecoder(r.Body).Decode(&event)
+		event.SingleValueExtendedProperty = existing.SingleValueExtendedProperty
+		g.events[id] = event
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestNewOutlookCalendar_Tenant(t *testing.T) {
+	o := NewOutlookCalendar("client", "secret", "organizations")
+
+	if o.OAuth().TokenURL != "https://login.microsoftonline.com/organizations/oauth2/v2.0/token" {
+		t.Errorf("unexpected token URL %s", o.OAuth().TokenURL)
+	}
+	if !strings.Contains(o.OAuth().AuthCodeURL("state", "https://calsun.example/cb"), "offline_access") {
+		t.Error("authorization URL should request offline access")
+	}
+}
+
+func TestOutlookCalendar_UpsertEvent(t *testing.T) {
+	graph := &fakeGraph{events: make(map[string]graphEvent)}
+	server := httptest.NewServer(graph)
+	defer server.Close()
+
+	o := NewOutlookCalendar("client", "secret", "organizations")
+	o.baseURL = server.URL
+
+	event := CalendarEvent{
+		UID:         "abc@calsun",
+		Start:       time.Date(2024, 6, 21, 2, 25, 0, 0, time.UTC),
+		End:         time.Date(2024, 6, 21, 2, 26, 0, 0, time.UTC),
+		Summary:     "Sunrise 04:25",
+		Description: "Day length: 17h 35m",
+	}
+
+	if err := o.UpsertEvent(context.Background(), "token", "", event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(graph.methods, ",") != "GET,POST" {
+		t.Errorf("expected lookup then create, got %v", graph.methods)
+	}
+
+	created := graph.events["AAMkabc@calsun"]
+	if created.Subject != "Sunrise 04:25" || created.Body.Content != "Day length: 17h 35m" {
+		t.Errorf("unexpected event: %+v", created)
+	}
+	if created.Start.DateTime != "2024-06-21T02:25:00" || created.Start.TimeZone != "UTC" {
+		t.Errorf("unexpected start: %+v", created.Start)
+	}
+
+	graph.methods = nil
+	event.Summary = "Sunrise 04:26"
+	if err := o.UpsertEvent(context.Background(), "token", "", event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(graph.methods, ",") != "GET,PATCH" {
+		t.Errorf("expected lookup then update, got %v", graph.methods)
+	}
+	if len(graph.events) != 1 || graph.events["AAMkabc@calsun"].Subject != "Sunrise 04:26" {
+		t.Errorf("expected the existing event to be updated, got %+v", graph.events)
+	}
+
+	if err := o.UpsertEvent(context.Background(), "wrong", "", event); err == nil {
+		t.Error("expected error for unauthorized request")
+	}
+}
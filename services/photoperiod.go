@@ -0,0 +1,161 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// BuildPhotoperiodEvents generates an all-day event on each day the day
+// length crosses one of the thresholds (in hours), rising or falling. Polar
+// days and nights count as 24 and 0 hours.
+func BuildPhotoperiodEvents(opts CalendarOptions, thresholds []float64, start time.Time, days int) []CalendarEvent {
+	var events []CalendarEvent
+	for _, c := range dayLengthCrossings(opts, thresholds, start, days) {
+		events = append(events, newPhotoperiodEvent(c.date, c.threshold, c.hours, c.change, opts))
+	}
+	return events
+}
+
+// DaylightAlert is a day length threshold crossed in one direction, such as
+// the day length dropping below 9 hours
+type DaylightAlert struct {
+	Hours  float64
+	Rising bool // Alert when the day length rises above Hours rather than drops below it
+}
+
+// BuildDaylightAlertEvents generates an all-day event on each day the day
+// length crosses one of the alerts' thresholds in the alert's direction
+func BuildDaylightAlertEvents(opts CalendarOptions, alerts []DaylightAlert, start time.Time, days int) []CalendarEvent {
+	var thresholds []float64
+	for _, alert := range alerts {
+		if !slices.Contains(thresholds, alert.Hours) {
+			thresholds = append(thresholds, alert.Hours)
+		}
+	}
+
+	var events []CalendarEvent
+	for _, c := range dayLengthCrossings(opts, thresholds, start, days) {
+		if alert := (DaylightAlert{Hours: c.threshold, Rising: c.change > 0}); slices.Contains(alerts, alert) {
+			events = append(events, newDaylightAlertEvent(c, alert, opts))
+		}
+	}
+	return events
+}
+
+// dayLengthCrossing is a day on which the day length crosses a threshold
+type dayLengthCrossing struct {
+	date      time.Time // UTC midnight of the day
+	threshold float64   // Hours
+	hours     float64   // Day length on the day
+	change    float64   // Hours since the day before; positive when rising
+}
+
+// dayLengthCrossings returns the days starting at start on which the day
+// length crosses one of the thresholds (in hours), in order. Polar days and
+// nights count as 24 and 0 hours.
+func dayLengthCrossings(opts CalendarOptions, thresholds []float64, start time.Time, days int) []dayLengthCrossing {
+	dayLength := dailyMetrics[MetricDayLength]
+	hours := func(date time.Time) (float64, bool) {
+		noon := date.Add(12 * time.Hour)
+		return dayLength(opts.Lat, opts.Lng, noon, GetSunTimes(opts.Lat, opts.Lng, noon))
+	}
+
+	var crossings []dayLengthCrossing
+	prev, prevOK := hours(start.AddDate(0, 0, -1))
+	for i := 0; i < days; i++ {
+		date := start.AddDate(0, 0, i)
+		cur, ok := hours(date)
+		if ok && prevOK {
+			for _, threshold := range thresholds {
+				if (prev < threshold) != (cur < threshold) {
+					crossings = append(crossings, dayLengthCrossing{date: date, threshold: threshold, hours: cur, change: cur - prev})
+				}
+			}
+		}
+		prev, prevOK = cur, ok
+	}
+	return crossings
+}
+
+// newPhotoperiodEvent creates an all-day event for a day length threshold
+// crossing on date
+func newPhotoperiodEvent(date time.Time, threshold, hours, change float64, opts CalendarOptions) CalendarEvent {
+	direction, eventType, trend := "above", "photoperiod_rising", "days getting longer"
+	if change < 0 {
+		direction, eventType, trend = "below", "photoperiod_falling", "days getting shorter"
+	}
+	local := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, opts.Timezone)
+	delta := time.Duration(change * float64(time.Hour)).Round(time.Second)
+
+	lines := []string{
+		fmt.Sprintf("Day length: %s (%s since yesterday)", FormatDuration(time.Duration(hours*float64(time.Hour))), formatSignedDuration(delta)),
+		fmt.Sprintf("Location: %s", opts.Location),
+		"Coordinates: " + FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+		"",
+		fmt.Sprintf("Threshold: %s, %s", formatHours(threshold), trend),
+	}
+
+	return CalendarEvent{
+		UID:         locationUID(local, opts.Lat, opts.Lng, opts.Precision, fmt.Sprintf("%s-%g", eventType, threshold)),
+		Type:        eventType,
+		Start:       local,
+		End:         local.AddDate(0, 0, 1),
+		Summary:     fmt.Sprintf("Day length %s %s", direction, formatHours(threshold)),
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+		AllDay:      true,
+	}
+}
+
+// newDaylightAlertEvent creates an all-day event for an alert's day length
+// crossing (e.g., "Days now shorter than 9h")
+func newDaylightAlertEvent(c dayLengthCrossing, alert DaylightAlert, opts CalendarOptions) CalendarEvent {
+	direction, comparison, rule := "below", "shorter", "dropped below"
+	if alert.Rising {
+		direction, comparison, rule = "above", "longer", "rose above"
+	}
+	local := time.Date(c.date.Year(), c.date.Month(), c.date.Day(), 0, 0, 0, 0, opts.Timezone)
+	delta := time.Duration(c.change * float64(time.Hour)).Round(time.Second)
+
+	lines := []string{
+		fmt.Sprintf("Day length: %s (%s since yesterday)", FormatDuration(time.Duration(c.hours*float64(time.Hour))), formatSignedDuration(delta)),
+		fmt.Sprintf("Location: %s", opts.Location),
+		"Coordinates: " + FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+		"",
+		fmt.Sprintf("Alert: the day length %s %s", rule, formatHours(alert.Hours)),
+	}
+
+	return CalendarEvent{
+		UID:         locationUID(local, opts.Lat, opts.Lng, opts.Precision, fmt.Sprintf("daylight_alert-%s-%g", direction, alert.Hours)),
+		Type:        "daylight_alert",
+		Start:       local,
+		End:         local.AddDate(0, 0, 1),
+		Summary:     fmt.Sprintf("Days now %s than %s", comparison, formatHours(alert.Hours)),
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+		AllDay:      true,
+	}
+}
+
+// formatHours formats a number of hours compactly (e.g., "12h", "13h 30m")
+func formatHours(hours float64) string {
+	d := time.Duration(hours * float64(time.Hour)).Round(time.Minute)
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return FormatDuration(d)
+}
+
+// formatSignedDuration formats a small duration with its sign (e.g., "+2m 41s")
+func formatSignedDuration(d time.Duration) string {
+	sign := "+"
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	if d >= time.Hour {
+		return sign + FormatDuration(d)
+	}
+	return fmt.Sprintf("%s%dm %ds", sign, int(d.Minutes()), int(d.Seconds())%60)
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildPhotoperiodEvents(t *testing.T) {
+	tz := GetTimezone(55.6761, 12.5683)
+	opts := CalendarOptions{Lat: 55.6761, Lng: 12.5683, Location: "Copenhagen", Timezone: tz}
+
+	// Copenhagen passes 12h just before the March equinox and 14h in mid April
+	events := BuildPhotoperiodEvents(opts, []float64{12, 14}, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 60)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 crossings, got %d", len(events))
+	}
+
+	twelve, fourteen := events[0], events[1]
+	if twelve.Summary != "Day length above 12h" || twelve.Type != "photoperiod_rising" {
+		t.Errorf("unexpected event: %s (%s)", twelve.Summary, twelve.Type)
+	}
+	if d := twelve.Start.Format("2006-01-02"); d < "2024-03-14" || d > "2024-03-19" {
+		t.Errorf("expected 12h around March 17, got %s", d)
+	}
+	if fourteen.Summary != "Day length above 14h" {
+		t.Errorf("unexpected event: %s", fourteen.Summary)
+	}
+	if !twelve.AllDay || twelve.Start.Location() != tz || twelve.Start.Hour() != 0 || twelve.End.Sub(twelve.Start) != 24*time.Hour {
+		t.Errorf("expected an all-day event at local midnight, got %s to %s", twelve.Start, twelve.End)
+	}
+	if !strings.Contains(twelve.Description, "Day length: 12h 2m (+4m") || !strings.Contains(twelve.Description, "Threshold: 12h, days getting longer") {
+		t.Errorf("unexpected description: %s", twelve.Description)
+	}
+}
+
+func TestBuildPhotoperiodEvents_Falling(t *testing.T) {
+	opts := CalendarOptions{Lat: 55.6761, Lng: 12.5683, Timezone: time.UTC}
+	events := BuildPhotoperiodEvents(opts, []float64{13.5}, time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC), 60)
+	if len(events) != 1 || events[0].Summary != "Day length below 13h 30m" || events[0].Type != "photoperiod_falling" {
+		t.Fatalf("expected one falling crossing of 13h 30m, got %+v", events)
+	}
+}
+
+func TestBuildPhotoperiodEvents_PolarDay(t *testing.T) {
+	// The midnight sun starts in Tromsø around May 20, so day length jumps to 24h
+	opts := CalendarOptions{Lat: 69.6492, Lng: 18.9553, Timezone: time.UTC}
+	events := BuildPhotoperiodEvents(opts, []float64{23.5}, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), 40)
+	if len(events) != 1 {
+		t.Fatalf("expected one crossing, got %d", len(events))
+	}
+}
+
+func TestBuildDaylightAlertEvents(t *testing.T) {
+	opts := CalendarOptions{Lat: 55.6761, Lng: 12.5683, Location: "Copenhagen", Timezone: GetTimezone(55.6761, 12.5683)}
+	alerts := []DaylightAlert{{Hours: 9}, {Hours: 15, Rising: true}}
+
+	// A year from January 1: 15h is passed rising in late April and falling
+	// in mid August, 9h falling in early November and rising in mid February
+	events := BuildDaylightAlertEvents(opts, alerts, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), 365)
+	if len(events) != 2 {
+		t.Fatalf("expected one alert per direction, got %d", len(events))
+	}
+	longer, shorter := events[0], events[1]
+	if longer.Summary != "Days now longer than 15h" || longer.Start.Month() != time.April || longer.Type != "daylight_alert" {
+		t.Errorf("expected days longer than 15h in April, got %s on %s", longer.Summary, longer.Start)
+	}
+	if shorter.Summary != "Days now shorter than 9h" || shorter.Start.Month() != time.November {
+		t.Errorf("expected days shorter than 9h in November, got %s on %s", shorter.Summary, shorter.Start)
+	}
+	if !shorter.AllDay || !strings.Contains(shorter.Description, "Alert: the day length dropped below 9h") || !strings.Contains(shorter.Description, "since yesterday)") {
+		t.Errorf("unexpected description: %s", shorter.Description)
+	}
+	if longer.UID == shorter.UID {
+		t.Error("expected distinct UIDs per alert")
+	}
+
+	// The same threshold alerted in both directions
+	both := BuildDaylightAlertEvents(opts, []DaylightAlert{{Hours: 12}, {Hours: 12, Rising: true}}, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), 365)
+	if len(both) != 2 || both[0].Summary != "Days now longer than 12h" || both[1].Summary != "Days now shorter than 12h" {
+		t.Errorf("expected the equinox crossings in both directions, got %+v", both)
+	}
+}
+
+func TestFormatSignedDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		161 * time.Second:  "+2m 41s",
+		-161 * time.Second: "-2m 41s",
+		0:                  "+0m 0s",
+		90 * time.Minute:   "+1h 30m",
+	}
+	for d, want := range tests {
+		if got := formatSignedDuration(d); got != want {
+			t.Errorf("formatSignedDuration(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	// placeCandidates is how many geocoding results are compared to detect
+	// ambiguous place names
+	placeCandidates = 5
+	// ambiguousDistanceKm is how far apart two results must be to count as
+	// different places rather than, e.g., a city and its municipality
+	ambiguousDistanceKm = 50
+	// ambiguousImportance is how prominent a distant result must be, relative
+	// to the best one, to make a name ambiguous
+	ambiguousImportance = 0.8
+	// maxPinnedPlaces bounds the place store; further names are resolved but
+	// not pinned
+	maxPinnedPlaces = 50000
+)
+
+// ErrPlaceNotFound is returned by Resolve when no place matches the query
+var ErrPlaceNotFound = errors.New("place not found")
+
+// AmbiguousPlaceError is returned by Resolve when the query matches several
+// distinct places of similar prominence (e.g., "Springfield")
+type AmbiguousPlaceError struct {
+	Query      string
+	Candidates []Place
+}
+
+func (e *AmbiguousPlaceError) Error() string {
+	names := make([]string, len(e.Candidates))
+	for i, p := range e.Candidates {
+		names[i] = fmt.Sprintf("%s (%.4f, %.4f)", p.DisplayName, p.Lat, p.Lng)
+	}
+	return fmt.Sprintf("%q matches several places: %s", e.Query, strings.Join(names, "; "))
+}
+
+// PlaceStore pins the place each name resolved to the first time, so
+// calendars subscribed by place name keep the same coordinates (and event
+// UIDs) even if the geocoding results change later. Pins are persisted as
+// JSON when the store has a path.
+type PlaceStore struct {
+	mu     sync.Mutex
+	path   string
+	places map[string]Place // By lowercased, normalized query
+}
+
+// OpenPlaceStore loads the store at path, creating it on first save
+func OpenPlaceStore(path string) (*PlaceStore, error) {
+	s := &PlaceStore{path: path, places: make(map[string]Place)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.places); err != nil {
+		return nil, fmt.Errorf("invalid place store %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Get returns the place pinned for a query
+func (s *PlaceStore) Get(query string) (Place, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	place, ok := s.places[placeKey(query)]
+	return place, ok
+}
+
+// Pin stores the place a query resolved to, unless the store is full
+func (s *PlaceStore) Pin(query string, place Place) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.places) >= maxPinnedPlaces {
+		return nil
+	}
+	s.places[placeKey(query)] = place
+	if s.path == "" {
+		return nil
+	}
+	return writeJSONAtomic(s.path, s.places)
+}
+
+// placeKey returns the store key for a query
+func placeKey(query string) string {
+	return strings.ToLower(normalizePlaceQuery(query))
+}
+
+// UsePlaceStore makes the geocoder pin resolved places in store
+func (g *Geocoder) UsePlaceStore(store *PlaceStore) {
+	g.pins = store
+}
+
+// Resolve returns the place a name refers to, for use in place of
+// coordinates. The most prominent match is used, with coordinates rounded
+// to 4 decimals (about 10 m), and pinned so later calls return the same
+// place. Returns ErrPlaceNotFound if nothing matches, and an
+// *AmbiguousPlaceError if distinct places match about equally well.
+func (g *Geocoder) Resolve(query string) (Place, error) {
+	query = normalizePlaceQuery(query)
+	if place, ok := g.pins.Get(query); ok {
+		return place, nil
+	}
+
+	places, err := g.Suggest(query, placeCandidates)
+	if err != nil {
+		return Place{}, err
+	}
+	if len(places) == 0 {
+		return Place{}, ErrPlaceNotFound
+	}
+
+	best := places[0]
+	candidates := []Place{best}
+	for _, p := range places[1:] {
+		if p.Importance >= best.Importance*ambiguousImportance && distanceKm(best, p) > ambiguousDistanceKm {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) > 1 {
+		return Place{}, &AmbiguousPlaceError{Query: query, Candidates: candidates}
+	}
+
+	best.Lat = math.Round(best.Lat*1e4) / 1e4
+	best.Lng = math.Round(best.Lng*1e4) / 1e4
+	if err := g.pins.Pin(query, best); err != nil {
+		log.Printf("pin place %q: %v", query, err)
+	}
+	return best, nil
+}
+
+// distanceKm returns the great-circle distance between two places
+func distanceKm(a, b Place) float64 {
+	const earthRadiusKm = 6371
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const springfieldResponse = `[
+	{"lat": "39.7990", "lon": "-89.6440", "name": "Springfield", "display_name": "Springfield, Illinois, United States", "importance": 0.62},
+	{"lat": "37.2090", "lon": "-93.2923", "name": "Springfield", "display_name": "Springfield, Missouri, United States", "importance": 0.58},
+	{"lat": "42.1015", "lon": "-72.5898", "name": "Springfield", "display_name": "Springfield, Massachusetts, United States", "importance": 0.30}
+]`
+
+// newFixedGeocoder returns a geocoder whose upstream always responds with body
+func newFixedGeocoder(t *testing.T, body string) *Geocoder {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	g := NewGeocoder(server.URL)
+	g.minInterval = 0
+	return g
+}
+
+func TestGeocoder_Resolve(t *testing.T) {
+	var requests int32
+	g := newTestGeocoder(t, &requests)
+
+	place, err := g.Resolve("Copenhagen")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Nearby results (the city and its municipality) are not ambiguous
+	if place.Name != "Copenhagen" || place.Lat != 55.6867 || place.Lng != 12.5701 {
+		t.Errorf("expected rounded Copenhagen, got %+v", place)
+	}
+}
+
+func TestGeocoder_ResolvePinned(t *testing.T) {
+	var requests int32
+	g := newTestGeocoder(t, &requests)
+	g.pins.Pin("copenhagen", Place{Name: "Pinned", Lat: 1, Lng: 2})
+
+	place, err := g.Resolve(" Copenhagen ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if place.Name != "Pinned" {
+		t.Errorf("expected pinned place, got %+v", place)
+	}
+	if requests != 0 {
+		t.Errorf("expected no upstream request, got %d", requests)
+	}
+}
+
+func TestGeocoder_ResolveAmbiguous(t *testing.T) {
+	g := newFixedGeocoder(t, springfieldResponse)
+
+	_, err := g.Resolve("Springfield")
+	var ambiguous *AmbiguousPlaceError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("expected ambiguity error, got %v", err)
+	}
+	// The much less prominent Massachusetts result is not a candidate
+	if len(ambiguous.Candidates) != 2 {
+		t.Errorf("expected 2 candidates, got %+v", ambiguous.Candidates)
+	}
+	if !strings.Contains(err.Error(), "Missouri") {
+		t.Errorf("expected candidates in message, got %q", err.Error())
+	}
+	if _, ok := g.pins.Get("Springfield"); ok {
+		t.Error("ambiguous names should not be pinned")
+	}
+}
+
+func TestGeocoder_ResolveNotFound(t *testing.T) {
+	g := newFixedGeocoder(t, `[]`)
+
+	if _, err := g.Resolve("Nowhere"); !errors.Is(err, ErrPlaceNotFound) {
+		t.Errorf("expected ErrPlaceNotFound, got %v", err)
+	}
+}
+
+func TestPlaceStore_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "places.json")
+	store, err := OpenPlaceStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.Pin("Copenhagen", Place{Name: "Copenhagen", Lat: 55.6867, Lng: 12.5701}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reopened, err := OpenPlaceStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	place, ok := reopened.Get("COPENHAGEN")
+	if !ok || place.Lat != 55.6867 {
+		t.Errorf("expected pinned place after reopening, got %+v (%v)", place, ok)
+	}
+}
+
+func TestDistanceKm(t *testing.T) {
+	copenhagen := Place{Lat: 55.6761, Lng: 12.5683}
+	aarhus := Place{Lat: 56.1629, Lng: 10.2039}
+
+	if d := distanceKm(copenhagen, aarhus); d < 150 || d > 160 {
+		t.Errorf("expected about 156 km, got %.1f", d)
+	}
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Bright planets with rise and set events (see BuildPlanetEvents)
+const (
+	PlanetVenus   = "venus"
+	PlanetMars    = "mars"
+	PlanetJupiter = "jupiter"
+	PlanetSaturn  = "saturn"
+)
+
+// Planets lists the planets with rise and set events
+var Planets = []string{PlanetVenus, PlanetMars, PlanetJupiter, PlanetSaturn}
+
+// orbitalElements are Keplerian elements of an orbit in the J2000 ecliptic:
+// the semi-major axis (au), eccentricity, inclination, mean longitude,
+// longitude of perihelion, and longitude of the ascending node (degrees)
+type orbitalElements struct {
+	a, e, i, l, peri, node float64
+}
+
+// planetOrbits holds the elements of the planets and the Earth-moon
+// barycentre at J2000 and their rates per Julian century (Standish, "Keplerian
+// Elements for Approximate Positions of the Major Planets", JPL, valid 1800 to
+// 2050). Positions are accurate to a few arcminutes, a minute or so of rise
+// and set time.
+var planetOrbits = map[string][2]orbitalElements{
+	PlanetVenus: {
+		{0.72333566, 0.00677672, 3.39467605, 181.97909950, 131.60246718, 76.67984255},
+		{0.00000390, -0.00004107, -0.00078890, 58517.81538729, 0.00268329, -0.27769418},
+	},
+	"earth": {
+		{1.00000261, 0.01671123, -0.00001531, 100.46457166, 102.93768193, 0},
+		{0.00000562, -0.00004392, -0.01294668, 35999.37244981, 0.32327364, 0},
+	},
+	PlanetMars: {
+		{1.52371034, 0.09339410, 1.84969142, -4.55343205, -23.94362959, 49.55953891},
+		{0.00001847, 0.00007882, -0.00813131, 19140.30268499, 0.44441088, -0.29257343},
+	},
+	PlanetJupiter: {
+		{5.20288700, 0.04838624, 1.30439695, 34.39644051, 14.72847983, 100.47390909},
+		{-0.00011607, -0.00013253, -0.00183714, 3034.74612775, 0.21252668, 0.20469106},
+	},
+	PlanetSaturn: {
+		{9.53667594, 0.05386179, 2.48599187, 49.95424423, 92.59887831, 113.66242448},
+		{-0.00125060, -0.00050991, 0.00193609, 1222.49362201, -0.41897216, -0.28867794},
+	},
+}
+
+// julianCenturies returns the Julian centuries since J2000.0 at t
+func julianCenturies(t time.Time) float64 {
+	jd := float64(t.UnixNano())/float64(24*time.Hour) + 2440587.5
+	return (jd - 2451545.0) / 36525
+}
+
+// heliocentricPosition returns a body's position in the J2000 ecliptic, in
+// au, c Julian centuries after J2000.0
+func heliocentricPosition(body string, c float64) [3]float64 {
+	orbit := planetOrbits[body]
+	el := func(v, rate float64) float64 { return v + rate*c }
+	a, e := el(orbit[0].a, orbit[1].a), el(orbit[0].e, orbit[1].e)
+	i := degToRad(el(orbit[0].i, orbit[1].i))
+	l, peri := el(orbit[0].l, orbit[1].l), el(orbit[0].peri, orbit[1].peri)
+	node := degToRad(el(orbit[0].node, orbit[1].node))
+	omega := degToRad(peri) - node
+
+	// Solve Kepler's equation for the eccentric anomaly
+	m := degToRad(math.Mod(l-peri, 360))
+	ea := m + e*math.Sin(m)
+	for range 10 {
+		delta := (ea - e*math.Sin(ea) - m) / (1 - e*math.Cos(ea))
+		ea -= delta
+		if math.Abs(delta) < 1e-9 {
+			break
+		}
+	}
+	x, y := a*(math.Cos(ea)-e), a*math.Sqrt(1-e*e)*math.Sin(ea)
+
+	cw, sw, cn, sn, ci, si := math.Cos(omega), math.Sin(omega), math.Cos(node), math.Sin(node), math.Cos(i), math.Sin(i)
+	return [3]float64{
+		(cw*cn-sw*sn*ci)*x + (-sw*cn-cw*sn*ci)*y,
+		(cw*sn+sw*cn*ci)*x + (-sw*sn+cw*cn*ci)*y,
+		sw*si*x + cw*si*y,
+	}
+}
+
+// planetEquatorial returns a planet's geocentric right ascension and
+// declination (radians) at t, for the equator and equinox of date
+func planetEquatorial(planet string, t time.Time) (ra, dec float64) {
+	c := julianCenturies(t)
+	p, earth := heliocentricPosition(planet, c), heliocentricPosition("earth", c)
+	x, y, z := p[0]-earth[0], p[1]-earth[1], p[2]-earth[2]
+
+	return eclipticToEquatorial(x, y, z, c)
+}
+
+// eclipticToEquatorial converts a geocentric position in the J2000 ecliptic
+// to right ascension and declination (radians) for the equator and equinox
+// c Julian centuries after J2000.0
+func eclipticToEquatorial(x, y, z, c float64) (ra, dec float64) {
+	// Ecliptic coordinates, precessed from J2000 to the equinox of date
+	lambda := math.Atan2(y, x) + degToRad(1.396971*c)
+	beta := math.Atan2(z, math.Hypot(x, y))
+	epsilon := degToRad(23.439291 - 0.0130042*c)
+
+	ra = math.Atan2(math.Sin(lambda)*math.Cos(epsilon)-math.Tan(beta)*math.Sin(epsilon), math.Cos(lambda))
+	dec = math.Asin(math.Sin(beta)*math.Cos(epsilon) + math.Cos(beta)*math.Sin(epsilon)*math.Sin(lambda))
+	return ra, dec
+}
+
+// greenwichSiderealTime returns the Greenwich mean sidereal time at t, in
+// radians
+func greenwichSiderealTime(t time.Time) float64 {
+	days := julianCenturies(t) * 36525
+	return degToRad(math.Mod(280.46061837+360.98564736629*days, 360))
+}
+
+// PlanetPosition returns a planet's azimuth (degrees clockwise from north) and
+// elevation above the horizon (degrees, without refraction) at a given time
+// and location
+func PlanetPosition(planet string, lat, lng float64, t time.Time) (azimuth, elevation float64) {
+	ra, dec := planetEquatorial(planet, t)
+	h := greenwichSiderealTime(t) + degToRad(lng) - ra
+	phi := degToRad(lat)
+
+	elevation = radToDeg(math.Asin(math.Sin(phi)*math.Sin(dec) + math.Cos(phi)*math.Cos(dec)*math.Cos(h)))
+	azimuth = radToDeg(math.Atan2(math.Sin(h), math.Cos(h)*math.Sin(phi)-math.Tan(dec)*math.Cos(phi))) + 180
+	return math.Mod(azimuth, 360), elevation
+}
+
+// planetHorizon is the elevation of a planet's centre as it rises and sets:
+// refraction lifts it by about 34 arcminutes (planets have no visible disc)
+const planetHorizon = -0.5667
+
+// planetSearchStep is the sampling interval when searching for planet rise
+// and set; planets rise once a day, so crossings are never this close
+const planetSearchStep = 20 * time.Minute
+
+// PlanetRiseSet returns when a planet rises and sets during the local day of
+// date in tz. A zero time means it does not rise or set that day.
+func PlanetRiseSet(planet string, lat, lng float64, date time.Time, tz *time.Location) (rise, set time.Time) {
+	local := date.In(tz)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, tz)
+	end := start.AddDate(0, 0, 1)
+
+	altitude := func(t time.Time) float64 {
+		_, elevation := PlanetPosition(planet, lat, lng, t)
+		return elevation - planetHorizon
+	}
+	prev := altitude(start)
+	for t := start; t.Before(end); t = t.Add(planetSearchStep) {
+		next := t.Add(planetSearchStep)
+		if next.After(end) {
+			next = end
+		}
+		cur := altitude(next)
+		if (prev < 0) != (cur < 0) {
+			crossing := bisectCrossing(altitude, t, next)
+			if crossing.Before(end) {
+				if prev < 0 {
+					rise = crossing
+				} else {
+					set = crossing
+				}
+			}
+		}
+		prev = cur
+	}
+	return rise, set
+}
+
+// bisectCrossing narrows [low, high], over which value changes sign, down to
+// the time of its zero, to within a second
+func bisectCrossing(value func(time.Time) float64, low, high time.Time) time.Time {
+	lowNegative := value(low) < 0
+	for high.Sub(low) > time.Second {
+		mid := low.Add(high.Sub(low) / 2)
+		if (value(mid) < 0) == lowNegative {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return high.Round(time.Second)
+}
+
+// BuildPlanetEvents generates 1-minute rise and set events for the given
+// planets on the local days starting at start, with the bearing and how dark
+// the sky is, in order
+func BuildPlanetEvents(opts CalendarOptions, planets []string, start time.Time, days int) []CalendarEvent {
+	var events []CalendarEvent
+	for i := 0; i < days; i++ {
+		date := start.AddDate(0, 0, i)
+		for _, planet := range Planets {
+			if !slices.Contains(planets, planet) {
+				continue
+			}
+			rise, set := PlanetRiseSet(planet, opts.Lat, opts.Lng, date, opts.Timezone)
+			if !rise.IsZero() {
+				events = append(events, newPlanetEvent(planet, "rise", "rises", rise, opts))
+			}
+			if !set.IsZero() {
+				events = append(events, newPlanetEvent(planet, "set", "sets", set, opts))
+			}
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+	return events
+}
+
+// newPlanetEvent creates a 1-minute planet rise or set event (e.g., "Venus
+// rises 05:12 ESE")
+func newPlanetEvent(planet, event, verb string, t time.Time, opts CalendarOptions) CalendarEvent {
+	azimuth, _ := PlanetPosition(planet, opts.Lat, opts.Lng, t)
+	_, sunElevation := SunPosition(opts.Lat, opts.Lng, t)
+	name := strings.ToUpper(planet[:1]) + planet[1:]
+
+	lines := []string{
+		fmt.Sprintf("Time: %s", opts.Clock(t, "15:04")),
+		fmt.Sprintf("Location: %s", opts.Location),
+		"Coordinates: " + FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+		fmt.Sprintf("Bearing: %s (%.0f°)", CompassPoint(azimuth), azimuth),
+		"",
+		fmt.Sprintf("Sky: %s (sun %.0f° %s the horizon)", skyCondition(sunElevation), math.Abs(sunElevation), aboveOrBelow(sunElevation)),
+	}
+
+	return CalendarEvent{
+		UID:         locationUID(t, opts.Lat, opts.Lng, opts.Precision, planet+"-"+event),
+		Type:        planet + "_" + event,
+		Start:       t,
+		End:         t.Add(time.Minute),
+		Summary:     fmt.Sprintf("%s %s %s %s", name, verb, opts.Clock(t, "15:04"), CompassPoint(azimuth)),
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+	}
+}
+
+// skyCondition names the light of the sky for a sun elevation in degrees
+func skyCondition(sunElevation float64) string {
+	switch {
+	case sunElevation > -0.833:
+		return "daylight"
+	case sunElevation > -6:
+		return "civil twilight"
+	case sunElevation > -12:
+		return "nautical twilight"
+	case sunElevation > -18:
+		return "astronomical twilight"
+	}
+	return "dark"
+}
+
+// aboveOrBelow returns "above" for a non-negative elevation, else "below"
+func aboveOrBelow(elevation float64) string {
+	if elevation >= 0 {
+		return "above"
+	}
+	return "below"
+}
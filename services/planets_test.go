@@ -0,0 +1,93 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// geocentricElongation returns a planet's angle from the sun as seen from
+// the Earth, in ecliptic longitude (degrees, 0-360) and in space
+func geocentricElongation(planet string, t time.Time) (longitude, angle float64) {
+	c := julianCenturies(t)
+	p, earth := heliocentricPosition(planet, c), heliocentricPosition("earth", c)
+	g := [3]float64{p[0] - earth[0], p[1] - earth[1], p[2] - earth[2]}
+
+	longitude = math.Mod(radToDeg(math.Atan2(g[1], g[0])-math.Atan2(-earth[1], -earth[0]))+720, 360)
+	dot := -(g[0]*earth[0] + g[1]*earth[1] + g[2]*earth[2])
+	angle = radToDeg(math.Acos(dot / math.Sqrt(g[0]*g[0]+g[1]*g[1]+g[2]*g[2]) / math.Sqrt(earth[0]*earth[0]+earth[1]*earth[1]+earth[2]*earth[2])))
+	return longitude, angle
+}
+
+func TestPlanetEphemeris(t *testing.T) {
+	// Published oppositions: the planet is 180° from the sun in longitude
+	for _, opposition := range []struct {
+		planet string
+		time   time.Time
+	}{
+		{PlanetJupiter, time.Date(2024, 12, 7, 21, 0, 0, 0, time.UTC)},
+		{PlanetMars, time.Date(2025, 1, 16, 2, 0, 0, 0, time.UTC)},
+		{PlanetSaturn, time.Date(2025, 9, 21, 4, 0, 0, 0, time.UTC)},
+	} {
+		if longitude, _ := geocentricElongation(opposition.planet, opposition.time); math.Abs(longitude-180) > 0.3 {
+			t.Errorf("%s: expected opposition at %s, got %.2f° from the sun", opposition.planet, opposition.time, longitude)
+		}
+	}
+
+	// Venus' greatest eastern elongation of January 10, 2025 is 47.2°
+	if _, angle := geocentricElongation(PlanetVenus, time.Date(2025, 1, 10, 3, 0, 0, 0, time.UTC)); math.Abs(angle-47.2) > 0.3 {
+		t.Errorf("expected Venus 47.2° from the sun, got %.2f°", angle)
+	}
+}
+
+func TestPlanetRiseSet(t *testing.T) {
+	tz := GetTimezone(55.6761, 12.5683)
+	date := time.Date(2024, 12, 7, 12, 0, 0, 0, tz)
+
+	rise, set := PlanetRiseSet(PlanetJupiter, 55.6761, 12.5683, date, tz)
+	if rise.IsZero() || set.IsZero() {
+		t.Fatalf("expected Jupiter to rise and set, got %s and %s", rise, set)
+	}
+	for name, tm := range map[string]time.Time{"rise": rise, "set": set} {
+		if _, elevation := PlanetPosition(PlanetJupiter, 55.6761, 12.5683, tm); math.Abs(elevation-planetHorizon) > 0.01 {
+			t.Errorf("%s: expected Jupiter on the horizon, got %.3f°", name, elevation)
+		}
+	}
+
+	// At opposition, Jupiter rises around sunset and sets around sunrise
+	day := GetSunTimes(55.6761, 12.5683, date)
+	if d := rise.Sub(day.Sunset.Time).Abs(); d > time.Hour {
+		t.Errorf("expected Jupiter to rise near sunset %s, got %s", day.Sunset.Time.In(tz), rise.In(tz))
+	}
+	if d := set.Sub(day.Sunrise.Time).Abs(); d > time.Hour {
+		t.Errorf("expected Jupiter to set near sunrise %s, got %s", day.Sunrise.Time.In(tz), set.In(tz))
+	}
+	if azimuth, _ := PlanetPosition(PlanetJupiter, 55.6761, 12.5683, rise); azimuth < 20 || azimuth > 70 {
+		t.Errorf("expected Jupiter (declination +22°) to rise in the northeast, got %.0f°", azimuth)
+	}
+}
+
+func TestBuildPlanetEvents(t *testing.T) {
+	opts := testCalendarOptions()
+	start := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	events := BuildPlanetEvents(opts, []string{PlanetVenus}, start, 3)
+	if len(events) != 6 {
+		t.Fatalf("expected a rise and set of Venus on each of 3 days, got %d events", len(events))
+	}
+	for i, e := range events {
+		if i > 0 && e.Start.Before(events[i-1].Start) {
+			t.Errorf("event %d out of order", i)
+		}
+		if !strings.HasPrefix(e.Summary, "Venus rises ") && !strings.HasPrefix(e.Summary, "Venus sets ") {
+			t.Errorf("unexpected summary %q", e.Summary)
+		}
+	}
+
+	// The evening star sets after dusk
+	set := events[1]
+	if set.Type != "venus_set" || !strings.Contains(set.Description, "Sky: dark (sun 37° below") {
+		t.Errorf("expected Venus to set in the dark, got %s: %q", set.Type, set.Description)
+	}
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+const (
+	// plusCodeAlphabet is the Open Location Code digit alphabet
+	plusCodeAlphabet = "23456789CFGHJMPQRVWX"
+	// plusCodeSeparator follows the eighth digit of a full code
+	plusCodeSeparator = '+'
+	plusCodePadding   = '0'
+	// plusCodePairLength is how many digits are encoded as lat/lng pairs;
+	// further digits refine a 5x4 grid
+	plusCodePairLength = 10
+	maxPlusCodeLength  = 15
+)
+
+// plusCodePairResolutions are the degrees per step of each digit pair
+var plusCodePairResolutions = []float64{20, 1, 0.05, 0.0025, 0.000125}
+
+// DecodePlusCode returns the center of the area a full Open Location Code
+// (e.g., "9F7JMHG9+C8") identifies. Short codes (e.g., "MHG9+C8
+// Copenhagen") need a reference location and are rejected.
+func DecodePlusCode(code string) (lat, lng float64, err error) {
+	code = strings.ToUpper(code)
+	sep := strings.IndexByte(code, plusCodeSeparator)
+	if sep < 0 || strings.Count(code, string(plusCodeSeparator)) != 1 {
+		return 0, 0, errors.New("plus code must contain one +")
+	}
+	if sep < 8 {
+		return 0, 0, errors.New("short plus codes are not supported; use the full code")
+	}
+	if sep != 8 || len(code) == sep+2 || len(code) > maxPlusCodeLength {
+		return 0, 0, errors.New("invalid plus code format")
+	}
+
+	// Padding (e.g., "9F8F0000+") must be whole pairs ending at the separator
+	digits := code[:sep] + code[sep+1:]
+	if pad := strings.IndexByte(digits, plusCodePadding); pad >= 0 {
+		if pad%2 != 0 || pad == 0 || strings.TrimRight(code[pad:sep], "0") != "" || len(code) > sep+1 {
+			return 0, 0, errors.New("invalid plus code padding")
+		}
+		digits = digits[:pad]
+	}
+
+	values := make([]int, len(digits))
+	for i, c := range digits {
+		values[i] = strings.IndexRune(plusCodeAlphabet, c)
+		if values[i] < 0 {
+			return 0, 0, errors.New("plus code contains invalid character " + string(c))
+		}
+	}
+	if values[0] > 8 || values[1] > 17 {
+		return 0, 0, errors.New("plus code is outside the valid range")
+	}
+
+	lat, lng = -90, -180
+	latRes, lngRes := 0.0, 0.0
+	for i := 0; i < len(values) && i < plusCodePairLength; i += 2 {
+		latRes, lngRes = plusCodePairResolutions[i/2], plusCodePairResolutions[i/2]
+		lat += float64(values[i]) * latRes
+		lng += float64(values[i+1]) * lngRes
+	}
+	for _, v := range values[min(len(values), plusCodePairLength):] {
+		latRes, lngRes = latRes/5, lngRes/4
+		lat += float64(v/4) * latRes
+		lng += float64(v%4) * lngRes
+	}
+
+	return math.Min(lat+latRes/2, 90), lng + lngRes/2, nil
+}
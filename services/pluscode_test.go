@@ -0,0 +1,50 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDecodePlusCode(t *testing.T) {
+	tests := []struct {
+		code     string
+		lat, lng float64
+	}{
+		{"9F7JMHG9+C8", 55.6760625, 12.5683125},
+		{"9f7jmhg9+c8", 55.6760625, 12.5683125},
+		{"7FG49QCJ+2V", 20.3700625, 2.7821875},
+		{"8FWC2345+G6G", 48.0063125, 8.058078125},
+		{"7FG49Q00+", 20.375, 2.775},
+		{"CFX30000+", 89.5, 1.5},
+	}
+	for _, tt := range tests {
+		lat, lng, err := DecodePlusCode(tt.code)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.code, err)
+			continue
+		}
+		if math.Abs(lat-tt.lat) > 1e-9 || math.Abs(lng-tt.lng) > 1e-9 {
+			t.Errorf("%s: expected %v,%v, got %v,%v", tt.code, tt.lat, tt.lng, lat, lng)
+		}
+	}
+}
+
+func TestDecodePlusCode_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"9F7JMHG9C8",   // No separator
+		"MHG9+C8",      // Short code
+		"9F7JMHG9+C",   // Single digit after separator
+		"9F7JMHG9+C8+", // Two separators
+		"9F7JQHGA+C8",  // Invalid character
+		"XF7JMHG9+C8",  // Latitude out of range
+		"9F7J0H00+",    // Padding not ending at separator
+		"9F700000+",    // Odd padding
+		"9F7J0000+C8",  // Digits after padding
+	}
+	for _, code := range tests {
+		if _, _, err := DecodePlusCode(code); err == nil {
+			t.Errorf("%q: expected error", code)
+		}
+	}
+}
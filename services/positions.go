@@ -0,0 +1,39 @@
+package services
+
+import (
+	"math"
+	"time"
+)
+
+// SunSample is the sun's position at one point in time
+type SunSample struct {
+	Time      time.Time
+	Azimuth   float64 // Degrees clockwise from north
+	Elevation float64 // Degrees above the horizon
+}
+
+// DaySunSamples samples the sun's position every step through the local day
+// of date in tz, from midnight up to the next midnight. Days with a clock
+// change have an hour more or less of samples.
+func DaySunSamples(lat, lng float64, date time.Time, tz *time.Location, step time.Duration) []SunSample {
+	local := date.In(tz)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, tz)
+	end := start.AddDate(0, 0, 1)
+
+	samples := make([]SunSample, 0, int(end.Sub(start)/step)+1)
+	for t := start; t.Before(end); t = t.Add(step) {
+		azimuth, elevation := SunPosition(lat, lng, t)
+		samples = append(samples, SunSample{Time: t, Azimuth: azimuth, Elevation: elevation})
+	}
+	return samples
+}
+
+// ShadowLength returns the length of the shadow an upright object of height
+// casts on level ground with the sun at elevation (degrees). Reports false if
+// the sun is not above the horizon.
+func ShadowLength(height, elevation float64) (float64, bool) {
+	if elevation <= 0 {
+		return 0, false
+	}
+	return height / math.Tan(degToRad(elevation)), true
+}
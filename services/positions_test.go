@@ -0,0 +1,44 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDaySunSamples(t *testing.T) {
+	tz := GetTimezone(55.6761, 12.5683)
+
+	samples := DaySunSamples(55.6761, 12.5683, time.Date(2024, 6, 21, 15, 0, 0, 0, tz), tz, time.Hour)
+	if len(samples) != 24 {
+		t.Fatalf("expected 24 hourly samples, got %d", len(samples))
+	}
+	if got := samples[0].Time.In(tz).Format("2006-01-02 15:04"); got != "2024-06-21 00:00" {
+		t.Errorf("expected the first sample at local midnight, got %s", got)
+	}
+	// Highest around solar noon (13:10 local), due south
+	noon := samples[13]
+	if noon.Elevation < 57 || noon.Elevation > 58 || math.Abs(noon.Azimuth-180) > 10 {
+		t.Errorf("expected the sun high in the south at 13:00, got %+v", noon)
+	}
+	if samples[0].Elevation > 0 {
+		t.Error("expected the sun below the horizon at midnight")
+	}
+
+	// The spring clock change leaves out an hour
+	if n := len(DaySunSamples(55.6761, 12.5683, time.Date(2024, 3, 31, 12, 0, 0, 0, tz), tz, time.Hour)); n != 23 {
+		t.Errorf("expected 23 samples on the day clocks go forward, got %d", n)
+	}
+}
+
+func TestShadowLength(t *testing.T) {
+	if length, ok := ShadowLength(2, 45); !ok || math.Abs(length-2) > 1e-9 {
+		t.Errorf("expected a 2 m shadow at 45°, got %v, %v", length, ok)
+	}
+	if length, ok := ShadowLength(1, 30); !ok || math.Abs(length-math.Sqrt(3)) > 1e-9 {
+		t.Errorf("expected a √3 m shadow at 30°, got %v, %v", length, ok)
+	}
+	if _, ok := ShadowLength(1, -2); ok {
+		t.Error("expected no shadow with the sun below the horizon")
+	}
+}
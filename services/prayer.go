@@ -0,0 +1,166 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// PrayerMethod defines how the twilight prayers (Fajr and Isha) are computed
+type PrayerMethod struct {
+	Name        string
+	FajrAngle   float64 // Sun depression below the horizon at Fajr, in degrees
+	IshaAngle   float64 // Sun depression below the horizon at Isha, in degrees
+	IshaMinutes int     // If set, Isha is this many minutes after Maghrib instead
+}
+
+// PrayerMethods are the supported calculation methods, by ID
+var PrayerMethods = map[string]PrayerMethod{
+	"mwl":         {Name: "Muslim World League", FajrAngle: 18, IshaAngle: 17},
+	"isna":        {Name: "Islamic Society of North America", FajrAngle: 15, IshaAngle: 15},
+	"umm-al-qura": {Name: "Umm al-Qura, Makkah", FajrAngle: 18.5, IshaMinutes: 90},
+}
+
+// Asr shadow factors: the shadow of an object equals its noon shadow plus
+// the object's length (standard) or twice its length (Hanafi)
+const (
+	AsrStandard = 1
+	AsrHanafi   = 2
+)
+
+// Prayers lists the five daily prayers in order
+var Prayers = []string{"fajr", "dhuhr", "asr", "maghrib", "isha"}
+
+// PrayerTimes holds the prayer times for a day. A zero time means the time
+// could not be determined (e.g., no sunrise near the poles).
+type PrayerTimes struct {
+	Fajr    time.Time
+	Sunrise time.Time
+	Dhuhr   time.Time
+	Asr     time.Time
+	Maghrib time.Time
+	Isha    time.Time
+}
+
+// Get returns the time of the named prayer (one of Prayers)
+func (p PrayerTimes) Get(prayer string) time.Time {
+	switch prayer {
+	case "fajr":
+		return p.Fajr
+	case "dhuhr":
+		return p.Dhuhr
+	case "asr":
+		return p.Asr
+	case "maghrib":
+		return p.Maghrib
+	case "isha":
+		return p.Isha
+	}
+	return time.Time{}
+}
+
+// GetPrayerTimes computes the prayer times for the day containing date, which
+// should be local noon at the location.
+//
+// Dhuhr is at solar noon, Maghrib at sunset, and Asr when an object's shadow
+// reaches asrFactor times its length plus its noon shadow. Fajr and Isha use
+// the method's twilight angles. At high latitudes, where twilight lasts all
+// night or is very long, they are limited to a share of the night
+// proportional to the angle (the "angle-based" rule).
+func GetPrayerTimes(lat, lng float64, date time.Time, method PrayerMethod, asrFactor float64) PrayerTimes {
+	day := GetSunTimes(lat, lng, date)
+	times := day.Times
+	prayers := PrayerTimes{
+		Sunrise: times.Sunrise,
+		Dhuhr:   times.SolarNoon,
+		Maghrib: times.Sunset,
+	}
+
+	// Asr altitude from the noon zenith angle
+	noonElevation := day.NoonElevation
+	zenith := (90 - noonElevation) * math.Pi / 180
+	asrAltitude := radToDeg(math.Atan(1 / (asrFactor + math.Tan(zenith))))
+	_, prayers.Asr = altitudeTimes(lat, lng, times.SolarNoon, noonElevation, asrAltitude)
+
+	prayers.Fajr, _ = altitudeTimes(lat, lng, times.SolarNoon, noonElevation, -method.FajrAngle)
+	if method.IshaMinutes > 0 {
+		if !times.Sunset.IsZero() {
+			prayers.Isha = times.Sunset.Add(time.Duration(method.IshaMinutes) * time.Minute)
+		}
+	} else {
+		_, prayers.Isha = altitudeTimes(lat, lng, times.SolarNoon, noonElevation, -method.IshaAngle)
+	}
+
+	// High latitude adjustment
+	if !times.Sunrise.IsZero() && !times.Sunset.IsZero() {
+		night := 24*time.Hour - times.Sunset.Sub(times.Sunrise)
+		if limit := time.Duration(method.FajrAngle / 60 * float64(night)); prayers.Fajr.IsZero() || times.Sunrise.Sub(prayers.Fajr) > limit {
+			prayers.Fajr = times.Sunrise.Add(-limit).Round(time.Second)
+		}
+		if method.IshaMinutes == 0 {
+			if limit := time.Duration(method.IshaAngle / 60 * float64(night)); prayers.Isha.IsZero() || prayers.Isha.Sub(times.Sunset) > limit {
+				prayers.Isha = times.Sunset.Add(limit).Round(time.Second)
+			}
+		}
+	}
+
+	return prayers
+}
+
+// PrayerOptions configures a prayer times calendar
+type PrayerOptions struct {
+	Lat       float64
+	Lng       float64
+	Location  string
+	Timezone  *time.Location
+	MethodID  string  // Key of PrayerMethods
+	AsrFactor float64 // AsrStandard or AsrHanafi
+	Precision int     // Decimals of coordinates in descriptions and UIDs (1-4); 0 uses DefaultPrecision
+}
+
+// BuildPrayerEvents generates an event for each prayer on days days starting
+// with the day containing start
+func BuildPrayerEvents(opts PrayerOptions, start time.Time, days int) []CalendarEvent {
+	method := PrayerMethods[opts.MethodID]
+	madhab := "standard"
+	if opts.AsrFactor == AsrHanafi {
+		madhab = "Hanafi"
+	}
+
+	local := start.In(opts.Timezone)
+	noon := time.Date(local.Year(), local.Month(), local.Day(), 12, 0, 0, 0, opts.Timezone)
+
+	events := make([]CalendarEvent, 0, days*len(Prayers))
+	for i := 0; i < days; i++ {
+		day := noon.AddDate(0, 0, i)
+		times := GetPrayerTimes(opts.Lat, opts.Lng, day, method, opts.AsrFactor)
+
+		for _, prayer := range Prayers {
+			t := times.Get(prayer)
+			if t.IsZero() {
+				continue
+			}
+			title := strings.ToUpper(prayer[:1]) + prayer[1:]
+			localTime := t.In(opts.Timezone)
+
+			events = append(events, CalendarEvent{
+				UID:     locationUID(t, opts.Lat, opts.Lng, opts.Precision, prayer),
+				Type:    prayer,
+				Start:   t,
+				End:     t.Add(time.Minute),
+				Summary: fmt.Sprintf("%s %s", title, localTime.Format("15:04")),
+				Description: strings.Join([]string{
+					fmt.Sprintf("Time: %s", localTime.Format("15:04:05")),
+					fmt.Sprintf("Location: %s", opts.Location),
+					"Coordinates: " + FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+					"",
+					fmt.Sprintf("Method: %s", method.Name),
+					fmt.Sprintf("Asr: %s", madhab),
+				}, "\n"),
+				Location: opts.Location,
+			})
+		}
+	}
+	return events
+}
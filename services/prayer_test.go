@@ -0,0 +1,103 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetPrayerTimes(t *testing.T) {
+	// Makkah, Umm al-Qura: published times are about 05:17, 12:30, 15:53,
+	// 18:28, and 19:58
+	tz := time.FixedZone("AST", 3*60*60)
+	noon := time.Date(2024, 3, 10, 12, 0, 0, 0, tz)
+	times := GetPrayerTimes(21.4225, 39.8262, noon, PrayerMethods["umm-al-qura"], AsrStandard)
+
+	want := map[string]string{"fajr": "05:17", "dhuhr": "12:30", "asr": "15:53", "maghrib": "18:28", "isha": "19:58"}
+	for _, prayer := range Prayers {
+		got := times.Get(prayer).In(tz)
+		expected, _ := time.ParseInLocation("2006-01-02 15:04", "2024-03-10 "+want[prayer], tz)
+		if d := got.Sub(expected).Abs(); d > 3*time.Minute {
+			t.Errorf("%s: expected about %s, got %s", prayer, want[prayer], got.Format("15:04"))
+		}
+	}
+
+	// Isha is a fixed 90 minutes after Maghrib
+	if times.Isha.Sub(times.Maghrib) != 90*time.Minute {
+		t.Errorf("expected Isha 90 minutes after Maghrib, got %s", times.Isha.Sub(times.Maghrib))
+	}
+}
+
+func TestGetPrayerTimes_AsrMadhab(t *testing.T) {
+	noon := time.Date(2024, 3, 10, 12, 0, 0, 0, GetTimezone(40.7128, -74.0060))
+	standard := GetPrayerTimes(40.7128, -74.0060, noon, PrayerMethods["isna"], AsrStandard)
+	hanafi := GetPrayerTimes(40.7128, -74.0060, noon, PrayerMethods["isna"], AsrHanafi)
+
+	if !hanafi.Asr.After(standard.Asr.Add(30 * time.Minute)) {
+		t.Errorf("expected Hanafi Asr well after standard Asr, got %s and %s", hanafi.Asr, standard.Asr)
+	}
+	if !standard.Asr.After(standard.Dhuhr) || !hanafi.Asr.Before(hanafi.Maghrib) {
+		t.Error("expected Asr between Dhuhr and Maghrib")
+	}
+	// ISNA uses a shallower angle than MWL, so Fajr is later
+	mwl := GetPrayerTimes(40.7128, -74.0060, noon, PrayerMethods["mwl"], AsrStandard)
+	if !standard.Fajr.After(mwl.Fajr) {
+		t.Errorf("expected ISNA Fajr after MWL Fajr, got %s and %s", standard.Fajr, mwl.Fajr)
+	}
+}
+
+func TestGetPrayerTimes_HighLatitude(t *testing.T) {
+	// In London around the summer solstice the sun never gets 17° below the
+	// horizon, so Fajr and Isha use the angle-based rule
+	tz := GetTimezone(51.5074, -0.1278)
+	noon := time.Date(2024, 6, 21, 12, 0, 0, 0, tz)
+	times := GetPrayerTimes(51.5074, -0.1278, noon, PrayerMethods["mwl"], AsrStandard)
+
+	if times.Fajr.IsZero() || times.Isha.IsZero() {
+		t.Fatal("expected adjusted Fajr and Isha")
+	}
+	night := 24*time.Hour - times.Maghrib.Sub(times.Sunrise)
+	if d := times.Sunrise.Sub(times.Fajr) - time.Duration(18.0/60*float64(night)); d.Abs() > time.Second {
+		t.Errorf("expected Fajr 18/60 of the night before sunrise, off by %s", d)
+	}
+	if !times.Isha.Before(times.Fajr.AddDate(0, 0, 1)) {
+		t.Error("expected Isha before the next Fajr")
+	}
+}
+
+func TestBuildPrayerEvents(t *testing.T) {
+	tz := GetTimezone(21.4225, 39.8262)
+	events := BuildPrayerEvents(PrayerOptions{
+		Lat:       21.4225,
+		Lng:       39.8262,
+		Location:  "Makkah",
+		Timezone:  tz,
+		MethodID:  "umm-al-qura",
+		AsrFactor: AsrHanafi,
+	}, time.Date(2024, 3, 10, 0, 0, 0, 0, tz), 2)
+
+	if len(events) != 10 {
+		t.Fatalf("expected 10 events, got %d", len(events))
+	}
+	for i, prayer := range Prayers {
+		if events[i].Type != prayer {
+			t.Errorf("event %d: expected %s, got %s", i, prayer, events[i].Type)
+		}
+	}
+	if !strings.HasPrefix(events[0].Summary, "Fajr 05:") {
+		t.Errorf("unexpected summary: %s", events[0].Summary)
+	}
+	if !strings.Contains(events[2].Description, "Method: Umm al-Qura, Makkah") || !strings.Contains(events[2].Description, "Asr: Hanafi") {
+		t.Errorf("unexpected description: %s", events[2].Description)
+	}
+	if events[0].UID == events[5].UID {
+		t.Error("expected unique UIDs per day")
+	}
+}
+
+func BenchmarkGetPrayerTimes(b *testing.B) {
+	noon := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < b.N; i++ {
+		GetPrayerTimes(21.4225, 39.8262, noon, PrayerMethods["mwl"], AsrStandard)
+	}
+}
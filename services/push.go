@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxPushBefore is the most minutes before sunrise or sunset an alert
+	// may be sent
+	MaxPushBefore = 120
+
+	// maxPushSubscriptions bounds the stored subscriptions, each of which
+	// costs requests every day
+	maxPushSubscriptions = 10000
+
+	// pushWindow is how long after its time a missed alert is still sent,
+	// e.g. after a restart
+	pushWindow = 5 * time.Minute
+)
+
+// ErrTooManyPushSubscriptions is returned when the subscription limit is
+// reached
+var ErrTooManyPushSubscriptions = errors.New("too many push subscriptions")
+
+// PushEvents lists the events push alerts can be sent before
+var PushEvents = []string{"sunrise", "sunset"}
+
+// PushSubscription is a browser's Web Push subscription with the alerts it
+// asked for. The ID is derived from the endpoint, so subscribing again
+// replaces the alerts.
+type PushSubscription struct {
+	ID        string            `json:"id"`
+	Endpoint  string            `json:"endpoint"`
+	P256dh    string            `json:"p256dh"` // Browser's public key, base64url
+	Auth      string            `json:"auth"`   // Authentication secret, base64url
+	Lat       float64           `json:"lat"`
+	Lng       float64           `json:"lng"`
+	Name      string            `json:"name"`
+	Events    []string          `json:"events"` // "sunrise" and/or "sunset"
+	Before    int               `json:"before"` // Minutes before the event
+	BaseURL   string            `json:"base_url"`
+	CreatedAt time.Time         `json:"created_at"`
+	LastSent  map[string]string `json:"last_sent,omitempty"` // Event to the local date of its last alert
+}
+
+// pushSubscriptionID returns the ID of the subscription with an endpoint
+func pushSubscriptionID(endpoint string) string {
+	sum := sha256.Sum256([]byte(endpoint))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Validate checks the subscription's endpoint, keys, and alerts
+func (s PushSubscription) Validate() error {
+	u, err := url.Parse(s.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("invalid endpoint: must be an https URL")
+	}
+	if _, _, err := decodePushKeys(s.P256dh, s.Auth); err != nil {
+		return err
+	}
+	if len(s.Events) == 0 {
+		return errors.New("no events to alert before")
+	}
+	for _, event := range s.Events {
+		if !slices.Contains(PushEvents, event) {
+			return fmt.Errorf("invalid event %q", event)
+		}
+	}
+	if s.Before < 0 || s.Before > MaxPushBefore {
+		return fmt.Errorf("before must be between 0 and %d minutes", MaxPushBefore)
+	}
+	return nil
+}
+
+// PushStore persists push subscriptions to a JSON file
+type PushStore struct {
+	mu   sync.Mutex
+	path string
+	subs map[string]PushSubscription
+}
+
+// OpenPushStore loads the store at path, creating it on first save
+func OpenPushStore(path string) (*PushStore, error) {
+	s := &PushStore{path: path, subs: make(map[string]PushSubscription)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var subs []PushSubscription
+	if err := json.Unmarshal(data, &subs); err != nil {
+		return nil, fmt.Errorf("invalid push store %s: %w", path, err)
+	}
+	for _, sub := range subs {
+		s.subs[sub.ID] = sub
+	}
+	return s, nil
+}
+
+// List returns all subscriptions, ordered by ID
+func (s *PushStore) List() []PushSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sortedLocked()
+}
+
+// Put adds or replaces a subscription and saves the store. New
+// subscriptions are refused once the store holds maxPushSubscriptions.
+func (s *PushStore) Put(sub PushSubscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.subs[sub.ID]; !exists && len(s.subs) >= maxPushSubscriptions {
+		return ErrTooManyPushSubscriptions
+	}
+	s.subs[sub.ID] = sub
+	return writeJSONAtomic(s.path, s.sortedLocked())
+}
+
+// Delete removes a subscription and saves the store. Returns false if no
+// subscription had the given ID.
+func (s *PushStore) Delete(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[id]; !ok {
+		return false, nil
+	}
+	delete(s.subs, id)
+	return true, writeJSONAtomic(s.path, s.sortedLocked())
+}
+
+// sortedLocked returns the subscriptions ordered by ID. The caller must hold
+// s.mu.
+func (s *PushStore) sortedLocked() []PushSubscription {
+	subs := make([]PushSubscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].ID < subs[j].ID })
+	return subs
+}
+
+// PushSender delivers push messages to browsers (see WebPush)
+type PushSender interface {
+	PublicKey() string
+	Send(ctx context.Context, sub PushSubscription, payload []byte, ttl time.Duration) error
+}
+
+// PushNotification is the payload of an alert, shown by the service worker
+type PushNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Tag   string `json:"tag"` // Replaces an earlier notification with the same tag
+	URL   string `json:"url"` // Opened when the notification is clicked
+}
+
+// PushNotifier manages push subscriptions and sends their alerts. Call Tick
+// regularly (e.g., every minute).
+type PushNotifier struct {
+	store  *PushStore
+	sender PushSender
+	now    Clock
+}
+
+// NewPushNotifier creates a notifier backed by store
+func NewPushNotifier(store *PushStore, sender PushSender) *PushNotifier {
+	return &PushNotifier{store: store, sender: sender, now: time.Now}
+}
+
+// PublicKey returns the key browsers subscribe with
+func (p *PushNotifier) PublicKey() string {
+	return p.sender.PublicKey()
+}
+
+// Subscriptions returns all stored subscriptions
+func (p *PushNotifier) Subscriptions() []PushSubscription {
+	return p.store.List()
+}
+
+// Subscribe validates and stores a subscription, replacing any with the same
+// endpoint
+func (p *PushNotifier) Subscribe(sub PushSubscription) (PushSubscription, error) {
+	if err := sub.Validate(); err != nil {
+		return PushSubscription{}, err
+	}
+	sub.ID = pushSubscriptionID(sub.Endpoint)
+	sub.CreatedAt = p.now()
+	sub.LastSent = nil
+	return sub, p.store.Put(sub)
+}
+
+// Unsubscribe deletes the subscription with an endpoint
+func (p *PushNotifier) Unsubscribe(endpoint string) (bool, error) {
+	return p.store.Delete(pushSubscriptionID(endpoint))
+}
+
+// Tick sends the alerts that are due, deleting subscriptions the push
+// service reports gone
+func (p *PushNotifier) Tick(ctx context.Context) {
+	now := p.now()
+	for _, sub := range p.store.List() {
+		if ctx.Err() != nil {
+			return
+		}
+
+		tz := GetTimezone(sub.Lat, sub.Lng)
+		local := now.In(tz)
+		// Tomorrow's early sunrise may be alerted before midnight
+		for offset := range 2 {
+			noon := time.Date(local.Year(), local.Month(), local.Day()+offset, 12, 0, 0, 0, tz)
+			day := GetSunTimes(sub.Lat, sub.Lng, noon)
+			for _, event := range []*SunEvent{day.Sunrise, day.Sunset} {
+				if event == nil || !slices.Contains(sub.Events, event.Type) {
+					continue
+				}
+				alert := event.Time.Add(-time.Duration(sub.Before) * time.Minute)
+				date := noon.Format("2006-01-02")
+				if now.Before(alert) || !now.Before(alert.Add(pushWindow)) || sub.LastSent[event.Type] == date {
+					continue
+				}
+				p.alert(ctx, &sub, event, date, tz)
+			}
+		}
+	}
+}
+
+// alert records and sends an alert for an event on a local date
+func (p *PushNotifier) alert(ctx context.Context, sub *PushSubscription, event *SunEvent, date string, tz *time.Location) {
+	// Record the alert first so a failing push service doesn't cause a retry
+	// every tick
+	if sub.LastSent == nil {
+		sub.LastSent = make(map[string]string)
+	}
+	sub.LastSent[event.Type] = date
+	if err := p.store.Put(*sub); err != nil {
+		log.Printf("push alert %s: %v", sub.ID[:8], err)
+		return
+	}
+
+	payload, err := json.Marshal(newPushNotification(*sub, event, tz))
+	if err != nil {
+		return
+	}
+	ttl := max(event.Time.Sub(p.now()), time.Minute)
+	switch err := p.sender.Send(ctx, *sub, payload, ttl); {
+	case errors.Is(err, ErrPushGone):
+		p.store.Delete(sub.ID)
+	case err != nil:
+		log.Printf("push alert %s: %v", sub.ID[:8], err)
+	}
+}
+
+// newPushNotification creates the alert for an event (e.g., "Sunset in 15
+// minutes", "Sunset at 18:42 WSW in Copenhagen")
+func newPushNotification(sub PushSubscription, event *SunEvent, tz *time.Location) PushNotification {
+	name := strings.ToUpper(event.Type[:1]) + event.Type[1:]
+	title := fmt.Sprintf("%s in %d minutes", name, sub.Before)
+	switch sub.Before {
+	case 0:
+		title = name + " now"
+	case 1:
+		title = name + " in 1 minute"
+	}
+	return PushNotification{
+		Title: title,
+		Body:  fmt.Sprintf("%s at %s %s in %s", name, event.Time.In(tz).Format("15:04"), CompassPoint(event.Azimuth), locationLabel(sub.Name, sub.Lat, sub.Lng)),
+		Tag:   "calsun-" + event.Type,
+		URL:   sub.BaseURL + "/",
+	}
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakePushSender records sent notifications, failing with err
+type fakePushSender struct {
+	sent []PushNotification
+	ttls []time.Duration
+	err  error
+}
+
+func (f *fakePushSender) PublicKey() string { return "test-key" }
+
+func (f *fakePushSender) Send(ctx context.Context, sub PushSubscription, payload []byte, ttl time.Duration) error {
+	var n PushNotification
+	json.Unmarshal(payload, &n)
+	f.sent = append(f.sent, n)
+	f.ttls = append(f.ttls, ttl)
+	return f.err
+}
+
+// testPushSubscription returns a valid subscription for Copenhagen
+func testPushSubscription(t *testing.T) PushSubscription {
+	uaPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return PushSubscription{
+		Endpoint: "https://push.example/send/abc",
+		P256dh:   pushEncoding.EncodeToString(uaPrivate.PublicKey().Bytes()),
+		Auth:     "BTBZMqHH6r4Tts7J_aSIgg",
+		Lat:      55.6761,
+		Lng:      12.5683,
+		Name:     "Copenhagen",
+		Events:   []string{"sunset"},
+		Before:   15,
+		BaseURL:  "https://calsun.example",
+	}
+}
+
+// newTestPushNotifier creates a notifier with a fresh store and fake sender
+func newTestPushNotifier(t *testing.T) (*PushNotifier, *fakePushSender) {
+	store, err := OpenPushStore(filepath.Join(t.TempDir(), "push.json"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	sender := &fakePushSender{}
+	return NewPushNotifier(store, sender), sender
+}
+
+func TestPushSubscription_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*PushSubscription)
+		err    string
+	}{
+		{"valid", func(*PushSubscription) {}, ""},
+		{"http endpoint", func(s *PushSubscription) { s.Endpoint = "http://push.example/abc" }, "invalid endpoint"},
+		{"bad key", func(s *PushSubscription) { s.P256dh = "abc" }, "invalid p256dh key"},
+		{"no events", func(s *PushSubscription) { s.Events = nil }, "no events"},
+		{"unknown event", func(s *PushSubscription) { s.Events = []string{"noon"} }, `invalid event "noon"`},
+		{"too early", func(s *PushSubscription) { s.Before = 121 }, "before must be between 0 and 120"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := testPushSubscription(t)
+			tt.modify(&sub)
+			err := sub.Validate()
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestPushNotifier_Subscribe(t *testing.T) {
+	p, _ := newTestPushNotifier(t)
+	sub := testPushSubscription(t)
+	if _, err := p.Subscribe(sub); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Subscribing again replaces the alerts
+	sub.Events = []string{"sunrise", "sunset"}
+	if _, err := p.Subscribe(sub); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	subs := p.store.List()
+	if len(subs) != 1 || len(subs[0].Events) != 2 {
+		t.Fatalf("expected one subscription with both events, got %+v", subs)
+	}
+
+	// The store is saved
+	store, err := OpenPushStore(p.store.path)
+	if err != nil || len(store.List()) != 1 {
+		t.Fatalf("expected the subscription to be saved, got %v", err)
+	}
+
+	if ok, err := p.Unsubscribe(sub.Endpoint); !ok || err != nil {
+		t.Errorf("expected to unsubscribe, got %v %v", ok, err)
+	}
+	if len(p.store.List()) != 0 {
+		t.Error("expected no subscriptions")
+	}
+}
+
+func TestPushNotifier_Tick(t *testing.T) {
+	p, sender := newTestPushNotifier(t)
+	if _, err := p.Subscribe(testPushSubscription(t)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Sunset in Copenhagen on 2024-06-21 is just after 21:58 CEST (19:58 UTC)
+	tick := func(utc string) {
+		p.now = func() time.Time {
+			t, _ := time.Parse(time.RFC3339, utc)
+			return t
+		}
+		p.Tick(context.Background())
+	}
+	tick("2024-06-21T19:40:00Z")
+	if len(sender.sent) != 0 {
+		t.Fatalf("expected no alert 18 minutes before sunset, got %+v", sender.sent)
+	}
+	tick("2024-06-21T19:43:00Z")
+	tick("2024-06-21T19:44:00Z")
+	if len(sender.sent) != 1 {
+		t.Fatalf("expected one alert 15 minutes before sunset, got %d", len(sender.sent))
+	}
+	n := sender.sent[0]
+	if n.Title != "Sunset in 15 minutes" || n.Body != "Sunset at 21:58 NW in Copenhagen" || n.URL != "https://calsun.example/" || n.Tag != "calsun-sunset" {
+		t.Errorf("unexpected notification: %+v", n)
+	}
+	if sender.ttls[0] < 14*time.Minute || sender.ttls[0] > 15*time.Minute {
+		t.Errorf("expected the message kept until sunset, got %v", sender.ttls[0])
+	}
+
+	// Too late for a missed alert
+	tick("2024-06-22T19:55:00Z")
+	if len(sender.sent) != 1 {
+		t.Errorf("expected no alert past the window, got %d", len(sender.sent))
+	}
+}
+
+func TestPushNotifier_TickGone(t *testing.T) {
+	p, sender := newTestPushNotifier(t)
+	sender.err = ErrPushGone
+	if _, err := p.Subscribe(testPushSubscription(t)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p.now = func() time.Time { return time.Date(2024, 6, 21, 19, 44, 0, 0, time.UTC) }
+	p.Tick(context.Background())
+	if len(sender.sent) != 1 || len(p.store.List()) != 0 {
+		t.Errorf("expected the gone subscription deleted after the alert, got %d sent", len(sender.sent))
+	}
+}
+
+func TestNewPushNotification(t *testing.T) {
+	sub := testPushSubscription(t)
+	sub.Name = ""
+	event := &SunEvent{Type: "sunrise", Time: time.Date(2024, 6, 21, 2, 25, 0, 0, time.UTC), Azimuth: 48}
+	tz, _ := time.LoadLocation("Europe/Copenhagen")
+
+	for before, title := range map[int]string{0: "Sunrise now", 1: "Sunrise in 1 minute", 30: "Sunrise in 30 minutes"} {
+		sub.Before = before
+		if n := newPushNotification(sub, event, tz); n.Title != title {
+			t.Errorf("expected %q, got %q", title, n.Title)
+		}
+	}
+	if n := newPushNotification(sub, event, tz); n.Body != "Sunrise at 04:25 NE in 55.6761, 12.5683" {
+		t.Errorf("unexpected body: %q", n.Body)
+	}
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestQRCodePNG(t *testing.T) {
+	data, err := QRCodePNG("webcal://example.com/calendar.ics?lat=55.6761&lng=12.5683", 256)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("expected valid PNG: %v", err)
+	}
+	if img.Bounds().Dx() != 256 || img.Bounds().Dy() != 256 {
+		t.Errorf("expected 256x256 image, got %dx%d", img.Bounds().Dx(), img.Bounds().Dy())
+	}
+}
+
+func TestQRCodeSVG(t *testing.T) {
+	svg, err := QRCodeSVG("webcal://example.com/calendar.ics?lat=55.6761&lng=12.5683", 200)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(svg, "</svg>") {
+		t.Error("expected a complete SVG document")
+	}
+	if !strings.Contains(svg, `width="200"`) {
+		t.Error("expected requested width")
+	}
+	if !strings.Contains(svg, "h1v1h-1z") {
+		t.Error("expected dark modules to be drawn")
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RamadanOptions configures a Ramadan suhoor and iftar calendar
+type RamadanOptions struct {
+	Lat         float64
+	Lng         float64
+	Location    string
+	Timezone    *time.Location
+	MethodID    string // Key of PrayerMethods, for Fajr
+	HijriAdjust int    // Days to shift the tabular Hijri calendar by, to match local moon sighting
+	Precision   int    // Decimals of coordinates in descriptions and UIDs (1-4); 0 uses DefaultPrecision
+}
+
+// RamadanDates returns the local dates of the first day of Ramadan and of Eid
+// al-Fitr (the day after Ramadan) for the Ramadan in progress at now, or the
+// next one if none is. Dates are at midnight in tz.
+func RamadanDates(now time.Time, tz *time.Location, adjust int) (start, eid time.Time) {
+	local := now.In(tz)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, tz)
+
+	year := ToHijri(today.AddDate(0, 0, adjust)).Year
+	if ToHijri(today.AddDate(0, 0, adjust)).Month > Ramadan {
+		year++
+	}
+
+	toLocal := func(h HijriDate) time.Time {
+		d := FromHijri(h).AddDate(0, 0, -adjust)
+		return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, tz)
+	}
+	return toLocal(HijriDate{year, Ramadan, 1}), toLocal(HijriDate{year, Ramadan + 1, 1})
+}
+
+// BuildRamadanEvents generates a suhoor (end of the pre-dawn meal, at Fajr)
+// and an iftar (breaking the fast, at Maghrib) event for each day of the
+// Ramadan in progress at now, or the next one. Descriptions count down the
+// fast and the days to Eid.
+func BuildRamadanEvents(opts RamadanOptions, now time.Time) []CalendarEvent {
+	method := PrayerMethods[opts.MethodID]
+	start, eid := RamadanDates(now, opts.Timezone, opts.HijriAdjust)
+
+	var events []CalendarEvent
+	for day := start; day.Before(eid); day = day.AddDate(0, 0, 1) {
+		noon := day.Add(12 * time.Hour)
+		times := GetPrayerTimes(opts.Lat, opts.Lng, noon, method, AsrStandard)
+		hijri := ToHijri(day.AddDate(0, 0, opts.HijriAdjust))
+		daysToEid := int(eid.Sub(day).Hours()/24 + 0.5)
+
+		countdown := fmt.Sprintf("Day %d of Ramadan (%s)", hijri.Day, hijri)
+		if daysToEid == 1 {
+			countdown += "\nEid al-Fitr is tomorrow"
+		} else {
+			countdown += fmt.Sprintf("\nEid al-Fitr in %d days", daysToEid)
+		}
+
+		var fast string
+		if !times.Fajr.IsZero() && !times.Maghrib.IsZero() {
+			fast = fmt.Sprintf("Fast: %s (%s to %s)", FormatDuration(times.Maghrib.Sub(times.Fajr)),
+				times.Fajr.In(opts.Timezone).Format("15:04"), times.Maghrib.In(opts.Timezone).Format("15:04"))
+		}
+
+		if !times.Fajr.IsZero() {
+			events = append(events, newRamadanEvent("suhoor", "Suhoor ends", times.Fajr, opts, fast, countdown))
+		}
+		if !times.Maghrib.IsZero() {
+			events = append(events, newRamadanEvent("iftar", "Iftar", times.Maghrib, opts, fast, countdown))
+		}
+	}
+	return events
+}
+
+// newRamadanEvent creates a 1-minute event of the given type
+func newRamadanEvent(eventType, title string, t time.Time, opts RamadanOptions, fast, countdown string) CalendarEvent {
+	localTime := t.In(opts.Timezone)
+	lines := []string{
+		fmt.Sprintf("Time: %s", localTime.Format("15:04")),
+		fmt.Sprintf("Location: %s", opts.Location),
+		"Coordinates: " + FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+		"",
+	}
+	if fast != "" {
+		lines = append(lines, fast)
+	}
+	lines = append(lines, countdown)
+
+	return CalendarEvent{
+		UID:         locationUID(t, opts.Lat, opts.Lng, opts.Precision, eventType),
+		Type:        eventType,
+		Start:       t,
+		End:         t.Add(time.Minute),
+		Summary:     fmt.Sprintf("%s %s", title, localTime.Format("15:04")),
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+	}
+}
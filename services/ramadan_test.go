@@ -0,0 +1,62 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRamadanDates(t *testing.T) {
+	tz := GetTimezone(21.4225, 39.8262)
+	tests := []struct {
+		now    time.Time
+		adjust int
+		start  string
+		eid    string
+	}{
+		// Before, during, and after Ramadan 1445
+		{time.Date(2024, 1, 15, 12, 0, 0, 0, tz), 0, "2024-03-11", "2024-04-10"},
+		{time.Date(2024, 3, 20, 12, 0, 0, 0, tz), 0, "2024-03-11", "2024-04-10"},
+		{time.Date(2024, 4, 10, 12, 0, 0, 0, tz), 0, "2025-03-01", "2025-03-31"},
+		// Sighting a day later shifts both dates
+		{time.Date(2024, 1, 15, 12, 0, 0, 0, tz), -1, "2024-03-12", "2024-04-11"},
+	}
+
+	for _, tt := range tests {
+		start, eid := RamadanDates(tt.now, tz, tt.adjust)
+		if start.Format("2006-01-02") != tt.start || eid.Format("2006-01-02") != tt.eid {
+			t.Errorf("%s (adjust %d): got %s to %s, want %s to %s", tt.now.Format("2006-01-02"), tt.adjust,
+				start.Format("2006-01-02"), eid.Format("2006-01-02"), tt.start, tt.eid)
+		}
+		if start.Location() != tz || start.Hour() != 0 {
+			t.Errorf("expected local midnight, got %s", start)
+		}
+	}
+}
+
+func TestBuildRamadanEvents(t *testing.T) {
+	tz := GetTimezone(21.4225, 39.8262)
+	opts := RamadanOptions{Lat: 21.4225, Lng: 39.8262, Location: "Makkah", Timezone: tz, MethodID: "umm-al-qura"}
+
+	events := BuildRamadanEvents(opts, time.Date(2024, 2, 1, 12, 0, 0, 0, tz))
+	if len(events) != 60 {
+		t.Fatalf("expected 60 events for a 30 day Ramadan, got %d", len(events))
+	}
+
+	suhoor, iftar := events[0], events[1]
+	if suhoor.Type != "suhoor" || iftar.Type != "iftar" {
+		t.Errorf("unexpected types: %s, %s", suhoor.Type, iftar.Type)
+	}
+	if suhoor.Start.In(tz).Format("2006-01-02") != "2024-03-11" {
+		t.Errorf("expected the first suhoor on March 11, got %s", suhoor.Start.In(tz))
+	}
+	if !strings.HasPrefix(suhoor.Summary, "Suhoor ends 05:") || !strings.HasPrefix(iftar.Summary, "Iftar 18:") {
+		t.Errorf("unexpected summaries: %s, %s", suhoor.Summary, iftar.Summary)
+	}
+	if !strings.Contains(iftar.Description, "Fast: 13h") || !strings.Contains(iftar.Description, "Day 1 of Ramadan (1 Ramadan 1445 AH)") || !strings.Contains(iftar.Description, "Eid al-Fitr in 30 days") {
+		t.Errorf("unexpected description: %s", iftar.Description)
+	}
+	if last := events[len(events)-1]; !strings.Contains(last.Description, "Eid al-Fitr is tomorrow") {
+		t.Errorf("unexpected last description: %s", last.Description)
+	}
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// PassPredictionWindow is how far from its TLE's epoch a satellite's passes
+// are predicted. Orbits are fitted to recent tracking and drift (and the ISS
+// reboosts), so predictions degrade after a week or so.
+const PassPredictionWindow = 7 * 24 * time.Hour
+
+// passMinElevation is the highest elevation (degrees) a pass must reach to
+// get an event; lower passes are lost in haze and behind buildings
+const passMinElevation = 10
+
+// passSearchStep is the sampling interval when searching for passes; passes
+// of low satellites last several minutes
+const passSearchStep = 30 * time.Second
+
+// passVisibilityStep is the sampling interval when finding when a pass is
+// visible
+const passVisibilityStep = 10 * time.Second
+
+// SatellitePass is a satellite's pass over a location, from rise to set.
+// The visible part is while the satellite is sunlit and the sky is dark
+// (the sun at least 6° below the horizon); its times are zero if it is never
+// visible.
+type SatellitePass struct {
+	Rise, Max, Set           time.Time
+	MaxElevation             float64 // Degrees
+	VisibleStart, VisibleEnd time.Time
+}
+
+// Visible reports whether any part of the pass is visible
+func (p SatellitePass) Visible() bool {
+	return !p.VisibleStart.IsZero()
+}
+
+// VisibleMax returns when the satellite is highest while visible: the
+// highest point of the pass, or the nearer end of the visible part
+func (p SatellitePass) VisibleMax() time.Time {
+	switch {
+	case p.Max.Before(p.VisibleStart):
+		return p.VisibleStart
+	case p.Max.After(p.VisibleEnd):
+		return p.VisibleEnd
+	}
+	return p.Max
+}
+
+// LookAngles returns the satellite's azimuth (degrees clockwise from north)
+// and elevation (degrees) from a location on the ground at t
+func (s *Satellite) LookAngles(lat, lng float64, t time.Time) (azimuth, elevation float64, err error) {
+	position, _, err := s.Propagate(t)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Rotate from TEME to Earth-fixed coordinates by the sidereal time
+	theta := greenwichSiderealTime(t)
+	x := math.Cos(theta)*position[0] + math.Sin(theta)*position[1]
+	y := -math.Sin(theta)*position[0] + math.Cos(theta)*position[1]
+	z := position[2]
+
+	// The observer on the WGS 84 ellipsoid
+	const a, f = 6378.137, 1 / 298.257223563
+	phi, lambda := degToRad(lat), degToRad(lng)
+	e2 := f * (2 - f)
+	n := a / math.Sqrt(1-e2*math.Sin(phi)*math.Sin(phi))
+	dx := x - n*math.Cos(phi)*math.Cos(lambda)
+	dy := y - n*math.Cos(phi)*math.Sin(lambda)
+	dz := z - n*(1-e2)*math.Sin(phi)
+
+	// Topocentric south, east, and zenith components
+	south := math.Sin(phi)*math.Cos(lambda)*dx + math.Sin(phi)*math.Sin(lambda)*dy - math.Cos(phi)*dz
+	east := -math.Sin(lambda)*dx + math.Cos(lambda)*dy
+	zenith := math.Cos(phi)*math.Cos(lambda)*dx + math.Cos(phi)*math.Sin(lambda)*dy + math.Sin(phi)*dz
+	distance := math.Sqrt(south*south + east*east + zenith*zenith)
+
+	azimuth = math.Mod(radToDeg(math.Atan2(east, -south))+360, 360)
+	elevation = radToDeg(math.Asin(zenith / distance))
+	return azimuth, elevation, nil
+}
+
+// sunlit reports whether the satellite is outside the Earth's shadow at t,
+// taking the shadow as a cylinder
+func (s *Satellite) sunlit(t time.Time) bool {
+	position, _, err := s.Propagate(t)
+	if err != nil {
+		return false
+	}
+	c := julianCenturies(t)
+	earth := heliocentricPosition("earth", c)
+	ra, dec := eclipticToEquatorial(-earth[0], -earth[1], -earth[2], c)
+	sun := [3]float64{math.Cos(dec) * math.Cos(ra), math.Cos(dec) * math.Sin(ra), math.Sin(dec)}
+
+	along := position[0]*sun[0] + position[1]*sun[1] + position[2]*sun[2]
+	if along > 0 {
+		return true
+	}
+	across := [3]float64{position[0] - along*sun[0], position[1] - along*sun[1], position[2] - along*sun[2]}
+	return math.Sqrt(across[0]*across[0]+across[1]*across[1]+across[2]*across[2]) > sgp4EarthRadius
+}
+
+// Passes returns the satellite's passes over a location that rise from from
+// until to, in order. A pass in progress at from is left out.
+func (s *Satellite) Passes(lat, lng float64, from, to time.Time) []SatellitePass {
+	elevation := func(t time.Time) float64 {
+		_, el, err := s.LookAngles(lat, lng, t)
+		if err != nil {
+			return -90
+		}
+		return el
+	}
+
+	var passes []SatellitePass
+	prev := elevation(from)
+	for t := from; t.Before(to); t = t.Add(passSearchStep) {
+		next := t.Add(passSearchStep)
+		cur := elevation(next)
+		if prev < 0 && cur >= 0 {
+			pass := SatellitePass{Rise: bisectCrossing(elevation, t, next)}
+			// Follow the pass until it sets
+			peak, peakElevation := next, cur
+			for cur >= 0 {
+				t, next = next, next.Add(passSearchStep)
+				if cur = elevation(next); cur > peakElevation {
+					peak, peakElevation = next, cur
+				}
+			}
+			pass.Set = bisectCrossing(elevation, t, next)
+			pass.Max = findMoonExtreme(elevation, peak.Add(-passSearchStep), peak.Add(passSearchStep), 1)
+			pass.MaxElevation = elevation(pass.Max)
+			pass.VisibleStart, pass.VisibleEnd = s.visibleSpan(lat, lng, pass)
+			if pass.Rise.Before(to) {
+				passes = append(passes, pass)
+			}
+		}
+		prev = cur
+	}
+	return passes
+}
+
+// visibleSpan returns the first and last time during a pass that the
+// satellite is sunlit against a dark sky, or zero times
+func (s *Satellite) visibleSpan(lat, lng float64, pass SatellitePass) (start, end time.Time) {
+	for t := pass.Rise; !t.After(pass.Set); t = t.Add(passVisibilityStep) {
+		if _, sunElevation := SunPosition(lat, lng, t); sunElevation < -6 && s.sunlit(t) {
+			if start.IsZero() {
+				start = t
+			}
+			end = t
+		}
+	}
+	return start, end
+}
+
+// BuildSatellitePassEvents generates an event for each pass of a satellite
+// that is visible at 10° or more above the horizon on the local days starting at start, named
+// with label (e.g., "ISS pass 21:14, max 67° (WSW to ENE)"). Passes are only
+// predicted within PassPredictionWindow of the orbit's epoch.
+func BuildSatellitePassEvents(opts CalendarOptions, sat *Satellite, label string, start time.Time, days int) []CalendarEvent {
+	from := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, opts.Timezone)
+	to := from.AddDate(0, 0, days)
+	if earliest := sat.Epoch.Add(-PassPredictionWindow); from.Before(earliest) {
+		from = earliest
+	}
+	if latest := sat.Epoch.Add(PassPredictionWindow); to.After(latest) {
+		to = latest
+	}
+	if !from.Before(to) {
+		return nil
+	}
+
+	var events []CalendarEvent
+	var day string
+	var seq int
+	for _, pass := range sat.Passes(opts.Lat, opts.Lng, from, to) {
+		if !pass.Visible() {
+			continue
+		}
+		if _, elevation, _ := sat.LookAngles(opts.Lat, opts.Lng, pass.VisibleMax()); elevation < passMinElevation {
+			continue
+		}
+		// UIDs number the day's visible passes, so they survive the small
+		// shifts of updated orbits
+		if d := pass.VisibleStart.In(opts.Timezone).Format("2006-01-02"); d != day {
+			day, seq = d, 0
+		}
+		seq++
+		events = append(events, newSatellitePassEvent(sat, label, pass, seq, opts))
+	}
+	return events
+}
+
+// newSatellitePassEvent creates an event lasting the visible part of a pass,
+// noting where the satellite appears, is highest, and disappears
+func newSatellitePassEvent(sat *Satellite, label string, pass SatellitePass, seq int, opts CalendarOptions) CalendarEvent {
+	tz := opts.Timezone
+	look := func(t time.Time) (string, float64) {
+		azimuth, elevation, _ := sat.LookAngles(opts.Lat, opts.Lng, t)
+		return CompassPoint(azimuth), math.Max(elevation, 0) // Not "-0°" at rise and set
+	}
+	startDir, startEl := look(pass.VisibleStart)
+	maxDir, maxEl := look(pass.VisibleMax())
+	endDir, endEl := look(pass.VisibleEnd)
+
+	appears := fmt.Sprintf("Appears: %s in the %s, %.0f° up", pass.VisibleStart.In(tz).Format("15:04:05"), startDir, startEl)
+	if pass.VisibleStart.After(pass.Rise) && !sat.sunlit(pass.VisibleStart.Add(-passVisibilityStep)) {
+		appears += " (leaves the Earth's shadow)"
+	}
+	end := pass.VisibleEnd.Add(passVisibilityStep)
+	disappears := fmt.Sprintf("Disappears: %s in the %s, %.0f° up", pass.VisibleEnd.In(tz).Format("15:04:05"), endDir, endEl)
+	if end.After(pass.Set) {
+		end = pass.Set
+	} else if !sat.sunlit(end) {
+		disappears += " (enters the Earth's shadow)"
+	}
+
+	lines := []string{
+		appears,
+		fmt.Sprintf("Highest: %s in the %s, %.0f° up", pass.VisibleMax().In(tz).Format("15:04:05"), maxDir, maxEl),
+		disappears,
+		fmt.Sprintf("Location: %s", opts.Location),
+		"Coordinates: " + FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+		"",
+		fmt.Sprintf("Predicted from orbital elements of %s UTC", sat.Epoch.UTC().Format("2006-01-02 15:04")),
+	}
+
+	date := pass.VisibleStart.In(tz)
+	return CalendarEvent{
+		UID:         locationUID(date, opts.Lat, opts.Lng, opts.Precision, fmt.Sprintf("%s-pass-%d", strings.ToLower(label), seq)),
+		Type:        strings.ToLower(label) + "_pass",
+		Start:       pass.VisibleStart,
+		End:         end,
+		Summary:     fmt.Sprintf("%s pass %s, max %.0f° (%s to %s)", label, opts.Clock(pass.VisibleStart, "15:04"), maxEl, startDir, endDir),
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testISS is an ISS-like orbit with its epoch at noon UTC on 16 March 2025
+var testISS = TLE{
+	Name:  "ISS (ZARYA)",
+	Line1: "1 25544U 98067A   25075.50000000  .00016717  00000-0  30000-3 0  9992",
+	Line2: "2 25544  51.6416  30.4627 0006703 130.5360 325.0288 15.49815308432002",
+}
+
+func TestSatellite_Passes(t *testing.T) {
+	sat, err := ParseTLE(testISS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts := testCalendarOptions()
+	from := time.Date(2025, 3, 16, 0, 0, 0, 0, opts.Timezone)
+	passes := sat.Passes(opts.Lat, opts.Lng, from, from.AddDate(0, 0, 1))
+	if len(passes) < 4 {
+		t.Fatalf("expected several passes a day, got %d", len(passes))
+	}
+
+	for i, pass := range passes {
+		if !pass.Rise.Before(pass.Max) || !pass.Max.Before(pass.Set) || pass.Set.Sub(pass.Rise) > 12*time.Minute {
+			t.Errorf("pass %d: expected rise, max, and set within minutes, got %v, %v, %v", i, pass.Rise, pass.Max, pass.Set)
+		}
+		if i > 0 && !passes[i-1].Set.Before(pass.Rise) {
+			t.Errorf("pass %d: expected passes in order", i)
+		}
+		for _, tm := range []time.Time{pass.Rise, pass.Set} {
+			if _, elevation, _ := sat.LookAngles(opts.Lat, opts.Lng, tm); math.Abs(elevation) > 0.1 {
+				t.Errorf("pass %d: expected 0° at rise and set, got %.2f°", i, elevation)
+			}
+		}
+		if _, elevation, _ := sat.LookAngles(opts.Lat, opts.Lng, pass.Max.Add(-time.Minute)); elevation > pass.MaxElevation {
+			t.Errorf("pass %d: expected the highest point at %v", i, pass.Max)
+		}
+
+		// Only visible in the dark
+		if pass.Visible() {
+			if _, sunElevation := SunPosition(opts.Lat, opts.Lng, pass.VisibleStart); sunElevation >= -6 {
+				t.Errorf("pass %d: expected a dark sky when visible, got the sun at %.1f°", i, sunElevation)
+			}
+			if pass.VisibleStart.Before(pass.Rise) || pass.VisibleEnd.After(pass.Set) || pass.VisibleEnd.Before(pass.VisibleStart) {
+				t.Errorf("pass %d: expected the visible part within the pass", i)
+			}
+		}
+	}
+}
+
+func TestSatellite_Sunlit(t *testing.T) {
+	sat, err := ParseTLE(testISS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Low orbits spend about a third of each 93-minute orbit in shadow
+	var shadowed int
+	for m := range 93 {
+		if !sat.sunlit(sat.Epoch.Add(time.Duration(m) * time.Minute)) {
+			shadowed++
+		}
+	}
+	if shadowed < 25 || shadowed > 40 {
+		t.Errorf("expected about 35 minutes in shadow, got %d", shadowed)
+	}
+}
+
+func TestBuildSatellitePassEvents(t *testing.T) {
+	sat, err := ParseTLE(testISS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts := testCalendarOptions()
+	start := time.Date(2025, 3, 16, 0, 0, 0, 0, opts.Timezone)
+	events := BuildSatellitePassEvents(opts, sat, "ISS", start, 2)
+	if len(events) != 4 {
+		t.Fatalf("expected 4 visible passes, got %d", len(events))
+	}
+
+	first := events[0]
+	if first.Type != "iss_pass" || first.Summary != "ISS pass 19:01, max 31° (WSW to ESE)" {
+		t.Errorf("unexpected event: %s %q", first.Type, first.Summary)
+	}
+	for _, want := range []string{
+		"Appears: 19:01:52 in the WSW, 0° up",
+		"Highest: 19:07:05 in the SSE, 31° up",
+		"in the ESE, 12° up (enters the Earth's shadow)",
+		"Predicted from orbital elements of 2025-03-16 12:00 UTC",
+	} {
+		if !strings.Contains(first.Description, want) {
+			t.Errorf("expected description to contain %q, got:\n%s", want, first.Description)
+		}
+	}
+
+	// Evening passes in a dark sky, numbered per day
+	uids := map[string]bool{}
+	for _, event := range events {
+		if hour := event.Start.In(opts.Timezone).Hour(); hour < 18 {
+			t.Errorf("expected evening passes, got %v", event.Start)
+		}
+		if !event.End.After(event.Start) {
+			t.Errorf("expected a duration, got %v to %v", event.Start, event.End)
+		}
+		uids[event.UID] = true
+	}
+	if len(uids) != len(events) {
+		t.Error("expected unique UIDs")
+	}
+
+	// Nothing predicted beyond a week of the orbit's epoch
+	if events := BuildSatellitePassEvents(opts, sat, "ISS", start.AddDate(0, 0, 8), 7); len(events) != 0 {
+		t.Errorf("expected no passes a week after the epoch, got %d", len(events))
+	}
+}
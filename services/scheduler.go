@@ -0,0 +1,24 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// RunEvery runs job immediately and then at every interval in a background
+// goroutine, until ctx is cancelled
+func RunEvery(ctx context.Context, interval time.Duration, job func(context.Context)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			job(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
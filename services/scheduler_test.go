@@ -0,0 +1,31 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunEvery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var runs int32
+	RunEvery(ctx, 5*time.Millisecond, func(context.Context) {
+		atomic.AddInt32(&runs, 1)
+	})
+
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	stopped := atomic.LoadInt32(&runs)
+
+	if stopped < 2 {
+		t.Errorf("expected job to run repeatedly, ran %d times", stopped)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if atomic.LoadInt32(&runs) != stopped {
+		t.Error("job should not run after cancellation")
+	}
+}
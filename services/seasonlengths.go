@@ -0,0 +1,67 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BuildSeasonLengthEvents generates an all-day event on the local date each
+// astronomical season starts, in the years from first to last, giving its
+// length (e.g., "Spring begins (lasts 92d 18h)")
+func BuildSeasonLengthEvents(opts CalendarOptions, first, last int) []CalendarEvent {
+	var events []CalendarEvent
+	for year := first; year <= last; year++ {
+		seasons := SeasonLengths(opts.Lat, year)
+		longest, shortest := 0, 0
+		for i, s := range seasons {
+			if s.Duration() > seasons[longest].Duration() {
+				longest = i
+			}
+			if s.Duration() < seasons[shortest].Duration() {
+				shortest = i
+			}
+		}
+		for i, s := range seasons {
+			var note string
+			switch i {
+			case longest:
+				note = fmt.Sprintf("The longest season of %d", year)
+			case shortest:
+				note = fmt.Sprintf("The shortest season of %d", year)
+			}
+			events = append(events, newSeasonLengthEvent(s, note, opts))
+		}
+	}
+	return events
+}
+
+// newSeasonLengthEvent creates an all-day event on the local date a season
+// starts, with an optional note comparing it to the year's other seasons
+func newSeasonLengthEvent(s SeasonLength, note string, opts CalendarOptions) CalendarEvent {
+	start, end := s.Start.In(opts.Timezone), s.End.In(opts.Timezone)
+	date := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, opts.Timezone)
+	name := SeasonStartName(s.Season)
+	title := strings.ToUpper(s.Season[:1]) + s.Season[1:]
+
+	lines := []string{
+		fmt.Sprintf("%s: %s", strings.ToUpper(name[:1])+name[1:], opts.Locale.LongDate(start)+start.Format(" at 15:04 MST")),
+		fmt.Sprintf("Ends: %s", opts.Locale.LongDate(end)+end.Format(" at 15:04 MST")),
+		fmt.Sprintf("Length: %s", FormatSeasonLength(s.Duration())),
+		fmt.Sprintf("Location: %s", opts.Location),
+	}
+	if note != "" {
+		lines = append(lines, "", note)
+	}
+
+	return CalendarEvent{
+		UID:         locationUID(date, opts.Lat, opts.Lng, opts.Precision, "season-"+s.Season),
+		Type:        "season_length",
+		Start:       date,
+		End:         date.AddDate(0, 0, 1),
+		Summary:     fmt.Sprintf("%s begins (lasts %s)", title, FormatSeasonLength(s.Duration())),
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+		AllDay:      true,
+	}
+}
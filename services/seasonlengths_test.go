@@ -0,0 +1,35 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildSeasonLengthEvents(t *testing.T) {
+	opts := testCalendarOptions()
+	events := BuildSeasonLengthEvents(opts, 2025, 2026)
+	if len(events) != 8 {
+		t.Fatalf("expected 8 events over two years, got %d", len(events))
+	}
+
+	spring := events[0]
+	if spring.Summary != "Spring begins (lasts 92d 18h)" || spring.Type != "season_length" || !spring.AllDay {
+		t.Errorf("unexpected spring event: %+v", spring)
+	}
+	if !spring.Start.Equal(time.Date(2025, 3, 20, 0, 0, 0, 0, opts.Timezone)) {
+		t.Errorf("expected the local date of the equinox, got %s", spring.Start)
+	}
+	if !strings.Contains(spring.Description, "Spring equinox: Thursday, March 20, 2025 at 10:01 CET\nEnds: Saturday, June 21, 2025 at 04:42 CEST\nLength: 92d 18h") {
+		t.Errorf("unexpected description: %q", spring.Description)
+	}
+	if !strings.HasSuffix(events[1].Description, "The longest season of 2025") || !strings.HasSuffix(events[3].Description, "The shortest season of 2025") {
+		t.Errorf("expected summer longest and winter shortest, got %q and %q", events[1].Description, events[3].Description)
+	}
+	if strings.Contains(spring.Description, "season of") {
+		t.Error("expected no note on spring")
+	}
+	if events[0].UID == events[4].UID {
+		t.Error("expected distinct UIDs each year")
+	}
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// SeasonEvent is an equinox or solstice
+type SeasonEvent int
+
+const (
+	MarchEquinox SeasonEvent = iota
+	JuneSolstice
+	SeptemberEquinox
+	DecemberSolstice
+)
+
+// Seasons, named as in the hemisphere of a location
+const (
+	SeasonSpring = "spring"
+	SeasonSummer = "summer"
+	SeasonAutumn = "autumn"
+	SeasonWinter = "winter"
+)
+
+// Seasons lists the seasons in the order they start in a year in the
+// northern hemisphere
+var Seasons = []string{SeasonSpring, SeasonSummer, SeasonAutumn, SeasonWinter}
+
+// seasonMeanTerms holds the polynomial coefficients of each event's mean
+// instant in Julian Ephemeris Days, for years 1000-3000 (Meeus, Astronomical
+// Algorithms, table 27.B)
+var seasonMeanTerms = [4][5]float64{
+	{2451623.80984, 365242.37404, 0.05169, -0.00411, -0.00057},
+	{2451716.56767, 365241.62603, 0.00325, 0.00888, -0.00030},
+	{2451810.21715, 365242.01767, -0.11575, 0.00337, 0.00078},
+	{2451900.05952, 365242.74049, -0.06223, -0.00823, 0.00032},
+}
+
+// seasonPeriodicTerms corrects the mean instants for the Earth's orbit
+// (Meeus, table 27.C): amplitude, phase (degrees), and rate (degrees per
+// Julian century)
+var seasonPeriodicTerms = [][3]float64{
+	{485, 324.96, 1934.136}, {203, 337.23, 32964.467}, {199, 342.08, 20.186},
+	{182, 27.85, 445267.112}, {156, 73.14, 45036.886}, {136, 171.52, 22518.443},
+	{77, 222.54, 65928.934}, {74, 296.72, 3034.906}, {70, 243.58, 9037.513},
+	{58, 119.81, 33718.147}, {52, 297.17, 150.678}, {50, 21.02, 2281.226},
+	{45, 247.54, 29929.562}, {44, 325.15, 31555.956}, {29, 60.93, 4443.417},
+	{18, 155.12, 67555.328}, {17, 288.79, 4562.452}, {16, 198.04, 62894.029},
+	{14, 199.76, 31436.921}, {12, 95.39, 14577.848}, {12, 287.11, 31931.756},
+	{12, 320.81, 34777.259}, {9, 227.73, 1222.114}, {8, 15.45, 16859.074},
+}
+
+// Time returns the instant of the event in a year, accurate to about a
+// minute for years 1000-3000
+func (e SeasonEvent) Time(year int) time.Time {
+	y := float64(year-2000) / 1000
+	c := seasonMeanTerms[e]
+	jde0 := c[0] + y*(c[1]+y*(c[2]+y*(c[3]+y*c[4])))
+
+	t := (jde0 - 2451545.0) / 36525
+	w := degToRad(35999.373*t - 2.47)
+	dl := 1 + 0.0334*math.Cos(w) + 0.0007*math.Cos(2*w)
+	var s float64
+	for _, term := range seasonPeriodicTerms {
+		s += term[0] * math.Cos(degToRad(term[1]+term[2]*t))
+	}
+	jde := jde0 + 0.00001*s/dl
+
+	// Julian day 2440587.5 is the Unix epoch; JDE counts Terrestrial Time
+	seconds := (jde-2440587.5)*86400 - deltaT(year)
+	return time.Unix(0, int64(seconds*float64(time.Second))).UTC().Round(time.Second)
+}
+
+// Name returns the event's name (e.g., "June solstice")
+func (e SeasonEvent) Name() string {
+	return [...]string{"March equinox", "June solstice", "September equinox", "December solstice"}[e]
+}
+
+// SeasonStart returns the equinox or solstice starting a season at a
+// latitude; seasons in the southern hemisphere are six months apart from the
+// north's
+func SeasonStart(season string, lat float64) SeasonEvent {
+	var e SeasonEvent
+	switch season {
+	case SeasonSummer:
+		e = JuneSolstice
+	case SeasonAutumn:
+		e = SeptemberEquinox
+	case SeasonWinter:
+		e = DecemberSolstice
+	}
+	if lat < 0 {
+		e = (e + 2) % 4
+	}
+	return e
+}
+
+// SeasonStartName returns how the start of a season is called (e.g.,
+// "summer solstice", "spring equinox")
+func SeasonStartName(season string) string {
+	if season == SeasonSummer || season == SeasonWinter {
+		return season + " solstice"
+	}
+	return season + " equinox"
+}
+
+// NextSeasonStart returns the next start of a season at a latitude after t
+func NextSeasonStart(season string, lat float64, t time.Time) time.Time {
+	e := SeasonStart(season, lat)
+	for year := t.UTC().Year(); ; year++ {
+		if start := e.Time(year); start.After(t) {
+			return start
+		}
+	}
+}
+
+// SeasonLength is an astronomical season from the equinox or solstice
+// starting it to the one starting the next
+type SeasonLength struct {
+	Season string      // Named for the hemisphere (see Seasons)
+	Event  SeasonEvent // The equinox or solstice starting the season
+	Start  time.Time
+	End    time.Time
+}
+
+// Duration returns the length of the season
+func (s SeasonLength) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// SeasonLengths returns the four astronomical seasons starting in a year at
+// a latitude, in order. They differ by several days, as the Earth moves
+// fastest near perihelion in early January.
+func SeasonLengths(lat float64, year int) []SeasonLength {
+	seasons := make([]SeasonLength, 0, 4)
+	for e := MarchEquinox; e <= DecemberSolstice; e++ {
+		end := ((e + 1) % 4).Time(year)
+		if e == DecemberSolstice {
+			end = MarchEquinox.Time(year + 1)
+		}
+		season := Seasons[e]
+		if lat < 0 {
+			season = Seasons[(e+2)%4]
+		}
+		seasons = append(seasons, SeasonLength{Season: season, Event: e, Start: e.Time(year), End: end})
+	}
+	return seasons
+}
+
+// FormatSeasonLength formats a season's length in days and hours, rounded to
+// the hour (e.g., "92d 18h")
+func FormatSeasonLength(d time.Duration) string {
+	hours := int(d.Round(time.Hour).Hours())
+	return fmt.Sprintf("%dd %dh", hours/24, hours%24)
+}
+
+// SolarLongitude returns the sun's apparent ecliptic longitude at t, in
+// degrees from the March equinox (0 to 360), accurate to about 0.01°
+// (Astronomical Almanac low precision formulas)
+func SolarLongitude(t time.Time) float64 {
+	n := daysSinceJ2000(t)
+	meanLongitude := 280.460 + 0.9856474*n
+	meanAnomaly := degToRad(357.528 + 0.9856003*n)
+	longitude := meanLongitude + 1.915*math.Sin(meanAnomaly) + 0.020*math.Sin(2*meanAnomaly)
+	return math.Mod(math.Mod(longitude, 360)+360, 360)
+}
+
+// daysSinceJ2000 returns the days from 2000 January 1, 12h UT to t
+func daysSinceJ2000(t time.Time) float64 {
+	// Julian day 2440587.5 is the Unix epoch, and 2451545.0 is J2000.0
+	return float64(t.UnixNano())/float64(24*time.Hour) + 2440587.5 - 2451545.0
+}
+
+// SeasonProgress is how far an astronomical season has come at a point in
+// time
+type SeasonProgress struct {
+	Season   string    // Named for the hemisphere (see Seasons)
+	Start    time.Time // The equinox or solstice starting the season
+	End      time.Time // The one starting the next season
+	Fraction float64   // Of the season's time passed, 0 to 1
+}
+
+// GetSeasonProgress returns the astronomical season at a latitude at t and
+// how far through it t is. Seasons differ in length, so the fraction is of
+// the season's time rather than of its 90° of solar longitude.
+func GetSeasonProgress(lat float64, t time.Time) SeasonProgress {
+	// The last equinox or solstice at or before t
+	e, year := DecemberSolstice, t.UTC().Year()-1
+	for next, nextYear := MarchEquinox, year+1; !next.Time(nextYear).After(t); {
+		e, year = next, nextYear
+		if next = (next + 1) % 4; next == MarchEquinox {
+			nextYear++
+		}
+	}
+	start := e.Time(year)
+	if e == DecemberSolstice {
+		year++
+	}
+	end := ((e + 1) % 4).Time(year)
+
+	season := Seasons[e]
+	if lat < 0 {
+		season = Seasons[(e+2)%4]
+	}
+	return SeasonProgress{
+		Season:   season,
+		Start:    start,
+		End:      end,
+		Fraction: float64(t.Sub(start)) / float64(end.Sub(start)),
+	}
+}
+
+// FormatSeasonProgress describes season progress in whole percent, rounded
+// down (e.g., "42% through astronomical spring")
+func FormatSeasonProgress(p SeasonProgress) string {
+	return fmt.Sprintf("%d%% through astronomical %s", int(p.Fraction*100), p.Season)
+}
+
+// deltaT approximates the difference between Terrestrial Time and Universal
+// Time in seconds (Espenak and Meeus polynomials for 1986-2150)
+func deltaT(year int) float64 {
+	y := float64(year)
+	switch {
+	case year < 2005:
+		t := y - 2000
+		return 63.86 + 0.3345*t - 0.060374*t*t + 0.0017275*t*t*t + 0.000651814*t*t*t*t + 0.00002373599*t*t*t*t*t
+	case year < 2050:
+		t := y - 2000
+		return 62.92 + 0.32217*t + 0.005589*t*t
+	}
+	u := (y - 1820) / 100
+	return -20 + 32*u*u - 0.5628*(2150-y)
+}
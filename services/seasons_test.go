@@ -0,0 +1,152 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSeasonEvent_Time(t *testing.T) {
+	// Published instants (UTC), to the minute
+	tests := []struct {
+		event SeasonEvent
+		year  int
+		want  time.Time
+	}{
+		{MarchEquinox, 2024, time.Date(2024, 3, 20, 3, 6, 0, 0, time.UTC)},
+		{JuneSolstice, 2024, time.Date(2024, 6, 20, 20, 51, 0, 0, time.UTC)},
+		{SeptemberEquinox, 2024, time.Date(2024, 9, 22, 12, 44, 0, 0, time.UTC)},
+		{DecemberSolstice, 2024, time.Date(2024, 12, 21, 9, 20, 0, 0, time.UTC)},
+		{JuneSolstice, 2025, time.Date(2025, 6, 21, 2, 42, 0, 0, time.UTC)},
+		{DecemberSolstice, 2023, time.Date(2023, 12, 22, 3, 27, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got := tt.event.Time(tt.year)
+		if diff := got.Sub(tt.want).Abs(); diff > 2*time.Minute {
+			t.Errorf("%s %d = %s, want %s", tt.event.Name(), tt.year, got, tt.want)
+		}
+	}
+}
+
+func TestSeasonStart_Hemispheres(t *testing.T) {
+	tests := []struct {
+		season string
+		lat    float64
+		want   SeasonEvent
+	}{
+		{SeasonSpring, 55.7, MarchEquinox},
+		{SeasonSummer, 55.7, JuneSolstice},
+		{SeasonSummer, -33.9, DecemberSolstice},
+		{SeasonAutumn, -33.9, MarchEquinox},
+		{SeasonWinter, -33.9, JuneSolstice},
+	}
+
+	for _, tt := range tests {
+		if got := SeasonStart(tt.season, tt.lat); got != tt.want {
+			t.Errorf("SeasonStart(%s, %g) = %s, want %s", tt.season, tt.lat, got.Name(), tt.want.Name())
+		}
+	}
+	if SeasonStartName(SeasonSummer) != "summer solstice" || SeasonStartName(SeasonSpring) != "spring equinox" {
+		t.Error("unexpected season start names")
+	}
+}
+
+func TestNextSeasonStart(t *testing.T) {
+	// After 2024's June solstice, the next is in 2025
+	after := time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC)
+	if got := NextSeasonStart(SeasonSummer, 55.7, after); got.Year() != 2025 || got.Month() != time.June {
+		t.Errorf("expected the 2025 June solstice, got %s", got)
+	}
+	if got := NextSeasonStart(SeasonWinter, 55.7, after); got.Year() != 2024 || got.Month() != time.December {
+		t.Errorf("expected the 2024 December solstice, got %s", got)
+	}
+}
+
+func TestSolarLongitude(t *testing.T) {
+	tests := []struct {
+		event SeasonEvent
+		want  float64
+	}{
+		{MarchEquinox, 0},
+		{JuneSolstice, 90},
+		{SeptemberEquinox, 180},
+		{DecemberSolstice, 270},
+	}
+	for _, tt := range tests {
+		got := SolarLongitude(tt.event.Time(2024))
+		// Just after the March equinox, or just before it (359.99°)
+		if diff := math.Mod(got-tt.want+540, 360) - 180; math.Abs(diff) > 0.02 {
+			t.Errorf("solar longitude at the %s = %.3f, want %.0f", tt.event.Name(), got, tt.want)
+		}
+	}
+}
+
+func TestGetSeasonProgress(t *testing.T) {
+	// Spring 2024 runs from March 20 03:06 to June 20 20:51 UTC, 92.7 days
+	at := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	north := GetSeasonProgress(55.7, at)
+	if north.Season != SeasonSpring || FormatSeasonProgress(north) != "45% through astronomical spring" {
+		t.Errorf("unexpected northern progress %+v", north)
+	}
+	if !north.Start.Equal(MarchEquinox.Time(2024)) || !north.End.Equal(JuneSolstice.Time(2024)) {
+		t.Errorf("expected spring from the March equinox to the June solstice, got %s to %s", north.Start, north.End)
+	}
+
+	if south := GetSeasonProgress(-33.9, at); south.Season != SeasonAutumn || south.Fraction != north.Fraction {
+		t.Errorf("expected the same progress through autumn in the south, got %+v", south)
+	}
+
+	// Winter spans the new year
+	winter := GetSeasonProgress(55.7, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	if winter.Season != SeasonWinter || !winter.Start.Equal(DecemberSolstice.Time(2024)) || !winter.End.Equal(MarchEquinox.Time(2025)) {
+		t.Errorf("unexpected winter %+v", winter)
+	}
+	if winter.Fraction < 0.11 || winter.Fraction > 0.13 {
+		t.Errorf("expected about 12%% through winter, got %.3f", winter.Fraction)
+	}
+}
+
+func TestSeasonLengths(t *testing.T) {
+	seasons := SeasonLengths(55.68, 2025)
+	if len(seasons) != 4 {
+		t.Fatalf("expected 4 seasons, got %d", len(seasons))
+	}
+	// Published lengths for 2025, in days
+	want := []struct {
+		season string
+		days   float64
+	}{{SeasonSpring, 92.74}, {SeasonSummer, 93.65}, {SeasonAutumn, 89.86}, {SeasonWinter, 88.99}}
+	for i, s := range seasons {
+		if s.Season != want[i].season || math.Abs(s.Duration().Hours()/24-want[i].days) > 0.01 {
+			t.Errorf("season %d = %s of %.2f days, want %s of %.2f", i, s.Season, s.Duration().Hours()/24, want[i].season, want[i].days)
+		}
+		if i > 0 && !s.Start.Equal(seasons[i-1].End) {
+			t.Errorf("%s should start when %s ends", s.Season, seasons[i-1].Season)
+		}
+	}
+	if !seasons[3].End.Equal(MarchEquinox.Time(2026)) {
+		t.Errorf("expected winter to end at the next March equinox, got %s", seasons[3].End)
+	}
+
+	south := SeasonLengths(-33.87, 2025)
+	if south[0].Season != SeasonAutumn || south[0].Event != MarchEquinox || south[0].Duration() != seasons[0].Duration() {
+		t.Errorf("expected autumn from the March equinox in the south, got %+v", south[0])
+	}
+}
+
+func TestFormatSeasonLength(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{92*24*time.Hour + 18*time.Hour + 25*time.Minute, "92d 18h"},
+		{88*24*time.Hour + 23*time.Hour + 40*time.Minute, "89d 0h"},
+	}
+	for _, tt := range tests {
+		if got := FormatSeasonLength(tt.d); got != tt.want {
+			t.Errorf("FormatSeasonLength(%s) = %s, want %s", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+// Time series metrics, for graphing in dashboards
+const (
+	MetricDayLength   = "day_length"   // Hours between sunrise and sunset
+	MetricNightLength = "night_length" // Hours between sunset and the next sunrise
+	MetricDarkness    = "darkness"     // Hours between astronomical dusk and the next dawn
+	MetricSunrise     = "sunrise"      // Local time of sunrise, in hours after midnight
+	MetricSunset      = "sunset"       // Local time of sunset, in hours after midnight
+	MetricElevation   = "elevation"    // Sun elevation above the horizon, in degrees
+)
+
+// SeriesMetrics lists the supported time series metrics
+var SeriesMetrics = []string{MetricDayLength, MetricNightLength, MetricDarkness, MetricSunrise, MetricSunset, MetricElevation}
+
+// MaxSeriesDays limits the range of the daily metrics
+const MaxSeriesDays = 3660
+
+// SeriesPoint is one value of a time series
+type SeriesPoint struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series returns metric for a location between from and to. Daily metrics
+// have one point per local day, at local midnight; days on which the sun does
+// not rise or set are left out of the sunrise and sunset series. Elevation is
+// sampled every step.
+func Series(metric string, lat, lng float64, from, to time.Time, step time.Duration) ([]SeriesPoint, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("range ends before it starts")
+	}
+
+	if metric == MetricElevation {
+		if step <= 0 {
+			return nil, fmt.Errorf("invalid step %s", step)
+		}
+		points := make([]SeriesPoint, 0, int(to.Sub(from)/step)+1)
+		for t := from; !t.After(to); t = t.Add(step) {
+			_, elevation := SunPosition(lat, lng, t)
+			points = append(points, SeriesPoint{Time: t, Value: elevation})
+		}
+		return points, nil
+	}
+
+	value, ok := dailyMetrics[metric]
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %q", metric)
+	}
+
+	tz := GetTimezone(lat, lng)
+	start := from.In(tz)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, tz)
+	if to.Sub(day) > MaxSeriesDays*24*time.Hour {
+		return nil, fmt.Errorf("range exceeds %d days", MaxSeriesDays)
+	}
+
+	var points []SeriesPoint
+	for ; !day.After(to); day = day.AddDate(0, 0, 1) {
+		noon := day.Add(12 * time.Hour)
+		if v, ok := value(lat, lng, noon, GetSunTimes(lat, lng, noon)); ok {
+			points = append(points, SeriesPoint{Time: day, Value: v})
+		}
+	}
+	return points, nil
+}
+
+// dailyMetrics computes the daily metrics from a day's sun times, reporting
+// false if the metric has no value that day
+var dailyMetrics = map[string]func(lat, lng float64, noon time.Time, day DaySunTimes) (float64, bool){
+	MetricDayLength: func(_, _ float64, _ time.Time, day DaySunTimes) (float64, bool) {
+		dayLength, ok := day.DayLength()
+		return dayLength.Hours(), ok
+	},
+	MetricNightLength: func(lat, lng float64, noon time.Time, day DaySunTimes) (float64, bool) {
+		nightLength, ok := day.NightLength(GetSunTimes(lat, lng, noon.AddDate(0, 0, 1)))
+		return nightLength.Hours(), ok
+	},
+	MetricDarkness: func(lat, lng float64, noon time.Time, day DaySunTimes) (float64, bool) {
+		darkness, ok := day.DarknessLength(GetSunTimes(lat, lng, noon.AddDate(0, 0, 1)))
+		return darkness.Hours(), ok
+	},
+	MetricSunrise: func(_, _ float64, noon time.Time, day DaySunTimes) (float64, bool) {
+		if day.Sunrise == nil {
+			return 0, false
+		}
+		return hoursAfterMidnight(day.Sunrise.Time.In(noon.Location())), true
+	},
+	MetricSunset: func(_, _ float64, noon time.Time, day DaySunTimes) (float64, bool) {
+		if day.Sunset == nil {
+			return 0, false
+		}
+		return hoursAfterMidnight(day.Sunset.Time.In(noon.Location())), true
+	},
+}
+
+// hoursAfterMidnight returns the wall clock time of t as fractional hours
+func hoursAfterMidnight(t time.Time) float64 {
+	return float64(t.Hour()) + float64(t.Minute())/60 + float64(t.Second())/3600
+}
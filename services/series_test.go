@@ -0,0 +1,115 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSeries_DayLength(t *testing.T) {
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 29)
+
+	points, err := Series(MetricDayLength, 55.6761, 12.5683, from, to, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Copenhagen's local midnight on June 1 is before the range starts, so
+	// the day containing from is included
+	if len(points) != 30 {
+		t.Fatalf("expected 30 points, got %d", len(points))
+	}
+	for _, p := range points {
+		if p.Value < 17 || p.Value > 18 {
+			t.Errorf("unexpected June day length in Copenhagen: %.2f hours", p.Value)
+		}
+		if local := p.Time.In(GetTimezone(55.6761, 12.5683)); local.Hour() != 0 || local.Minute() != 0 {
+			t.Errorf("expected points at local midnight, got %s", local)
+		}
+	}
+}
+
+func TestSeries_PolarDayLength(t *testing.T) {
+	from := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+	points, err := Series(MetricDayLength, 78.2232, 15.6267, from, from, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(points) != 1 || points[0].Value != 24 {
+		t.Errorf("expected a 24 hour day during midnight sun, got %+v", points)
+	}
+
+	// No sunrise during polar night, so there are no sunrise points
+	from = time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC)
+	points, err = Series(MetricSunrise, 78.2232, 15.6267, from, from, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(points) != 0 {
+		t.Errorf("expected no sunrise points during polar night, got %+v", points)
+	}
+}
+
+func TestSeries_SunriseSunset(t *testing.T) {
+	from := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	sunrise, err := Series(MetricSunrise, 55.6761, 12.5683, from, from, 0)
+	if err != nil || len(sunrise) != 1 {
+		t.Fatalf("expected one sunrise point, got %+v (%v)", sunrise, err)
+	}
+	sunset, err := Series(MetricSunset, 55.6761, 12.5683, from, from, 0)
+	if err != nil || len(sunset) != 1 {
+		t.Fatalf("expected one sunset point, got %+v (%v)", sunset, err)
+	}
+	// Local times (CET) of about 06:40 and 18:05
+	if sunrise[0].Value < 6.4 || sunrise[0].Value > 7 || sunset[0].Value < 17.8 || sunset[0].Value > 18.4 {
+		t.Errorf("unexpected sunrise/sunset hours: %.2f, %.2f", sunrise[0].Value, sunset[0].Value)
+	}
+}
+
+func TestSeries_Elevation(t *testing.T) {
+	from := time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	points, err := Series(MetricElevation, 55.6761, 12.5683, from, to, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(points) != 25 {
+		t.Fatalf("expected 25 hourly points, got %d", len(points))
+	}
+
+	highest := math.Inf(-1)
+	for _, p := range points {
+		highest = math.Max(highest, p.Value)
+	}
+	// Solar noon elevation in Copenhagen at the summer solstice is ~57.8
+	if highest < 55 || highest > 58 {
+		t.Errorf("unexpected maximum elevation %.2f", highest)
+	}
+
+	if _, err := Series(MetricElevation, 55.6761, 12.5683, from, to, 0); err == nil {
+		t.Error("expected error for a zero step")
+	}
+}
+
+func TestSeries_Invalid(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := Series("temperature", 55.6761, 12.5683, from, from, 0); err == nil {
+		t.Error("expected error for unknown metric")
+	}
+	if _, err := Series(MetricDayLength, 55.6761, 12.5683, from, from.Add(-time.Hour), 0); err == nil {
+		t.Error("expected error for reversed range")
+	}
+	if _, err := Series(MetricDayLength, 55.6761, 12.5683, from, from.AddDate(20, 0, 0), 0); err == nil {
+		t.Error("expected error for a range that is too long")
+	}
+}
+
+func BenchmarkSeries_DayLength(b *testing.B) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < b.N; i++ {
+		Series(MetricDayLength, 55.6761, 12.5683, from, from.AddDate(1, 0, 0), 24*time.Hour)
+	}
+}
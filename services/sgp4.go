@@ -0,0 +1,358 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLE is a satellite's two-line element set, as published by Celestrak
+type TLE struct {
+	Name  string
+	Line1 string
+	Line2 string
+}
+
+// SGP4 gravity constants (WGS 72, which TLEs are fitted with)
+const (
+	sgp4EarthRadius = 6378.135              // km
+	sgp4XKE         = 0.0743669161331734132 // sqrt(GM) in Earth radii^1.5 per minute
+	sgp4J2          = 0.001082616
+	sgp4J3          = -0.00000253881
+	sgp4J4          = -0.00000165597
+	sgp4J3OverJ2    = sgp4J3 / sgp4J2
+)
+
+// sgp4DeepSpacePeriod is the orbital period from which SGP4 needs the deep
+// space (SDP4) perturbations, which are not implemented
+const sgp4DeepSpacePeriod = 225 * time.Minute
+
+// Satellite is a near-Earth satellite orbit, propagated with SGP4 (Hoots and
+// Roehrich, Spacetrack Report #3, as revised by Vallado et al. 2006)
+type Satellite struct {
+	Name  string
+	Epoch time.Time
+
+	// Mean elements at epoch (radians, radians per minute)
+	ecco, inclo, nodeo, argpo, mo, no, bstar float64
+
+	// Initialized coefficients
+	isimp                                      bool
+	aycof, con41, cc1, cc4, cc5, d2, d3, d4    float64
+	delmo, eta, argpdot, omgcof, sinmao, t2cof float64
+	t3cof, t4cof, t5cof, x1mth2, x7thm1, mdot  float64
+	nodedot, xlcof, xmcof, nodecf              float64
+}
+
+// ParseTLE parses a two-line element set into a satellite, checking the
+// lines' checksums. Deep space orbits (periods of 225 minutes or more) are
+// rejected.
+func ParseTLE(tle TLE) (*Satellite, error) {
+	line1, line2 := strings.TrimRight(tle.Line1, " \r"), strings.TrimRight(tle.Line2, " \r")
+	if len(line1) != 69 || len(line2) != 69 || line1[0] != '1' || line2[0] != '2' {
+		return nil, errors.New("invalid TLE: expected two 69-character lines")
+	}
+	for _, line := range []string{line1, line2} {
+		if tleChecksum(line[:68]) != int(line[68]-'0') {
+			return nil, fmt.Errorf("invalid TLE: checksum mismatch in line %c", line[0])
+		}
+	}
+
+	var err error
+	field := func(line string, from, to int) float64 {
+		v, e := strconv.ParseFloat(strings.TrimSpace(line[from:to]), 64)
+		if e != nil && err == nil {
+			err = fmt.Errorf("invalid TLE: columns %d-%d: %q", from+1, to, line[from:to])
+		}
+		return v
+	}
+	year := int(field(line1, 18, 20))
+	day := field(line1, 20, 32)
+	bstar := tleExponent(line1[53:61], &err)
+	inclination := field(line2, 8, 16)
+	node := field(line2, 17, 25)
+	ecc := field(line2, 26, 33) / 1e7 // Assumed leading decimal point
+	argp := field(line2, 34, 42)
+	meanAnomaly := field(line2, 43, 51)
+	meanMotion := field(line2, 52, 63)
+	if err != nil {
+		return nil, err
+	}
+
+	if year < 57 {
+		year += 2000
+	} else {
+		year += 1900
+	}
+	epoch := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration((day - 1) * float64(24*time.Hour))).Round(time.Microsecond)
+
+	sat := &Satellite{
+		Name:  strings.TrimSpace(tle.Name),
+		Epoch: epoch,
+		ecco:  ecc,
+		inclo: degToRad(inclination),
+		nodeo: degToRad(node),
+		argpo: degToRad(argp),
+		mo:    degToRad(meanAnomaly),
+		no:    meanMotion * 2 * math.Pi / 1440,
+		bstar: bstar,
+	}
+	if err := sat.init(); err != nil {
+		return nil, err
+	}
+	return sat, nil
+}
+
+// tleChecksum sums a TLE line's digits, counting minus signs as 1, modulo 10
+func tleChecksum(line string) int {
+	sum := 0
+	for _, c := range line {
+		switch {
+		case c >= '0' && c <= '9':
+			sum += int(c - '0')
+		case c == '-':
+			sum++
+		}
+	}
+	return sum % 10
+}
+
+// tleExponent parses a TLE field in assumed-decimal exponent notation (e.g.,
+// " 28098-4" for 0.28098e-4)
+func tleExponent(s string, err *error) float64 {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return 0
+	}
+	sign := ""
+	if s[0] == '-' || s[0] == '+' {
+		sign, s = s[:1], s[1:]
+	}
+	v, e := strconv.ParseFloat(fmt.Sprintf("%s0.%se%s", sign, s[:len(s)-2], s[len(s)-2:]), 64)
+	if e != nil && *err == nil {
+		*err = fmt.Errorf("invalid TLE: %q", s)
+	}
+	return v
+}
+
+// init computes the SGP4 coefficients from the mean elements
+func (s *Satellite) init() error {
+	const x2o3 = 2.0 / 3.0
+	if s.ecco < 0 || s.ecco >= 1 || s.no <= 0 {
+		return errors.New("invalid TLE: eccentricity or mean motion out of range")
+	}
+
+	// Recover the original mean motion and semi-major axis from the elements
+	eccsq := s.ecco * s.ecco
+	omeosq := 1 - eccsq
+	rteosq := math.Sqrt(omeosq)
+	cosio := math.Cos(s.inclo)
+	cosio2 := cosio * cosio
+	ak := math.Pow(sgp4XKE/s.no, x2o3)
+	d1 := 0.75 * sgp4J2 * (3*cosio2 - 1) / (rteosq * omeosq)
+	del := d1 / (ak * ak)
+	adel := ak * (1 - del*del - del*(1.0/3.0+134*del*del/81))
+	del = d1 / (adel * adel)
+	s.no /= 1 + del
+	if time.Duration(2*math.Pi/s.no*float64(time.Minute)) >= sgp4DeepSpacePeriod {
+		return errors.New("deep space orbits are not supported")
+	}
+
+	ao := math.Pow(sgp4XKE/s.no, x2o3)
+	sinio := math.Sin(s.inclo)
+	po := ao * omeosq
+	con42 := 1 - 5*cosio2
+	s.con41 = -con42 - cosio2 - cosio2
+	posq := po * po
+	rp := ao * (1 - s.ecco)
+	s.isimp = rp < 220/sgp4EarthRadius+1
+
+	// Atmospheric density parameters, adjusted for low perigees
+	sfour := 78/sgp4EarthRadius + 1
+	qzms24 := math.Pow((120-78)/sgp4EarthRadius, 4)
+	if perige := (rp - 1) * sgp4EarthRadius; perige < 156 {
+		sfour = perige - 78
+		if perige < 98 {
+			sfour = 20
+		}
+		qzms24 = math.Pow((120-sfour)/sgp4EarthRadius, 4)
+		sfour = sfour/sgp4EarthRadius + 1
+	}
+
+	pinvsq := 1 / posq
+	tsi := 1 / (ao - sfour)
+	s.eta = ao * s.ecco * tsi
+	etasq := s.eta * s.eta
+	eeta := s.ecco * s.eta
+	psisq := math.Abs(1 - etasq)
+	coef := qzms24 * math.Pow(tsi, 4)
+	coef1 := coef / math.Pow(psisq, 3.5)
+	cc2 := coef1 * s.no * (ao*(1+1.5*etasq+eeta*(4+etasq)) + 0.375*sgp4J2*tsi/psisq*s.con41*(8+3*etasq*(8+etasq)))
+	s.cc1 = s.bstar * cc2
+	cc3 := 0.0
+	if s.ecco > 1e-4 {
+		cc3 = -2 * coef * tsi * sgp4J3OverJ2 * s.no * sinio / s.ecco
+	}
+	s.x1mth2 = 1 - cosio2
+	s.cc4 = 2 * s.no * coef1 * ao * omeosq * (s.eta*(2+0.5*etasq) + s.ecco*(0.5+2*etasq) -
+		sgp4J2*tsi/(ao*psisq)*(-3*s.con41*(1-2*eeta+etasq*(1.5-0.5*eeta))+0.75*s.x1mth2*(2*etasq-eeta*(1+etasq))*math.Cos(2*s.argpo)))
+	s.cc5 = 2 * coef1 * ao * omeosq * (1 + 2.75*(etasq+eeta) + eeta*etasq)
+
+	// Secular rates of the mean anomaly, argument of perigee, and node
+	cosio4 := cosio2 * cosio2
+	temp1 := 1.5 * sgp4J2 * pinvsq * s.no
+	temp2 := 0.5 * temp1 * sgp4J2 * pinvsq
+	temp3 := -0.46875 * sgp4J4 * pinvsq * pinvsq * s.no
+	s.mdot = s.no + 0.5*temp1*rteosq*s.con41 + 0.0625*temp2*rteosq*(13-78*cosio2+137*cosio4)
+	s.argpdot = -0.5*temp1*con42 + 0.0625*temp2*(7-114*cosio2+395*cosio4) + temp3*(3-36*cosio2+49*cosio4)
+	xhdot1 := -temp1 * cosio
+	s.nodedot = xhdot1 + (0.5*temp2*(4-19*cosio2)+2*temp3*(3-7*cosio2))*cosio
+	s.omgcof = s.bstar * cc3 * math.Cos(s.argpo)
+	if s.ecco > 1e-4 {
+		s.xmcof = -x2o3 * coef * s.bstar / eeta
+	}
+	s.nodecf = 3.5 * omeosq * xhdot1 * s.cc1
+	s.t2cof = 1.5 * s.cc1
+	if math.Abs(cosio+1) > 1.5e-12 {
+		s.xlcof = -0.25 * sgp4J3OverJ2 * sinio * (3 + 5*cosio) / (1 + cosio)
+	} else {
+		s.xlcof = -0.25 * sgp4J3OverJ2 * sinio * (3 + 5*cosio) / 1.5e-12
+	}
+	s.aycof = -0.5 * sgp4J3OverJ2 * sinio
+	s.delmo = math.Pow(1+s.eta*math.Cos(s.mo), 3)
+	s.sinmao = math.Sin(s.mo)
+	s.x7thm1 = 7*cosio2 - 1
+
+	if !s.isimp {
+		cc1sq := s.cc1 * s.cc1
+		s.d2 = 4 * ao * tsi * cc1sq
+		temp := s.d2 * tsi * s.cc1 / 3
+		s.d3 = (17*ao + sfour) * temp
+		s.d4 = 0.5 * temp * ao * tsi * (221*ao + 31*sfour) * s.cc1
+		s.t3cof = s.d2 + 2*cc1sq
+		s.t4cof = 0.25 * (3*s.d3 + s.cc1*(12*s.d2+10*cc1sq))
+		s.t5cof = 0.2 * (3*s.d4 + 12*s.cc1*s.d3 + 6*s.d2*s.d2 + 15*cc1sq*(2*s.d2+cc1sq))
+	}
+	return nil
+}
+
+// Propagate returns the satellite's position (km) and velocity (km/s) at t,
+// in the TEME frame (true equator, mean equinox of date)
+func (s *Satellite) Propagate(t time.Time) (position, velocity [3]float64, err error) {
+	return s.propagate(t.Sub(s.Epoch).Minutes())
+}
+
+// propagate runs SGP4 for tsince minutes after the epoch
+func (s *Satellite) propagate(tsince float64) (position, velocity [3]float64, err error) {
+	const x2o3 = 2.0 / 3.0
+	twoPi := 2 * math.Pi
+
+	// Secular gravity and atmospheric drag
+	xmdf := s.mo + s.mdot*tsince
+	argpdf := s.argpo + s.argpdot*tsince
+	nodedf := s.nodeo + s.nodedot*tsince
+	argpm, mm := argpdf, xmdf
+	t2 := tsince * tsince
+	nodem := nodedf + s.nodecf*t2
+	tempa := 1 - s.cc1*tsince
+	tempe := s.bstar * s.cc4 * tsince
+	templ := s.t2cof * t2
+	if !s.isimp {
+		delomg := s.omgcof * tsince
+		delm := s.xmcof * (math.Pow(1+s.eta*math.Cos(xmdf), 3) - s.delmo)
+		temp := delomg + delm
+		mm = xmdf + temp
+		argpm = argpdf - temp
+		t3 := t2 * tsince
+		t4 := t3 * tsince
+		tempa -= s.d2*t2 + s.d3*t3 + s.d4*t4
+		tempe += s.bstar * s.cc5 * (math.Sin(mm) - s.sinmao)
+		templ += s.t3cof*t3 + t4*(s.t4cof+tsince*s.t5cof)
+	}
+
+	am := math.Pow(sgp4XKE/s.no, x2o3) * tempa * tempa
+	nm := sgp4XKE / math.Pow(am, 1.5)
+	em := s.ecco - tempe
+	if em >= 1 || em < -0.001 || am < 0.95 {
+		return position, velocity, errors.New("satellite orbit has decayed")
+	}
+	em = math.Max(em, 1e-6)
+	mm += s.no * templ
+	xlm := mm + argpm + nodem
+	nodem = math.Mod(nodem, twoPi)
+	argpm = math.Mod(argpm, twoPi)
+	xlm = math.Mod(xlm, twoPi)
+	mm = math.Mod(xlm-argpm-nodem, twoPi)
+	sinim, cosim := math.Sin(s.inclo), math.Cos(s.inclo)
+
+	// Long-period periodics
+	axnl := em * math.Cos(argpm)
+	temp := 1 / (am * (1 - em*em))
+	aynl := em*math.Sin(argpm) + temp*s.aycof
+	xl := mm + argpm + nodem + temp*s.xlcof*axnl
+
+	// Solve Kepler's equation
+	u := math.Mod(xl-nodem, twoPi)
+	eo1 := u
+	var sineo1, coseo1 float64
+	for i := 0; i < 10; i++ {
+		sineo1, coseo1 = math.Sin(eo1), math.Cos(eo1)
+		delta := (u - aynl*coseo1 + axnl*sineo1 - eo1) / (1 - coseo1*axnl - sineo1*aynl)
+		if math.Abs(delta) >= 0.95 {
+			delta = math.Copysign(0.95, delta)
+		}
+		eo1 += delta
+		if math.Abs(delta) < 1e-12 {
+			break
+		}
+	}
+	sineo1, coseo1 = math.Sin(eo1), math.Cos(eo1)
+
+	// Short-period periodics
+	ecose := axnl*coseo1 + aynl*sineo1
+	esine := axnl*sineo1 - aynl*coseo1
+	el2 := axnl*axnl + aynl*aynl
+	pl := am * (1 - el2)
+	if pl < 0 {
+		return position, velocity, errors.New("satellite orbit has decayed")
+	}
+	rl := am * (1 - ecose)
+	rdotl := math.Sqrt(am) * esine / rl
+	rvdotl := math.Sqrt(pl) / rl
+	betal := math.Sqrt(1 - el2)
+	temp = esine / (1 + betal)
+	sinu := am / rl * (sineo1 - aynl - axnl*temp)
+	cosu := am / rl * (coseo1 - axnl + aynl*temp)
+	su := math.Atan2(sinu, cosu)
+	sin2u := (cosu + cosu) * sinu
+	cos2u := 1 - 2*sinu*sinu
+	temp = 1 / pl
+	temp1 := 0.5 * sgp4J2 * temp
+	temp2 := temp1 * temp
+
+	mrt := rl*(1-1.5*temp2*betal*s.con41) + 0.5*temp1*s.x1mth2*cos2u
+	if mrt < 1 {
+		return position, velocity, errors.New("satellite orbit has decayed")
+	}
+	su -= 0.25 * temp2 * s.x7thm1 * sin2u
+	xnode := nodem + 1.5*temp2*cosim*sin2u
+	xinc := s.inclo + 1.5*temp2*cosim*sinim*cos2u
+	mvt := rdotl - nm*temp1*s.x1mth2*sin2u/sgp4XKE
+	rvdot := rvdotl + nm*temp1*(s.x1mth2*cos2u+1.5*s.con41)/sgp4XKE
+
+	// Orientation vectors
+	sinsu, cossu := math.Sin(su), math.Cos(su)
+	snod, cnod := math.Sin(xnode), math.Cos(xnode)
+	sini, cosi := math.Sin(xinc), math.Cos(xinc)
+	xmx, xmy := -snod*cosi, cnod*cosi
+	ux, uy, uz := xmx*sinsu+cnod*cossu, xmy*sinsu+snod*cossu, sini*sinsu
+	vx, vy, vz := xmx*cossu-cnod*sinsu, xmy*cossu-snod*sinsu, sini*cossu
+
+	kmPerSecond := sgp4EarthRadius * sgp4XKE / 60
+	position = [3]float64{mrt * ux * sgp4EarthRadius, mrt * uy * sgp4EarthRadius, mrt * uz * sgp4EarthRadius}
+	velocity = [3]float64{(mvt*ux + rvdot*vx) * kmPerSecond, (mvt*uy + rvdot*vy) * kmPerSecond, (mvt*uz + rvdot*vz) * kmPerSecond}
+	return position, velocity, nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// vallado00005 is the first test case of Vallado et al., "Revisiting
+// Spacetrack Report #3" (2006), a low-perigee eccentric orbit
+var vallado00005 = TLE{
+	Name:  "00005",
+	Line1: "1 00005U 58002B   00179.78495062  .00000023  00000-0  28098-4 0  4753",
+	Line2: "2 00005  34.2682 348.7242 1859667 331.7664  19.3264 10.82419157413667",
+}
+
+func TestSatellite_Propagate(t *testing.T) {
+	sat, err := ParseTLE(vallado00005)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2000, 6, 27, 18, 50, 19, 733567000, time.UTC); sat.Epoch.Sub(want).Abs() > time.Millisecond {
+		t.Errorf("expected epoch %v, got %v", want, sat.Epoch)
+	}
+
+	// Vallado's reference state vectors (TEME, km and km/s)
+	tests := []struct {
+		minutes  float64
+		position [3]float64
+		velocity [3]float64
+	}{
+		{0, [3]float64{7022.46529266, -1400.08296755, 0.03995155}, [3]float64{1.893841015, 6.405893759, 4.534807250}},
+		{360, [3]float64{-7154.03120202, -3783.17682504, -3536.19412294}, [3]float64{4.741887409, -4.151817765, -2.093935425}},
+	}
+	for _, tt := range tests {
+		position, velocity, err := sat.Propagate(sat.Epoch.Add(time.Duration(tt.minutes * float64(time.Minute))))
+		if err != nil {
+			t.Fatalf("unexpected error at %v minutes: %v", tt.minutes, err)
+		}
+		for i := range 3 {
+			if math.Abs(position[i]-tt.position[i]) > 1e-3 {
+				t.Errorf("at %v minutes: expected position %v, got %v", tt.minutes, tt.position, position)
+				break
+			}
+			if math.Abs(velocity[i]-tt.velocity[i]) > 1e-6 {
+				t.Errorf("at %v minutes: expected velocity %v, got %v", tt.minutes, tt.velocity, velocity)
+				break
+			}
+		}
+	}
+}
+
+func TestParseTLE_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		tle     TLE
+		message string
+	}{
+		{"short line", TLE{Line1: vallado00005.Line1[:60], Line2: vallado00005.Line2}, "two 69-character lines"},
+		{"swapped lines", TLE{Line1: vallado00005.Line2, Line2: vallado00005.Line1}, "two 69-character lines"},
+		{"checksum", TLE{Line1: vallado00005.Line1[:68] + "0", Line2: vallado00005.Line2}, "checksum mismatch in line 1"},
+		{"deep space", TLE{
+			// A geostationary satellite
+			Line1: "1 28884U 05041A   25075.50000000  .00000100  00000-0  00000-0 0  9995",
+			Line2: "2 28884   0.0500  90.0000 0002000 180.0000 270.0000  1.00270000 70003",
+		}, "deep space"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTLE(tt.tle)
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TzeitAngle is the sun's depression below the horizon at nightfall (tzeit
+// hakochavim, when three small stars are visible), in degrees
+const TzeitAngle = 8.5
+
+// ShabbatOptions configures a Shabbat times calendar
+type ShabbatOptions struct {
+	Lat             float64
+	Lng             float64
+	Location        string
+	Timezone        *time.Location
+	CandleMinutes   int // Candle lighting, in minutes before Friday sunset
+	HavdalahMinutes int // Havdalah, in minutes after Saturday sunset; zero uses tzeit
+	Precision       int // Decimals of coordinates in descriptions and UIDs (1-4); 0 uses DefaultPrecision
+}
+
+// BuildShabbatEvents generates candle lighting events on Fridays and havdalah
+// events on Saturdays, for days days starting with the day containing start.
+// Days on which the sun does not set (or night does not fall) are skipped.
+func BuildShabbatEvents(opts ShabbatOptions, start time.Time, days int) []CalendarEvent {
+	local := start.In(opts.Timezone)
+	noon := time.Date(local.Year(), local.Month(), local.Day(), 12, 0, 0, 0, opts.Timezone)
+
+	var events []CalendarEvent
+	for i := 0; i < days; i++ {
+		day := noon.AddDate(0, 0, i)
+
+		switch day.Weekday() {
+		case time.Friday:
+			sunset := GetSunTimes(opts.Lat, opts.Lng, day).Sunset
+			if sunset == nil {
+				continue
+			}
+			t := sunset.Time.Add(-time.Duration(opts.CandleMinutes) * time.Minute).Truncate(time.Minute)
+			events = append(events, newShabbatEvent("candle_lighting", "Candle lighting", t, opts,
+				fmt.Sprintf("%d minutes before sunset (%s)", opts.CandleMinutes, sunset.Time.In(opts.Timezone).Format("15:04"))))
+
+		case time.Saturday:
+			var t time.Time
+			var rule string
+			if opts.HavdalahMinutes > 0 {
+				sunset := GetSunTimes(opts.Lat, opts.Lng, day).Sunset
+				if sunset == nil {
+					continue
+				}
+				t = sunset.Time.Add(time.Duration(opts.HavdalahMinutes) * time.Minute)
+				rule = fmt.Sprintf("%d minutes after sunset (%s)", opts.HavdalahMinutes, sunset.Time.In(opts.Timezone).Format("15:04"))
+			} else {
+				_, t = SunAltitudeTimes(opts.Lat, opts.Lng, day, -TzeitAngle)
+				if t.IsZero() {
+					continue
+				}
+				rule = fmt.Sprintf("Nightfall (sun %.1f° below the horizon)", TzeitAngle)
+			}
+			// Round up so havdalah is never early
+			if rounded := t.Truncate(time.Minute); rounded.Before(t) {
+				t = rounded.Add(time.Minute)
+			}
+			events = append(events, newShabbatEvent("havdalah", "Havdalah", t, opts, rule))
+		}
+	}
+	return events
+}
+
+// newShabbatEvent creates a 1-minute event of the given type
+func newShabbatEvent(eventType, title string, t time.Time, opts ShabbatOptions, rule string) CalendarEvent {
+	localTime := t.In(opts.Timezone)
+	return CalendarEvent{
+		UID:     locationUID(t, opts.Lat, opts.Lng, opts.Precision, eventType),
+		Type:    eventType,
+		Start:   t,
+		End:     t.Add(time.Minute),
+		Summary: fmt.Sprintf("%s %s", title, localTime.Format("15:04")),
+		Description: strings.Join([]string{
+			fmt.Sprintf("Time: %s", localTime.Format("15:04")),
+			fmt.Sprintf("Location: %s", opts.Location),
+			"Coordinates: " + FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+			"",
+			rule,
+		}, "\n"),
+		Location: opts.Location,
+	}
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildShabbatEvents(t *testing.T) {
+	tz := GetTimezone(40.7128, -74.0060)
+	opts := ShabbatOptions{Lat: 40.7128, Lng: -74.0060, Location: "New York", Timezone: tz, CandleMinutes: 18}
+
+	// Monday March 4 to Sunday March 10, 2024
+	events := BuildShabbatEvents(opts, time.Date(2024, 3, 4, 0, 0, 0, 0, tz), 7)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+
+	candles, havdalah := events[0], events[1]
+	if candles.Type != "candle_lighting" || havdalah.Type != "havdalah" {
+		t.Errorf("unexpected types: %s, %s", candles.Type, havdalah.Type)
+	}
+	if candles.Start.In(tz).Weekday() != time.Friday || havdalah.Start.In(tz).Weekday() != time.Saturday {
+		t.Error("expected candle lighting on Friday and havdalah on Saturday")
+	}
+
+	// Published times are 17:38 and about 18:38
+	if candles.Summary != "Candle lighting 17:38" {
+		t.Errorf("unexpected candle lighting: %s", candles.Summary)
+	}
+	want := time.Date(2024, 3, 9, 18, 38, 0, 0, tz)
+	if d := havdalah.Start.Sub(want).Abs(); d > 2*time.Minute {
+		t.Errorf("expected havdalah about 18:38, got %s", havdalah.Start.In(tz).Format("15:04"))
+	}
+	if havdalah.Start.Second() != 0 {
+		t.Error("expected havdalah on a whole minute")
+	}
+	if !strings.Contains(candles.Description, "18 minutes before sunset (17:56)") {
+		t.Errorf("unexpected description: %s", candles.Description)
+	}
+	if !strings.Contains(havdalah.Description, "Nightfall (sun 8.5° below the horizon)") {
+		t.Errorf("unexpected description: %s", havdalah.Description)
+	}
+}
+
+func TestBuildShabbatEvents_Minutes(t *testing.T) {
+	tz := GetTimezone(31.7683, 35.2137)
+	opts := ShabbatOptions{Lat: 31.7683, Lng: 35.2137, Timezone: tz, CandleMinutes: 40, HavdalahMinutes: 72}
+
+	events := BuildShabbatEvents(opts, time.Date(2024, 3, 8, 9, 0, 0, 0, tz), 2)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+
+	friday := GetSunTimes(31.7683, 35.2137, time.Date(2024, 3, 8, 12, 0, 0, 0, tz)).Sunset.Time
+	if d := friday.Sub(events[0].Start); d < 40*time.Minute || d >= 41*time.Minute {
+		t.Errorf("expected candle lighting 40 minutes before sunset, got %s", d)
+	}
+	saturday := GetSunTimes(31.7683, 35.2137, time.Date(2024, 3, 9, 12, 0, 0, 0, tz)).Sunset.Time
+	if d := events[1].Start.Sub(saturday); d < 72*time.Minute || d >= 73*time.Minute {
+		t.Errorf("expected havdalah 72 minutes after sunset, got %s", d)
+	}
+}
+
+func TestBuildShabbatEvents_PolarNight(t *testing.T) {
+	tz := GetTimezone(78.2232, 15.6267)
+	opts := ShabbatOptions{Lat: 78.2232, Lng: 15.6267, Timezone: tz, CandleMinutes: 18}
+
+	// No sunset in Longyearbyen around the winter solstice
+	events := BuildShabbatEvents(opts, time.Date(2024, 12, 16, 0, 0, 0, 0, tz), 7)
+	for _, event := range events {
+		if event.Type == "candle_lighting" {
+			t.Errorf("expected no candle lighting without a sunset, got %s", event.Summary)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// SolarPanel describes a panel orientation and when it is in productive sun
+type SolarPanel struct {
+	Azimuth      float64 // Direction the panel faces, in degrees clockwise from north
+	Spread       float64 // Degrees either side of Azimuth the sun may be
+	MinElevation float64 // Degrees above the horizon the sun must be
+}
+
+// Faces reports whether the panel is in productive sun at the given sun
+// position
+func (p SolarPanel) Faces(azimuth, elevation float64) bool {
+	diff := math.Mod(azimuth-p.Azimuth+540, 360) - 180
+	return elevation >= p.MinElevation && math.Abs(diff) <= p.Spread
+}
+
+// TimeRange is a span of time
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// sunWindowStep is the sampling interval when searching for sun windows
+const sunWindowStep = 5 * time.Minute
+
+// SunWindows returns the spans between from and to where cond holds for the
+// sun's azimuth and elevation, with edges refined to the second
+func SunWindows(lat, lng float64, from, to time.Time, cond func(azimuth, elevation float64) bool) []TimeRange {
+	holds := func(t time.Time) bool {
+		return cond(SunPosition(lat, lng, t))
+	}
+	// refine bisects for the edge between a, where cond is was, and b
+	refine := func(a, b time.Time, was bool) time.Time {
+		for b.Sub(a) > time.Second {
+			mid := a.Add(b.Sub(a) / 2)
+			if holds(mid) == was {
+				a = mid
+			} else {
+				b = mid
+			}
+		}
+		return b.Round(time.Second)
+	}
+
+	var windows []TimeRange
+	var start time.Time
+	inside := holds(from)
+	if inside {
+		start = from
+	}
+	for t := from; t.Before(to); {
+		next := t.Add(sunWindowStep)
+		if next.After(to) {
+			next = to
+		}
+		if now := holds(next); now != inside {
+			edge := refine(t, next, inside)
+			if now {
+				start = edge
+			} else {
+				windows = append(windows, TimeRange{start, edge})
+			}
+			inside = now
+		}
+		t = next
+	}
+	if inside {
+		windows = append(windows, TimeRange{start, to})
+	}
+	return windows
+}
+
+// BuildSolarEvents generates "peak production window" events spanning the
+// times each day that the sun is high enough and in front of the panel
+func BuildSolarEvents(opts CalendarOptions, panel SolarPanel, start time.Time, days int) []CalendarEvent {
+	var events []CalendarEvent
+	for i := 0; i < days; i++ {
+		noon := GetDayTimes(opts.Lat, opts.Lng, start.AddDate(0, 0, i)).SolarNoon
+		for j, w := range SunWindows(opts.Lat, opts.Lng, noon.Add(-12*time.Hour), noon.Add(12*time.Hour), panel.Faces) {
+			events = append(events, newSolarEvent(w, j, panel, opts))
+		}
+	}
+	return events
+}
+
+// newSolarEvent creates an event spanning the day's index'th production window
+func newSolarEvent(w TimeRange, index int, panel SolarPanel, opts CalendarOptions) CalendarEvent {
+	// Find the sun's highest point within the window, to the minute
+	var peakTime time.Time
+	peak := -90.0
+	for t := w.Start; !t.After(w.End); t = t.Add(time.Minute) {
+		if _, elevation := SunPosition(opts.Lat, opts.Lng, t); elevation > peak {
+			peak, peakTime = elevation, t
+		}
+	}
+
+	lines := []string{
+		fmt.Sprintf("Time: %s (%s)", opts.ClockSpan(w.Start, w.End, " to "), FormatDuration(w.End.Sub(w.Start))),
+		fmt.Sprintf("Location: %s", opts.Location),
+		"Coordinates: " + FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+		"",
+		fmt.Sprintf("Panel: facing %s (%.0f°), sun within %.0f° and above %.0f°", CompassPoint(panel.Azimuth), panel.Azimuth, panel.Spread, panel.MinElevation),
+		fmt.Sprintf("Peak elevation: %s at %s", opts.Locale.Degrees(peak, 1), peakTime.In(opts.Timezone).Format("15:04")),
+	}
+
+	return CalendarEvent{
+		UID:         locationUID(w.Start, opts.Lat, opts.Lng, opts.Precision, fmt.Sprintf("solar_window-%d", index)),
+		Type:        "solar_window",
+		Start:       w.Start,
+		End:         w.End,
+		Summary:     "Peak production window",
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+	}
+}
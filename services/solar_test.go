@@ -0,0 +1,96 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSolarPanelFaces(t *testing.T) {
+	south := SolarPanel{Azimuth: 180, Spread: 60, MinElevation: 15}
+	north := SolarPanel{Azimuth: 0, Spread: 30, MinElevation: 10}
+
+	tests := []struct {
+		panel     SolarPanel
+		azimuth   float64
+		elevation float64
+		want      bool
+	}{
+		{south, 180, 40, true},
+		{south, 120, 40, true},
+		{south, 119, 40, false},
+		{south, 180, 14, false},
+		{north, 350, 20, true}, // Wraps around north
+		{north, 25, 20, true},
+		{north, 31, 20, false},
+	}
+	for _, tt := range tests {
+		if got := tt.panel.Faces(tt.azimuth, tt.elevation); got != tt.want {
+			t.Errorf("%+v at %g°/%g°: got %v, want %v", tt.panel, tt.azimuth, tt.elevation, got, tt.want)
+		}
+	}
+}
+
+func TestSunWindows(t *testing.T) {
+	from := time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC)
+	above := func(_, elevation float64) bool { return elevation > -0.833 } // Sunrise/sunset altitude
+
+	// Above the horizon from about sunrise to sunset
+	windows := SunWindows(55.6761, 12.5683, from, from.Add(24*time.Hour), above)
+	if len(windows) != 1 {
+		t.Fatalf("expected 1 window, got %d", len(windows))
+	}
+	times := GetDayTimes(55.6761, 12.5683, from.Add(12*time.Hour))
+	if d := windows[0].Start.Sub(times.Sunrise).Abs(); d > 2*time.Minute {
+		t.Errorf("expected window to start near sunrise, off by %s", d)
+	}
+	if d := windows[0].End.Sub(times.Sunset).Abs(); d > 2*time.Minute {
+		t.Errorf("expected window to end near sunset, off by %s", d)
+	}
+
+	// Midnight sun: the whole range
+	windows = SunWindows(78.2232, 15.6267, from, from.Add(24*time.Hour), above)
+	if len(windows) != 1 || !windows[0].Start.Equal(from) || !windows[0].End.Equal(from.Add(24*time.Hour)) {
+		t.Errorf("expected the whole day, got %v", windows)
+	}
+}
+
+func TestBuildSolarEvents(t *testing.T) {
+	tz := GetTimezone(55.6761, 12.5683)
+	opts := CalendarOptions{Lat: 55.6761, Lng: 12.5683, Location: "Copenhagen", Timezone: tz}
+	panel := SolarPanel{Azimuth: 180, Spread: 45, MinElevation: 20}
+
+	events := BuildSolarEvents(opts, panel, time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), 2)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+
+	event := events[0]
+	noon := GetDayTimes(55.6761, 12.5683, event.Start).SolarNoon
+	if !event.Start.Before(noon) || !event.End.After(noon) {
+		t.Errorf("expected the window around solar noon %s, got %s to %s", noon, event.Start, event.End)
+	}
+	if d := event.End.Sub(event.Start); d < 3*time.Hour || d > 5*time.Hour {
+		t.Errorf("unexpected window length %s", d)
+	}
+	for _, edge := range []time.Time{event.Start, event.End} {
+		if azimuth, _ := SunPosition(55.6761, 12.5683, edge); azimuth < 134 || azimuth > 226 {
+			t.Errorf("expected window edges at the azimuth limits, got %.1f°", azimuth)
+		}
+	}
+	if event.Type != "solar_window" || event.Summary != "Peak production window" {
+		t.Errorf("unexpected event: %s (%s)", event.Summary, event.Type)
+	}
+	if !strings.Contains(event.Description, "Panel: facing S (180°), sun within 45° and above 20°") || !strings.Contains(event.Description, "Peak elevation: 57.") {
+		t.Errorf("unexpected description: %s", event.Description)
+	}
+}
+
+func TestBuildSolarEvents_LowSun(t *testing.T) {
+	// The sun stays below 20° in Copenhagen in December
+	opts := CalendarOptions{Lat: 55.6761, Lng: 12.5683, Timezone: time.UTC}
+	panel := SolarPanel{Azimuth: 180, Spread: 90, MinElevation: 20}
+	if events := BuildSolarEvents(opts, panel, time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC), 3); len(events) != 0 {
+		t.Errorf("expected no events, got %d", len(events))
+	}
+}
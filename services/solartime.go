@@ -0,0 +1,55 @@
+package services
+
+import (
+	"math"
+	"time"
+)
+
+// SolarTime is the time a sundial shows at a longitude. Mean and Apparent
+// are the same instant as the clock time they were computed for, in zones
+// whose wall clock reads the solar time, so their offsets from the location's
+// zone give the difference from clock time.
+type SolarTime struct {
+	Mean           time.Time     // Local mean solar time, from the longitude alone
+	Apparent       time.Time     // Local apparent (true) solar time, as a sundial shows it
+	EquationOfTime time.Duration // Apparent minus mean solar time, within about ±16 minutes
+}
+
+// GetSolarTime returns the mean and apparent solar time at longitude lng at t
+func GetSolarTime(t time.Time, lng float64) SolarTime {
+	mean := time.Duration(lng * 4 * float64(time.Minute)).Round(time.Second)
+	eot := EquationOfTime(t).Round(time.Second)
+	return SolarTime{
+		Mean:           t.In(time.FixedZone("LMT", int(mean.Seconds()))),
+		Apparent:       t.In(time.FixedZone("LAT", int((mean + eot).Seconds()))),
+		EquationOfTime: eot,
+	}
+}
+
+// Offset returns how far the apparent solar time is ahead of the clock time
+// in tz (negative when the sundial is behind the clock)
+func (s SolarTime) Offset(tz *time.Location) time.Duration {
+	_, solar := s.Apparent.Zone()
+	_, clock := s.Apparent.In(tz).Zone()
+	return time.Duration(solar-clock) * time.Second
+}
+
+// HourAngle returns the sun's hour angle in degrees, from -180 to 180:
+// negative in the morning, 0 at solar noon, and 15° for every hour after
+func (s SolarTime) HourAngle() float64 {
+	midnight := time.Date(s.Apparent.Year(), s.Apparent.Month(), s.Apparent.Day(), 0, 0, 0, 0, s.Apparent.Location())
+	hours := s.Apparent.Sub(midnight).Hours()
+	return (hours - 12) * 15
+}
+
+// EquationOfTime returns how far apparent solar time runs ahead of mean
+// solar time at t, using the NOAA approximation (accurate to about 30
+// seconds)
+func EquationOfTime(t time.Time) time.Duration {
+	t = t.UTC()
+	hours := float64(t.Hour()) + float64(t.Minute())/60 + float64(t.Second())/3600
+	gamma := 2 * math.Pi / 365 * (float64(t.YearDay()-1) + (hours-12)/24)
+	minutes := 229.18 * (0.000075 + 0.001868*math.Cos(gamma) - 0.032077*math.Sin(gamma) -
+		0.014615*math.Cos(2*gamma) - 0.040849*math.Sin(2*gamma))
+	return time.Duration(minutes * float64(time.Minute))
+}
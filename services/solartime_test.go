@@ -0,0 +1,63 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEquationOfTime(t *testing.T) {
+	tests := []struct {
+		date time.Time
+		want float64 // Minutes
+	}{
+		{time.Date(2025, 2, 11, 12, 0, 0, 0, time.UTC), -14.2},
+		{time.Date(2025, 5, 14, 12, 0, 0, 0, time.UTC), 3.7},
+		{time.Date(2025, 7, 26, 12, 0, 0, 0, time.UTC), -6.5},
+		{time.Date(2025, 11, 3, 12, 0, 0, 0, time.UTC), 16.4},
+	}
+	for _, tt := range tests {
+		if got := EquationOfTime(tt.date).Minutes(); math.Abs(got-tt.want) > 0.5 {
+			t.Errorf("%s: got %.1f minutes, want %.1f", tt.date.Format(time.DateOnly), got, tt.want)
+		}
+	}
+}
+
+func TestGetSolarTime(t *testing.T) {
+	copenhagen, _ := time.LoadLocation("Europe/Copenhagen")
+	lng := 12.5683
+
+	// At solar noon the sundial reads 12:00, within the minute or so the
+	// solar noon approximation is off by
+	noon := GetDayTimes(55.6761, lng, time.Date(2025, 11, 3, 12, 0, 0, 0, time.UTC)).SolarNoon
+	solar := GetSolarTime(noon, lng)
+	if clock := solar.Apparent.Format("15:04:05"); clock < "11:58:30" || clock > "12:01:30" {
+		t.Errorf("expected 12:00 at solar noon, got %s", clock)
+	}
+	if math.Abs(solar.HourAngle()) > 0.4 {
+		t.Errorf("expected an hour angle near 0 at solar noon, got %.2f", solar.HourAngle())
+	}
+
+	// 12.5683° east is 50m16s ahead of UTC, so 9m44s behind CET; in early
+	// November apparent time runs 16m26s ahead of mean time, so the sundial
+	// reads 10:06:42
+	solar = GetSolarTime(time.Date(2025, 11, 3, 9, 0, 0, 0, time.UTC), lng)
+	if got := solar.Mean.Format("15:04:05"); got != "09:50:16" {
+		t.Errorf("expected mean solar time 09:50:16, got %s", got)
+	}
+	if !solar.Apparent.Equal(solar.Mean) {
+		t.Error("expected solar times at the same instant as the clock time")
+	}
+	if offset := solar.Offset(copenhagen); offset < 6*time.Minute || offset > 7*time.Minute {
+		t.Errorf("expected the sundial about 6.7 minutes ahead of the clock, got %v", offset)
+	}
+	if angle := solar.HourAngle(); angle > -28 || angle < -28.7 {
+		t.Errorf("expected an hour angle near -28.3°, got %.2f", angle)
+	}
+
+	// Daylight saving time puts the clock an hour further ahead
+	solar = GetSolarTime(time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC), lng)
+	if offset := solar.Offset(copenhagen); offset > -73*time.Minute || offset < -74*time.Minute {
+		t.Errorf("expected the sundial about 73 minutes behind the clock, got %v", offset)
+	}
+}
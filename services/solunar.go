@@ -0,0 +1,80 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Solunar periods are centred on the moon's transits (major) and on moonrise
+// and moonset (minor)
+const (
+	SolunarMajorDuration = 2 * time.Hour
+	SolunarMinorDuration = time.Hour
+)
+
+// solunarPeriod is a major or minor period and the moon event it is centred on
+type solunarPeriod struct {
+	eventType string // "solunar_major" or "solunar_minor"
+	source    string // Moon event, e.g. "Moon overhead"
+	center    time.Time
+}
+
+// BuildSolunarEvents generates solunar major and minor feeding period events
+// for the local days starting at start. Major periods last two hours around
+// the moon's upper and lower transits, minor periods one hour around moonrise
+// and moonset.
+func BuildSolunarEvents(opts CalendarOptions, start time.Time, days int) []CalendarEvent {
+	var events []CalendarEvent
+	for _, moon := range GetMoonDaysRange(opts.Lat, opts.Lng, start, days, opts.Timezone) {
+		periods := []solunarPeriod{
+			{"solunar_major", "Moon overhead", moon.Transit},
+			{"solunar_major", "Moon underfoot", moon.UnderTransit},
+			{"solunar_minor", "Moonrise", moon.Rise},
+			{"solunar_minor", "Moonset", moon.Set},
+		}
+		var day []CalendarEvent
+		for _, p := range periods {
+			if !p.center.IsZero() {
+				day = append(day, newSolunarEvent(p, opts))
+			}
+		}
+		sort.Slice(day, func(i, j int) bool { return day[i].Start.Before(day[j].Start) })
+		events = append(events, day...)
+	}
+	return events
+}
+
+// newSolunarEvent creates an event spanning a solunar period
+func newSolunarEvent(p solunarPeriod, opts CalendarOptions) CalendarEvent {
+	title, duration := "Major solunar period", SolunarMajorDuration
+	if p.eventType == "solunar_minor" {
+		title, duration = "Minor solunar period", SolunarMinorDuration
+	}
+	start := p.center.Add(-duration / 2).Truncate(time.Minute)
+	end := start.Add(duration)
+	phase := GetMoonPhase(p.center)
+
+	lines := []string{
+		fmt.Sprintf("Time: %s", opts.ClockSpan(start, end, " to ")),
+		fmt.Sprintf("Location: %s", opts.Location),
+		"Coordinates: " + FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+		"",
+		fmt.Sprintf("%s: %s", p.source, p.center.In(opts.Timezone).Format("15:04")),
+		fmt.Sprintf("Moon: %s (%d%% illuminated)", phase.Name, int(phase.Illumination*100+0.5)),
+	}
+	if phase.Name == "New moon" || phase.Name == "Full moon" {
+		lines = append(lines, "Peak activity expected around the new and full moon")
+	}
+
+	return CalendarEvent{
+		UID:         locationUID(p.center, opts.Lat, opts.Lng, opts.Precision, p.eventType+"-"+strings.ToLower(p.source)),
+		Type:        p.eventType,
+		Start:       start,
+		End:         end,
+		Summary:     fmt.Sprintf("%s %s", title, opts.Clock(start, "15:04")),
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+	}
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildSolunarEvents(t *testing.T) {
+	tz := GetTimezone(40.7128, -74.0060)
+	opts := CalendarOptions{Lat: 40.7128, Lng: -74.0060, Location: "New York", Timezone: tz}
+	start := time.Date(2024, 1, 22, 0, 0, 0, 0, tz)
+
+	events := BuildSolunarEvents(opts, start, 1)
+	if len(events) != 4 {
+		t.Fatalf("expected 4 periods, got %d", len(events))
+	}
+
+	var majors, minors int
+	for i, event := range events {
+		switch event.Type {
+		case "solunar_major":
+			majors++
+			if event.End.Sub(event.Start) != SolunarMajorDuration {
+				t.Errorf("expected a 2 hour major period, got %s", event.End.Sub(event.Start))
+			}
+		case "solunar_minor":
+			minors++
+			if event.End.Sub(event.Start) != SolunarMinorDuration {
+				t.Errorf("expected a 1 hour minor period, got %s", event.End.Sub(event.Start))
+			}
+		}
+		if i > 0 && event.Start.Before(events[i-1].Start) {
+			t.Error("expected periods in chronological order")
+		}
+	}
+	if majors != 2 || minors != 2 {
+		t.Errorf("expected 2 major and 2 minor periods, got %d and %d", majors, minors)
+	}
+
+	// Moon overhead at about 22:07 local time
+	moon := GetMoonDay(40.7128, -74.0060, start, tz)
+	last := events[3]
+	if !last.Start.Before(moon.Transit) || !last.End.After(moon.Transit) || !strings.HasPrefix(last.Summary, "Major solunar period 21:0") {
+		t.Errorf("expected the last period around the transit at %s, got %s", moon.Transit.In(tz), last.Summary)
+	}
+	if !strings.Contains(last.Description, "Moon overhead: 22:0") || !strings.Contains(last.Description, "Moon: Waxing gibbous") {
+		t.Errorf("unexpected description: %s", last.Description)
+	}
+}
+
+func TestBuildSolunarEvents_FullMoon(t *testing.T) {
+	opts := CalendarOptions{Lat: 55.6761, Lng: 12.5683, Timezone: time.UTC}
+	for _, event := range BuildSolunarEvents(opts, time.Date(2024, 1, 25, 0, 0, 0, 0, time.UTC), 1) {
+		if !strings.Contains(event.Description, "Peak activity expected") {
+			t.Errorf("expected peak activity note at full moon: %s", event.Description)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+// SpokenTime formats a clock time the way it is read aloud, e.g. "6:45 in
+// the morning", "7 in the evening", "noon"
+func SpokenTime(t time.Time) string {
+	hour, minute := t.Hour(), t.Minute()
+	switch {
+	case hour == 12 && minute == 0:
+		return "noon"
+	case hour == 0 && minute == 0:
+		return "midnight"
+	}
+
+	var period string
+	switch {
+	case hour < 5:
+		period = "at night"
+	case hour < 12:
+		period = "in the morning"
+	case hour < 17:
+		period = "in the afternoon"
+	case hour < 21:
+		period = "in the evening"
+	default:
+		period = "at night"
+	}
+
+	hour12 := hour % 12
+	if hour12 == 0 {
+		hour12 = 12
+	}
+	if minute == 0 {
+		return fmt.Sprintf("%d %s", hour12, period)
+	}
+	return fmt.Sprintf("%d:%02d %s", hour12, minute, period)
+}
+
+// SpokenDuration formats a duration in words, e.g. "11 hours and 20 minutes"
+func SpokenDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+
+	switch {
+	case hours == 0:
+		return plural(minutes, "minute")
+	case minutes == 0:
+		return plural(hours, "hour")
+	default:
+		return plural(hours, "hour") + " and " + plural(minutes, "minute")
+	}
+}
+
+// ISODuration formats a duration as ISO 8601 to the minute, e.g. "PT11H20M"
+func ISODuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("PT%dM", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("PT%dH", hours)
+	default:
+		return fmt.Sprintf("PT%dH%dM", hours, minutes)
+	}
+}
+
+// plural returns "1 unit" or "n units"
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpokenTime(t *testing.T) {
+	tests := []struct {
+		hour, minute int
+		want         string
+	}{
+		{6, 45, "6:45 in the morning"},
+		{7, 0, "7 in the morning"},
+		{12, 0, "noon"},
+		{12, 30, "12:30 in the afternoon"},
+		{16, 5, "4:05 in the afternoon"},
+		{18, 14, "6:14 in the evening"},
+		{22, 10, "10:10 at night"},
+		{0, 0, "midnight"},
+		{0, 40, "12:40 at night"},
+		{3, 20, "3:20 at night"},
+	}
+
+	for _, tt := range tests {
+		got := SpokenTime(time.Date(2024, 3, 10, tt.hour, tt.minute, 0, 0, time.UTC))
+		if got != tt.want {
+			t.Errorf("%02d:%02d: expected %q, got %q", tt.hour, tt.minute, tt.want, got)
+		}
+	}
+}
+
+func TestSpokenDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{11*time.Hour + 20*time.Minute, "11 hours and 20 minutes"},
+		{time.Hour + time.Minute, "1 hour and 1 minute"},
+		{8 * time.Hour, "8 hours"},
+		{45 * time.Minute, "45 minutes"},
+		{29 * time.Second, "0 minutes"},
+	}
+
+	for _, tt := range tests {
+		if got := SpokenDuration(tt.d); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.d, tt.want, got)
+		}
+	}
+}
+
+func TestISODuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{11*time.Hour + 20*time.Minute + 10*time.Second, "PT11H20M"},
+		{8 * time.Hour, "PT8H"},
+		{45 * time.Minute, "PT45M"},
+	}
+
+	for _, tt := range tests {
+		if got := ISODuration(tt.d); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.d, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import "time"
+
+// SunExtreme is an extreme of a sun value over a range of days
+type SunExtreme struct {
+	Date  time.Time     // The day, as passed to GetSunTimes
+	Time  time.Time     // The event, for sunrises and sunsets
+	Value time.Duration // Local clock time since midnight, or day length
+}
+
+// SunStats aggregates sun times over a range of days. Sunrise and sunset
+// extremes compare local clock times and are nil if the sun never rises or
+// sets in the range; day length extremes are nil if no day has a length
+// (see DaySunTimes.DayLength). The mean and total cover the days with a
+// length.
+type SunStats struct {
+	Days            int
+	EarliestSunrise *SunExtreme
+	LatestSunrise   *SunExtreme
+	EarliestSunset  *SunExtreme
+	LatestSunset    *SunExtreme
+	ShortestDay     *SunExtreme
+	LongestDay      *SunExtreme
+	MeanDayLength   time.Duration
+	TotalDaylight   time.Duration
+}
+
+// SummarizeSunTimes aggregates days, in date order, with clock times in tz.
+// Ties keep the earliest day.
+func SummarizeSunTimes(days []DaySunTimes, tz *time.Location) SunStats {
+	stats := SunStats{Days: len(days)}
+	var lengthDays int
+	for _, day := range days {
+		if day.Sunrise != nil {
+			e := SunExtreme{Date: day.Date, Time: day.Sunrise.Time, Value: clockTime(day.Sunrise.Time.In(tz))}
+			stats.EarliestSunrise = minExtreme(stats.EarliestSunrise, e)
+			stats.LatestSunrise = maxExtreme(stats.LatestSunrise, e)
+		}
+		if day.Sunset != nil {
+			e := SunExtreme{Date: day.Date, Time: day.Sunset.Time, Value: clockTime(day.Sunset.Time.In(tz))}
+			stats.EarliestSunset = minExtreme(stats.EarliestSunset, e)
+			stats.LatestSunset = maxExtreme(stats.LatestSunset, e)
+		}
+		if dayLength, ok := day.DayLength(); ok {
+			e := SunExtreme{Date: day.Date, Value: dayLength}
+			stats.ShortestDay = minExtreme(stats.ShortestDay, e)
+			stats.LongestDay = maxExtreme(stats.LongestDay, e)
+			stats.TotalDaylight += dayLength
+			lengthDays++
+		}
+	}
+	if lengthDays > 0 {
+		stats.MeanDayLength = (stats.TotalDaylight / time.Duration(lengthDays)).Round(time.Second)
+	}
+	return stats
+}
+
+// minExtreme returns e if it is below cur (or cur is nil), else cur
+func minExtreme(cur *SunExtreme, e SunExtreme) *SunExtreme {
+	if cur == nil || e.Value < cur.Value {
+		return &e
+	}
+	return cur
+}
+
+// maxExtreme returns e if it is above cur (or cur is nil), else cur
+func maxExtreme(cur *SunExtreme, e SunExtreme) *SunExtreme {
+	if cur == nil || e.Value > cur.Value {
+		return &e
+	}
+	return cur
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizeSunTimes(t *testing.T) {
+	tz := GetTimezone(55.6761, 12.5683)
+	days := GetSunTimesRange(55.6761, 12.5683, time.Date(2025, 3, 1, 12, 0, 0, 0, tz), 31)
+
+	stats := SummarizeSunTimes(days, tz)
+	if stats.Days != 31 {
+		t.Errorf("expected 31 days, got %d", stats.Days)
+	}
+	// The clocks go forward on March 30, so the earliest sunrise is the 29th
+	if got := stats.EarliestSunrise.Date.Day(); got != 29 {
+		t.Errorf("expected the earliest sunrise on March 29, got %d", got)
+	}
+	if got := stats.LatestSunrise.Date.Day(); got != 1 {
+		t.Errorf("expected the latest sunrise on March 1, got %d", got)
+	}
+	if got := stats.LatestSunset.Date.Day(); got != 31 {
+		t.Errorf("expected the latest sunset on March 31, got %d", got)
+	}
+	if stats.ShortestDay.Date.Day() != 1 || stats.LongestDay.Date.Day() != 31 || stats.ShortestDay.Value >= stats.LongestDay.Value {
+		t.Errorf("unexpected day length extremes %+v, %+v", stats.ShortestDay, stats.LongestDay)
+	}
+	if stats.MeanDayLength <= stats.ShortestDay.Value || stats.MeanDayLength >= stats.LongestDay.Value {
+		t.Errorf("expected the mean day length between the extremes, got %v", stats.MeanDayLength)
+	}
+	if mean := stats.TotalDaylight / 31; (mean - stats.MeanDayLength).Abs() > time.Second {
+		t.Errorf("expected the total to be 31 mean days, got %v", stats.TotalDaylight)
+	}
+	if got := stats.EarliestSunrise.Time.In(tz).Format("15:04"); got != "05:49" {
+		t.Errorf("expected the earliest sunrise at 05:49, got %s", got)
+	}
+}
+
+func TestSummarizeSunTimes_PolarNight(t *testing.T) {
+	days := GetSunTimesRange(78.2232, 15.6267, time.Date(2024, 12, 1, 12, 0, 0, 0, time.UTC), 31)
+
+	stats := SummarizeSunTimes(days, time.UTC)
+	if stats.EarliestSunrise != nil || stats.LatestSunset != nil {
+		t.Errorf("expected no sunrises or sunsets, got %+v", stats)
+	}
+	if stats.ShortestDay == nil || stats.LongestDay.Value != 0 || stats.TotalDaylight != 0 {
+		t.Errorf("expected zero-length days, got %+v", stats.LongestDay)
+	}
+}
+
+func BenchmarkSummarizeSunTimes(b *testing.B) {
+	tz := GetTimezone(55.6761, 12.5683)
+	days := GetSunTimesRange(55.6761, 12.5683, time.Date(2024, 1, 1, 12, 0, 0, 0, tz), 366)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		SummarizeSunTimes(days, tz)
+	}
+}
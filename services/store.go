@@ -0,0 +1,25 @@
+package services
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// writeJSONAtomic writes v as indented JSON to path, readable only by the
+// server, replacing the file atomically so a crash cannot leave it truncated
+func writeJSONAtomic(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
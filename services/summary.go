@@ -0,0 +1,170 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Daylight summary periods
+const (
+	SummaryWeekly  = "weekly"
+	SummaryMonthly = "monthly"
+)
+
+// SummaryPeriods lists the daylight summary periods
+var SummaryPeriods = []string{SummaryWeekly, SummaryMonthly}
+
+// BuildWeeklySummaryEvents generates an all-day event on each Sunday in the
+// range summarizing the daylight gained or lost over the week ending that
+// day, and since the most recent solstice (e.g., "Daylight this week: +17m
+// 32s"). Polar days and nights count as 24 and 0 hours; weeks ending on a
+// day when the sun only rises or only sets are skipped.
+func BuildWeeklySummaryEvents(opts CalendarOptions, start time.Time, days int) []CalendarEvent {
+	var events []CalendarEvent
+	for i := 0; i < days; i++ {
+		date := start.AddDate(0, 0, i)
+		local := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, opts.Timezone)
+		if local.Weekday() != time.Sunday {
+			continue
+		}
+		weekStart := local.AddDate(0, 0, -6)
+		cur, ok := localDayLength(opts, local)
+		prev, prevOK := localDayLength(opts, local.AddDate(0, 0, -7))
+		if !ok || !prevOK {
+			continue
+		}
+		events = append(events, newSummaryEvent(opts, SummaryWeekly, weekStart, local, cur, cur-prev, "this week"))
+	}
+	return events
+}
+
+// BuildMonthlySummaryEvents generates an all-day event on the first of each
+// month in the range summarizing the month ahead: the earliest and latest
+// sunrise, the range of day lengths, and the full moons, equinoxes, and
+// solstices in it
+func BuildMonthlySummaryEvents(opts CalendarOptions, start time.Time, days int) []CalendarEvent {
+	var events []CalendarEvent
+	for i := 0; i < days; i++ {
+		date := start.AddDate(0, 0, i)
+		if date.Day() != 1 {
+			continue
+		}
+		first := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, opts.Timezone)
+		events = append(events, newMonthlySummaryEvent(opts, first))
+	}
+	return events
+}
+
+// newMonthlySummaryEvent creates the summary event of the month starting on
+// first
+func newMonthlySummaryEvent(opts CalendarOptions, first time.Time) CalendarEvent {
+	next := first.AddDate(0, 1, 0)
+	noon := time.Date(first.Year(), first.Month(), 1, 12, 0, 0, 0, opts.Timezone)
+	stats := SummarizeSunTimes(GetSunTimesRange(opts.Lat, opts.Lng, noon, daysBetween(first, next)), opts.Timezone)
+	month := first.Format("January 2006")
+
+	summary := month + " sun summary"
+	var lines []string
+	if stats.EarliestSunrise != nil {
+		lines = append(lines, fmt.Sprintf("Sunrise: earliest %s, latest %s", formatClockExtreme(*stats.EarliestSunrise, opts), formatClockExtreme(*stats.LatestSunrise, opts)))
+	} else {
+		lines = append(lines, "Sunrise: none this month")
+	}
+	if stats.ShortestDay != nil {
+		shortest, longest := *stats.ShortestDay, *stats.LongestDay
+		if shortest.Value == longest.Value {
+			// Polar day or night all month
+			lines = append(lines, fmt.Sprintf("Day length: %s every day", FormatDuration(shortest.Value)))
+			summary = fmt.Sprintf("%s: %s of daylight every day", month, FormatDuration(shortest.Value))
+		} else {
+			lines = append(lines, fmt.Sprintf("Day length: %s (%s) to %s (%s)", FormatDuration(shortest.Value), opts.Locale.MonthDay(shortest.Date), FormatDuration(longest.Value), opts.Locale.MonthDay(longest.Date)))
+			summary = fmt.Sprintf("%s: %s to %s of daylight", month, FormatDuration(shortest.Value), FormatDuration(longest.Value))
+		}
+	}
+	for _, full := range FullMoons(first, next) {
+		local := full.In(opts.Timezone)
+		lines = append(lines, "Full moon: "+opts.Locale.MonthDay(local)+local.Format(" at 15:04"))
+	}
+	for e := MarchEquinox; e <= DecemberSolstice; e++ {
+		if t := e.Time(first.Year()).In(opts.Timezone); !t.Before(first) && t.Before(next) {
+			lines = append(lines, fmt.Sprintf("%s: %s at %s", e.Name(), opts.Locale.MonthDay(t), t.Format("15:04")))
+		}
+	}
+	lines = append(lines,
+		fmt.Sprintf("Location: %s", opts.Location),
+		"Coordinates: "+FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+	)
+
+	return CalendarEvent{
+		UID:         locationUID(first, opts.Lat, opts.Lng, opts.Precision, "summary-"+SummaryMonthly),
+		Type:        "daylight_summary_" + SummaryMonthly,
+		Start:       first,
+		End:         first.AddDate(0, 0, 1),
+		Summary:     summary,
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+		AllDay:      true,
+	}
+}
+
+// formatClockExtreme formats a sunrise or sunset extreme as its local time
+// and date (e.g., "07:54 (January 31)")
+func formatClockExtreme(e SunExtreme, opts CalendarOptions) string {
+	local := e.Time.In(opts.Timezone)
+	return fmt.Sprintf("%s (%s)", local.Format("15:04"), opts.Locale.MonthDay(local))
+}
+
+// localDayLength returns the day length on a local date, with polar days
+// and nights as 24 and 0 hours
+func localDayLength(opts CalendarOptions, date time.Time) (time.Duration, bool) {
+	noon := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, opts.Timezone)
+	return GetSunTimes(opts.Lat, opts.Lng, noon).DayLength()
+}
+
+// previousSolstice returns the season starting at the latest solstice on or
+// before a local date at a latitude (summer or winter, named for the
+// hemisphere) and its instant
+func previousSolstice(lat float64, date time.Time) (string, time.Time) {
+	end := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location()).AddDate(0, 0, 1)
+	var season string
+	var latest time.Time
+	for year := date.Year() - 1; year <= date.Year(); year++ {
+		for _, s := range []string{SeasonSummer, SeasonWinter} {
+			if t := SeasonStart(s, lat).Time(year); t.Before(end) && t.After(latest) {
+				season, latest = s, t
+			}
+		}
+	}
+	return season, latest
+}
+
+// newSummaryEvent creates an all-day daylight summary event on last, the
+// final day of a period starting on first
+func newSummaryEvent(opts CalendarOptions, period string, first, last time.Time, dayLength, change time.Duration, during string) CalendarEvent {
+	lines := []string{
+		fmt.Sprintf("Period: %s – %s", opts.Locale.WeekdayDate(first), opts.Locale.LongDate(last)),
+		fmt.Sprintf("Day length: %s (%s %s)", FormatDuration(dayLength), formatSignedDuration(change.Round(time.Second)), during),
+	}
+	if season, solstice := previousSolstice(opts.Lat, last); !solstice.IsZero() {
+		solsticeDate := solstice.In(opts.Timezone)
+		if solsticeLength, ok := localDayLength(opts, solsticeDate); ok {
+			lines = append(lines, fmt.Sprintf("Since the %s (%s): %s", SeasonStartName(season), opts.Locale.MonthDay(solsticeDate), formatSignedDuration((dayLength-solsticeLength).Round(time.Second))))
+		}
+	}
+	lines = append(lines,
+		fmt.Sprintf("Location: %s", opts.Location),
+		"Coordinates: "+FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+	)
+
+	return CalendarEvent{
+		UID:         locationUID(last, opts.Lat, opts.Lng, opts.Precision, "summary-"+period),
+		Type:        "daylight_summary_" + period,
+		Start:       last,
+		End:         last.AddDate(0, 0, 1),
+		Summary:     fmt.Sprintf("Daylight %s: %s", during, formatSignedDuration(change.Round(time.Second))),
+		Description: strings.Join(lines, "\n"),
+		Location:    opts.Location,
+		AllDay:      true,
+	}
+}
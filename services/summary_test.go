@@ -0,0 +1,105 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildWeeklySummaryEvents(t *testing.T) {
+	opts := testCalendarOptions()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	events := BuildWeeklySummaryEvents(opts, start, 28)
+	if len(events) != 4 {
+		t.Fatalf("expected 4 Sundays, got %d events", len(events))
+	}
+	first := events[0]
+	if !first.AllDay || first.Start.Format("2006-01-02") != "2025-01-05" || first.Start.Weekday() != time.Sunday {
+		t.Errorf("expected an all-day event on Sunday 2025-01-05, got %+v", first)
+	}
+	if first.Type != "daylight_summary_weekly" || !strings.HasPrefix(first.Summary, "Daylight this week: +") {
+		t.Errorf("expected daylight gained in early January, got %q", first.Summary)
+	}
+	for _, want := range []string{"Period: Monday, December 30 – Sunday, January 5, 2025", "this week)", "Since the winter solstice (December 21): +"} {
+		if !strings.Contains(first.Description, want) {
+			t.Errorf("expected description to contain %q, got %q", want, first.Description)
+		}
+	}
+}
+
+func TestBuildWeeklySummaryEvents_AfterSummerSolstice(t *testing.T) {
+	opts := testCalendarOptions()
+	start := time.Date(2025, 7, 7, 0, 0, 0, 0, time.UTC)
+
+	events := BuildWeeklySummaryEvents(opts, start, 7)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	if !strings.HasPrefix(events[0].Summary, "Daylight this week: -") || !strings.Contains(events[0].Description, "Since the summer solstice (June 21): -") {
+		t.Errorf("expected daylight lost since the summer solstice, got %q: %q", events[0].Summary, events[0].Description)
+	}
+}
+
+func TestPreviousSolstice(t *testing.T) {
+	tests := []struct {
+		lat    float64
+		date   time.Time
+		season string
+		year   int
+	}{
+		{55.7, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), SeasonWinter, 2024},
+		{55.7, time.Date(2025, 6, 21, 0, 0, 0, 0, time.UTC), SeasonSummer, 2025},
+		{-33.9, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), SeasonSummer, 2024},
+	}
+	for _, tt := range tests {
+		season, at := previousSolstice(tt.lat, tt.date)
+		if season != tt.season || at.Year() != tt.year {
+			t.Errorf("previousSolstice(%g, %s) = %s %v, want %s %d", tt.lat, tt.date.Format("2006-01-02"), season, at, tt.season, tt.year)
+		}
+	}
+}
+
+func TestBuildMonthlySummaryEvents(t *testing.T) {
+	opts := testCalendarOptions()
+	start := time.Date(2025, 2, 20, 0, 0, 0, 0, time.UTC)
+
+	events := BuildMonthlySummaryEvents(opts, start, 45)
+	if len(events) != 2 {
+		t.Fatalf("expected March and April, got %d events", len(events))
+	}
+	march := events[0]
+	if !march.AllDay || march.Start.Format("2006-01-02") != "2025-03-01" || march.Type != "daylight_summary_monthly" {
+		t.Errorf("expected an all-day event on 2025-03-01, got %+v", march)
+	}
+	if march.Summary != "March 2025: 10h 42m to 13h 1m of daylight" {
+		t.Errorf("unexpected summary %q", march.Summary)
+	}
+	for _, want := range []string{
+		"Sunrise: earliest 05:49 (March 29), latest 07:02 (March 1)",
+		"Day length: 10h 42m (March 1) to 13h 1m (March 31)",
+		"Full moon: March 14 at 07:55",
+		"March equinox: March 20 at 10:01",
+	} {
+		if !strings.Contains(march.Description, want) {
+			t.Errorf("expected description to contain %q, got %q", want, march.Description)
+		}
+	}
+	if strings.Contains(events[1].Description, "equinox") {
+		t.Errorf("expected no equinox in April, got %q", events[1].Description)
+	}
+}
+
+func TestBuildMonthlySummaryEvents_PolarNight(t *testing.T) {
+	opts := testCalendarOptions()
+	opts.Lat, opts.Lng = 78.2232, 15.6267
+	start := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	events := BuildMonthlySummaryEvents(opts, start, 1)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	if events[0].Summary != "December 2024: 0h 0m of daylight every day" || !strings.Contains(events[0].Description, "Sunrise: none this month") {
+		t.Errorf("unexpected polar night summary %q: %q", events[0].Summary, events[0].Description)
+	}
+}
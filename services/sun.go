@@ -2,9 +2,10 @@ package services
 
 import (
 	"math"
+	"runtime"
+	"sync"
 	"time"
 
-	"github.com/bradfitz/latlong"
 	"github.com/sixdouglas/suncalc"
 )
 
@@ -14,23 +15,159 @@ type SunEvent struct {
 	Time      time.Time // Exact time of the event
 	Azimuth   float64   // Sun's azimuth angle in degrees
 	Elevation float64   // Sun's elevation angle in degrees
+
+	// Over a terrain skyline (see ApplyHorizonProfile)
+	Skyline  float64   // Skyline elevation where the sun crosses it, in degrees
+	FlatTime time.Time // Time over a flat horizon; zero if there is none
 }
 
-// DaySunTimes holds the sunrise and sunset for a specific day
+// DaySunTimes holds a day's sun values: sunrise and sunset with their
+// positions, and the times of all daily phases. They come from a single
+// calculation, so callers needing several values reuse them instead of
+// repeating it.
 type DaySunTimes struct {
 	Date    time.Time
+	Lat     float64
+	Lng     float64
 	Sunrise *SunEvent
 	Sunset  *SunEvent
+	Times   DayTimes
+
+	NoonElevation float64 // Sun's elevation at solar noon, in degrees
+
+	Altitude float64         // Observer height above the horizon in metres (see GetSunTimesAt)
+	Skyline  *HorizonProfile // Terrain skyline sunrise and sunset are over, if any
 }
 
-// GetSunTimes calculates sunrise and sunset for a given location and date
+// GetSunTimes calculates the sun's values for a given location and date
 func GetSunTimes(lat, lng float64, date time.Time) DaySunTimes {
-	times := suncalc.GetTimes(date, lat, lng)
+	return GetSunTimesAt(lat, lng, 0, date)
+}
 
+// GetSunTimesAt calculates the sun's values for an observer altitude metres
+// above the horizon (e.g., on a hill over the sea). The horizon dips below eye
+// level, so the sun rises earlier and sets later; twilight and the other
+// phases keep their elevations.
+func GetSunTimesAt(lat, lng, altitude float64, date time.Time) DaySunTimes {
+	times := GetDayTimes(lat, lng, date)
+	if altitude > 0 {
+		observed := suncalc.GetTimesWithObserver(date, suncalc.Observer{Latitude: lat, Longitude: lng, Height: altitude, Location: time.UTC})
+		times.Sunrise, times.Sunset = observed[suncalc.Sunrise].Value, observed[suncalc.Sunset].Value
+	}
+
+	_, noonElevation := SunPosition(lat, lng, times.SolarNoon)
 	return DaySunTimes{
-		Date:    date,
-		Sunrise: newSunEvent("sunrise", times[suncalc.Sunrise].Value, lat, lng),
-		Sunset:  newSunEvent("sunset", times[suncalc.Sunset].Value, lat, lng),
+		Date:          date,
+		Lat:           lat,
+		Lng:           lng,
+		Sunrise:       newSunEvent("sunrise", times.Sunrise, lat, lng),
+		Sunset:        newSunEvent("sunset", times.Sunset, lat, lng),
+		Times:         times,
+		NoonElevation: noonElevation,
+		Altitude:      altitude,
+	}
+}
+
+// HorizonDip returns how far the horizon is below eye level from altitude
+// metres above it, in degrees, with standard refraction
+func HorizonDip(altitude float64) float64 {
+	return 2.076 * math.Sqrt(math.Max(altitude, 0)) / 60
+}
+
+// PolarDay reports whether the sun stays above the horizon (or skyline) all
+// day
+func (d DaySunTimes) PolarDay() bool {
+	if d.Sunrise != nil || d.Sunset != nil {
+		return false
+	}
+	if d.Skyline != nil {
+		return skylineClearance(d.Skyline, d.Lat, d.Lng, d.Times.SolarNoon) > 0
+	}
+	return d.NoonElevation > 0
+}
+
+// DayLength returns the time between sunrise and sunset: 24 hours on polar
+// days and zero on polar nights. Reports false if the sun only rises or only
+// sets that day.
+func (d DaySunTimes) DayLength() (time.Duration, bool) {
+	switch {
+	case d.Sunrise != nil && d.Sunset != nil:
+		return d.Sunset.Time.Sub(d.Sunrise.Time), true
+	case d.Sunrise != nil || d.Sunset != nil:
+		return 0, false
+	case d.PolarDay():
+		return 24 * time.Hour, true
+	}
+	return 0, true
+}
+
+// astronomicalNightElevation is the sun's elevation below which the sky is
+// fully dark, in degrees
+const astronomicalNightElevation = -18
+
+// NightLength returns the time from the day's sunset to next's sunrise, where
+// next is the following day: 24 hours in polar night and zero in polar day.
+// Reports false if the night starts or ends a polar day or night.
+func (d DaySunTimes) NightLength(next DaySunTimes) (time.Duration, bool) {
+	switch {
+	case d.Sunset != nil && next.Sunrise != nil:
+		return next.Sunrise.Time.Sub(d.Sunset.Time), true
+	case d.Sunset != nil || next.Sunrise != nil:
+		return 0, false
+	case d.PolarDay():
+		return 0, true
+	}
+	return 24 * time.Hour, true
+}
+
+// DarknessLength returns the time from the day's astronomical dusk to next's
+// astronomical dawn, where next is the following day: zero when twilight lasts
+// all night, and 24 hours when the sun stays far below the horizon. Reports
+// false if only one of them occurs.
+func (d DaySunTimes) DarknessLength(next DaySunTimes) (time.Duration, bool) {
+	switch {
+	case !d.Times.Night.IsZero() && !next.Times.NightEnd.IsZero():
+		return next.Times.NightEnd.Sub(d.Times.Night), true
+	case !d.Times.Night.IsZero() || !next.Times.NightEnd.IsZero():
+		return 0, false
+	case d.NoonElevation < astronomicalNightElevation:
+		return 24 * time.Hour, true
+	}
+	return 0, true
+}
+
+// DayTimes holds the times of the sun's daily phases for a location. A zero
+// time means the phase does not occur that day (e.g., near the poles).
+type DayTimes struct {
+	NightEnd      time.Time // Morning astronomical twilight starts
+	NauticalDawn  time.Time // Morning nautical twilight starts
+	Dawn          time.Time // Morning civil twilight starts
+	Sunrise       time.Time
+	GoldenHourEnd time.Time // Morning golden hour ends
+	SolarNoon     time.Time
+	GoldenHour    time.Time // Evening golden hour starts
+	Sunset        time.Time
+	Dusk          time.Time // Evening civil twilight ends
+	NauticalDusk  time.Time // Evening nautical twilight ends
+	Night         time.Time // Evening astronomical twilight ends
+}
+
+// GetDayTimes calculates the sun's daily phase times for a location and date
+func GetDayTimes(lat, lng float64, date time.Time) DayTimes {
+	times := suncalc.GetTimes(date, lat, lng)
+
+	return DayTimes{
+		NightEnd:      times[suncalc.NightEnd].Value,
+		NauticalDawn:  times[suncalc.NauticalDawn].Value,
+		Dawn:          times[suncalc.Dawn].Value,
+		Sunrise:       times[suncalc.Sunrise].Value,
+		GoldenHourEnd: times[suncalc.GoldenHourEnd].Value,
+		SolarNoon:     times[suncalc.SolarNoon].Value,
+		GoldenHour:    times[suncalc.GoldenHour].Value,
+		Sunset:        times[suncalc.Sunset].Value,
+		Dusk:          times[suncalc.Dusk].Value,
+		NauticalDusk:  times[suncalc.NauticalDusk].Value,
+		Night:         times[suncalc.Night].Value,
 	}
 }
 
@@ -49,13 +186,114 @@ func newSunEvent(eventType string, t time.Time, lat, lng float64) *SunEvent {
 	}
 }
 
-// GetSunTimesRange calculates sunrise/sunset for a range of days
+// SunPosition returns the sun's azimuth (degrees clockwise from north) and
+// elevation above the horizon (degrees) at a given time and location
+func SunPosition(lat, lng float64, t time.Time) (azimuth, elevation float64) {
+	pos := suncalc.GetPosition(t, lat, lng)
+	return radToDeg(pos.Azimuth) + 180, radToDeg(pos.Altitude)
+}
+
+// SunAltitudeTimes returns when the sun rises through and sets through the
+// given altitude (degrees above the horizon, negative for twilight) on the
+// solar day containing date. A zero time means the sun does not cross the
+// altitude that day.
+func SunAltitudeTimes(lat, lng float64, date time.Time, altitude float64) (rising, setting time.Time) {
+	noon := suncalc.GetTimes(date, lat, lng)[suncalc.SolarNoon].Value
+	_, noonAltitude := SunPosition(lat, lng, noon)
+	return altitudeTimes(lat, lng, noon, noonAltitude, altitude)
+}
+
+// altitudeTimes finds the altitude crossings around a known solar noon
+func altitudeTimes(lat, lng float64, noon time.Time, noonAltitude, altitude float64) (rising, setting time.Time) {
+	if noonAltitude < altitude {
+		return time.Time{}, time.Time{}
+	}
+
+	rising = findAltitudeCrossing(lat, lng, noon.Add(-12*time.Hour), noon, altitude)
+	setting = findAltitudeCrossing(lat, lng, noon.Add(12*time.Hour), noon, altitude)
+	return rising, setting
+}
+
+// findAltitudeCrossing bisects between low (around solar midnight) and high
+// (solar noon) for the time the sun is at altitude, to within a second.
+// Returns the zero time if the sun is above the altitude at low.
+func findAltitudeCrossing(lat, lng float64, low, high time.Time, altitude float64) time.Time {
+	if _, elevation := SunPosition(lat, lng, low); elevation >= altitude {
+		return time.Time{}
+	}
+	for low.Sub(high).Abs() > time.Second {
+		mid := low.Add(high.Sub(low) / 2)
+		if _, elevation := SunPosition(lat, lng, mid); elevation < altitude {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return high.Round(time.Second)
+}
+
+// parallelRangeThreshold is the range length from which the range functions
+// compute days concurrently; shorter ranges are not worth the overhead
+const parallelRangeThreshold = 32
+
+// GetSunTimesRange calculates sunrise/sunset for a range of days. Long ranges
+// are computed by a bounded pool of workers (one per CPU); results are always
+// in date order.
 func GetSunTimesRange(lat, lng float64, startDate time.Time, days int) []DaySunTimes {
+	return GetSunTimesRangeAt(lat, lng, 0, startDate, days)
+}
+
+// GetSunTimesRangeAt is GetSunTimesRange for an observer altitude metres above
+// the horizon (see GetSunTimesAt)
+func GetSunTimesRangeAt(lat, lng, altitude float64, startDate time.Time, days int) []DaySunTimes {
+	if days < parallelRangeThreshold {
+		return getSunTimesSequential(lat, lng, altitude, startDate, days)
+	}
+	return computeDays(days, func(i int) DaySunTimes {
+		return GetSunTimesAt(lat, lng, altitude, startDate.AddDate(0, 0, i))
+	})
+}
+
+// computeDays calls compute for each day index in [0, days) on a bounded pool
+// of workers (one per CPU), returning the results in index order
+func computeDays[T any](days int, compute func(i int) T) []T {
+	results := make([]T, days)
+	workers := min(runtime.GOMAXPROCS(0), days)
+	if workers < 2 {
+		for i := range results {
+			results[i] = compute(i)
+		}
+		return results
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = compute(i)
+			}
+		}()
+	}
+	for i := 0; i < days; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// getSunTimesSequential calculates sunrise/sunset for a range of days, one
+// day at a time
+func getSunTimesSequential(lat, lng, altitude float64, startDate time.Time, days int) []DaySunTimes {
 	results := make([]DaySunTimes, 0, days)
 
 	for i := 0; i < days; i++ {
 		date := startDate.AddDate(0, 0, i)
-		results = append(results, GetSunTimes(lat, lng, date))
+		results = append(results, GetSunTimesAt(lat, lng, altitude, date))
 	}
 
 	return results
@@ -66,6 +304,11 @@ func radToDeg(rad float64) float64 {
 	return rad * 180 / math.Pi
 }
 
+// degToRad converts degrees to radians
+func degToRad(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
 // DaysUntilNextSolstice calculates the days until the next solstice
 // Returns the number of days and the type of solstice ("summer" or "winter")
 func DaysUntilNextSolstice(date time.Time) (int, string) {
@@ -100,19 +343,3 @@ func DaysUntilNextSolstice(date time.Time) (int, string) {
 	}
 	return daysToWinter, "winter"
 }
-
-// GetTimezone returns the timezone for a given latitude and longitude.
-// Returns UTC if the timezone cannot be determined.
-func GetTimezone(lat, lng float64) *time.Location {
-	zoneName := latlong.LookupZoneName(lat, lng)
-	if zoneName == "" {
-		return time.UTC
-	}
-
-	loc, err := time.LoadLocation(zoneName)
-	if err != nil {
-		return time.UTC
-	}
-
-	return loc
-}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"reflect"
 	"testing"
 	"time"
 )
@@ -43,6 +44,29 @@ func TestGetSunTimes(t *testing.T) {
 	}
 }
 
+func TestGetSunTimesAt(t *testing.T) {
+	date := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+	ground := GetSunTimes(55.6761, 12.5683, date)
+	hill := GetSunTimesAt(55.6761, 12.5683, 500, date)
+
+	// The horizon 0.77° lower brings sunrise several minutes earlier and
+	// sunset as much later
+	earlier := ground.Sunrise.Time.Sub(hill.Sunrise.Time)
+	later := hill.Sunset.Time.Sub(ground.Sunset.Time)
+	if earlier < 5*time.Minute || earlier > 10*time.Minute || later < 5*time.Minute || later > 10*time.Minute {
+		t.Errorf("expected sunrise and sunset 5-10 minutes further apart, got %s and %s", earlier, later)
+	}
+	if hill.Times.Dawn != ground.Times.Dawn || hill.Times.SolarNoon != ground.Times.SolarNoon || hill.Altitude != 500 {
+		t.Errorf("expected twilight and noon unchanged, got %+v", hill.Times)
+	}
+	if zero := GetSunTimesAt(55.6761, 12.5683, 0, date); !reflect.DeepEqual(zero, ground) {
+		t.Error("expected altitude 0 to match GetSunTimes")
+	}
+	if dip := HorizonDip(500); dip < 0.77 || dip > 0.78 {
+		t.Errorf("expected a dip of about 0.77°, got %.3f", dip)
+	}
+}
+
 func TestGetSunTimesRange(t *testing.T) {
 	lat := 55.6761
 	lng := 12.5683
@@ -66,6 +90,132 @@ func TestGetSunTimesRange(t *testing.T) {
 	}
 }
 
+func TestGetSunTimesRange_Parallel(t *testing.T) {
+	startDate := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Long ranges are computed concurrently, but must match the sequential
+	// results in order (including polar days without events)
+	for _, loc := range [][2]float64{{55.6761, 12.5683}, {78.2232, 15.6267}} {
+		got := GetSunTimesRange(loc[0], loc[1], startDate, 365)
+		want := getSunTimesSequential(loc[0], loc[1], 0, startDate, 365)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: parallel results differ from sequential", loc)
+		}
+	}
+}
+
+func BenchmarkGetSunTimesRange(b *testing.B) {
+	startDate := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < b.N; i++ {
+		GetSunTimesRange(55.6761, 12.5683, startDate, 365)
+	}
+}
+
+func BenchmarkGetSunTimesRange_Sequential(b *testing.B) {
+	startDate := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < b.N; i++ {
+		getSunTimesSequential(55.6761, 12.5683, 0, startDate, 365)
+	}
+}
+
+func TestDaySunTimes_DayLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lng float64
+		date     time.Time
+		want     time.Duration
+		polarDay bool
+	}{
+		{"equinox", 55.6761, 12.5683, time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC), 12*time.Hour + 10*time.Minute, false},
+		{"polar day", 78.2232, 15.6267, time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC), 24 * time.Hour, true},
+		{"polar night", 78.2232, 15.6267, time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := GetSunTimes(tt.lat, tt.lng, tt.date)
+			got, ok := day.DayLength()
+			if !ok {
+				t.Fatal("expected a day length")
+			}
+			if (got - tt.want).Abs() > 5*time.Minute {
+				t.Errorf("expected about %s, got %s", tt.want, got)
+			}
+			if day.PolarDay() != tt.polarDay {
+				t.Errorf("expected PolarDay() = %v", tt.polarDay)
+			}
+		})
+	}
+}
+
+func TestDaySunTimes_NightLength(t *testing.T) {
+	tests := []struct {
+		name         string
+		lat, lng     float64
+		date         time.Time
+		wantNight    time.Duration
+		wantDarkness time.Duration
+	}{
+		{"winter", 55.6761, 12.5683, time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC), 17 * time.Hour, 12*time.Hour + 18*time.Minute},
+		{"clocks change overnight", 55.6761, 12.5683, time.Date(2024, 3, 30, 12, 0, 0, 0, time.UTC), 11 * time.Hour, 6*time.Hour + 33*time.Minute},
+		{"twilight all night", 55.6761, 12.5683, time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC), 6*time.Hour + 28*time.Minute, 0},
+		{"polar day", 78.2232, 15.6267, time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC), 0, 0},
+		{"polar night", 78.2232, 15.6267, time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC), 24 * time.Hour, 15*time.Hour + 24*time.Minute},
+		{"dark all day", 89.5, 0, time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC), 24 * time.Hour, 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := GetSunTimes(tt.lat, tt.lng, tt.date)
+			next := GetSunTimes(tt.lat, tt.lng, tt.date.AddDate(0, 0, 1))
+
+			night, ok := day.NightLength(next)
+			if !ok {
+				t.Fatal("expected a night length")
+			}
+			if (night - tt.wantNight).Abs() > 5*time.Minute {
+				t.Errorf("expected a night of about %s, got %s", tt.wantNight, night)
+			}
+			darkness, ok := day.DarknessLength(next)
+			if !ok {
+				t.Fatal("expected a darkness length")
+			}
+			if (darkness - tt.wantDarkness).Abs() > 5*time.Minute {
+				t.Errorf("expected darkness of about %s, got %s", tt.wantDarkness, darkness)
+			}
+		})
+	}
+}
+
+func TestDaySunTimes_NightLength_PolarTransition(t *testing.T) {
+	// The sun sets, but does not rise again the next day
+	day := DaySunTimes{Sunset: &SunEvent{Type: "sunset", Time: time.Date(2024, 11, 10, 12, 0, 0, 0, time.UTC)}}
+	if _, ok := day.NightLength(DaySunTimes{}); ok {
+		t.Error("expected no night length when the night starts a polar night")
+	}
+	day.Times.Night = time.Date(2024, 11, 10, 16, 0, 0, 0, time.UTC)
+	if _, ok := day.DarknessLength(DaySunTimes{}); ok {
+		t.Error("expected no darkness length when dawn does not follow")
+	}
+}
+
+func TestDaySunTimes_OnePass(t *testing.T) {
+	date := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+	day := GetSunTimes(55.6761, 12.5683, date)
+
+	// Sunrise and sunset match the day's phase times
+	if !day.Sunrise.Time.Equal(day.Times.Sunrise) || !day.Sunset.Time.Equal(day.Times.Sunset) {
+		t.Error("expected sunrise and sunset from the same calculation as the phase times")
+	}
+	if day.Times != GetDayTimes(55.6761, 12.5683, date) {
+		t.Error("expected the day's phase times")
+	}
+	// Copenhagen's noon sun at the June solstice is 90 - 55.68 + 23.44 degrees up
+	if e := day.NoonElevation; e < 57.5 || e > 58 {
+		t.Errorf("unexpected noon elevation %.2f", e)
+	}
+}
+
 func TestGetSunTimesAzimuth(t *testing.T) {
 	lat := 55.6761
 	lng := 12.5683
@@ -145,3 +295,99 @@ func TestDaysUntilNextSolstice(t *testing.T) {
 		})
 	}
 }
+
+func TestSunPosition(t *testing.T) {
+	// Copenhagen at solar noon around the summer solstice
+	noon := time.Date(2024, 6, 21, 11, 10, 0, 0, time.UTC)
+	azimuth, elevation := SunPosition(55.6761, 12.5683, noon)
+
+	if azimuth < 170 || azimuth > 190 {
+		t.Errorf("expected azimuth near 180 (south) at solar noon, got %.1f", azimuth)
+	}
+	if elevation < 57 || elevation > 58.5 {
+		t.Errorf("expected elevation around 57.8, got %.1f", elevation)
+	}
+
+	_, elevation = SunPosition(55.6761, 12.5683, noon.Add(12*time.Hour))
+	if elevation >= 0 {
+		t.Errorf("expected sun below horizon at midnight, got %.1f", elevation)
+	}
+}
+
+func TestGetDayTimes(t *testing.T) {
+	// Copenhagen on the spring equinox
+	times := GetDayTimes(55.6761, 12.5683, time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC))
+
+	order := []time.Time{
+		times.NightEnd, times.NauticalDawn, times.Dawn, times.Sunrise, times.GoldenHourEnd,
+		times.SolarNoon, times.GoldenHour, times.Sunset, times.Dusk, times.NauticalDusk, times.Night,
+	}
+	for i, tm := range order {
+		if tm.IsZero() {
+			t.Fatalf("phase %d should occur on the equinox", i)
+		}
+		if i > 0 && !tm.After(order[i-1]) {
+			t.Errorf("phase %d (%s) should be after phase %d (%s)", i, tm, i-1, order[i-1])
+		}
+	}
+
+	// Midsummer in Copenhagen never gets astronomically dark
+	summer := GetDayTimes(55.6761, 12.5683, time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC))
+	if !summer.Night.IsZero() || !summer.NightEnd.IsZero() {
+		t.Errorf("expected no astronomical night at midsummer, got %s - %s", summer.Night, summer.NightEnd)
+	}
+}
+
+func TestSunAltitudeTimes(t *testing.T) {
+	lat, lng := 55.6761, 12.5683
+	date := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	// At -0.833° the crossings match sunrise and sunset
+	rising, setting := SunAltitudeTimes(lat, lng, date, -0.833)
+	day := GetSunTimes(lat, lng, date)
+	if d := rising.Sub(day.Sunrise.Time).Abs(); d > 30*time.Second {
+		t.Errorf("rising %s differs from sunrise %s by %s", rising, day.Sunrise.Time, d)
+	}
+	if d := setting.Sub(day.Sunset.Time).Abs(); d > 30*time.Second {
+		t.Errorf("setting %s differs from sunset %s by %s", setting, day.Sunset.Time, d)
+	}
+
+	// Astronomical twilight matches the library's night end
+	rising, _ = SunAltitudeTimes(lat, lng, date, -18)
+	if d := rising.Sub(GetDayTimes(lat, lng, date).NightEnd).Abs(); d > 30*time.Second {
+		t.Errorf("-18° rising differs from night end by %s", d)
+	}
+
+	// The sun never reaches 40° in Copenhagen in March
+	rising, setting = SunAltitudeTimes(lat, lng, date, 40)
+	if !rising.IsZero() || !setting.IsZero() {
+		t.Errorf("expected no crossing, got %s and %s", rising, setting)
+	}
+
+	// Midnight sun: the sun never goes below -5°
+	rising, setting = SunAltitudeTimes(78.2232, 15.6267, time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC), -5)
+	if !rising.IsZero() || !setting.IsZero() {
+		t.Errorf("expected no crossing during midnight sun, got %s and %s", rising, setting)
+	}
+}
+
+func BenchmarkGetSunTimes(b *testing.B) {
+	date := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < b.N; i++ {
+		GetSunTimes(55.6761, 12.5683, date)
+	}
+}
+
+// BenchmarkGetSunTimes_Derived covers a day's derived values, which reuse the
+// noon elevation computed with the day instead of recomputing it
+func BenchmarkGetSunTimes_Derived(b *testing.B) {
+	date := time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < b.N; i++ {
+		day := GetSunTimes(78.2232, 15.6267, date) // Midnight sun
+		next := GetSunTimes(78.2232, 15.6267, date.AddDate(0, 0, 1))
+		day.PolarDay()
+		day.DayLength()
+		day.NightLength(next)
+		day.DarknessLength(next)
+	}
+}
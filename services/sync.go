@@ -0,0 +1,354 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const pendingConnectTTL = 15 * time.Minute
+
+// ErrUnknownState is returned when an OAuth callback's state does not match a
+// pending connection (expired or forged)
+var ErrUnknownState = errors.New("unknown or expired connection state")
+
+// PushProvider writes events into a user's calendar on a remote service
+type PushProvider interface {
+	// OAuth returns the provider's OAuth client configuration
+	OAuth() *OAuthConfig
+	// DefaultCalendarID returns the calendar events are written to
+	DefaultCalendarID() string
+	// UpsertEvent creates or updates the event, keyed by its UID, so that
+	// repeated writes of the same event are idempotent
+	UpsertEvent(ctx context.Context, accessToken, calendarID string, event CalendarEvent) error
+}
+
+// SyncSubscription is a location whose events are pushed to a user's calendar
+type SyncSubscription struct {
+	ID             string            `json:"id"`
+	Provider       string            `json:"provider"`
+	Token          OAuthToken        `json:"token"`
+	CalendarID     string            `json:"calendar_id"`
+	Lat            float64           `json:"lat"`
+	Lng            float64           `json:"lng"`
+	Name           string            `json:"name"`
+	Days           int               `json:"days"`
+	Events         []string          `json:"events,omitempty"` // Event types; subscriptions saved before it use the Include flags
+	IncludeSunrise bool              `json:"include_sunrise"`
+	IncludeSunset  bool              `json:"include_sunset"`
+	Synced         map[string]string `json:"synced"` // Event UID -> hash of the last written version
+	LastSync       time.Time         `json:"last_sync"`
+}
+
+// SyncStore persists push subscriptions (including OAuth tokens) to a JSON
+// file. The file should be kept private to the server.
+type SyncStore struct {
+	mu   sync.Mutex
+	path string
+	subs map[string]*SyncSubscription
+}
+
+// OpenSyncStore loads the store at path, creating it on first save
+func OpenSyncStore(path string) (*SyncStore, error) {
+	s := &SyncStore{path: path, subs: make(map[string]*SyncSubscription)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var subs []*SyncSubscription
+	if err := json.Unmarshal(data, &subs); err != nil {
+		return nil, fmt.Errorf("invalid sync store %s: %w", path, err)
+	}
+	for _, sub := range subs {
+		s.subs[sub.ID] = sub
+	}
+	return s, nil
+}
+
+// Get returns a copy of the subscription with the given ID
+func (s *SyncStore) Get(id string) (SyncSubscription, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subs[id]
+	if !ok {
+		return SyncSubscription{}, false
+	}
+	return copySubscription(sub), true
+}
+
+// List returns copies of all subscriptions, ordered by ID
+func (s *SyncStore) List() []SyncSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := make([]SyncSubscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, copySubscription(sub))
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].ID < subs[j].ID })
+	return subs
+}
+
+// Put adds or replaces a subscription and saves the store
+func (s *SyncStore) Put(sub SyncSubscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := copySubscription(&sub)
+	s.subs[sub.ID] = &stored
+	return s.saveLocked()
+}
+
+// Delete removes a subscription and saves the store. Returns false if no
+// subscription had the given ID.
+func (s *SyncStore) Delete(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[id]; !ok {
+		return false, nil
+	}
+	delete(s.subs, id)
+	return true, s.saveLocked()
+}
+
+// saveLocked atomically writes the store to disk. The caller must hold s.mu.
+func (s *SyncStore) saveLocked() error {
+	subs := make([]*SyncSubscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].ID < subs[j].ID })
+
+	return writeJSONAtomic(s.path, subs)
+}
+
+// copySubscription returns a deep copy of sub
+func copySubscription(sub *SyncSubscription) SyncSubscription {
+	c := *sub
+	c.Synced = make(map[string]string, len(sub.Synced))
+	for k, v := range sub.Synced {
+		c.Synced[k] = v
+	}
+	return c
+}
+
+// Syncer pushes sun events for stored subscriptions into remote calendars.
+// Only events whose content changed since the last sync are written.
+type Syncer struct {
+	store     *SyncStore
+	client    *http.Client
+	providers map[string]PushProvider
+	pending   *ttlCache[SyncSubscription]
+	now       Clock
+}
+
+// NewSyncer creates a sync engine backed by store
+func NewSyncer(store *SyncStore) *Syncer {
+	return &Syncer{
+		store:     store,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		providers: make(map[string]PushProvider),
+		pending:   newTTLCache[SyncSubscription](pendingConnectTTL, 1000),
+		now:       time.Now,
+	}
+}
+
+// Register makes a provider available under name (e.g., "google")
+func (s *Syncer) Register(name string, provider PushProvider) {
+	s.providers[name] = provider
+}
+
+// Provider returns the provider registered under name
+func (s *Syncer) Provider(name string) (PushProvider, bool) {
+	p, ok := s.providers[name]
+	return p, ok
+}
+
+// Providers returns the names of the registered providers, sorted
+func (s *Syncer) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Store returns the subscription store
+func (s *Syncer) Store() *SyncStore {
+	return s.store
+}
+
+// BeginConnect records a pending subscription awaiting OAuth consent and
+// returns the authorization URL to send the user to
+func (s *Syncer) BeginConnect(sub SyncSubscription, redirectURI string) (string, error) {
+	provider, ok := s.providers[sub.Provider]
+	if !ok {
+		return "", fmt.Errorf("unknown provider %q", sub.Provider)
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	s.pending.Set(state, sub)
+
+	return provider.OAuth().AuthCodeURL(state, redirectURI), nil
+}
+
+// CompleteConnect exchanges the authorization code for the pending
+// subscription identified by state, stores it, and returns it
+func (s *Syncer) CompleteConnect(ctx context.Context, state, code, redirectURI string) (SyncSubscription, error) {
+	sub, ok := s.pending.Get(state)
+	if !ok {
+		return SyncSubscription{}, ErrUnknownState
+	}
+	s.pending.Delete(state)
+
+	provider := s.providers[sub.Provider]
+	token, err := provider.OAuth().Exchange(ctx, s.client, code, redirectURI, s.now())
+	if err != nil {
+		return SyncSubscription{}, err
+	}
+
+	id, err := randomToken()
+	if err != nil {
+		return SyncSubscription{}, err
+	}
+	sub.ID = id
+	sub.Token = *token
+	sub.CalendarID = provider.DefaultCalendarID()
+	sub.Synced = make(map[string]string)
+
+	if err := s.store.Put(sub); err != nil {
+		return SyncSubscription{}, err
+	}
+	return sub, nil
+}
+
+// SyncAll syncs every subscription, logging failures
+func (s *Syncer) SyncAll(ctx context.Context) {
+	for _, sub := range s.store.List() {
+		if err := s.Sync(ctx, sub.ID); err != nil {
+			log.Printf("sync %s (%s): %v", sub.ID[:8], sub.Provider, err)
+		}
+	}
+}
+
+// Sync pushes the upcoming events of one subscription. Subscriptions whose
+// access has been revoked are removed.
+func (s *Syncer) Sync(ctx context.Context, id string) error {
+	sub, ok := s.store.Get(id)
+	if !ok {
+		return fmt.Errorf("unknown subscription")
+	}
+	provider, ok := s.providers[sub.Provider]
+	if !ok {
+		return fmt.Errorf("unknown provider %q", sub.Provider)
+	}
+
+	if now := s.now(); sub.Token.Expired(now) {
+		token, err := provider.OAuth().Refresh(ctx, s.client, &sub.Token, now)
+		if errors.Is(err, ErrTokenRevoked) {
+			_, delErr := s.store.Delete(sub.ID)
+			return errors.Join(err, delErr)
+		}
+		if err != nil {
+			return err
+		}
+		sub.Token = *token
+	}
+
+	synced := make(map[string]string)
+	var syncErr error
+	for _, event := range s.upcomingEvents(sub) {
+		hash := eventHash(event)
+		synced[event.UID] = hash
+		if sub.Synced[event.UID] == hash {
+			continue
+		}
+
+		if err := provider.UpsertEvent(ctx, sub.Token.AccessToken, sub.CalendarID, event); err != nil {
+			// Retry this event on the next sync
+			synced[event.UID] = ""
+			syncErr = err
+			break
+		}
+	}
+
+	sub.Synced = synced
+	sub.LastSync = s.now()
+	if err := s.store.Put(sub); err != nil {
+		return err
+	}
+	return syncErr
+}
+
+// EventSet returns the event types pushed for the subscription
+func (sub SyncSubscription) EventSet() EventSet {
+	if len(sub.Events) > 0 {
+		return NewEventSet(sub.Events...)
+	}
+	set := NewEventSet()
+	set[EventSunrise] = sub.IncludeSunrise
+	set[EventSunset] = sub.IncludeSunset
+	return set
+}
+
+// upcomingEvents generates the subscription's events from today onwards. The
+// previous day is computed too so the first event's "Yesterday" delta is
+// stable across syncs.
+func (s *Syncer) upcomingEvents(sub SyncSubscription) []CalendarEvent {
+	today := s.now().UTC().Truncate(24 * time.Hour)
+	sunTimes := GetSunTimesRange(sub.Lat, sub.Lng, today.AddDate(0, 0, -1), sub.Days+1)
+
+	events := BuildSunEvents(sunTimes, CalendarOptions{
+		Lat:      sub.Lat,
+		Lng:      sub.Lng,
+		Location: locationLabel(sub.Name, sub.Lat, sub.Lng),
+		Timezone: GetTimezone(sub.Lat, sub.Lng),
+		Events:   sub.EventSet(),
+	})
+
+	upcoming := events[:0]
+	for _, event := range events {
+		if !event.Start.Before(today) {
+			upcoming = append(upcoming, event)
+		}
+	}
+	return upcoming
+}
+
+// eventHash returns a hash of the event's content, used to detect changes in
+// push sync and the delta feed
+func eventHash(event CalendarEvent) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s", event.Start.UTC().Format(time.RFC3339), event.End.UTC().Format(time.RFC3339), event.Summary, event.Description, event.Location)
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// randomToken returns a random 128-bit hex token
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeProvider records upserted events in memory
+type fakeProvider struct {
+	oauth   *OAuthConfig
+	upserts map[string]int // UID -> number of writes
+}
+
+func (p *fakeProvider) OAuth() *OAuthConfig       { return p.oauth }
+func (p *fakeProvider) DefaultCalendarID() string { return "primary" }
+
+func (p *fakeProvider) UpsertEvent(ctx context.Context, accessToken, calendarID string, event CalendarEvent) error {
+	p.upserts[event.UID]++
+	return nil
+}
+
+func newTestSyncer(t *testing.T, tokenHandler http.HandlerFunc) (*Syncer, *fakeProvider) {
+	server := httptest.NewServer(tokenHandler)
+	t.Cleanup(server.Close)
+
+	store, err := OpenSyncStore(filepath.Join(t.TempDir(), "sync.json"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+
+	provider := &fakeProvider{
+		oauth:   &OAuthConfig{ClientID: "client", AuthURL: "https://auth.example", TokenURL: server.URL},
+		upserts: make(map[string]int),
+	}
+	syncer := NewSyncer(store)
+	syncer.Register("fake", provider)
+	syncer.now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+	return syncer, provider
+}
+
+func connect(t *testing.T, s *Syncer) SyncSubscription {
+	authURL, err := s.BeginConnect(SyncSubscription{
+		Provider: "fake", Lat: 55.6761, Lng: 12.5683, Name: "Copenhagen",
+		Days: 7, Events: []string{EventSunrise, EventSunset},
+	}, "https://calsun.example/callback")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("invalid auth URL: %v", err)
+	}
+
+	sub, err := s.CompleteConnect(context.Background(), u.Query().Get("state"), "code", "https://calsun.example/callback")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return sub
+}
+
+func TestSyncer_ConnectAndSync(t *testing.T) {
+	s, provider := newTestSyncer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"at","refresh_token":"rt","expires_in":3600}`))
+	})
+
+	sub := connect(t, s)
+	if sub.ID == "" || sub.CalendarID != "primary" {
+		t.Fatalf("unexpected subscription: %+v", sub)
+	}
+
+	if err := s.Sync(context.Background(), sub.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(provider.upserts) != 14 {
+		t.Errorf("expected 14 events (7 days x 2), got %d", len(provider.upserts))
+	}
+
+	// A second sync with unchanged events writes nothing
+	if err := s.Sync(context.Background(), sub.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for uid, writes := range provider.upserts {
+		if writes != 1 {
+			t.Errorf("event %s written %d times, expected once", uid, writes)
+		}
+	}
+
+	// The next day only the newly added day is written
+	s.now = func() time.Time { return time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC) }
+	if err := s.Sync(context.Background(), sub.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(provider.upserts) != 16 {
+		t.Errorf("expected 2 new events, got %d total", len(provider.upserts))
+	}
+}
+
+func TestSyncer_UnknownState(t *testing.T) {
+	s, _ := newTestSyncer(t, nil)
+
+	if _, err := s.CompleteConnect(context.Background(), "forged", "code", "https://calsun.example/callback"); err != ErrUnknownState {
+		t.Errorf("expected ErrUnknownState, got %v", err)
+	}
+}
+
+func TestSyncer_RevokedTokenRemovesSubscription(t *testing.T) {
+	s, _ := newTestSyncer(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("grant_type") == "refresh_token" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"at","refresh_token":"rt","expires_in":0}`))
+	})
+
+	sub := connect(t, s)
+
+	if err := s.Sync(context.Background(), sub.ID); err == nil {
+		t.Error("expected error for revoked token")
+	}
+	if _, ok := s.Store().Get(sub.ID); ok {
+		t.Error("expected subscription to be removed")
+	}
+}
+
+func TestSyncStore_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "sync.json")
+
+	store, err := OpenSyncStore(path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	if err := store.Put(SyncSubscription{ID: "a", Provider: "google", Synced: map[string]string{"uid": "hash"}}); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	reopened, err := OpenSyncStore(path)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	sub, ok := reopened.Get("a")
+	if !ok || sub.Provider != "google" || sub.Synced["uid"] != "hash" {
+		t.Errorf("unexpected subscription after reload: %+v", sub)
+	}
+
+	if deleted, _ := reopened.Delete("a"); !deleted {
+		t.Error("expected subscription to be deleted")
+	}
+	if len(reopened.List()) != 0 {
+		t.Error("expected empty store")
+	}
+}
+
+func TestSyncSubscription_EventSet(t *testing.T) {
+	sub := SyncSubscription{Events: []string{EventDawn, EventSunset}}
+	if got := strings.Join(sub.EventSet().Types(), ","); got != "dawn,sunset" {
+		t.Errorf("expected dawn,sunset, got %s", got)
+	}
+
+	// Subscriptions saved before event types could be chosen
+	legacy := SyncSubscription{IncludeSunrise: true}
+	if got := strings.Join(legacy.EventSet().Types(), ","); got != "sunrise" {
+		t.Errorf("expected sunrise, got %s", got)
+	}
+}
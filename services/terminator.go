@@ -0,0 +1,193 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+	"time"
+)
+
+// SubsolarPoint returns the latitude and longitude where the sun is overhead
+// at t
+func SubsolarPoint(t time.Time) (lat, lng float64) {
+	n := daysSinceJ2000(t)
+	longitude := degToRad(SolarLongitude(t))
+	obliquity := degToRad(23.439 - 0.0000004*n)
+
+	rightAscension := radToDeg(math.Atan2(math.Cos(obliquity)*math.Sin(longitude), math.Cos(longitude)))
+	declination := radToDeg(math.Asin(math.Sin(obliquity) * math.Sin(longitude)))
+	// Greenwich mean sidereal time, in degrees
+	gmst := 280.46061837 + 360.98564736629*n
+	return declination, math.Mod(math.Mod(rightAscension-gmst+180, 360)+360, 360) - 180
+}
+
+// terminatorBand is a shade of the map: the sunlit side, the three twilights,
+// and night
+type terminatorBand struct {
+	minElevation float64 // Lowest sun elevation of the band, in degrees
+	color        color.RGBA
+}
+
+// terminatorBands are ordered from day to night; below the last band's
+// minimum is night
+var terminatorBands = []terminatorBand{
+	{-0.833, color.RGBA{0xa8, 0xd8, 0xf0, 0xff}}, // Day
+	{-6, color.RGBA{0x6d, 0x9e, 0xc4, 0xff}},     // Civil twilight
+	{-12, color.RGBA{0x3f, 0x6a, 0x95, 0xff}},    // Nautical twilight
+	{-18, color.RGBA{0x25, 0x46, 0x6b, 0xff}},    // Astronomical twilight
+}
+
+// Colours of night, the grid, and the markers
+var (
+	terminatorNight    = color.RGBA{0x14, 0x2a, 0x45, 0xff}
+	terminatorGrid     = color.RGBA{0xff, 0xff, 0xff, 0x40}
+	terminatorLocation = color.RGBA{0xe6, 0x39, 0x46, 0xff}
+	terminatorSun      = color.RGBA{0xff, 0xd1, 0x66, 0xff}
+)
+
+// terminatorGridStep is the spacing of the map's grid lines, in degrees
+const terminatorGridStep = 30
+
+// terminatorShade returns the index of the band for a point on the map, or
+// len(terminatorBands) for night
+func terminatorShade(lat, lng, sunLat, sunLng float64) int {
+	phi, delta := degToRad(lat), degToRad(sunLat)
+	sinElevation := math.Sin(phi)*math.Sin(delta) + math.Cos(phi)*math.Cos(delta)*math.Cos(degToRad(lng-sunLng))
+	elevation := radToDeg(math.Asin(sinElevation))
+	for i, band := range terminatorBands {
+		if elevation >= band.minElevation {
+			return i
+		}
+	}
+	return len(terminatorBands)
+}
+
+// terminatorColor returns the colour of a band index from terminatorShade
+func terminatorColor(shade int) color.RGBA {
+	if shade < len(terminatorBands) {
+		return terminatorBands[shade].color
+	}
+	return terminatorNight
+}
+
+// TerminatorSVG renders an equirectangular world map, width pixels wide and
+// half as high, shaded by daylight and twilight at t, with a grid every 30°,
+// the point where the sun is overhead, and a marker at lat, lng. The shading
+// is drawn in 1° cells, merged into runs along each row.
+func TerminatorSVG(t time.Time, lat, lng float64, width int) string {
+	sunLat, sunLng := SubsolarPoint(t)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 360 180" shape-rendering="crispEdges">`, width, width/2)
+	fmt.Fprintf(&b, `<title>Day and night at %s</title>`, t.UTC().Format("2006-01-02 15:04 MST"))
+	for y := 0; y < 180; y++ {
+		cellLat := 89.5 - float64(y)
+		for x := 0; x < 360; {
+			shade := terminatorShade(cellLat, float64(x)-179.5, sunLat, sunLng)
+			run := 1
+			for x+run < 360 && terminatorShade(cellLat, float64(x+run)-179.5, sunLat, sunLng) == shade {
+				run++
+			}
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="1" fill="%s"/>`, x, y, run, hexColor(terminatorColor(shade)))
+			x += run
+		}
+	}
+
+	fmt.Fprintf(&b, `<g stroke="%s" stroke-opacity="%.2f" stroke-width="0.5" shape-rendering="auto">`, hexColor(terminatorGrid), float64(terminatorGrid.A)/0xff)
+	for x := 0; x <= 360; x += terminatorGridStep {
+		fmt.Fprintf(&b, `<line x1="%d" y1="0" x2="%d" y2="180"/>`, x, x)
+	}
+	for y := 0; y <= 180; y += terminatorGridStep {
+		fmt.Fprintf(&b, `<line x1="0" y1="%d" x2="360" y2="%d"/>`, y, y)
+	}
+	b.WriteString(`</g>`)
+
+	sunX, sunY := mapPoint(sunLat, sunLng, 360)
+	fmt.Fprintf(&b, `<circle cx="%.2f" cy="%.2f" r="3" fill="%s" shape-rendering="auto"/>`, sunX, sunY, hexColor(terminatorSun))
+	x, y := mapPoint(lat, lng, 360)
+	fmt.Fprintf(&b, `<circle cx="%.2f" cy="%.2f" r="2.5" fill="%s" stroke="#fff" stroke-width="0.8" shape-rendering="auto"/>`, x, y, hexColor(terminatorLocation))
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+// TerminatorPNG renders the map of TerminatorSVG as a PNG image, width
+// pixels wide and half as high
+func TerminatorPNG(t time.Time, lat, lng float64, width int) ([]byte, error) {
+	sunLat, sunLng := SubsolarPoint(t)
+	height := width / 2
+	scale := float64(width) / 360
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		pixelLat := 90 - (float64(y)+0.5)/scale
+		for x := 0; x < width; x++ {
+			pixelLng := (float64(x)+0.5)/scale - 180
+			img.SetRGBA(x, y, terminatorColor(terminatorShade(pixelLat, pixelLng, sunLat, sunLng)))
+		}
+	}
+
+	for deg := 0; deg <= 360; deg += terminatorGridStep {
+		x := min(int(float64(deg)*scale), width-1)
+		for y := 0; y < height; y++ {
+			blendPixel(img, x, y, terminatorGrid)
+		}
+	}
+	for deg := 0; deg <= 180; deg += terminatorGridStep {
+		y := min(int(float64(deg)*scale), height-1)
+		for x := 0; x < width; x++ {
+			blendPixel(img, x, y, terminatorGrid)
+		}
+	}
+
+	radius := max(float64(width)/120, 2)
+	sunX, sunY := mapPoint(sunLat, sunLng, float64(width))
+	fillCircle(img, sunX, sunY, radius*1.2, terminatorSun)
+	x, y := mapPoint(lat, lng, float64(width))
+	fillCircle(img, x, y, radius+1, color.RGBA{0xff, 0xff, 0xff, 0xff})
+	fillCircle(img, x, y, radius, terminatorLocation)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mapPoint returns the position of a coordinate on an equirectangular map
+// width units wide
+func mapPoint(lat, lng, width float64) (x, y float64) {
+	scale := width / 360
+	return (lng + 180) * scale, (90 - lat) * scale
+}
+
+// blendPixel draws c over the pixel at x, y, using c's alpha
+func blendPixel(img *image.RGBA, x, y int, c color.RGBA) {
+	under := img.RGBAAt(x, y)
+	alpha := uint32(c.A)
+	mix := func(a, b uint8) uint8 {
+		return uint8((uint32(a)*alpha + uint32(b)*(0xff-alpha)) / 0xff)
+	}
+	img.SetRGBA(x, y, color.RGBA{mix(c.R, under.R), mix(c.G, under.G), mix(c.B, under.B), 0xff})
+}
+
+// fillCircle draws a filled circle centred at cx, cy
+func fillCircle(img *image.RGBA, cx, cy, radius float64, c color.RGBA) {
+	bounds := img.Bounds()
+	for y := int(cy - radius); y <= int(cy+radius); y++ {
+		for x := int(cx - radius); x <= int(cx+radius); x++ {
+			dx, dy := float64(x)+0.5-cx, float64(y)+0.5-cy
+			if dx*dx+dy*dy <= radius*radius && image.Pt(x, y).In(bounds) {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// hexColor formats a colour as #rrggbb, ignoring its alpha
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
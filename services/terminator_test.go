@@ -0,0 +1,72 @@
+package services
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSubsolarPoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		time     time.Time
+		lat, lng float64
+	}{
+		// The sun crosses the Greenwich meridian about 7 minutes after noon
+		{"March equinox", time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC), 0, 1.8},
+		{"June solstice", time.Date(2024, 6, 20, 20, 51, 0, 0, time.UTC), 23.44, -132.3},
+		{"December midnight", time.Date(2024, 12, 21, 0, 0, 0, 0, time.UTC), -23.44, 179.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lat, lng := SubsolarPoint(tt.time)
+			if math.Abs(lat-tt.lat) > 0.2 || math.Abs(lng-tt.lng) > 0.2 {
+				t.Errorf("expected about %.2f, %.2f, got %.2f, %.2f", tt.lat, tt.lng, lat, lng)
+			}
+		})
+	}
+}
+
+func TestTerminatorPNG(t *testing.T) {
+	at := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	data, err := TerminatorPNG(at, 55.6761, 12.5683, 360)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("expected valid PNG: %v", err)
+	}
+	if img.Bounds().Dx() != 360 || img.Bounds().Dy() != 180 {
+		t.Errorf("expected a 360x180 image, got %dx%d", img.Bounds().Dx(), img.Bounds().Dy())
+	}
+
+	// At noon UTC on the equinox, Africa is in daylight and the Pacific in night
+	day, night := terminatorBands[0].color, terminatorNight
+	if r, g, b, _ := img.At(205, 100).RGBA(); uint8(r>>8) != day.R || uint8(g>>8) != day.G || uint8(b>>8) != day.B {
+		t.Error("expected daylight at 25°E on the equator")
+	}
+	if r, g, b, _ := img.At(5, 100).RGBA(); uint8(r>>8) != night.R || uint8(g>>8) != night.G || uint8(b>>8) != night.B {
+		t.Error("expected night at 175°W on the equator")
+	}
+}
+
+func TestTerminatorSVG(t *testing.T) {
+	svg := TerminatorSVG(time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), 55.6761, 12.5683, 720)
+
+	if !strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="720" height="360"`) {
+		t.Errorf("unexpected SVG header: %.100s", svg)
+	}
+	for _, band := range append(terminatorBands, terminatorBand{color: terminatorNight}) {
+		if !strings.Contains(svg, `fill="`+hexColor(band.color)+`"`) {
+			t.Errorf("expected the %s band", hexColor(band.color))
+		}
+	}
+	// The location marker at 192.57, 34.32 on the 360x180 view box
+	if !strings.Contains(svg, `<circle cx="192.57" cy="34.32"`) {
+		t.Error("expected the location marker")
+	}
+}
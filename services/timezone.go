@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bradfitz/latlong"
+)
+
+// timezoneCache caches detected zone names by coordinates, rounded to 4
+// decimals (about 11 m), so repeated calendars for a location skip the
+// latlong lookup. Zones not found are cached as "".
+var timezoneCache = newTTLCache[string](24*time.Hour, 10000)
+
+// zoneLocations caches loaded zones by name, as time.LoadLocation reads and
+// parses the zone's data on every call
+var zoneLocations sync.Map
+
+// TimezoneCache returns the cache of detected zone names, for the admin
+// endpoints
+func TimezoneCache() Cache {
+	return timezoneCache
+}
+
+// TimezoneName returns the IANA zone name (e.g., "Europe/Copenhagen") for a
+// latitude and longitude, or "" if it cannot be determined (e.g., at sea)
+func TimezoneName(lat, lng float64) string {
+	key := fmt.Sprintf("%.4f,%.4f", lat, lng)
+	if name, ok := timezoneCache.Get(key); ok {
+		return name
+	}
+	name := latlong.LookupZoneName(lat, lng)
+	timezoneCache.Set(key, name)
+	return name
+}
+
+// LoadZone returns the zone with an IANA name, loading it once
+func LoadZone(name string) (*time.Location, error) {
+	if loc, ok := zoneLocations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	zoneLocations.Store(name, loc)
+	return loc, nil
+}
+
+// GetTimezone returns the timezone for a given latitude and longitude.
+// Returns UTC if the timezone cannot be determined.
+func GetTimezone(lat, lng float64) *time.Location {
+	name := TimezoneName(lat, lng)
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := LoadZone(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// ZoneTransition is a change of a zone's offset or abbreviation, such as
+// the start or end of daylight saving time
+type ZoneTransition struct {
+	Time         time.Time // Instant of the change, in the zone
+	OffsetBefore int       // Seconds east of UTC before the change
+	OffsetAfter  int       // Seconds east of UTC from the change
+	Abbreviation string    // Abbreviation from the change (e.g., "CEST")
+	DST          bool      // Daylight saving time is in effect from the change
+}
+
+// ZoneTransitions returns up to n transitions of loc after from, in order.
+// Zones without daylight saving time (including UTC) have none.
+func ZoneTransitions(loc *time.Location, from time.Time, n int) []ZoneTransition {
+	var transitions []ZoneTransition
+	t := from.In(loc)
+	for len(transitions) < n {
+		_, end := t.ZoneBounds()
+		if end.IsZero() {
+			break
+		}
+		_, before := t.Zone()
+		next := end.In(loc)
+		abbreviation, after := next.Zone()
+		transitions = append(transitions, ZoneTransition{
+			Time:         next,
+			OffsetBefore: before,
+			OffsetAfter:  after,
+			Abbreviation: abbreviation,
+			DST:          next.IsDST(),
+		})
+		t = next
+	}
+	return transitions
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetTimezone(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lng float64
+		want     string
+	}{
+		{"Copenhagen", 55.6761, 12.5683, "Europe/Copenhagen"},
+		{"New York", 40.7128, -74.006, "America/New_York"},
+		{"Adelaide", -34.9285, 138.6007, "Australia/Adelaide"},
+		{"Mid-Atlantic", 30, -40, "UTC"},
+	}
+	for _, tt := range tests {
+		if got := GetTimezone(tt.lat, tt.lng).String(); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTimezoneNameCached(t *testing.T) {
+	timezoneCache.Clear()
+	if name := TimezoneName(55.6761, 12.5683); name != "Europe/Copenhagen" {
+		t.Fatalf("expected Europe/Copenhagen, got %q", name)
+	}
+	TimezoneName(30, -40)
+	entries := TimezoneCache().Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected both lookups cached, got %+v", entries)
+	}
+	if name, ok := timezoneCache.Get("30.0000,-40.0000"); !ok || name != "" {
+		t.Errorf("expected the zone not found cached, got %q", name)
+	}
+
+	first, _ := LoadZone("Europe/Copenhagen")
+	second, _ := LoadZone("Europe/Copenhagen")
+	if first != second {
+		t.Error("expected the loaded zone reused")
+	}
+	if _, err := LoadZone("Mars/Olympus_Mons"); err == nil {
+		t.Error("expected an error for an unknown zone")
+	}
+}
+
+func TestZoneTransitions(t *testing.T) {
+	copenhagen, _ := LoadZone("Europe/Copenhagen")
+	from := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	transitions := ZoneTransitions(copenhagen, from, 3)
+	if len(transitions) != 3 {
+		t.Fatalf("expected 3 transitions, got %d", len(transitions))
+	}
+	spring, autumn := transitions[0], transitions[1]
+	if !spring.Time.Equal(time.Date(2025, 3, 30, 1, 0, 0, 0, time.UTC)) || spring.OffsetBefore != 3600 || spring.OffsetAfter != 7200 || !spring.DST || spring.Abbreviation != "CEST" {
+		t.Errorf("expected the spring change on 2025-03-30, got %+v", spring)
+	}
+	if !autumn.Time.Equal(time.Date(2025, 10, 26, 1, 0, 0, 0, time.UTC)) || autumn.OffsetAfter != 3600 || autumn.DST {
+		t.Errorf("expected the autumn change on 2025-10-26, got %+v", autumn)
+	}
+	if got := transitions[2].Time.Year(); got != 2026 {
+		t.Errorf("expected the third change in 2026, got %d", got)
+	}
+
+	if transitions := ZoneTransitions(time.UTC, from, 2); len(transitions) != 0 {
+		t.Errorf("expected no transitions for UTC, got %+v", transitions)
+	}
+	tokyo, _ := LoadZone("Asia/Tokyo")
+	if transitions := ZoneTransitions(tokyo, from, 2); len(transitions) != 0 {
+		t.Errorf("expected no transitions for a zone without DST, got %+v", transitions)
+	}
+}
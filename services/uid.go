@@ -0,0 +1,73 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultUIDDomain is the part of event UIDs after the "@" unless the
+// operator configures another
+const DefaultUIDDomain = "calsun"
+
+// uidDomain is the domain event UIDs end with, set once at startup
+var uidDomain = DefaultUIDDomain
+
+// SetUIDDomain sets the domain event UIDs end with. Call it before serving
+// any requests.
+func SetUIDDomain(domain string) {
+	uidDomain = domain
+}
+
+// UID schemes, chosen per calendar
+const (
+	UIDVersion1 = 1 // Date, location, and event type (EventUID); the default
+	UIDVersion2 = 2 // Version 1 and the calendar's options, with a "v2-" segment
+)
+
+// VersionedUID returns the UID of an event with the version 1 UID uid in
+// the given scheme. Version 2 UIDs also hash options, a canonical form of the
+// calendar's options, so changing them makes clients treat every event as
+// new. Other versions return uid unchanged.
+func VersionedUID(uid string, version int, options string) string {
+	if version != UIDVersion2 {
+		return uid
+	}
+	local, domain, _ := strings.Cut(uid, "@")
+	hash := sha256.Sum256([]byte(local + "?" + options))
+	return fmt.Sprintf("v%d-%x@%s", version, hash[:8], domain)
+}
+
+// uidRecord is the persisted state of the UID domain guard
+type uidRecord struct {
+	Domain string `json:"uid_domain"`
+}
+
+// CheckUIDDomain compares domain to the one recorded at path, refusing a
+// change unless migrate is set: every event UID would change with it, so
+// subscribed calendars would show each event twice. Without a record the
+// instance has used DefaultUIDDomain. The domain is recorded on success.
+func CheckUIDDomain(path, domain string, migrate bool) error {
+	record := uidRecord{Domain: DefaultUIDDomain}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &record); err != nil {
+			return fmt.Errorf("invalid UID record %s: %w", path, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+
+	if record.Domain == domain {
+		if err == nil {
+			return nil
+		}
+	} else if !migrate {
+		return fmt.Errorf("UID domain changed from %q to %q, which changes every event UID and duplicates events in subscribed calendars; set CALSUN_UID_MIGRATE=true to change it anyway", record.Domain, domain)
+	}
+	return writeJSONAtomic(path, uidRecord{Domain: domain})
+}
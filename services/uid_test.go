@@ -0,0 +1,98 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventUID_Stable(t *testing.T) {
+	// Subscribed calendars rely on UIDs never changing; this value must not
+	// change with the UID domain left at its default
+	date := time.Date(2024, 6, 21, 3, 0, 0, 0, time.UTC)
+	if got := EventUID(date, 55.6761, 12.5683, "sunrise"); got != "b1dd7f60d4204a96@calsun" {
+		t.Errorf("EventUID changed: got %s", got)
+	}
+}
+
+func TestEventUID_Domain(t *testing.T) {
+	t.Cleanup(func() { SetUIDDomain(DefaultUIDDomain) })
+	SetUIDDomain("sun.example.com")
+
+	date := time.Date(2024, 6, 21, 3, 0, 0, 0, time.UTC)
+	if got := EventUID(date, 55.6761, 12.5683, "sunrise"); got != "b1dd7f60d4204a96@sun.example.com" {
+		t.Errorf("expected the hash at the configured domain, got %s", got)
+	}
+}
+
+func TestVersionedUID(t *testing.T) {
+	uid := "b1dd7f60d4204a96@calsun"
+	if got := VersionedUID(uid, UIDVersion1, "events=sunrise"); got != uid {
+		t.Errorf("expected version 1 UIDs unchanged, got %s", got)
+	}
+
+	v2 := VersionedUID(uid, UIDVersion2, "events=sunrise")
+	if !strings.HasPrefix(v2, "v2-") || !strings.HasSuffix(v2, "@calsun") || len(v2) != len("v2-")+16+len("@calsun") {
+		t.Errorf("expected a versioned hash at the same domain, got %s", v2)
+	}
+	if again := VersionedUID(uid, UIDVersion2, "events=sunrise"); again != v2 {
+		t.Errorf("expected version 2 UIDs stable for the same options, got %s and %s", v2, again)
+	}
+	if other := VersionedUID(uid, UIDVersion2, "events=sunrise,sunset"); other == v2 {
+		t.Error("expected other options to change version 2 UIDs")
+	}
+	if other := VersionedUID("0123456789abcdef@calsun", UIDVersion2, "events=sunrise"); other == v2 {
+		t.Error("expected other events to keep distinct version 2 UIDs")
+	}
+}
+
+func TestCheckUIDDomain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "uid.json")
+
+	// Without a record, the default domain is accepted and recorded
+	if err := CheckUIDDomain(path, DefaultUIDDomain, false); err != nil {
+		t.Fatalf("expected the default domain to be accepted, got %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || !strings.Contains(string(data), `"calsun"`) {
+		t.Fatalf("expected the domain to be recorded, got %q (%v)", data, err)
+	}
+
+	// A change is refused without the migration flag
+	err := CheckUIDDomain(path, "sun.example.com", false)
+	if err == nil || !strings.Contains(err.Error(), "CALSUN_UID_MIGRATE") {
+		t.Fatalf("expected the change to be refused, got %v", err)
+	}
+
+	// With the flag the change is recorded, so later starts need no flag
+	if err := CheckUIDDomain(path, "sun.example.com", true); err != nil {
+		t.Fatalf("expected the migration to succeed, got %v", err)
+	}
+	if err := CheckUIDDomain(path, "sun.example.com", false); err != nil {
+		t.Errorf("expected the migrated domain to be accepted, got %v", err)
+	}
+	if err := CheckUIDDomain(path, DefaultUIDDomain, false); err == nil {
+		t.Error("expected changing back to be refused too")
+	}
+}
+
+func TestCheckUIDDomain_NewDomainWithoutRecord(t *testing.T) {
+	// Instances without a record have used the default domain
+	path := filepath.Join(t.TempDir(), "uid.json")
+	if err := CheckUIDDomain(path, "sun.example.com", false); err == nil {
+		t.Error("expected a domain other than the default to need the migration flag")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected nothing to be recorded for a refused change")
+	}
+}
+
+func TestCheckUIDDomain_InvalidRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "uid.json")
+	os.WriteFile(path, []byte("not json"), 0o600)
+
+	if err := CheckUIDDomain(path, DefaultUIDDomain, false); err == nil {
+		t.Error("expected an invalid record to be an error")
+	}
+}
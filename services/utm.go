@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// WGS 84 ellipsoid and UTM projection constants
+const (
+	wgs84A         = 6378137.0
+	wgs84F         = 1 / 298.257223563
+	utmScale       = 0.9996
+	utmFalseEast   = 500000.0
+	utmFalseNorth  = 10000000.0 // Added to southern hemisphere northings
+	utmBandLetters = "CDEFGHJKLMNPQRSTUVWX"
+	// utmBandSlack tolerates coordinates just outside their latitude band,
+	// e.g., from rounding at a band boundary
+	utmBandSlack = 0.5
+)
+
+// mgrsRowLetters are the 100 km square row letters, repeating every 2000 km
+const mgrsRowLetters = "ABCDEFGHJKLMNPQRSTUV"
+
+// mgrsColumnLetters are the 100 km square column letters for zone sets
+// 1 to 3 (and 4 to 6)
+var mgrsColumnLetters = []string{"ABCDEFGH", "JKLMNPQR", "STUVWXYZ"}
+
+// ParseUTM converts a UTM coordinate given as zone and latitude band, easting,
+// and northing in meters (e.g., "31U 448252 5411933") to latitude and
+// longitude. The polar UPS zones are not supported.
+func ParseUTM(s string) (lat, lng float64, err error) {
+	fields := strings.Fields(strings.ToUpper(s))
+	if len(fields) != 3 {
+		return 0, 0, errors.New("UTM must be zone and band, easting, and northing (e.g., 31U 448252 5411933)")
+	}
+	zone, band, err := parseUTMZone(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	easting, err1 := strconv.ParseFloat(fields[1], 64)
+	northing, err2 := strconv.ParseFloat(fields[2], 64)
+	if err1 != nil || err2 != nil || easting < 100000 || easting > 900000 || northing < 0 || northing > utmFalseNorth {
+		return 0, 0, errors.New("UTM easting must be 100000 to 900000 and northing 0 to 10000000 meters")
+	}
+
+	lat, lng = utmToLatLng(zone, band >= 'N', easting, northing)
+	if !inUTMBand(lat, band) {
+		return 0, 0, fmt.Errorf("UTM northing is outside latitude band %c", band)
+	}
+	return lat, lng, nil
+}
+
+// ParseMGRS converts an MGRS grid reference (e.g., "31UDQ4825111932", with
+// optional spaces) to the latitude and longitude of the center of the square
+// it identifies. The polar UPS zones are not supported.
+func ParseMGRS(s string) (lat, lng float64, err error) {
+	ref := strings.ToUpper(strings.Join(strings.Fields(s), ""))
+	digits := strings.IndexFunc(ref, unicode.IsLetter)
+	if digits < 1 || len(ref) < digits+3 {
+		return 0, 0, errors.New("MGRS must be zone, band, 100 km square, and digits (e.g., 31UDQ4825111932)")
+	}
+	zone, band, err := parseUTMZone(ref[:digits+1])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	column := strings.IndexByte(mgrsColumnLetters[(zone-1)%3], ref[digits+1])
+	row := strings.IndexByte(mgrsRowLetters, ref[digits+2])
+	if column < 0 || row < 0 {
+		return 0, 0, fmt.Errorf("invalid MGRS 100 km square %s for zone %d", ref[digits+1:digits+3], zone)
+	}
+	if zone%2 == 0 {
+		// Even zones start their row letters at F
+		row = (row + len(mgrsRowLetters) - 5) % len(mgrsRowLetters)
+	}
+
+	numbers := ref[digits+3:]
+	if len(numbers)%2 != 0 || len(numbers) > 10 || strings.IndexFunc(numbers, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return 0, 0, errors.New("MGRS must have an even number of up to 10 digits after the 100 km square")
+	}
+	precision := len(numbers) / 2
+	resolution := math.Pow10(5 - precision) // Meters per digit step
+	easting, northing := float64(column+1)*100000, float64(row)*100000
+	if precision > 0 {
+		e, _ := strconv.Atoi(numbers[:precision])
+		n, _ := strconv.Atoi(numbers[precision:])
+		easting += float64(e) * resolution
+		northing += float64(n) * resolution
+	}
+	easting += resolution / 2
+	northing += resolution / 2
+
+	// Row letters repeat every 2000 km; pick the repetition in the band
+	north := band >= 'N'
+	for ; northing <= utmFalseNorth; northing += 2000000 {
+		lat, lng = utmToLatLng(zone, north, easting, northing)
+		if inUTMBand(lat, band) {
+			return lat, lng, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("MGRS square %s is not in latitude band %c", ref[digits+1:digits+3], band)
+}
+
+// parseUTMZone parses a zone number and latitude band letter (e.g., "31U")
+func parseUTMZone(s string) (int, byte, error) {
+	if len(s) < 2 {
+		return 0, 0, fmt.Errorf("invalid UTM zone %q", s)
+	}
+	zone, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || zone < 1 || zone > 60 {
+		return 0, 0, fmt.Errorf("invalid UTM zone %q: zone must be 1 to 60", s)
+	}
+	band := s[len(s)-1]
+	if strings.IndexByte(utmBandLetters, band) < 0 {
+		return 0, 0, fmt.Errorf("invalid UTM zone %q: latitude band must be C to X (polar zones are not supported)", s)
+	}
+	return zone, band, nil
+}
+
+// inUTMBand reports whether a latitude is within a latitude band, which are
+// 8 degrees tall from 80°S, except X (72°N to 84°N)
+func inUTMBand(lat float64, band byte) bool {
+	south := -80 + 8*float64(strings.IndexByte(utmBandLetters, band))
+	north := south + 8
+	if band == 'X' {
+		north = 84
+	}
+	return lat >= south-utmBandSlack && lat <= north+utmBandSlack
+}
+
+// utmToLatLng converts UTM easting and northing to latitude and longitude
+// (inverse transverse Mercator, accurate to well under a meter within a zone)
+func utmToLatLng(zone int, north bool, easting, northing float64) (lat, lng float64) {
+	e2 := wgs84F * (2 - wgs84F)
+	ep2 := e2 / (1 - e2)
+	e1 := (1 - math.Sqrt(1-e2)) / (1 + math.Sqrt(1-e2))
+
+	x := easting - utmFalseEast
+	y := northing
+	if !north {
+		y -= utmFalseNorth
+	}
+
+	// Footpoint latitude
+	mu := y / utmScale / (wgs84A * (1 - e2/4 - 3*e2*e2/64 - 5*e2*e2*e2/256))
+	phi := mu +
+		(3*e1/2-27*math.Pow(e1, 3)/32)*math.Sin(2*mu) +
+		(21*e1*e1/16-55*math.Pow(e1, 4)/32)*math.Sin(4*mu) +
+		(151*math.Pow(e1, 3)/96)*math.Sin(6*mu) +
+		(1097*math.Pow(e1, 4)/512)*math.Sin(8*mu)
+
+	sin, cos, tan := math.Sin(phi), math.Cos(phi), math.Tan(phi)
+	c := ep2 * cos * cos
+	t := tan * tan
+	n := wgs84A / math.Sqrt(1-e2*sin*sin)
+	r := wgs84A * (1 - e2) / math.Pow(1-e2*sin*sin, 1.5)
+	d := x / (n * utmScale)
+
+	lat = phi - (n*tan/r)*(d*d/2-
+		(5+3*t+10*c-4*c*c-9*ep2)*math.Pow(d, 4)/24+
+		(61+90*t+298*c+45*t*t-252*ep2-3*c*c)*math.Pow(d, 6)/720)
+	lng = (d - (1+2*t+c)*math.Pow(d, 3)/6 +
+		(5-2*c+28*t-3*c*c+8*ep2+24*t*t)*math.Pow(d, 5)/120) / cos
+
+	centralMeridian := float64(zone-1)*6 - 180 + 3
+	return lat * 180 / math.Pi, centralMeridian + lng*180/math.Pi
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// nearly reports whether two coordinates are within about 1 meter
+func nearly(lat1, lng1, lat2, lng2 float64) bool {
+	return math.Abs(lat1-lat2) < 1e-5 && math.Abs(lng1-lng2) < 1e-5
+}
+
+func TestParseUTM(t *testing.T) {
+	tests := []struct {
+		utm      string
+		lat, lng float64
+	}{
+		{"31U 448252 5411933", 48.85820, 2.29450},    // Eiffel Tower
+		{"56h 334369 6250948", -33.86880, 151.20930}, // Sydney
+		{"4Q 612340 2356780", 21.30944, -157.91686},  // Honolulu
+	}
+	for _, tt := range tests {
+		lat, lng, err := ParseUTM(tt.utm)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.utm, err)
+			continue
+		}
+		if !nearly(lat, lng, tt.lat, tt.lng) {
+			t.Errorf("%s: expected %.5f,%.5f, got %.5f,%.5f", tt.utm, tt.lat, tt.lng, lat, lng)
+		}
+	}
+}
+
+func TestParseUTM_Invalid(t *testing.T) {
+	tests := []struct {
+		utm      string
+		expected string
+	}{
+		{"31U 448252", "must be zone and band"},
+		{"61U 448252 5411933", "zone must be 1 to 60"},
+		{"31Z 448252 5411933", "latitude band"},
+		{"31U 48252 5411933", "easting"},
+		{"31U east 5411933", "easting"},
+		{"31S 448252 5411933", "outside latitude band S"}, // S is a band, not the southern hemisphere
+	}
+	for _, tt := range tests {
+		_, _, err := ParseUTM(tt.utm)
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.utm, tt.expected, err)
+		}
+	}
+}
+
+func TestParseMGRS(t *testing.T) {
+	tests := []struct {
+		mgrs     string
+		lat, lng float64
+	}{
+		{"31UDQ4825111932", 48.85820, 2.29450},
+		{"31U DQ 48251 11932", 48.85820, 2.29450},
+		{"56HLH3436950948", -33.86880, 151.20931},
+		{"4QFJ12345678", 21.30948, -157.91682}, // 10 m square center
+	}
+	for _, tt := range tests {
+		lat, lng, err := ParseMGRS(tt.mgrs)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.mgrs, err)
+			continue
+		}
+		if !nearly(lat, lng, tt.lat, tt.lng) {
+			t.Errorf("%s: expected %.5f,%.5f, got %.5f,%.5f", tt.mgrs, tt.lat, tt.lng, lat, lng)
+		}
+	}
+}
+
+func TestParseMGRS_Precision(t *testing.T) {
+	// A 1 km reference is the center of its square, about 500 m from the corner
+	lat, lng, err := ParseMGRS("31UDQ4811")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cornerLat, cornerLng := utmToLatLng(31, true, 448000, 5411000)
+	if d := distanceKm(Place{Lat: lat, Lng: lng}, Place{Lat: cornerLat, Lng: cornerLng}); d < 0.6 || d > 0.8 {
+		t.Errorf("expected about 0.7 km from the corner, got %.2f km", d)
+	}
+}
+
+func TestParseMGRS_Invalid(t *testing.T) {
+	tests := []string{
+		"31UDQ482511193",   // Odd number of digits
+		"31UDQ48251119321", // Too many digits
+		"31UIQ4825111932",  // I is not a column letter
+		"31UJQ4825111932",  // J is not a column letter in zone 31
+		"DQ4825111932",     // Missing zone
+		"31U",              // Missing square
+		"31ADQ4825111932",  // Polar band
+	}
+	for _, mgrs := range tests {
+		if _, _, err := ParseMGRS(mgrs); err == nil {
+			t.Errorf("%q: expected error", mgrs)
+		}
+	}
+}
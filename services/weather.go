@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// OpenMeteoURL is the public Open-Meteo forecast API
+	OpenMeteoURL = "https://api.open-meteo.com"
+	// OpenMeteoAirQualityURL is the public Open-Meteo air quality API
+	OpenMeteoAirQualityURL = "https://air-quality-api.open-meteo.com"
+
+	// ForecastDays is how many days ahead weather forecasts cover
+	ForecastDays = 7
+
+	weatherCacheTTL  = time.Hour
+	weatherCacheSize = 10000
+)
+
+// HourlyForecast is an hourly forecast of a whole-number quantity (e.g.,
+// cloud cover in percent), keyed by the Unix time of each hour
+type HourlyForecast map[int64]int
+
+// At returns the forecast value for the hour nearest to t, reporting false if
+// t is outside the forecast
+func (f HourlyForecast) At(t time.Time) (int, bool) {
+	v, ok := f[t.Round(time.Hour).Unix()]
+	return v, ok
+}
+
+// WeatherProvider fetches weather forecasts for a location. Forecasts cover
+// the next ForecastDays days.
+type WeatherProvider interface {
+	// CloudCover returns the hourly total cloud cover in percent
+	CloudCover(ctx context.Context, lat, lng float64) (HourlyForecast, error)
+	// AirQuality returns the hourly US Air Quality Index
+	AirQuality(ctx context.Context, lat, lng float64) (HourlyForecast, error)
+}
+
+// OpenMeteo is a WeatherProvider backed by the Open-Meteo forecast and air
+// quality APIs. Forecasts are cached per location (rounded to about 1 km) and
+// day.
+type OpenMeteo struct {
+	forecastURL   string
+	airQualityURL string
+	client        *http.Client
+	cache         *ttlCache[HourlyForecast]
+	now           Clock
+}
+
+// NewOpenMeteo creates a weather provider for the Open-Meteo forecast API at
+// forecastURL and air quality API at airQualityURL
+func NewOpenMeteo(forecastURL, airQualityURL string) *OpenMeteo {
+	return &OpenMeteo{
+		forecastURL:   strings.TrimRight(forecastURL, "/"),
+		airQualityURL: strings.TrimRight(airQualityURL, "/"),
+		client:        &http.Client{Timeout: 10 * time.Second},
+		cache:         newTTLCache[HourlyForecast](weatherCacheTTL, weatherCacheSize),
+		now:           time.Now,
+	}
+}
+
+// Cache returns the cached forecasts
+func (o *OpenMeteo) Cache() Cache {
+	return o.cache
+}
+
+// CloudCover returns the hourly cloud cover forecast for a location
+func (o *OpenMeteo) CloudCover(ctx context.Context, lat, lng float64) (HourlyForecast, error) {
+	return o.hourly(ctx, o.forecastURL+"/v1/forecast", "cloud_cover", lat, lng)
+}
+
+// AirQuality returns the hourly US AQI forecast for a location
+func (o *OpenMeteo) AirQuality(ctx context.Context, lat, lng float64) (HourlyForecast, error) {
+	return o.hourly(ctx, o.airQualityURL+"/v1/air-quality", "us_aqi", lat, lng)
+}
+
+// hourly fetches (or returns the cached) hourly forecast of variable from an
+// Open-Meteo endpoint
+func (o *OpenMeteo) hourly(ctx context.Context, endpoint, variable string, lat, lng float64) (HourlyForecast, error) {
+	latStr := strconv.FormatFloat(lat, 'f', 2, 64)
+	lngStr := strconv.FormatFloat(lng, 'f', 2, 64)
+	key := variable + ":" + latStr + "," + lngStr + "," + o.now().UTC().Format("2006-01-02")
+	if forecast, ok := o.cache.Get(key); ok {
+		return forecast, nil
+	}
+
+	params := url.Values{}
+	params.Set("latitude", latStr)
+	params.Set("longitude", lngStr)
+	params.Set("hourly", variable)
+	params.Set("forecast_days", strconv.Itoa(ForecastDays))
+	params.Set("timeformat", "unixtime")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("forecast request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("forecast request failed: status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Hourly map[string]json.RawMessage `json:"hourly"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid forecast response: %w", err)
+	}
+	var times []int64
+	var values []*float64
+	if err := json.Unmarshal(body.Hourly["time"], &times); err != nil {
+		return nil, fmt.Errorf("invalid forecast response: %w", err)
+	}
+	if err := json.Unmarshal(body.Hourly[variable], &values); err != nil {
+		return nil, fmt.Errorf("invalid forecast response: %w", err)
+	}
+	if len(times) != len(values) {
+		return nil, fmt.Errorf("invalid forecast response: mismatched hourly data")
+	}
+
+	forecast := make(HourlyForecast, len(times))
+	for i, t := range times {
+		if v := values[i]; v != nil {
+			forecast[t] = int(math.Round(*v))
+		}
+	}
+
+	o.cache.Set(key, forecast)
+	return forecast, nil
+}
+
+// SunVisibility rates how likely the sun is to be visible under the given
+// cloud cover: "likely", "possible", or "unlikely"
+func SunVisibility(cloudCover int) string {
+	switch {
+	case cloudCover < 30:
+		return "likely"
+	case cloudCover < 70:
+		return "possible"
+	default:
+		return "unlikely"
+	}
+}
+
+// AQICategory returns the US EPA category for an Air Quality Index value
+func AQICategory(aqi int) string {
+	switch {
+	case aqi <= 50:
+		return "good"
+	case aqi <= 100:
+		return "moderate"
+	case aqi <= 150:
+		return "unhealthy for sensitive groups"
+	case aqi <= 200:
+		return "unhealthy"
+	case aqi <= 300:
+		return "very unhealthy"
+	default:
+		return "hazardous"
+	}
+}
+
+// AnnotateCloudCover adds the forecast cloud cover and sun visibility to the
+// description of each timed (not all-day) event covered by the forecast, and
+// tags the event with its visibility (e.g., "Visibility: likely")
+func AnnotateCloudCover(events []CalendarEvent, forecast HourlyForecast) {
+	for i := range events {
+		if events[i].AllDay {
+			continue
+		}
+		cover, ok := forecast.At(events[i].Start)
+		if !ok {
+			continue
+		}
+		visibility := SunVisibility(cover)
+		events[i].Description += fmt.Sprintf("\nCloud cover: %d%% (forecast)\nSun visible: %s", cover, visibility)
+		events[i].Categories = append(events[i].Categories, "Visibility: "+visibility)
+	}
+}
+
+// AnnotateAirQuality adds the forecast Air Quality Index to the description
+// of each sunrise covered by the forecast, for planning morning activities
+func AnnotateAirQuality(events []CalendarEvent, forecast HourlyForecast) {
+	for i := range events {
+		if events[i].Type != "sunrise" {
+			continue
+		}
+		aqi, ok := forecast.At(events[i].Start)
+		if !ok {
+			continue
+		}
+		events[i].Description += fmt.Sprintf("\nAir quality: AQI %d, %s (forecast)", aqi, AQICategory(aqi))
+	}
+}
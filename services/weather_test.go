@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOpenMeteo_CloudCover(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		q := r.URL.Query()
+		if r.URL.Path != "/v1/forecast" || q.Get("latitude") != "55.68" || q.Get("longitude") != "12.57" || q.Get("hourly") != "cloud_cover" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		w.Write([]byte(`{"hourly": {"time": [1718935200, 1718938800, 1718942400], "cloud_cover": [10, null, 95]}}`))
+	}))
+	defer server.Close()
+
+	o := NewOpenMeteo(server.URL, server.URL)
+	forecast, err := o.CloudCover(context.Background(), 55.6761, 12.5683)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(forecast) != 2 {
+		t.Errorf("expected 2 hours (missing value skipped), got %v", forecast)
+	}
+
+	// Nearest hour
+	if cover, ok := forecast.At(time.Unix(1718935200+20*60, 0)); !ok || cover != 10 {
+		t.Errorf("expected 10%% at 20 past, got %d (%v)", cover, ok)
+	}
+	if cover, ok := forecast.At(time.Unix(1718942400-25*60, 0)); !ok || cover != 95 {
+		t.Errorf("expected 95%% rounded up to the next hour, got %d (%v)", cover, ok)
+	}
+	if _, ok := forecast.At(time.Unix(1718938800, 0)); ok {
+		t.Error("expected no value for a missing hour")
+	}
+
+	// Nearby coordinates share the cached forecast
+	if _, err := o.CloudCover(context.Background(), 55.6789, 12.5712); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a cached forecast, got %d requests", calls.Load())
+	}
+}
+
+func TestOpenMeteo_AirQuality(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/air-quality" || r.URL.Query().Get("hourly") != "us_aqi" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		w.Write([]byte(`{"hourly": {"time": [1718935200], "us_aqi": [42.4]}}`))
+	}))
+	defer server.Close()
+
+	forecast, err := NewOpenMeteo(server.URL, server.URL).AirQuality(context.Background(), 55.6761, 12.5683)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if aqi, ok := forecast.At(time.Unix(1718935200, 0)); !ok || aqi != 42 {
+		t.Errorf("expected AQI 42, got %d (%v)", aqi, ok)
+	}
+}
+
+func TestOpenMeteo_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		message string
+	}{
+		{"server error", http.StatusInternalServerError, ``, "status 500"},
+		{"invalid json", http.StatusOK, `{"hourly":`, "invalid forecast response"},
+		{"missing variable", http.StatusOK, `{"hourly": {"time": [1718935200]}}`, "invalid forecast response"},
+		{"mismatched data", http.StatusOK, `{"hourly": {"time": [1718935200], "cloud_cover": []}}`, "mismatched"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := NewOpenMeteo(server.URL, server.URL).CloudCover(context.Background(), 55.6761, 12.5683)
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestSunVisibility(t *testing.T) {
+	tests := map[int]string{0: "likely", 29: "likely", 30: "possible", 69: "possible", 70: "unlikely", 100: "unlikely"}
+	for cover, want := range tests {
+		if got := SunVisibility(cover); got != want {
+			t.Errorf("SunVisibility(%d) = %s, want %s", cover, got, want)
+		}
+	}
+}
+
+func TestAQICategory(t *testing.T) {
+	tests := map[int]string{0: "good", 50: "good", 51: "moderate", 101: "unhealthy for sensitive groups", 151: "unhealthy", 201: "very unhealthy", 301: "hazardous"}
+	for aqi, want := range tests {
+		if got := AQICategory(aqi); got != want {
+			t.Errorf("AQICategory(%d) = %s, want %s", aqi, got, want)
+		}
+	}
+}
+
+func TestAnnotateAirQuality(t *testing.T) {
+	start := time.Date(2024, 6, 21, 3, 0, 0, 0, time.UTC)
+	events := []CalendarEvent{
+		{Type: "sunrise", Start: start, Description: "Time: 03:00"},
+		{Type: "sunset", Start: start, Description: "Time: 03:00"},
+	}
+
+	AnnotateAirQuality(events, HourlyForecast{start.Unix(): 72})
+
+	if events[0].Description != "Time: 03:00\nAir quality: AQI 72, moderate (forecast)" {
+		t.Errorf("unexpected sunrise description: %q", events[0].Description)
+	}
+	if events[1].Description != "Time: 03:00" {
+		t.Error("expected sunsets to be left out")
+	}
+}
+
+func TestAnnotateCloudCover(t *testing.T) {
+	start := time.Date(2024, 6, 21, 3, 0, 0, 0, time.UTC)
+	events := []CalendarEvent{
+		{Start: start.Add(-20 * time.Minute), Description: "Time: 02:40"},
+		{Start: start.AddDate(0, 0, 10), Description: "Time: 03:00"},
+	}
+
+	AnnotateCloudCover(events, HourlyForecast{start.Unix(): 45})
+
+	if events[0].Description != "Time: 02:40\nCloud cover: 45% (forecast)\nSun visible: possible" {
+		t.Errorf("unexpected description: %q", events[0].Description)
+	}
+	if len(events[0].Categories) != 1 || events[0].Categories[0] != "Visibility: possible" {
+		t.Errorf("unexpected categories: %v", events[0].Categories)
+	}
+	if events[1].Description != "Time: 03:00" || len(events[1].Categories) != 0 {
+		t.Error("expected events outside the forecast to be unchanged")
+	}
+}
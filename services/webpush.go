@@ -0,0 +1,269 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// vapidTokenLifetime is how long a VAPID token is valid; push services
+	// reject tokens valid for over 24 hours
+	vapidTokenLifetime = 12 * time.Hour
+
+	// pushRecordSize is the record size of encrypted push messages
+	pushRecordSize = 4096
+)
+
+// ErrPushGone is returned when the push service reports a subscription as
+// expired or unsubscribed; it should be deleted
+var ErrPushGone = errors.New("push subscription is gone")
+
+// pushEncoding encodes the keys of Web Push: unpadded base64url
+var pushEncoding = base64.RawURLEncoding
+
+// vapidRecord is the persisted VAPID key pair
+type vapidRecord struct {
+	PrivateKey string `json:"private_key"` // PKCS #8, base64
+}
+
+// LoadOrCreateVAPIDKey loads the server's VAPID signing key from path,
+// generating and saving one on first use. Browsers tie subscriptions to the
+// key, so it must not change.
+func LoadOrCreateVAPIDKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return key, writeJSONAtomic(path, vapidRecord{PrivateKey: base64.StdEncoding.EncodeToString(der)})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record vapidRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("invalid VAPID key %s: %w", path, err)
+	}
+	der, err := base64.StdEncoding.DecodeString(record.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID key %s: %w", path, err)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID key %s: %w", path, err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok || key.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("invalid VAPID key %s: not a P-256 key", path)
+	}
+	return key, nil
+}
+
+// WebPush sends encrypted Web Push messages (RFC 8030, RFC 8291), identifying
+// the server to push services with VAPID (RFC 8292)
+type WebPush struct {
+	key     *ecdsa.PrivateKey
+	subject string // Contact for push services, a mailto: or https: URL
+	client  *http.Client
+	now     Clock
+}
+
+// NewWebPush creates a Web Push sender signing with key
+func NewWebPush(key *ecdsa.PrivateKey, subject string) *WebPush {
+	return &WebPush{
+		key:     key,
+		subject: subject,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		now:     time.Now,
+	}
+}
+
+// PublicKey returns the VAPID public key browsers subscribe with (the
+// applicationServerKey), base64url encoded
+func (w *WebPush) PublicKey() string {
+	key, _ := w.key.PublicKey.ECDH()
+	return pushEncoding.EncodeToString(key.Bytes())
+}
+
+// Send encrypts payload for a subscription and posts it to its push service,
+// which keeps it for up to ttl while the browser is offline
+func (w *WebPush) Send(ctx context.Context, sub PushSubscription, payload []byte, ttl time.Duration) error {
+	uaPublic, authSecret, err := decodePushKeys(sub.P256dh, sub.Auth)
+	if err != nil {
+		return err
+	}
+	body, err := encryptPushPayload(payload, uaPublic, authSecret, nil, nil)
+	if err != nil {
+		return err
+	}
+	endpoint, err := url.Parse(sub.Endpoint)
+	if err != nil {
+		return err
+	}
+	token, err := w.vapidToken(endpoint.Scheme + "://" + endpoint.Host)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(ttl.Seconds())))
+	req.Header.Set("Urgency", "high")
+	req.Header.Set("Authorization", "vapid t="+token+", k="+w.PublicKey())
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrPushGone
+	case resp.StatusCode/100 != 2:
+		return fmt.Errorf("push request failed: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// vapidToken returns a signed JWT (ES256) for the push service at audience
+func (w *WebPush) vapidToken(audience string) (string, error) {
+	header := pushEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]any{
+		"aud": audience,
+		"exp": w.now().Add(vapidTokenLifetime).Unix(),
+		"sub": w.subject,
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + pushEncoding.EncodeToString(claims)
+
+	hash := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, w.key, hash[:])
+	if err != nil {
+		return "", err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return unsigned + "." + pushEncoding.EncodeToString(signature), nil
+}
+
+// decodePushKeys decodes and checks a subscription's P-256 public key and
+// authentication secret
+func decodePushKeys(p256dh, auth string) (*ecdh.PublicKey, []byte, error) {
+	raw, err := pushEncoding.DecodeString(trimPadding(p256dh))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	authSecret, err := pushEncoding.DecodeString(trimPadding(auth))
+	if err != nil || len(authSecret) != 16 {
+		return nil, nil, errors.New("invalid auth secret: must be 16 bytes")
+	}
+	return uaPublic, authSecret, nil
+}
+
+// trimPadding removes base64 padding, which some browsers include
+func trimPadding(s string) string {
+	for len(s) > 0 && s[len(s)-1] == '=' {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// encryptPushPayload encrypts payload as a single aes128gcm record for a
+// browser's key (RFC 8291). The server key and salt are random unless given,
+// for the RFC's test vector.
+func encryptPushPayload(payload []byte, uaPublic *ecdh.PublicKey, authSecret []byte, asPrivate *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	// Push services accept bodies of up to 4096 bytes: the 86-byte header,
+	// the payload, its delimiter, and the 16-byte tag
+	if len(payload) > 4096-86-1-16 {
+		return nil, fmt.Errorf("push payload of %d bytes is too large", len(payload))
+	}
+	var err error
+	if asPrivate == nil {
+		if asPrivate, err = ecdh.P256().GenerateKey(rand.Reader); err != nil {
+			return nil, err
+		}
+	}
+	if salt == nil {
+		salt = make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+	}
+
+	shared, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asPrivate.PublicKey().Bytes()
+	keyInfo := "WebPush: info\x00" + string(uaPublic.Bytes()) + string(asPublic)
+	ikm, err := hkdf.Key(sha256.New, shared, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Header: salt, record size, and the server's public key as key ID
+	header := make([]byte, 0, 21+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, pushRecordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+
+	// The only record is the last, delimited by 0x02
+	plaintext := append(append([]byte{}, payload...), 2)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mustDecode decodes unpadded base64url test data
+func mustDecode(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := pushEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid test data %q: %v", s, err)
+	}
+	return b
+}
+
+// decryptPushPayload decrypts an aes128gcm push message as a browser does
+func decryptPushPayload(t *testing.T, body []byte, uaPrivate *ecdh.PrivateKey, authSecret []byte) string {
+	t.Helper()
+	salt, keyID := body[:16], body[21:21+int(body[20])]
+	asPublic, err := ecdh.P256().NewPublicKey(keyID)
+	if err != nil {
+		t.Fatalf("invalid key ID: %v", err)
+	}
+	shared, _ := uaPrivate.ECDH(asPublic)
+	ikm, _ := hkdf.Key(sha256.New, shared, authSecret, "WebPush: info\x00"+string(uaPrivate.PublicKey().Bytes())+string(keyID), 32)
+	cek, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	nonce, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plaintext, err := gcm.Open(nil, nonce, body[21+len(keyID):], nil)
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	if plaintext[len(plaintext)-1] != 2 {
+		t.Fatalf("expected the last record delimiter, got %x", plaintext[len(plaintext)-1])
+	}
+	return string(plaintext[:len(plaintext)-1])
+}
+
+func TestEncryptPushPayload_RFC8291(t *testing.T) {
+	// The example of RFC 8291, Appendix A
+	asPrivate, err := ecdh.P256().NewPrivateKey(mustDecode(t, "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uaPublic, authSecret, err := decodePushKeys("BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4", "BTBZMqHH6r4Tts7J_aSIgg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := encryptPushPayload([]byte("When I grow up, I want to be a watermelon"), uaPublic, authSecret, asPrivate, mustDecode(t, "DGv6ra1nlYgDCS1FRnbzlw"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+	if got := pushEncoding.EncodeToString(body); got != want {
+		t.Errorf("expected the RFC's message\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestEncryptPushPayload_TooLarge(t *testing.T) {
+	uaPrivate, _ := ecdh.P256().GenerateKey(rand.Reader)
+	if _, err := encryptPushPayload(make([]byte, 4000), uaPrivate.PublicKey(), make([]byte, 16), nil, nil); err == nil {
+		t.Error("expected an error for a payload over 4096 bytes encrypted")
+	}
+}
+
+func TestDecodePushKeys(t *testing.T) {
+	uaPrivate, _ := ecdh.P256().GenerateKey(rand.Reader)
+	p256dh := pushEncoding.EncodeToString(uaPrivate.PublicKey().Bytes())
+	tests := []struct {
+		name, p256dh, auth string
+		valid              bool
+	}{
+		{"valid", p256dh, "BTBZMqHH6r4Tts7J_aSIgg", true},
+		{"padded", p256dh + "=", "BTBZMqHH6r4Tts7J_aSIgg==", true},
+		{"not a point", "BCVxsr7N", "BTBZMqHH6r4Tts7J_aSIgg", false},
+		{"short auth", p256dh, "BTBZ", false},
+		{"not base64", "!!!", "BTBZMqHH6r4Tts7J_aSIgg", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodePushKeys(tt.p256dh, tt.auth); (err == nil) != tt.valid {
+				t.Errorf("expected valid %v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestLoadOrCreateVAPIDKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vapid.json")
+	key, err := LoadOrCreateVAPIDKey(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	loaded, err := LoadOrCreateVAPIDKey(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !key.Equal(loaded) {
+		t.Error("expected the saved key to be loaded")
+	}
+}
+
+func TestWebPush_Send(t *testing.T) {
+	key, _ := LoadOrCreateVAPIDKey(filepath.Join(t.TempDir(), "vapid.json"))
+	uaPrivate, _ := ecdh.P256().GenerateKey(rand.Reader)
+	authSecret := []byte("0123456789abcdef")
+
+	var received string
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = decryptPushPayload(t, body, uaPrivate, authSecret)
+		headers = r.Header
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	w := NewWebPush(key, "mailto:admin@example.com")
+	w.now = func() time.Time { return time.Unix(1700000000, 0) }
+	sub := PushSubscription{
+		Endpoint: server.URL + "/push/abc",
+		P256dh:   pushEncoding.EncodeToString(uaPrivate.PublicKey().Bytes()),
+		Auth:     pushEncoding.EncodeToString(authSecret),
+	}
+	if err := w.Send(context.Background(), sub, []byte(`{"title":"Sunset"}`), 15*time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if received != `{"title":"Sunset"}` {
+		t.Errorf("expected the decrypted payload, got %q", received)
+	}
+	if headers.Get("Content-Encoding") != "aes128gcm" || headers.Get("TTL") != "900" {
+		t.Errorf("unexpected headers: %v", headers)
+	}
+
+	// The VAPID token is an ES256 JWT for the push service's origin, signed
+	// by the public key sent with it
+	token, publicKey, ok := strings.Cut(strings.TrimPrefix(headers.Get("Authorization"), "vapid t="), ", k=")
+	if !ok || publicKey != w.PublicKey() {
+		t.Fatalf("unexpected authorization: %s", headers.Get("Authorization"))
+	}
+	parts := strings.Split(token, ".")
+	var claims struct {
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+		Sub string `json:"sub"`
+	}
+	json.Unmarshal(mustDecode(t, parts[1]), &claims)
+	if claims.Aud != server.URL || claims.Exp != 1700000000+12*3600 || claims.Sub != "mailto:admin@example.com" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+	signature := mustDecode(t, parts[2])
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(&key.PublicKey, hash[:], r, s) {
+		t.Error("expected a valid signature")
+	}
+}
+
+func TestWebPush_SendErrors(t *testing.T) {
+	key, _ := LoadOrCreateVAPIDKey(filepath.Join(t.TempDir(), "vapid.json"))
+	uaPrivate, _ := ecdh.P256().GenerateKey(rand.Reader)
+	tests := []struct {
+		status int
+		gone   bool
+	}{
+		{http.StatusGone, true},
+		{http.StatusNotFound, true},
+		{http.StatusTooManyRequests, false},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+		sub := PushSubscription{
+			Endpoint: server.URL,
+			P256dh:   pushEncoding.EncodeToString(uaPrivate.PublicKey().Bytes()),
+			Auth:     "BTBZMqHH6r4Tts7J_aSIgg",
+		}
+		err := NewWebPush(key, "mailto:admin@example.com").Send(context.Background(), sub, []byte("{}"), time.Minute)
+		if err == nil || (err == ErrPushGone) != tt.gone {
+			t.Errorf("status %d: expected gone %v, got %v", tt.status, tt.gone, err)
+		}
+		server.Close()
+	}
+}
@@ -0,0 +1,39 @@
+package services
+
+import "time"
+
+// WhenSearchDays is the longest search of FindSunEventDate; in a year every
+// clock time the sunrise or sunset reaches comes around
+const WhenSearchDays = 366
+
+// whenChunkDays is how many days FindSunEventDate computes at a time, so a
+// match early in the search doesn't compute the whole horizon
+const whenChunkDays = 32
+
+// FindSunEventDate searches days local dates from start (midnight in the
+// location's timezone) for the first on which the sunrise or sunset
+// (eventType) is at or before the clock time, or at or after it with after.
+// Times are compared to the minute as they are displayed, so a 06:30:40
+// sunrise counts as 06:30. Days without the event never match. Returns the
+// matching day and its index from start, or false if none matches.
+func FindSunEventDate(lat, lng float64, eventType string, clock time.Duration, after bool, start time.Time, days int) (DaySunTimes, int, bool) {
+	tz := start.Location()
+	noon := time.Date(start.Year(), start.Month(), start.Day(), 12, 0, 0, 0, tz)
+	for offset := 0; offset < days; offset += whenChunkDays {
+		chunk := GetSunTimesRange(lat, lng, noon.AddDate(0, 0, offset), min(whenChunkDays, days-offset))
+		for i, day := range chunk {
+			event := day.Sunrise
+			if eventType == EventSunset {
+				event = day.Sunset
+			}
+			if event == nil {
+				continue
+			}
+			t := clockTime(event.Time.In(tz)).Truncate(time.Minute)
+			if (after && t >= clock) || (!after && t <= clock) {
+				return day, offset + i, true
+			}
+		}
+	}
+	return DaySunTimes{}, 0, false
+}
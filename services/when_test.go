@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindSunEventDate(t *testing.T) {
+	copenhagen, _ := time.LoadLocation("Europe/Copenhagen")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, copenhagen)
+
+	day, offset, ok := FindSunEventDate(55.6761, 12.5683, EventSunrise, 6*time.Hour+30*time.Minute, false, start, WhenSearchDays)
+	if !ok {
+		t.Fatal("expected a match")
+	}
+	sunrise := day.Sunrise.Time.In(copenhagen)
+	if sunrise.Format("15:04") > "06:30" {
+		t.Errorf("expected a sunrise at or before 06:30, got %s", sunrise.Format("15:04"))
+	}
+	if got := start.AddDate(0, 0, offset).Format("2006-01-02"); got != sunrise.Format("2006-01-02") {
+		t.Errorf("offset %d gives %s, but the sunrise is on %s", offset, got, sunrise.Format("2006-01-02"))
+	}
+	// The day before still has a later sunrise
+	prev := GetSunTimes(55.6761, 12.5683, sunrise.AddDate(0, 0, -1))
+	if prev.Sunrise.Time.In(copenhagen).Format("15:04") <= "06:30" {
+		t.Errorf("expected the previous day's sunrise after 06:30, got %s", prev.Sunrise.Time.In(copenhagen).Format("15:04"))
+	}
+}
+
+func TestFindSunEventDate_AcrossChunks(t *testing.T) {
+	copenhagen, _ := time.LoadLocation("Europe/Copenhagen")
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, copenhagen)
+
+	_, offset, ok := FindSunEventDate(55.6761, 12.5683, EventSunset, 21*time.Hour, true, start, WhenSearchDays)
+	if !ok || offset < whenChunkDays {
+		t.Errorf("expected a match past the first chunk, got %d, %v", offset, ok)
+	}
+}
+
+func TestFindSunEventDate_PolarNight(t *testing.T) {
+	start := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	// Svalbard has no sunrise until mid-February
+	_, _, ok := FindSunEventDate(78.2232, 15.6267, EventSunrise, 23*time.Hour+59*time.Minute, false, start, 60)
+	if ok {
+		t.Error("expected no sunrise during the polar night")
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// sunAnchors are the daily sun events an offset can be relative to
+var sunAnchors = map[string]func(DayTimes) time.Time{
+	"dawn":    func(t DayTimes) time.Time { return t.Dawn },
+	"sunrise": func(t DayTimes) time.Time { return t.Sunrise },
+	"noon":    func(t DayTimes) time.Time { return t.SolarNoon },
+	"sunset":  func(t DayTimes) time.Time { return t.Sunset },
+	"dusk":    func(t DayTimes) time.Time { return t.Dusk },
+}
+
+// SunOffset is a time relative to a daily sun event, e.g. "sunset-30m"
+type SunOffset struct {
+	Anchor string // "dawn", "sunrise", "noon", "sunset", or "dusk"
+	Offset time.Duration
+}
+
+// ParseSunOffset parses an offset such as "sunrise", "sunrise+15m", or
+// "sunset-1h30m"
+func ParseSunOffset(s string) (SunOffset, error) {
+	anchor, offset := s, ""
+	if i := strings.IndexAny(s, "+-"); i >= 0 {
+		anchor, offset = s[:i], s[i:]
+	}
+	if _, ok := sunAnchors[anchor]; !ok {
+		return SunOffset{}, fmt.Errorf("unknown sun event %q", anchor)
+	}
+
+	o := SunOffset{Anchor: anchor}
+	if offset != "" {
+		d, err := time.ParseDuration(offset)
+		if err != nil || strings.ContainsAny(offset[1:], "+-") {
+			return SunOffset{}, fmt.Errorf("invalid offset %q", offset)
+		}
+		o.Offset = d
+	}
+	return o, nil
+}
+
+// Time returns the offset's time on a day, or the zero time if the anchor
+// event does not occur that day
+func (o SunOffset) Time(times DayTimes) time.Time {
+	t := sunAnchors[o.Anchor](times)
+	if t.IsZero() {
+		return t
+	}
+	return t.Add(o.Offset)
+}
+
+// String formats the offset for descriptions (e.g., "sunset - 30m")
+func (o SunOffset) String() string {
+	switch {
+	case o.Offset > 0:
+		return fmt.Sprintf("%s + %s", o.Anchor, formatOffset(o.Offset))
+	case o.Offset < 0:
+		return fmt.Sprintf("%s - %s", o.Anchor, formatOffset(-o.Offset))
+	}
+	return o.Anchor
+}
+
+// formatOffset formats a duration compactly (e.g., "15m", "1h30m")
+func formatOffset(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	}
+	return fmt.Sprintf("%dh%dm", hours, minutes)
+}
+
+// ActivityWindow is a user-defined daily window between two sun offsets,
+// e.g. a "Safe run window" from sunrise+15m to sunset-30m
+type ActivityWindow struct {
+	Name  string
+	Start SunOffset
+	End   SunOffset
+}
+
+// BuildWindowEvents generates one event spanning the activity window for
+// each day. Days where either end does not occur (e.g., polar night) or the
+// window is empty have no event.
+func BuildWindowEvents(opts CalendarOptions, w ActivityWindow, start time.Time, days int) []CalendarEvent {
+	var events []CalendarEvent
+	for i := 0; i < days; i++ {
+		times := GetDayTimes(opts.Lat, opts.Lng, start.AddDate(0, 0, i))
+		from, to := w.Start.Time(times), w.End.Time(times)
+		if from.IsZero() || to.IsZero() || !to.After(from) {
+			continue
+		}
+
+		lines := []string{
+			fmt.Sprintf("Time: %s (%s)", opts.ClockSpan(from, to, " to "), FormatDuration(to.Sub(from))),
+			fmt.Sprintf("Location: %s", opts.Location),
+			"Coordinates: " + FormatCoordinates(opts.Lat, opts.Lng, opts.Precision),
+			"",
+			fmt.Sprintf("Window: %s to %s", w.Start, w.End),
+		}
+		events = append(events, CalendarEvent{
+			UID:         locationUID(from, opts.Lat, opts.Lng, opts.Precision, "window-"+w.Name),
+			Type:        "activity_window",
+			Start:       from,
+			End:         to,
+			Summary:     w.Name,
+			Description: strings.Join(lines, "\n"),
+			Location:    opts.Location,
+		})
+	}
+	return events
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSunOffset(t *testing.T) {
+	tests := []struct {
+		input  string
+		want   SunOffset
+		format string
+	}{
+		{"sunrise", SunOffset{"sunrise", 0}, "sunrise"},
+		{"sunrise+15m", SunOffset{"sunrise", 15 * time.Minute}, "sunrise + 15m"},
+		{"sunset-1h30m", SunOffset{"sunset", -90 * time.Minute}, "sunset - 1h30m"},
+		{"noon+2h", SunOffset{"noon", 2 * time.Hour}, "noon + 2h"},
+		{"dusk-0m", SunOffset{"dusk", 0}, "dusk"},
+	}
+	for _, tt := range tests {
+		got, err := ParseSunOffset(tt.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.input, got, tt.want)
+		}
+		if got.String() != tt.format {
+			t.Errorf("%s: formatted as %q, want %q", tt.input, got.String(), tt.format)
+		}
+	}
+
+	for _, input := range []string{"", "moonrise+1h", "sunrise+", "sunrise+15", "sunset--30m", "sunrise 15m"} {
+		if _, err := ParseSunOffset(input); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
+
+func TestBuildWindowEvents(t *testing.T) {
+	tz := GetTimezone(55.6761, 12.5683)
+	opts := CalendarOptions{Lat: 55.6761, Lng: 12.5683, Location: "Copenhagen", Timezone: tz}
+	window := ActivityWindow{
+		Name:  "Safe run window",
+		Start: SunOffset{"sunrise", 15 * time.Minute},
+		End:   SunOffset{"sunset", -30 * time.Minute},
+	}
+	date := time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)
+
+	events := BuildWindowEvents(opts, window, date, 3)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+
+	times := GetDayTimes(55.6761, 12.5683, date)
+	event := events[0]
+	if !event.Start.Equal(times.Sunrise.Add(15*time.Minute)) || !event.End.Equal(times.Sunset.Add(-30*time.Minute)) {
+		t.Errorf("unexpected window %s to %s", event.Start, event.End)
+	}
+	if event.Summary != "Safe run window" || event.Type != "activity_window" {
+		t.Errorf("unexpected event: %s (%s)", event.Summary, event.Type)
+	}
+	if !strings.Contains(event.Description, "Window: sunrise + 15m to sunset - 30m") {
+		t.Errorf("unexpected description: %s", event.Description)
+	}
+	if event.UID == events[1].UID {
+		t.Error("expected a UID per day")
+	}
+}
+
+func TestBuildWindowEvents_Skipped(t *testing.T) {
+	opts := CalendarOptions{Lat: 69.6492, Lng: 18.9553, Timezone: time.UTC}
+
+	// No sunrise in Tromsø around the winter solstice
+	polar := ActivityWindow{Name: "Walk", Start: SunOffset{"sunrise", 0}, End: SunOffset{"sunset", 0}}
+	if events := BuildWindowEvents(opts, polar, time.Date(2024, 12, 20, 0, 0, 0, 0, time.UTC), 3); len(events) != 0 {
+		t.Errorf("expected no events in polar night, got %d", len(events))
+	}
+
+	// Windows ending before they start are skipped
+	empty := ActivityWindow{Name: "Walk", Start: SunOffset{"noon", time.Hour}, End: SunOffset{"noon", -time.Hour}}
+	if events := BuildWindowEvents(opts, empty, time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC), 3); len(events) != 0 {
+		t.Errorf("expected no events for an empty window, got %d", len(events))
+	}
+}